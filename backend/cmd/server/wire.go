@@ -70,6 +70,7 @@ func provideServiceBuildInfo(buildInfo handler.BuildInfo) service.BuildInfo {
 func provideCleanup(
 	entClient *ent.Client,
 	rdb *redis.Client,
+	dbReadRouter *repository.DBReadRouter,
 	opsMetricsCollector *service.OpsMetricsCollector,
 	opsAggregation *service.OpsAggregationService,
 	opsAlertEvaluator *service.OpsAlertEvaluatorService,
@@ -82,11 +83,14 @@ func provideCleanup(
 	accountExpiry *service.AccountExpiryService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
 	usageCleanup *service.UsageCleanupService,
+	usageExport *service.UsageExportService,
+	usageLogPartition *service.UsageLogPartitionService,
 	idempotencyCleanup *service.IdempotencyCleanupService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
 	usageRecordWorkerPool *service.UsageRecordWorkerPool,
+	upstreamCircuitBreaker *service.UpstreamCircuitBreaker,
 	subscriptionService *service.SubscriptionService,
 	oauth *service.OAuthService,
 	openaiOAuth *service.OpenAIOAuthService,
@@ -95,6 +99,10 @@ func provideCleanup(
 	openAIGateway *service.OpenAIGatewayService,
 	scheduledTestRunner *service.ScheduledTestRunnerService,
 	backupSvc *service.BackupService,
+	invoice *service.InvoiceService,
+	userNotification *service.UserNotificationService,
+	subscriptionRenewal *service.SubscriptionRenewalService,
+	requestCapture *service.RequestCaptureService,
 ) func() {
 	return func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -161,6 +169,42 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UsageExportService", func() error {
+				if usageExport != nil {
+					usageExport.Stop()
+				}
+				return nil
+			}},
+			{"UsageLogPartitionService", func() error {
+				if usageLogPartition != nil {
+					usageLogPartition.Stop()
+				}
+				return nil
+			}},
+			{"InvoiceService", func() error {
+				if invoice != nil {
+					invoice.Stop()
+				}
+				return nil
+			}},
+			{"UserNotificationService", func() error {
+				if userNotification != nil {
+					userNotification.Stop()
+				}
+				return nil
+			}},
+			{"SubscriptionRenewalService", func() error {
+				if subscriptionRenewal != nil {
+					subscriptionRenewal.Stop()
+				}
+				return nil
+			}},
+			{"RequestCaptureService", func() error {
+				if requestCapture != nil {
+					requestCapture.Stop()
+				}
+				return nil
+			}},
 			{"IdempotencyCleanupService", func() error {
 				if idempotencyCleanup != nil {
 					idempotencyCleanup.Stop()
@@ -203,6 +247,10 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UpstreamCircuitBreaker", func() error {
+				upstreamCircuitBreaker.Stop()
+				return nil
+			}},
 			{"OAuthService", func() error {
 				oauth.Stop()
 				return nil
@@ -246,6 +294,10 @@ func provideCleanup(
 				}
 				return rdb.Close()
 			}},
+			{"DBReadRouter", func() error {
+				dbReadRouter.Close()
+				return nil
+			}},
 			{"Ent", func() error {
 				if entClient == nil {
 					return nil
@@ -43,6 +43,10 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	if err != nil {
 		return nil, err
 	}
+	dbReadRouter, err := repository.ProvideDBReadRouter(configConfig, db)
+	if err != nil {
+		return nil, err
+	}
 	userRepository := repository.NewUserRepository(client, db)
 	redeemCodeRepository := repository.NewRedeemCodeRepository(client)
 	redisClient := repository.ProvideRedis(configConfig)
@@ -51,7 +55,10 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	groupRepository := repository.NewGroupRepository(client, db)
 	settingService := service.ProvideSettingService(settingRepository, groupRepository, configConfig)
 	emailCache := repository.NewEmailCache(redisClient)
-	emailService := service.NewEmailService(settingRepository, emailCache)
+	emailTemplateRepository := repository.NewEmailTemplateRepository(db)
+	userNotificationRepository := repository.NewUserNotificationRepository(db)
+	emailTemplateService := service.NewEmailTemplateService(emailTemplateRepository, settingRepository, userRepository, userNotificationRepository)
+	emailService := service.NewEmailService(settingRepository, emailCache, emailTemplateService)
 	turnstileVerifier := repository.NewTurnstileVerifier()
 	turnstileService := service.NewTurnstileService(settingService, turnstileVerifier)
 	emailQueueService := service.ProvideEmailQueueService(emailService)
@@ -77,10 +84,13 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	}
 	totpCache := repository.NewTotpCache(redisClient)
 	totpService := service.NewTotpService(userRepository, secretEncryptor, totpCache, settingService, emailService, emailQueueService)
-	authHandler := handler.NewAuthHandler(configConfig, authService, userService, settingService, promoService, redeemService, totpService)
+	passkeyRepository := repository.NewPasskeyRepository(db)
+	passkeyCache := repository.NewPasskeyCache(redisClient)
+	passkeyService := service.NewPasskeyService(passkeyRepository, passkeyCache, userRepository, settingService, emailService)
+	authHandler := handler.NewAuthHandler(configConfig, authService, userService, settingService, promoService, redeemService, totpService, passkeyService)
 	userHandler := handler.NewUserHandler(userService)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
-	usageLogRepository := repository.NewUsageLogRepository(client, db)
+	usageLogRepository := repository.NewUsageLogRepository(client, db, dbReadRouter)
 	usageBillingRepository := repository.NewUsageBillingRepository(client, db)
 	usageService := service.NewUsageService(usageLogRepository, userRepository, client, apiKeyAuthCacheInvalidator)
 	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
@@ -108,7 +118,8 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	privacyClientFactory := providePrivacyClientFactory()
 	adminService := service.NewAdminService(userRepository, groupRepository, accountRepository, soraAccountRepository, proxyRepository, apiKeyRepository, redeemCodeRepository, userGroupRateRepository, billingCacheService, proxyExitInfoProber, proxyLatencyCache, apiKeyAuthCacheInvalidator, client, settingService, subscriptionService, userSubscriptionRepository, privacyClientFactory)
 	concurrencyCache := repository.ProvideConcurrencyCache(redisClient, configConfig)
-	concurrencyService := service.ProvideConcurrencyService(concurrencyCache, accountRepository, configConfig)
+	fairQueueCache := repository.NewFairQueueCache(redisClient)
+	concurrencyService := service.ProvideConcurrencyService(concurrencyCache, fairQueueCache, accountRepository, configConfig)
 	adminUserHandler := admin.NewUserHandler(adminService, concurrencyService)
 	claudeOAuthClient := repository.NewClaudeOAuthClient()
 	oAuthService := service.NewOAuthService(proxyRepository, claudeOAuthClient)
@@ -159,9 +170,10 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	proxyHandler := admin.NewProxyHandler(adminService)
 	adminRedeemHandler := admin.NewRedeemHandler(adminService, redeemService)
 	promoHandler := admin.NewPromoHandler(promoService)
-	opsRepository := repository.NewOpsRepository(db)
+	opsRepository := repository.NewOpsRepository(db, dbReadRouter)
 	pricingRemoteClient := repository.ProvidePricingRemoteClient(configConfig)
-	pricingService, err := service.ProvidePricingService(configConfig, pricingRemoteClient)
+	pricingRepository := repository.NewPricingRepository(db)
+	pricingService, err := service.ProvidePricingService(configConfig, pricingRemoteClient, pricingRepository)
 	if err != nil {
 		return nil, err
 	}
@@ -172,10 +184,12 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	digestSessionStore := service.NewDigestSessionStore()
 	gatewayService := service.NewGatewayService(accountRepository, groupRepository, usageLogRepository, usageBillingRepository, userRepository, userSubscriptionRepository, userGroupRateRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, identityService, httpUpstream, deferredService, claudeTokenProvider, sessionLimitCache, rpmCache, digestSessionStore, settingService)
 	openAITokenProvider := service.ProvideOpenAITokenProvider(accountRepository, geminiTokenCache, openAIOAuthService, oauthRefreshAPI)
-	openAIGatewayService := service.NewOpenAIGatewayService(accountRepository, usageLogRepository, usageBillingRepository, userRepository, userSubscriptionRepository, userGroupRateRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider)
+	openAIGatewayService := service.NewOpenAIGatewayService(accountRepository, usageLogRepository, usageBillingRepository, userRepository, userSubscriptionRepository, userGroupRateRepository, groupRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider)
 	geminiMessagesCompatService := service.NewGeminiMessagesCompatService(accountRepository, groupRepository, gatewayCache, schedulerSnapshotService, geminiTokenProvider, rateLimitService, httpUpstream, antigravityGatewayService, configConfig)
 	opsSystemLogSink := service.ProvideOpsSystemLogSink(opsRepository)
-	opsService := service.NewOpsService(opsRepository, settingRepository, configConfig, accountRepository, userRepository, concurrencyService, gatewayService, openAIGatewayService, geminiMessagesCompatService, antigravityGatewayService, opsSystemLogSink)
+	upstreamCircuitCache := repository.NewUpstreamCircuitCache(redisClient)
+	upstreamCircuitBreaker := service.ProvideUpstreamCircuitBreaker(upstreamCircuitCache, configConfig, gatewayService, openAIGatewayService, antigravityGatewayService, geminiMessagesCompatService)
+	opsService := service.ProvideOpsService(opsRepository, settingRepository, configConfig, accountRepository, userRepository, concurrencyService, gatewayService, openAIGatewayService, geminiMessagesCompatService, antigravityGatewayService, opsSystemLogSink, upstreamCircuitBreaker)
 	soraS3Storage := service.NewSoraS3Storage(settingService)
 	settingService.SetOnS3UpdateCallback(soraS3Storage.RefreshClient)
 	soraGenerationRepository := repository.NewSoraGenerationRepository(db)
@@ -194,6 +208,15 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	usageCleanupRepository := repository.NewUsageCleanupRepository(client, db)
 	usageCleanupService := service.ProvideUsageCleanupService(usageCleanupRepository, timingWheelService, dashboardAggregationService, configConfig)
 	adminUsageHandler := admin.NewUsageHandler(usageService, apiKeyService, adminService, usageCleanupService)
+	usageExportRepository := repository.NewUsageExportRepository(db)
+	usageExportService := service.ProvideUsageExportService(usageExportRepository, usageLogRepository, backupService, timingWheelService, configConfig)
+	adminUsageExportHandler := admin.NewUsageExportHandler(usageExportService)
+	usageSessionRepository := repository.NewUsageSessionRepository(db)
+	usageSessionService := service.NewUsageSessionService(usageSessionRepository)
+	adminUsageSessionHandler := admin.NewUsageSessionHandler(usageSessionService)
+	usageLogPartitionRepository := repository.NewUsageLogPartitionRepository(db)
+	usageLogPartitionService := service.ProvideUsageLogPartitionService(usageLogPartitionRepository, usageLogRepository, backupService, timingWheelService, db, configConfig)
+	adminUsageLogArchiveHandler := admin.NewUsageLogArchiveHandler(usageLogPartitionService)
 	userAttributeDefinitionRepository := repository.NewUserAttributeDefinitionRepository(client)
 	userAttributeValueRepository := repository.NewUserAttributeValueRepository(client)
 	userAttributeService := service.NewUserAttributeService(userAttributeDefinitionRepository, userAttributeValueRepository)
@@ -207,24 +230,53 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	scheduledTestResultRepository := repository.NewScheduledTestResultRepository(db)
 	scheduledTestService := service.ProvideScheduledTestService(scheduledTestPlanRepository, scheduledTestResultRepository)
 	scheduledTestHandler := admin.NewScheduledTestHandler(scheduledTestService)
-	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, adminAnnouncementHandler, dataManagementHandler, backupHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, userAttributeHandler, errorPassthroughHandler, adminAPIKeyHandler, scheduledTestHandler)
+	invoiceRepository := repository.NewInvoiceRepository(db)
+	invoiceService := service.ProvideInvoiceService(invoiceRepository, userRepository, configConfig)
+	adminInvoiceHandler := admin.NewInvoiceHandler(invoiceService)
+	requestCaptureRepository := repository.NewRequestCaptureRepository(db)
+	requestCaptureService := service.ProvideRequestCaptureService(requestCaptureRepository, settingRepository, secretEncryptor, configConfig)
+	adminRequestCaptureHandler := admin.NewRequestCaptureHandler(requestCaptureService)
+	contentModerationRepository := repository.NewContentModerationRepository(db)
+	contentModerationService := service.NewContentModerationService(contentModerationRepository, accountRepository, apiKeyRepository, apiKeyAuthCacheInvalidator, httpUpstream, configConfig)
+	adminContentModerationHandler := admin.NewContentModerationHandler(contentModerationService)
+	emailTemplateHandler := admin.NewEmailTemplateHandler(emailTemplateService, emailService)
+	pricingHandler := admin.NewPricingHandler(pricingService)
+	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, adminAnnouncementHandler, dataManagementHandler, backupHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, adminUsageExportHandler, adminUsageSessionHandler, adminUsageLogArchiveHandler, userAttributeHandler, errorPassthroughHandler, adminAPIKeyHandler, scheduledTestHandler, adminInvoiceHandler, adminRequestCaptureHandler, adminContentModerationHandler, emailTemplateHandler, pricingHandler)
 	usageRecordWorkerPool := service.NewUsageRecordWorkerPool(configConfig)
 	userMsgQueueCache := repository.NewUserMsgQueueCache(redisClient)
 	userMessageQueueService := service.ProvideUserMessageQueueService(userMsgQueueCache, rpmCache, configConfig)
-	gatewayHandler := handler.NewGatewayHandler(gatewayService, geminiMessagesCompatService, antigravityGatewayService, userService, concurrencyService, billingCacheService, usageService, apiKeyService, usageRecordWorkerPool, errorPassthroughService, userMessageQueueService, configConfig, settingService)
+	responsesConversationCache := repository.NewResponsesConversationCache(redisClient)
+	responsesConversationService := service.NewResponsesConversationService(responsesConversationCache, configConfig)
+	hedgeDelayService := service.NewHedgeDelayService(configConfig)
+	gatewayHandler := handler.NewGatewayHandler(gatewayService, geminiMessagesCompatService, antigravityGatewayService, userService, concurrencyService, billingCacheService, usageService, apiKeyService, usageRecordWorkerPool, errorPassthroughService, userMessageQueueService, responsesConversationService, hedgeDelayService, configConfig, settingService)
 	openAIGatewayHandler := handler.NewOpenAIGatewayHandler(openAIGatewayService, concurrencyService, billingCacheService, apiKeyService, usageRecordWorkerPool, errorPassthroughService, configConfig)
 	soraSDKClient := service.ProvideSoraSDKClient(configConfig, httpUpstream, openAITokenProvider, accountRepository, soraAccountRepository)
 	soraMediaStorage := service.ProvideSoraMediaStorage(configConfig)
-	soraGatewayService := service.NewSoraGatewayService(soraSDKClient, rateLimitService, httpUpstream, configConfig)
+	soraGatewayService := service.ProvideSoraGatewayService(soraSDKClient, rateLimitService, httpUpstream, configConfig, upstreamCircuitBreaker)
 	soraClientHandler := handler.NewSoraClientHandler(soraGenerationService, soraQuotaService, soraS3Storage, soraGatewayService, gatewayService, soraMediaStorage, apiKeyService)
 	soraGatewayHandler := handler.NewSoraGatewayHandler(gatewayService, soraGatewayService, concurrencyService, billingCacheService, usageRecordWorkerPool, configConfig)
 	handlerSettingHandler := handler.ProvideSettingHandler(settingService, buildInfo)
 	totpHandler := handler.NewTotpHandler(totpService)
+	passkeyHandler := handler.NewPasskeyHandler(passkeyService)
 	idempotencyCoordinator := service.ProvideIdempotencyCoordinator(idempotencyRepository, configConfig)
 	idempotencyCleanupService := service.ProvideIdempotencyCleanupService(idempotencyRepository, configConfig)
-	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, redeemHandler, subscriptionHandler, announcementHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, soraGatewayHandler, soraClientHandler, handlerSettingHandler, totpHandler, idempotencyCoordinator, idempotencyCleanupService)
+	usageExportHandler := handler.NewUsageExportHandler(usageExportService, apiKeyService)
+	usageSessionHandler := handler.NewUsageSessionHandler(usageSessionService, apiKeyService)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService)
+	userNotificationService := service.ProvideUserNotificationService(userNotificationRepository, userRepository, userSubscriptionRepository, apiKeyRepository, emailQueueService, settingService, gatewayService, openAIGatewayService, configConfig)
+	userNotificationHandler := handler.NewUserNotificationHandler(userNotificationService)
+	subscriptionChargeRepository := repository.NewSubscriptionChargeRepository(db)
+	subscriptionRenewalService := service.ProvideSubscriptionRenewalService(subscriptionChargeRepository, subscriptionService, billingCacheService, apiKeyAuthCacheInvalidator, client, userNotificationService, configConfig)
+	subscriptionRenewalHandler := handler.NewSubscriptionRenewalHandler(subscriptionRenewalService)
+	subscriptionPurchaseService := service.NewSubscriptionPurchaseService(subscriptionChargeRepository, groupRepository, subscriptionService, billingCacheService, apiKeyAuthCacheInvalidator, client)
+	subscriptionPurchaseHandler := handler.NewSubscriptionPurchaseHandler(subscriptionPurchaseService)
+	requestCaptureHandler := handler.NewRequestCaptureHandler(requestCaptureService)
+	contentModerationHandler := handler.NewContentModerationHandler(contentModerationService)
+	userRateLimitHeaderService := service.NewUserRateLimitHeaderService(configConfig, billingCacheService, concurrencyService)
+	userRateLimitHeadersHandler := handler.NewUserRateLimitHeadersHandler(userRateLimitHeaderService)
+	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, usageExportHandler, usageSessionHandler, redeemHandler, subscriptionHandler, announcementHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, soraGatewayHandler, soraClientHandler, handlerSettingHandler, totpHandler, passkeyHandler, invoiceHandler, userNotificationHandler, subscriptionRenewalHandler, subscriptionPurchaseHandler, requestCaptureHandler, contentModerationHandler, userRateLimitHeadersHandler, idempotencyCoordinator, idempotencyCleanupService)
 	jwtAuthMiddleware := middleware.NewJWTAuthMiddleware(authService, userService)
-	adminAuthMiddleware := middleware.NewAdminAuthMiddleware(authService, userService, settingService)
+	adminAuthMiddleware := middleware.NewAdminAuthMiddleware(authService, userService, settingService, passkeyService)
 	apiKeyAuthMiddleware := middleware.NewAPIKeyAuthMiddleware(apiKeyService, subscriptionService, configConfig)
 	engine := server.ProvideRouter(configConfig, handlers, jwtAuthMiddleware, adminAuthMiddleware, apiKeyAuthMiddleware, apiKeyService, subscriptionService, opsService, settingService, redisClient)
 	httpServer := server.ProvideHTTPServer(configConfig, engine)
@@ -236,9 +288,9 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	soraMediaCleanupService := service.ProvideSoraMediaCleanupService(soraMediaStorage, configConfig)
 	tokenRefreshService := service.ProvideTokenRefreshService(accountRepository, soraAccountRepository, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, compositeTokenCacheInvalidator, schedulerCache, configConfig, tempUnschedCache, privacyClientFactory, proxyRepository, oauthRefreshAPI)
 	accountExpiryService := service.ProvideAccountExpiryService(accountRepository)
-	subscriptionExpiryService := service.ProvideSubscriptionExpiryService(userSubscriptionRepository)
+	subscriptionExpiryService := service.ProvideSubscriptionExpiryService(userSubscriptionRepository, userNotificationService)
 	scheduledTestRunnerService := service.ProvideScheduledTestRunnerService(scheduledTestPlanRepository, scheduledTestService, accountTestService, rateLimitService, configConfig)
-	v := provideCleanup(client, redisClient, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, opsSystemLogSink, soraMediaCleanupService, schedulerSnapshotService, tokenRefreshService, accountExpiryService, subscriptionExpiryService, usageCleanupService, idempotencyCleanupService, pricingService, emailQueueService, billingCacheService, usageRecordWorkerPool, subscriptionService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, openAIGatewayService, scheduledTestRunnerService, backupService)
+	v := provideCleanup(client, redisClient, dbReadRouter, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, opsSystemLogSink, soraMediaCleanupService, schedulerSnapshotService, tokenRefreshService, accountExpiryService, subscriptionExpiryService, usageCleanupService, usageExportService, usageLogPartitionService, idempotencyCleanupService, pricingService, emailQueueService, billingCacheService, usageRecordWorkerPool, upstreamCircuitBreaker, subscriptionService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, openAIGatewayService, scheduledTestRunnerService, backupService, invoiceService, userNotificationService, subscriptionRenewalService, requestCaptureService)
 	application := &Application{
 		Server:  httpServer,
 		Cleanup: v,
@@ -267,6 +319,7 @@ func provideServiceBuildInfo(buildInfo handler.BuildInfo) service.BuildInfo {
 func provideCleanup(
 	entClient *ent.Client,
 	rdb *redis.Client,
+	dbReadRouter *repository.DBReadRouter,
 	opsMetricsCollector *service.OpsMetricsCollector,
 	opsAggregation *service.OpsAggregationService,
 	opsAlertEvaluator *service.OpsAlertEvaluatorService,
@@ -279,11 +332,14 @@ func provideCleanup(
 	accountExpiry *service.AccountExpiryService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
 	usageCleanup *service.UsageCleanupService,
+	usageExport *service.UsageExportService,
+	usageLogPartition *service.UsageLogPartitionService,
 	idempotencyCleanup *service.IdempotencyCleanupService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
 	usageRecordWorkerPool *service.UsageRecordWorkerPool,
+	upstreamCircuitBreaker *service.UpstreamCircuitBreaker,
 	subscriptionService *service.SubscriptionService,
 	oauth *service.OAuthService,
 	openaiOAuth *service.OpenAIOAuthService,
@@ -292,6 +348,10 @@ func provideCleanup(
 	openAIGateway *service.OpenAIGatewayService,
 	scheduledTestRunner *service.ScheduledTestRunnerService,
 	backupSvc *service.BackupService,
+	invoice *service.InvoiceService,
+	userNotification *service.UserNotificationService,
+	subscriptionRenewal *service.SubscriptionRenewalService,
+	requestCapture *service.RequestCaptureService,
 ) func() {
 	return func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -357,6 +417,42 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UsageExportService", func() error {
+				if usageExport != nil {
+					usageExport.Stop()
+				}
+				return nil
+			}},
+			{"UsageLogPartitionService", func() error {
+				if usageLogPartition != nil {
+					usageLogPartition.Stop()
+				}
+				return nil
+			}},
+			{"InvoiceService", func() error {
+				if invoice != nil {
+					invoice.Stop()
+				}
+				return nil
+			}},
+			{"UserNotificationService", func() error {
+				if userNotification != nil {
+					userNotification.Stop()
+				}
+				return nil
+			}},
+			{"SubscriptionRenewalService", func() error {
+				if subscriptionRenewal != nil {
+					subscriptionRenewal.Stop()
+				}
+				return nil
+			}},
+			{"RequestCaptureService", func() error {
+				if requestCapture != nil {
+					requestCapture.Stop()
+				}
+				return nil
+			}},
 			{"IdempotencyCleanupService", func() error {
 				if idempotencyCleanup != nil {
 					idempotencyCleanup.Stop()
@@ -399,6 +495,10 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UpstreamCircuitBreaker", func() error {
+				upstreamCircuitBreaker.Stop()
+				return nil
+			}},
 			{"OAuthService", func() error {
 				oauth.Stop()
 				return nil
@@ -442,6 +542,10 @@ func provideCleanup(
 				}
 				return rdb.Close()
 			}},
+			{"DBReadRouter", func() error {
+				dbReadRouter.Close()
+				return nil
+			}},
 			{"Ent", func() error {
 				if entClient == nil {
 					return nil
@@ -11,6 +11,7 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/dialect/sql"
 	"github.com/Wei-Shaw/sub2api/ent/group"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // Group is the model entity for the Group schema.
@@ -72,6 +73,10 @@ type Group struct {
 	ModelRouting map[string][]int64 `json:"model_routing,omitempty"`
 	// 是否启用模型路由配置
 	ModelRoutingEnabled bool `json:"model_routing_enabled,omitempty"`
+	// 模型降级链：模型模式 -> 有序降级目标列表
+	ModelFallbacks map[string][]domain.ModelFallbackTarget `json:"model_fallbacks,omitempty"`
+	// 请求改写规则：转发前按顺序执行的请求体/请求头改写
+	RequestTransforms []domain.RequestTransformRule `json:"request_transforms,omitempty"`
 	// 是否注入 MCP XML 调用协议提示词（仅 antigravity 平台）
 	McpXMLInject bool `json:"mcp_xml_inject,omitempty"`
 	// 支持的模型系列：claude, gemini_text, gemini_image
@@ -82,6 +87,22 @@ type Group struct {
 	AllowMessagesDispatch bool `json:"allow_messages_dispatch,omitempty"`
 	// 默认映射模型 ID，当账号级映射找不到时使用此值
 	DefaultMappedModel string `json:"default_mapped_model,omitempty"`
+	// 订阅售价（从余额扣除），为空表示不可购买/续费
+	Price *float64 `json:"price,omitempty"`
+	// 每次购买/续费延长的天数，0 表示使用 default_validity_days
+	RenewalPeriodDays int `json:"renewal_period_days,omitempty"`
+	// 公平排队权重，0 表示默认权重 1
+	QueueWeight int `json:"queue_weight,omitempty"`
+	// 公平排队优先级档位，数值越大越优先
+	QueuePriority int `json:"queue_priority,omitempty"`
+	// 账号调度策略：空表示默认（优先级 → 负载 → LRU），cost_aware 表示成本感知
+	SchedulingStrategy string `json:"scheduling_strategy,omitempty"`
+	// 是否对非流式请求启用对冲：首个上游迟迟未返回时在另一账号上并发重试
+	HedgeEnabled bool `json:"hedge_enabled,omitempty"`
+	// 触发对冲的延迟分位数（50-99），取自 ops 延迟直方图
+	HedgePercentile int `json:"hedge_percentile,omitempty"`
+	// 对冲落败请求的计费策略：ignore 不计费，bill 按已完成的用量计费
+	HedgeLoserBilling string `json:"hedge_loser_billing,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the GroupQuery when eager-loading is set.
 	Edges        GroupEdges `json:"edges"`
@@ -188,15 +209,15 @@ func (*Group) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case group.FieldModelRouting, group.FieldSupportedModelScopes:
+		case group.FieldModelRouting, group.FieldModelFallbacks, group.FieldRequestTransforms, group.FieldSupportedModelScopes:
 			values[i] = new([]byte)
-		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject, group.FieldAllowMessagesDispatch:
+		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject, group.FieldAllowMessagesDispatch, group.FieldHedgeEnabled:
 			values[i] = new(sql.NullBool)
-		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k, group.FieldSoraImagePrice360, group.FieldSoraImagePrice540, group.FieldSoraVideoPricePerRequest, group.FieldSoraVideoPricePerRequestHd:
+		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k, group.FieldSoraImagePrice360, group.FieldSoraImagePrice540, group.FieldSoraVideoPricePerRequest, group.FieldSoraVideoPricePerRequestHd, group.FieldPrice:
 			values[i] = new(sql.NullFloat64)
-		case group.FieldID, group.FieldDefaultValidityDays, group.FieldSoraStorageQuotaBytes, group.FieldFallbackGroupID, group.FieldFallbackGroupIDOnInvalidRequest, group.FieldSortOrder:
+		case group.FieldID, group.FieldDefaultValidityDays, group.FieldSoraStorageQuotaBytes, group.FieldFallbackGroupID, group.FieldFallbackGroupIDOnInvalidRequest, group.FieldSortOrder, group.FieldRenewalPeriodDays, group.FieldQueueWeight, group.FieldQueuePriority, group.FieldHedgePercentile:
 			values[i] = new(sql.NullInt64)
-		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType, group.FieldDefaultMappedModel:
+		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType, group.FieldDefaultMappedModel, group.FieldSchedulingStrategy, group.FieldHedgeLoserBilling:
 			values[i] = new(sql.NullString)
 		case group.FieldCreatedAt, group.FieldUpdatedAt, group.FieldDeletedAt:
 			values[i] = new(sql.NullTime)
@@ -399,6 +420,22 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.ModelRoutingEnabled = value.Bool
 			}
+		case group.FieldModelFallbacks:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_fallbacks", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelFallbacks); err != nil {
+					return fmt.Errorf("unmarshal field model_fallbacks: %w", err)
+				}
+			}
+		case group.FieldRequestTransforms:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field request_transforms", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.RequestTransforms); err != nil {
+					return fmt.Errorf("unmarshal field request_transforms: %w", err)
+				}
+			}
 		case group.FieldMcpXMLInject:
 			if value, ok := values[i].(*sql.NullBool); !ok {
 				return fmt.Errorf("unexpected type %T for field mcp_xml_inject", values[i])
@@ -431,6 +468,55 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.DefaultMappedModel = value.String
 			}
+		case group.FieldPrice:
+			if value, ok := values[i].(*sql.NullFloat64); !ok {
+				return fmt.Errorf("unexpected type %T for field price", values[i])
+			} else if value.Valid {
+				_m.Price = new(float64)
+				*_m.Price = value.Float64
+			}
+		case group.FieldRenewalPeriodDays:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field renewal_period_days", values[i])
+			} else if value.Valid {
+				_m.RenewalPeriodDays = int(value.Int64)
+			}
+		case group.FieldQueueWeight:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field queue_weight", values[i])
+			} else if value.Valid {
+				_m.QueueWeight = int(value.Int64)
+			}
+		case group.FieldQueuePriority:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field queue_priority", values[i])
+			} else if value.Valid {
+				_m.QueuePriority = int(value.Int64)
+			}
+		case group.FieldSchedulingStrategy:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field scheduling_strategy", values[i])
+			} else if value.Valid {
+				_m.SchedulingStrategy = value.String
+			}
+		case group.FieldHedgeEnabled:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field hedge_enabled", values[i])
+			} else if value.Valid {
+				_m.HedgeEnabled = value.Bool
+			}
+		case group.FieldHedgePercentile:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field hedge_percentile", values[i])
+			} else if value.Valid {
+				_m.HedgePercentile = int(value.Int64)
+			}
+		case group.FieldHedgeLoserBilling:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field hedge_loser_billing", values[i])
+			} else if value.Valid {
+				_m.HedgeLoserBilling = value.String
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -616,6 +702,12 @@ func (_m *Group) String() string {
 	builder.WriteString("model_routing_enabled=")
 	builder.WriteString(fmt.Sprintf("%v", _m.ModelRoutingEnabled))
 	builder.WriteString(", ")
+	builder.WriteString("model_fallbacks=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelFallbacks))
+	builder.WriteString(", ")
+	builder.WriteString("request_transforms=")
+	builder.WriteString(fmt.Sprintf("%v", _m.RequestTransforms))
+	builder.WriteString(", ")
 	builder.WriteString("mcp_xml_inject=")
 	builder.WriteString(fmt.Sprintf("%v", _m.McpXMLInject))
 	builder.WriteString(", ")
@@ -630,6 +722,32 @@ func (_m *Group) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("default_mapped_model=")
 	builder.WriteString(_m.DefaultMappedModel)
+	builder.WriteString(", ")
+	if v := _m.Price; v != nil {
+		builder.WriteString("price=")
+		builder.WriteString(fmt.Sprintf("%v", *v))
+	}
+	builder.WriteString(", ")
+	builder.WriteString("renewal_period_days=")
+	builder.WriteString(fmt.Sprintf("%v", _m.RenewalPeriodDays))
+	builder.WriteString(", ")
+	builder.WriteString("queue_weight=")
+	builder.WriteString(fmt.Sprintf("%v", _m.QueueWeight))
+	builder.WriteString(", ")
+	builder.WriteString("queue_priority=")
+	builder.WriteString(fmt.Sprintf("%v", _m.QueuePriority))
+	builder.WriteString(", ")
+	builder.WriteString("scheduling_strategy=")
+	builder.WriteString(_m.SchedulingStrategy)
+	builder.WriteString(", ")
+	builder.WriteString("hedge_enabled=")
+	builder.WriteString(fmt.Sprintf("%v", _m.HedgeEnabled))
+	builder.WriteString(", ")
+	builder.WriteString("hedge_percentile=")
+	builder.WriteString(fmt.Sprintf("%v", _m.HedgePercentile))
+	builder.WriteString(", ")
+	builder.WriteString("hedge_loser_billing=")
+	builder.WriteString(_m.HedgeLoserBilling)
 	builder.WriteByte(')')
 	return builder.String()
 }
@@ -69,6 +69,10 @@ const (
 	FieldModelRouting = "model_routing"
 	// FieldModelRoutingEnabled holds the string denoting the model_routing_enabled field in the database.
 	FieldModelRoutingEnabled = "model_routing_enabled"
+	// FieldModelFallbacks holds the string denoting the model_fallbacks field in the database.
+	FieldModelFallbacks = "model_fallbacks"
+	// FieldRequestTransforms holds the string denoting the request_transforms field in the database.
+	FieldRequestTransforms = "request_transforms"
 	// FieldMcpXMLInject holds the string denoting the mcp_xml_inject field in the database.
 	FieldMcpXMLInject = "mcp_xml_inject"
 	// FieldSupportedModelScopes holds the string denoting the supported_model_scopes field in the database.
@@ -79,6 +83,22 @@ const (
 	FieldAllowMessagesDispatch = "allow_messages_dispatch"
 	// FieldDefaultMappedModel holds the string denoting the default_mapped_model field in the database.
 	FieldDefaultMappedModel = "default_mapped_model"
+	// FieldPrice holds the string denoting the price field in the database.
+	FieldPrice = "price"
+	// FieldRenewalPeriodDays holds the string denoting the renewal_period_days field in the database.
+	FieldRenewalPeriodDays = "renewal_period_days"
+	// FieldQueueWeight holds the string denoting the queue_weight field in the database.
+	FieldQueueWeight = "queue_weight"
+	// FieldQueuePriority holds the string denoting the queue_priority field in the database.
+	FieldQueuePriority = "queue_priority"
+	// FieldSchedulingStrategy holds the string denoting the scheduling_strategy field in the database.
+	FieldSchedulingStrategy = "scheduling_strategy"
+	// FieldHedgeEnabled holds the string denoting the hedge_enabled field in the database.
+	FieldHedgeEnabled = "hedge_enabled"
+	// FieldHedgePercentile holds the string denoting the hedge_percentile field in the database.
+	FieldHedgePercentile = "hedge_percentile"
+	// FieldHedgeLoserBilling holds the string denoting the hedge_loser_billing field in the database.
+	FieldHedgeLoserBilling = "hedge_loser_billing"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -181,11 +201,21 @@ var Columns = []string{
 	FieldFallbackGroupIDOnInvalidRequest,
 	FieldModelRouting,
 	FieldModelRoutingEnabled,
+	FieldModelFallbacks,
+	FieldRequestTransforms,
 	FieldMcpXMLInject,
 	FieldSupportedModelScopes,
 	FieldSortOrder,
 	FieldAllowMessagesDispatch,
 	FieldDefaultMappedModel,
+	FieldPrice,
+	FieldRenewalPeriodDays,
+	FieldQueueWeight,
+	FieldQueuePriority,
+	FieldSchedulingStrategy,
+	FieldHedgeEnabled,
+	FieldHedgePercentile,
+	FieldHedgeLoserBilling,
 }
 
 var (
@@ -259,6 +289,24 @@ var (
 	DefaultDefaultMappedModel string
 	// DefaultMappedModelValidator is a validator for the "default_mapped_model" field. It is called by the builders before save.
 	DefaultMappedModelValidator func(string) error
+	// DefaultRenewalPeriodDays holds the default value on creation for the "renewal_period_days" field.
+	DefaultRenewalPeriodDays int
+	// DefaultQueueWeight holds the default value on creation for the "queue_weight" field.
+	DefaultQueueWeight int
+	// DefaultQueuePriority holds the default value on creation for the "queue_priority" field.
+	DefaultQueuePriority int
+	// DefaultSchedulingStrategy holds the default value on creation for the "scheduling_strategy" field.
+	DefaultSchedulingStrategy string
+	// SchedulingStrategyValidator is a validator for the "scheduling_strategy" field. It is called by the builders before save.
+	SchedulingStrategyValidator func(string) error
+	// DefaultHedgeEnabled holds the default value on creation for the "hedge_enabled" field.
+	DefaultHedgeEnabled bool
+	// DefaultHedgePercentile holds the default value on creation for the "hedge_percentile" field.
+	DefaultHedgePercentile int
+	// DefaultHedgeLoserBilling holds the default value on creation for the "hedge_loser_billing" field.
+	DefaultHedgeLoserBilling string
+	// HedgeLoserBillingValidator is a validator for the "hedge_loser_billing" field. It is called by the builders before save.
+	HedgeLoserBillingValidator func(string) error
 )
 
 // OrderOption defines the ordering options for the Group queries.
@@ -419,6 +467,46 @@ func ByDefaultMappedModel(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldDefaultMappedModel, opts...).ToFunc()
 }
 
+// ByPrice orders the results by the price field.
+func ByPrice(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldPrice, opts...).ToFunc()
+}
+
+// ByRenewalPeriodDays orders the results by the renewal_period_days field.
+func ByRenewalPeriodDays(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldRenewalPeriodDays, opts...).ToFunc()
+}
+
+// ByQueueWeight orders the results by the queue_weight field.
+func ByQueueWeight(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldQueueWeight, opts...).ToFunc()
+}
+
+// ByQueuePriority orders the results by the queue_priority field.
+func ByQueuePriority(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldQueuePriority, opts...).ToFunc()
+}
+
+// BySchedulingStrategy orders the results by the scheduling_strategy field.
+func BySchedulingStrategy(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldSchedulingStrategy, opts...).ToFunc()
+}
+
+// ByHedgeEnabled orders the results by the hedge_enabled field.
+func ByHedgeEnabled(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldHedgeEnabled, opts...).ToFunc()
+}
+
+// ByHedgePercentile orders the results by the hedge_percentile field.
+func ByHedgePercentile(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldHedgePercentile, opts...).ToFunc()
+}
+
+// ByHedgeLoserBilling orders the results by the hedge_loser_billing field.
+func ByHedgeLoserBilling(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldHedgeLoserBilling, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -205,6 +205,46 @@ func DefaultMappedModel(v string) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldDefaultMappedModel, v))
 }
 
+// Price applies equality check predicate on the "price" field. It's identical to PriceEQ.
+func Price(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldPrice, v))
+}
+
+// RenewalPeriodDays applies equality check predicate on the "renewal_period_days" field. It's identical to RenewalPeriodDaysEQ.
+func RenewalPeriodDays(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldRenewalPeriodDays, v))
+}
+
+// QueueWeight applies equality check predicate on the "queue_weight" field. It's identical to QueueWeightEQ.
+func QueueWeight(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldQueueWeight, v))
+}
+
+// QueuePriority applies equality check predicate on the "queue_priority" field. It's identical to QueuePriorityEQ.
+func QueuePriority(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldQueuePriority, v))
+}
+
+// SchedulingStrategy applies equality check predicate on the "scheduling_strategy" field. It's identical to SchedulingStrategyEQ.
+func SchedulingStrategy(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSchedulingStrategy, v))
+}
+
+// HedgeEnabled applies equality check predicate on the "hedge_enabled" field. It's identical to HedgeEnabledEQ.
+func HedgeEnabled(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgeEnabled, v))
+}
+
+// HedgePercentile applies equality check predicate on the "hedge_percentile" field. It's identical to HedgePercentileEQ.
+func HedgePercentile(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgePercentile, v))
+}
+
+// HedgeLoserBilling applies equality check predicate on the "hedge_loser_billing" field. It's identical to HedgeLoserBillingEQ.
+func HedgeLoserBilling(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgeLoserBilling, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldCreatedAt, v))
@@ -1430,6 +1470,26 @@ func ModelRoutingEnabledNEQ(v bool) predicate.Group {
 	return predicate.Group(sql.FieldNEQ(FieldModelRoutingEnabled, v))
 }
 
+// ModelFallbacksIsNil applies the IsNil predicate on the "model_fallbacks" field.
+func ModelFallbacksIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldModelFallbacks))
+}
+
+// ModelFallbacksNotNil applies the NotNil predicate on the "model_fallbacks" field.
+func ModelFallbacksNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldModelFallbacks))
+}
+
+// RequestTransformsIsNil applies the IsNil predicate on the "request_transforms" field.
+func RequestTransformsIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldRequestTransforms))
+}
+
+// RequestTransformsNotNil applies the NotNil predicate on the "request_transforms" field.
+func RequestTransformsNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldRequestTransforms))
+}
+
 // McpXMLInjectEQ applies the EQ predicate on the "mcp_xml_inject" field.
 func McpXMLInjectEQ(v bool) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldMcpXMLInject, v))
@@ -1555,6 +1615,356 @@ func DefaultMappedModelContainsFold(v string) predicate.Group {
 	return predicate.Group(sql.FieldContainsFold(FieldDefaultMappedModel, v))
 }
 
+// PriceEQ applies the EQ predicate on the "price" field.
+func PriceEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldPrice, v))
+}
+
+// PriceNEQ applies the NEQ predicate on the "price" field.
+func PriceNEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldPrice, v))
+}
+
+// PriceIn applies the In predicate on the "price" field.
+func PriceIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldPrice, vs...))
+}
+
+// PriceNotIn applies the NotIn predicate on the "price" field.
+func PriceNotIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldPrice, vs...))
+}
+
+// PriceGT applies the GT predicate on the "price" field.
+func PriceGT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldPrice, v))
+}
+
+// PriceGTE applies the GTE predicate on the "price" field.
+func PriceGTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldPrice, v))
+}
+
+// PriceLT applies the LT predicate on the "price" field.
+func PriceLT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldPrice, v))
+}
+
+// PriceLTE applies the LTE predicate on the "price" field.
+func PriceLTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldPrice, v))
+}
+
+// PriceIsNil applies the IsNil predicate on the "price" field.
+func PriceIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldPrice))
+}
+
+// PriceNotNil applies the NotNil predicate on the "price" field.
+func PriceNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldPrice))
+}
+
+// RenewalPeriodDaysEQ applies the EQ predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldRenewalPeriodDays, v))
+}
+
+// RenewalPeriodDaysNEQ applies the NEQ predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysNEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldRenewalPeriodDays, v))
+}
+
+// RenewalPeriodDaysIn applies the In predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldRenewalPeriodDays, vs...))
+}
+
+// RenewalPeriodDaysNotIn applies the NotIn predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysNotIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldRenewalPeriodDays, vs...))
+}
+
+// RenewalPeriodDaysGT applies the GT predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysGT(v int) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldRenewalPeriodDays, v))
+}
+
+// RenewalPeriodDaysGTE applies the GTE predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysGTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldRenewalPeriodDays, v))
+}
+
+// RenewalPeriodDaysLT applies the LT predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysLT(v int) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldRenewalPeriodDays, v))
+}
+
+// RenewalPeriodDaysLTE applies the LTE predicate on the "renewal_period_days" field.
+func RenewalPeriodDaysLTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldRenewalPeriodDays, v))
+}
+
+// QueueWeightEQ applies the EQ predicate on the "queue_weight" field.
+func QueueWeightEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldQueueWeight, v))
+}
+
+// QueueWeightNEQ applies the NEQ predicate on the "queue_weight" field.
+func QueueWeightNEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldQueueWeight, v))
+}
+
+// QueueWeightIn applies the In predicate on the "queue_weight" field.
+func QueueWeightIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldQueueWeight, vs...))
+}
+
+// QueueWeightNotIn applies the NotIn predicate on the "queue_weight" field.
+func QueueWeightNotIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldQueueWeight, vs...))
+}
+
+// QueueWeightGT applies the GT predicate on the "queue_weight" field.
+func QueueWeightGT(v int) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldQueueWeight, v))
+}
+
+// QueueWeightGTE applies the GTE predicate on the "queue_weight" field.
+func QueueWeightGTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldQueueWeight, v))
+}
+
+// QueueWeightLT applies the LT predicate on the "queue_weight" field.
+func QueueWeightLT(v int) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldQueueWeight, v))
+}
+
+// QueueWeightLTE applies the LTE predicate on the "queue_weight" field.
+func QueueWeightLTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldQueueWeight, v))
+}
+
+// QueuePriorityEQ applies the EQ predicate on the "queue_priority" field.
+func QueuePriorityEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldQueuePriority, v))
+}
+
+// QueuePriorityNEQ applies the NEQ predicate on the "queue_priority" field.
+func QueuePriorityNEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldQueuePriority, v))
+}
+
+// QueuePriorityIn applies the In predicate on the "queue_priority" field.
+func QueuePriorityIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldQueuePriority, vs...))
+}
+
+// QueuePriorityNotIn applies the NotIn predicate on the "queue_priority" field.
+func QueuePriorityNotIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldQueuePriority, vs...))
+}
+
+// QueuePriorityGT applies the GT predicate on the "queue_priority" field.
+func QueuePriorityGT(v int) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldQueuePriority, v))
+}
+
+// QueuePriorityGTE applies the GTE predicate on the "queue_priority" field.
+func QueuePriorityGTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldQueuePriority, v))
+}
+
+// QueuePriorityLT applies the LT predicate on the "queue_priority" field.
+func QueuePriorityLT(v int) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldQueuePriority, v))
+}
+
+// QueuePriorityLTE applies the LTE predicate on the "queue_priority" field.
+func QueuePriorityLTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldQueuePriority, v))
+}
+
+// SchedulingStrategyEQ applies the EQ predicate on the "scheduling_strategy" field.
+func SchedulingStrategyEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyNEQ applies the NEQ predicate on the "scheduling_strategy" field.
+func SchedulingStrategyNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyIn applies the In predicate on the "scheduling_strategy" field.
+func SchedulingStrategyIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldSchedulingStrategy, vs...))
+}
+
+// SchedulingStrategyNotIn applies the NotIn predicate on the "scheduling_strategy" field.
+func SchedulingStrategyNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldSchedulingStrategy, vs...))
+}
+
+// SchedulingStrategyGT applies the GT predicate on the "scheduling_strategy" field.
+func SchedulingStrategyGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyGTE applies the GTE predicate on the "scheduling_strategy" field.
+func SchedulingStrategyGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyLT applies the LT predicate on the "scheduling_strategy" field.
+func SchedulingStrategyLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyLTE applies the LTE predicate on the "scheduling_strategy" field.
+func SchedulingStrategyLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyContains applies the Contains predicate on the "scheduling_strategy" field.
+func SchedulingStrategyContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyHasPrefix applies the HasPrefix predicate on the "scheduling_strategy" field.
+func SchedulingStrategyHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyHasSuffix applies the HasSuffix predicate on the "scheduling_strategy" field.
+func SchedulingStrategyHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyEqualFold applies the EqualFold predicate on the "scheduling_strategy" field.
+func SchedulingStrategyEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldSchedulingStrategy, v))
+}
+
+// SchedulingStrategyContainsFold applies the ContainsFold predicate on the "scheduling_strategy" field.
+func SchedulingStrategyContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldSchedulingStrategy, v))
+}
+
+// HedgeEnabledEQ applies the EQ predicate on the "hedge_enabled" field.
+func HedgeEnabledEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgeEnabled, v))
+}
+
+// HedgeEnabledNEQ applies the NEQ predicate on the "hedge_enabled" field.
+func HedgeEnabledNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldHedgeEnabled, v))
+}
+
+// HedgePercentileEQ applies the EQ predicate on the "hedge_percentile" field.
+func HedgePercentileEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgePercentile, v))
+}
+
+// HedgePercentileNEQ applies the NEQ predicate on the "hedge_percentile" field.
+func HedgePercentileNEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldHedgePercentile, v))
+}
+
+// HedgePercentileIn applies the In predicate on the "hedge_percentile" field.
+func HedgePercentileIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldHedgePercentile, vs...))
+}
+
+// HedgePercentileNotIn applies the NotIn predicate on the "hedge_percentile" field.
+func HedgePercentileNotIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldHedgePercentile, vs...))
+}
+
+// HedgePercentileGT applies the GT predicate on the "hedge_percentile" field.
+func HedgePercentileGT(v int) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldHedgePercentile, v))
+}
+
+// HedgePercentileGTE applies the GTE predicate on the "hedge_percentile" field.
+func HedgePercentileGTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldHedgePercentile, v))
+}
+
+// HedgePercentileLT applies the LT predicate on the "hedge_percentile" field.
+func HedgePercentileLT(v int) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldHedgePercentile, v))
+}
+
+// HedgePercentileLTE applies the LTE predicate on the "hedge_percentile" field.
+func HedgePercentileLTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldHedgePercentile, v))
+}
+
+// HedgeLoserBillingEQ applies the EQ predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingNEQ applies the NEQ predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingIn applies the In predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldHedgeLoserBilling, vs...))
+}
+
+// HedgeLoserBillingNotIn applies the NotIn predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldHedgeLoserBilling, vs...))
+}
+
+// HedgeLoserBillingGT applies the GT predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingGTE applies the GTE predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingLT applies the LT predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingLTE applies the LTE predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingContains applies the Contains predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingHasPrefix applies the HasPrefix predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingHasSuffix applies the HasSuffix predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingEqualFold applies the EqualFold predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldHedgeLoserBilling, v))
+}
+
+// HedgeLoserBillingContainsFold applies the ContainsFold predicate on the "hedge_loser_billing" field.
+func HedgeLoserBillingContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldHedgeLoserBilling, v))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.Group {
 	return predicate.Group(func(s *sql.Selector) {
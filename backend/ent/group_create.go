@@ -18,6 +18,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
 	"github.com/Wei-Shaw/sub2api/ent/user"
 	"github.com/Wei-Shaw/sub2api/ent/usersubscription"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // GroupCreate is the builder for creating a Group entity.
@@ -390,6 +391,18 @@ func (_c *GroupCreate) SetNillableModelRoutingEnabled(v *bool) *GroupCreate {
 	return _c
 }
 
+// SetModelFallbacks sets the "model_fallbacks" field.
+func (_c *GroupCreate) SetModelFallbacks(v map[string][]domain.ModelFallbackTarget) *GroupCreate {
+	_c.mutation.SetModelFallbacks(v)
+	return _c
+}
+
+// SetRequestTransforms sets the "request_transforms" field.
+func (_c *GroupCreate) SetRequestTransforms(v []domain.RequestTransformRule) *GroupCreate {
+	_c.mutation.SetRequestTransforms(v)
+	return _c
+}
+
 // SetMcpXMLInject sets the "mcp_xml_inject" field.
 func (_c *GroupCreate) SetMcpXMLInject(v bool) *GroupCreate {
 	_c.mutation.SetMcpXMLInject(v)
@@ -452,6 +465,118 @@ func (_c *GroupCreate) SetNillableDefaultMappedModel(v *string) *GroupCreate {
 	return _c
 }
 
+// SetPrice sets the "price" field.
+func (_c *GroupCreate) SetPrice(v float64) *GroupCreate {
+	_c.mutation.SetPrice(v)
+	return _c
+}
+
+// SetNillablePrice sets the "price" field if the given value is not nil.
+func (_c *GroupCreate) SetNillablePrice(v *float64) *GroupCreate {
+	if v != nil {
+		_c.SetPrice(*v)
+	}
+	return _c
+}
+
+// SetRenewalPeriodDays sets the "renewal_period_days" field.
+func (_c *GroupCreate) SetRenewalPeriodDays(v int) *GroupCreate {
+	_c.mutation.SetRenewalPeriodDays(v)
+	return _c
+}
+
+// SetNillableRenewalPeriodDays sets the "renewal_period_days" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableRenewalPeriodDays(v *int) *GroupCreate {
+	if v != nil {
+		_c.SetRenewalPeriodDays(*v)
+	}
+	return _c
+}
+
+// SetQueueWeight sets the "queue_weight" field.
+func (_c *GroupCreate) SetQueueWeight(v int) *GroupCreate {
+	_c.mutation.SetQueueWeight(v)
+	return _c
+}
+
+// SetNillableQueueWeight sets the "queue_weight" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableQueueWeight(v *int) *GroupCreate {
+	if v != nil {
+		_c.SetQueueWeight(*v)
+	}
+	return _c
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (_c *GroupCreate) SetQueuePriority(v int) *GroupCreate {
+	_c.mutation.SetQueuePriority(v)
+	return _c
+}
+
+// SetNillableQueuePriority sets the "queue_priority" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableQueuePriority(v *int) *GroupCreate {
+	if v != nil {
+		_c.SetQueuePriority(*v)
+	}
+	return _c
+}
+
+// SetSchedulingStrategy sets the "scheduling_strategy" field.
+func (_c *GroupCreate) SetSchedulingStrategy(v string) *GroupCreate {
+	_c.mutation.SetSchedulingStrategy(v)
+	return _c
+}
+
+// SetNillableSchedulingStrategy sets the "scheduling_strategy" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableSchedulingStrategy(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetSchedulingStrategy(*v)
+	}
+	return _c
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (_c *GroupCreate) SetHedgeEnabled(v bool) *GroupCreate {
+	_c.mutation.SetHedgeEnabled(v)
+	return _c
+}
+
+// SetNillableHedgeEnabled sets the "hedge_enabled" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableHedgeEnabled(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetHedgeEnabled(*v)
+	}
+	return _c
+}
+
+// SetHedgePercentile sets the "hedge_percentile" field.
+func (_c *GroupCreate) SetHedgePercentile(v int) *GroupCreate {
+	_c.mutation.SetHedgePercentile(v)
+	return _c
+}
+
+// SetNillableHedgePercentile sets the "hedge_percentile" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableHedgePercentile(v *int) *GroupCreate {
+	if v != nil {
+		_c.SetHedgePercentile(*v)
+	}
+	return _c
+}
+
+// SetHedgeLoserBilling sets the "hedge_loser_billing" field.
+func (_c *GroupCreate) SetHedgeLoserBilling(v string) *GroupCreate {
+	_c.mutation.SetHedgeLoserBilling(v)
+	return _c
+}
+
+// SetNillableHedgeLoserBilling sets the "hedge_loser_billing" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableHedgeLoserBilling(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetHedgeLoserBilling(*v)
+	}
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *GroupCreate) AddAPIKeyIDs(ids ...int64) *GroupCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -649,6 +774,34 @@ func (_c *GroupCreate) defaults() error {
 		v := group.DefaultDefaultMappedModel
 		_c.mutation.SetDefaultMappedModel(v)
 	}
+	if _, ok := _c.mutation.RenewalPeriodDays(); !ok {
+		v := group.DefaultRenewalPeriodDays
+		_c.mutation.SetRenewalPeriodDays(v)
+	}
+	if _, ok := _c.mutation.QueueWeight(); !ok {
+		v := group.DefaultQueueWeight
+		_c.mutation.SetQueueWeight(v)
+	}
+	if _, ok := _c.mutation.QueuePriority(); !ok {
+		v := group.DefaultQueuePriority
+		_c.mutation.SetQueuePriority(v)
+	}
+	if _, ok := _c.mutation.SchedulingStrategy(); !ok {
+		v := group.DefaultSchedulingStrategy
+		_c.mutation.SetSchedulingStrategy(v)
+	}
+	if _, ok := _c.mutation.HedgeEnabled(); !ok {
+		v := group.DefaultHedgeEnabled
+		_c.mutation.SetHedgeEnabled(v)
+	}
+	if _, ok := _c.mutation.HedgePercentile(); !ok {
+		v := group.DefaultHedgePercentile
+		_c.mutation.SetHedgePercentile(v)
+	}
+	if _, ok := _c.mutation.HedgeLoserBilling(); !ok {
+		v := group.DefaultHedgeLoserBilling
+		_c.mutation.SetHedgeLoserBilling(v)
+	}
 	return nil
 }
 
@@ -730,6 +883,37 @@ func (_c *GroupCreate) check() error {
 			return &ValidationError{Name: "default_mapped_model", err: fmt.Errorf(`ent: validator failed for field "Group.default_mapped_model": %w`, err)}
 		}
 	}
+	if _, ok := _c.mutation.RenewalPeriodDays(); !ok {
+		return &ValidationError{Name: "renewal_period_days", err: errors.New(`ent: missing required field "Group.renewal_period_days"`)}
+	}
+	if _, ok := _c.mutation.QueueWeight(); !ok {
+		return &ValidationError{Name: "queue_weight", err: errors.New(`ent: missing required field "Group.queue_weight"`)}
+	}
+	if _, ok := _c.mutation.QueuePriority(); !ok {
+		return &ValidationError{Name: "queue_priority", err: errors.New(`ent: missing required field "Group.queue_priority"`)}
+	}
+	if _, ok := _c.mutation.SchedulingStrategy(); !ok {
+		return &ValidationError{Name: "scheduling_strategy", err: errors.New(`ent: missing required field "Group.scheduling_strategy"`)}
+	}
+	if v, ok := _c.mutation.SchedulingStrategy(); ok {
+		if err := group.SchedulingStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "scheduling_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.scheduling_strategy": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.HedgeEnabled(); !ok {
+		return &ValidationError{Name: "hedge_enabled", err: errors.New(`ent: missing required field "Group.hedge_enabled"`)}
+	}
+	if _, ok := _c.mutation.HedgePercentile(); !ok {
+		return &ValidationError{Name: "hedge_percentile", err: errors.New(`ent: missing required field "Group.hedge_percentile"`)}
+	}
+	if _, ok := _c.mutation.HedgeLoserBilling(); !ok {
+		return &ValidationError{Name: "hedge_loser_billing", err: errors.New(`ent: missing required field "Group.hedge_loser_billing"`)}
+	}
+	if v, ok := _c.mutation.HedgeLoserBilling(); ok {
+		if err := group.HedgeLoserBillingValidator(v); err != nil {
+			return &ValidationError{Name: "hedge_loser_billing", err: fmt.Errorf(`ent: validator failed for field "Group.hedge_loser_billing": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -865,6 +1049,14 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 		_node.ModelRoutingEnabled = value
 	}
+	if value, ok := _c.mutation.ModelFallbacks(); ok {
+		_spec.SetField(group.FieldModelFallbacks, field.TypeJSON, value)
+		_node.ModelFallbacks = value
+	}
+	if value, ok := _c.mutation.RequestTransforms(); ok {
+		_spec.SetField(group.FieldRequestTransforms, field.TypeJSON, value)
+		_node.RequestTransforms = value
+	}
 	if value, ok := _c.mutation.McpXMLInject(); ok {
 		_spec.SetField(group.FieldMcpXMLInject, field.TypeBool, value)
 		_node.McpXMLInject = value
@@ -885,6 +1077,38 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldDefaultMappedModel, field.TypeString, value)
 		_node.DefaultMappedModel = value
 	}
+	if value, ok := _c.mutation.Price(); ok {
+		_spec.SetField(group.FieldPrice, field.TypeFloat64, value)
+		_node.Price = &value
+	}
+	if value, ok := _c.mutation.RenewalPeriodDays(); ok {
+		_spec.SetField(group.FieldRenewalPeriodDays, field.TypeInt, value)
+		_node.RenewalPeriodDays = value
+	}
+	if value, ok := _c.mutation.QueueWeight(); ok {
+		_spec.SetField(group.FieldQueueWeight, field.TypeInt, value)
+		_node.QueueWeight = value
+	}
+	if value, ok := _c.mutation.QueuePriority(); ok {
+		_spec.SetField(group.FieldQueuePriority, field.TypeInt, value)
+		_node.QueuePriority = value
+	}
+	if value, ok := _c.mutation.SchedulingStrategy(); ok {
+		_spec.SetField(group.FieldSchedulingStrategy, field.TypeString, value)
+		_node.SchedulingStrategy = value
+	}
+	if value, ok := _c.mutation.HedgeEnabled(); ok {
+		_spec.SetField(group.FieldHedgeEnabled, field.TypeBool, value)
+		_node.HedgeEnabled = value
+	}
+	if value, ok := _c.mutation.HedgePercentile(); ok {
+		_spec.SetField(group.FieldHedgePercentile, field.TypeInt, value)
+		_node.HedgePercentile = value
+	}
+	if value, ok := _c.mutation.HedgeLoserBilling(); ok {
+		_spec.SetField(group.FieldHedgeLoserBilling, field.TypeString, value)
+		_node.HedgeLoserBilling = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1533,6 +1757,42 @@ func (u *GroupUpsert) UpdateModelRoutingEnabled() *GroupUpsert {
 	return u
 }
 
+// SetModelFallbacks sets the "model_fallbacks" field.
+func (u *GroupUpsert) SetModelFallbacks(v map[string][]domain.ModelFallbackTarget) *GroupUpsert {
+	u.Set(group.FieldModelFallbacks, v)
+	return u
+}
+
+// UpdateModelFallbacks sets the "model_fallbacks" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelFallbacks() *GroupUpsert {
+	u.SetExcluded(group.FieldModelFallbacks)
+	return u
+}
+
+// ClearModelFallbacks clears the value of the "model_fallbacks" field.
+func (u *GroupUpsert) ClearModelFallbacks() *GroupUpsert {
+	u.SetNull(group.FieldModelFallbacks)
+	return u
+}
+
+// SetRequestTransforms sets the "request_transforms" field.
+func (u *GroupUpsert) SetRequestTransforms(v []domain.RequestTransformRule) *GroupUpsert {
+	u.Set(group.FieldRequestTransforms, v)
+	return u
+}
+
+// UpdateRequestTransforms sets the "request_transforms" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateRequestTransforms() *GroupUpsert {
+	u.SetExcluded(group.FieldRequestTransforms)
+	return u
+}
+
+// ClearRequestTransforms clears the value of the "request_transforms" field.
+func (u *GroupUpsert) ClearRequestTransforms() *GroupUpsert {
+	u.SetNull(group.FieldRequestTransforms)
+	return u
+}
+
 // SetMcpXMLInject sets the "mcp_xml_inject" field.
 func (u *GroupUpsert) SetMcpXMLInject(v bool) *GroupUpsert {
 	u.Set(group.FieldMcpXMLInject, v)
@@ -1599,6 +1859,138 @@ func (u *GroupUpsert) UpdateDefaultMappedModel() *GroupUpsert {
 	return u
 }
 
+// SetPrice sets the "price" field.
+func (u *GroupUpsert) SetPrice(v float64) *GroupUpsert {
+	u.Set(group.FieldPrice, v)
+	return u
+}
+
+// UpdatePrice sets the "price" field to the value that was provided on create.
+func (u *GroupUpsert) UpdatePrice() *GroupUpsert {
+	u.SetExcluded(group.FieldPrice)
+	return u
+}
+
+// AddPrice adds v to the "price" field.
+func (u *GroupUpsert) AddPrice(v float64) *GroupUpsert {
+	u.Add(group.FieldPrice, v)
+	return u
+}
+
+// ClearPrice clears the value of the "price" field.
+func (u *GroupUpsert) ClearPrice() *GroupUpsert {
+	u.SetNull(group.FieldPrice)
+	return u
+}
+
+// SetRenewalPeriodDays sets the "renewal_period_days" field.
+func (u *GroupUpsert) SetRenewalPeriodDays(v int) *GroupUpsert {
+	u.Set(group.FieldRenewalPeriodDays, v)
+	return u
+}
+
+// UpdateRenewalPeriodDays sets the "renewal_period_days" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateRenewalPeriodDays() *GroupUpsert {
+	u.SetExcluded(group.FieldRenewalPeriodDays)
+	return u
+}
+
+// AddRenewalPeriodDays adds v to the "renewal_period_days" field.
+func (u *GroupUpsert) AddRenewalPeriodDays(v int) *GroupUpsert {
+	u.Add(group.FieldRenewalPeriodDays, v)
+	return u
+}
+
+// SetQueueWeight sets the "queue_weight" field.
+func (u *GroupUpsert) SetQueueWeight(v int) *GroupUpsert {
+	u.Set(group.FieldQueueWeight, v)
+	return u
+}
+
+// UpdateQueueWeight sets the "queue_weight" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateQueueWeight() *GroupUpsert {
+	u.SetExcluded(group.FieldQueueWeight)
+	return u
+}
+
+// AddQueueWeight adds v to the "queue_weight" field.
+func (u *GroupUpsert) AddQueueWeight(v int) *GroupUpsert {
+	u.Add(group.FieldQueueWeight, v)
+	return u
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (u *GroupUpsert) SetQueuePriority(v int) *GroupUpsert {
+	u.Set(group.FieldQueuePriority, v)
+	return u
+}
+
+// UpdateQueuePriority sets the "queue_priority" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateQueuePriority() *GroupUpsert {
+	u.SetExcluded(group.FieldQueuePriority)
+	return u
+}
+
+// AddQueuePriority adds v to the "queue_priority" field.
+func (u *GroupUpsert) AddQueuePriority(v int) *GroupUpsert {
+	u.Add(group.FieldQueuePriority, v)
+	return u
+}
+
+// SetSchedulingStrategy sets the "scheduling_strategy" field.
+func (u *GroupUpsert) SetSchedulingStrategy(v string) *GroupUpsert {
+	u.Set(group.FieldSchedulingStrategy, v)
+	return u
+}
+
+// UpdateSchedulingStrategy sets the "scheduling_strategy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateSchedulingStrategy() *GroupUpsert {
+	u.SetExcluded(group.FieldSchedulingStrategy)
+	return u
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (u *GroupUpsert) SetHedgeEnabled(v bool) *GroupUpsert {
+	u.Set(group.FieldHedgeEnabled, v)
+	return u
+}
+
+// UpdateHedgeEnabled sets the "hedge_enabled" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateHedgeEnabled() *GroupUpsert {
+	u.SetExcluded(group.FieldHedgeEnabled)
+	return u
+}
+
+// SetHedgePercentile sets the "hedge_percentile" field.
+func (u *GroupUpsert) SetHedgePercentile(v int) *GroupUpsert {
+	u.Set(group.FieldHedgePercentile, v)
+	return u
+}
+
+// UpdateHedgePercentile sets the "hedge_percentile" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateHedgePercentile() *GroupUpsert {
+	u.SetExcluded(group.FieldHedgePercentile)
+	return u
+}
+
+// AddHedgePercentile adds v to the "hedge_percentile" field.
+func (u *GroupUpsert) AddHedgePercentile(v int) *GroupUpsert {
+	u.Add(group.FieldHedgePercentile, v)
+	return u
+}
+
+// SetHedgeLoserBilling sets the "hedge_loser_billing" field.
+func (u *GroupUpsert) SetHedgeLoserBilling(v string) *GroupUpsert {
+	u.Set(group.FieldHedgeLoserBilling, v)
+	return u
+}
+
+// UpdateHedgeLoserBilling sets the "hedge_loser_billing" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateHedgeLoserBilling() *GroupUpsert {
+	u.SetExcluded(group.FieldHedgeLoserBilling)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -2218,6 +2610,48 @@ func (u *GroupUpsertOne) UpdateModelRoutingEnabled() *GroupUpsertOne {
 	})
 }
 
+// SetModelFallbacks sets the "model_fallbacks" field.
+func (u *GroupUpsertOne) SetModelFallbacks(v map[string][]domain.ModelFallbackTarget) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallbacks(v)
+	})
+}
+
+// UpdateModelFallbacks sets the "model_fallbacks" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelFallbacks() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallbacks()
+	})
+}
+
+// ClearModelFallbacks clears the value of the "model_fallbacks" field.
+func (u *GroupUpsertOne) ClearModelFallbacks() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelFallbacks()
+	})
+}
+
+// SetRequestTransforms sets the "request_transforms" field.
+func (u *GroupUpsertOne) SetRequestTransforms(v []domain.RequestTransformRule) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRequestTransforms(v)
+	})
+}
+
+// UpdateRequestTransforms sets the "request_transforms" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateRequestTransforms() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRequestTransforms()
+	})
+}
+
+// ClearRequestTransforms clears the value of the "request_transforms" field.
+func (u *GroupUpsertOne) ClearRequestTransforms() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRequestTransforms()
+	})
+}
+
 // SetMcpXMLInject sets the "mcp_xml_inject" field.
 func (u *GroupUpsertOne) SetMcpXMLInject(v bool) *GroupUpsertOne {
 	return u.Update(func(s *GroupUpsert) {
@@ -2295,6 +2729,160 @@ func (u *GroupUpsertOne) UpdateDefaultMappedModel() *GroupUpsertOne {
 	})
 }
 
+// SetPrice sets the "price" field.
+func (u *GroupUpsertOne) SetPrice(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetPrice(v)
+	})
+}
+
+// AddPrice adds v to the "price" field.
+func (u *GroupUpsertOne) AddPrice(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddPrice(v)
+	})
+}
+
+// UpdatePrice sets the "price" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdatePrice() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdatePrice()
+	})
+}
+
+// ClearPrice clears the value of the "price" field.
+func (u *GroupUpsertOne) ClearPrice() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearPrice()
+	})
+}
+
+// SetRenewalPeriodDays sets the "renewal_period_days" field.
+func (u *GroupUpsertOne) SetRenewalPeriodDays(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRenewalPeriodDays(v)
+	})
+}
+
+// AddRenewalPeriodDays adds v to the "renewal_period_days" field.
+func (u *GroupUpsertOne) AddRenewalPeriodDays(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddRenewalPeriodDays(v)
+	})
+}
+
+// UpdateRenewalPeriodDays sets the "renewal_period_days" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateRenewalPeriodDays() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRenewalPeriodDays()
+	})
+}
+
+// SetQueueWeight sets the "queue_weight" field.
+func (u *GroupUpsertOne) SetQueueWeight(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetQueueWeight(v)
+	})
+}
+
+// AddQueueWeight adds v to the "queue_weight" field.
+func (u *GroupUpsertOne) AddQueueWeight(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddQueueWeight(v)
+	})
+}
+
+// UpdateQueueWeight sets the "queue_weight" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateQueueWeight() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateQueueWeight()
+	})
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (u *GroupUpsertOne) SetQueuePriority(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetQueuePriority(v)
+	})
+}
+
+// AddQueuePriority adds v to the "queue_priority" field.
+func (u *GroupUpsertOne) AddQueuePriority(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddQueuePriority(v)
+	})
+}
+
+// UpdateQueuePriority sets the "queue_priority" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateQueuePriority() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateQueuePriority()
+	})
+}
+
+// SetSchedulingStrategy sets the "scheduling_strategy" field.
+func (u *GroupUpsertOne) SetSchedulingStrategy(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSchedulingStrategy(v)
+	})
+}
+
+// UpdateSchedulingStrategy sets the "scheduling_strategy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateSchedulingStrategy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSchedulingStrategy()
+	})
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (u *GroupUpsertOne) SetHedgeEnabled(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgeEnabled(v)
+	})
+}
+
+// UpdateHedgeEnabled sets the "hedge_enabled" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateHedgeEnabled() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgeEnabled()
+	})
+}
+
+// SetHedgePercentile sets the "hedge_percentile" field.
+func (u *GroupUpsertOne) SetHedgePercentile(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgePercentile(v)
+	})
+}
+
+// AddHedgePercentile adds v to the "hedge_percentile" field.
+func (u *GroupUpsertOne) AddHedgePercentile(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddHedgePercentile(v)
+	})
+}
+
+// UpdateHedgePercentile sets the "hedge_percentile" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateHedgePercentile() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgePercentile()
+	})
+}
+
+// SetHedgeLoserBilling sets the "hedge_loser_billing" field.
+func (u *GroupUpsertOne) SetHedgeLoserBilling(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgeLoserBilling(v)
+	})
+}
+
+// UpdateHedgeLoserBilling sets the "hedge_loser_billing" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateHedgeLoserBilling() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgeLoserBilling()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -3080,6 +3668,48 @@ func (u *GroupUpsertBulk) UpdateModelRoutingEnabled() *GroupUpsertBulk {
 	})
 }
 
+// SetModelFallbacks sets the "model_fallbacks" field.
+func (u *GroupUpsertBulk) SetModelFallbacks(v map[string][]domain.ModelFallbackTarget) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallbacks(v)
+	})
+}
+
+// UpdateModelFallbacks sets the "model_fallbacks" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelFallbacks() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallbacks()
+	})
+}
+
+// ClearModelFallbacks clears the value of the "model_fallbacks" field.
+func (u *GroupUpsertBulk) ClearModelFallbacks() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelFallbacks()
+	})
+}
+
+// SetRequestTransforms sets the "request_transforms" field.
+func (u *GroupUpsertBulk) SetRequestTransforms(v []domain.RequestTransformRule) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRequestTransforms(v)
+	})
+}
+
+// UpdateRequestTransforms sets the "request_transforms" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateRequestTransforms() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRequestTransforms()
+	})
+}
+
+// ClearRequestTransforms clears the value of the "request_transforms" field.
+func (u *GroupUpsertBulk) ClearRequestTransforms() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRequestTransforms()
+	})
+}
+
 // SetMcpXMLInject sets the "mcp_xml_inject" field.
 func (u *GroupUpsertBulk) SetMcpXMLInject(v bool) *GroupUpsertBulk {
 	return u.Update(func(s *GroupUpsert) {
@@ -3157,6 +3787,160 @@ func (u *GroupUpsertBulk) UpdateDefaultMappedModel() *GroupUpsertBulk {
 	})
 }
 
+// SetPrice sets the "price" field.
+func (u *GroupUpsertBulk) SetPrice(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetPrice(v)
+	})
+}
+
+// AddPrice adds v to the "price" field.
+func (u *GroupUpsertBulk) AddPrice(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddPrice(v)
+	})
+}
+
+// UpdatePrice sets the "price" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdatePrice() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdatePrice()
+	})
+}
+
+// ClearPrice clears the value of the "price" field.
+func (u *GroupUpsertBulk) ClearPrice() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearPrice()
+	})
+}
+
+// SetRenewalPeriodDays sets the "renewal_period_days" field.
+func (u *GroupUpsertBulk) SetRenewalPeriodDays(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRenewalPeriodDays(v)
+	})
+}
+
+// AddRenewalPeriodDays adds v to the "renewal_period_days" field.
+func (u *GroupUpsertBulk) AddRenewalPeriodDays(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddRenewalPeriodDays(v)
+	})
+}
+
+// UpdateRenewalPeriodDays sets the "renewal_period_days" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateRenewalPeriodDays() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRenewalPeriodDays()
+	})
+}
+
+// SetQueueWeight sets the "queue_weight" field.
+func (u *GroupUpsertBulk) SetQueueWeight(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetQueueWeight(v)
+	})
+}
+
+// AddQueueWeight adds v to the "queue_weight" field.
+func (u *GroupUpsertBulk) AddQueueWeight(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddQueueWeight(v)
+	})
+}
+
+// UpdateQueueWeight sets the "queue_weight" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateQueueWeight() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateQueueWeight()
+	})
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (u *GroupUpsertBulk) SetQueuePriority(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetQueuePriority(v)
+	})
+}
+
+// AddQueuePriority adds v to the "queue_priority" field.
+func (u *GroupUpsertBulk) AddQueuePriority(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddQueuePriority(v)
+	})
+}
+
+// UpdateQueuePriority sets the "queue_priority" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateQueuePriority() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateQueuePriority()
+	})
+}
+
+// SetSchedulingStrategy sets the "scheduling_strategy" field.
+func (u *GroupUpsertBulk) SetSchedulingStrategy(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSchedulingStrategy(v)
+	})
+}
+
+// UpdateSchedulingStrategy sets the "scheduling_strategy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateSchedulingStrategy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSchedulingStrategy()
+	})
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (u *GroupUpsertBulk) SetHedgeEnabled(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgeEnabled(v)
+	})
+}
+
+// UpdateHedgeEnabled sets the "hedge_enabled" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateHedgeEnabled() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgeEnabled()
+	})
+}
+
+// SetHedgePercentile sets the "hedge_percentile" field.
+func (u *GroupUpsertBulk) SetHedgePercentile(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgePercentile(v)
+	})
+}
+
+// AddHedgePercentile adds v to the "hedge_percentile" field.
+func (u *GroupUpsertBulk) AddHedgePercentile(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddHedgePercentile(v)
+	})
+}
+
+// UpdateHedgePercentile sets the "hedge_percentile" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateHedgePercentile() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgePercentile()
+	})
+}
+
+// SetHedgeLoserBilling sets the "hedge_loser_billing" field.
+func (u *GroupUpsertBulk) SetHedgeLoserBilling(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetHedgeLoserBilling(v)
+	})
+}
+
+// UpdateHedgeLoserBilling sets the "hedge_loser_billing" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateHedgeLoserBilling() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateHedgeLoserBilling()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -20,6 +20,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
 	"github.com/Wei-Shaw/sub2api/ent/user"
 	"github.com/Wei-Shaw/sub2api/ent/usersubscription"
+	"github.com/Wei-Shaw/sub2api/internal/domain"
 )
 
 // GroupUpdate is the builder for updating Group entities.
@@ -578,6 +579,36 @@ func (_u *GroupUpdate) SetNillableModelRoutingEnabled(v *bool) *GroupUpdate {
 	return _u
 }
 
+// SetModelFallbacks sets the "model_fallbacks" field.
+func (_u *GroupUpdate) SetModelFallbacks(v map[string][]domain.ModelFallbackTarget) *GroupUpdate {
+	_u.mutation.SetModelFallbacks(v)
+	return _u
+}
+
+// ClearModelFallbacks clears the value of the "model_fallbacks" field.
+func (_u *GroupUpdate) ClearModelFallbacks() *GroupUpdate {
+	_u.mutation.ClearModelFallbacks()
+	return _u
+}
+
+// SetRequestTransforms sets the "request_transforms" field.
+func (_u *GroupUpdate) SetRequestTransforms(v []domain.RequestTransformRule) *GroupUpdate {
+	_u.mutation.SetRequestTransforms(v)
+	return _u
+}
+
+// AppendRequestTransforms appends value to the "request_transforms" field.
+func (_u *GroupUpdate) AppendRequestTransforms(v []domain.RequestTransformRule) *GroupUpdate {
+	_u.mutation.AppendRequestTransforms(v)
+	return _u
+}
+
+// ClearRequestTransforms clears the value of the "request_transforms" field.
+func (_u *GroupUpdate) ClearRequestTransforms() *GroupUpdate {
+	_u.mutation.ClearRequestTransforms()
+	return _u
+}
+
 // SetMcpXMLInject sets the "mcp_xml_inject" field.
 func (_u *GroupUpdate) SetMcpXMLInject(v bool) *GroupUpdate {
 	_u.mutation.SetMcpXMLInject(v)
@@ -653,6 +684,159 @@ func (_u *GroupUpdate) SetNillableDefaultMappedModel(v *string) *GroupUpdate {
 	return _u
 }
 
+// SetPrice sets the "price" field.
+func (_u *GroupUpdate) SetPrice(v float64) *GroupUpdate {
+	_u.mutation.ResetPrice()
+	_u.mutation.SetPrice(v)
+	return _u
+}
+
+// SetNillablePrice sets the "price" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillablePrice(v *float64) *GroupUpdate {
+	if v != nil {
+		_u.SetPrice(*v)
+	}
+	return _u
+}
+
+// AddPrice adds value to the "price" field.
+func (_u *GroupUpdate) AddPrice(v float64) *GroupUpdate {
+	_u.mutation.AddPrice(v)
+	return _u
+}
+
+// ClearPrice clears the value of the "price" field.
+func (_u *GroupUpdate) ClearPrice() *GroupUpdate {
+	_u.mutation.ClearPrice()
+	return _u
+}
+
+// SetRenewalPeriodDays sets the "renewal_period_days" field.
+func (_u *GroupUpdate) SetRenewalPeriodDays(v int) *GroupUpdate {
+	_u.mutation.ResetRenewalPeriodDays()
+	_u.mutation.SetRenewalPeriodDays(v)
+	return _u
+}
+
+// SetNillableRenewalPeriodDays sets the "renewal_period_days" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableRenewalPeriodDays(v *int) *GroupUpdate {
+	if v != nil {
+		_u.SetRenewalPeriodDays(*v)
+	}
+	return _u
+}
+
+// AddRenewalPeriodDays adds value to the "renewal_period_days" field.
+func (_u *GroupUpdate) AddRenewalPeriodDays(v int) *GroupUpdate {
+	_u.mutation.AddRenewalPeriodDays(v)
+	return _u
+}
+
+// SetQueueWeight sets the "queue_weight" field.
+func (_u *GroupUpdate) SetQueueWeight(v int) *GroupUpdate {
+	_u.mutation.ResetQueueWeight()
+	_u.mutation.SetQueueWeight(v)
+	return _u
+}
+
+// SetNillableQueueWeight sets the "queue_weight" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableQueueWeight(v *int) *GroupUpdate {
+	if v != nil {
+		_u.SetQueueWeight(*v)
+	}
+	return _u
+}
+
+// AddQueueWeight adds value to the "queue_weight" field.
+func (_u *GroupUpdate) AddQueueWeight(v int) *GroupUpdate {
+	_u.mutation.AddQueueWeight(v)
+	return _u
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (_u *GroupUpdate) SetQueuePriority(v int) *GroupUpdate {
+	_u.mutation.ResetQueuePriority()
+	_u.mutation.SetQueuePriority(v)
+	return _u
+}
+
+// SetNillableQueuePriority sets the "queue_priority" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableQueuePriority(v *int) *GroupUpdate {
+	if v != nil {
+		_u.SetQueuePriority(*v)
+	}
+	return _u
+}
+
+// AddQueuePriority adds value to the "queue_priority" field.
+func (_u *GroupUpdate) AddQueuePriority(v int) *GroupUpdate {
+	_u.mutation.AddQueuePriority(v)
+	return _u
+}
+
+// SetSchedulingStrategy sets the "scheduling_strategy" field.
+func (_u *GroupUpdate) SetSchedulingStrategy(v string) *GroupUpdate {
+	_u.mutation.SetSchedulingStrategy(v)
+	return _u
+}
+
+// SetNillableSchedulingStrategy sets the "scheduling_strategy" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableSchedulingStrategy(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetSchedulingStrategy(*v)
+	}
+	return _u
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (_u *GroupUpdate) SetHedgeEnabled(v bool) *GroupUpdate {
+	_u.mutation.SetHedgeEnabled(v)
+	return _u
+}
+
+// SetNillableHedgeEnabled sets the "hedge_enabled" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableHedgeEnabled(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetHedgeEnabled(*v)
+	}
+	return _u
+}
+
+// SetHedgePercentile sets the "hedge_percentile" field.
+func (_u *GroupUpdate) SetHedgePercentile(v int) *GroupUpdate {
+	_u.mutation.ResetHedgePercentile()
+	_u.mutation.SetHedgePercentile(v)
+	return _u
+}
+
+// SetNillableHedgePercentile sets the "hedge_percentile" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableHedgePercentile(v *int) *GroupUpdate {
+	if v != nil {
+		_u.SetHedgePercentile(*v)
+	}
+	return _u
+}
+
+// AddHedgePercentile adds value to the "hedge_percentile" field.
+func (_u *GroupUpdate) AddHedgePercentile(v int) *GroupUpdate {
+	_u.mutation.AddHedgePercentile(v)
+	return _u
+}
+
+// SetHedgeLoserBilling sets the "hedge_loser_billing" field.
+func (_u *GroupUpdate) SetHedgeLoserBilling(v string) *GroupUpdate {
+	_u.mutation.SetHedgeLoserBilling(v)
+	return _u
+}
+
+// SetNillableHedgeLoserBilling sets the "hedge_loser_billing" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableHedgeLoserBilling(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetHedgeLoserBilling(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdate) AddAPIKeyIDs(ids ...int64) *GroupUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -943,6 +1127,16 @@ func (_u *GroupUpdate) check() error {
 			return &ValidationError{Name: "default_mapped_model", err: fmt.Errorf(`ent: validator failed for field "Group.default_mapped_model": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.SchedulingStrategy(); ok {
+		if err := group.SchedulingStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "scheduling_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.scheduling_strategy": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.HedgeLoserBilling(); ok {
+		if err := group.HedgeLoserBillingValidator(v); err != nil {
+			return &ValidationError{Name: "hedge_loser_billing", err: fmt.Errorf(`ent: validator failed for field "Group.hedge_loser_billing": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -1126,6 +1320,23 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.ModelFallbacks(); ok {
+		_spec.SetField(group.FieldModelFallbacks, field.TypeJSON, value)
+	}
+	if _u.mutation.ModelFallbacksCleared() {
+		_spec.ClearField(group.FieldModelFallbacks, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.RequestTransforms(); ok {
+		_spec.SetField(group.FieldRequestTransforms, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedRequestTransforms(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldRequestTransforms, value)
+		})
+	}
+	if _u.mutation.RequestTransformsCleared() {
+		_spec.ClearField(group.FieldRequestTransforms, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.McpXMLInject(); ok {
 		_spec.SetField(group.FieldMcpXMLInject, field.TypeBool, value)
 	}
@@ -1149,6 +1360,48 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.DefaultMappedModel(); ok {
 		_spec.SetField(group.FieldDefaultMappedModel, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.Price(); ok {
+		_spec.SetField(group.FieldPrice, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedPrice(); ok {
+		_spec.AddField(group.FieldPrice, field.TypeFloat64, value)
+	}
+	if _u.mutation.PriceCleared() {
+		_spec.ClearField(group.FieldPrice, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.RenewalPeriodDays(); ok {
+		_spec.SetField(group.FieldRenewalPeriodDays, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedRenewalPeriodDays(); ok {
+		_spec.AddField(group.FieldRenewalPeriodDays, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.QueueWeight(); ok {
+		_spec.SetField(group.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueueWeight(); ok {
+		_spec.AddField(group.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.QueuePriority(); ok {
+		_spec.SetField(group.FieldQueuePriority, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueuePriority(); ok {
+		_spec.AddField(group.FieldQueuePriority, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.SchedulingStrategy(); ok {
+		_spec.SetField(group.FieldSchedulingStrategy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.HedgeEnabled(); ok {
+		_spec.SetField(group.FieldHedgeEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.HedgePercentile(); ok {
+		_spec.SetField(group.FieldHedgePercentile, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedHedgePercentile(); ok {
+		_spec.AddField(group.FieldHedgePercentile, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.HedgeLoserBilling(); ok {
+		_spec.SetField(group.FieldHedgeLoserBilling, field.TypeString, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -2006,6 +2259,36 @@ func (_u *GroupUpdateOne) SetNillableModelRoutingEnabled(v *bool) *GroupUpdateOn
 	return _u
 }
 
+// SetModelFallbacks sets the "model_fallbacks" field.
+func (_u *GroupUpdateOne) SetModelFallbacks(v map[string][]domain.ModelFallbackTarget) *GroupUpdateOne {
+	_u.mutation.SetModelFallbacks(v)
+	return _u
+}
+
+// ClearModelFallbacks clears the value of the "model_fallbacks" field.
+func (_u *GroupUpdateOne) ClearModelFallbacks() *GroupUpdateOne {
+	_u.mutation.ClearModelFallbacks()
+	return _u
+}
+
+// SetRequestTransforms sets the "request_transforms" field.
+func (_u *GroupUpdateOne) SetRequestTransforms(v []domain.RequestTransformRule) *GroupUpdateOne {
+	_u.mutation.SetRequestTransforms(v)
+	return _u
+}
+
+// AppendRequestTransforms appends value to the "request_transforms" field.
+func (_u *GroupUpdateOne) AppendRequestTransforms(v []domain.RequestTransformRule) *GroupUpdateOne {
+	_u.mutation.AppendRequestTransforms(v)
+	return _u
+}
+
+// ClearRequestTransforms clears the value of the "request_transforms" field.
+func (_u *GroupUpdateOne) ClearRequestTransforms() *GroupUpdateOne {
+	_u.mutation.ClearRequestTransforms()
+	return _u
+}
+
 // SetMcpXMLInject sets the "mcp_xml_inject" field.
 func (_u *GroupUpdateOne) SetMcpXMLInject(v bool) *GroupUpdateOne {
 	_u.mutation.SetMcpXMLInject(v)
@@ -2081,6 +2364,159 @@ func (_u *GroupUpdateOne) SetNillableDefaultMappedModel(v *string) *GroupUpdateO
 	return _u
 }
 
+// SetPrice sets the "price" field.
+func (_u *GroupUpdateOne) SetPrice(v float64) *GroupUpdateOne {
+	_u.mutation.ResetPrice()
+	_u.mutation.SetPrice(v)
+	return _u
+}
+
+// SetNillablePrice sets the "price" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillablePrice(v *float64) *GroupUpdateOne {
+	if v != nil {
+		_u.SetPrice(*v)
+	}
+	return _u
+}
+
+// AddPrice adds value to the "price" field.
+func (_u *GroupUpdateOne) AddPrice(v float64) *GroupUpdateOne {
+	_u.mutation.AddPrice(v)
+	return _u
+}
+
+// ClearPrice clears the value of the "price" field.
+func (_u *GroupUpdateOne) ClearPrice() *GroupUpdateOne {
+	_u.mutation.ClearPrice()
+	return _u
+}
+
+// SetRenewalPeriodDays sets the "renewal_period_days" field.
+func (_u *GroupUpdateOne) SetRenewalPeriodDays(v int) *GroupUpdateOne {
+	_u.mutation.ResetRenewalPeriodDays()
+	_u.mutation.SetRenewalPeriodDays(v)
+	return _u
+}
+
+// SetNillableRenewalPeriodDays sets the "renewal_period_days" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableRenewalPeriodDays(v *int) *GroupUpdateOne {
+	if v != nil {
+		_u.SetRenewalPeriodDays(*v)
+	}
+	return _u
+}
+
+// AddRenewalPeriodDays adds value to the "renewal_period_days" field.
+func (_u *GroupUpdateOne) AddRenewalPeriodDays(v int) *GroupUpdateOne {
+	_u.mutation.AddRenewalPeriodDays(v)
+	return _u
+}
+
+// SetQueueWeight sets the "queue_weight" field.
+func (_u *GroupUpdateOne) SetQueueWeight(v int) *GroupUpdateOne {
+	_u.mutation.ResetQueueWeight()
+	_u.mutation.SetQueueWeight(v)
+	return _u
+}
+
+// SetNillableQueueWeight sets the "queue_weight" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableQueueWeight(v *int) *GroupUpdateOne {
+	if v != nil {
+		_u.SetQueueWeight(*v)
+	}
+	return _u
+}
+
+// AddQueueWeight adds value to the "queue_weight" field.
+func (_u *GroupUpdateOne) AddQueueWeight(v int) *GroupUpdateOne {
+	_u.mutation.AddQueueWeight(v)
+	return _u
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (_u *GroupUpdateOne) SetQueuePriority(v int) *GroupUpdateOne {
+	_u.mutation.ResetQueuePriority()
+	_u.mutation.SetQueuePriority(v)
+	return _u
+}
+
+// SetNillableQueuePriority sets the "queue_priority" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableQueuePriority(v *int) *GroupUpdateOne {
+	if v != nil {
+		_u.SetQueuePriority(*v)
+	}
+	return _u
+}
+
+// AddQueuePriority adds value to the "queue_priority" field.
+func (_u *GroupUpdateOne) AddQueuePriority(v int) *GroupUpdateOne {
+	_u.mutation.AddQueuePriority(v)
+	return _u
+}
+
+// SetSchedulingStrategy sets the "scheduling_strategy" field.
+func (_u *GroupUpdateOne) SetSchedulingStrategy(v string) *GroupUpdateOne {
+	_u.mutation.SetSchedulingStrategy(v)
+	return _u
+}
+
+// SetNillableSchedulingStrategy sets the "scheduling_strategy" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableSchedulingStrategy(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetSchedulingStrategy(*v)
+	}
+	return _u
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (_u *GroupUpdateOne) SetHedgeEnabled(v bool) *GroupUpdateOne {
+	_u.mutation.SetHedgeEnabled(v)
+	return _u
+}
+
+// SetNillableHedgeEnabled sets the "hedge_enabled" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableHedgeEnabled(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetHedgeEnabled(*v)
+	}
+	return _u
+}
+
+// SetHedgePercentile sets the "hedge_percentile" field.
+func (_u *GroupUpdateOne) SetHedgePercentile(v int) *GroupUpdateOne {
+	_u.mutation.ResetHedgePercentile()
+	_u.mutation.SetHedgePercentile(v)
+	return _u
+}
+
+// SetNillableHedgePercentile sets the "hedge_percentile" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableHedgePercentile(v *int) *GroupUpdateOne {
+	if v != nil {
+		_u.SetHedgePercentile(*v)
+	}
+	return _u
+}
+
+// AddHedgePercentile adds value to the "hedge_percentile" field.
+func (_u *GroupUpdateOne) AddHedgePercentile(v int) *GroupUpdateOne {
+	_u.mutation.AddHedgePercentile(v)
+	return _u
+}
+
+// SetHedgeLoserBilling sets the "hedge_loser_billing" field.
+func (_u *GroupUpdateOne) SetHedgeLoserBilling(v string) *GroupUpdateOne {
+	_u.mutation.SetHedgeLoserBilling(v)
+	return _u
+}
+
+// SetNillableHedgeLoserBilling sets the "hedge_loser_billing" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableHedgeLoserBilling(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetHedgeLoserBilling(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdateOne) AddAPIKeyIDs(ids ...int64) *GroupUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -2384,6 +2820,16 @@ func (_u *GroupUpdateOne) check() error {
 			return &ValidationError{Name: "default_mapped_model", err: fmt.Errorf(`ent: validator failed for field "Group.default_mapped_model": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.SchedulingStrategy(); ok {
+		if err := group.SchedulingStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "scheduling_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.scheduling_strategy": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.HedgeLoserBilling(); ok {
+		if err := group.HedgeLoserBillingValidator(v); err != nil {
+			return &ValidationError{Name: "hedge_loser_billing", err: fmt.Errorf(`ent: validator failed for field "Group.hedge_loser_billing": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -2584,6 +3030,23 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.ModelFallbacks(); ok {
+		_spec.SetField(group.FieldModelFallbacks, field.TypeJSON, value)
+	}
+	if _u.mutation.ModelFallbacksCleared() {
+		_spec.ClearField(group.FieldModelFallbacks, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.RequestTransforms(); ok {
+		_spec.SetField(group.FieldRequestTransforms, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedRequestTransforms(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldRequestTransforms, value)
+		})
+	}
+	if _u.mutation.RequestTransformsCleared() {
+		_spec.ClearField(group.FieldRequestTransforms, field.TypeJSON)
+	}
 	if value, ok := _u.mutation.McpXMLInject(); ok {
 		_spec.SetField(group.FieldMcpXMLInject, field.TypeBool, value)
 	}
@@ -2607,6 +3070,48 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.DefaultMappedModel(); ok {
 		_spec.SetField(group.FieldDefaultMappedModel, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.Price(); ok {
+		_spec.SetField(group.FieldPrice, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedPrice(); ok {
+		_spec.AddField(group.FieldPrice, field.TypeFloat64, value)
+	}
+	if _u.mutation.PriceCleared() {
+		_spec.ClearField(group.FieldPrice, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.RenewalPeriodDays(); ok {
+		_spec.SetField(group.FieldRenewalPeriodDays, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedRenewalPeriodDays(); ok {
+		_spec.AddField(group.FieldRenewalPeriodDays, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.QueueWeight(); ok {
+		_spec.SetField(group.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueueWeight(); ok {
+		_spec.AddField(group.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.QueuePriority(); ok {
+		_spec.SetField(group.FieldQueuePriority, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueuePriority(); ok {
+		_spec.AddField(group.FieldQueuePriority, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.SchedulingStrategy(); ok {
+		_spec.SetField(group.FieldSchedulingStrategy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.HedgeEnabled(); ok {
+		_spec.SetField(group.FieldHedgeEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.HedgePercentile(); ok {
+		_spec.SetField(group.FieldHedgePercentile, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedHedgePercentile(); ok {
+		_spec.AddField(group.FieldHedgePercentile, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.HedgeLoserBilling(); ok {
+		_spec.SetField(group.FieldHedgeLoserBilling, field.TypeString, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -106,6 +106,7 @@ type APIKeyMutation struct {
 	window_5h_start    *time.Time
 	window_1d_start    *time.Time
 	window_7d_start    *time.Time
+	priority           *string
 	clearedFields      map[string]struct{}
 	user               *int64
 	cleareduser        bool
@@ -1354,6 +1355,42 @@ func (m *APIKeyMutation) ResetWindow7dStart() {
 	delete(m.clearedFields, apikey.FieldWindow7dStart)
 }
 
+// SetPriority sets the "priority" field.
+func (m *APIKeyMutation) SetPriority(s string) {
+	m.priority = &s
+}
+
+// Priority returns the value of the "priority" field in the mutation.
+func (m *APIKeyMutation) Priority() (r string, exists bool) {
+	v := m.priority
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPriority returns the old "priority" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldPriority(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPriority is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPriority requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPriority: %w", err)
+	}
+	return oldValue.Priority, nil
+}
+
+// ResetPriority resets all changes to the "priority" field.
+func (m *APIKeyMutation) ResetPriority() {
+	m.priority = nil
+}
+
 // ClearUser clears the "user" edge to the User entity.
 func (m *APIKeyMutation) ClearUser() {
 	m.cleareduser = true
@@ -1496,7 +1533,7 @@ func (m *APIKeyMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *APIKeyMutation) Fields() []string {
-	fields := make([]string, 0, 23)
+	fields := make([]string, 0, 24)
 	if m.created_at != nil {
 		fields = append(fields, apikey.FieldCreatedAt)
 	}
@@ -1566,6 +1603,9 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.window_7d_start != nil {
 		fields = append(fields, apikey.FieldWindow7dStart)
 	}
+	if m.priority != nil {
+		fields = append(fields, apikey.FieldPriority)
+	}
 	return fields
 }
 
@@ -1620,6 +1660,8 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.Window1dStart()
 	case apikey.FieldWindow7dStart:
 		return m.Window7dStart()
+	case apikey.FieldPriority:
+		return m.Priority()
 	}
 	return nil, false
 }
@@ -1675,6 +1717,8 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldWindow1dStart(ctx)
 	case apikey.FieldWindow7dStart:
 		return m.OldWindow7dStart(ctx)
+	case apikey.FieldPriority:
+		return m.OldPriority(ctx)
 	}
 	return nil, fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -1845,6 +1889,13 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetWindow7dStart(v)
 		return nil
+	case apikey.FieldPriority:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPriority(v)
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -2119,6 +2170,9 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldWindow7dStart:
 		m.ResetWindow7dStart()
 		return nil
+	case apikey.FieldPriority:
+		m.ResetPriority()
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -8245,6 +8299,9 @@ type GroupMutation struct {
 	addfallback_group_id_on_invalid_request *int64
 	model_routing                           *map[string][]int64
 	model_routing_enabled                   *bool
+	model_fallbacks                         *map[string][]domain.ModelFallbackTarget
+	request_transforms                      *[]domain.RequestTransformRule
+	appendrequest_transforms                []domain.RequestTransformRule
 	mcp_xml_inject                          *bool
 	supported_model_scopes                  *[]string
 	appendsupported_model_scopes            []string
@@ -8252,6 +8309,19 @@ type GroupMutation struct {
 	addsort_order                           *int
 	allow_messages_dispatch                 *bool
 	default_mapped_model                    *string
+	price                                   *float64
+	addprice                                *float64
+	renewal_period_days                     *int
+	addrenewal_period_days                  *int
+	queue_weight                            *int
+	addqueue_weight                         *int
+	queue_priority                          *int
+	addqueue_priority                       *int
+	scheduling_strategy                     *string
+	hedge_enabled                           *bool
+	hedge_percentile                        *int
+	addhedge_percentile                     *int
+	hedge_loser_billing                     *string
 	clearedFields                           map[string]struct{}
 	api_keys                                map[int64]struct{}
 	removedapi_keys                         map[int64]struct{}
@@ -9853,6 +9923,120 @@ func (m *GroupMutation) ResetModelRoutingEnabled() {
 	m.model_routing_enabled = nil
 }
 
+// SetModelFallbacks sets the "model_fallbacks" field.
+func (m *GroupMutation) SetModelFallbacks(mft map[string][]domain.ModelFallbackTarget) {
+	m.model_fallbacks = &mft
+}
+
+// ModelFallbacks returns the value of the "model_fallbacks" field in the mutation.
+func (m *GroupMutation) ModelFallbacks() (r map[string][]domain.ModelFallbackTarget, exists bool) {
+	v := m.model_fallbacks
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelFallbacks returns the old "model_fallbacks" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelFallbacks(ctx context.Context) (v map[string][]domain.ModelFallbackTarget, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelFallbacks is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelFallbacks requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelFallbacks: %w", err)
+	}
+	return oldValue.ModelFallbacks, nil
+}
+
+// ClearModelFallbacks clears the value of the "model_fallbacks" field.
+func (m *GroupMutation) ClearModelFallbacks() {
+	m.model_fallbacks = nil
+	m.clearedFields[group.FieldModelFallbacks] = struct{}{}
+}
+
+// ModelFallbacksCleared returns if the "model_fallbacks" field was cleared in this mutation.
+func (m *GroupMutation) ModelFallbacksCleared() bool {
+	_, ok := m.clearedFields[group.FieldModelFallbacks]
+	return ok
+}
+
+// ResetModelFallbacks resets all changes to the "model_fallbacks" field.
+func (m *GroupMutation) ResetModelFallbacks() {
+	m.model_fallbacks = nil
+	delete(m.clearedFields, group.FieldModelFallbacks)
+}
+
+// SetRequestTransforms sets the "request_transforms" field.
+func (m *GroupMutation) SetRequestTransforms(dtr []domain.RequestTransformRule) {
+	m.request_transforms = &dtr
+	m.appendrequest_transforms = nil
+}
+
+// RequestTransforms returns the value of the "request_transforms" field in the mutation.
+func (m *GroupMutation) RequestTransforms() (r []domain.RequestTransformRule, exists bool) {
+	v := m.request_transforms
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRequestTransforms returns the old "request_transforms" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldRequestTransforms(ctx context.Context) (v []domain.RequestTransformRule, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRequestTransforms is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRequestTransforms requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRequestTransforms: %w", err)
+	}
+	return oldValue.RequestTransforms, nil
+}
+
+// AppendRequestTransforms adds dtr to the "request_transforms" field.
+func (m *GroupMutation) AppendRequestTransforms(dtr []domain.RequestTransformRule) {
+	m.appendrequest_transforms = append(m.appendrequest_transforms, dtr...)
+}
+
+// AppendedRequestTransforms returns the list of values that were appended to the "request_transforms" field in this mutation.
+func (m *GroupMutation) AppendedRequestTransforms() ([]domain.RequestTransformRule, bool) {
+	if len(m.appendrequest_transforms) == 0 {
+		return nil, false
+	}
+	return m.appendrequest_transforms, true
+}
+
+// ClearRequestTransforms clears the value of the "request_transforms" field.
+func (m *GroupMutation) ClearRequestTransforms() {
+	m.request_transforms = nil
+	m.appendrequest_transforms = nil
+	m.clearedFields[group.FieldRequestTransforms] = struct{}{}
+}
+
+// RequestTransformsCleared returns if the "request_transforms" field was cleared in this mutation.
+func (m *GroupMutation) RequestTransformsCleared() bool {
+	_, ok := m.clearedFields[group.FieldRequestTransforms]
+	return ok
+}
+
+// ResetRequestTransforms resets all changes to the "request_transforms" field.
+func (m *GroupMutation) ResetRequestTransforms() {
+	m.request_transforms = nil
+	m.appendrequest_transforms = nil
+	delete(m.clearedFields, group.FieldRequestTransforms)
+}
+
 // SetMcpXMLInject sets the "mcp_xml_inject" field.
 func (m *GroupMutation) SetMcpXMLInject(b bool) {
 	m.mcp_xml_inject = &b
@@ -10010,62 +10194,464 @@ func (m *GroupMutation) AllowMessagesDispatch() (r bool, exists bool) {
 	return *v, true
 }
 
-// OldAllowMessagesDispatch returns the old "allow_messages_dispatch" field's value of the Group entity.
+// OldAllowMessagesDispatch returns the old "allow_messages_dispatch" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldAllowMessagesDispatch(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowMessagesDispatch is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowMessagesDispatch requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowMessagesDispatch: %w", err)
+	}
+	return oldValue.AllowMessagesDispatch, nil
+}
+
+// ResetAllowMessagesDispatch resets all changes to the "allow_messages_dispatch" field.
+func (m *GroupMutation) ResetAllowMessagesDispatch() {
+	m.allow_messages_dispatch = nil
+}
+
+// SetDefaultMappedModel sets the "default_mapped_model" field.
+func (m *GroupMutation) SetDefaultMappedModel(s string) {
+	m.default_mapped_model = &s
+}
+
+// DefaultMappedModel returns the value of the "default_mapped_model" field in the mutation.
+func (m *GroupMutation) DefaultMappedModel() (r string, exists bool) {
+	v := m.default_mapped_model
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDefaultMappedModel returns the old "default_mapped_model" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldDefaultMappedModel(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDefaultMappedModel is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDefaultMappedModel requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDefaultMappedModel: %w", err)
+	}
+	return oldValue.DefaultMappedModel, nil
+}
+
+// ResetDefaultMappedModel resets all changes to the "default_mapped_model" field.
+func (m *GroupMutation) ResetDefaultMappedModel() {
+	m.default_mapped_model = nil
+}
+
+// SetPrice sets the "price" field.
+func (m *GroupMutation) SetPrice(f float64) {
+	m.price = &f
+	m.addprice = nil
+}
+
+// Price returns the value of the "price" field in the mutation.
+func (m *GroupMutation) Price() (r float64, exists bool) {
+	v := m.price
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPrice returns the old "price" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldPrice(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPrice is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPrice requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPrice: %w", err)
+	}
+	return oldValue.Price, nil
+}
+
+// AddPrice adds f to the "price" field.
+func (m *GroupMutation) AddPrice(f float64) {
+	if m.addprice != nil {
+		*m.addprice += f
+	} else {
+		m.addprice = &f
+	}
+}
+
+// AddedPrice returns the value that was added to the "price" field in this mutation.
+func (m *GroupMutation) AddedPrice() (r float64, exists bool) {
+	v := m.addprice
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearPrice clears the value of the "price" field.
+func (m *GroupMutation) ClearPrice() {
+	m.price = nil
+	m.addprice = nil
+	m.clearedFields[group.FieldPrice] = struct{}{}
+}
+
+// PriceCleared returns if the "price" field was cleared in this mutation.
+func (m *GroupMutation) PriceCleared() bool {
+	_, ok := m.clearedFields[group.FieldPrice]
+	return ok
+}
+
+// ResetPrice resets all changes to the "price" field.
+func (m *GroupMutation) ResetPrice() {
+	m.price = nil
+	m.addprice = nil
+	delete(m.clearedFields, group.FieldPrice)
+}
+
+// SetRenewalPeriodDays sets the "renewal_period_days" field.
+func (m *GroupMutation) SetRenewalPeriodDays(i int) {
+	m.renewal_period_days = &i
+	m.addrenewal_period_days = nil
+}
+
+// RenewalPeriodDays returns the value of the "renewal_period_days" field in the mutation.
+func (m *GroupMutation) RenewalPeriodDays() (r int, exists bool) {
+	v := m.renewal_period_days
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRenewalPeriodDays returns the old "renewal_period_days" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldRenewalPeriodDays(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRenewalPeriodDays is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRenewalPeriodDays requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRenewalPeriodDays: %w", err)
+	}
+	return oldValue.RenewalPeriodDays, nil
+}
+
+// AddRenewalPeriodDays adds i to the "renewal_period_days" field.
+func (m *GroupMutation) AddRenewalPeriodDays(i int) {
+	if m.addrenewal_period_days != nil {
+		*m.addrenewal_period_days += i
+	} else {
+		m.addrenewal_period_days = &i
+	}
+}
+
+// AddedRenewalPeriodDays returns the value that was added to the "renewal_period_days" field in this mutation.
+func (m *GroupMutation) AddedRenewalPeriodDays() (r int, exists bool) {
+	v := m.addrenewal_period_days
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetRenewalPeriodDays resets all changes to the "renewal_period_days" field.
+func (m *GroupMutation) ResetRenewalPeriodDays() {
+	m.renewal_period_days = nil
+	m.addrenewal_period_days = nil
+}
+
+// SetQueueWeight sets the "queue_weight" field.
+func (m *GroupMutation) SetQueueWeight(i int) {
+	m.queue_weight = &i
+	m.addqueue_weight = nil
+}
+
+// QueueWeight returns the value of the "queue_weight" field in the mutation.
+func (m *GroupMutation) QueueWeight() (r int, exists bool) {
+	v := m.queue_weight
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldQueueWeight returns the old "queue_weight" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldQueueWeight(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldQueueWeight is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldQueueWeight requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldQueueWeight: %w", err)
+	}
+	return oldValue.QueueWeight, nil
+}
+
+// AddQueueWeight adds i to the "queue_weight" field.
+func (m *GroupMutation) AddQueueWeight(i int) {
+	if m.addqueue_weight != nil {
+		*m.addqueue_weight += i
+	} else {
+		m.addqueue_weight = &i
+	}
+}
+
+// AddedQueueWeight returns the value that was added to the "queue_weight" field in this mutation.
+func (m *GroupMutation) AddedQueueWeight() (r int, exists bool) {
+	v := m.addqueue_weight
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetQueueWeight resets all changes to the "queue_weight" field.
+func (m *GroupMutation) ResetQueueWeight() {
+	m.queue_weight = nil
+	m.addqueue_weight = nil
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (m *GroupMutation) SetQueuePriority(i int) {
+	m.queue_priority = &i
+	m.addqueue_priority = nil
+}
+
+// QueuePriority returns the value of the "queue_priority" field in the mutation.
+func (m *GroupMutation) QueuePriority() (r int, exists bool) {
+	v := m.queue_priority
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldQueuePriority returns the old "queue_priority" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldQueuePriority(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldQueuePriority is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldQueuePriority requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldQueuePriority: %w", err)
+	}
+	return oldValue.QueuePriority, nil
+}
+
+// AddQueuePriority adds i to the "queue_priority" field.
+func (m *GroupMutation) AddQueuePriority(i int) {
+	if m.addqueue_priority != nil {
+		*m.addqueue_priority += i
+	} else {
+		m.addqueue_priority = &i
+	}
+}
+
+// AddedQueuePriority returns the value that was added to the "queue_priority" field in this mutation.
+func (m *GroupMutation) AddedQueuePriority() (r int, exists bool) {
+	v := m.addqueue_priority
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetQueuePriority resets all changes to the "queue_priority" field.
+func (m *GroupMutation) ResetQueuePriority() {
+	m.queue_priority = nil
+	m.addqueue_priority = nil
+}
+
+// SetSchedulingStrategy sets the "scheduling_strategy" field.
+func (m *GroupMutation) SetSchedulingStrategy(s string) {
+	m.scheduling_strategy = &s
+}
+
+// SchedulingStrategy returns the value of the "scheduling_strategy" field in the mutation.
+func (m *GroupMutation) SchedulingStrategy() (r string, exists bool) {
+	v := m.scheduling_strategy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldSchedulingStrategy returns the old "scheduling_strategy" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldSchedulingStrategy(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldSchedulingStrategy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldSchedulingStrategy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldSchedulingStrategy: %w", err)
+	}
+	return oldValue.SchedulingStrategy, nil
+}
+
+// ResetSchedulingStrategy resets all changes to the "scheduling_strategy" field.
+func (m *GroupMutation) ResetSchedulingStrategy() {
+	m.scheduling_strategy = nil
+}
+
+// SetHedgeEnabled sets the "hedge_enabled" field.
+func (m *GroupMutation) SetHedgeEnabled(b bool) {
+	m.hedge_enabled = &b
+}
+
+// HedgeEnabled returns the value of the "hedge_enabled" field in the mutation.
+func (m *GroupMutation) HedgeEnabled() (r bool, exists bool) {
+	v := m.hedge_enabled
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldHedgeEnabled returns the old "hedge_enabled" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldHedgeEnabled(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldHedgeEnabled is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldHedgeEnabled requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldHedgeEnabled: %w", err)
+	}
+	return oldValue.HedgeEnabled, nil
+}
+
+// ResetHedgeEnabled resets all changes to the "hedge_enabled" field.
+func (m *GroupMutation) ResetHedgeEnabled() {
+	m.hedge_enabled = nil
+}
+
+// SetHedgePercentile sets the "hedge_percentile" field.
+func (m *GroupMutation) SetHedgePercentile(i int) {
+	m.hedge_percentile = &i
+	m.addhedge_percentile = nil
+}
+
+// HedgePercentile returns the value of the "hedge_percentile" field in the mutation.
+func (m *GroupMutation) HedgePercentile() (r int, exists bool) {
+	v := m.hedge_percentile
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldHedgePercentile returns the old "hedge_percentile" field's value of the Group entity.
 // If the Group object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *GroupMutation) OldAllowMessagesDispatch(ctx context.Context) (v bool, err error) {
+func (m *GroupMutation) OldHedgePercentile(ctx context.Context) (v int, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldAllowMessagesDispatch is only allowed on UpdateOne operations")
+		return v, errors.New("OldHedgePercentile is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldAllowMessagesDispatch requires an ID field in the mutation")
+		return v, errors.New("OldHedgePercentile requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldAllowMessagesDispatch: %w", err)
+		return v, fmt.Errorf("querying old value for OldHedgePercentile: %w", err)
 	}
-	return oldValue.AllowMessagesDispatch, nil
+	return oldValue.HedgePercentile, nil
 }
 
-// ResetAllowMessagesDispatch resets all changes to the "allow_messages_dispatch" field.
-func (m *GroupMutation) ResetAllowMessagesDispatch() {
-	m.allow_messages_dispatch = nil
+// AddHedgePercentile adds i to the "hedge_percentile" field.
+func (m *GroupMutation) AddHedgePercentile(i int) {
+	if m.addhedge_percentile != nil {
+		*m.addhedge_percentile += i
+	} else {
+		m.addhedge_percentile = &i
+	}
 }
 
-// SetDefaultMappedModel sets the "default_mapped_model" field.
-func (m *GroupMutation) SetDefaultMappedModel(s string) {
-	m.default_mapped_model = &s
+// AddedHedgePercentile returns the value that was added to the "hedge_percentile" field in this mutation.
+func (m *GroupMutation) AddedHedgePercentile() (r int, exists bool) {
+	v := m.addhedge_percentile
+	if v == nil {
+		return
+	}
+	return *v, true
 }
 
-// DefaultMappedModel returns the value of the "default_mapped_model" field in the mutation.
-func (m *GroupMutation) DefaultMappedModel() (r string, exists bool) {
-	v := m.default_mapped_model
+// ResetHedgePercentile resets all changes to the "hedge_percentile" field.
+func (m *GroupMutation) ResetHedgePercentile() {
+	m.hedge_percentile = nil
+	m.addhedge_percentile = nil
+}
+
+// SetHedgeLoserBilling sets the "hedge_loser_billing" field.
+func (m *GroupMutation) SetHedgeLoserBilling(s string) {
+	m.hedge_loser_billing = &s
+}
+
+// HedgeLoserBilling returns the value of the "hedge_loser_billing" field in the mutation.
+func (m *GroupMutation) HedgeLoserBilling() (r string, exists bool) {
+	v := m.hedge_loser_billing
 	if v == nil {
 		return
 	}
 	return *v, true
 }
 
-// OldDefaultMappedModel returns the old "default_mapped_model" field's value of the Group entity.
+// OldHedgeLoserBilling returns the old "hedge_loser_billing" field's value of the Group entity.
 // If the Group object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *GroupMutation) OldDefaultMappedModel(ctx context.Context) (v string, err error) {
+func (m *GroupMutation) OldHedgeLoserBilling(ctx context.Context) (v string, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldDefaultMappedModel is only allowed on UpdateOne operations")
+		return v, errors.New("OldHedgeLoserBilling is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldDefaultMappedModel requires an ID field in the mutation")
+		return v, errors.New("OldHedgeLoserBilling requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldDefaultMappedModel: %w", err)
+		return v, fmt.Errorf("querying old value for OldHedgeLoserBilling: %w", err)
 	}
-	return oldValue.DefaultMappedModel, nil
+	return oldValue.HedgeLoserBilling, nil
 }
 
-// ResetDefaultMappedModel resets all changes to the "default_mapped_model" field.
-func (m *GroupMutation) ResetDefaultMappedModel() {
-	m.default_mapped_model = nil
+// ResetHedgeLoserBilling resets all changes to the "hedge_loser_billing" field.
+func (m *GroupMutation) ResetHedgeLoserBilling() {
+	m.hedge_loser_billing = nil
 }
 
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
@@ -10426,7 +11012,7 @@ func (m *GroupMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *GroupMutation) Fields() []string {
-	fields := make([]string, 0, 32)
+	fields := make([]string, 0, 42)
 	if m.created_at != nil {
 		fields = append(fields, group.FieldCreatedAt)
 	}
@@ -10508,6 +11094,12 @@ func (m *GroupMutation) Fields() []string {
 	if m.model_routing_enabled != nil {
 		fields = append(fields, group.FieldModelRoutingEnabled)
 	}
+	if m.model_fallbacks != nil {
+		fields = append(fields, group.FieldModelFallbacks)
+	}
+	if m.request_transforms != nil {
+		fields = append(fields, group.FieldRequestTransforms)
+	}
 	if m.mcp_xml_inject != nil {
 		fields = append(fields, group.FieldMcpXMLInject)
 	}
@@ -10523,6 +11115,30 @@ func (m *GroupMutation) Fields() []string {
 	if m.default_mapped_model != nil {
 		fields = append(fields, group.FieldDefaultMappedModel)
 	}
+	if m.price != nil {
+		fields = append(fields, group.FieldPrice)
+	}
+	if m.renewal_period_days != nil {
+		fields = append(fields, group.FieldRenewalPeriodDays)
+	}
+	if m.queue_weight != nil {
+		fields = append(fields, group.FieldQueueWeight)
+	}
+	if m.queue_priority != nil {
+		fields = append(fields, group.FieldQueuePriority)
+	}
+	if m.scheduling_strategy != nil {
+		fields = append(fields, group.FieldSchedulingStrategy)
+	}
+	if m.hedge_enabled != nil {
+		fields = append(fields, group.FieldHedgeEnabled)
+	}
+	if m.hedge_percentile != nil {
+		fields = append(fields, group.FieldHedgePercentile)
+	}
+	if m.hedge_loser_billing != nil {
+		fields = append(fields, group.FieldHedgeLoserBilling)
+	}
 	return fields
 }
 
@@ -10585,6 +11201,10 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.ModelRouting()
 	case group.FieldModelRoutingEnabled:
 		return m.ModelRoutingEnabled()
+	case group.FieldModelFallbacks:
+		return m.ModelFallbacks()
+	case group.FieldRequestTransforms:
+		return m.RequestTransforms()
 	case group.FieldMcpXMLInject:
 		return m.McpXMLInject()
 	case group.FieldSupportedModelScopes:
@@ -10595,6 +11215,22 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.AllowMessagesDispatch()
 	case group.FieldDefaultMappedModel:
 		return m.DefaultMappedModel()
+	case group.FieldPrice:
+		return m.Price()
+	case group.FieldRenewalPeriodDays:
+		return m.RenewalPeriodDays()
+	case group.FieldQueueWeight:
+		return m.QueueWeight()
+	case group.FieldQueuePriority:
+		return m.QueuePriority()
+	case group.FieldSchedulingStrategy:
+		return m.SchedulingStrategy()
+	case group.FieldHedgeEnabled:
+		return m.HedgeEnabled()
+	case group.FieldHedgePercentile:
+		return m.HedgePercentile()
+	case group.FieldHedgeLoserBilling:
+		return m.HedgeLoserBilling()
 	}
 	return nil, false
 }
@@ -10658,6 +11294,10 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldModelRouting(ctx)
 	case group.FieldModelRoutingEnabled:
 		return m.OldModelRoutingEnabled(ctx)
+	case group.FieldModelFallbacks:
+		return m.OldModelFallbacks(ctx)
+	case group.FieldRequestTransforms:
+		return m.OldRequestTransforms(ctx)
 	case group.FieldMcpXMLInject:
 		return m.OldMcpXMLInject(ctx)
 	case group.FieldSupportedModelScopes:
@@ -10668,6 +11308,22 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldAllowMessagesDispatch(ctx)
 	case group.FieldDefaultMappedModel:
 		return m.OldDefaultMappedModel(ctx)
+	case group.FieldPrice:
+		return m.OldPrice(ctx)
+	case group.FieldRenewalPeriodDays:
+		return m.OldRenewalPeriodDays(ctx)
+	case group.FieldQueueWeight:
+		return m.OldQueueWeight(ctx)
+	case group.FieldQueuePriority:
+		return m.OldQueuePriority(ctx)
+	case group.FieldSchedulingStrategy:
+		return m.OldSchedulingStrategy(ctx)
+	case group.FieldHedgeEnabled:
+		return m.OldHedgeEnabled(ctx)
+	case group.FieldHedgePercentile:
+		return m.OldHedgePercentile(ctx)
+	case group.FieldHedgeLoserBilling:
+		return m.OldHedgeLoserBilling(ctx)
 	}
 	return nil, fmt.Errorf("unknown Group field %s", name)
 }
@@ -10866,6 +11522,20 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetModelRoutingEnabled(v)
 		return nil
+	case group.FieldModelFallbacks:
+		v, ok := value.(map[string][]domain.ModelFallbackTarget)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelFallbacks(v)
+		return nil
+	case group.FieldRequestTransforms:
+		v, ok := value.([]domain.RequestTransformRule)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRequestTransforms(v)
+		return nil
 	case group.FieldMcpXMLInject:
 		v, ok := value.(bool)
 		if !ok {
@@ -10901,6 +11571,62 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetDefaultMappedModel(v)
 		return nil
+	case group.FieldPrice:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPrice(v)
+		return nil
+	case group.FieldRenewalPeriodDays:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRenewalPeriodDays(v)
+		return nil
+	case group.FieldQueueWeight:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetQueueWeight(v)
+		return nil
+	case group.FieldQueuePriority:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetQueuePriority(v)
+		return nil
+	case group.FieldSchedulingStrategy:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetSchedulingStrategy(v)
+		return nil
+	case group.FieldHedgeEnabled:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetHedgeEnabled(v)
+		return nil
+	case group.FieldHedgePercentile:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetHedgePercentile(v)
+		return nil
+	case group.FieldHedgeLoserBilling:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetHedgeLoserBilling(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -10957,6 +11683,21 @@ func (m *GroupMutation) AddedFields() []string {
 	if m.addsort_order != nil {
 		fields = append(fields, group.FieldSortOrder)
 	}
+	if m.addprice != nil {
+		fields = append(fields, group.FieldPrice)
+	}
+	if m.addrenewal_period_days != nil {
+		fields = append(fields, group.FieldRenewalPeriodDays)
+	}
+	if m.addqueue_weight != nil {
+		fields = append(fields, group.FieldQueueWeight)
+	}
+	if m.addqueue_priority != nil {
+		fields = append(fields, group.FieldQueuePriority)
+	}
+	if m.addhedge_percentile != nil {
+		fields = append(fields, group.FieldHedgePercentile)
+	}
 	return fields
 }
 
@@ -10997,6 +11738,16 @@ func (m *GroupMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedFallbackGroupIDOnInvalidRequest()
 	case group.FieldSortOrder:
 		return m.AddedSortOrder()
+	case group.FieldPrice:
+		return m.AddedPrice()
+	case group.FieldRenewalPeriodDays:
+		return m.AddedRenewalPeriodDays()
+	case group.FieldQueueWeight:
+		return m.AddedQueueWeight()
+	case group.FieldQueuePriority:
+		return m.AddedQueuePriority()
+	case group.FieldHedgePercentile:
+		return m.AddedHedgePercentile()
 	}
 	return nil, false
 }
@@ -11118,6 +11869,41 @@ func (m *GroupMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddSortOrder(v)
 		return nil
+	case group.FieldPrice:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddPrice(v)
+		return nil
+	case group.FieldRenewalPeriodDays:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddRenewalPeriodDays(v)
+		return nil
+	case group.FieldQueueWeight:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddQueueWeight(v)
+		return nil
+	case group.FieldQueuePriority:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddQueuePriority(v)
+		return nil
+	case group.FieldHedgePercentile:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddHedgePercentile(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group numeric field %s", name)
 }
@@ -11171,6 +11957,15 @@ func (m *GroupMutation) ClearedFields() []string {
 	if m.FieldCleared(group.FieldModelRouting) {
 		fields = append(fields, group.FieldModelRouting)
 	}
+	if m.FieldCleared(group.FieldModelFallbacks) {
+		fields = append(fields, group.FieldModelFallbacks)
+	}
+	if m.FieldCleared(group.FieldRequestTransforms) {
+		fields = append(fields, group.FieldRequestTransforms)
+	}
+	if m.FieldCleared(group.FieldPrice) {
+		fields = append(fields, group.FieldPrice)
+	}
 	return fields
 }
 
@@ -11230,6 +12025,15 @@ func (m *GroupMutation) ClearField(name string) error {
 	case group.FieldModelRouting:
 		m.ClearModelRouting()
 		return nil
+	case group.FieldModelFallbacks:
+		m.ClearModelFallbacks()
+		return nil
+	case group.FieldRequestTransforms:
+		m.ClearRequestTransforms()
+		return nil
+	case group.FieldPrice:
+		m.ClearPrice()
+		return nil
 	}
 	return fmt.Errorf("unknown Group nullable field %s", name)
 }
@@ -11319,6 +12123,12 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldModelRoutingEnabled:
 		m.ResetModelRoutingEnabled()
 		return nil
+	case group.FieldModelFallbacks:
+		m.ResetModelFallbacks()
+		return nil
+	case group.FieldRequestTransforms:
+		m.ResetRequestTransforms()
+		return nil
 	case group.FieldMcpXMLInject:
 		m.ResetMcpXMLInject()
 		return nil
@@ -11334,6 +12144,30 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldDefaultMappedModel:
 		m.ResetDefaultMappedModel()
 		return nil
+	case group.FieldPrice:
+		m.ResetPrice()
+		return nil
+	case group.FieldRenewalPeriodDays:
+		m.ResetRenewalPeriodDays()
+		return nil
+	case group.FieldQueueWeight:
+		m.ResetQueueWeight()
+		return nil
+	case group.FieldQueuePriority:
+		m.ResetQueuePriority()
+		return nil
+	case group.FieldSchedulingStrategy:
+		m.ResetSchedulingStrategy()
+		return nil
+	case group.FieldHedgeEnabled:
+		m.ResetHedgeEnabled()
+		return nil
+	case group.FieldHedgePercentile:
+		m.ResetHedgePercentile()
+		return nil
+	case group.FieldHedgeLoserBilling:
+		m.ResetHedgeLoserBilling()
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -17413,7 +18247,7 @@ func (m *UsageCleanupTaskMutation) OldFilters(ctx context.Context) (v json.RawMe
 	return oldValue.Filters, nil
 }
 
-// AppendFilters adds jm to the "filters" field.
+// AppendFilters adds j to the "filters" field.
 func (m *UsageCleanupTaskMutation) AppendFilters(jm json.RawMessage) {
 	m.appendfilters = append(m.appendfilters, jm...)
 }
@@ -21269,6 +22103,10 @@ type UserMutation struct {
 	addsora_storage_quota_bytes   *int64
 	sora_storage_used_bytes       *int64
 	addsora_storage_used_bytes    *int64
+	queue_weight                  *int
+	addqueue_weight               *int
+	queue_priority                *int
+	addqueue_priority             *int
 	clearedFields                 map[string]struct{}
 	api_keys                      map[int64]struct{}
 	removedapi_keys               map[int64]struct{}
@@ -22095,6 +22933,118 @@ func (m *UserMutation) ResetSoraStorageUsedBytes() {
 	m.addsora_storage_used_bytes = nil
 }
 
+// SetQueueWeight sets the "queue_weight" field.
+func (m *UserMutation) SetQueueWeight(i int) {
+	m.queue_weight = &i
+	m.addqueue_weight = nil
+}
+
+// QueueWeight returns the value of the "queue_weight" field in the mutation.
+func (m *UserMutation) QueueWeight() (r int, exists bool) {
+	v := m.queue_weight
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldQueueWeight returns the old "queue_weight" field's value of the User entity.
+// If the User object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserMutation) OldQueueWeight(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldQueueWeight is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldQueueWeight requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldQueueWeight: %w", err)
+	}
+	return oldValue.QueueWeight, nil
+}
+
+// AddQueueWeight adds i to the "queue_weight" field.
+func (m *UserMutation) AddQueueWeight(i int) {
+	if m.addqueue_weight != nil {
+		*m.addqueue_weight += i
+	} else {
+		m.addqueue_weight = &i
+	}
+}
+
+// AddedQueueWeight returns the value that was added to the "queue_weight" field in this mutation.
+func (m *UserMutation) AddedQueueWeight() (r int, exists bool) {
+	v := m.addqueue_weight
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetQueueWeight resets all changes to the "queue_weight" field.
+func (m *UserMutation) ResetQueueWeight() {
+	m.queue_weight = nil
+	m.addqueue_weight = nil
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (m *UserMutation) SetQueuePriority(i int) {
+	m.queue_priority = &i
+	m.addqueue_priority = nil
+}
+
+// QueuePriority returns the value of the "queue_priority" field in the mutation.
+func (m *UserMutation) QueuePriority() (r int, exists bool) {
+	v := m.queue_priority
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldQueuePriority returns the old "queue_priority" field's value of the User entity.
+// If the User object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserMutation) OldQueuePriority(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldQueuePriority is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldQueuePriority requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldQueuePriority: %w", err)
+	}
+	return oldValue.QueuePriority, nil
+}
+
+// AddQueuePriority adds i to the "queue_priority" field.
+func (m *UserMutation) AddQueuePriority(i int) {
+	if m.addqueue_priority != nil {
+		*m.addqueue_priority += i
+	} else {
+		m.addqueue_priority = &i
+	}
+}
+
+// AddedQueuePriority returns the value that was added to the "queue_priority" field in this mutation.
+func (m *UserMutation) AddedQueuePriority() (r int, exists bool) {
+	v := m.addqueue_priority
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetQueuePriority resets all changes to the "queue_priority" field.
+func (m *UserMutation) ResetQueuePriority() {
+	m.queue_priority = nil
+	m.addqueue_priority = nil
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *UserMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -22615,7 +23565,7 @@ func (m *UserMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UserMutation) Fields() []string {
-	fields := make([]string, 0, 16)
+	fields := make([]string, 0, 18)
 	if m.created_at != nil {
 		fields = append(fields, user.FieldCreatedAt)
 	}
@@ -22664,6 +23614,12 @@ func (m *UserMutation) Fields() []string {
 	if m.sora_storage_used_bytes != nil {
 		fields = append(fields, user.FieldSoraStorageUsedBytes)
 	}
+	if m.queue_weight != nil {
+		fields = append(fields, user.FieldQueueWeight)
+	}
+	if m.queue_priority != nil {
+		fields = append(fields, user.FieldQueuePriority)
+	}
 	return fields
 }
 
@@ -22704,6 +23660,10 @@ func (m *UserMutation) Field(name string) (ent.Value, bool) {
 		return m.SoraStorageQuotaBytes()
 	case user.FieldSoraStorageUsedBytes:
 		return m.SoraStorageUsedBytes()
+	case user.FieldQueueWeight:
+		return m.QueueWeight()
+	case user.FieldQueuePriority:
+		return m.QueuePriority()
 	}
 	return nil, false
 }
@@ -22745,6 +23705,10 @@ func (m *UserMutation) OldField(ctx context.Context, name string) (ent.Value, er
 		return m.OldSoraStorageQuotaBytes(ctx)
 	case user.FieldSoraStorageUsedBytes:
 		return m.OldSoraStorageUsedBytes(ctx)
+	case user.FieldQueueWeight:
+		return m.OldQueueWeight(ctx)
+	case user.FieldQueuePriority:
+		return m.OldQueuePriority(ctx)
 	}
 	return nil, fmt.Errorf("unknown User field %s", name)
 }
@@ -22866,6 +23830,20 @@ func (m *UserMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetSoraStorageUsedBytes(v)
 		return nil
+	case user.FieldQueueWeight:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetQueueWeight(v)
+		return nil
+	case user.FieldQueuePriority:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetQueuePriority(v)
+		return nil
 	}
 	return fmt.Errorf("unknown User field %s", name)
 }
@@ -22886,6 +23864,12 @@ func (m *UserMutation) AddedFields() []string {
 	if m.addsora_storage_used_bytes != nil {
 		fields = append(fields, user.FieldSoraStorageUsedBytes)
 	}
+	if m.addqueue_weight != nil {
+		fields = append(fields, user.FieldQueueWeight)
+	}
+	if m.addqueue_priority != nil {
+		fields = append(fields, user.FieldQueuePriority)
+	}
 	return fields
 }
 
@@ -22902,6 +23886,10 @@ func (m *UserMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedSoraStorageQuotaBytes()
 	case user.FieldSoraStorageUsedBytes:
 		return m.AddedSoraStorageUsedBytes()
+	case user.FieldQueueWeight:
+		return m.AddedQueueWeight()
+	case user.FieldQueuePriority:
+		return m.AddedQueuePriority()
 	}
 	return nil, false
 }
@@ -22939,6 +23927,20 @@ func (m *UserMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddSoraStorageUsedBytes(v)
 		return nil
+	case user.FieldQueueWeight:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddQueueWeight(v)
+		return nil
+	case user.FieldQueuePriority:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddQueuePriority(v)
+		return nil
 	}
 	return fmt.Errorf("unknown User numeric field %s", name)
 }
@@ -23035,6 +24037,12 @@ func (m *UserMutation) ResetField(name string) error {
 	case user.FieldSoraStorageUsedBytes:
 		m.ResetSoraStorageUsedBytes()
 		return nil
+	case user.FieldQueueWeight:
+		m.ResetQueueWeight()
+		return nil
+	case user.FieldQueuePriority:
+		m.ResetQueuePriority()
+		return nil
 	}
 	return fmt.Errorf("unknown User field %s", name)
 }
@@ -25570,6 +26578,7 @@ type UserSubscriptionMutation struct {
 	addmonthly_usage_usd    *float64
 	assigned_at             *time.Time
 	notes                   *string
+	auto_renew              *bool
 	clearedFields           map[string]struct{}
 	user                    *int64
 	cleareduser             bool
@@ -26433,6 +27442,42 @@ func (m *UserSubscriptionMutation) ResetNotes() {
 	delete(m.clearedFields, usersubscription.FieldNotes)
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (m *UserSubscriptionMutation) SetAutoRenew(b bool) {
+	m.auto_renew = &b
+}
+
+// AutoRenew returns the value of the "auto_renew" field in the mutation.
+func (m *UserSubscriptionMutation) AutoRenew() (r bool, exists bool) {
+	v := m.auto_renew
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAutoRenew returns the old "auto_renew" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldAutoRenew(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAutoRenew is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAutoRenew requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAutoRenew: %w", err)
+	}
+	return oldValue.AutoRenew, nil
+}
+
+// ResetAutoRenew resets all changes to the "auto_renew" field.
+func (m *UserSubscriptionMutation) ResetAutoRenew() {
+	m.auto_renew = nil
+}
+
 // ClearUser clears the "user" edge to the User entity.
 func (m *UserSubscriptionMutation) ClearUser() {
 	m.cleareduser = true
@@ -26615,7 +27660,7 @@ func (m *UserSubscriptionMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UserSubscriptionMutation) Fields() []string {
-	fields := make([]string, 0, 17)
+	fields := make([]string, 0, 18)
 	if m.created_at != nil {
 		fields = append(fields, usersubscription.FieldCreatedAt)
 	}
@@ -26667,6 +27712,9 @@ func (m *UserSubscriptionMutation) Fields() []string {
 	if m.notes != nil {
 		fields = append(fields, usersubscription.FieldNotes)
 	}
+	if m.auto_renew != nil {
+		fields = append(fields, usersubscription.FieldAutoRenew)
+	}
 	return fields
 }
 
@@ -26709,6 +27757,8 @@ func (m *UserSubscriptionMutation) Field(name string) (ent.Value, bool) {
 		return m.AssignedAt()
 	case usersubscription.FieldNotes:
 		return m.Notes()
+	case usersubscription.FieldAutoRenew:
+		return m.AutoRenew()
 	}
 	return nil, false
 }
@@ -26752,6 +27802,8 @@ func (m *UserSubscriptionMutation) OldField(ctx context.Context, name string) (e
 		return m.OldAssignedAt(ctx)
 	case usersubscription.FieldNotes:
 		return m.OldNotes(ctx)
+	case usersubscription.FieldAutoRenew:
+		return m.OldAutoRenew(ctx)
 	}
 	return nil, fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -26880,6 +27932,13 @@ func (m *UserSubscriptionMutation) SetField(name string, value ent.Value) error
 		}
 		m.SetNotes(v)
 		return nil
+	case usersubscription.FieldAutoRenew:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAutoRenew(v)
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -27058,6 +28117,9 @@ func (m *UserSubscriptionMutation) ResetField(name string) error {
 	case usersubscription.FieldNotes:
 		m.ResetNotes()
 		return nil
+	case usersubscription.FieldAutoRenew:
+		m.ResetAutoRenew()
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -126,6 +126,12 @@ func init() {
 	apikeyDescUsage7d := apikeyFields[16].Descriptor()
 	// apikey.DefaultUsage7d holds the default value on creation for the usage_7d field.
 	apikey.DefaultUsage7d = apikeyDescUsage7d.Default.(float64)
+	// apikeyDescPriority is the schema descriptor for priority field.
+	apikeyDescPriority := apikeyFields[20].Descriptor()
+	// apikey.DefaultPriority holds the default value on creation for the priority field.
+	apikey.DefaultPriority = apikeyDescPriority.Default.(string)
+	// apikey.PriorityValidator is a validator for the "priority" field. It is called by the builders before save.
+	apikey.PriorityValidator = apikeyDescPriority.Validators[0].(func(string) error)
 	accountMixin := schema.Account{}.Mixin()
 	accountMixinHooks1 := accountMixin[1].Hooks()
 	account.Hooks[0] = accountMixinHooks1[0]
@@ -442,27 +448,59 @@ func init() {
 	// group.DefaultModelRoutingEnabled holds the default value on creation for the model_routing_enabled field.
 	group.DefaultModelRoutingEnabled = groupDescModelRoutingEnabled.Default.(bool)
 	// groupDescMcpXMLInject is the schema descriptor for mcp_xml_inject field.
-	groupDescMcpXMLInject := groupFields[24].Descriptor()
+	groupDescMcpXMLInject := groupFields[26].Descriptor()
 	// group.DefaultMcpXMLInject holds the default value on creation for the mcp_xml_inject field.
 	group.DefaultMcpXMLInject = groupDescMcpXMLInject.Default.(bool)
 	// groupDescSupportedModelScopes is the schema descriptor for supported_model_scopes field.
-	groupDescSupportedModelScopes := groupFields[25].Descriptor()
+	groupDescSupportedModelScopes := groupFields[27].Descriptor()
 	// group.DefaultSupportedModelScopes holds the default value on creation for the supported_model_scopes field.
 	group.DefaultSupportedModelScopes = groupDescSupportedModelScopes.Default.([]string)
 	// groupDescSortOrder is the schema descriptor for sort_order field.
-	groupDescSortOrder := groupFields[26].Descriptor()
+	groupDescSortOrder := groupFields[28].Descriptor()
 	// group.DefaultSortOrder holds the default value on creation for the sort_order field.
 	group.DefaultSortOrder = groupDescSortOrder.Default.(int)
 	// groupDescAllowMessagesDispatch is the schema descriptor for allow_messages_dispatch field.
-	groupDescAllowMessagesDispatch := groupFields[27].Descriptor()
+	groupDescAllowMessagesDispatch := groupFields[29].Descriptor()
 	// group.DefaultAllowMessagesDispatch holds the default value on creation for the allow_messages_dispatch field.
 	group.DefaultAllowMessagesDispatch = groupDescAllowMessagesDispatch.Default.(bool)
 	// groupDescDefaultMappedModel is the schema descriptor for default_mapped_model field.
-	groupDescDefaultMappedModel := groupFields[28].Descriptor()
+	groupDescDefaultMappedModel := groupFields[30].Descriptor()
 	// group.DefaultDefaultMappedModel holds the default value on creation for the default_mapped_model field.
 	group.DefaultDefaultMappedModel = groupDescDefaultMappedModel.Default.(string)
 	// group.DefaultMappedModelValidator is a validator for the "default_mapped_model" field. It is called by the builders before save.
 	group.DefaultMappedModelValidator = groupDescDefaultMappedModel.Validators[0].(func(string) error)
+	// groupDescRenewalPeriodDays is the schema descriptor for renewal_period_days field.
+	groupDescRenewalPeriodDays := groupFields[32].Descriptor()
+	// group.DefaultRenewalPeriodDays holds the default value on creation for the renewal_period_days field.
+	group.DefaultRenewalPeriodDays = groupDescRenewalPeriodDays.Default.(int)
+	// groupDescQueueWeight is the schema descriptor for queue_weight field.
+	groupDescQueueWeight := groupFields[33].Descriptor()
+	// group.DefaultQueueWeight holds the default value on creation for the queue_weight field.
+	group.DefaultQueueWeight = groupDescQueueWeight.Default.(int)
+	// groupDescQueuePriority is the schema descriptor for queue_priority field.
+	groupDescQueuePriority := groupFields[34].Descriptor()
+	// group.DefaultQueuePriority holds the default value on creation for the queue_priority field.
+	group.DefaultQueuePriority = groupDescQueuePriority.Default.(int)
+	// groupDescSchedulingStrategy is the schema descriptor for scheduling_strategy field.
+	groupDescSchedulingStrategy := groupFields[35].Descriptor()
+	// group.DefaultSchedulingStrategy holds the default value on creation for the scheduling_strategy field.
+	group.DefaultSchedulingStrategy = groupDescSchedulingStrategy.Default.(string)
+	// group.SchedulingStrategyValidator is a validator for the "scheduling_strategy" field. It is called by the builders before save.
+	group.SchedulingStrategyValidator = groupDescSchedulingStrategy.Validators[0].(func(string) error)
+	// groupDescHedgeEnabled is the schema descriptor for hedge_enabled field.
+	groupDescHedgeEnabled := groupFields[36].Descriptor()
+	// group.DefaultHedgeEnabled holds the default value on creation for the hedge_enabled field.
+	group.DefaultHedgeEnabled = groupDescHedgeEnabled.Default.(bool)
+	// groupDescHedgePercentile is the schema descriptor for hedge_percentile field.
+	groupDescHedgePercentile := groupFields[37].Descriptor()
+	// group.DefaultHedgePercentile holds the default value on creation for the hedge_percentile field.
+	group.DefaultHedgePercentile = groupDescHedgePercentile.Default.(int)
+	// groupDescHedgeLoserBilling is the schema descriptor for hedge_loser_billing field.
+	groupDescHedgeLoserBilling := groupFields[38].Descriptor()
+	// group.DefaultHedgeLoserBilling holds the default value on creation for the hedge_loser_billing field.
+	group.DefaultHedgeLoserBilling = groupDescHedgeLoserBilling.Default.(string)
+	// group.HedgeLoserBillingValidator is a validator for the "hedge_loser_billing" field. It is called by the builders before save.
+	group.HedgeLoserBillingValidator = groupDescHedgeLoserBilling.Validators[0].(func(string) error)
 	idempotencyrecordMixin := schema.IdempotencyRecord{}.Mixin()
 	idempotencyrecordMixinFields0 := idempotencyrecordMixin[0].Fields()
 	_ = idempotencyrecordMixinFields0
@@ -1006,6 +1044,14 @@ func init() {
 	userDescSoraStorageUsedBytes := userFields[12].Descriptor()
 	// user.DefaultSoraStorageUsedBytes holds the default value on creation for the sora_storage_used_bytes field.
 	user.DefaultSoraStorageUsedBytes = userDescSoraStorageUsedBytes.Default.(int64)
+	// userDescQueueWeight is the schema descriptor for queue_weight field.
+	userDescQueueWeight := userFields[13].Descriptor()
+	// user.DefaultQueueWeight holds the default value on creation for the queue_weight field.
+	user.DefaultQueueWeight = userDescQueueWeight.Default.(int)
+	// userDescQueuePriority is the schema descriptor for queue_priority field.
+	userDescQueuePriority := userFields[14].Descriptor()
+	// user.DefaultQueuePriority holds the default value on creation for the queue_priority field.
+	user.DefaultQueuePriority = userDescQueuePriority.Default.(int)
 	userallowedgroupFields := schema.UserAllowedGroup{}.Fields()
 	_ = userallowedgroupFields
 	// userallowedgroupDescCreatedAt is the schema descriptor for created_at field.
@@ -1175,6 +1221,10 @@ func init() {
 	usersubscriptionDescAssignedAt := usersubscriptionFields[12].Descriptor()
 	// usersubscription.DefaultAssignedAt holds the default value on creation for the assigned_at field.
 	usersubscription.DefaultAssignedAt = usersubscriptionDescAssignedAt.Default.(func() time.Time)
+	// usersubscriptionDescAutoRenew is the schema descriptor for auto_renew field.
+	usersubscriptionDescAutoRenew := usersubscriptionFields[14].Descriptor()
+	// usersubscription.DefaultAutoRenew holds the default value on creation for the auto_renew field.
+	usersubscription.DefaultAutoRenew = usersubscriptionDescAutoRenew.Default.(bool)
 }
 
 const (
@@ -115,6 +115,12 @@ func (APIKey) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			Comment("Start time of the current 7d rate limit window"),
+
+		// ========== Scheduling priority ==========
+		field.String("priority").
+			MaxLen(16).
+			Default("normal").
+			Comment("Scheduling priority: interactive, normal, batch"),
 	}
 }
 
@@ -133,6 +133,18 @@ func (Group) Fields() []ent.Field {
 			Default(false).
 			Comment("是否启用模型路由配置"),
 
+		// 模型降级链 (added by migration 089)
+		field.JSON("model_fallbacks", map[string][]domain.ModelFallbackTarget{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型降级链：模型模式 -> 有序降级目标列表"),
+
+		// 请求改写规则 (added by migration 095)
+		field.JSON("request_transforms", []domain.RequestTransformRule{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("请求改写规则：转发前按顺序执行的请求体/请求头改写"),
+
 		// MCP XML 协议注入开关 (added by migration 042)
 		field.Bool("mcp_xml_inject").
 			Default(true).
@@ -157,6 +169,42 @@ func (Group) Fields() []ent.Field {
 			MaxLen(100).
 			Default("").
 			Comment("默认映射模型 ID，当账号级映射找不到时使用此值"),
+
+		// 订阅售价与续费周期 (added by migration 078)
+		field.Float("price").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
+			Comment("订阅售价（从余额扣除），为空表示不可购买/续费"),
+		field.Int("renewal_period_days").
+			Default(0).
+			Comment("每次购买/续费延长的天数，0 表示使用 default_validity_days"),
+
+		// 公平排队配置 (added by migration 091)
+		field.Int("queue_weight").
+			Default(0).
+			Comment("公平排队权重，0 表示默认权重 1"),
+		field.Int("queue_priority").
+			Default(0).
+			Comment("公平排队优先级档位，数值越大越优先"),
+
+		// 账号调度策略 (added by migration 092)
+		field.String("scheduling_strategy").
+			MaxLen(32).
+			Default("").
+			Comment("账号调度策略：空表示默认（优先级 → 负载 → LRU），cost_aware 表示成本感知"),
+
+		// 对冲请求配置 (added by migration 093)
+		field.Bool("hedge_enabled").
+			Default(false).
+			Comment("是否对非流式请求启用对冲：首个上游迟迟未返回时在另一账号上并发重试"),
+		field.Int("hedge_percentile").
+			Default(95).
+			Comment("触发对冲的延迟分位数（50-99），取自 ops 延迟直方图"),
+		field.String("hedge_loser_billing").
+			MaxLen(16).
+			Default("ignore").
+			Comment("对冲落败请求的计费策略：ignore 不计费，bill 按已完成的用量计费"),
 	}
 }
 
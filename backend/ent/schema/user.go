@@ -78,6 +78,14 @@ func (User) Fields() []ent.Field {
 			Default(0),
 		field.Int64("sora_storage_used_bytes").
 			Default(0),
+
+		// 公平排队配置 (added by migration 091)
+		field.Int("queue_weight").
+			Default(0).
+			Comment("公平排队权重，0 表示使用分组配置"),
+		field.Int("queue_priority").
+			Default(0).
+			Comment("公平排队优先级档位，0 表示使用分组配置"),
 	}
 }
 
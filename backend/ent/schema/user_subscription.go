@@ -79,6 +79,11 @@ func (UserSubscription) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			SchemaType(map[string]string{dialect.Postgres: "text"}),
+
+		// 自动续费 (added by migration 078)
+		field.Bool("auto_renew").
+			Default(false).
+			Comment("到期前自动从余额扣费续订"),
 	}
 }
 
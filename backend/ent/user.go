@@ -49,6 +49,10 @@ type User struct {
 	SoraStorageQuotaBytes int64 `json:"sora_storage_quota_bytes,omitempty"`
 	// SoraStorageUsedBytes holds the value of the "sora_storage_used_bytes" field.
 	SoraStorageUsedBytes int64 `json:"sora_storage_used_bytes,omitempty"`
+	// 公平排队权重，0 表示使用分组配置
+	QueueWeight int `json:"queue_weight,omitempty"`
+	// 公平排队优先级档位，0 表示使用分组配置
+	QueuePriority int `json:"queue_priority,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the UserQuery when eager-loading is set.
 	Edges        UserEdges `json:"edges"`
@@ -181,7 +185,7 @@ func (*User) scanValues(columns []string) ([]any, error) {
 			values[i] = new(sql.NullBool)
 		case user.FieldBalance:
 			values[i] = new(sql.NullFloat64)
-		case user.FieldID, user.FieldConcurrency, user.FieldSoraStorageQuotaBytes, user.FieldSoraStorageUsedBytes:
+		case user.FieldID, user.FieldConcurrency, user.FieldSoraStorageQuotaBytes, user.FieldSoraStorageUsedBytes, user.FieldQueueWeight, user.FieldQueuePriority:
 			values[i] = new(sql.NullInt64)
 		case user.FieldEmail, user.FieldPasswordHash, user.FieldRole, user.FieldStatus, user.FieldUsername, user.FieldNotes, user.FieldTotpSecretEncrypted:
 			values[i] = new(sql.NullString)
@@ -307,6 +311,18 @@ func (_m *User) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.SoraStorageUsedBytes = value.Int64
 			}
+		case user.FieldQueueWeight:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field queue_weight", values[i])
+			} else if value.Valid {
+				_m.QueueWeight = int(value.Int64)
+			}
+		case user.FieldQueuePriority:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field queue_priority", values[i])
+			} else if value.Valid {
+				_m.QueuePriority = int(value.Int64)
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -446,6 +462,12 @@ func (_m *User) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("sora_storage_used_bytes=")
 	builder.WriteString(fmt.Sprintf("%v", _m.SoraStorageUsedBytes))
+	builder.WriteString(", ")
+	builder.WriteString("queue_weight=")
+	builder.WriteString(fmt.Sprintf("%v", _m.QueueWeight))
+	builder.WriteString(", ")
+	builder.WriteString("queue_priority=")
+	builder.WriteString(fmt.Sprintf("%v", _m.QueuePriority))
 	builder.WriteByte(')')
 	return builder.String()
 }
@@ -47,6 +47,10 @@ const (
 	FieldSoraStorageQuotaBytes = "sora_storage_quota_bytes"
 	// FieldSoraStorageUsedBytes holds the string denoting the sora_storage_used_bytes field in the database.
 	FieldSoraStorageUsedBytes = "sora_storage_used_bytes"
+	// FieldQueueWeight holds the string denoting the queue_weight field in the database.
+	FieldQueueWeight = "queue_weight"
+	// FieldQueuePriority holds the string denoting the queue_priority field in the database.
+	FieldQueuePriority = "queue_priority"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -158,6 +162,8 @@ var Columns = []string{
 	FieldTotpEnabledAt,
 	FieldSoraStorageQuotaBytes,
 	FieldSoraStorageUsedBytes,
+	FieldQueueWeight,
+	FieldQueuePriority,
 }
 
 var (
@@ -218,6 +224,10 @@ var (
 	DefaultSoraStorageQuotaBytes int64
 	// DefaultSoraStorageUsedBytes holds the default value on creation for the "sora_storage_used_bytes" field.
 	DefaultSoraStorageUsedBytes int64
+	// DefaultQueueWeight holds the default value on creation for the "queue_weight" field.
+	DefaultQueueWeight int
+	// DefaultQueuePriority holds the default value on creation for the "queue_priority" field.
+	DefaultQueuePriority int
 )
 
 // OrderOption defines the ordering options for the User queries.
@@ -308,6 +318,16 @@ func BySoraStorageUsedBytes(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldSoraStorageUsedBytes, opts...).ToFunc()
 }
 
+// ByQueueWeight orders the results by the queue_weight field.
+func ByQueueWeight(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldQueueWeight, opts...).ToFunc()
+}
+
+// ByQueuePriority orders the results by the queue_priority field.
+func ByQueuePriority(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldQueuePriority, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
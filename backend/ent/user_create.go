@@ -238,6 +238,34 @@ func (_c *UserCreate) SetNillableSoraStorageUsedBytes(v *int64) *UserCreate {
 	return _c
 }
 
+// SetQueueWeight sets the "queue_weight" field.
+func (_c *UserCreate) SetQueueWeight(v int) *UserCreate {
+	_c.mutation.SetQueueWeight(v)
+	return _c
+}
+
+// SetNillableQueueWeight sets the "queue_weight" field if the given value is not nil.
+func (_c *UserCreate) SetNillableQueueWeight(v *int) *UserCreate {
+	if v != nil {
+		_c.SetQueueWeight(*v)
+	}
+	return _c
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (_c *UserCreate) SetQueuePriority(v int) *UserCreate {
+	_c.mutation.SetQueuePriority(v)
+	return _c
+}
+
+// SetNillableQueuePriority sets the "queue_priority" field if the given value is not nil.
+func (_c *UserCreate) SetNillableQueuePriority(v *int) *UserCreate {
+	if v != nil {
+		_c.SetQueuePriority(*v)
+	}
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *UserCreate) AddAPIKeyIDs(ids ...int64) *UserCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -460,6 +488,14 @@ func (_c *UserCreate) defaults() error {
 		v := user.DefaultSoraStorageUsedBytes
 		_c.mutation.SetSoraStorageUsedBytes(v)
 	}
+	if _, ok := _c.mutation.QueueWeight(); !ok {
+		v := user.DefaultQueueWeight
+		_c.mutation.SetQueueWeight(v)
+	}
+	if _, ok := _c.mutation.QueuePriority(); !ok {
+		v := user.DefaultQueuePriority
+		_c.mutation.SetQueuePriority(v)
+	}
 	return nil
 }
 
@@ -529,6 +565,12 @@ func (_c *UserCreate) check() error {
 	if _, ok := _c.mutation.SoraStorageUsedBytes(); !ok {
 		return &ValidationError{Name: "sora_storage_used_bytes", err: errors.New(`ent: missing required field "User.sora_storage_used_bytes"`)}
 	}
+	if _, ok := _c.mutation.QueueWeight(); !ok {
+		return &ValidationError{Name: "queue_weight", err: errors.New(`ent: missing required field "User.queue_weight"`)}
+	}
+	if _, ok := _c.mutation.QueuePriority(); !ok {
+		return &ValidationError{Name: "queue_priority", err: errors.New(`ent: missing required field "User.queue_priority"`)}
+	}
 	return nil
 }
 
@@ -620,6 +662,14 @@ func (_c *UserCreate) createSpec() (*User, *sqlgraph.CreateSpec) {
 		_spec.SetField(user.FieldSoraStorageUsedBytes, field.TypeInt64, value)
 		_node.SoraStorageUsedBytes = value
 	}
+	if value, ok := _c.mutation.QueueWeight(); ok {
+		_spec.SetField(user.FieldQueueWeight, field.TypeInt, value)
+		_node.QueueWeight = value
+	}
+	if value, ok := _c.mutation.QueuePriority(); ok {
+		_spec.SetField(user.FieldQueuePriority, field.TypeInt, value)
+		_node.QueuePriority = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1042,6 +1092,42 @@ func (u *UserUpsert) AddSoraStorageUsedBytes(v int64) *UserUpsert {
 	return u
 }
 
+// SetQueueWeight sets the "queue_weight" field.
+func (u *UserUpsert) SetQueueWeight(v int) *UserUpsert {
+	u.Set(user.FieldQueueWeight, v)
+	return u
+}
+
+// UpdateQueueWeight sets the "queue_weight" field to the value that was provided on create.
+func (u *UserUpsert) UpdateQueueWeight() *UserUpsert {
+	u.SetExcluded(user.FieldQueueWeight)
+	return u
+}
+
+// AddQueueWeight adds v to the "queue_weight" field.
+func (u *UserUpsert) AddQueueWeight(v int) *UserUpsert {
+	u.Add(user.FieldQueueWeight, v)
+	return u
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (u *UserUpsert) SetQueuePriority(v int) *UserUpsert {
+	u.Set(user.FieldQueuePriority, v)
+	return u
+}
+
+// UpdateQueuePriority sets the "queue_priority" field to the value that was provided on create.
+func (u *UserUpsert) UpdateQueuePriority() *UserUpsert {
+	u.SetExcluded(user.FieldQueuePriority)
+	return u
+}
+
+// AddQueuePriority adds v to the "queue_priority" field.
+func (u *UserUpsert) AddQueuePriority(v int) *UserUpsert {
+	u.Add(user.FieldQueuePriority, v)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1346,6 +1432,48 @@ func (u *UserUpsertOne) UpdateSoraStorageUsedBytes() *UserUpsertOne {
 	})
 }
 
+// SetQueueWeight sets the "queue_weight" field.
+func (u *UserUpsertOne) SetQueueWeight(v int) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.SetQueueWeight(v)
+	})
+}
+
+// AddQueueWeight adds v to the "queue_weight" field.
+func (u *UserUpsertOne) AddQueueWeight(v int) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.AddQueueWeight(v)
+	})
+}
+
+// UpdateQueueWeight sets the "queue_weight" field to the value that was provided on create.
+func (u *UserUpsertOne) UpdateQueueWeight() *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateQueueWeight()
+	})
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (u *UserUpsertOne) SetQueuePriority(v int) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.SetQueuePriority(v)
+	})
+}
+
+// AddQueuePriority adds v to the "queue_priority" field.
+func (u *UserUpsertOne) AddQueuePriority(v int) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.AddQueuePriority(v)
+	})
+}
+
+// UpdateQueuePriority sets the "queue_priority" field to the value that was provided on create.
+func (u *UserUpsertOne) UpdateQueuePriority() *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateQueuePriority()
+	})
+}
+
 // Exec executes the query.
 func (u *UserUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1816,6 +1944,48 @@ func (u *UserUpsertBulk) UpdateSoraStorageUsedBytes() *UserUpsertBulk {
 	})
 }
 
+// SetQueueWeight sets the "queue_weight" field.
+func (u *UserUpsertBulk) SetQueueWeight(v int) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.SetQueueWeight(v)
+	})
+}
+
+// AddQueueWeight adds v to the "queue_weight" field.
+func (u *UserUpsertBulk) AddQueueWeight(v int) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.AddQueueWeight(v)
+	})
+}
+
+// UpdateQueueWeight sets the "queue_weight" field to the value that was provided on create.
+func (u *UserUpsertBulk) UpdateQueueWeight() *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateQueueWeight()
+	})
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (u *UserUpsertBulk) SetQueuePriority(v int) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.SetQueuePriority(v)
+	})
+}
+
+// AddQueuePriority adds v to the "queue_priority" field.
+func (u *UserUpsertBulk) AddQueuePriority(v int) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.AddQueuePriority(v)
+	})
+}
+
+// UpdateQueuePriority sets the "queue_priority" field to the value that was provided on create.
+func (u *UserUpsertBulk) UpdateQueuePriority() *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateQueuePriority()
+	})
+}
+
 // Exec executes the query.
 func (u *UserUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -284,6 +284,48 @@ func (_u *UserUpdate) AddSoraStorageUsedBytes(v int64) *UserUpdate {
 	return _u
 }
 
+// SetQueueWeight sets the "queue_weight" field.
+func (_u *UserUpdate) SetQueueWeight(v int) *UserUpdate {
+	_u.mutation.ResetQueueWeight()
+	_u.mutation.SetQueueWeight(v)
+	return _u
+}
+
+// SetNillableQueueWeight sets the "queue_weight" field if the given value is not nil.
+func (_u *UserUpdate) SetNillableQueueWeight(v *int) *UserUpdate {
+	if v != nil {
+		_u.SetQueueWeight(*v)
+	}
+	return _u
+}
+
+// AddQueueWeight adds value to the "queue_weight" field.
+func (_u *UserUpdate) AddQueueWeight(v int) *UserUpdate {
+	_u.mutation.AddQueueWeight(v)
+	return _u
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (_u *UserUpdate) SetQueuePriority(v int) *UserUpdate {
+	_u.mutation.ResetQueuePriority()
+	_u.mutation.SetQueuePriority(v)
+	return _u
+}
+
+// SetNillableQueuePriority sets the "queue_priority" field if the given value is not nil.
+func (_u *UserUpdate) SetNillableQueuePriority(v *int) *UserUpdate {
+	if v != nil {
+		_u.SetQueuePriority(*v)
+	}
+	return _u
+}
+
+// AddQueuePriority adds value to the "queue_priority" field.
+func (_u *UserUpdate) AddQueuePriority(v int) *UserUpdate {
+	_u.mutation.AddQueuePriority(v)
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *UserUpdate) AddAPIKeyIDs(ids ...int64) *UserUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -763,6 +805,18 @@ func (_u *UserUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.AddedSoraStorageUsedBytes(); ok {
 		_spec.AddField(user.FieldSoraStorageUsedBytes, field.TypeInt64, value)
 	}
+	if value, ok := _u.mutation.QueueWeight(); ok {
+		_spec.SetField(user.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueueWeight(); ok {
+		_spec.AddField(user.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.QueuePriority(); ok {
+		_spec.SetField(user.FieldQueuePriority, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueuePriority(); ok {
+		_spec.AddField(user.FieldQueuePriority, field.TypeInt, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1448,6 +1502,48 @@ func (_u *UserUpdateOne) AddSoraStorageUsedBytes(v int64) *UserUpdateOne {
 	return _u
 }
 
+// SetQueueWeight sets the "queue_weight" field.
+func (_u *UserUpdateOne) SetQueueWeight(v int) *UserUpdateOne {
+	_u.mutation.ResetQueueWeight()
+	_u.mutation.SetQueueWeight(v)
+	return _u
+}
+
+// SetNillableQueueWeight sets the "queue_weight" field if the given value is not nil.
+func (_u *UserUpdateOne) SetNillableQueueWeight(v *int) *UserUpdateOne {
+	if v != nil {
+		_u.SetQueueWeight(*v)
+	}
+	return _u
+}
+
+// AddQueueWeight adds value to the "queue_weight" field.
+func (_u *UserUpdateOne) AddQueueWeight(v int) *UserUpdateOne {
+	_u.mutation.AddQueueWeight(v)
+	return _u
+}
+
+// SetQueuePriority sets the "queue_priority" field.
+func (_u *UserUpdateOne) SetQueuePriority(v int) *UserUpdateOne {
+	_u.mutation.ResetQueuePriority()
+	_u.mutation.SetQueuePriority(v)
+	return _u
+}
+
+// SetNillableQueuePriority sets the "queue_priority" field if the given value is not nil.
+func (_u *UserUpdateOne) SetNillableQueuePriority(v *int) *UserUpdateOne {
+	if v != nil {
+		_u.SetQueuePriority(*v)
+	}
+	return _u
+}
+
+// AddQueuePriority adds value to the "queue_priority" field.
+func (_u *UserUpdateOne) AddQueuePriority(v int) *UserUpdateOne {
+	_u.mutation.AddQueuePriority(v)
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *UserUpdateOne) AddAPIKeyIDs(ids ...int64) *UserUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -1957,6 +2053,18 @@ func (_u *UserUpdateOne) sqlSave(ctx context.Context) (_node *User, err error) {
 	if value, ok := _u.mutation.AddedSoraStorageUsedBytes(); ok {
 		_spec.AddField(user.FieldSoraStorageUsedBytes, field.TypeInt64, value)
 	}
+	if value, ok := _u.mutation.QueueWeight(); ok {
+		_spec.SetField(user.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueueWeight(); ok {
+		_spec.AddField(user.FieldQueueWeight, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.QueuePriority(); ok {
+		_spec.SetField(user.FieldQueuePriority, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedQueuePriority(); ok {
+		_spec.AddField(user.FieldQueuePriority, field.TypeInt, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -75,6 +76,13 @@ type Config struct {
 	Dashboard               DashboardCacheConfig          `mapstructure:"dashboard_cache"`
 	DashboardAgg            DashboardAggregationConfig    `mapstructure:"dashboard_aggregation"`
 	UsageCleanup            UsageCleanupConfig            `mapstructure:"usage_cleanup"`
+	UsageExport             UsageExportConfig             `mapstructure:"usage_export"`
+	UsageLogPartition       UsageLogPartitionConfig       `mapstructure:"usage_log_partition"`
+	Invoice                 InvoiceConfig                 `mapstructure:"invoice"`
+	UserNotification        UserNotificationConfig        `mapstructure:"user_notification"`
+	SubscriptionRenewal     SubscriptionRenewalConfig     `mapstructure:"subscription_renewal"`
+	RequestCapture          RequestCaptureConfig          `mapstructure:"request_capture"`
+	ContentModeration       ContentModerationConfig       `mapstructure:"content_moderation"`
 	Concurrency             ConcurrencyConfig             `mapstructure:"concurrency"`
 	TokenRefresh            TokenRefreshConfig            `mapstructure:"token_refresh"`
 	Sora                    SoraConfig                    `mapstructure:"sora"`
@@ -214,6 +222,26 @@ type PricingConfig struct {
 	UpdateIntervalHours int `mapstructure:"update_interval_hours"`
 	// 哈希校验间隔（分钟）
 	HashCheckIntervalMinutes int `mapstructure:"hash_check_interval_minutes"`
+	// 多价格数据源；为空时使用 remote_url/hash_url 组成的单一 LiteLLM 数据源
+	Sources []PricingSourceConfig `mapstructure:"sources"`
+	// 同步到的价格变化是否需要管理员审核后才生效（首次同步始终直接生效）
+	RequireApproval bool `mapstructure:"require_approval"`
+}
+
+// PricingSourceConfig 价格数据源配置
+type PricingSourceConfig struct {
+	// 数据源名称（唯一，用于状态展示和差异来源标记）
+	Name string `mapstructure:"name"`
+	// 数据格式：litellm / openrouter / yaml
+	Type string `mapstructure:"type"`
+	// 远程 URL（与 path 二选一）
+	URL string `mapstructure:"url"`
+	// 哈希校验 URL（可选，仅对远程数据源生效）
+	HashURL string `mapstructure:"hash_url"`
+	// 本地文件路径（与 url 二选一）
+	Path string `mapstructure:"path"`
+	// 优先级：数值越大越优先，同一模型以优先级最高的数据源为准
+	Priority int `mapstructure:"priority"`
 }
 
 type ServerConfig struct {
@@ -467,6 +495,77 @@ type GatewayConfig struct {
 	// UserMessageQueue: 用户消息串行队列配置
 	// 对 role:"user" 的真实用户消息实施账号级串行化 + RPM 自适应延迟
 	UserMessageQueue UserMessageQueueConfig `mapstructure:"user_message_queue"`
+
+	// ResponsesCompat: Anthropic 分组上的 /v1/responses 兼容层配置
+	ResponsesCompat GatewayResponsesCompatConfig `mapstructure:"responses_compat"`
+
+	// Hedge: 非流式请求对冲配置（需在分组上开启 hedge_enabled）
+	Hedge GatewayHedgeConfig `mapstructure:"hedge"`
+
+	// CircuitBreaker: 上游熔断配置（按账号与上游主机分别熔断）
+	CircuitBreaker GatewayCircuitBreakerConfig `mapstructure:"circuit_breaker"`
+
+	// UserRateLimitHeaders: 按用户自身额度生成限流响应头（替代上游账号的限流头）
+	UserRateLimitHeaders GatewayUserRateLimitHeadersConfig `mapstructure:"user_rate_limit_headers"`
+}
+
+// GatewayUserRateLimitHeadersConfig 用户限流响应头配置
+// 上游返回的 anthropic-ratelimit-* / x-ratelimit-* 描述的是上游账号而非用户的限额，
+// 启用后网关丢弃这些上游头部，改为根据用户订阅窗口与 API Key 额度生成同名头部，
+// 使客户端的退避逻辑基于真正约束用户的限额；用户并发占用通过 x-sub2api-concurrency-* 头部输出。
+type GatewayUserRateLimitHeadersConfig struct {
+	// Enabled: 是否启用
+	Enabled bool `mapstructure:"enabled"`
+	// WarningThreshold: 额度使用率达到该比例时状态为 allowed_warning（0-1）
+	WarningThreshold float64 `mapstructure:"warning_threshold"`
+}
+
+// GatewayCircuitBreakerConfig 上游熔断器配置
+// 在滑动窗口内统计错误率与慢调用率，超过阈值时熔断（open）；熔断到期后进入半开（half-open），
+// 仅放行一个真实请求作为探测，成功则恢复（closed），失败则重新熔断。状态通过 Redis 在实例间共享。
+type GatewayCircuitBreakerConfig struct {
+	// Enabled: 是否启用上游熔断
+	Enabled bool `mapstructure:"enabled"`
+	// WindowSeconds: 统计滑动窗口（秒）
+	WindowSeconds int `mapstructure:"window_seconds"`
+	// MinRequests: 窗口内最少请求数，低于该值不触发熔断
+	MinRequests int `mapstructure:"min_requests"`
+	// ErrorRateThreshold: 错误率阈值（百分比，网络错误与 5xx 计为错误）
+	ErrorRateThreshold int `mapstructure:"error_rate_threshold"`
+	// SlowCallThresholdMs: 慢调用阈值（毫秒，按上游响应头耗时计算），0 表示不统计慢调用
+	SlowCallThresholdMs int `mapstructure:"slow_call_threshold_ms"`
+	// SlowCallRateThreshold: 慢调用率阈值（百分比），0 表示不按慢调用熔断
+	SlowCallRateThreshold int `mapstructure:"slow_call_rate_threshold"`
+	// OpenDurationSeconds: 熔断持续时间（秒），到期后进入半开状态
+	OpenDurationSeconds int `mapstructure:"open_duration_seconds"`
+	// ProbeTimeoutSeconds: 半开探测请求的占用时长（秒），超时未上报结果则允许新的探测
+	ProbeTimeoutSeconds int `mapstructure:"probe_timeout_seconds"`
+}
+
+// GatewayHedgeConfig 对冲请求配置
+// 对冲延迟取自分组上游响应头延迟（进程内统计）的分位数，并限制在 [MinDelayMs, MaxDelayMs] 内
+type GatewayHedgeConfig struct {
+	// MinDelayMs: 对冲延迟下限（毫秒）
+	MinDelayMs int `mapstructure:"min_delay_ms"`
+	// MaxDelayMs: 对冲延迟上限（毫秒）
+	MaxDelayMs int `mapstructure:"max_delay_ms"`
+	// DefaultDelayMs: 样本不足或分位落在被取消请求（延迟未知）中时使用的对冲延迟（毫秒）
+	DefaultDelayMs int `mapstructure:"default_delay_ms"`
+	// StatsWindowMinutes: 计算分位数的统计窗口（分钟）
+	StatsWindowMinutes int `mapstructure:"stats_window_minutes"`
+	// MaxTokens: 仅对 max_tokens 不超过该值的请求对冲，0 表示不限制
+	MaxTokens int `mapstructure:"max_tokens"`
+}
+
+// GatewayResponsesCompatConfig Anthropic 分组 /v1/responses 兼容层配置
+// 将 Responses API 请求转换为 Anthropic Messages，供 Codex 等仅支持 Responses 的客户端使用 Claude 账号池
+type GatewayResponsesCompatConfig struct {
+	// Enabled: 是否允许 Anthropic 分组处理 /v1/responses（关闭时返回 404）
+	Enabled bool `mapstructure:"enabled"`
+	// ConversationTTLMinutes: previous_response_id 会话上下文保留时长（分钟），0 表示不保存会话
+	ConversationTTLMinutes int `mapstructure:"conversation_ttl_minutes"`
+	// MaxConversationBytes: 单个会话上下文最大字节数，超出时不再保存（后续请求需客户端回传完整上下文）
+	MaxConversationBytes int `mapstructure:"max_conversation_bytes"`
 }
 
 // UserMessageQueueConfig 用户消息串行队列配置
@@ -682,6 +781,19 @@ type GatewaySchedulingConfig struct {
 	// 兜底层账户选择策略: "last_used"(按最后使用时间排序，默认) 或 "random"(随机)
 	FallbackSelectionMode string `mapstructure:"fallback_selection_mode"`
 
+	// 公平排队：账号满载时等待者按优先级档位分层、档内按权重加权轮转放行
+	FairQueueEnabled bool `mapstructure:"fair_queue_enabled"`
+	// 公平排队主体: "user"(按用户，默认) 或 "api_key"(按 API Key)
+	FairQueuePrincipal string `mapstructure:"fair_queue_principal"`
+
+	// API Key 优先级：每个账号为 interactive 预留的槽位比例（0-1），normal / batch 请求不可占用
+	InteractiveReservedRatio float64 `mapstructure:"interactive_reserved_ratio"`
+	// batch 请求只使用负载率（%）低于该阈值的账号
+	BatchLoadThreshold int `mapstructure:"batch_load_threshold"`
+	// batch 独立排队队列的等待超时与单账号最大长度
+	BatchWaitTimeout time.Duration `mapstructure:"batch_wait_timeout"`
+	BatchMaxWaiting  int           `mapstructure:"batch_max_waiting"`
+
 	// 负载计算
 	LoadBatchEnabled bool `mapstructure:"load_batch_enabled"`
 
@@ -734,6 +846,22 @@ type DatabaseConfig struct {
 	ConnMaxLifetimeMinutes int `mapstructure:"conn_max_lifetime_minutes"`
 	// ConnMaxIdleTimeMinutes: 空闲连接最大存活时间，及时释放不活跃连接
 	ConnMaxIdleTimeMinutes int `mapstructure:"conn_max_idle_time_minutes"`
+	// ReadReplicas: 只读副本（可选），仪表盘 / 运维 / 统计类只读查询路由到副本
+	ReadReplicas DatabaseReadReplicaConfig `mapstructure:"read_replicas"`
+}
+
+// DatabaseReadReplicaConfig 只读副本配置。
+// 副本复制延迟超过 MaxLagSeconds 或不可用时，只读查询自动回退到主库。
+type DatabaseReadReplicaConfig struct {
+	// DSNs: 副本连接串列表（key=value 或 postgres:// URL），为空表示不启用
+	DSNs []string `mapstructure:"dsns"`
+	// MaxLagSeconds: 允许的最大复制延迟（秒）
+	MaxLagSeconds int `mapstructure:"max_lag_seconds"`
+	// HealthCheckIntervalSeconds: 副本健康与延迟检测间隔（秒）
+	HealthCheckIntervalSeconds int `mapstructure:"health_check_interval_seconds"`
+	// MaxOpenConns / MaxIdleConns: 每个副本的连接池上限
+	MaxOpenConns int `mapstructure:"max_open_conns"`
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
 }
 
 func (d *DatabaseConfig) DSN() string {
@@ -954,6 +1082,139 @@ type UsageCleanupConfig struct {
 	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
 }
 
+// UsageExportConfig 使用记录导出任务配置
+type UsageExportConfig struct {
+	// Enabled: 是否启用导出任务执行器
+	Enabled bool `mapstructure:"enabled"`
+	// Storage: 导出文件存储位置（local/s3），s3 复用数据库备份的 S3 配置
+	Storage string `mapstructure:"storage"`
+	// LocalDir: 本地存储目录（storage=local 时使用，也用作 s3 上传前的临时目录）
+	LocalDir string `mapstructure:"local_dir"`
+	// MaxRangeDays: 单次导出允许的最大时间跨度（天）
+	MaxRangeDays int `mapstructure:"max_range_days"`
+	// BatchSize: 单批读取数量（keyset 分页）
+	BatchSize int `mapstructure:"batch_size"`
+	// WorkerIntervalSeconds: 后台任务轮询间隔（秒）
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"`
+	// TaskTimeoutSeconds: 单次任务最大执行时长（秒）
+	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
+	// RetentionHours: 导出文件保留时长（小时），过期后自动删除
+	RetentionHours int `mapstructure:"retention_hours"`
+	// MaxActiveJobsPerUser: 每个用户同时排队/执行中的任务上限
+	MaxActiveJobsPerUser int `mapstructure:"max_active_jobs_per_user"`
+}
+
+// UsageLogPartitionConfig usage_logs 月度分区维护与归档配置
+type UsageLogPartitionConfig struct {
+	// PremakeMonths: 提前创建的未来月份分区数量（不含当月）
+	PremakeMonths int `mapstructure:"premake_months"`
+	// MaintenanceIntervalMinutes: 分区维护（预创建/归档/删除）执行间隔（分钟）
+	MaintenanceIntervalMinutes int `mapstructure:"maintenance_interval_minutes"`
+	// RetentionDays: usage_logs 在线保留天数；0 表示沿用 dashboard_aggregation.retention.usage_logs_days
+	// （仅在预聚合启用时生效，与分区化之前的行为一致）
+	RetentionDays int `mapstructure:"retention_days"`
+	// Archive: 过期分区删除前的归档配置
+	Archive UsageLogArchiveConfig `mapstructure:"archive"`
+}
+
+// UsageLogArchiveConfig 过期 usage_logs 分区归档配置
+type UsageLogArchiveConfig struct {
+	// Enabled: 删除过期分区前是否先导出归档；关闭时直接删除
+	Enabled bool `mapstructure:"enabled"`
+	// Format: 归档格式（parquet/jsonl），jsonl 以 gzip 压缩
+	Format string `mapstructure:"format"`
+	// Storage: 归档文件存储位置（local/s3），s3 复用数据库备份的 S3 配置
+	Storage string `mapstructure:"storage"`
+	// LocalDir: 本地存储目录（storage=local 时使用，也用作 s3 上传/下载的临时目录）
+	LocalDir string `mapstructure:"local_dir"`
+	// BatchSize: 导出/导入时的单批行数
+	BatchSize int `mapstructure:"batch_size"`
+	// TaskTimeoutSeconds: 单次维护或导入的最大执行时长（秒）
+	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
+}
+
+// InvoiceConfig 月度账单（对账单）配置
+type InvoiceConfig struct {
+	// Enabled: 是否启用按月自动出账
+	Enabled bool `mapstructure:"enabled"`
+	// Schedule: 出账 cron 表达式（5 段），默认每月 1 日 00:30 结算上一个自然月
+	Schedule string `mapstructure:"schedule"`
+	// NumberPrefix: 账单编号前缀，编号格式为 <prefix>-<YYYY>-<6 位序号>
+	NumberPrefix string `mapstructure:"number_prefix"`
+	// Currency: 账单币种（仅用于展示）
+	Currency string `mapstructure:"currency"`
+	// IssuerName / IssuerAddress / IssuerEmail: 账单抬头中的开具方信息
+	IssuerName    string `mapstructure:"issuer_name"`
+	IssuerAddress string `mapstructure:"issuer_address"`
+	IssuerEmail   string `mapstructure:"issuer_email"`
+}
+
+// UserNotificationConfig 终端用户额度/余额提醒配置
+type UserNotificationConfig struct {
+	// Enabled: 是否启用用户提醒（余额不足、订阅用量、到期提醒等）
+	Enabled bool `mapstructure:"enabled"`
+	// ScanIntervalMinutes: 到期类提醒（订阅/API Key 即将到期）的扫描间隔（分钟）
+	ScanIntervalMinutes int `mapstructure:"scan_interval_minutes"`
+	// QueueSize: 扣费后待检查用户的队列容量，队列满时丢弃本次检查
+	QueueSize int `mapstructure:"queue_size"`
+	// WebhookTimeoutSeconds: 用户 Webhook 请求超时（秒）
+	WebhookTimeoutSeconds int `mapstructure:"webhook_timeout_seconds"`
+}
+
+// SubscriptionRenewalConfig 订阅自动续费配置
+type SubscriptionRenewalConfig struct {
+	// Enabled: 是否启用自动续费任务
+	Enabled bool `mapstructure:"enabled"`
+	// ScanIntervalMinutes: 扫描待续费订阅的间隔（分钟）
+	ScanIntervalMinutes int `mapstructure:"scan_interval_minutes"`
+	// LeadHours: 到期前多少小时开始尝试续费
+	LeadHours int `mapstructure:"lead_hours"`
+	// RetryIntervalMinutes: 余额不足等失败后，同一周期内两次尝试的最小间隔（分钟）
+	RetryIntervalMinutes int `mapstructure:"retry_interval_minutes"`
+	// GraceHours: 到期后仍继续重试的宽限时长（小时），超过后放弃本周期
+	GraceHours int `mapstructure:"grace_hours"`
+	// BatchSize: 单次扫描处理的订阅上限
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+// RequestCaptureConfig 请求/响应完整抓取配置（按分组或 API Key 开启，用于排障与合规审计）
+type RequestCaptureConfig struct {
+	// Enabled: 总开关；关闭时忽略后台配置的抓取策略
+	Enabled bool `mapstructure:"enabled"`
+	// RetentionDays: 抓取记录保留天数，过期后由清理任务删除
+	RetentionDays int `mapstructure:"retention_days"`
+	// MaxBodyBytes: 请求体/响应体各自的抓取上限（字节），超出部分截断
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+	// EncryptAtRest: 是否使用 totp.encryption_key 对抓取内容进行 AES-256-GCM 加密存储
+	EncryptAtRest bool `mapstructure:"encrypt_at_rest"`
+	// RedactSecrets: 是否脱敏 API Key、Bearer Token、私钥等敏感信息
+	RedactSecrets bool `mapstructure:"redact_secrets"`
+	// RedactEmails: 是否脱敏邮箱地址
+	RedactEmails bool `mapstructure:"redact_emails"`
+	// RedactPatterns: 额外的脱敏正则表达式
+	RedactPatterns []string `mapstructure:"redact_patterns"`
+	// QueueSize: 异步写入队列容量，队列满时丢弃本次抓取
+	QueueSize int `mapstructure:"queue_size"`
+	// CleanupIntervalMinutes: 过期记录清理间隔（分钟）
+	CleanupIntervalMinutes int `mapstructure:"cleanup_interval_minutes"`
+}
+
+// ContentModerationConfig 入站提示词内容审核配置（规则在管理后台按分组配置）
+type ContentModerationConfig struct {
+	// Enabled: 总开关；关闭时不执行任何审核规则
+	Enabled bool `mapstructure:"enabled"`
+	// FailOpen: 外部审核（OpenAI Moderation / HTTP Hook）调用失败时是否放行
+	FailOpen bool `mapstructure:"fail_open"`
+	// ExternalTimeoutSeconds: 外部审核请求超时（秒）
+	ExternalTimeoutSeconds int `mapstructure:"external_timeout_seconds"`
+	// MaxTextBytes: 参与审核的提示词文本上限（字节），超出时保留最新的部分
+	MaxTextBytes int `mapstructure:"max_text_bytes"`
+	// SuspendAfterViolations: 同一 API Key 在统计窗口内被拦截达到该次数后自动停用，0 表示不停用
+	SuspendAfterViolations int `mapstructure:"suspend_after_violations"`
+	// ViolationWindowHours: 自动停用的违规统计窗口（小时）
+	ViolationWindowHours int `mapstructure:"violation_window_hours"`
+}
+
 func NormalizeRunMode(value string) string {
 	normalized := strings.ToLower(strings.TrimSpace(value))
 	switch normalized {
@@ -1034,6 +1295,7 @@ func load(allowMissingJWTSecret bool) (*Config, error) {
 	cfg.LinuxDo.UserInfoUsernamePath = strings.TrimSpace(cfg.LinuxDo.UserInfoUsernamePath)
 	cfg.Dashboard.KeyPrefix = strings.TrimSpace(cfg.Dashboard.KeyPrefix)
 	cfg.CORS.AllowedOrigins = normalizeStringSlice(cfg.CORS.AllowedOrigins)
+	cfg.Database.ReadReplicas.DSNs = normalizeStringSlice(cfg.Database.ReadReplicas.DSNs)
 	cfg.Security.ResponseHeaders.AdditionalAllowed = normalizeStringSlice(cfg.Security.ResponseHeaders.AdditionalAllowed)
 	cfg.Security.ResponseHeaders.ForceRemove = normalizeStringSlice(cfg.Security.ResponseHeaders.ForceRemove)
 	cfg.Security.CSP.Policy = strings.TrimSpace(cfg.Security.CSP.Policy)
@@ -1071,6 +1333,9 @@ func load(allowMissingJWTSecret bool) (*Config, error) {
 	} else {
 		cfg.Totp.EncryptionKeyConfigured = true
 	}
+	if cfg.RequestCapture.Enabled && cfg.RequestCapture.EncryptAtRest && !cfg.Totp.EncryptionKeyConfigured {
+		slog.Warn("request_capture.encrypt_at_rest uses an auto-generated TOTP encryption key; captures become unreadable after restart. Set totp.encryption_key.")
+	}
 
 	originalJWTSecret := cfg.JWT.Secret
 	if allowMissingJWTSecret && originalJWTSecret == "" {
@@ -1213,6 +1478,11 @@ func setDefaults() {
 	viper.SetDefault("database.max_idle_conns", 128)
 	viper.SetDefault("database.conn_max_lifetime_minutes", 30)
 	viper.SetDefault("database.conn_max_idle_time_minutes", 5)
+	viper.SetDefault("database.read_replicas.dsns", []string{})
+	viper.SetDefault("database.read_replicas.max_lag_seconds", 30)
+	viper.SetDefault("database.read_replicas.health_check_interval_seconds", 10)
+	viper.SetDefault("database.read_replicas.max_open_conns", 64)
+	viper.SetDefault("database.read_replicas.max_idle_conns", 16)
 
 	// Redis
 	viper.SetDefault("redis.host", "localhost")
@@ -1271,6 +1541,7 @@ func setDefaults() {
 	viper.SetDefault("pricing.fallback_file", "./resources/model-pricing/model_prices_and_context_window.json")
 	viper.SetDefault("pricing.update_interval_hours", 24)
 	viper.SetDefault("pricing.hash_check_interval_minutes", 10)
+	viper.SetDefault("pricing.require_approval", true)
 
 	// Timezone (default to Asia/Shanghai for Chinese users)
 	viper.SetDefault("timezone", "Asia/Shanghai")
@@ -1314,6 +1585,70 @@ func setDefaults() {
 	viper.SetDefault("usage_cleanup.worker_interval_seconds", 10)
 	viper.SetDefault("usage_cleanup.task_timeout_seconds", 1800)
 
+	// Usage export task
+	viper.SetDefault("usage_export.enabled", true)
+	viper.SetDefault("usage_export.storage", "local")
+	viper.SetDefault("usage_export.local_dir", "./data/exports")
+	viper.SetDefault("usage_export.max_range_days", 93)
+	viper.SetDefault("usage_export.batch_size", 5000)
+	viper.SetDefault("usage_export.worker_interval_seconds", 10)
+	viper.SetDefault("usage_export.task_timeout_seconds", 3600)
+	viper.SetDefault("usage_export.retention_hours", 72)
+	viper.SetDefault("usage_export.max_active_jobs_per_user", 3)
+
+	// Usage log partitioning & archive
+	viper.SetDefault("usage_log_partition.premake_months", 2)
+	viper.SetDefault("usage_log_partition.maintenance_interval_minutes", 60)
+	viper.SetDefault("usage_log_partition.retention_days", 0)
+	viper.SetDefault("usage_log_partition.archive.enabled", true)
+	viper.SetDefault("usage_log_partition.archive.format", "parquet")
+	viper.SetDefault("usage_log_partition.archive.storage", "local")
+	viper.SetDefault("usage_log_partition.archive.local_dir", "./data/usage-archives")
+	viper.SetDefault("usage_log_partition.archive.batch_size", 5000)
+	viper.SetDefault("usage_log_partition.archive.task_timeout_seconds", 7200)
+
+	// Invoice
+	viper.SetDefault("invoice.enabled", false)
+	viper.SetDefault("invoice.schedule", "30 0 1 * *")
+	viper.SetDefault("invoice.number_prefix", "INV")
+	viper.SetDefault("invoice.currency", "USD")
+	viper.SetDefault("invoice.issuer_name", "")
+	viper.SetDefault("invoice.issuer_address", "")
+	viper.SetDefault("invoice.issuer_email", "")
+
+	// User notification
+	viper.SetDefault("user_notification.enabled", true)
+	viper.SetDefault("user_notification.scan_interval_minutes", 60)
+	viper.SetDefault("user_notification.queue_size", 1024)
+	viper.SetDefault("user_notification.webhook_timeout_seconds", 10)
+
+	// Subscription auto-renewal
+	viper.SetDefault("subscription_renewal.enabled", true)
+	viper.SetDefault("subscription_renewal.scan_interval_minutes", 10)
+	viper.SetDefault("subscription_renewal.lead_hours", 24)
+	viper.SetDefault("subscription_renewal.retry_interval_minutes", 360)
+	viper.SetDefault("subscription_renewal.grace_hours", 72)
+	viper.SetDefault("subscription_renewal.batch_size", 200)
+
+	// Request capture
+	viper.SetDefault("request_capture.enabled", false)
+	viper.SetDefault("request_capture.retention_days", 7)
+	viper.SetDefault("request_capture.max_body_bytes", 1<<20)
+	viper.SetDefault("request_capture.encrypt_at_rest", false)
+	viper.SetDefault("request_capture.redact_secrets", true)
+	viper.SetDefault("request_capture.redact_emails", true)
+	viper.SetDefault("request_capture.redact_patterns", []string{})
+	viper.SetDefault("request_capture.queue_size", 256)
+	viper.SetDefault("request_capture.cleanup_interval_minutes", 60)
+
+	// Content moderation
+	viper.SetDefault("content_moderation.enabled", false)
+	viper.SetDefault("content_moderation.fail_open", true)
+	viper.SetDefault("content_moderation.external_timeout_seconds", 5)
+	viper.SetDefault("content_moderation.max_text_bytes", 32<<10)
+	viper.SetDefault("content_moderation.suspend_after_violations", 0)
+	viper.SetDefault("content_moderation.violation_window_hours", 24)
+
 	// Idempotency
 	viper.SetDefault("idempotency.observe_only", true)
 	viper.SetDefault("idempotency.default_ttl_seconds", 86400)
@@ -1410,6 +1745,12 @@ func setDefaults() {
 	viper.SetDefault("gateway.scheduling.fallback_wait_timeout", 30*time.Second)
 	viper.SetDefault("gateway.scheduling.fallback_max_waiting", 100)
 	viper.SetDefault("gateway.scheduling.fallback_selection_mode", "last_used")
+	viper.SetDefault("gateway.scheduling.fair_queue_enabled", true)
+	viper.SetDefault("gateway.scheduling.fair_queue_principal", "user")
+	viper.SetDefault("gateway.scheduling.interactive_reserved_ratio", 0.2)
+	viper.SetDefault("gateway.scheduling.batch_load_threshold", 70)
+	viper.SetDefault("gateway.scheduling.batch_wait_timeout", 300*time.Second)
+	viper.SetDefault("gateway.scheduling.batch_max_waiting", 200)
 	viper.SetDefault("gateway.scheduling.load_batch_enabled", true)
 	viper.SetDefault("gateway.scheduling.slot_cleanup_interval", 30*time.Second)
 	viper.SetDefault("gateway.scheduling.db_fallback_enabled", true)
@@ -1437,6 +1778,9 @@ func setDefaults() {
 	viper.SetDefault("gateway.usage_record.auto_scale_cooldown_seconds", 10)
 	viper.SetDefault("gateway.user_group_rate_cache_ttl_seconds", 30)
 	viper.SetDefault("gateway.models_list_cache_ttl_seconds", 15)
+	viper.SetDefault("gateway.responses_compat.enabled", true)
+	viper.SetDefault("gateway.responses_compat.conversation_ttl_minutes", 1440)
+	viper.SetDefault("gateway.responses_compat.max_conversation_bytes", 4<<20)
 	// TLS指纹伪装配置（默认关闭，需要账号级别单独启用）
 	// 用户消息串行队列默认值
 	viper.SetDefault("gateway.user_message_queue.enabled", false)
@@ -1445,6 +1789,21 @@ func setDefaults() {
 	viper.SetDefault("gateway.user_message_queue.min_delay_ms", 200)
 	viper.SetDefault("gateway.user_message_queue.max_delay_ms", 2000)
 	viper.SetDefault("gateway.user_message_queue.cleanup_interval_seconds", 60)
+	viper.SetDefault("gateway.hedge.min_delay_ms", 300)
+	viper.SetDefault("gateway.hedge.max_delay_ms", 5000)
+	viper.SetDefault("gateway.hedge.default_delay_ms", 1500)
+	viper.SetDefault("gateway.hedge.stats_window_minutes", 15)
+	viper.SetDefault("gateway.hedge.max_tokens", 4096)
+	viper.SetDefault("gateway.circuit_breaker.enabled", false)
+	viper.SetDefault("gateway.circuit_breaker.window_seconds", 60)
+	viper.SetDefault("gateway.circuit_breaker.min_requests", 20)
+	viper.SetDefault("gateway.circuit_breaker.error_rate_threshold", 50)
+	viper.SetDefault("gateway.circuit_breaker.slow_call_threshold_ms", 0)
+	viper.SetDefault("gateway.circuit_breaker.slow_call_rate_threshold", 0)
+	viper.SetDefault("gateway.circuit_breaker.open_duration_seconds", 30)
+	viper.SetDefault("gateway.circuit_breaker.probe_timeout_seconds", 60)
+	viper.SetDefault("gateway.user_rate_limit_headers.enabled", false)
+	viper.SetDefault("gateway.user_rate_limit_headers.warning_threshold", 0.8)
 
 	viper.SetDefault("gateway.tls_fingerprint.enabled", true)
 	viper.SetDefault("concurrency.ping_interval", 10)
@@ -1702,6 +2061,20 @@ func (c *Config) Validate() error {
 	if c.Database.ConnMaxIdleTimeMinutes < 0 {
 		return fmt.Errorf("database.conn_max_idle_time_minutes must be non-negative")
 	}
+	if len(c.Database.ReadReplicas.DSNs) > 0 {
+		if c.Database.ReadReplicas.MaxLagSeconds <= 0 {
+			return fmt.Errorf("database.read_replicas.max_lag_seconds must be positive")
+		}
+		if c.Database.ReadReplicas.HealthCheckIntervalSeconds <= 0 {
+			return fmt.Errorf("database.read_replicas.health_check_interval_seconds must be positive")
+		}
+		if c.Database.ReadReplicas.MaxOpenConns <= 0 {
+			return fmt.Errorf("database.read_replicas.max_open_conns must be positive")
+		}
+		if c.Database.ReadReplicas.MaxIdleConns < 0 || c.Database.ReadReplicas.MaxIdleConns > c.Database.ReadReplicas.MaxOpenConns {
+			return fmt.Errorf("database.read_replicas.max_idle_conns must be between 0 and max_open_conns")
+		}
+	}
 	if c.Redis.DialTimeoutSeconds <= 0 {
 		return fmt.Errorf("redis.dial_timeout_seconds must be positive")
 	}
@@ -1833,6 +2206,125 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("usage_cleanup.task_timeout_seconds must be non-negative")
 		}
 	}
+	if c.UsageExport.Enabled {
+		switch strings.ToLower(strings.TrimSpace(c.UsageExport.Storage)) {
+		case "", "local", "s3":
+		default:
+			return fmt.Errorf("usage_export.storage must be one of: local, s3")
+		}
+		if c.UsageExport.BatchSize <= 0 {
+			return fmt.Errorf("usage_export.batch_size must be positive")
+		}
+		if c.UsageExport.WorkerIntervalSeconds <= 0 {
+			return fmt.Errorf("usage_export.worker_interval_seconds must be positive")
+		}
+		if c.UsageExport.TaskTimeoutSeconds <= 0 {
+			return fmt.Errorf("usage_export.task_timeout_seconds must be positive")
+		}
+	}
+	if c.UsageExport.MaxRangeDays < 0 {
+		return fmt.Errorf("usage_export.max_range_days must be non-negative")
+	}
+	if c.UsageExport.RetentionHours < 0 {
+		return fmt.Errorf("usage_export.retention_hours must be non-negative")
+	}
+	if c.UsageExport.MaxActiveJobsPerUser < 0 {
+		return fmt.Errorf("usage_export.max_active_jobs_per_user must be non-negative")
+	}
+	if c.UsageLogPartition.PremakeMonths < 0 {
+		return fmt.Errorf("usage_log_partition.premake_months must be non-negative")
+	}
+	if c.UsageLogPartition.MaintenanceIntervalMinutes < 0 {
+		return fmt.Errorf("usage_log_partition.maintenance_interval_minutes must be non-negative")
+	}
+	if c.UsageLogPartition.RetentionDays < 0 {
+		return fmt.Errorf("usage_log_partition.retention_days must be non-negative")
+	}
+	if c.UsageLogPartition.Archive.Enabled {
+		switch strings.ToLower(strings.TrimSpace(c.UsageLogPartition.Archive.Format)) {
+		case "", "parquet", "jsonl":
+		default:
+			return fmt.Errorf("usage_log_partition.archive.format must be one of: parquet, jsonl")
+		}
+		switch strings.ToLower(strings.TrimSpace(c.UsageLogPartition.Archive.Storage)) {
+		case "", "local", "s3":
+		default:
+			return fmt.Errorf("usage_log_partition.archive.storage must be one of: local, s3")
+		}
+		if c.UsageLogPartition.Archive.BatchSize < 0 {
+			return fmt.Errorf("usage_log_partition.archive.batch_size must be non-negative")
+		}
+		if c.UsageLogPartition.Archive.TaskTimeoutSeconds < 0 {
+			return fmt.Errorf("usage_log_partition.archive.task_timeout_seconds must be non-negative")
+		}
+	}
+	if c.Invoice.Enabled && strings.TrimSpace(c.Invoice.Schedule) == "" {
+		return fmt.Errorf("invoice.schedule is required when invoice.enabled=true")
+	}
+	if len(strings.TrimSpace(c.Invoice.NumberPrefix)) > 16 {
+		return fmt.Errorf("invoice.number_prefix must be at most 16 characters")
+	}
+	if c.UserNotification.Enabled {
+		if c.UserNotification.ScanIntervalMinutes <= 0 {
+			return fmt.Errorf("user_notification.scan_interval_minutes must be positive")
+		}
+		if c.UserNotification.QueueSize <= 0 {
+			return fmt.Errorf("user_notification.queue_size must be positive")
+		}
+		if c.UserNotification.WebhookTimeoutSeconds <= 0 {
+			return fmt.Errorf("user_notification.webhook_timeout_seconds must be positive")
+		}
+	}
+	if c.SubscriptionRenewal.Enabled {
+		if c.SubscriptionRenewal.ScanIntervalMinutes <= 0 {
+			return fmt.Errorf("subscription_renewal.scan_interval_minutes must be positive")
+		}
+		if c.SubscriptionRenewal.LeadHours < 0 {
+			return fmt.Errorf("subscription_renewal.lead_hours must be non-negative")
+		}
+		if c.SubscriptionRenewal.RetryIntervalMinutes <= 0 {
+			return fmt.Errorf("subscription_renewal.retry_interval_minutes must be positive")
+		}
+		if c.SubscriptionRenewal.GraceHours < 0 {
+			return fmt.Errorf("subscription_renewal.grace_hours must be non-negative")
+		}
+		if c.SubscriptionRenewal.BatchSize <= 0 {
+			return fmt.Errorf("subscription_renewal.batch_size must be positive")
+		}
+	}
+	if c.RequestCapture.Enabled {
+		if c.RequestCapture.RetentionDays <= 0 {
+			return fmt.Errorf("request_capture.retention_days must be positive")
+		}
+		if c.RequestCapture.MaxBodyBytes <= 0 {
+			return fmt.Errorf("request_capture.max_body_bytes must be positive")
+		}
+		if c.RequestCapture.QueueSize <= 0 {
+			return fmt.Errorf("request_capture.queue_size must be positive")
+		}
+		if c.RequestCapture.CleanupIntervalMinutes <= 0 {
+			return fmt.Errorf("request_capture.cleanup_interval_minutes must be positive")
+		}
+		for _, pattern := range c.RequestCapture.RedactPatterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("request_capture.redact_patterns: invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	if c.ContentModeration.Enabled {
+		if c.ContentModeration.ExternalTimeoutSeconds <= 0 {
+			return fmt.Errorf("content_moderation.external_timeout_seconds must be positive")
+		}
+		if c.ContentModeration.MaxTextBytes <= 0 {
+			return fmt.Errorf("content_moderation.max_text_bytes must be positive")
+		}
+		if c.ContentModeration.SuspendAfterViolations < 0 {
+			return fmt.Errorf("content_moderation.suspend_after_violations must be non-negative")
+		}
+		if c.ContentModeration.ViolationWindowHours <= 0 {
+			return fmt.Errorf("content_moderation.violation_window_hours must be positive")
+		}
+	}
 	if c.Idempotency.DefaultTTLSeconds <= 0 {
 		return fmt.Errorf("idempotency.default_ttl_seconds must be positive")
 	}
@@ -1854,6 +2346,27 @@ func (c *Config) Validate() error {
 	if c.Idempotency.CleanupBatchSize <= 0 {
 		return fmt.Errorf("idempotency.cleanup_batch_size must be positive")
 	}
+	seenPricingSources := make(map[string]struct{}, len(c.Pricing.Sources))
+	for i, src := range c.Pricing.Sources {
+		name := strings.TrimSpace(src.Name)
+		if name == "" {
+			return fmt.Errorf("pricing.sources[%d].name is required", i)
+		}
+		if _, ok := seenPricingSources[name]; ok {
+			return fmt.Errorf("pricing.sources[%d].name %q is duplicated", i, name)
+		}
+		seenPricingSources[name] = struct{}{}
+		switch src.Type {
+		case "litellm", "openrouter", "yaml":
+		default:
+			return fmt.Errorf("pricing.sources[%d].type must be one of: litellm/openrouter/yaml", i)
+		}
+		hasURL := strings.TrimSpace(src.URL) != ""
+		hasPath := strings.TrimSpace(src.Path) != ""
+		if hasURL == hasPath {
+			return fmt.Errorf("pricing.sources[%d] requires exactly one of url/path", i)
+		}
+	}
 	if c.Gateway.MaxBodySize <= 0 {
 		return fmt.Errorf("gateway.max_body_size must be positive")
 	}
@@ -2173,6 +2686,12 @@ func (c *Config) Validate() error {
 	if c.Gateway.ModelsListCacheTTLSeconds < 10 || c.Gateway.ModelsListCacheTTLSeconds > 30 {
 		return fmt.Errorf("gateway.models_list_cache_ttl_seconds must be between 10-30")
 	}
+	if c.Gateway.ResponsesCompat.ConversationTTLMinutes < 0 {
+		return fmt.Errorf("gateway.responses_compat.conversation_ttl_minutes must be non-negative")
+	}
+	if c.Gateway.ResponsesCompat.MaxConversationBytes < 0 {
+		return fmt.Errorf("gateway.responses_compat.max_conversation_bytes must be non-negative")
+	}
 	if c.Gateway.Scheduling.StickySessionMaxWaiting <= 0 {
 		return fmt.Errorf("gateway.scheduling.sticky_session_max_waiting must be positive")
 	}
@@ -2185,6 +2704,49 @@ func (c *Config) Validate() error {
 	if c.Gateway.Scheduling.FallbackMaxWaiting <= 0 {
 		return fmt.Errorf("gateway.scheduling.fallback_max_waiting must be positive")
 	}
+	switch c.Gateway.Scheduling.FairQueuePrincipal {
+	case "", "user", "api_key":
+	default:
+		return fmt.Errorf("gateway.scheduling.fair_queue_principal must be one of: user/api_key")
+	}
+	if r := c.Gateway.Scheduling.InteractiveReservedRatio; r < 0 || r >= 1 {
+		return fmt.Errorf("gateway.scheduling.interactive_reserved_ratio must be in [0, 1)")
+	}
+	if t := c.Gateway.Scheduling.BatchLoadThreshold; t <= 0 || t > 100 {
+		return fmt.Errorf("gateway.scheduling.batch_load_threshold must be between 1 and 100")
+	}
+	if c.Gateway.Scheduling.BatchWaitTimeout <= 0 {
+		return fmt.Errorf("gateway.scheduling.batch_wait_timeout must be positive")
+	}
+	if c.Gateway.Scheduling.BatchMaxWaiting <= 0 {
+		return fmt.Errorf("gateway.scheduling.batch_max_waiting must be positive")
+	}
+	if cb := c.Gateway.CircuitBreaker; cb.Enabled {
+		if cb.WindowSeconds <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.window_seconds must be positive")
+		}
+		if cb.MinRequests <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.min_requests must be positive")
+		}
+		if cb.ErrorRateThreshold <= 0 || cb.ErrorRateThreshold > 100 {
+			return fmt.Errorf("gateway.circuit_breaker.error_rate_threshold must be between 1 and 100")
+		}
+		if cb.SlowCallThresholdMs < 0 {
+			return fmt.Errorf("gateway.circuit_breaker.slow_call_threshold_ms must be non-negative")
+		}
+		if cb.SlowCallRateThreshold < 0 || cb.SlowCallRateThreshold > 100 {
+			return fmt.Errorf("gateway.circuit_breaker.slow_call_rate_threshold must be between 0 and 100")
+		}
+		if cb.OpenDurationSeconds <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.open_duration_seconds must be positive")
+		}
+		if cb.ProbeTimeoutSeconds <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.probe_timeout_seconds must be positive")
+		}
+	}
+	if rl := c.Gateway.UserRateLimitHeaders; rl.Enabled && (rl.WarningThreshold <= 0 || rl.WarningThreshold > 1) {
+		return fmt.Errorf("gateway.user_rate_limit_headers.warning_threshold must be between 0 and 1")
+	}
 	if c.Gateway.Scheduling.SlotCleanupInterval < 0 {
 		return fmt.Errorf("gateway.scheduling.slot_cleanup_interval must be non-negative")
 	}
@@ -0,0 +1,8 @@
+package domain
+
+// ModelFallbackTarget 模型降级链中的一个目标。
+// GroupID 为空表示在当前分组内改用 Model；非空表示切换到指定分组并改用 Model。
+type ModelFallbackTarget struct {
+	Model   string `json:"model"`
+	GroupID *int64 `json:"group_id,omitempty"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+// RequestTransformRule 分组请求改写规则，按配置顺序在转发前执行。
+// Path 使用 gjson/sjson 路径语法（如 "max_tokens"、"thinking.type"、"metadata.user_id"）。
+type RequestTransformRule struct {
+	Name     string `json:"name,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+	// Models 仅对匹配的请求模型生效（支持 * 通配），为空表示所有模型
+	Models []string `json:"models,omitempty"`
+	Action string   `json:"action"`
+	Path   string   `json:"path,omitempty"`
+	// Value set 的 JSON 值；prepend_system / set_header 的字符串
+	Value any `json:"value,omitempty"`
+	// Min / Max clamp 的上下限（至少设置一个）
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Match remove_items 的匹配条件：数组元素的字段 -> 期望值（字符串支持 * 通配）
+	Match  map[string]any `json:"match,omitempty"`
+	Header string         `json:"header,omitempty"`
+}
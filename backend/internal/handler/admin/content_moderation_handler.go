@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentModerationHandler handles admin content moderation rules and violations
+type ContentModerationHandler struct {
+	moderationService *service.ContentModerationService
+}
+
+// NewContentModerationHandler creates a new admin ContentModerationHandler
+func NewContentModerationHandler(moderationService *service.ContentModerationService) *ContentModerationHandler {
+	return &ContentModerationHandler{moderationService: moderationService}
+}
+
+// ContentModerationRuleRequest creates a rule, or partially updates one (omitted fields are kept)
+type ContentModerationRuleRequest struct {
+	Name            *string  `json:"name"`
+	Enabled         *bool    `json:"enabled"`
+	Priority        *int     `json:"priority"`
+	Kind            *string  `json:"kind"`
+	Action          *string  `json:"action"`
+	GroupIDs        []int64  `json:"group_ids"`
+	Patterns        []string `json:"patterns"`
+	CaseSensitive   *bool    `json:"case_sensitive"`
+	AccountID       *int64   `json:"account_id"`
+	ModerationModel *string  `json:"moderation_model"`
+	Categories      []string `json:"categories"`
+	WebhookURL      *string  `json:"webhook_url"`
+	WebhookSecret   *string  `json:"webhook_secret"`
+	RefusalMessage  *string  `json:"refusal_message"`
+	Description     *string  `json:"description"`
+}
+
+func (req *ContentModerationRuleRequest) applyTo(rule *service.ContentModerationRule) {
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.Kind != nil {
+		rule.Kind = strings.TrimSpace(*req.Kind)
+	}
+	if req.Action != nil {
+		rule.Action = strings.TrimSpace(*req.Action)
+	}
+	if req.GroupIDs != nil {
+		rule.GroupIDs = req.GroupIDs
+	}
+	if req.Patterns != nil {
+		rule.Patterns = req.Patterns
+	}
+	if req.CaseSensitive != nil {
+		rule.CaseSensitive = *req.CaseSensitive
+	}
+	if req.AccountID != nil {
+		rule.AccountID = req.AccountID
+	}
+	if req.ModerationModel != nil {
+		rule.ModerationModel = strings.TrimSpace(*req.ModerationModel)
+	}
+	if req.Categories != nil {
+		rule.Categories = req.Categories
+	}
+	if req.WebhookURL != nil {
+		rule.WebhookURL = strings.TrimSpace(*req.WebhookURL)
+	}
+	if req.WebhookSecret != nil {
+		rule.WebhookSecret = *req.WebhookSecret
+	}
+	if req.RefusalMessage != nil {
+		rule.RefusalMessage = strings.TrimSpace(*req.RefusalMessage)
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+}
+
+// ListRules handles listing all moderation rules
+// GET /api/v1/admin/content-moderation/rules
+func (h *ContentModerationHandler) ListRules(c *gin.Context) {
+	rules, err := h.moderationService.ListRules(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"rules":              rules,
+		"moderation_enabled": h.moderationService.Enabled(),
+	})
+}
+
+// GetRule handles getting a moderation rule
+// GET /api/v1/admin/content-moderation/rules/:id
+func (h *ContentModerationHandler) GetRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid rule ID")
+		return
+	}
+	rule, err := h.moderationService.GetRule(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, rule)
+}
+
+// CreateRule handles creating a moderation rule
+// POST /api/v1/admin/content-moderation/rules
+func (h *ContentModerationHandler) CreateRule(c *gin.Context) {
+	var req ContentModerationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rule := &service.ContentModerationRule{
+		Enabled: true,
+		Action:  service.ContentModerationActionBlock,
+	}
+	req.applyTo(rule)
+	created, err := h.moderationService.CreateRule(c.Request.Context(), rule)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, created)
+}
+
+// UpdateRule handles partially updating a moderation rule
+// PUT /api/v1/admin/content-moderation/rules/:id
+func (h *ContentModerationHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid rule ID")
+		return
+	}
+	var req ContentModerationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rule, err := h.moderationService.GetRule(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	req.applyTo(rule)
+	updated, err := h.moderationService.UpdateRule(c.Request.Context(), rule)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, updated)
+}
+
+// DeleteRule handles deleting a moderation rule
+// DELETE /api/v1/admin/content-moderation/rules/:id
+func (h *ContentModerationHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid rule ID")
+		return
+	}
+	if err := h.moderationService.DeleteRule(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Rule deleted successfully"})
+}
+
+// ListViolations handles listing moderation violations
+// GET /api/v1/admin/content-moderation/violations
+func (h *ContentModerationHandler) ListViolations(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	var filter service.ContentModerationViolationFilter
+	for name, dest := range map[string]**int64{
+		"user_id":    &filter.UserID,
+		"api_key_id": &filter.APIKeyID,
+		"group_id":   &filter.GroupID,
+	} {
+		v := strings.TrimSpace(c.Query(name))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid "+name)
+			return
+		}
+		*dest = &id
+	}
+	switch action := strings.TrimSpace(c.Query("action")); action {
+	case "", service.ContentModerationActionBlock, service.ContentModerationActionFlag:
+		filter.Action = action
+	default:
+		response.BadRequest(c, "Invalid action, use block or flag")
+		return
+	}
+
+	userTZ := c.Query("timezone")
+	if v := c.Query("start_date"); v != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", v, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+			return
+		}
+		filter.StartTime = &t
+	}
+	if v := c.Query("end_date"); v != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", v, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+			return
+		}
+		end := t.AddDate(0, 0, 1)
+		filter.EndTime = &end
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	violations, result, err := h.moderationService.ListViolations(c.Request.Context(), filter, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, violations, result.Total, page, pageSize)
+}
+
+// ListUserStats handles listing per-user violation counters over the last N days
+// GET /api/v1/admin/content-moderation/violations/users?days=30
+func (h *ContentModerationHandler) ListUserStats(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	days := 30
+	if v := strings.TrimSpace(c.Query("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 365 {
+			response.BadRequest(c, "Invalid days, must be between 1 and 365")
+			return
+		}
+		days = n
+	}
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	stats, result, err := h.moderationService.ListUserStats(c.Request.Context(), days, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, stats, result.Total, page, pageSize)
+}
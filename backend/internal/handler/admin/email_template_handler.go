@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailTemplateHandler handles admin email template management
+type EmailTemplateHandler struct {
+	templateService *service.EmailTemplateService
+	emailService    *service.EmailService
+}
+
+// NewEmailTemplateHandler creates a new admin EmailTemplateHandler
+func NewEmailTemplateHandler(templateService *service.EmailTemplateService, emailService *service.EmailService) *EmailTemplateHandler {
+	return &EmailTemplateHandler{
+		templateService: templateService,
+		emailService:    emailService,
+	}
+}
+
+// EmailTemplateRequest is the template content for saving or previewing
+type EmailTemplateRequest struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body"`
+	// TextBody is the plaintext alternative; empty derives it from the HTML body
+	TextBody string `json:"text_body"`
+}
+
+// EmailTemplatePreviewRequest renders a draft (when subject/html_body are set) or the stored template with sample data
+type EmailTemplatePreviewRequest struct {
+	Locale string `json:"locale"`
+	EmailTemplateRequest
+}
+
+// EmailTemplateTestSendRequest sends the preview to a mailbox
+type EmailTemplateTestSendRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	EmailTemplatePreviewRequest
+}
+
+func (req *EmailTemplatePreviewRequest) draft() *service.EmailTemplate {
+	if strings.TrimSpace(req.Subject) == "" && strings.TrimSpace(req.HTMLBody) == "" && strings.TrimSpace(req.TextBody) == "" {
+		return nil
+	}
+	return &service.EmailTemplate{
+		Subject:  req.Subject,
+		HTMLBody: req.HTMLBody,
+		TextBody: req.TextBody,
+	}
+}
+
+// List handles listing template types, their locales and the default locale
+// GET /api/v1/admin/email-templates
+func (h *EmailTemplateHandler) List(c *gin.Context) {
+	templates, err := h.templateService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"templates":      templates,
+		"default_locale": h.templateService.GetDefaultLocale(c.Request.Context()),
+	})
+}
+
+// UpdateDefaultLocale handles setting the locale used when a user has no preference
+// PUT /api/v1/admin/email-templates/default-locale
+func (h *EmailTemplateHandler) UpdateDefaultLocale(c *gin.Context) {
+	var req struct {
+		Locale string `json:"locale" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if err := h.templateService.SetDefaultLocale(c.Request.Context(), req.Locale); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"default_locale": h.templateService.GetDefaultLocale(c.Request.Context())})
+}
+
+// Get handles getting the effective template of a type and locale
+// GET /api/v1/admin/email-templates/:key/:locale
+func (h *EmailTemplateHandler) Get(c *gin.Context) {
+	tpl, err := h.templateService.Get(c.Request.Context(), c.Param("key"), c.Param("locale"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, tpl)
+}
+
+// Update handles saving a template override
+// PUT /api/v1/admin/email-templates/:key/:locale
+func (h *EmailTemplateHandler) Update(c *gin.Context) {
+	var req EmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	tpl, err := h.templateService.Save(c.Request.Context(), &service.EmailTemplate{
+		Key:      c.Param("key"),
+		Locale:   c.Param("locale"),
+		Subject:  req.Subject,
+		HTMLBody: req.HTMLBody,
+		TextBody: req.TextBody,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, tpl)
+}
+
+// Reset handles deleting a template override, restoring the built-in template
+// DELETE /api/v1/admin/email-templates/:key/:locale
+func (h *EmailTemplateHandler) Reset(c *gin.Context) {
+	if err := h.templateService.Reset(c.Request.Context(), c.Param("key"), c.Param("locale")); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Template reset to built-in"})
+}
+
+// Preview handles rendering a template with sample data
+// POST /api/v1/admin/email-templates/:key/preview
+func (h *EmailTemplateHandler) Preview(c *gin.Context) {
+	var req EmailTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rendered, err := h.templateService.Preview(c.Request.Context(), c.Param("key"), req.Locale, req.draft())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, rendered)
+}
+
+// TestSend handles rendering a template with sample data and sending it
+// POST /api/v1/admin/email-templates/:key/test-send
+func (h *EmailTemplateHandler) TestSend(c *gin.Context) {
+	var req EmailTemplateTestSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rendered, err := h.templateService.Preview(c.Request.Context(), c.Param("key"), req.Locale, req.draft())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if err := h.emailService.SendRenderedEmail(c.Request.Context(), req.Email, rendered); err != nil {
+		response.BadRequest(c, "Failed to send test email: "+err.Error())
+		return
+	}
+	response.Success(c, gin.H{"message": "Test email sent successfully"})
+}
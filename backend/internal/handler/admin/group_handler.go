@@ -106,6 +106,10 @@ type CreateGroupRequest struct {
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 	MCPXMLInject        *bool              `json:"mcp_xml_inject"`
+	// 模型降级链（pattern -> 有序降级目标）
+	ModelFallbacks map[string][]service.ModelFallbackTarget `json:"model_fallbacks"`
+	// 请求改写规则（按顺序在转发前执行）
+	RequestTransforms []service.RequestTransformRule `json:"request_transforms"`
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string `json:"supported_model_scopes"`
 	// Sora 存储配额
@@ -113,6 +117,18 @@ type CreateGroupRequest struct {
 	// OpenAI Messages 调度配置（仅 openai 平台使用）
 	AllowMessagesDispatch bool   `json:"allow_messages_dispatch"`
 	DefaultMappedModel    string `json:"default_mapped_model"`
+	// 订阅售价（从余额扣除，负数或不传表示不可购买/续费）与续费周期（天，0 表示使用默认有效期）
+	Price             *float64 `json:"price"`
+	RenewalPeriodDays int      `json:"renewal_period_days"`
+	// 公平排队权重（0 表示默认 1）与优先级档位（0-9，数值越大越优先）
+	QueueWeight   int `json:"queue_weight"`
+	QueuePriority int `json:"queue_priority"`
+	// 账号调度策略（空表示默认，cost_aware 表示成本感知）
+	SchedulingStrategy string `json:"scheduling_strategy" binding:"omitempty,oneof=cost_aware"`
+	// 对冲请求配置（仅非流式请求生效）
+	HedgeEnabled      bool   `json:"hedge_enabled"`
+	HedgePercentile   int    `json:"hedge_percentile"`
+	HedgeLoserBilling string `json:"hedge_loser_billing" binding:"omitempty,oneof=ignore bill"`
 	// 从指定分组复制账号（创建后自动绑定）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -144,6 +160,10 @@ type UpdateGroupRequest struct {
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled *bool              `json:"model_routing_enabled"`
 	MCPXMLInject        *bool              `json:"mcp_xml_inject"`
+	// 模型降级链（pattern -> 有序降级目标，传 {} 清空）
+	ModelFallbacks map[string][]service.ModelFallbackTarget `json:"model_fallbacks"`
+	// 请求改写规则（按顺序在转发前执行）
+	RequestTransforms []service.RequestTransformRule `json:"request_transforms"`
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes *[]string `json:"supported_model_scopes"`
 	// Sora 存储配额
@@ -151,6 +171,18 @@ type UpdateGroupRequest struct {
 	// OpenAI Messages 调度配置（仅 openai 平台使用）
 	AllowMessagesDispatch *bool   `json:"allow_messages_dispatch"`
 	DefaultMappedModel    *string `json:"default_mapped_model"`
+	// 订阅售价（负数表示清除）与续费周期（天）
+	Price             *float64 `json:"price"`
+	RenewalPeriodDays *int     `json:"renewal_period_days"`
+	// 公平排队权重与优先级档位
+	QueueWeight   *int `json:"queue_weight"`
+	QueuePriority *int `json:"queue_priority"`
+	// 账号调度策略（传空字符串恢复默认）
+	SchedulingStrategy *string `json:"scheduling_strategy"`
+	// 对冲请求配置
+	HedgeEnabled      *bool   `json:"hedge_enabled"`
+	HedgePercentile   *int    `json:"hedge_percentile"`
+	HedgeLoserBilling *string `json:"hedge_loser_billing"`
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -263,11 +295,21 @@ func (h *GroupHandler) Create(c *gin.Context) {
 		FallbackGroupIDOnInvalidRequest: req.FallbackGroupIDOnInvalidRequest,
 		ModelRouting:                    req.ModelRouting,
 		ModelRoutingEnabled:             req.ModelRoutingEnabled,
+		ModelFallbacks:                  req.ModelFallbacks,
+		RequestTransforms:               req.RequestTransforms,
 		MCPXMLInject:                    req.MCPXMLInject,
 		SupportedModelScopes:            req.SupportedModelScopes,
 		SoraStorageQuotaBytes:           req.SoraStorageQuotaBytes,
 		AllowMessagesDispatch:           req.AllowMessagesDispatch,
 		DefaultMappedModel:              req.DefaultMappedModel,
+		Price:                           req.Price,
+		RenewalPeriodDays:               req.RenewalPeriodDays,
+		QueueWeight:                     req.QueueWeight,
+		QueuePriority:                   req.QueuePriority,
+		SchedulingStrategy:              req.SchedulingStrategy,
+		HedgeEnabled:                    req.HedgeEnabled,
+		HedgePercentile:                 req.HedgePercentile,
+		HedgeLoserBilling:               req.HedgeLoserBilling,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -316,11 +358,21 @@ func (h *GroupHandler) Update(c *gin.Context) {
 		FallbackGroupIDOnInvalidRequest: req.FallbackGroupIDOnInvalidRequest,
 		ModelRouting:                    req.ModelRouting,
 		ModelRoutingEnabled:             req.ModelRoutingEnabled,
+		ModelFallbacks:                  req.ModelFallbacks,
+		RequestTransforms:               req.RequestTransforms,
 		MCPXMLInject:                    req.MCPXMLInject,
 		SupportedModelScopes:            req.SupportedModelScopes,
 		SoraStorageQuotaBytes:           req.SoraStorageQuotaBytes,
 		AllowMessagesDispatch:           req.AllowMessagesDispatch,
 		DefaultMappedModel:              req.DefaultMappedModel,
+		Price:                           req.Price,
+		RenewalPeriodDays:               req.RenewalPeriodDays,
+		QueueWeight:                     req.QueueWeight,
+		QueuePriority:                   req.QueuePriority,
+		SchedulingStrategy:              req.SchedulingStrategy,
+		HedgeEnabled:                    req.HedgeEnabled,
+		HedgePercentile:                 req.HedgePercentile,
+		HedgeLoserBilling:               req.HedgeLoserBilling,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -517,3 +569,55 @@ func (h *GroupHandler) UpdateSortOrder(c *gin.Context) {
 
 	response.Success(c, gin.H{"message": "Sort order updated successfully"})
 }
+
+// RequestTransformDryRunRequest represents the request to test transform rules against a sample body
+type RequestTransformDryRunRequest struct {
+	Rules []service.RequestTransformRule `json:"rules" binding:"required"`
+	Body  json.RawMessage                `json:"body" binding:"required"`
+	// Format 样例请求体格式（anthropic / openai_responses / openai_chat / gemini），默认 anthropic
+	Format string `json:"format"`
+	// Model 规则匹配所用模型，为空时取请求体 model 字段（Gemini 原生请求需填写）
+	Model string `json:"model"`
+}
+
+// DryRunRequestTransforms applies transform rules to a sample request body without forwarding it
+// POST /api/v1/admin/groups/request-transforms/dry-run
+func (h *GroupHandler) DryRunRequestTransforms(c *gin.Context) {
+	var req RequestTransformDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	body := bytes.TrimSpace(req.Body)
+	if len(body) == 0 || body[0] != '{' {
+		response.BadRequest(c, "Invalid request: body must be a JSON object")
+		return
+	}
+
+	format := strings.TrimSpace(req.Format)
+	if format == "" {
+		format = service.RequestTransformFormatAnthropic
+	}
+	if !service.IsRequestTransformFormat(format) {
+		response.BadRequest(c, "Invalid request: unsupported format "+format)
+		return
+	}
+
+	rules, err := service.NormalizeRequestTransforms(req.Rules)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	result := service.ApplyRequestTransformsWithFormat(body, rules, format, strings.TrimSpace(req.Model))
+	headers := result.Headers
+	if headers == nil {
+		headers = []service.RequestHeaderTransform{}
+	}
+	response.Success(c, gin.H{
+		"body":         json.RawMessage(result.Body),
+		"body_changed": result.BodyChanged,
+		"headers":      headers,
+		"trace":        result.Trace,
+	})
+}
@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceHandler handles admin invoice management
+type InvoiceHandler struct {
+	invoiceService *service.InvoiceService
+}
+
+// NewInvoiceHandler creates a new admin InvoiceHandler
+func NewInvoiceHandler(invoiceService *service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: invoiceService}
+}
+
+// GenerateInvoicesRequest closes a billing month for one user or for all users
+type GenerateInvoicesRequest struct {
+	// Month in YYYY-MM (server timezone)
+	Month  string `json:"month" binding:"required"`
+	UserID *int64 `json:"user_id"`
+}
+
+// List handles listing invoices
+// GET /api/v1/admin/invoices
+func (h *InvoiceHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	var filter service.InvoiceListFilter
+	if v := strings.TrimSpace(c.Query("user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filter.UserID = &id
+	}
+	if v := strings.TrimSpace(c.Query("month")); v != "" {
+		month, err := timezone.ParseInLocation("2006-01", v)
+		if err != nil {
+			response.BadRequest(c, "Invalid month format, use YYYY-MM")
+			return
+		}
+		start, _ := service.InvoiceMonthPeriod(month)
+		filter.PeriodStart = &start
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	invoices, result, err := h.invoiceService.List(c.Request.Context(), filter, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, invoices, result.Total, page, pageSize)
+}
+
+// GetByID handles getting an invoice
+// GET /api/v1/admin/invoices/:id
+func (h *InvoiceHandler) GetByID(c *gin.Context) {
+	invoice, ok := h.loadInvoice(c)
+	if !ok {
+		return
+	}
+	response.Success(c, gin.H{
+		"invoice":  invoice,
+		"verified": service.VerifyInvoiceContent(invoice),
+	})
+}
+
+// Download handles downloading an invoice as HTML
+// GET /api/v1/admin/invoices/:id/download
+func (h *InvoiceHandler) Download(c *gin.Context) {
+	invoice, ok := h.loadInvoice(c)
+	if !ok {
+		return
+	}
+	body, err := h.invoiceService.RenderHTML(invoice)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	disposition := "attachment"
+	if c.Query("inline") == "1" || c.Query("inline") == "true" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, service.InvoiceFileName(invoice)))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+}
+
+// Generate handles closing a billing month (idempotent: existing invoices are kept as-is)
+// POST /api/v1/admin/invoices/generate
+func (h *InvoiceHandler) Generate(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+	var req GenerateInvoicesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	month, err := timezone.ParseInLocation("2006-01", strings.TrimSpace(req.Month))
+	if err != nil {
+		response.BadRequest(c, "Invalid month format, use YYYY-MM")
+		return
+	}
+
+	executeAdminIdempotentJSON(c, "admin.invoices.generate", req, service.DefaultWriteIdempotencyTTL(), func(ctx context.Context) (any, error) {
+		if req.UserID != nil && *req.UserID > 0 {
+			start, end := service.InvoiceMonthPeriod(month)
+			invoice, created, err := h.invoiceService.GenerateForUser(ctx, *req.UserID, start, end)
+			if err != nil {
+				return nil, err
+			}
+			return gin.H{"invoice": invoice, "created": created}, nil
+		}
+		return h.invoiceService.CloseMonth(ctx, month)
+	})
+}
+
+func (h *InvoiceHandler) loadInvoice(c *gin.Context) (*service.Invoice, bool) {
+	invoiceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || invoiceID <= 0 {
+		response.BadRequest(c, "Invalid invoice ID")
+		return nil, false
+	}
+	invoice, err := h.invoiceService.Get(c.Request.Context(), invoiceID, 0)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return nil, false
+	}
+	return invoice, true
+}
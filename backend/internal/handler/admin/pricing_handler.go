@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricingHandler handles model pricing sources, revision review, overrides and history
+type PricingHandler struct {
+	pricingService *service.PricingService
+}
+
+// NewPricingHandler creates a new admin PricingHandler
+func NewPricingHandler(pricingService *service.PricingService) *PricingHandler {
+	return &PricingHandler{pricingService: pricingService}
+}
+
+// SetPriceOverrideRequest sets an admin price override for a model
+type SetPriceOverrideRequest struct {
+	Model   string                      `json:"model" binding:"required"`
+	Pricing service.LiteLLMModelPricing `json:"pricing"`
+	Note    string                      `json:"note"`
+}
+
+// GetStatus handles getting sync status, per-source status and the pending revision
+// GET /api/v1/admin/pricing/status
+func (h *PricingHandler) GetStatus(c *gin.Context) {
+	status, err := h.pricingService.GetSyncStatus(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, status)
+}
+
+// Sync handles fetching all sources now. Changes still need approval when
+// pricing.require_approval is enabled.
+// POST /api/v1/admin/pricing/sync
+func (h *PricingHandler) Sync(c *gin.Context) {
+	result, err := h.pricingService.SyncNow(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// ListRevisions handles listing pricing revisions (without diffs)
+// GET /api/v1/admin/pricing/revisions
+func (h *PricingHandler) ListRevisions(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filter := service.PricingRevisionListFilter{Status: strings.TrimSpace(c.Query("status"))}
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	revisions, result, err := h.pricingService.ListRevisions(c.Request.Context(), filter, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, revisions, result.Total, page, pageSize)
+}
+
+// GetRevision handles getting a revision with its per-model diff
+// GET /api/v1/admin/pricing/revisions/:id
+func (h *PricingHandler) GetRevision(c *gin.Context) {
+	id, ok := parsePricingRevisionID(c)
+	if !ok {
+		return
+	}
+	rev, err := h.pricingService.GetRevision(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, rev)
+}
+
+// ApproveRevision handles approving a pending revision so its prices take effect
+// POST /api/v1/admin/pricing/revisions/:id/approve
+func (h *PricingHandler) ApproveRevision(c *gin.Context) {
+	id, ok := parsePricingRevisionID(c)
+	if !ok {
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	rev, err := h.pricingService.ApproveRevision(c.Request.Context(), id, &subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, rev)
+}
+
+// RejectRevision handles rejecting a pending revision
+// POST /api/v1/admin/pricing/revisions/:id/reject
+func (h *PricingHandler) RejectRevision(c *gin.Context) {
+	id, ok := parsePricingRevisionID(c)
+	if !ok {
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	if err := h.pricingService.RejectRevision(c.Request.Context(), id, &subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Revision rejected"})
+}
+
+// ListOverrides handles listing admin price overrides
+// GET /api/v1/admin/pricing/overrides
+func (h *PricingHandler) ListOverrides(c *gin.Context) {
+	overrides, err := h.pricingService.ListOverrides(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, overrides)
+}
+
+// SetOverride handles creating or replacing a price override. The model is
+// passed in the body because model names may contain slashes.
+// PUT /api/v1/admin/pricing/overrides
+func (h *PricingHandler) SetOverride(c *gin.Context) {
+	var req SetPriceOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	override, err := h.pricingService.SetOverride(c.Request.Context(), &service.SetPriceOverrideInput{
+		Model:   req.Model,
+		Pricing: req.Pricing,
+		Note:    req.Note,
+		ActorID: &subject.UserID,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, override)
+}
+
+// DeleteOverride handles removing a price override
+// DELETE /api/v1/admin/pricing/overrides?model=xxx
+func (h *PricingHandler) DeleteOverride(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		response.BadRequest(c, "model is required")
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	if err := h.pricingService.DeleteOverride(c.Request.Context(), model, &subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Override deleted"})
+}
+
+// ListHistory handles listing effective price changes, optionally for one model
+// GET /api/v1/admin/pricing/history
+func (h *PricingHandler) ListHistory(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	history, result, err := h.pricingService.ListHistory(c.Request.Context(), c.Query("model"), params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, history, result.Total, page, pageSize)
+}
+
+func parsePricingRevisionID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid revision ID")
+		return 0, false
+	}
+	return id, true
+}
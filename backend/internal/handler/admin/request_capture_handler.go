@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestCaptureHandler handles admin request/response capture management
+type RequestCaptureHandler struct {
+	captureService *service.RequestCaptureService
+}
+
+// NewRequestCaptureHandler creates a new admin RequestCaptureHandler
+func NewRequestCaptureHandler(captureService *service.RequestCaptureService) *RequestCaptureHandler {
+	return &RequestCaptureHandler{captureService: captureService}
+}
+
+// UpdateRequestCapturePolicyRequest selects which groups / API keys are captured
+type UpdateRequestCapturePolicyRequest struct {
+	Enabled       bool    `json:"enabled"`
+	GroupIDs      []int64 `json:"group_ids"`
+	APIKeyIDs     []int64 `json:"api_key_ids"`
+	AllowUserView bool    `json:"allow_user_view"`
+}
+
+// GetPolicy handles getting the capture policy
+// GET /api/v1/admin/request-captures/policy
+func (h *RequestCaptureHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.captureService.GetPolicy(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"policy":          policy,
+		"capture_enabled": h.captureService.Enabled(),
+	})
+}
+
+// UpdatePolicy handles updating the capture policy
+// PUT /api/v1/admin/request-captures/policy
+func (h *RequestCaptureHandler) UpdatePolicy(c *gin.Context) {
+	var req UpdateRequestCapturePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	policy, err := h.captureService.UpdatePolicy(c.Request.Context(), &service.RequestCapturePolicy{
+		Enabled:       req.Enabled,
+		GroupIDs:      req.GroupIDs,
+		APIKeyIDs:     req.APIKeyIDs,
+		AllowUserView: req.AllowUserView,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, policy)
+}
+
+// List handles listing captures. Pass request_id (as stored in usage logs) to
+// find the capture of a usage record.
+// GET /api/v1/admin/request-captures
+func (h *RequestCaptureHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	var filter service.RequestCaptureListFilter
+	userID, ok := parseRequestCaptureIDQuery(c, "user_id")
+	if !ok {
+		return
+	}
+	apiKeyID, ok := parseRequestCaptureIDQuery(c, "api_key_id")
+	if !ok {
+		return
+	}
+	groupID, ok := parseRequestCaptureIDQuery(c, "group_id")
+	if !ok {
+		return
+	}
+	filter.UserID, filter.APIKeyID, filter.GroupID = userID, apiKeyID, groupID
+	filter.RequestID = strings.TrimSpace(c.Query("request_id"))
+
+	userTZ := c.Query("timezone")
+	if v := c.Query("start_date"); v != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", v, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+			return
+		}
+		filter.StartTime = &t
+	}
+	if v := c.Query("end_date"); v != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", v, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+			return
+		}
+		end := t.AddDate(0, 0, 1)
+		filter.EndTime = &end
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	captures, result, err := h.captureService.List(c.Request.Context(), filter, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, captures, result.Total, page, pageSize)
+}
+
+// GetByID handles getting a capture with its decrypted request and response
+// GET /api/v1/admin/request-captures/:id
+func (h *RequestCaptureHandler) GetByID(c *gin.Context) {
+	captureID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || captureID <= 0 {
+		response.BadRequest(c, "Invalid capture ID")
+		return
+	}
+	detail, err := h.captureService.Get(c.Request.Context(), captureID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, detail)
+}
+
+func parseRequestCaptureIDQuery(c *gin.Context, name string) (*int64, bool) {
+	v := strings.TrimSpace(c.Query(name))
+	if v == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid "+name)
+		return nil, false
+	}
+	return &id, true
+}
@@ -84,6 +84,8 @@ func (h *SettingHandler) GetSettings(c *gin.Context) {
 		InvitationCodeEnabled:                settings.InvitationCodeEnabled,
 		TotpEnabled:                          settings.TotpEnabled,
 		TotpEncryptionKeyConfigured:          h.settingService.IsTotpEncryptionKeyConfigured(),
+		PasskeyEnabled:                       settings.PasskeyEnabled,
+		Admin2FARequired:                     settings.Admin2FARequired,
 		SMTPHost:                             settings.SMTPHost,
 		SMTPPort:                             settings.SMTPPort,
 		SMTPUsername:                         settings.SMTPUsername,
@@ -140,7 +142,9 @@ type UpdateSettingsRequest struct {
 	PasswordResetEnabled             bool     `json:"password_reset_enabled"`
 	FrontendURL                      string   `json:"frontend_url"`
 	InvitationCodeEnabled            bool     `json:"invitation_code_enabled"`
-	TotpEnabled                      bool     `json:"totp_enabled"` // TOTP 双因素认证
+	TotpEnabled                      bool     `json:"totp_enabled"`       // TOTP 双因素认证
+	PasskeyEnabled                   bool     `json:"passkey_enabled"`    // WebAuthn 通行密钥
+	Admin2FARequired                 bool     `json:"admin_2fa_required"` // 管理员必须设置 2FA
 
 	// 邮件服务设置
 	SMTPHost     string `json:"smtp_host"`
@@ -271,6 +275,24 @@ func (h *SettingHandler) UpdateSettings(c *gin.Context) {
 		}
 	}
 
+	// 通行密钥依赖前端地址确定 RP ID 与 Origin
+	if req.PasskeyEnabled && !previousSettings.PasskeyEnabled {
+		frontendURL := strings.TrimSpace(req.FrontendURL)
+		if frontendURL == "" {
+			frontendURL = h.settingService.GetFrontendURL(c.Request.Context())
+		}
+		if frontendURL == "" {
+			response.BadRequest(c, "Cannot enable passkeys: frontend URL must be configured first")
+			return
+		}
+	}
+
+	// 强制管理员 2FA 时至少要有一种可用的 2FA 方式，否则管理员将无法完成设置
+	if req.Admin2FARequired && !req.TotpEnabled && !req.PasskeyEnabled {
+		response.BadRequest(c, "Cannot require admin 2FA: enable TOTP or passkeys first")
+		return
+	}
+
 	// LinuxDo Connect 参数验证
 	if req.LinuxDoConnectEnabled {
 		req.LinuxDoConnectClientID = strings.TrimSpace(req.LinuxDoConnectClientID)
@@ -451,6 +473,8 @@ func (h *SettingHandler) UpdateSettings(c *gin.Context) {
 		FrontendURL:                      req.FrontendURL,
 		InvitationCodeEnabled:            req.InvitationCodeEnabled,
 		TotpEnabled:                      req.TotpEnabled,
+		PasskeyEnabled:                   req.PasskeyEnabled,
+		Admin2FARequired:                 req.Admin2FARequired,
 		SMTPHost:                         req.SMTPHost,
 		SMTPPort:                         req.SMTPPort,
 		SMTPUsername:                     req.SMTPUsername,
@@ -547,6 +571,8 @@ func (h *SettingHandler) UpdateSettings(c *gin.Context) {
 		InvitationCodeEnabled:                updatedSettings.InvitationCodeEnabled,
 		TotpEnabled:                          updatedSettings.TotpEnabled,
 		TotpEncryptionKeyConfigured:          h.settingService.IsTotpEncryptionKeyConfigured(),
+		PasskeyEnabled:                       updatedSettings.PasskeyEnabled,
+		Admin2FARequired:                     updatedSettings.Admin2FARequired,
 		SMTPHost:                             updatedSettings.SMTPHost,
 		SMTPPort:                             updatedSettings.SMTPPort,
 		SMTPUsername:                         updatedSettings.SMTPUsername,
@@ -633,6 +659,12 @@ func diffSettings(before *service.SystemSettings, after *service.SystemSettings,
 	if before.TotpEnabled != after.TotpEnabled {
 		changed = append(changed, "totp_enabled")
 	}
+	if before.PasskeyEnabled != after.PasskeyEnabled {
+		changed = append(changed, "passkey_enabled")
+	}
+	if before.Admin2FARequired != after.Admin2FARequired {
+		changed = append(changed, "admin_2fa_required")
+	}
 	if before.SMTPHost != after.SMTPHost {
 		changed = append(changed, "smtp_host")
 	}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageExportHandler handles admin usage export jobs (any user, includes account columns)
+type UsageExportHandler struct {
+	exportService *service.UsageExportService
+}
+
+// NewUsageExportHandler creates a new admin UsageExportHandler
+func NewUsageExportHandler(exportService *service.UsageExportService) *UsageExportHandler {
+	return &UsageExportHandler{exportService: exportService}
+}
+
+// CreateUsageExportRequest represents an admin export job creation request
+type CreateUsageExportRequest struct {
+	Format    string  `json:"format"`
+	StartDate string  `json:"start_date"`
+	EndDate   string  `json:"end_date"`
+	UserID    *int64  `json:"user_id"`
+	APIKeyID  *int64  `json:"api_key_id"`
+	AccountID *int64  `json:"account_id"`
+	GroupID   *int64  `json:"group_id"`
+	Model     *string `json:"model"`
+	Timezone  string  `json:"timezone"`
+}
+
+// Create handles creating a usage export job
+// POST /api/v1/admin/usage/exports
+func (h *UsageExportHandler) Create(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req CreateUsageExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	req.StartDate = strings.TrimSpace(req.StartDate)
+	req.EndDate = strings.TrimSpace(req.EndDate)
+	if req.StartDate == "" || req.EndDate == "" {
+		response.BadRequest(c, "start_date and end_date are required")
+		return
+	}
+
+	startTime, err := timezone.ParseInUserLocation("2006-01-02", req.StartDate, req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+		return
+	}
+	endTime, err := timezone.ParseInUserLocation("2006-01-02", req.EndDate, req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+		return
+	}
+	// 左闭右开区间 [start, end)，结束日期取次日零点
+	endTime = endTime.AddDate(0, 0, 1)
+
+	exportReq := service.UsageExportRequest{
+		Format: req.Format,
+		Filters: service.UsageExportFilters{
+			StartTime: startTime,
+			EndTime:   endTime,
+			UserID:    req.UserID,
+			APIKeyID:  req.APIKeyID,
+			AccountID: req.AccountID,
+			GroupID:   req.GroupID,
+			Model:     req.Model,
+		},
+		CreatedBy:  subject.UserID,
+		AdminScope: true,
+	}
+
+	idempotencyPayload := struct {
+		OperatorID int64                    `json:"operator_id"`
+		Body       CreateUsageExportRequest `json:"body"`
+	}{
+		OperatorID: subject.UserID,
+		Body:       req,
+	}
+	executeAdminIdempotentJSON(c, "admin.usage.exports.create", idempotencyPayload, service.DefaultWriteIdempotencyTTL(), func(ctx context.Context) (any, error) {
+		job, err := h.exportService.CreateJob(ctx, exportReq)
+		if err != nil {
+			logger.LegacyPrintf("handler.admin.usage", "[UsageExport] 创建导出任务失败: operator=%d err=%v", subject.UserID, err)
+			return nil, err
+		}
+		return job, nil
+	})
+}
+
+// List handles listing all usage export jobs
+// GET /api/v1/admin/usage/exports
+func (h *UsageExportHandler) List(c *gin.Context) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+
+	var createdBy *int64
+	if v := strings.TrimSpace(c.Query("created_by")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid created_by")
+			return
+		}
+		createdBy = &id
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	jobs, result, err := h.exportService.ListJobs(c.Request.Context(), createdBy, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, jobs, result.Total, page, pageSize)
+}
+
+// Get handles getting an export job
+// GET /api/v1/admin/usage/exports/:id
+func (h *UsageExportHandler) Get(c *gin.Context) {
+	jobID, ok := h.parseJobID(c)
+	if !ok {
+		return
+	}
+	job, err := h.exportService.GetJob(c.Request.Context(), jobID, 0)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, job)
+}
+
+// Cancel handles canceling an export job
+// POST /api/v1/admin/usage/exports/:id/cancel
+func (h *UsageExportHandler) Cancel(c *gin.Context) {
+	jobID, ok := h.parseJobID(c)
+	if !ok {
+		return
+	}
+	if err := h.exportService.CancelJob(c.Request.Context(), jobID, 0); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"id": jobID, "status": service.UsageExportStatusCanceled})
+}
+
+// Download handles downloading an export file.
+// Local storage streams the file; S3 storage returns a presigned URL.
+// GET /api/v1/admin/usage/exports/:id/download
+func (h *UsageExportHandler) Download(c *gin.Context) {
+	jobID, ok := h.parseJobID(c)
+	if !ok {
+		return
+	}
+	download, err := h.exportService.GetDownload(c.Request.Context(), jobID, 0)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if download.URL != "" {
+		response.Success(c, gin.H{"url": download.URL, "file_name": download.FileName})
+		return
+	}
+	c.Header("Content-Type", download.ContentType)
+	c.FileAttachment(download.LocalPath, download.FileName)
+}
+
+func (h *UsageExportHandler) parseJobID(c *gin.Context) (int64, bool) {
+	if h.exportService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage export service unavailable")
+		return 0, false
+	}
+	jobID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || jobID <= 0 {
+		response.BadRequest(c, "Invalid export job id")
+		return 0, false
+	}
+	return jobID, true
+}
@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageLogArchiveHandler handles usage_logs partitions and archived months
+type UsageLogArchiveHandler struct {
+	partitionService *service.UsageLogPartitionService
+}
+
+// NewUsageLogArchiveHandler creates a new UsageLogArchiveHandler
+func NewUsageLogArchiveHandler(partitionService *service.UsageLogPartitionService) *UsageLogArchiveHandler {
+	return &UsageLogArchiveHandler{partitionService: partitionService}
+}
+
+// ListPartitions handles listing usage_logs partitions
+// GET /api/v1/admin/usage/partitions
+func (h *UsageLogArchiveHandler) ListPartitions(c *gin.Context) {
+	if h.partitionService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage log archive service unavailable")
+		return
+	}
+	partitions, err := h.partitionService.ListPartitions(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, partitions)
+}
+
+// ListArchives handles listing archived months
+// GET /api/v1/admin/usage/archives
+func (h *UsageLogArchiveHandler) ListArchives(c *gin.Context) {
+	if h.partitionService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage log archive service unavailable")
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	archives, result, err := h.partitionService.ListArchives(c.Request.Context(), params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, archives, result.Total, page, pageSize)
+}
+
+// GetArchive handles getting an archived month
+// GET /api/v1/admin/usage/archives/:id
+func (h *UsageLogArchiveHandler) GetArchive(c *gin.Context) {
+	archiveID, ok := h.parseArchiveID(c)
+	if !ok {
+		return
+	}
+	archive, err := h.partitionService.GetArchive(c.Request.Context(), archiveID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, archive)
+}
+
+// ListRecords handles querying records inside an archive file without restoring it
+// GET /api/v1/admin/usage/archives/:id/records
+func (h *UsageLogArchiveHandler) ListRecords(c *gin.Context) {
+	archiveID, ok := h.parseArchiveID(c)
+	if !ok {
+		return
+	}
+	filters := service.UsageLogArchiveRecordFilters{
+		Model:     c.Query("model"),
+		RequestID: c.Query("request_id"),
+		SessionID: c.Query("session_id"),
+	}
+	for _, item := range []struct {
+		name string
+		dst  **int64
+	}{
+		{"user_id", &filters.UserID},
+		{"api_key_id", &filters.APIKeyID},
+		{"account_id", &filters.AccountID},
+		{"group_id", &filters.GroupID},
+	} {
+		v := strings.TrimSpace(c.Query(item.name))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid "+item.name)
+			return
+		}
+		*item.dst = &id
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	records, result, err := h.partitionService.QueryArchiveRecords(c.Request.Context(), archiveID, filters, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, records, result.Total, page, pageSize)
+}
+
+// Download handles downloading an archive file.
+// Local storage streams the file; S3 storage returns a presigned URL.
+// GET /api/v1/admin/usage/archives/:id/download
+func (h *UsageLogArchiveHandler) Download(c *gin.Context) {
+	archiveID, ok := h.parseArchiveID(c)
+	if !ok {
+		return
+	}
+	download, err := h.partitionService.GetArchiveDownload(c.Request.Context(), archiveID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if download.URL != "" {
+		response.Success(c, gin.H{"url": download.URL, "file_name": download.FileName})
+		return
+	}
+	c.Header("Content-Type", download.ContentType)
+	c.FileAttachment(download.LocalPath, download.FileName)
+}
+
+// Restore handles re-importing an archived month into usage_logs (runs in background)
+// POST /api/v1/admin/usage/archives/:id/restore
+func (h *UsageLogArchiveHandler) Restore(c *gin.Context) {
+	archiveID, ok := h.parseArchiveID(c)
+	if !ok {
+		return
+	}
+	archive, err := h.partitionService.RestoreArchive(c.Request.Context(), archiveID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, archive)
+}
+
+// Release handles releasing a restored month so retention can drop it again
+// DELETE /api/v1/admin/usage/archives/:id/restore
+func (h *UsageLogArchiveHandler) Release(c *gin.Context) {
+	archiveID, ok := h.parseArchiveID(c)
+	if !ok {
+		return
+	}
+	archive, err := h.partitionService.ReleaseArchive(c.Request.Context(), archiveID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, archive)
+}
+
+func (h *UsageLogArchiveHandler) parseArchiveID(c *gin.Context) (int64, bool) {
+	if h.partitionService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Usage log archive service unavailable")
+		return 0, false
+	}
+	archiveID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || archiveID <= 0 {
+		response.BadRequest(c, "Invalid archive id")
+		return 0, false
+	}
+	return archiveID, true
+}
@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageSessionHandler handles admin conversation-level usage analytics (any user)
+type UsageSessionHandler struct {
+	sessionService *service.UsageSessionService
+}
+
+// NewUsageSessionHandler creates a new admin UsageSessionHandler
+func NewUsageSessionHandler(sessionService *service.UsageSessionService) *UsageSessionHandler {
+	return &UsageSessionHandler{sessionService: sessionService}
+}
+
+// List handles listing sessions with filters
+// GET /api/v1/admin/usage/sessions
+func (h *UsageSessionHandler) List(c *gin.Context) {
+	filters := service.UsageSessionFilters{
+		Model:  c.Query("model"),
+		SortBy: c.Query("sort_by"),
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  **int64
+	}{
+		{"user_id", &filters.UserID},
+		{"api_key_id", &filters.APIKeyID},
+		{"account_id", &filters.AccountID},
+		{"group_id", &filters.GroupID},
+	} {
+		raw := c.Query(f.name)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid "+f.name)
+			return
+		}
+		*f.dst = &id
+	}
+
+	userTZ := c.Query("timezone")
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+			return
+		}
+		filters.StartTime = &t
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+			return
+		}
+		// 左闭右开区间 [start, end)，结束日期取次日零点
+		t = t.AddDate(0, 0, 1)
+		filters.EndTime = &t
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	sessions, result, err := h.sessionService.ListSessions(c.Request.Context(), filters, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, sessions, result.Total, page, pageSize)
+}
+
+// Get handles getting a single session aggregate
+// GET /api/v1/admin/usage/sessions/:session_id
+func (h *UsageSessionHandler) Get(c *gin.Context) {
+	userID, ok := parseOptionalSessionUserID(c)
+	if !ok {
+		return
+	}
+	session, err := h.sessionService.GetSession(c.Request.Context(), c.Param("session_id"), userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, session)
+}
+
+// ListLogs handles listing the requests of a session
+// GET /api/v1/admin/usage/sessions/:session_id/logs
+func (h *UsageSessionHandler) ListLogs(c *gin.Context) {
+	userID, ok := parseOptionalSessionUserID(c)
+	if !ok {
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	records, result, err := h.sessionService.ListSessionLogs(c.Request.Context(), c.Param("session_id"), userID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminUsageLog, 0, len(records))
+	for i := range records {
+		out = append(out, *dto.UsageLogFromServiceAdmin(&records[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// parseOptionalSessionUserID reads the optional user_id query parameter that
+// narrows a session to one user's requests.
+func parseOptionalSessionUserID(c *gin.Context) (*int64, bool) {
+	raw := c.Query("user_id")
+	if raw == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user_id")
+		return nil, false
+	}
+	return &id, true
+}
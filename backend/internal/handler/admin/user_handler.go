@@ -42,6 +42,9 @@ type CreateUserRequest struct {
 	Concurrency           int     `json:"concurrency"`
 	AllowedGroups         []int64 `json:"allowed_groups"`
 	SoraStorageQuotaBytes int64   `json:"sora_storage_quota_bytes"`
+	// 公平排队权重与优先级档位（0 表示使用分组配置）
+	QueueWeight   int `json:"queue_weight"`
+	QueuePriority int `json:"queue_priority"`
 }
 
 // UpdateUserRequest represents admin update user request
@@ -59,6 +62,9 @@ type UpdateUserRequest struct {
 	// map[groupID]*rate，nil 表示删除该分组的专属倍率
 	GroupRates            map[int64]*float64 `json:"group_rates"`
 	SoraStorageQuotaBytes *int64             `json:"sora_storage_quota_bytes"`
+	// 公平排队权重与优先级档位（0 表示使用分组配置）
+	QueueWeight   *int `json:"queue_weight"`
+	QueuePriority *int `json:"queue_priority"`
 }
 
 // UpdateBalanceRequest represents balance update request
@@ -188,6 +194,8 @@ func (h *UserHandler) Create(c *gin.Context) {
 		Concurrency:           req.Concurrency,
 		AllowedGroups:         req.AllowedGroups,
 		SoraStorageQuotaBytes: req.SoraStorageQuotaBytes,
+		QueueWeight:           req.QueueWeight,
+		QueuePriority:         req.QueuePriority,
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -224,6 +232,8 @@ func (h *UserHandler) Update(c *gin.Context) {
 		AllowedGroups:         req.AllowedGroups,
 		GroupRates:            req.GroupRates,
 		SoraStorageQuotaBytes: req.SoraStorageQuotaBytes,
+		QueueWeight:           req.QueueWeight,
+		QueuePriority:         req.QueuePriority,
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
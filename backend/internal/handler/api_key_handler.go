@@ -42,6 +42,9 @@ type CreateAPIKeyRequest struct {
 	RateLimit5h *float64 `json:"rate_limit_5h"`
 	RateLimit1d *float64 `json:"rate_limit_1d"`
 	RateLimit7d *float64 `json:"rate_limit_7d"`
+
+	// 调度优先级: interactive / normal / batch（默认 normal）
+	Priority string `json:"priority" binding:"omitempty,oneof=interactive normal batch"`
 }
 
 // UpdateAPIKeyRequest represents the update API key request payload
@@ -60,6 +63,9 @@ type UpdateAPIKeyRequest struct {
 	RateLimit1d         *float64 `json:"rate_limit_1d"`
 	RateLimit7d         *float64 `json:"rate_limit_7d"`
 	ResetRateLimitUsage *bool    `json:"reset_rate_limit_usage"` // 重置限速用量
+
+	// 调度优先级 (nil = no change)
+	Priority *string `json:"priority" binding:"omitempty,oneof=interactive normal batch"`
 }
 
 // List handles listing user's API keys with pagination
@@ -155,6 +161,7 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 		IPWhitelist:   req.IPWhitelist,
 		IPBlacklist:   req.IPBlacklist,
 		ExpiresInDays: req.ExpiresInDays,
+		Priority:      req.Priority,
 	}
 	if req.Quota != nil {
 		svcReq.Quota = *req.Quota
@@ -208,6 +215,7 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 		RateLimit1d:         req.RateLimit1d,
 		RateLimit7d:         req.RateLimit7d,
 		ResetRateLimitUsage: req.ResetRateLimitUsage,
+		Priority:            req.Priority,
 	}
 	if req.Name != "" {
 		svcReq.Name = &req.Name
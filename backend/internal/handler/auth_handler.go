@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"log/slog"
 	"strings"
 
@@ -16,25 +18,27 @@ import (
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	cfg           *config.Config
-	authService   *service.AuthService
-	userService   *service.UserService
-	settingSvc    *service.SettingService
-	promoService  *service.PromoService
-	redeemService *service.RedeemService
-	totpService   *service.TotpService
+	cfg            *config.Config
+	authService    *service.AuthService
+	userService    *service.UserService
+	settingSvc     *service.SettingService
+	promoService   *service.PromoService
+	redeemService  *service.RedeemService
+	totpService    *service.TotpService
+	passkeyService *service.PasskeyService
 }
 
 // NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(cfg *config.Config, authService *service.AuthService, userService *service.UserService, settingService *service.SettingService, promoService *service.PromoService, redeemService *service.RedeemService, totpService *service.TotpService) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, authService *service.AuthService, userService *service.UserService, settingService *service.SettingService, promoService *service.PromoService, redeemService *service.RedeemService, totpService *service.TotpService, passkeyService *service.PasskeyService) *AuthHandler {
 	return &AuthHandler{
-		cfg:           cfg,
-		authService:   authService,
-		userService:   userService,
-		settingSvc:    settingService,
-		promoService:  promoService,
-		redeemService: redeemService,
-		totpService:   totpService,
+		cfg:            cfg,
+		authService:    authService,
+		userService:    userService,
+		settingSvc:     settingService,
+		promoService:   promoService,
+		redeemService:  redeemService,
+		totpService:    totpService,
+		passkeyService: passkeyService,
 	}
 }
 
@@ -177,8 +181,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 	_ = token // token 由 authService.Login 返回但此处由 respondWithTokenPair 重新生成
 
-	// Check if TOTP 2FA is enabled for this user
-	if h.totpService != nil && h.settingSvc.IsTotpEnabled(c.Request.Context()) && user.TotpEnabled {
+	// Check if 2FA (TOTP and/or passkey) is enabled for this user
+	if methods := h.secondFactorMethods(c.Request.Context(), user); h.totpService != nil && len(methods) > 0 {
 		// Create a temporary login session for 2FA
 		tempToken, err := h.totpService.CreateLoginSession(c.Request.Context(), user.ID, user.Email)
 		if err != nil {
@@ -190,6 +194,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			Requires2FA:     true,
 			TempToken:       tempToken,
 			UserEmailMasked: service.MaskEmail(user.Email),
+			Methods:         methods,
 		})
 		return
 	}
@@ -203,17 +208,32 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	h.respondWithTokenPair(c, user)
 }
 
+// secondFactorMethods returns the 2FA methods available to the user ("totp", "passkey")
+func (h *AuthHandler) secondFactorMethods(ctx context.Context, user *service.User) []string {
+	var methods []string
+	if h.settingSvc.IsTotpEnabled(ctx) && user.TotpEnabled {
+		methods = append(methods, "totp")
+	}
+	if h.passkeyService != nil && h.passkeyService.HasSecondFactor(ctx, user.ID) {
+		methods = append(methods, "passkey")
+	}
+	return methods
+}
+
 // TotpLoginResponse represents the response when 2FA is required
 type TotpLoginResponse struct {
-	Requires2FA     bool   `json:"requires_2fa"`
-	TempToken       string `json:"temp_token,omitempty"`
-	UserEmailMasked string `json:"user_email_masked,omitempty"`
+	Requires2FA     bool     `json:"requires_2fa"`
+	TempToken       string   `json:"temp_token,omitempty"`
+	UserEmailMasked string   `json:"user_email_masked,omitempty"`
+	Methods         []string `json:"methods,omitempty"` // available 2FA methods: "totp", "passkey"
 }
 
-// Login2FARequest represents the 2FA login request
+// Login2FARequest represents the 2FA login request; either totp_code or passkey is required
 type Login2FARequest struct {
 	TempToken string `json:"temp_token" binding:"required"`
-	TotpCode  string `json:"totp_code" binding:"required,len=6"`
+	TotpCode  string `json:"totp_code" binding:"omitempty,len=6"`
+	// Passkey is the WebAuthn assertion (PublicKeyCredential JSON) for the options from /login/2fa/passkey/options
+	Passkey json.RawMessage `json:"passkey"`
 }
 
 // Login2FA completes the login with 2FA verification
@@ -225,9 +245,15 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 		return
 	}
 
+	if req.TotpCode == "" && len(req.Passkey) == 0 {
+		response.BadRequest(c, "Invalid request: totp_code or passkey is required")
+		return
+	}
+
 	slog.Debug("login_2fa_request",
 		"temp_token_len", len(req.TempToken),
-		"totp_code_len", len(req.TotpCode))
+		"totp_code_len", len(req.TotpCode),
+		"passkey", len(req.Passkey) > 0)
 
 	// Get the login session
 	session, err := h.totpService.GetLoginSession(c.Request.Context(), req.TempToken)
@@ -247,8 +273,18 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 		"user_id", session.UserID,
 		"email", session.Email)
 
-	// Verify the TOTP code
-	if err := h.totpService.VerifyCode(c.Request.Context(), session.UserID, req.TotpCode); err != nil {
+	// Verify the passkey assertion or the TOTP code
+	var verifyErr error
+	if len(req.Passkey) > 0 {
+		if h.passkeyService == nil {
+			response.ErrorFrom(c, service.ErrPasskeyNotEnabled)
+			return
+		}
+		verifyErr = h.passkeyService.FinishSecondFactor(c.Request.Context(), req.TempToken, session.UserID, req.Passkey)
+	} else {
+		verifyErr = h.totpService.VerifyCode(c.Request.Context(), session.UserID, req.TotpCode)
+	}
+	if err := verifyErr; err != nil {
 		slog.Debug("login_2fa_verify_failed",
 			"user_id", session.UserID,
 			"error", err)
@@ -275,6 +311,90 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 	h.respondWithTokenPair(c, user)
 }
 
+// Login2FAPasskeyOptionsRequest requests passkey assertion options for a pending 2FA session
+type Login2FAPasskeyOptionsRequest struct {
+	TempToken string `json:"temp_token" binding:"required"`
+}
+
+// Login2FAPasskeyOptions returns WebAuthn assertion options for completing 2FA with a passkey
+// POST /api/v1/auth/login/2fa/passkey/options
+func (h *AuthHandler) Login2FAPasskeyOptions(c *gin.Context) {
+	var req Login2FAPasskeyOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if h.totpService == nil || h.passkeyService == nil {
+		response.ErrorFrom(c, service.ErrPasskeyNotEnabled)
+		return
+	}
+
+	session, err := h.totpService.GetLoginSession(c.Request.Context(), req.TempToken)
+	if err != nil || session == nil {
+		response.BadRequest(c, "Invalid or expired 2FA session")
+		return
+	}
+
+	options, err := h.passkeyService.BeginSecondFactor(c.Request.Context(), req.TempToken, session.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, options)
+}
+
+// PasskeyLoginOptions starts a passwordless login with a discoverable passkey
+// POST /api/v1/auth/passkey/login/options
+func (h *AuthHandler) PasskeyLoginOptions(c *gin.Context) {
+	if h.passkeyService == nil {
+		response.ErrorFrom(c, service.ErrPasskeyNotEnabled)
+		return
+	}
+
+	options, err := h.passkeyService.BeginLogin(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, options)
+}
+
+// PasskeyLoginRequest completes a passwordless login
+type PasskeyLoginRequest struct {
+	ChallengeToken string          `json:"challenge_token" binding:"required"`
+	Credential     json.RawMessage `json:"credential" binding:"required"`
+}
+
+// PasskeyLogin verifies the passkey assertion and issues tokens; a user-verifying passkey satisfies 2FA
+// POST /api/v1/auth/passkey/login
+func (h *AuthHandler) PasskeyLogin(c *gin.Context) {
+	var req PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if h.passkeyService == nil {
+		response.ErrorFrom(c, service.ErrPasskeyNotEnabled)
+		return
+	}
+
+	user, err := h.passkeyService.FinishLogin(c.Request.Context(), req.ChallengeToken, req.Credential)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	// Backend mode: only admin can login
+	if h.settingSvc.IsBackendModeEnabled(c.Request.Context()) && !user.IsAdmin() {
+		response.Forbidden(c, "Backend mode is active. Only admin login is allowed.")
+		return
+	}
+
+	h.respondWithTokenPair(c, user)
+}
+
 // GetCurrentUser handles getting current authenticated user
 // GET /api/v1/auth/me
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	pkghttputil "github.com/Wei-Shaw/sub2api/internal/pkg/httputil"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// ContentModerationHandler provides the gateway policy stage that checks
+// inbound prompts before account selection.
+type ContentModerationHandler struct {
+	moderationService *service.ContentModerationService
+}
+
+// NewContentModerationHandler creates a new ContentModerationHandler
+func NewContentModerationHandler(moderationService *service.ContentModerationService) *ContentModerationHandler {
+	return &ContentModerationHandler{moderationService: moderationService}
+}
+
+// Middleware runs the moderation rules of the API key's group against the
+// request prompt and rejects blocked requests with a protocol-correct error.
+// It must run after API key authentication and before the gateway handler.
+func (h *ContentModerationHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h == nil || !h.moderationService.Enabled() || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		endpoint := GetInboundEndpoint(c)
+		if !isModeratedEndpoint(endpoint, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+		if !ok || !h.moderationService.HasRules(c.Request.Context(), apiKey.GroupID) {
+			c.Next()
+			return
+		}
+
+		body, err := pkghttputil.ReadRequestBodyWithPrealloc(c.Request)
+		if err != nil {
+			if maxErr, ok := extractMaxBytesError(err); ok {
+				writeContentModerationError(c, endpoint, http.StatusRequestEntityTooLarge, "", buildBodyTooLargeMessage(maxErr.Limit))
+				return
+			}
+			writeContentModerationError(c, endpoint, http.StatusBadRequest, "", "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		model := gjson.GetBytes(body, "model").String()
+		if model == "" && endpoint == EndpointGeminiModels {
+			model, _, _ = strings.Cut(strings.TrimPrefix(c.Param("modelAction"), "/"), ":")
+		}
+		decision, err := h.moderationService.Check(c.Request.Context(), &service.ContentModerationInput{
+			APIKey:   apiKey,
+			Endpoint: endpoint,
+			Model:    model,
+			Body:     body,
+		})
+		if err != nil {
+			writeContentModerationError(c, endpoint, http.StatusServiceUnavailable, "", "Content moderation is temporarily unavailable, please retry later")
+			return
+		}
+		if decision != nil && decision.Blocked {
+			requestLogger(c, "handler.content_moderation",
+				zap.Int64("api_key_id", apiKey.ID),
+				zap.Int64("rule_id", decision.RuleID),
+			).Info("content_moderation.blocked")
+			writeContentModerationError(c, endpoint, http.StatusBadRequest, "content_policy_violation", decision.Message)
+			return
+		}
+		c.Next()
+	}
+}
+
+// isModeratedEndpoint limits moderation to generation requests; token
+// counting and model listing are left untouched.
+func isModeratedEndpoint(endpoint, path string) bool {
+	switch endpoint {
+	case EndpointMessages:
+		return !strings.HasSuffix(path, "/count_tokens")
+	case EndpointChatCompletions, EndpointResponses:
+		return true
+	case EndpointGeminiModels:
+		return strings.HasSuffix(path, ":generateContent") || strings.HasSuffix(path, ":streamGenerateContent")
+	}
+	return false
+}
+
+// writeContentModerationError writes an error in the format of the inbound
+// protocol and aborts the chain. code is only surfaced by OpenAI-style errors.
+func writeContentModerationError(c *gin.Context, endpoint string, status int, code, message string) {
+	errType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errType = "api_error"
+	}
+	switch endpoint {
+	case EndpointGeminiModels:
+		googleError(c, status, message)
+	case EndpointChatCompletions, EndpointResponses:
+		body := gin.H{"type": errType, "message": message}
+		if code != "" {
+			body["code"] = code
+		}
+		c.JSON(status, gin.H{"error": body})
+	default:
+		c.JSON(status, gin.H{
+			"type": "error",
+			"error": gin.H{
+				"type":    errType,
+				"message": message,
+			},
+		})
+	}
+	c.Abort()
+}
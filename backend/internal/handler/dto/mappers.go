@@ -64,6 +64,8 @@ func UserFromServiceAdmin(u *service.User) *AdminUser {
 		GroupRates:            u.GroupRates,
 		SoraStorageQuotaBytes: u.SoraStorageQuotaBytes,
 		SoraStorageUsedBytes:  u.SoraStorageUsedBytes,
+		QueueWeight:           u.QueueWeight,
+		QueuePriority:         u.QueuePriority,
 	}
 }
 
@@ -95,6 +97,7 @@ func APIKeyFromService(k *service.APIKey) *APIKey {
 		Window5hStart: k.Window5hStart,
 		Window1dStart: k.Window1dStart,
 		Window7dStart: k.Window7dStart,
+		Priority:      k.EffectivePriority(),
 		User:          UserFromServiceShallow(k.User),
 		Group:         GroupFromServiceShallow(k.Group),
 	}
@@ -128,6 +131,43 @@ func GroupFromService(g *service.Group) *Group {
 	return GroupFromServiceShallow(g)
 }
 
+func modelFallbacksFromService(chains map[string][]service.ModelFallbackTarget) map[string][]ModelFallbackTarget {
+	if chains == nil {
+		return nil
+	}
+	out := make(map[string][]ModelFallbackTarget, len(chains))
+	for pattern, targets := range chains {
+		converted := make([]ModelFallbackTarget, 0, len(targets))
+		for _, target := range targets {
+			converted = append(converted, ModelFallbackTarget{Model: target.Model, GroupID: target.GroupID})
+		}
+		out[pattern] = converted
+	}
+	return out
+}
+
+func requestTransformsFromService(rules []service.RequestTransformRule) []RequestTransformRule {
+	if rules == nil {
+		return nil
+	}
+	out := make([]RequestTransformRule, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, RequestTransformRule{
+			Name:     rule.Name,
+			Disabled: rule.Disabled,
+			Models:   rule.Models,
+			Action:   rule.Action,
+			Path:     rule.Path,
+			Value:    rule.Value,
+			Min:      rule.Min,
+			Max:      rule.Max,
+			Match:    rule.Match,
+			Header:   rule.Header,
+		})
+	}
+	return out
+}
+
 // GroupFromServiceAdmin converts a service Group to DTO for admin users.
 // It includes internal fields like model_routing and account_count.
 func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
@@ -138,6 +178,8 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		Group:                   groupFromServiceBase(g),
 		ModelRouting:            g.ModelRouting,
 		ModelRoutingEnabled:     g.ModelRoutingEnabled,
+		ModelFallbacks:          modelFallbacksFromService(g.ModelFallbacks),
+		RequestTransforms:       requestTransformsFromService(g.RequestTransforms),
 		MCPXMLInject:            g.MCPXMLInject,
 		DefaultMappedModel:      g.DefaultMappedModel,
 		SupportedModelScopes:    g.SupportedModelScopes,
@@ -145,6 +187,12 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		ActiveAccountCount:      g.ActiveAccountCount,
 		RateLimitedAccountCount: g.RateLimitedAccountCount,
 		SortOrder:               g.SortOrder,
+		QueueWeight:             g.QueueWeight,
+		QueuePriority:           g.QueuePriority,
+		SchedulingStrategy:      g.SchedulingStrategy,
+		HedgeEnabled:            g.HedgeEnabled,
+		HedgePercentile:         g.HedgePercentile,
+		HedgeLoserBilling:       g.HedgeLoserBilling,
 	}
 	if len(g.AccountGroups) > 0 {
 		out.AccountGroups = make([]AccountGroup, 0, len(g.AccountGroups))
@@ -181,6 +229,8 @@ func groupFromServiceBase(g *service.Group) Group {
 		FallbackGroupIDOnInvalidRequest: g.FallbackGroupIDOnInvalidRequest,
 		SoraStorageQuotaBytes:           g.SoraStorageQuotaBytes,
 		AllowMessagesDispatch:           g.AllowMessagesDispatch,
+		Price:                           g.Price,
+		RenewalPeriodDays:               g.RenewalPeriodDays,
 		CreatedAt:                       g.CreatedAt,
 		UpdatedAt:                       g.UpdatedAt,
 	}
@@ -310,6 +360,16 @@ func AccountFromServiceShallow(a *service.Account) *Account {
 		}
 	}
 
+	// 可用时间窗口：当前状态与下一次状态变化时间
+	if schedule, err := a.GetAvailabilitySchedule(); err == nil && schedule != nil {
+		now := time.Now()
+		available := schedule.IsAvailableAt(now)
+		out.AvailableNow = &available
+		if next, ok := schedule.NextChange(now); ok {
+			out.NextAvailabilityChangeAt = &next
+		}
+	}
+
 	return out
 }
 
@@ -527,6 +587,8 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		ReasoningEffort:       l.ReasoningEffort,
 		InboundEndpoint:       l.InboundEndpoint,
 		UpstreamEndpoint:      l.UpstreamEndpoint,
+		SessionID:             l.SessionID,
+		ServedModel:           l.ServedModel,
 		GroupID:               l.GroupID,
 		SubscriptionID:        l.SubscriptionID,
 		InputTokens:           l.InputTokens,
@@ -673,6 +735,7 @@ func userSubscriptionFromServiceBase(sub *service.UserSubscription) UserSubscrip
 		DailyUsageUSD:      sub.DailyUsageUSD,
 		WeeklyUsageUSD:     sub.WeeklyUsageUSD,
 		MonthlyUsageUSD:    sub.MonthlyUsageUSD,
+		AutoRenew:          sub.AutoRenew,
 		CreatedAt:          sub.CreatedAt,
 		UpdatedAt:          sub.UpdatedAt,
 		User:               UserFromServiceShallow(sub.User),
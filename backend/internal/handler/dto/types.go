@@ -29,6 +29,9 @@ type AdminUser struct {
 	GroupRates            map[int64]float64 `json:"group_rates,omitempty"`
 	SoraStorageQuotaBytes int64             `json:"sora_storage_quota_bytes"`
 	SoraStorageUsedBytes  int64             `json:"sora_storage_used_bytes"`
+	// 公平排队权重与优先级档位（0 表示使用分组配置）
+	QueueWeight   int `json:"queue_weight"`
+	QueuePriority int `json:"queue_priority"`
 }
 
 type APIKey struct {
@@ -61,6 +64,9 @@ type APIKey struct {
 	Reset1dAt     *time.Time `json:"reset_1d_at,omitempty"`
 	Reset7dAt     *time.Time `json:"reset_7d_at,omitempty"`
 
+	// 调度优先级: interactive / normal / batch
+	Priority string `json:"priority"`
+
 	User  *User  `json:"user,omitempty"`
 	Group *Group `json:"group,omitempty"`
 }
@@ -102,10 +108,34 @@ type Group struct {
 	// OpenAI Messages 调度开关（用户侧需要此字段判断是否展示 Claude Code 教程）
 	AllowMessagesDispatch bool `json:"allow_messages_dispatch"`
 
+	// 订阅售价（从余额扣除），null 表示不可购买/续费
+	Price             *float64 `json:"price"`
+	RenewalPeriodDays int      `json:"renewal_period_days"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// ModelFallbackTarget 分组模型降级链中的一个目标（group_id 为空表示当前分组）
+type ModelFallbackTarget struct {
+	Model   string `json:"model"`
+	GroupID *int64 `json:"group_id,omitempty"`
+}
+
+// RequestTransformRule 分组请求改写规则（动作含义见 service.RequestTransformRule）
+type RequestTransformRule struct {
+	Name     string         `json:"name,omitempty"`
+	Disabled bool           `json:"disabled,omitempty"`
+	Models   []string       `json:"models,omitempty"`
+	Action   string         `json:"action"`
+	Path     string         `json:"path,omitempty"`
+	Value    any            `json:"value,omitempty"`
+	Min      *float64       `json:"min,omitempty"`
+	Max      *float64       `json:"max,omitempty"`
+	Match    map[string]any `json:"match,omitempty"`
+	Header   string         `json:"header,omitempty"`
+}
+
 // AdminGroup 是管理员接口使用的 group DTO（包含敏感/内部字段）。
 // 注意：普通用户接口不得返回 model_routing/account_count/account_groups 等内部信息。
 type AdminGroup struct {
@@ -115,6 +145,12 @@ type AdminGroup struct {
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 
+	// 模型降级链（pattern -> 有序降级目标）
+	ModelFallbacks map[string][]ModelFallbackTarget `json:"model_fallbacks"`
+
+	// 请求改写规则（按顺序在转发前执行）
+	RequestTransforms []RequestTransformRule `json:"request_transforms"`
+
 	// MCP XML 协议注入（仅 antigravity 平台使用）
 	MCPXMLInject bool `json:"mcp_xml_inject"`
 
@@ -130,6 +166,18 @@ type AdminGroup struct {
 
 	// 分组排序
 	SortOrder int `json:"sort_order"`
+
+	// 公平排队权重（0 表示默认 1）与优先级档位（0-9）
+	QueueWeight   int `json:"queue_weight"`
+	QueuePriority int `json:"queue_priority"`
+
+	// 账号调度策略（空表示默认，cost_aware 表示成本感知）
+	SchedulingStrategy string `json:"scheduling_strategy"`
+
+	// 对冲请求配置（仅非流式请求生效）
+	HedgeEnabled      bool   `json:"hedge_enabled"`
+	HedgePercentile   int    `json:"hedge_percentile"`
+	HedgeLoserBilling string `json:"hedge_loser_billing"`
 }
 
 type Account struct {
@@ -215,6 +263,10 @@ type Account struct {
 	QuotaDailyResetAt    *string `json:"quota_daily_reset_at,omitempty"`
 	QuotaWeeklyResetAt   *string `json:"quota_weekly_reset_at,omitempty"`
 
+	// 可用时间窗口（根据 extra.availability_schedule 计算，未配置时省略）
+	AvailableNow             *bool      `json:"available_now,omitempty"`
+	NextAvailabilityChangeAt *time.Time `json:"next_availability_change_at,omitempty"`
+
 	Proxy         *Proxy         `json:"proxy,omitempty"`
 	AccountGroups []AccountGroup `json:"account_groups,omitempty"`
 
@@ -343,6 +395,11 @@ type UsageLog struct {
 	InboundEndpoint *string `json:"inbound_endpoint,omitempty"`
 	// UpstreamEndpoint is the normalized upstream endpoint path, e.g. /v1/responses.
 	UpstreamEndpoint *string `json:"upstream_endpoint,omitempty"`
+	// SessionID is the sticky session (conversation) key the request was routed with.
+	SessionID *string `json:"session_id,omitempty"`
+	// ServedModel is the model that actually served the request after a group
+	// model fallback; billing uses its price. Omitted when Model was served directly.
+	ServedModel *string `json:"served_model,omitempty"`
 
 	GroupID        *int64 `json:"group_id"`
 	SubscriptionID *int64 `json:"subscription_id"`
@@ -462,6 +519,8 @@ type UserSubscription struct {
 	WeeklyUsageUSD  float64 `json:"weekly_usage_usd"`
 	MonthlyUsageUSD float64 `json:"monthly_usage_usd"`
 
+	AutoRenew bool `json:"auto_renew"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -36,21 +36,23 @@ var gatewayCompatibilityMetricsLogCounter atomic.Uint64
 
 // GatewayHandler handles API gateway requests
 type GatewayHandler struct {
-	gatewayService            *service.GatewayService
-	geminiCompatService       *service.GeminiMessagesCompatService
-	antigravityGatewayService *service.AntigravityGatewayService
-	userService               *service.UserService
-	billingCacheService       *service.BillingCacheService
-	usageService              *service.UsageService
-	apiKeyService             *service.APIKeyService
-	usageRecordWorkerPool     *service.UsageRecordWorkerPool
-	errorPassthroughService   *service.ErrorPassthroughService
-	concurrencyHelper         *ConcurrencyHelper
-	userMsgQueueHelper        *UserMsgQueueHelper
-	maxAccountSwitches        int
-	maxAccountSwitchesGemini  int
-	cfg                       *config.Config
-	settingService            *service.SettingService
+	gatewayService               *service.GatewayService
+	geminiCompatService          *service.GeminiMessagesCompatService
+	antigravityGatewayService    *service.AntigravityGatewayService
+	userService                  *service.UserService
+	billingCacheService          *service.BillingCacheService
+	usageService                 *service.UsageService
+	apiKeyService                *service.APIKeyService
+	usageRecordWorkerPool        *service.UsageRecordWorkerPool
+	errorPassthroughService      *service.ErrorPassthroughService
+	responsesConversationService *service.ResponsesConversationService
+	hedgeDelayService            *service.HedgeDelayService
+	concurrencyHelper            *ConcurrencyHelper
+	userMsgQueueHelper           *UserMsgQueueHelper
+	maxAccountSwitches           int
+	maxAccountSwitchesGemini     int
+	cfg                          *config.Config
+	settingService               *service.SettingService
 }
 
 // NewGatewayHandler creates a new GatewayHandler
@@ -66,6 +68,8 @@ func NewGatewayHandler(
 	usageRecordWorkerPool *service.UsageRecordWorkerPool,
 	errorPassthroughService *service.ErrorPassthroughService,
 	userMsgQueueService *service.UserMessageQueueService,
+	responsesConversationService *service.ResponsesConversationService,
+	hedgeDelayService *service.HedgeDelayService,
 	cfg *config.Config,
 	settingService *service.SettingService,
 ) *GatewayHandler {
@@ -89,21 +93,23 @@ func NewGatewayHandler(
 	}
 
 	return &GatewayHandler{
-		gatewayService:            gatewayService,
-		geminiCompatService:       geminiCompatService,
-		antigravityGatewayService: antigravityGatewayService,
-		userService:               userService,
-		billingCacheService:       billingCacheService,
-		usageService:              usageService,
-		apiKeyService:             apiKeyService,
-		usageRecordWorkerPool:     usageRecordWorkerPool,
-		errorPassthroughService:   errorPassthroughService,
-		concurrencyHelper:         NewConcurrencyHelper(concurrencyService, SSEPingFormatClaude, pingInterval),
-		userMsgQueueHelper:        umqHelper,
-		maxAccountSwitches:        maxAccountSwitches,
-		maxAccountSwitchesGemini:  maxAccountSwitchesGemini,
-		cfg:                       cfg,
-		settingService:            settingService,
+		gatewayService:               gatewayService,
+		geminiCompatService:          geminiCompatService,
+		antigravityGatewayService:    antigravityGatewayService,
+		userService:                  userService,
+		billingCacheService:          billingCacheService,
+		usageService:                 usageService,
+		apiKeyService:                apiKeyService,
+		usageRecordWorkerPool:        usageRecordWorkerPool,
+		errorPassthroughService:      errorPassthroughService,
+		responsesConversationService: responsesConversationService,
+		hedgeDelayService:            hedgeDelayService,
+		concurrencyHelper:            NewConcurrencyHelper(concurrencyService, SSEPingFormatClaude, pingInterval),
+		userMsgQueueHelper:           umqHelper,
+		maxAccountSwitches:           maxAccountSwitches,
+		maxAccountSwitchesGemini:     maxAccountSwitchesGemini,
+		cfg:                          cfg,
+		settingService:               settingService,
 	}
 }
 
@@ -174,8 +180,15 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// 执行分组请求改写规则（客户端识别与版本检查基于原始请求）
+	body, parsedReq = applyGroupRequestTransforms(c, apiKey.Group, body, parsedReq, reqLog)
+	reqModel = parsedReq.Model
+	reqStream = parsedReq.Stream
+
 	// 在请求上下文中记录 thinking 状态，供 Antigravity 最终模型 key 推导/模型维度限流使用
 	c.Request = c.Request.WithContext(service.WithThinkingEnabled(c.Request.Context(), parsedReq.ThinkingEnabled, h.metadataBridgeEnabled()))
+	// 记录 API Key 调度优先级：batch 请求只使用低负载账号，且不占用为 interactive 预留的槽位
+	c.Request = c.Request.WithContext(service.WithRequestPriority(c.Request.Context(), apiKey.EffectivePriority()))
 
 	setOpsRequestContext(c, reqModel, reqStream, body)
 
@@ -282,6 +295,39 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 
 	if platform == service.PlatformGemini {
 		fs := NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
+		geminiAPIKey := apiKey
+		geminiSubscription := subscription
+
+		// 分组模型降级链：与 Claude 路径一致，调度失败或上游过载时按顺序改用降级目标
+		modelFallback := service.NewModelFallbackChain(apiKey.Group, reqModel)
+		switchModelFallback := func(lastErr *service.UpstreamFailoverError) bool {
+			if modelFallback == nil || !service.IsModelFallbackTrigger(lastErr) {
+				return false
+			}
+			next, ok := h.nextModelFallback(c, modelFallback, apiKey, subscription, body, reqLog)
+			if !ok {
+				return false
+			}
+			fallbackReq, err := service.ParseGatewayRequest(next.body, domain.PlatformAnthropic)
+			if err != nil {
+				return false
+			}
+			fallbackReq.SessionContext = parsedReq.SessionContext
+			reqLog.Info("gateway.model_fallback_switch",
+				zap.String("requested_model", modelFallback.RequestedModel()),
+				zap.String("from_model", reqModel),
+				zap.String("fallback_model", next.target.Model),
+				zap.Any("fallback_group_id", next.apiKey.GroupID),
+			)
+			applyModelFallbackSwitch(c, next)
+			body = next.body
+			parsedReq = fallbackReq
+			reqModel = fallbackReq.Model
+			geminiAPIKey = next.apiKey
+			geminiSubscription = next.subscription
+			fs = NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
+			return true
+		}
 
 		// 单账号分组提前设置 SingleAccountRetry 标记，让 Service 层首次 503 就不设模型限流标记。
 		// 避免单账号分组收到 503 (MODEL_CAPACITY_EXHAUSTED) 时设 29s 限流，导致后续请求连续快速失败。
@@ -291,9 +337,12 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		}
 
 		for {
-			selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, reqModel, fs.FailedAccountIDs, "") // Gemini 不使用会话限制
+			selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), geminiAPIKey.GroupID, sessionKey, reqModel, fs.FailedAccountIDs, "") // Gemini 不使用会话限制
 			if err != nil {
 				if len(fs.FailedAccountIDs) == 0 {
+					if switchModelFallback(nil) {
+						continue
+					}
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 					return
 				}
@@ -306,6 +355,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				case FailoverCanceled:
 					return
 				default: // FailoverExhausted
+					if switchModelFallback(fs.LastFailoverErr) {
+						continue
+					}
 					if fs.LastFailoverErr != nil {
 						h.handleFailoverExhausted(c, fs.LastFailoverErr, service.PlatformGemini, streamStarted)
 					} else {
@@ -341,13 +393,14 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					return
 				}
 				accountWaitCounted := false
-				canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
+				canWait, err := h.concurrencyHelper.IncrementAccountPlanWaitCount(c.Request.Context(), selection.WaitPlan)
 				if err != nil {
 					reqLog.Warn("gateway.account_wait_counter_increment_failed", zap.Int64("account_id", account.ID), zap.Error(err))
 				} else if !canWait {
 					reqLog.Info("gateway.account_wait_queue_full",
 						zap.Int64("account_id", account.ID),
 						zap.Int("max_waiting", selection.WaitPlan.MaxWaiting),
+						zap.Bool("batch", selection.WaitPlan.Batch),
 					)
 					h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 					return
@@ -357,16 +410,15 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				}
 				releaseWait := func() {
 					if accountWaitCounted {
-						h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+						h.concurrencyHelper.DecrementAccountPlanWaitCount(c.Request.Context(), selection.WaitPlan)
 						accountWaitCounted = false
 					}
 				}
 
-				accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+				accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotForWaitPlan(
 					c,
-					account.ID,
-					selection.WaitPlan.MaxConcurrency,
-					selection.WaitPlan.Timeout,
+					selection.WaitPlan,
+					account.MatchModelLimit(reqModel),
 					reqStream,
 					&streamStarted,
 				)
@@ -378,7 +430,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				}
 				// Slot acquired: no longer waiting in queue.
 				releaseWait()
-				if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
+				if err := h.gatewayService.BindStickySession(c.Request.Context(), geminiAPIKey.GroupID, sessionKey, account.ID); err != nil {
 					reqLog.Warn("gateway.bind_sticky_session_failed", zap.Int64("account_id", account.ID), zap.Error(err))
 				}
 			}
@@ -414,6 +466,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					case FailoverContinue:
 						continue
 					case FailoverExhausted:
+						if switchModelFallback(fs.LastFailoverErr) {
+							continue
+						}
 						h.handleFailoverExhausted(c, fs.LastFailoverErr, service.PlatformGemini, streamStarted)
 						return
 					case FailoverCanceled:
@@ -448,17 +503,19 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			if result.ReasoningEffort == nil {
 				result.ReasoningEffort = service.NormalizeClaudeOutputEffort(parsedReq.OutputEffort)
 			}
+			modelFallback.ApplyToResult(result)
 
 			// 使用量记录通过有界 worker 池提交，避免请求热路径创建无界 goroutine。
-			h.submitUsageRecordTask(func(ctx context.Context) {
+			h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
 				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 					Result:             result,
-					APIKey:             apiKey,
-					User:               apiKey.User,
+					APIKey:             geminiAPIKey,
+					User:               geminiAPIKey.User,
 					Account:            account,
-					Subscription:       subscription,
+					Subscription:       geminiSubscription,
 					InboundEndpoint:    inboundEndpoint,
 					UpstreamEndpoint:   upstreamEndpoint,
+					SessionID:          sessionHash,
 					UserAgent:          userAgent,
 					IPAddress:          clientIP,
 					RequestPayloadHash: requestPayloadHash,
@@ -474,7 +531,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 						zap.Int64("account_id", account.ID),
 					).Error("gateway.record_usage_failed", zap.Error(err))
 				}
-			})
+			}))
 			return
 		}
 	}
@@ -487,6 +544,36 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	}
 	fallbackUsed := false
 
+	// 分组模型降级链：调度失败（无可调度账号/全部限流）或上游过载时按顺序改用降级目标
+	modelFallback := service.NewModelFallbackChain(apiKey.Group, reqModel)
+	switchModelFallback := func(lastErr *service.UpstreamFailoverError) bool {
+		if modelFallback == nil || !service.IsModelFallbackTrigger(lastErr) {
+			return false
+		}
+		next, ok := h.nextModelFallback(c, modelFallback, apiKey, subscription, body, reqLog)
+		if !ok {
+			return false
+		}
+		fallbackReq, err := service.ParseGatewayRequest(next.body, domain.PlatformAnthropic)
+		if err != nil {
+			return false
+		}
+		fallbackReq.SessionContext = parsedReq.SessionContext
+		reqLog.Info("gateway.model_fallback_switch",
+			zap.String("requested_model", modelFallback.RequestedModel()),
+			zap.String("from_model", reqModel),
+			zap.String("fallback_model", next.target.Model),
+			zap.Any("fallback_group_id", next.apiKey.GroupID),
+		)
+		applyModelFallbackSwitch(c, next)
+		body = next.body
+		parsedReq = fallbackReq
+		reqModel = fallbackReq.Model
+		currentAPIKey = next.apiKey
+		currentSubscription = next.subscription
+		return true
+	}
+
 	// 单账号分组提前设置 SingleAccountRetry 标记，让 Service 层首次 503 就不设模型限流标记。
 	// 避免单账号分组收到 503 (MODEL_CAPACITY_EXHAUSTED) 时设 29s 限流，导致后续请求连续快速失败。
 	if h.gatewayService.IsSingleAntigravityAccountGroup(c.Request.Context(), currentAPIKey.GroupID) {
@@ -503,6 +590,10 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), currentAPIKey.GroupID, sessionKey, reqModel, fs.FailedAccountIDs, parsedReq.MetadataUserID)
 			if err != nil {
 				if len(fs.FailedAccountIDs) == 0 {
+					if switchModelFallback(nil) {
+						retryWithFallback = true
+						break
+					}
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 					return
 				}
@@ -515,13 +606,18 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				case FailoverCanceled:
 					return
 				default: // FailoverExhausted
-					if fs.LastFailoverErr != nil {
-						h.handleFailoverExhausted(c, fs.LastFailoverErr, platform, streamStarted)
-					} else {
-						h.handleFailoverExhaustedSimple(c, 502, streamStarted)
+					if !switchModelFallback(fs.LastFailoverErr) {
+						if fs.LastFailoverErr != nil {
+							h.handleFailoverExhausted(c, fs.LastFailoverErr, platform, streamStarted)
+						} else {
+							h.handleFailoverExhaustedSimple(c, 502, streamStarted)
+						}
+						return
 					}
-					return
+					retryWithFallback = true
 				}
+				// 已切换到模型降级目标：退出当前调度循环，按新模型重新调度
+				break
 			}
 			account := selection.Account
 			setOpsSelectedAccount(c, account.ID, account.Platform)
@@ -550,13 +646,14 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					return
 				}
 				accountWaitCounted := false
-				canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
+				canWait, err := h.concurrencyHelper.IncrementAccountPlanWaitCount(c.Request.Context(), selection.WaitPlan)
 				if err != nil {
 					reqLog.Warn("gateway.account_wait_counter_increment_failed", zap.Int64("account_id", account.ID), zap.Error(err))
 				} else if !canWait {
 					reqLog.Info("gateway.account_wait_queue_full",
 						zap.Int64("account_id", account.ID),
 						zap.Int("max_waiting", selection.WaitPlan.MaxWaiting),
+						zap.Bool("batch", selection.WaitPlan.Batch),
 					)
 					h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 					return
@@ -566,16 +663,15 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				}
 				releaseWait := func() {
 					if accountWaitCounted {
-						h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+						h.concurrencyHelper.DecrementAccountPlanWaitCount(c.Request.Context(), selection.WaitPlan)
 						accountWaitCounted = false
 					}
 				}
 
-				accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+				accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotForWaitPlan(
 					c,
-					account.ID,
-					selection.WaitPlan.MaxConcurrency,
-					selection.WaitPlan.Timeout,
+					selection.WaitPlan,
+					account.MatchModelLimit(reqModel),
 					reqStream,
 					&streamStarted,
 				)
@@ -656,6 +752,35 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			writerSizeBeforeForward := c.Writer.Size()
 			if account.Platform == service.PlatformAntigravity && account.Type != service.AccountTypeAPIKey {
 				result, err = h.antigravityGatewayService.Forward(requestCtx, c, account, body, hasBoundSession)
+			} else if h.hedgeDelayService.ShouldHedge(currentAPIKey.Group, reqStream, parsedReq.MaxTokens) {
+				// 对冲请求：首个上游在分位延迟内未返回响应头时，在另一账号上并发重试，取先成功者
+				var onHedgeLoser func(*service.Account, *service.ForwardResult)
+				if service.ShouldBillHedgeLoser(currentAPIKey.Group) {
+					onHedgeLoser = h.hedgeLoserUsageRecorder(c, service.RecordUsageInput{
+						APIKey:             currentAPIKey,
+						User:               currentAPIKey.User,
+						Subscription:       currentSubscription,
+						InboundEndpoint:    GetInboundEndpoint(c),
+						UpstreamEndpoint:   GetUpstreamEndpoint(c, account.Platform),
+						SessionID:          sessionHash,
+						UserAgent:          c.GetHeader("User-Agent"),
+						IPAddress:          ip.GetClientIP(c),
+						RequestPayloadHash: service.HashUsageRequestPayload(body),
+						ForceCacheBilling:  fs.ForceCacheBilling,
+						APIKeyService:      h.apiKeyService,
+					}, modelFallback)
+				}
+				outcome := h.forwardHedged(c, requestCtx, currentAPIKey,
+					hedgeCandidate{account: account, release: accountReleaseFunc, parsed: parsedReq},
+					reqModel, fs.FailedAccountIDs, onHedgeLoser)
+				account, accountReleaseFunc = outcome.account, outcome.release
+				result, err = outcome.result, outcome.err
+				if outcome.hedged {
+					setOpsSelectedAccount(c, account.ID, account.Platform)
+				}
+				if outcome.failedHedgeAccount != nil {
+					fs.FailedAccountIDs[outcome.failedHedgeAccount.ID] = struct{}{}
+				}
 			} else {
 				result, err = h.gatewayService.Forward(requestCtx, c, account, parsedReq)
 			}
@@ -733,11 +858,17 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					case FailoverContinue:
 						continue
 					case FailoverExhausted:
-						h.handleFailoverExhausted(c, fs.LastFailoverErr, account.Platform, streamStarted)
-						return
+						if !switchModelFallback(fs.LastFailoverErr) {
+							h.handleFailoverExhausted(c, fs.LastFailoverErr, account.Platform, streamStarted)
+							return
+						}
+						retryWithFallback = true
 					case FailoverCanceled:
 						return
 					}
+					if retryWithFallback {
+						break
+					}
 				}
 				wroteFallback := h.ensureForwardErrorResponse(c, streamStarted)
 				reqLog.Error("gateway.forward_failed",
@@ -767,9 +898,11 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			if result.ReasoningEffort == nil {
 				result.ReasoningEffort = service.NormalizeClaudeOutputEffort(parsedReq.OutputEffort)
 			}
+			// 模型降级：usage 记录请求模型，并按实际服务的模型计费
+			modelFallback.ApplyToResult(result)
 
 			// 使用量记录通过有界 worker 池提交，避免请求热路径创建无界 goroutine。
-			h.submitUsageRecordTask(func(ctx context.Context) {
+			h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
 				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 					Result:             result,
 					APIKey:             currentAPIKey,
@@ -778,6 +911,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					Subscription:       currentSubscription,
 					InboundEndpoint:    inboundEndpoint,
 					UpstreamEndpoint:   upstreamEndpoint,
+					SessionID:          sessionHash,
 					UserAgent:          userAgent,
 					IPAddress:          clientIP,
 					RequestPayloadHash: requestPayloadHash,
@@ -793,7 +927,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 						zap.Int64("account_id", account.ID),
 					).Error("gateway.record_usage_failed", zap.Error(err))
 				}
-			})
+			}))
 			return
 		}
 		if !retryWithFallback {
@@ -1371,6 +1505,8 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 	}
 	// count_tokens 走 messages 严格校验时，复用已解析请求，避免二次反序列化。
 	SetClaudeCodeClientContext(c, body, parsedReq)
+	// 与 messages 保持一致，计数基于分组改写后的请求
+	body, parsedReq = applyGroupRequestTransforms(c, apiKey.Group, body, parsedReq, reqLog)
 	reqLog = reqLog.With(zap.String("model", parsedReq.Model), zap.Bool("stream", parsedReq.Stream))
 	// 在请求上下文中记录 thinking 状态，供 Antigravity 最终模型 key 推导/模型维度限流使用
 	c.Request = c.Request.WithContext(service.WithThinkingEnabled(c.Request.Context(), parsedReq.ThinkingEnabled, h.metadataBridgeEnabled()))
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	pkghttputil "github.com/Wei-Shaw/sub2api/internal/pkg/httputil"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Responses serves the OpenAI Responses API on anthropic groups so that
+// Responses-only clients (e.g. Codex CLI) can use the Claude account pool.
+// The request is converted to Anthropic Messages and handled by Messages
+// (and therefore GatewayService.Forward); the Anthropic output is rewritten
+// into Responses JSON / SSE events on the way out.
+// POST /v1/responses
+func (h *GatewayHandler) Responses(c *gin.Context) {
+	if !h.responsesConversationService.Enabled() {
+		h.responsesErrorResponse(c, http.StatusNotFound, "not_found_error", "Responses API is not enabled for this group")
+		return
+	}
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.responsesErrorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+	reqLog := requestLogger(
+		c,
+		"handler.gateway.responses",
+		zap.Int64("api_key_id", apiKey.ID),
+		zap.Any("group_id", apiKey.GroupID),
+	)
+
+	body, err := pkghttputil.ReadRequestBodyWithPrealloc(c.Request)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			h.responsesErrorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		h.responsesErrorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		h.responsesErrorResponse(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
+		return
+	}
+
+	var responsesReq apicompat.ResponsesRequest
+	if err := json.Unmarshal(body, &responsesReq); err != nil {
+		h.responsesErrorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return
+	}
+	if responsesReq.Model == "" {
+		h.responsesErrorResponse(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+
+	input, err := h.responsesConversationService.ResolveInput(c.Request.Context(), apiKey.ID, &responsesReq)
+	if err != nil {
+		if errors.Is(err, service.ErrPreviousResponseNotFound) {
+			h.responsesErrorResponse(c, http.StatusBadRequest, "invalid_request_error",
+				"Previous response with id '"+responsesReq.PreviousResponseID+"' not found.")
+			return
+		}
+		reqLog.Warn("gateway.responses_resolve_input_failed", zap.Error(err))
+		h.responsesErrorResponse(c, http.StatusBadRequest, "invalid_request_error", "Invalid input")
+		return
+	}
+	resolvedReq := responsesReq
+	resolvedReq.Input, err = json.Marshal(input)
+	if err != nil {
+		h.responsesErrorResponse(c, http.StatusInternalServerError, "api_error", "Failed to build request")
+		return
+	}
+
+	anthropicReq, err := apicompat.ResponsesToAnthropicRequest(&resolvedReq)
+	if err != nil {
+		h.responsesErrorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to convert request: "+err.Error())
+		return
+	}
+	anthropicBody, err := json.Marshal(anthropicReq)
+	if err != nil {
+		h.responsesErrorResponse(c, http.StatusInternalServerError, "api_error", "Failed to build request")
+		return
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(anthropicBody))
+	c.Request.ContentLength = int64(len(anthropicBody))
+	original := c.Writer
+	writer := newResponsesCompatWriter(original, responsesReq.Stream, responsesReq.Model)
+	c.Writer = writer
+	h.Messages(c)
+	writer.finish()
+	c.Writer = original
+
+	if resp := writer.finalResponse(); resp != nil {
+		h.responsesConversationService.Save(c.Request.Context(), apiKey.ID, &responsesReq, input, resp)
+	}
+}
+
+// ResponsesUnsupported rejects the Responses API sub-resources
+// (e.g. /v1/responses/compact) and WebSocket mode on anthropic groups: only
+// POST /v1/responses can be converted to Anthropic Messages.
+func (h *GatewayHandler) ResponsesUnsupported(c *gin.Context) {
+	h.responsesErrorResponse(c, http.StatusNotFound, "not_found_error", "This Responses API endpoint is not supported for this group")
+}
+
+// responsesErrorResponse returns an OpenAI API format error response
+func (h *GatewayHandler) responsesErrorResponse(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
+
+// responsesCompatWriter rewrites the Anthropic Messages output produced by
+// Messages into Responses API format. Streaming output is converted line by
+// line; JSON bodies (non-stream responses and errors) are buffered and
+// converted in finish().
+type responsesCompatWriter struct {
+	gin.ResponseWriter
+	stream  bool
+	model   string
+	state   *apicompat.AnthropicEventToResponsesState
+	lineBuf []byte
+	body    bytes.Buffer
+	final   *apicompat.ResponsesResponse
+}
+
+func newResponsesCompatWriter(w gin.ResponseWriter, stream bool, model string) *responsesCompatWriter {
+	return &responsesCompatWriter{
+		ResponseWriter: w,
+		stream:         stream,
+		model:          model,
+		state:          apicompat.NewAnthropicEventToResponsesState(model),
+	}
+}
+
+func (w *responsesCompatWriter) streaming() bool {
+	return w.stream && w.Status() < http.StatusBadRequest &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+}
+
+func (w *responsesCompatWriter) Write(p []byte) (int, error) {
+	if !w.streaming() {
+		return w.body.Write(p)
+	}
+	w.lineBuf = append(w.lineBuf, p...)
+	for {
+		i := bytes.IndexByte(w.lineBuf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.lineBuf[:i]), "\r")
+		w.lineBuf = w.lineBuf[i+1:]
+		if err := w.handleLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *responsesCompatWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Size reports buffered bytes as written so the gateway does not fail over
+// after a response has been produced.
+func (w *responsesCompatWriter) Size() int {
+	size := w.ResponseWriter.Size()
+	if n := w.body.Len() + len(w.lineBuf); n > 0 {
+		if size < 0 {
+			size = 0
+		}
+		size += n
+	}
+	return size
+}
+
+func (w *responsesCompatWriter) Written() bool {
+	return w.ResponseWriter.Written() || w.body.Len() > 0 || len(w.lineBuf) > 0
+}
+
+func (w *responsesCompatWriter) handleLine(line string) error {
+	payload, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return nil
+	}
+	payload = strings.TrimSpace(payload)
+	if payload == "" || payload == "[DONE]" {
+		return nil
+	}
+	var evt apicompat.AnthropicStreamEvent
+	if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+		return nil
+	}
+	if evt.Type == "ping" {
+		// Keepalive has no Responses counterpart; forward as an SSE comment.
+		_, err := w.ResponseWriter.WriteString(string(SSEPingFormatComment))
+		return err
+	}
+	return w.writeEvents(apicompat.AnthropicEventToResponsesEvents(&evt, w.state))
+}
+
+func (w *responsesCompatWriter) writeEvents(events []apicompat.ResponsesStreamEvent) error {
+	for _, evt := range events {
+		sse, err := apicompat.ResponsesEventToSSE(evt)
+		if err != nil {
+			continue
+		}
+		if _, err := w.ResponseWriter.WriteString(sse); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish flushes converted output that was buffered during Messages.
+func (w *responsesCompatWriter) finish() {
+	if w.streaming() {
+		if len(w.lineBuf) > 0 {
+			_ = w.handleLine(strings.TrimSpace(string(w.lineBuf)))
+			w.lineBuf = nil
+		}
+		_ = w.writeEvents(apicompat.FinalizeAnthropicResponsesStream(w.state))
+		w.ResponseWriter.Flush()
+		if w.state.CompletedSent {
+			w.final = w.state.FinalResponse()
+		}
+		return
+	}
+	if w.body.Len() == 0 {
+		return
+	}
+
+	out := w.body.Bytes()
+	if w.Status() >= http.StatusBadRequest {
+		var anthropicErr struct {
+			Error apicompat.AnthropicError `json:"error"`
+		}
+		if err := json.Unmarshal(out, &anthropicErr); err == nil && anthropicErr.Error.Type != "" {
+			out, _ = json.Marshal(gin.H{"error": gin.H{
+				"type":    anthropicErr.Error.Type,
+				"message": anthropicErr.Error.Message,
+			}})
+		}
+	} else {
+		var anthropicResp apicompat.AnthropicResponse
+		if err := json.Unmarshal(out, &anthropicResp); err == nil && anthropicResp.Type == "message" {
+			w.final = apicompat.AnthropicToResponsesResponse(&anthropicResp, w.model)
+			out, _ = json.Marshal(w.final)
+		}
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.ResponseWriter.Write(out)
+}
+
+func (w *responsesCompatWriter) finalResponse() *apicompat.ResponsesResponse {
+	return w.final
+}
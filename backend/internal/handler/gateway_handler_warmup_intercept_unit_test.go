@@ -45,6 +45,9 @@ func (f *fakeSchedulerCache) UpdateLastUsed(_ context.Context, _ map[int64]time.
 func (f *fakeSchedulerCache) TryLockBucket(_ context.Context, _ service.SchedulerBucket, _ time.Duration) (bool, error) {
 	return true, nil
 }
+func (f *fakeSchedulerCache) TryLockAvailabilityCheck(_ context.Context, _ time.Time, _ time.Duration) (bool, error) {
+	return true, nil
+}
 func (f *fakeSchedulerCache) ListBuckets(_ context.Context) ([]service.SchedulerBucket, error) {
 	return nil, nil
 }
@@ -104,6 +107,12 @@ func (f *fakeConcurrencyCache) DecrementAccountWaitCount(context.Context, int64)
 func (f *fakeConcurrencyCache) GetAccountWaitingCount(context.Context, int64) (int, error) {
 	return 0, nil
 }
+func (f *fakeConcurrencyCache) IncrementAccountBatchWaitCount(context.Context, int64, int) (bool, error) {
+	return true, nil
+}
+func (f *fakeConcurrencyCache) DecrementAccountBatchWaitCount(context.Context, int64) error {
+	return nil
+}
 func (f *fakeConcurrencyCache) AcquireUserSlot(context.Context, int64, int, string) (bool, error) {
 	return true, nil
 }
@@ -128,6 +137,21 @@ func (f *fakeConcurrencyCache) GetAccountConcurrencyBatch(_ context.Context, acc
 }
 func (f *fakeConcurrencyCache) CleanupExpiredAccountSlots(context.Context, int64) error { return nil }
 func (f *fakeConcurrencyCache) CleanupStaleProcessSlots(context.Context, string) error  { return nil }
+func (f *fakeConcurrencyCache) AcquireAccountModelSlot(context.Context, service.AccountModelScope, int, string) (bool, error) {
+	return true, nil
+}
+func (f *fakeConcurrencyCache) ReleaseAccountModelSlot(context.Context, service.AccountModelScope, string) error {
+	return nil
+}
+func (f *fakeConcurrencyCache) AcquireAccountModelRPM(context.Context, service.AccountModelScope, int, string) (bool, error) {
+	return true, nil
+}
+func (f *fakeConcurrencyCache) ReleaseAccountModelRPM(context.Context, service.AccountModelScope, string) error {
+	return nil
+}
+func (f *fakeConcurrencyCache) GetAccountModelLoadBatch(context.Context, []service.AccountModelScope) (map[int64]*service.AccountModelLoadInfo, error) {
+	return map[int64]*service.AccountModelLoadInfo{}, nil
+}
 
 func newTestGatewayHandler(t *testing.T, group *service.Group, accounts []*service.Account) (*GatewayHandler, func()) {
 	t.Helper()
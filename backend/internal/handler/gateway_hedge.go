@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// hedgeForwardFunc 单次上游转发（生产环境为 GatewayService.Forward）
+type hedgeForwardFunc func(ctx context.Context, c *gin.Context, account *service.Account, parsed *service.ParsedRequest) (*service.ForwardResult, error)
+
+// hedgeCandidate 一个已获取槽位、待发起转发的账号
+type hedgeCandidate struct {
+	account *service.Account
+	release func()
+	parsed  *service.ParsedRequest
+}
+
+// hedgeAttempt 一次转发尝试。每个尝试使用独立的 gin.Context 副本与响应缓冲，
+// 胜出者的响应最终才写回客户端。
+type hedgeAttempt struct {
+	hedgeCandidate
+	gc      *gin.Context
+	writer  *hedgeResponseWriter
+	cancel  context.CancelFunc
+	headers chan struct{} // 上游返回响应头时关闭
+	done    chan struct{} // 转发结束时关闭
+	result  *service.ForwardResult
+	err     error
+
+	started       time.Time
+	headerLatency time.Duration // headers 关闭前写入
+}
+
+// hedgeLatencyObserver 记录上游响应头延迟；censored 表示请求在收到响应头前已被取消
+type hedgeLatencyObserver func(latency time.Duration, censored bool)
+
+// hedgeOutcome 对冲转发的最终结果
+type hedgeOutcome struct {
+	account *service.Account
+	release func()
+	result  *service.ForwardResult
+	err     error
+	hedged  bool
+	// failedHedgeAccount 两个请求都失败时，对冲账号需要同样计入 failover 排除列表
+	failedHedgeAccount *service.Account
+}
+
+func startHedgeAttempt(c *gin.Context, ctx context.Context, forward hedgeForwardFunc, candidate hedgeCandidate) *hedgeAttempt {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	a := &hedgeAttempt{
+		hedgeCandidate: candidate,
+		writer:         newHedgeResponseWriter(),
+		cancel:         cancel,
+		headers:        make(chan struct{}),
+		done:           make(chan struct{}),
+		started:        time.Now(),
+	}
+	var headersOnce sync.Once
+	attemptCtx = httptrace.WithClientTrace(attemptCtx, &httptrace.ClientTrace{
+		GotFirstResponseByte: func() {
+			headersOnce.Do(func() {
+				a.headerLatency = time.Since(a.started)
+				close(a.headers)
+			})
+		},
+	})
+	a.gc = c.Copy()
+	a.gc.Writer = a.writer
+	a.gc.Request = c.Request.WithContext(attemptCtx)
+
+	go func() {
+		defer close(a.done)
+		defer func() {
+			if r := recover(); r != nil {
+				a.err = fmt.Errorf("hedge attempt panic: %v", r)
+			}
+		}()
+		a.result, a.err = forward(attemptCtx, a.gc, a.account, a.parsed)
+	}()
+	return a
+}
+
+// observedHeaderLatency 返回上游响应头延迟；尚未收到响应头时返回 false
+func (a *hedgeAttempt) observedHeaderLatency() (time.Duration, bool) {
+	select {
+	case <-a.headers:
+		return a.headerLatency, true
+	default:
+		return 0, false
+	}
+}
+
+// runHedgedForward 发起首个请求；若 delay 内上游未返回响应头，则通过 launch 在另一账号上发起对冲请求，
+// 取先成功者。落败请求被取消，其槽位在转发真正结束后释放，并交由 onLoser 处理（计费策略）。
+// 胜出（或最终失败）尝试的响应与上下文键值会写回原始 gin.Context。
+// observe 用于记录各尝试的响应头延迟（可为 nil），落败请求在收到响应头前被取消时按截尾样本记录。
+func runHedgedForward(
+	c *gin.Context,
+	ctx context.Context,
+	forward hedgeForwardFunc,
+	primary hedgeCandidate,
+	delay time.Duration,
+	launch func() *hedgeCandidate,
+	onLoser func(account *service.Account, result *service.ForwardResult),
+	observe hedgeLatencyObserver,
+) hedgeOutcome {
+	if observe == nil {
+		observe = func(time.Duration, bool) {}
+	}
+	first := startHedgeAttempt(c, ctx, forward, primary)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var second *hedgeAttempt
+	select {
+	case <-first.done:
+	case <-first.headers:
+	case <-timer.C:
+		if candidate := launch(); candidate != nil {
+			second = startHedgeAttempt(c, ctx, forward, *candidate)
+		}
+	}
+	if second == nil {
+		<-first.done
+		if latency, ok := first.observedHeaderLatency(); ok {
+			observe(latency, false)
+		}
+		return commitHedgeAttempt(c, first, false)
+	}
+
+	var winner, loser *hedgeAttempt
+	select {
+	case <-first.done:
+		winner, loser = first, second
+	case <-second.done:
+		winner, loser = second, first
+	}
+	if winner.err != nil {
+		<-loser.done
+		if loser.err == nil {
+			winner, loser = loser, winner
+		} else {
+			// 两个请求都失败：以首个请求的错误为准
+			winner, loser = first, second
+		}
+	}
+
+	if latency, ok := winner.observedHeaderLatency(); ok {
+		observe(latency, false)
+	}
+
+	loserFinished := false
+	select {
+	case <-loser.done:
+		loserFinished = true
+	default:
+	}
+	canceledAfter := time.Since(loser.started)
+	loser.cancel()
+	go func() {
+		<-loser.done
+		if latency, ok := loser.observedHeaderLatency(); ok {
+			observe(latency, false)
+		} else if !loserFinished {
+			// 在收到响应头前被取消：实际延迟至少为 canceledAfter
+			observe(canceledAfter, true)
+		}
+		if loser.release != nil {
+			loser.release()
+		}
+		if loser.err == nil && loser.result != nil && onLoser != nil {
+			onLoser(loser.account, loser.result)
+		}
+	}()
+
+	outcome := commitHedgeAttempt(c, winner, true)
+	if winner.err != nil && loser.err != nil {
+		var failoverErr *service.UpstreamFailoverError
+		if errors.As(loser.err, &failoverErr) {
+			outcome.failedHedgeAccount = loser.account
+		}
+	}
+	return outcome
+}
+
+// commitHedgeAttempt 将尝试的上下文键值与缓冲响应写回原始请求
+func commitHedgeAttempt(c *gin.Context, a *hedgeAttempt, hedged bool) hedgeOutcome {
+	a.cancel()
+	for k, v := range a.gc.Keys {
+		c.Set(k, v)
+	}
+	a.writer.flushTo(c.Writer)
+	return hedgeOutcome{
+		account: a.account,
+		release: a.release,
+		result:  a.result,
+		err:     a.err,
+		hedged:  hedged,
+	}
+}
+
+// hedgeRequestCopy 为对冲请求准备独立的深拷贝，避免与首个请求共享 Body / Messages 等可变数据；
+// 对冲请求不继承 OnUpstreamAccepted（串行锁由首个请求负责释放）
+func hedgeRequestCopy(parsed *service.ParsedRequest) *service.ParsedRequest {
+	cloned := parsed.Clone()
+	if cloned != nil {
+		cloned.OnUpstreamAccepted = nil
+	}
+	return cloned
+}
+
+// forwardHedged 以对冲方式转发 Anthropic 非流式请求
+func (h *GatewayHandler) forwardHedged(
+	c *gin.Context,
+	ctx context.Context,
+	apiKey *service.APIKey,
+	primary hedgeCandidate,
+	reqModel string,
+	failedAccountIDs map[int64]struct{},
+	onLoser func(account *service.Account, result *service.ForwardResult),
+) hedgeOutcome {
+	// 须在首个请求开始转发前完成拷贝，否则会与其对请求的修改并发
+	hedgeParsed := hedgeRequestCopy(primary.parsed)
+
+	launch := func() *hedgeCandidate {
+		excluded := make(map[int64]struct{}, len(failedAccountIDs)+1)
+		for id := range failedAccountIDs {
+			excluded[id] = struct{}{}
+		}
+		excluded[primary.account.ID] = struct{}{}
+
+		// 对冲请求不绑定粘性会话，且只使用可立即获取槽位的账号
+		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(ctx, apiKey.GroupID, "", reqModel, excluded, "")
+		if err != nil || selection == nil || selection.Account == nil {
+			return nil
+		}
+		if !selection.Acquired {
+			return nil
+		}
+		account := selection.Account
+		if account.Platform == service.PlatformAntigravity && account.Type != service.AccountTypeAPIKey {
+			if selection.ReleaseFunc != nil {
+				selection.ReleaseFunc()
+			}
+			return nil
+		}
+		return &hedgeCandidate{
+			account: account,
+			release: wrapReleaseOnDone(c.Request.Context(), selection.ReleaseFunc),
+			parsed:  hedgeParsed,
+		}
+	}
+	observe := func(latency time.Duration, censored bool) {
+		h.hedgeDelayService.ObserveHeaderLatency(apiKey.Group, latency, censored)
+	}
+	return runHedgedForward(c, ctx, h.gatewayService.Forward, primary, h.hedgeDelayService.Delay(apiKey.Group), launch, onLoser, observe)
+}
+
+// hedgeResponseWriter 缓冲单次尝试的响应，实现 gin.ResponseWriter
+type hedgeResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func newHedgeResponseWriter() *hedgeResponseWriter {
+	return &hedgeResponseWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+func (w *hedgeResponseWriter) Header() http.Header { return w.header }
+
+func (w *hedgeResponseWriter) WriteHeader(code int) {
+	if w.wrote {
+		return
+	}
+	w.status = code
+	w.wrote = true
+}
+
+func (w *hedgeResponseWriter) WriteHeaderNow() { w.WriteHeader(w.status) }
+
+func (w *hedgeResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeaderNow()
+	return w.body.Write(p)
+}
+
+func (w *hedgeResponseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	return w.body.WriteString(s)
+}
+
+func (w *hedgeResponseWriter) Status() int { return w.status }
+
+func (w *hedgeResponseWriter) Size() int {
+	if !w.wrote {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *hedgeResponseWriter) Written() bool { return w.wrote }
+
+func (w *hedgeResponseWriter) Flush() {}
+
+func (w *hedgeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("hedge response writer does not support hijack")
+}
+
+func (w *hedgeResponseWriter) CloseNotify() <-chan bool { return make(chan bool) }
+
+func (w *hedgeResponseWriter) Pusher() http.Pusher { return nil }
+
+// flushTo 将缓冲的响应写入真实的 ResponseWriter（未写入任何内容时不做处理）
+func (w *hedgeResponseWriter) flushTo(dst gin.ResponseWriter) {
+	if !w.wrote {
+		return
+	}
+	for k, values := range w.header {
+		dst.Header()[k] = values
+	}
+	dst.WriteHeader(w.status)
+	_, _ = dst.Write(w.body.Bytes())
+}
+
+// hedgeLoserUsageRecorder 返回落败对冲请求的用量记录回调（分组策略为 bill 时使用）。
+// base 需在请求 goroutine 中预先构造，回调中不能再访问 gin.Context。
+func (h *GatewayHandler) hedgeLoserUsageRecorder(c *gin.Context, base service.RecordUsageInput, modelFallback *service.ModelFallbackChain) func(*service.Account, *service.ForwardResult) {
+	reqLog := requestLogger(c, "handler.gateway.hedge")
+	return func(account *service.Account, result *service.ForwardResult) {
+		modelFallback.ApplyToResult(result)
+		input := base
+		input.Account = account
+		input.Result = result
+		h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
+			if err := h.gatewayService.RecordUsage(ctx, &input); err != nil {
+				reqLog.Error("gateway.hedge_loser_record_usage_failed", zap.Int64("account_id", account.ID), zap.Error(err))
+			}
+		}))
+	}
+}
@@ -0,0 +1,334 @@
+//go:build unit
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// hedgeTestUpstream 按账号模拟上游：延迟后写响应，或返回错误
+type hedgeTestUpstream struct {
+	delay       map[int64]time.Duration
+	errs        map[int64]error
+	sendHeaders map[int64]bool
+}
+
+func (u *hedgeTestUpstream) forward(ctx context.Context, c *gin.Context, account *service.Account, _ *service.ParsedRequest) (*service.ForwardResult, error) {
+	if u.sendHeaders[account.ID] {
+		if trace := httptrace.ContextClientTrace(ctx); trace != nil && trace.GotFirstResponseByte != nil {
+			trace.GotFirstResponseByte()
+		}
+	}
+	select {
+	case <-time.After(u.delay[account.ID]):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if err := u.errs[account.ID]; err != nil {
+		return nil, err
+	}
+	c.Set("served_by", account.ID)
+	c.Header("X-Account", account.Name)
+	c.String(http.StatusOK, account.Name)
+	return &service.ForwardResult{Model: "claude"}, nil
+}
+
+type hedgeReleaseCounter struct {
+	mu    sync.Mutex
+	calls map[int64]int
+}
+
+func (r *hedgeReleaseCounter) release(id int64) func() {
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.calls == nil {
+			r.calls = make(map[int64]int)
+		}
+		r.calls[id]++
+	}
+}
+
+func (r *hedgeReleaseCounter) count(id int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[id]
+}
+
+func newHedgeTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	return c, rec
+}
+
+func TestRunHedgedForward_SecondAccountWins(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	primary := &service.Account{ID: 1, Name: "slow"}
+	backup := &service.Account{ID: 2, Name: "fast"}
+	upstream := &hedgeTestUpstream{delay: map[int64]time.Duration{1: 2 * time.Second, 2: 10 * time.Millisecond}}
+	releases := &hedgeReleaseCounter{}
+
+	launched := false
+	outcome := runHedgedForward(c, context.Background(), upstream.forward,
+		hedgeCandidate{account: primary, release: releases.release(1), parsed: &service.ParsedRequest{}},
+		20*time.Millisecond,
+		func() *hedgeCandidate {
+			launched = true
+			return &hedgeCandidate{account: backup, release: releases.release(2), parsed: &service.ParsedRequest{}}
+		},
+		nil,
+		nil,
+	)
+
+	require.True(t, launched)
+	require.True(t, outcome.hedged)
+	require.NoError(t, outcome.err)
+	require.Equal(t, backup, outcome.account)
+	require.Equal(t, "fast", rec.Body.String())
+	require.Equal(t, "fast", rec.Header().Get("X-Account"))
+	servedBy, _ := c.Get("served_by")
+	require.Equal(t, int64(2), servedBy)
+
+	// 落败请求被取消后释放其槽位；胜出请求的槽位交由调用方释放
+	require.Eventually(t, func() bool { return releases.count(1) == 1 }, time.Second, 5*time.Millisecond)
+	require.Zero(t, releases.count(2))
+}
+
+func TestRunHedgedForward_NoHedgeWhenHeadersArriveInTime(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	primary := &service.Account{ID: 1, Name: "primary"}
+	upstream := &hedgeTestUpstream{
+		delay:       map[int64]time.Duration{1: 50 * time.Millisecond},
+		sendHeaders: map[int64]bool{1: true},
+	}
+
+	outcome := runHedgedForward(c, context.Background(), upstream.forward,
+		hedgeCandidate{account: primary, parsed: &service.ParsedRequest{}},
+		10*time.Millisecond,
+		func() *hedgeCandidate {
+			t.Fatal("hedge must not launch once upstream headers arrived")
+			return nil
+		},
+		nil,
+		nil,
+	)
+
+	require.False(t, outcome.hedged)
+	require.NoError(t, outcome.err)
+	require.Equal(t, primary, outcome.account)
+	require.Equal(t, "primary", rec.Body.String())
+}
+
+func TestRunHedgedForward_FailedPrimaryFallsBackToHedge(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	primary := &service.Account{ID: 1, Name: "broken"}
+	backup := &service.Account{ID: 2, Name: "backup"}
+	upstream := &hedgeTestUpstream{
+		delay: map[int64]time.Duration{1: 40 * time.Millisecond, 2: 80 * time.Millisecond},
+		errs:  map[int64]error{1: &service.UpstreamFailoverError{StatusCode: http.StatusBadGateway}},
+	}
+
+	outcome := runHedgedForward(c, context.Background(), upstream.forward,
+		hedgeCandidate{account: primary, parsed: &service.ParsedRequest{}},
+		10*time.Millisecond,
+		func() *hedgeCandidate {
+			return &hedgeCandidate{account: backup, parsed: &service.ParsedRequest{}}
+		},
+		nil,
+		nil,
+	)
+
+	require.NoError(t, outcome.err)
+	require.Equal(t, backup, outcome.account)
+	require.Equal(t, "backup", rec.Body.String())
+}
+
+func TestRunHedgedForward_BothFailReportsHedgeAccount(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	primary := &service.Account{ID: 1}
+	backup := &service.Account{ID: 2}
+	upstream := &hedgeTestUpstream{
+		delay: map[int64]time.Duration{1: 30 * time.Millisecond, 2: 10 * time.Millisecond},
+		errs: map[int64]error{
+			1: errors.New("primary failed"),
+			2: &service.UpstreamFailoverError{StatusCode: http.StatusServiceUnavailable},
+		},
+	}
+
+	outcome := runHedgedForward(c, context.Background(), upstream.forward,
+		hedgeCandidate{account: primary, parsed: &service.ParsedRequest{}},
+		10*time.Millisecond,
+		func() *hedgeCandidate {
+			return &hedgeCandidate{account: backup, parsed: &service.ParsedRequest{}}
+		},
+		nil,
+		nil,
+	)
+
+	require.EqualError(t, outcome.err, "primary failed")
+	require.Equal(t, primary, outcome.account)
+	require.Equal(t, backup, outcome.failedHedgeAccount)
+	require.Zero(t, rec.Body.Len())
+}
+
+func TestRunHedgedForward_LoserCallbackOnlyForCompletedLoser(t *testing.T) {
+	c, _ := newHedgeTestContext()
+	primary := &service.Account{ID: 1, Name: "a"}
+	backup := &service.Account{ID: 2, Name: "b"}
+	// 两个请求几乎同时完成：落败者若已成功完成，应触发 onLoser
+	upstream := &hedgeTestUpstream{delay: map[int64]time.Duration{1: 30 * time.Millisecond, 2: 0}}
+
+	var mu sync.Mutex
+	var losers []int64
+	outcome := runHedgedForward(c, context.Background(), upstream.forward,
+		hedgeCandidate{account: primary, parsed: &service.ParsedRequest{}},
+		25*time.Millisecond,
+		func() *hedgeCandidate {
+			return &hedgeCandidate{account: backup, parsed: &service.ParsedRequest{}}
+		},
+		func(account *service.Account, _ *service.ForwardResult) {
+			mu.Lock()
+			losers = append(losers, account.ID)
+			mu.Unlock()
+		},
+		nil,
+	)
+	require.NoError(t, outcome.err)
+
+	// 落败请求要么被取消（不回调），要么已完成（回调一次且不是胜出账号）
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.LessOrEqual(t, len(losers), 1)
+	for _, id := range losers {
+		require.NotEqual(t, outcome.account.ID, id)
+	}
+}
+
+func TestRunHedgedForward_ConcurrentAttemptsUseIndependentRequests(t *testing.T) {
+	c, _ := newHedgeTestContext()
+	primaryParsed, err := service.ParseGatewayRequest([]byte(`{"model":"claude","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`), "")
+	require.NoError(t, err)
+	primaryParsed.OnUpstreamAccepted = func() {}
+	hedgeParsed := hedgeRequestCopy(primaryParsed)
+	require.Nil(t, hedgeParsed.OnUpstreamAccepted)
+
+	// 两个尝试同时在途，并各自修改请求（模拟转发过程中的请求体改写），在 -race 下不得产生数据竞争
+	var mu sync.Mutex
+	seen := make(map[int64]*service.ParsedRequest)
+	forward := func(ctx context.Context, c *gin.Context, account *service.Account, parsed *service.ParsedRequest) (*service.ForwardResult, error) {
+		mu.Lock()
+		seen[account.ID] = parsed
+		mu.Unlock()
+		for i := 0; i < 20; i++ {
+			parsed.Body = append(parsed.Body[:0], fmt.Sprintf(`{"account":%d,"i":%d}`, account.ID, i)...)
+			parsed.Messages[0].(map[string]any)["content"] = fmt.Sprintf("account-%d", account.ID)
+			time.Sleep(time.Millisecond)
+		}
+		if account.ID == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		c.String(http.StatusOK, account.Name)
+		return &service.ForwardResult{Model: "claude"}, nil
+	}
+
+	outcome := runHedgedForward(c, context.Background(), forward,
+		hedgeCandidate{account: &service.Account{ID: 1, Name: "primary"}, parsed: primaryParsed},
+		5*time.Millisecond,
+		func() *hedgeCandidate {
+			return &hedgeCandidate{account: &service.Account{ID: 2, Name: "hedge"}, parsed: hedgeParsed}
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, outcome.err)
+	require.Equal(t, int64(2), outcome.account.ID)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Same(t, primaryParsed, seen[1])
+	require.Same(t, hedgeParsed, seen[2])
+	require.Equal(t, "account-2", hedgeParsed.Messages[0].(map[string]any)["content"])
+	require.Contains(t, string(hedgeParsed.Body), `"account":2`)
+}
+
+func TestRunHedgedForward_ObservesHeaderLatency(t *testing.T) {
+	c, _ := newHedgeTestContext()
+	primary := &service.Account{ID: 1, Name: "slow"}
+	backup := &service.Account{ID: 2, Name: "fast"}
+	upstream := &hedgeTestUpstream{
+		delay:       map[int64]time.Duration{1: 2 * time.Second, 2: 10 * time.Millisecond},
+		sendHeaders: map[int64]bool{2: true},
+	}
+
+	type observation struct {
+		latency  time.Duration
+		censored bool
+	}
+	var mu sync.Mutex
+	var observed []observation
+	outcome := runHedgedForward(c, context.Background(), upstream.forward,
+		hedgeCandidate{account: primary, parsed: &service.ParsedRequest{}},
+		20*time.Millisecond,
+		func() *hedgeCandidate {
+			return &hedgeCandidate{account: backup, parsed: &service.ParsedRequest{}}
+		},
+		nil,
+		func(latency time.Duration, censored bool) {
+			mu.Lock()
+			observed = append(observed, observation{latency: latency, censored: censored})
+			mu.Unlock()
+		},
+	)
+	require.NoError(t, outcome.err)
+	require.Equal(t, backup, outcome.account)
+
+	// 胜出请求记录实际响应头延迟；首个请求在收到响应头前被取消，记录为截尾样本
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(observed) == 2
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.False(t, observed[0].censored)
+	require.Less(t, observed[0].latency, 20*time.Millisecond)
+	require.True(t, observed[1].censored)
+	require.GreaterOrEqual(t, observed[1].latency, 20*time.Millisecond)
+}
+
+func TestHedgeResponseWriterFlushTo(t *testing.T) {
+	w := newHedgeResponseWriter()
+	require.False(t, w.Written())
+	require.Equal(t, -1, w.Size())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.WriteString(`{"error":"busy"}`)
+	require.Equal(t, http.StatusTooManyRequests, w.Status())
+
+	c, rec := newHedgeTestContext()
+	w.flushTo(c.Writer)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.Equal(t, `{"error":"busy"}`, rec.Body.String())
+}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -215,6 +217,23 @@ func (h *ConcurrencyHelper) DecrementAccountWaitCount(ctx context.Context, accou
 	h.concurrencyService.DecrementAccountWaitCount(ctx, accountID)
 }
 
+// IncrementAccountPlanWaitCount 按等待计划递增账号等待计数；batch 计划使用独立的 batch 等待队列
+func (h *ConcurrencyHelper) IncrementAccountPlanWaitCount(ctx context.Context, plan *service.AccountWaitPlan) (bool, error) {
+	if plan.Batch {
+		return h.concurrencyService.IncrementAccountBatchWaitCount(ctx, plan.AccountID, plan.MaxWaiting)
+	}
+	return h.concurrencyService.IncrementAccountWaitCount(ctx, plan.AccountID, plan.MaxWaiting)
+}
+
+// DecrementAccountPlanWaitCount 与 IncrementAccountPlanWaitCount 对应
+func (h *ConcurrencyHelper) DecrementAccountPlanWaitCount(ctx context.Context, plan *service.AccountWaitPlan) {
+	if plan.Batch {
+		h.concurrencyService.DecrementAccountBatchWaitCount(ctx, plan.AccountID)
+		return
+	}
+	h.concurrencyService.DecrementAccountWaitCount(ctx, plan.AccountID)
+}
+
 // TryAcquireUserSlot 尝试立即获取用户并发槽位。
 // 返回值: (releaseFunc, acquired, error)
 func (h *ConcurrencyHelper) TryAcquireUserSlot(ctx context.Context, userID int64, maxConcurrency int) (func(), bool, error) {
@@ -241,6 +260,20 @@ func (h *ConcurrencyHelper) TryAcquireAccountSlot(ctx context.Context, accountID
 	return result.ReleaseFunc, true, nil
 }
 
+// TryAcquireAccountModelSlot 尝试立即获取账号并发槽位，并同时占用请求模型命中的模型级槽位
+// （modelLimit 为 nil 时等同 TryAcquireAccountSlot）。
+// 返回值: (releaseFunc, acquired, error)
+func (h *ConcurrencyHelper) TryAcquireAccountModelSlot(ctx context.Context, accountID int64, maxConcurrency int, modelLimit *service.AccountModelLimit) (func(), bool, error) {
+	result, err := h.concurrencyService.AcquireAccountModelSlot(ctx, accountID, maxConcurrency, modelLimit)
+	if err != nil {
+		return nil, false, err
+	}
+	if !result.Acquired {
+		return nil, false, nil
+	}
+	return result.ReleaseFunc, true, nil
+}
+
 // AcquireUserSlotWithWait acquires a user concurrency slot, waiting if necessary.
 // For streaming requests, sends ping events during the wait.
 // streamStarted is updated if streaming response has begun.
@@ -289,6 +322,14 @@ func (h *ConcurrencyHelper) waitForSlotWithPing(c *gin.Context, slotType string,
 
 // waitForSlotWithPingTimeout waits for a concurrency slot with a custom timeout.
 func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType string, id int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool, tryImmediate bool) (func(), error) {
+	return h.waitForModelSlotWithPingTimeout(c, slotType, id, maxConcurrency, nil, false, timeout, isStream, streamStarted, tryImmediate)
+}
+
+// waitForModelSlotWithPingTimeout waits for a concurrency slot; for account slots a non-nil
+// modelLimit additionally requires the model-scoped slot (extra.model_limits) to be free.
+// batch waiters poll in their own queue and skip the fair queue, so they never block
+// interactive waiters from the reserved slots.
+func (h *ConcurrencyHelper) waitForModelSlotWithPingTimeout(c *gin.Context, slotType string, id int64, maxConcurrency int, modelLimit *service.AccountModelLimit, batch bool, timeout time.Duration, isStream bool, streamStarted *bool, tryImmediate bool) (func(), error) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 	defer cancel()
 
@@ -296,7 +337,7 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 		if slotType == "user" {
 			return h.concurrencyService.AcquireUserSlot(ctx, id, maxConcurrency)
 		}
-		return h.concurrencyService.AcquireAccountSlot(ctx, id, maxConcurrency)
+		return h.concurrencyService.AcquireAccountModelSlot(ctx, id, maxConcurrency, modelLimit)
 	}
 
 	if tryImmediate {
@@ -309,6 +350,37 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 		}
 	}
 
+	// 账号满载：加入公平队列，仅在轮到自己时才尝试抢占槽位
+	var ticket *service.FairQueueTicket
+	if slotType == "account" && !batch && h.concurrencyService.FairQueueEnabled() {
+		var position int
+		ticket, position = h.concurrencyService.EnterAccountQueue(ctx, id, fairQueuePrincipalFromContext(c))
+		if ticket != nil {
+			admitted := false
+			defer func() {
+				h.concurrencyService.LeaveAccountQueue(ticket, admitted)
+			}()
+			inner := acquireSlot
+			acquireSlot = func() (*service.AcquireResult, error) {
+				ok, pos := h.concurrencyService.CheckAccountQueueAdmission(ctx, ticket, maxConcurrency)
+				if pos > 0 && !*streamStarted {
+					c.Header(fairQueuePositionHeader, strconv.Itoa(pos))
+				}
+				if !ok {
+					return &service.AcquireResult{}, nil
+				}
+				result, err := inner()
+				if err == nil && result.Acquired {
+					admitted = true
+				}
+				return result, err
+			}
+			if !*streamStarted {
+				c.Header(fairQueuePositionHeader, strconv.Itoa(position))
+			}
+		}
+	}
+
 	// Determine if ping is needed (streaming + ping format defined)
 	needPing := isStream && h.pingFormat != ""
 
@@ -371,11 +443,32 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 	}
 }
 
+// fairQueuePositionHeader 账号满载排队时向客户端报告的队列位置（从 1 开始）
+const fairQueuePositionHeader = "X-Queue-Position"
+
+// fairQueuePrincipalFromContext 从认证上下文解析公平排队主体（用户/分组的权重与优先级档位）
+func fairQueuePrincipalFromContext(c *gin.Context) service.FairQueuePrincipal {
+	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+	principal := service.ResolveFairQueuePrincipal(apiKey)
+	if principal.UserID == 0 {
+		if subject, ok := middleware2.GetAuthSubjectFromContext(c); ok {
+			principal.UserID = subject.UserID
+		}
+	}
+	return principal
+}
+
 // AcquireAccountSlotWithWaitTimeout acquires an account slot with a custom timeout (keeps SSE ping).
 func (h *ConcurrencyHelper) AcquireAccountSlotWithWaitTimeout(c *gin.Context, accountID int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (func(), error) {
 	return h.waitForSlotWithPingTimeout(c, "account", accountID, maxConcurrency, timeout, isStream, streamStarted, true)
 }
 
+// AcquireAccountSlotForWaitPlan 按等待计划获取账号槽位（保持 SSE ping），
+// 同时等待请求模型命中的模型级槽位（modelLimit 为 nil 时仅等待账号槽位）。
+func (h *ConcurrencyHelper) AcquireAccountSlotForWaitPlan(c *gin.Context, plan *service.AccountWaitPlan, modelLimit *service.AccountModelLimit, isStream bool, streamStarted *bool) (func(), error) {
+	return h.waitForModelSlotWithPingTimeout(c, "account", plan.AccountID, plan.MaxConcurrency, modelLimit, plan.Batch, plan.Timeout, isStream, streamStarted, true)
+}
+
 // nextBackoff 计算下一次退避时间
 // 性能优化：使用指数退避 + 随机抖动，避免惊群效应
 // current: 当前退避时间
@@ -53,6 +53,14 @@ func (m *concurrencyCacheMock) GetAccountWaitingCount(ctx context.Context, accou
 	return 0, nil
 }
 
+func (m *concurrencyCacheMock) IncrementAccountBatchWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error) {
+	return true, nil
+}
+
+func (m *concurrencyCacheMock) DecrementAccountBatchWaitCount(ctx context.Context, accountID int64) error {
+	return nil
+}
+
 func (m *concurrencyCacheMock) AcquireUserSlot(ctx context.Context, userID int64, maxConcurrency int, requestID string) (bool, error) {
 	if m.acquireUserSlotFn != nil {
 		return m.acquireUserSlotFn(ctx, userID, maxConcurrency, requestID)
@@ -93,6 +101,25 @@ func (m *concurrencyCacheMock) CleanupStaleProcessSlots(ctx context.Context, act
 	return nil
 }
 
+func (m *concurrencyCacheMock) AcquireAccountModelSlot(ctx context.Context, scope service.AccountModelScope, maxConcurrency int, requestID string) (bool, error) {
+	return true, nil
+}
+
+func (m *concurrencyCacheMock) ReleaseAccountModelSlot(ctx context.Context, scope service.AccountModelScope, requestID string) error {
+	return nil
+}
+
+func (m *concurrencyCacheMock) AcquireAccountModelRPM(ctx context.Context, scope service.AccountModelScope, maxRPM int, requestID string) (bool, error) {
+	return true, nil
+}
+func (m *concurrencyCacheMock) ReleaseAccountModelRPM(ctx context.Context, scope service.AccountModelScope, requestID string) error {
+	return nil
+}
+
+func (m *concurrencyCacheMock) GetAccountModelLoadBatch(ctx context.Context, scopes []service.AccountModelScope) (map[int64]*service.AccountModelLoadInfo, error) {
+	return map[int64]*service.AccountModelLoadInfo{}, nil
+}
+
 func TestConcurrencyHelper_TryAcquireUserSlot(t *testing.T) {
 	cache := &concurrencyCacheMock{
 		acquireUserSlotFn: func(ctx context.Context, userID int64, maxConcurrency int, requestID string) (bool, error) {
@@ -69,6 +69,14 @@ func (s *helperConcurrencyCacheStub) GetAccountWaitingCount(ctx context.Context,
 	return 0, nil
 }
 
+func (s *helperConcurrencyCacheStub) IncrementAccountBatchWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error) {
+	return true, nil
+}
+
+func (s *helperConcurrencyCacheStub) DecrementAccountBatchWaitCount(ctx context.Context, accountID int64) error {
+	return nil
+}
+
 func (s *helperConcurrencyCacheStub) AcquireUserSlot(ctx context.Context, userID int64, maxConcurrency int, requestID string) (bool, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -124,6 +132,25 @@ func (s *helperConcurrencyCacheStub) CleanupStaleProcessSlots(ctx context.Contex
 	return nil
 }
 
+func (s *helperConcurrencyCacheStub) AcquireAccountModelSlot(ctx context.Context, scope service.AccountModelScope, maxConcurrency int, requestID string) (bool, error) {
+	return true, nil
+}
+
+func (s *helperConcurrencyCacheStub) ReleaseAccountModelSlot(ctx context.Context, scope service.AccountModelScope, requestID string) error {
+	return nil
+}
+
+func (s *helperConcurrencyCacheStub) AcquireAccountModelRPM(ctx context.Context, scope service.AccountModelScope, maxRPM int, requestID string) (bool, error) {
+	return true, nil
+}
+func (s *helperConcurrencyCacheStub) ReleaseAccountModelRPM(ctx context.Context, scope service.AccountModelScope, requestID string) error {
+	return nil
+}
+
+func (s *helperConcurrencyCacheStub) GetAccountModelLoadBatch(ctx context.Context, scopes []service.AccountModelScope) (map[int64]*service.AccountModelLoadInfo, error) {
+	return map[int64]*service.AccountModelLoadInfo{}, nil
+}
+
 func newHelperTestContext(method, path string) (*gin.Context, *httptest.ResponseRecorder) {
 	gin.SetMode(gin.TestMode)
 	rec := httptest.NewRecorder()
@@ -319,3 +346,80 @@ type helperConcurrencyCacheStubWithError struct {
 func (s *helperConcurrencyCacheStubWithError) AcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error) {
 	return false, s.err
 }
+
+type helperFairQueueStub struct {
+	mu        sync.Mutex
+	positions []int
+	left      []bool
+}
+
+func (s *helperFairQueueStub) Enqueue(ctx context.Context, ticket *service.FairQueueTicket) (int, error) {
+	return 3, nil
+}
+
+func (s *helperFairQueueStub) Position(ctx context.Context, ticket *service.FairQueueTicket) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.positions) == 0 {
+		return 1, nil
+	}
+	v := s.positions[0]
+	s.positions = s.positions[1:]
+	return v, nil
+}
+
+func (s *helperFairQueueStub) Leave(ctx context.Context, ticket *service.FairQueueTicket, admitted bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.left = append(s.left, admitted)
+	return nil
+}
+
+func (s *helperFairQueueStub) GetSnapshot(ctx context.Context, maxPerAccount int) ([]service.FairQueueAccountSnapshot, error) {
+	return nil, nil
+}
+
+func TestAcquireAccountSlotWithWaitTimeout_FairQueueGatesAcquire(t *testing.T) {
+	cache := &helperConcurrencyCacheStub{
+		accountSeq: []bool{false, true},
+	}
+	fairQueue := &helperFairQueueStub{positions: []int{2, 1}}
+	concurrency := service.NewConcurrencyService(cache)
+	concurrency.SetFairQueue(fairQueue, service.FairQueuePrincipalUser)
+	helper := NewConcurrencyHelper(concurrency, SSEPingFormatNone, 5*time.Millisecond)
+	c, rec := newHelperTestContext(http.MethodPost, "/v1/messages")
+	streamStarted := false
+
+	// maxConcurrency=1 且无空闲槽位：位置 2 时不尝试获取，轮到队首后才获取
+	release, err := helper.AcquireAccountSlotWithWaitTimeout(c, 401, 1, time.Second, false, &streamStarted)
+	require.NoError(t, err)
+	require.NotNil(t, release)
+	release()
+
+	require.Equal(t, 2, cache.accountAcquireCalls)
+	require.Equal(t, []bool{true}, fairQueue.left)
+	require.Equal(t, "1", rec.Header().Get(fairQueuePositionHeader))
+}
+
+func TestAcquireAccountSlotForWaitPlan_BatchSkipsFairQueue(t *testing.T) {
+	cache := &helperConcurrencyCacheStub{
+		accountSeq: []bool{false, true},
+	}
+	fairQueue := &helperFairQueueStub{positions: []int{2, 2}}
+	concurrency := service.NewConcurrencyService(cache)
+	concurrency.SetFairQueue(fairQueue, service.FairQueuePrincipalUser)
+	helper := NewConcurrencyHelper(concurrency, SSEPingFormatNone, 5*time.Millisecond)
+	c, rec := newHelperTestContext(http.MethodPost, "/v1/messages")
+	streamStarted := false
+
+	// batch 等待者不进入公平队列，不会挡在交互式等待者之前
+	plan := &service.AccountWaitPlan{AccountID: 402, MaxConcurrency: 1, Timeout: time.Second, Batch: true}
+	release, err := helper.AcquireAccountSlotForWaitPlan(c, plan, nil, false, &streamStarted)
+	require.NoError(t, err)
+	require.NotNil(t, release)
+	release()
+
+	require.Equal(t, 2, cache.accountAcquireCalls)
+	require.Empty(t, fairQueue.left)
+	require.Empty(t, rec.Header().Get(fairQueuePositionHeader))
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
+)
+
+// modelFallbackServedModelHeader 分组模型降级生效时返回实际服务的模型
+const modelFallbackServedModelHeader = "X-Sub2API-Served-Model"
+
+// modelFallbackSwitch 一次模型降级切换的结果
+type modelFallbackSwitch struct {
+	target       service.ModelFallbackTarget
+	body         []byte
+	apiKey       *service.APIKey
+	subscription *service.UserSubscription
+}
+
+// modelFallbackGroupResolver 解析降级目标分组（GatewayService / OpenAIGatewayService）
+type modelFallbackGroupResolver interface {
+	ResolveGroupByID(ctx context.Context, groupID int64) (*service.Group, error)
+}
+
+func (h *GatewayHandler) nextModelFallback(
+	c *gin.Context,
+	chain *service.ModelFallbackChain,
+	apiKey *service.APIKey,
+	subscription *service.UserSubscription,
+	body []byte,
+	reqLog *zap.Logger,
+) (*modelFallbackSwitch, bool) {
+	return nextModelFallback(c, h.gatewayService, h.billingCacheService, chain, apiKey, subscription, body, reqLog)
+}
+
+// nextModelFallback 沿降级链推进到下一个可用目标：改写请求体中的模型，必要时切换到目标分组。
+// 目标分组不可用或计费校验失败时跳过该目标；降级链耗尽时返回 false。
+// 未指定分组的目标始终回到 API Key 原始分组，并沿用原始订阅。
+// body 为 nil 表示模型不在请求体中（Gemini 原生 API 的模型位于 URL 路径），由调用方自行切换。
+func nextModelFallback(
+	c *gin.Context,
+	groups modelFallbackGroupResolver,
+	billingCacheService *service.BillingCacheService,
+	chain *service.ModelFallbackChain,
+	apiKey *service.APIKey,
+	subscription *service.UserSubscription,
+	body []byte,
+	reqLog *zap.Logger,
+) (*modelFallbackSwitch, bool) {
+	for {
+		target, ok := chain.Next()
+		if !ok {
+			return nil, false
+		}
+
+		newBody := body
+		if body != nil {
+			var err error
+			newBody, err = sjson.SetBytes(body, "model", target.Model)
+			if err != nil {
+				reqLog.Warn("gateway.model_fallback_rewrite_failed", zap.String("fallback_model", target.Model), zap.Error(err))
+				continue
+			}
+		}
+
+		next := &modelFallbackSwitch{target: target, body: newBody, apiKey: apiKey, subscription: subscription}
+		if target.GroupID != nil && (apiKey.GroupID == nil || *target.GroupID != *apiKey.GroupID) {
+			group, err := groups.ResolveGroupByID(c.Request.Context(), *target.GroupID)
+			if err != nil {
+				reqLog.Warn("gateway.model_fallback_resolve_group_failed", zap.Int64("fallback_group_id", *target.GroupID), zap.Error(err))
+				continue
+			}
+			if !group.IsActive() || group.IsSubscriptionType() || apiKey.Group == nil || group.Platform != apiKey.Group.Platform {
+				reqLog.Warn("gateway.model_fallback_group_invalid",
+					zap.Int64("fallback_group_id", group.ID),
+					zap.String("fallback_platform", group.Platform),
+					zap.String("fallback_status", group.Status),
+				)
+				continue
+			}
+			fallbackAPIKey := cloneAPIKeyWithGroup(apiKey, group)
+			if err := billingCacheService.CheckBillingEligibility(c.Request.Context(), fallbackAPIKey.User, fallbackAPIKey, group, nil); err != nil {
+				reqLog.Info("gateway.model_fallback_billing_ineligible", zap.Int64("fallback_group_id", group.ID), zap.Error(err))
+				continue
+			}
+			next.apiKey = fallbackAPIKey
+			next.subscription = nil
+		}
+		return next, true
+	}
+}
+
+// applyModelFallbackSwitch 将降级目标写入请求上下文：按目标分组平台调度，并通过响应头告知实际服务的模型
+func applyModelFallbackSwitch(c *gin.Context, next *modelFallbackSwitch) {
+	if next.target.GroupID != nil {
+		ctx := context.WithValue(c.Request.Context(), ctxkey.ForcePlatform, "")
+		c.Request = c.Request.WithContext(ctx)
+	}
+	if !c.Writer.Written() {
+		c.Header(modelFallbackServedModelHeader, next.target.Model)
+	}
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNextModelFallback_RewritesBodyModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+	group := &service.Group{ID: 1, Platform: service.PlatformAnthropic, ModelFallbacks: map[string][]service.ModelFallbackTarget{
+		"claude-opus-*": {{Model: "claude-sonnet-4-5"}},
+	}}
+	apiKey := &service.APIKey{ID: 1, GroupID: &group.ID, Group: group}
+	chain := service.NewModelFallbackChain(group, "claude-opus-4-5")
+
+	h := &GatewayHandler{}
+	next, ok := h.nextModelFallback(c, chain, apiKey, nil, []byte(`{"model":"claude-opus-4-5"}`), zap.NewNop())
+	require.True(t, ok)
+	require.JSONEq(t, `{"model":"claude-sonnet-4-5"}`, string(next.body))
+	require.Same(t, apiKey, next.apiKey)
+
+	_, ok = h.nextModelFallback(c, chain, apiKey, nil, []byte(`{}`), zap.NewNop())
+	require.False(t, ok)
+}
+
+func TestNextModelFallback_GeminiNativeKeepsBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-2.5-pro:generateContent", nil)
+
+	group := &service.Group{ID: 2, Platform: service.PlatformGemini, ModelFallbacks: map[string][]service.ModelFallbackTarget{
+		"gemini-2.5-pro": {{Model: "gemini-2.5-flash"}},
+	}}
+	apiKey := &service.APIKey{ID: 1, GroupID: &group.ID, Group: group}
+	chain := service.NewModelFallbackChain(group, "gemini-2.5-pro")
+
+	next, ok := (&GatewayHandler{}).nextModelFallback(c, chain, apiKey, nil, nil, zap.NewNop())
+	require.True(t, ok)
+	require.Nil(t, next.body)
+	require.Equal(t, "gemini-2.5-flash", next.target.Model)
+}
+
+type modelFallbackGroupResolverStub struct {
+	groups map[int64]*service.Group
+}
+
+func (s *modelFallbackGroupResolverStub) ResolveGroupByID(_ context.Context, groupID int64) (*service.Group, error) {
+	group, ok := s.groups[groupID]
+	if !ok {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+func TestNextModelFallback_OpenAISwitchesGroup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+
+	cfg := &config.Config{RunMode: config.RunModeSimple}
+	billingCacheService := service.NewBillingCacheService(nil, nil, nil, nil, cfg)
+	t.Cleanup(billingCacheService.Stop)
+
+	missingID := int64(3)
+	soraID := int64(4)
+	targetID := int64(5)
+	resolver := &modelFallbackGroupResolverStub{groups: map[int64]*service.Group{
+		soraID:   {ID: soraID, Platform: service.PlatformSora, Status: service.StatusActive},
+		targetID: {ID: targetID, Platform: service.PlatformOpenAI, Status: service.StatusActive},
+	}}
+	group := &service.Group{ID: 1, Platform: service.PlatformOpenAI, ModelFallbacks: map[string][]service.ModelFallbackTarget{
+		"gpt-5.1*": {
+			{Model: "gpt-5-mini", GroupID: &missingID},
+			{Model: "gpt-5-mini", GroupID: &soraID},
+			{Model: "gpt-5-nano", GroupID: &targetID},
+		},
+	}}
+	apiKey := &service.APIKey{ID: 1, GroupID: &group.ID, Group: group, User: &service.User{ID: 9}}
+	subscription := &service.UserSubscription{ID: 7}
+	chain := service.NewModelFallbackChain(group, "gpt-5.1-codex")
+
+	next, ok := nextModelFallback(c, resolver, billingCacheService, chain, apiKey, subscription, []byte(`{"model":"gpt-5.1-codex"}`), zap.NewNop())
+	require.True(t, ok)
+	require.Equal(t, "gpt-5-nano", next.target.Model)
+	require.JSONEq(t, `{"model":"gpt-5-nano"}`, string(next.body))
+	require.Equal(t, targetID, *next.apiKey.GroupID)
+	require.Nil(t, next.subscription)
+	require.Equal(t, int64(1), *apiKey.GroupID)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// applyGroupRequestTransforms 执行分组请求改写规则：请求体被改写时重新解析，请求头改写记录到请求上下文中由转发时应用。
+// 改写后的请求体无法解析时放弃请求体改写，按原始请求转发。
+func applyGroupRequestTransforms(
+	c *gin.Context,
+	group *service.Group,
+	body []byte,
+	parsedReq *service.ParsedRequest,
+	reqLog *zap.Logger,
+) ([]byte, *service.ParsedRequest) {
+	transformed, changed := transformGroupRequestBody(c, group, body, service.RequestTransformFormatAnthropic, "", reqLog)
+	if !changed {
+		return body, parsedReq
+	}
+
+	transformedReq, err := service.ParseGatewayRequest(transformed, domain.PlatformAnthropic)
+	if err != nil {
+		reqLog.Warn("gateway.request_transform_reparse_failed", zap.Error(err))
+		return body, parsedReq
+	}
+	return transformed, transformedReq
+}
+
+// transformGroupRequestBody 按入站请求格式执行分组请求改写规则，返回改写后的请求体及是否发生改写；
+// 请求头改写记录到请求上下文中由转发时应用。model 为空时从请求体读取；非法 JSON 请求体不改写，交由后续校验处理。
+func transformGroupRequestBody(
+	c *gin.Context,
+	group *service.Group,
+	body []byte,
+	format string,
+	model string,
+	reqLog *zap.Logger,
+) ([]byte, bool) {
+	if group == nil || len(group.RequestTransforms) == 0 || !gjson.ValidBytes(body) {
+		return body, false
+	}
+
+	result := service.ApplyRequestTransformsWithFormat(body, group.RequestTransforms, format, model)
+	for _, trace := range result.Trace {
+		if trace.Error != "" {
+			reqLog.Warn("gateway.request_transform_rule_failed",
+				zap.Int("rule_index", trace.Index),
+				zap.String("rule_name", trace.Name),
+				zap.String("action", trace.Action),
+				zap.String("error", trace.Error),
+			)
+		}
+	}
+	if len(result.Headers) > 0 {
+		c.Request = c.Request.WithContext(service.WithRequestHeaderTransforms(c.Request.Context(), result.Headers))
+	}
+	return result.Body, result.BodyChanged
+}
@@ -0,0 +1,39 @@
+//go:build unit
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+func TestTransformGroupRequestBody_GeminiNative(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-2.5-pro:generateContent", nil)
+
+	group := &service.Group{ID: 1, Platform: service.PlatformGemini, RequestTransforms: []service.RequestTransformRule{
+		{Action: service.RequestTransformRemove, Path: "generationConfig.thinkingConfig", Models: []string{"gemini-2.5-pro"}},
+		{Action: service.RequestTransformSetHeader, Header: "X-Team", Value: "team-a"},
+	}}
+	body := []byte(`{"contents":[],"generationConfig":{"thinkingConfig":{"thinkingBudget":1024}}}`)
+
+	before := c.Request.Context()
+	out, changed := transformGroupRequestBody(c, group, body, service.RequestTransformFormatGemini, "gemini-2.5-pro", zap.NewNop())
+	require.True(t, changed)
+	require.False(t, gjson.GetBytes(out, "generationConfig.thinkingConfig").Exists())
+
+	require.True(t, before != c.Request.Context(), "请求头改写记录到请求上下文")
+
+	// 非法 JSON 不改写
+	out, changed = transformGroupRequestBody(c, group, []byte(`{`), service.RequestTransformFormatGemini, "gemini-2.5-pro", zap.NewNop())
+	require.False(t, changed)
+	require.Equal(t, `{`, string(out))
+}
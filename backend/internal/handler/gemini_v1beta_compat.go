@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	pkghttputil "github.com/Wei-Shaw/sub2api/internal/pkg/httputil"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// geminiCountTokensRequest is the body of :countTokens, which carries either
+// bare contents or a full generateContentRequest.
+type geminiCountTokensRequest struct {
+	Contents               []apicompat.GeminiContent `json:"contents"`
+	GenerateContentRequest *apicompat.GeminiRequest  `json:"generateContentRequest"`
+}
+
+// GeminiV1BetaCompat serves the Gemini native API (generateContent,
+// streamGenerateContent and countTokens) on anthropic and openai groups so
+// that Gemini SDK / CLI clients can use those account pools. The request is
+// converted to Anthropic Messages and handled by messages — GatewayHandler.Messages
+// for anthropic groups, OpenAIGatewayHandler.Messages (which forwards to
+// Responses upstreams) for openai groups — so scheduling, billing and usage
+// recording are identical to /v1/messages traffic. The Anthropic output is
+// rewritten into Gemini JSON / SSE on the way out. countTokens is only
+// available when countTokens is non-nil.
+// POST /v1beta/models/{model}:{action}
+func (h *GatewayHandler) GeminiV1BetaCompat(c *gin.Context, messages, countTokens gin.HandlerFunc) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok || apiKey == nil {
+		googleError(c, http.StatusUnauthorized, "Invalid API key")
+		return
+	}
+	reqLog := requestLogger(
+		c,
+		"handler.gemini_v1beta.compat",
+		zap.Int64("api_key_id", apiKey.ID),
+		zap.Any("group_id", apiKey.GroupID),
+	)
+
+	modelName, action, err := parseGeminiModelAction(strings.TrimPrefix(c.Param("modelAction"), "/"))
+	if err != nil {
+		googleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	switch action {
+	case "generateContent", "streamGenerateContent":
+	case "countTokens":
+		if countTokens == nil {
+			googleError(c, http.StatusNotFound, "countTokens is not supported for this group")
+			return
+		}
+	default:
+		googleError(c, http.StatusNotFound, "Unsupported action: "+action)
+		return
+	}
+
+	body, err := pkghttputil.ReadRequestBodyWithPrealloc(c.Request)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			googleError(c, http.StatusRequestEntityTooLarge, buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		googleError(c, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		googleError(c, http.StatusBadRequest, "Request body is empty")
+		return
+	}
+
+	var geminiReq apicompat.GeminiRequest
+	if action == "countTokens" {
+		var countReq geminiCountTokensRequest
+		if err := json.Unmarshal(body, &countReq); err != nil {
+			googleError(c, http.StatusBadRequest, "Failed to parse request body")
+			return
+		}
+		if countReq.GenerateContentRequest != nil {
+			geminiReq = *countReq.GenerateContentRequest
+		} else {
+			geminiReq.Contents = countReq.Contents
+		}
+	} else if err := json.Unmarshal(body, &geminiReq); err != nil {
+		googleError(c, http.StatusBadRequest, "Failed to parse request body")
+		return
+	}
+
+	stream := action == "streamGenerateContent"
+	anthropicReq, err := apicompat.GeminiToAnthropicRequest(&geminiReq, modelName, stream)
+	if err != nil {
+		reqLog.Debug("gemini_v1beta.compat_convert_failed", zap.Error(err))
+		googleError(c, http.StatusBadRequest, "Failed to convert request: "+err.Error())
+		return
+	}
+	anthropicBody, err := json.Marshal(anthropicReq)
+	if err == nil && action == "countTokens" {
+		anthropicBody, err = stripCountTokensFields(anthropicBody)
+	}
+	if err != nil {
+		googleError(c, http.StatusInternalServerError, "Failed to build request")
+		return
+	}
+
+	includeThoughts := false
+	if cfg := geminiReq.GenerationConfig; cfg != nil && cfg.ThinkingConfig != nil {
+		includeThoughts = cfg.ThinkingConfig.IncludeThoughts
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(anthropicBody))
+	c.Request.ContentLength = int64(len(anthropicBody))
+	original := c.Writer
+	writer := newGeminiCompatWriter(original, action, c.Query("alt") == "sse", modelName, includeThoughts)
+	c.Writer = writer
+	if action == "countTokens" {
+		countTokens(c)
+	} else {
+		messages(c)
+	}
+	writer.finish()
+	c.Writer = original
+}
+
+// stripCountTokensFields removes Messages-only fields that count_tokens rejects.
+func stripCountTokensFields(body []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"max_tokens", "stream", "temperature", "top_p", "stop_sequences"} {
+		delete(fields, key)
+	}
+	return json.Marshal(fields)
+}
+
+// geminiCompatWriter rewrites the Anthropic Messages output produced by the
+// messages handler into Gemini format. Streaming output is converted line by
+// line into SSE (alt=sse) or a streamed JSON array; JSON bodies (non-stream
+// responses, countTokens and errors) are buffered and converted in finish().
+type geminiCompatWriter struct {
+	gin.ResponseWriter
+	action   string
+	sse      bool
+	state    *apicompat.AnthropicEventToGeminiState
+	model    string
+	thought  bool
+	lineBuf  []byte
+	body     bytes.Buffer
+	chunks   int
+	failed   bool
+	streamed bool
+}
+
+func newGeminiCompatWriter(w gin.ResponseWriter, action string, sse bool, model string, includeThoughts bool) *geminiCompatWriter {
+	return &geminiCompatWriter{
+		ResponseWriter: w,
+		action:         action,
+		sse:            sse,
+		model:          model,
+		thought:        includeThoughts,
+		state:          apicompat.NewAnthropicEventToGeminiState(model, includeThoughts),
+	}
+}
+
+// streaming latches once the upstream stream starts, since the JSON-array
+// format rewrites Content-Type away from text/event-stream.
+func (w *geminiCompatWriter) streaming() bool {
+	if !w.streamed {
+		w.streamed = w.action == "streamGenerateContent" && w.Status() < http.StatusBadRequest &&
+			strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+	}
+	return w.streamed
+}
+
+// Flush holds back the headers of a JSON-array stream until the first chunk
+// has set its Content-Type.
+func (w *geminiCompatWriter) Flush() {
+	if w.streaming() && !w.sse && w.chunks == 0 {
+		return
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *geminiCompatWriter) Write(p []byte) (int, error) {
+	if !w.streaming() {
+		return w.body.Write(p)
+	}
+	w.lineBuf = append(w.lineBuf, p...)
+	for {
+		i := bytes.IndexByte(w.lineBuf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.lineBuf[:i]), "\r")
+		w.lineBuf = w.lineBuf[i+1:]
+		if err := w.handleLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *geminiCompatWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Size reports buffered bytes as written so the gateway does not fail over
+// after a response has been produced.
+func (w *geminiCompatWriter) Size() int {
+	size := w.ResponseWriter.Size()
+	if n := w.body.Len() + len(w.lineBuf); n > 0 {
+		if size < 0 {
+			size = 0
+		}
+		size += n
+	}
+	return size
+}
+
+func (w *geminiCompatWriter) Written() bool {
+	return w.ResponseWriter.Written() || w.body.Len() > 0 || len(w.lineBuf) > 0
+}
+
+func (w *geminiCompatWriter) handleLine(line string) error {
+	payload, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return nil
+	}
+	payload = strings.TrimSpace(payload)
+	if payload == "" || payload == "[DONE]" {
+		return nil
+	}
+	var evt apicompat.AnthropicStreamEvent
+	if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+		return nil
+	}
+	if evt.Type == "ping" {
+		// Keepalive has no Gemini counterpart; SSE clients accept a comment.
+		if !w.sse {
+			return nil
+		}
+		_, err := w.ResponseWriter.WriteString(string(SSEPingFormatComment))
+		return err
+	}
+	if err := w.writeChunks(apicompat.AnthropicEventToGeminiChunks(&evt, w.state)); err != nil {
+		return err
+	}
+	if w.state.Failed != nil && !w.failed {
+		w.failed = true
+		return w.writeStreamError(w.state.Failed)
+	}
+	return nil
+}
+
+func (w *geminiCompatWriter) writeChunks(chunks []apicompat.GeminiResponse) error {
+	for _, chunk := range chunks {
+		var out string
+		if w.sse {
+			sse, err := apicompat.GeminiChunkToSSE(chunk)
+			if err != nil {
+				continue
+			}
+			out = sse
+		} else {
+			data, err := json.Marshal(chunk)
+			if err != nil {
+				continue
+			}
+			out = w.arraySeparator() + string(data)
+		}
+		if _, err := w.ResponseWriter.WriteString(out); err != nil {
+			return err
+		}
+		w.chunks++
+	}
+	return nil
+}
+
+// arraySeparator returns the prefix for the next element of a streamed JSON
+// array (the default streamGenerateContent format without alt=sse).
+func (w *geminiCompatWriter) arraySeparator() string {
+	if w.chunks == 0 {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		return "["
+	}
+	return ",\r\n"
+}
+
+// writeStreamError reports a mid-stream upstream error in Google error format.
+func (w *geminiCompatWriter) writeStreamError(anthropicErr *apicompat.AnthropicError) error {
+	status := anthropicErrorTypeToHTTPStatus(anthropicErr.Type)
+	data, err := json.Marshal(googleErrorBody(status, anthropicErr.Message))
+	if err != nil {
+		return err
+	}
+	out := string(data)
+	if w.sse {
+		out = "data: " + out + "\n\n"
+	} else {
+		out = w.arraySeparator() + out
+		w.chunks++
+	}
+	_, err = w.ResponseWriter.WriteString(out)
+	return err
+}
+
+// finish flushes converted output that was buffered during messages.
+func (w *geminiCompatWriter) finish() {
+	if w.streaming() {
+		if len(w.lineBuf) > 0 {
+			_ = w.handleLine(strings.TrimSpace(string(w.lineBuf)))
+			w.lineBuf = nil
+		}
+		_ = w.writeChunks(apicompat.FinalizeAnthropicGeminiStream(w.state))
+		if !w.sse {
+			if w.chunks == 0 {
+				_, _ = w.ResponseWriter.WriteString(w.arraySeparator())
+			}
+			_, _ = w.ResponseWriter.WriteString("]")
+		}
+		w.ResponseWriter.Flush()
+		return
+	}
+	if w.body.Len() == 0 {
+		return
+	}
+
+	out := w.body.Bytes()
+	if w.Status() >= http.StatusBadRequest {
+		message := http.StatusText(w.Status())
+		var anthropicErr struct {
+			Error apicompat.AnthropicError `json:"error"`
+		}
+		if err := json.Unmarshal(out, &anthropicErr); err == nil && anthropicErr.Error.Message != "" {
+			message = anthropicErr.Error.Message
+		}
+		out, _ = json.Marshal(googleErrorBody(w.Status(), message))
+	} else if w.action == "countTokens" {
+		var counted struct {
+			InputTokens int `json:"input_tokens"`
+		}
+		if err := json.Unmarshal(out, &counted); err == nil {
+			out, _ = json.Marshal(gin.H{"totalTokens": counted.InputTokens})
+		}
+	} else {
+		var anthropicResp apicompat.AnthropicResponse
+		if err := json.Unmarshal(out, &anthropicResp); err == nil && anthropicResp.Type == "message" {
+			out, _ = json.Marshal(apicompat.AnthropicToGeminiResponse(&anthropicResp, w.model, w.thought))
+		}
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.ResponseWriter.Write(out)
+}
+
+// anthropicErrorTypeToHTTPStatus maps an Anthropic error type from a stream
+// error event to the HTTP status it would have been returned with.
+func anthropicErrorTypeToHTTPStatus(errType string) int {
+	switch errType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "request_too_large":
+		return http.StatusRequestEntityTooLarge
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,118 @@
+//go:build unit
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newGeminiCompatTestContext(t *testing.T) (*gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/claude-sonnet-4-5:generateContent", nil)
+	return c, rec
+}
+
+const geminiCompatTestStream = "event: message_start\n" +
+	`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":3}}}` + "\n\n" +
+	"event: content_block_start\n" +
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}` + "\n\n" +
+	"event: content_block_stop\n" +
+	`data: {"type":"content_block_stop","index":0}` + "\n\n" +
+	"event: message_delta\n" +
+	`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}` + "\n\n" +
+	"event: message_stop\n" +
+	`data: {"type":"message_stop"}` + "\n\n"
+
+func TestGeminiCompatWriter_StreamSSE(t *testing.T) {
+	c, rec := newGeminiCompatTestContext(t)
+	writer := newGeminiCompatWriter(c.Writer, "streamGenerateContent", true, "claude-sonnet-4-5", false)
+	c.Writer = writer
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	_, err := c.Writer.WriteString(geminiCompatTestStream)
+	require.NoError(t, err)
+	writer.finish()
+
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	require.Len(t, events, 2)
+	require.True(t, strings.HasPrefix(events[0], "data: "))
+	var first apicompat.GeminiResponse
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[0], "data: ")), &first))
+	require.Equal(t, "Hi", first.Candidates[0].Content.Parts[0].Text)
+
+	var last apicompat.GeminiResponse
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[1], "data: ")), &last))
+	require.Equal(t, "STOP", last.Candidates[0].FinishReason)
+	require.Equal(t, 5, last.UsageMetadata.TotalTokenCount)
+}
+
+func TestGeminiCompatWriter_StreamJSONArray(t *testing.T) {
+	c, rec := newGeminiCompatTestContext(t)
+	writer := newGeminiCompatWriter(c.Writer, "streamGenerateContent", false, "claude-sonnet-4-5", false)
+	c.Writer = writer
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	_, err := c.Writer.WriteString(geminiCompatTestStream)
+	require.NoError(t, err)
+	writer.finish()
+
+	require.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+	var chunks []apicompat.GeminiResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chunks))
+	require.Len(t, chunks, 2)
+	require.Equal(t, "Hi", chunks[0].Candidates[0].Content.Parts[0].Text)
+}
+
+func TestGeminiCompatWriter_NonStreamAndError(t *testing.T) {
+	c, rec := newGeminiCompatTestContext(t)
+	writer := newGeminiCompatWriter(c.Writer, "generateContent", false, "alias", false)
+	c.Writer = writer
+	c.JSON(http.StatusOK, gin.H{
+		"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-sonnet-4-5",
+		"content":     []gin.H{{"type": "text", "text": "Hello"}},
+		"stop_reason": "end_turn",
+		"usage":       gin.H{"input_tokens": 1, "output_tokens": 1},
+	})
+	writer.finish()
+
+	var resp apicompat.GeminiResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "Hello", resp.Candidates[0].Content.Parts[0].Text)
+	require.Equal(t, "alias", resp.ModelVersion)
+
+	c, rec = newGeminiCompatTestContext(t)
+	writer = newGeminiCompatWriter(c.Writer, "generateContent", false, "alias", false)
+	c.Writer = writer
+	c.JSON(http.StatusTooManyRequests, gin.H{"type": "error", "error": gin.H{"type": "rate_limit_error", "message": "slow down"}})
+	writer.finish()
+
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.JSONEq(t, `{"error":{"code":429,"message":"slow down","status":"RESOURCE_EXHAUSTED"}}`, rec.Body.String())
+}
+
+func TestGeminiCompatWriter_CountTokens(t *testing.T) {
+	c, rec := newGeminiCompatTestContext(t)
+	writer := newGeminiCompatWriter(c.Writer, "countTokens", false, "claude-sonnet-4-5", false)
+	c.Writer = writer
+	c.JSON(http.StatusOK, gin.H{"input_tokens": 42})
+	writer.finish()
+
+	require.JSONEq(t, `{"totalTokens":42}`, rec.Body.String())
+}
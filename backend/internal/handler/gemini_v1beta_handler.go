@@ -180,6 +180,8 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		googleError(c, http.StatusBadRequest, "Request body is empty")
 		return
 	}
+	// 分组请求改写规则：模型位于 URL 路径，按路径模型匹配规则
+	body, _ = transformGroupRequestBody(c, apiKey.Group, body, service.RequestTransformFormatGemini, modelName, reqLog)
 
 	setOpsRequestContext(c, modelName, stream, body)
 
@@ -343,6 +345,32 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	cleanedForUnknownBinding := false
 
 	fs := NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
+	currentAPIKey := apiKey
+	currentSubscription := subscription
+
+	// 分组模型降级链：模型位于 URL 路径，切换时只替换 modelName，请求体保持不变
+	modelFallback := service.NewModelFallbackChain(apiKey.Group, modelName)
+	switchModelFallback := func(lastErr *service.UpstreamFailoverError) bool {
+		if modelFallback == nil || !service.IsModelFallbackTrigger(lastErr) {
+			return false
+		}
+		next, ok := h.nextModelFallback(c, modelFallback, apiKey, subscription, nil, reqLog)
+		if !ok {
+			return false
+		}
+		reqLog.Info("gemini.model_fallback_switch",
+			zap.String("requested_model", modelFallback.RequestedModel()),
+			zap.String("from_model", modelName),
+			zap.String("fallback_model", next.target.Model),
+			zap.Any("fallback_group_id", next.apiKey.GroupID),
+		)
+		applyModelFallbackSwitch(c, next)
+		modelName = next.target.Model
+		currentAPIKey = next.apiKey
+		currentSubscription = next.subscription
+		fs = NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
+		return true
+	}
 
 	// 单账号分组提前设置 SingleAccountRetry 标记，让 Service 层首次 503 就不设模型限流标记。
 	// 避免单账号分组收到 503 (MODEL_CAPACITY_EXHAUSTED) 时设 29s 限流，导致后续请求连续快速失败。
@@ -352,9 +380,12 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	}
 
 	for {
-		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, fs.FailedAccountIDs, "") // Gemini 不使用会话限制
+		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), currentAPIKey.GroupID, sessionKey, modelName, fs.FailedAccountIDs, "") // Gemini 不使用会话限制
 		if err != nil {
 			if len(fs.FailedAccountIDs) == 0 {
+				if switchModelFallback(nil) {
+					continue
+				}
 				googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
 				return
 			}
@@ -367,6 +398,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			case FailoverCanceled:
 				return
 			default: // FailoverExhausted
+				if switchModelFallback(fs.LastFailoverErr) {
+					continue
+				}
 				h.handleGeminiFailoverExhausted(c, fs.LastFailoverErr)
 				return
 			}
@@ -426,11 +460,10 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				}
 			}()
 
-			accountReleaseFunc, err = geminiConcurrency.AcquireAccountSlotWithWaitTimeout(
+			accountReleaseFunc, err = geminiConcurrency.AcquireAccountSlotForWaitPlan(
 				c,
-				account.ID,
-				selection.WaitPlan.MaxConcurrency,
-				selection.WaitPlan.Timeout,
+				selection.WaitPlan,
+				account.MatchModelLimit(modelName),
 				stream,
 				&streamStarted,
 			)
@@ -443,7 +476,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				geminiConcurrency.DecrementAccountWaitCount(c.Request.Context(), account.ID)
 				accountWaitCounted = false
 			}
-			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
+			if err := h.gatewayService.BindStickySession(c.Request.Context(), currentAPIKey.GroupID, sessionKey, account.ID); err != nil {
 				reqLog.Warn("gemini.bind_sticky_session_failed", zap.Int64("account_id", account.ID), zap.Error(err))
 			}
 		}
@@ -472,6 +505,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				case FailoverContinue:
 					continue
 				case FailoverExhausted:
+					if switchModelFallback(fs.LastFailoverErr) {
+						continue
+					}
 					h.handleGeminiFailoverExhausted(c, fs.LastFailoverErr)
 					return
 				case FailoverCanceled:
@@ -502,19 +538,23 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			}
 		}
 
+		// 模型降级：usage 记录请求模型，并按实际服务的模型计费
+		modelFallback.ApplyToResult(result)
+
 		// 使用量记录通过有界 worker 池提交，避免请求热路径创建无界 goroutine。
 		requestPayloadHash := service.HashUsageRequestPayload(body)
 		inboundEndpoint := GetInboundEndpoint(c)
 		upstreamEndpoint := GetUpstreamEndpoint(c, account.Platform)
-		h.submitUsageRecordTask(func(ctx context.Context) {
+		h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
 			if err := h.gatewayService.RecordUsageWithLongContext(ctx, &service.RecordUsageLongContextInput{
 				Result:                result,
-				APIKey:                apiKey,
-				User:                  apiKey.User,
+				APIKey:                currentAPIKey,
+				User:                  currentAPIKey.User,
 				Account:               account,
-				Subscription:          subscription,
+				Subscription:          currentSubscription,
 				InboundEndpoint:       inboundEndpoint,
 				UpstreamEndpoint:      upstreamEndpoint,
+				SessionID:             sessionKey,
 				UserAgent:             userAgent,
 				IPAddress:             clientIP,
 				RequestPayloadHash:    requestPayloadHash,
@@ -532,7 +572,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 					zap.Int64("account_id", account.ID),
 				).Error("gemini.record_usage_failed", zap.Error(err))
 			}
-		})
+		}))
 		reqLog.Debug("gemini.request_completed",
 			zap.Int64("account_id", account.ID),
 			zap.Int("switch_count", fs.SwitchCount),
@@ -620,13 +660,18 @@ type pathParseError struct{ msg string }
 func (e *pathParseError) Error() string { return e.msg }
 
 func googleError(c *gin.Context, status int, message string) {
-	c.JSON(status, gin.H{
+	c.JSON(status, googleErrorBody(status, message))
+}
+
+// googleErrorBody builds the body written by googleError.
+func googleErrorBody(status int, message string) gin.H {
+	return gin.H{
 		"error": gin.H{
 			"code":    status,
 			"message": message,
 			"status":  googleapi.HTTPStatusToGoogleStatus(status),
 		},
-	})
+	}
 }
 
 func writeUpstreamResponse(c *gin.Context, res *service.UpstreamHTTPResult) {
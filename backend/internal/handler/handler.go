@@ -25,10 +25,19 @@ type AdminHandlers struct {
 	System           *admin.SystemHandler
 	Subscription     *admin.SubscriptionHandler
 	Usage            *admin.UsageHandler
+	UsageExport      *admin.UsageExportHandler
+	UsageSession     *admin.UsageSessionHandler
+	UsageLogArchive  *admin.UsageLogArchiveHandler
 	UserAttribute    *admin.UserAttributeHandler
 	ErrorPassthrough *admin.ErrorPassthroughHandler
 	APIKey           *admin.AdminAPIKeyHandler
 	ScheduledTest    *admin.ScheduledTestHandler
+	Invoice          *admin.InvoiceHandler
+	RequestCapture   *admin.RequestCaptureHandler
+	EmailTemplate    *admin.EmailTemplateHandler
+	Pricing          *admin.PricingHandler
+
+	ContentModeration *admin.ContentModerationHandler
 }
 
 // Handlers contains all HTTP handlers
@@ -37,6 +46,8 @@ type Handlers struct {
 	User          *UserHandler
 	APIKey        *APIKeyHandler
 	Usage         *UsageHandler
+	UsageExport   *UsageExportHandler
+	UsageSession  *UsageSessionHandler
 	Redeem        *RedeemHandler
 	Subscription  *SubscriptionHandler
 	Announcement  *AnnouncementHandler
@@ -47,6 +58,15 @@ type Handlers struct {
 	SoraClient    *SoraClientHandler
 	Setting       *SettingHandler
 	Totp          *TotpHandler
+	Passkey       *PasskeyHandler
+	Invoice       *InvoiceHandler
+
+	UserNotification     *UserNotificationHandler
+	SubscriptionRenewal  *SubscriptionRenewalHandler
+	SubscriptionPurchase *SubscriptionPurchaseHandler
+	RequestCapture       *RequestCaptureHandler
+	ContentModeration    *ContentModerationHandler
+	UserRateLimitHeaders *UserRateLimitHeadersHandler
 }
 
 // BuildInfo contains build-time information
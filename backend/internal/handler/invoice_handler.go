@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceHandler handles user invoice (statement) requests
+type InvoiceHandler struct {
+	invoiceService *service.InvoiceService
+}
+
+// NewInvoiceHandler creates a new InvoiceHandler
+func NewInvoiceHandler(invoiceService *service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: invoiceService}
+}
+
+// List handles listing the current user's invoices
+// GET /api/v1/invoices
+func (h *InvoiceHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	userID := subject.UserID
+	invoices, result, err := h.invoiceService.List(c.Request.Context(), service.InvoiceListFilter{UserID: &userID}, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, invoices, result.Total, page, pageSize)
+}
+
+// GetByID handles getting one of the current user's invoices
+// GET /api/v1/invoices/:id
+func (h *InvoiceHandler) GetByID(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	invoiceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || invoiceID <= 0 {
+		response.BadRequest(c, "Invalid invoice ID")
+		return
+	}
+	invoice, err := h.invoiceService.Get(c.Request.Context(), invoiceID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, invoice)
+}
+
+// Download handles downloading an invoice as a printable HTML document.
+// Pass ?inline=1 to display it in the browser instead of downloading.
+// GET /api/v1/invoices/:id/download
+func (h *InvoiceHandler) Download(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	invoiceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || invoiceID <= 0 {
+		response.BadRequest(c, "Invalid invoice ID")
+		return
+	}
+	invoice, err := h.invoiceService.Get(c.Request.Context(), invoiceID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	writeInvoiceHTML(c, h.invoiceService, invoice)
+}
+
+func writeInvoiceHTML(c *gin.Context, invoiceService *service.InvoiceService, invoice *service.Invoice) {
+	body, err := invoiceService.RenderHTML(invoice)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	disposition := "attachment"
+	if c.Query("inline") == "1" || c.Query("inline") == "true" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, service.InvoiceFileName(invoice)))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+}
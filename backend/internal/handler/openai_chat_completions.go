@@ -65,6 +65,8 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
 		return
 	}
+	// 分组请求改写规则，后续校验与转发基于改写后的请求
+	body, _ = transformGroupRequestBody(c, apiKey.Group, body, service.RequestTransformFormatOpenAIChat, "", reqLog)
 
 	modelResult := gjson.GetBytes(body, "model")
 	if !modelResult.Exists() || modelResult.Type != gjson.String || modelResult.String() == "" {
@@ -77,6 +79,8 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 	reqLog = reqLog.With(zap.String("model", reqModel), zap.Bool("stream", reqStream))
 
 	setOpsRequestContext(c, reqModel, reqStream, body)
+	// 记录 API Key 调度优先级：非 interactive 请求不占用预留槽位，batch 请求只使用低负载账号
+	c.Request = c.Request.WithContext(service.WithRequestPriority(c.Request.Context(), apiKey.EffectivePriority()))
 
 	if h.errorPassthroughService != nil {
 		service.BindErrorPassthroughService(c, h.errorPassthroughService)
@@ -104,6 +108,8 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 
 	sessionHash := h.gatewayService.GenerateSessionHash(c, body)
 	promptCacheKey := h.gatewayService.ExtractSessionID(c, body)
+	// 会话统计使用客户端会话标识，不含号池模式下生成的一次性重试键
+	usageSessionID := sessionHash
 
 	maxAccountSwitches := h.maxAccountSwitches
 	switchCount := 0
@@ -111,12 +117,42 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 	sameAccountRetryCount := make(map[int64]int)
 	var lastFailoverErr *service.UpstreamFailoverError
 
+	currentAPIKey := apiKey
+	currentSubscription := subscription
+	// 分组模型降级链：调度失败（无可调度账号/全部限流）或上游过载时按顺序改用降级目标
+	modelFallback := service.NewModelFallbackChain(apiKey.Group, reqModel)
+	switchModelFallback := func(lastErr *service.UpstreamFailoverError) bool {
+		if modelFallback == nil || !service.IsModelFallbackTrigger(lastErr) {
+			return false
+		}
+		next, ok := nextModelFallback(c, h.gatewayService, h.billingCacheService, modelFallback, apiKey, subscription, body, reqLog)
+		if !ok {
+			return false
+		}
+		reqLog.Info("openai_chat_completions.model_fallback_switch",
+			zap.String("requested_model", modelFallback.RequestedModel()),
+			zap.String("from_model", reqModel),
+			zap.String("fallback_model", next.target.Model),
+			zap.Any("fallback_group_id", next.apiKey.GroupID),
+		)
+		applyModelFallbackSwitch(c, next)
+		body = next.body
+		reqModel = next.target.Model
+		currentAPIKey = next.apiKey
+		currentSubscription = next.subscription
+		switchCount = 0
+		failedAccountIDs = make(map[int64]struct{})
+		sameAccountRetryCount = make(map[int64]int)
+		lastFailoverErr = nil
+		return true
+	}
+
 	for {
 		c.Set("openai_chat_completions_fallback_model", "")
 		reqLog.Debug("openai_chat_completions.account_selecting", zap.Int("excluded_account_count", len(failedAccountIDs)))
 		selection, scheduleDecision, err := h.gatewayService.SelectAccountWithScheduler(
 			c.Request.Context(),
-			apiKey.GroupID,
+			currentAPIKey.GroupID,
 			"",
 			sessionHash,
 			reqModel,
@@ -130,8 +166,8 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 			)
 			if len(failedAccountIDs) == 0 {
 				defaultModel := ""
-				if apiKey.Group != nil {
-					defaultModel = apiKey.Group.DefaultMappedModel
+				if currentAPIKey.Group != nil {
+					defaultModel = currentAPIKey.Group.DefaultMappedModel
 				}
 				if defaultModel != "" && defaultModel != reqModel {
 					reqLog.Info("openai_chat_completions.fallback_to_default_model",
@@ -139,7 +175,7 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 					)
 					selection, scheduleDecision, err = h.gatewayService.SelectAccountWithScheduler(
 						c.Request.Context(),
-						apiKey.GroupID,
+						currentAPIKey.GroupID,
 						"",
 						sessionHash,
 						defaultModel,
@@ -151,10 +187,16 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 					}
 				}
 				if err != nil {
+					if switchModelFallback(nil) {
+						continue
+					}
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "Service temporarily unavailable", streamStarted)
 					return
 				}
 			} else {
+				if switchModelFallback(lastFailoverErr) {
+					continue
+				}
 				if lastFailoverErr != nil {
 					h.handleFailoverExhausted(c, lastFailoverErr, streamStarted)
 				} else {
@@ -173,7 +215,7 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 		_ = scheduleDecision
 		setOpsSelectedAccount(c, account.ID, account.Platform)
 
-		accountReleaseFunc, acquired := h.acquireResponsesAccountSlot(c, apiKey.GroupID, sessionHash, selection, reqStream, &streamStarted, reqLog)
+		accountReleaseFunc, acquired := h.acquireResponsesAccountSlot(c, currentAPIKey.GroupID, sessionHash, selection, scheduledOpenAIModel(c, "openai_chat_completions_fallback_model", reqModel), reqStream, &streamStarted, reqLog)
 		if !acquired {
 			return
 		}
@@ -224,6 +266,9 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 				failedAccountIDs[account.ID] = struct{}{}
 				lastFailoverErr = failoverErr
 				if switchCount >= maxAccountSwitches {
+					if switchModelFallback(failoverErr) {
+						continue
+					}
 					h.handleFailoverExhausted(c, failoverErr, streamStarted)
 					return
 				}
@@ -253,16 +298,18 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
+		modelFallback.ApplyToOpenAIResult(result)
 
-		h.submitUsageRecordTask(func(ctx context.Context) {
+		h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
 			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
 				Result:           result,
-				APIKey:           apiKey,
-				User:             apiKey.User,
+				APIKey:           currentAPIKey,
+				User:             currentAPIKey.User,
 				Account:          account,
-				Subscription:     subscription,
+				Subscription:     currentSubscription,
 				InboundEndpoint:  GetInboundEndpoint(c),
 				UpstreamEndpoint: GetUpstreamEndpoint(c, account.Platform),
+				SessionID:        usageSessionID,
 				UserAgent:        userAgent,
 				IPAddress:        clientIP,
 				APIKeyService:    h.apiKeyService,
@@ -270,13 +317,13 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 				logger.L().With(
 					zap.String("component", "handler.openai_gateway.chat_completions"),
 					zap.Int64("user_id", subject.UserID),
-					zap.Int64("api_key_id", apiKey.ID),
-					zap.Any("group_id", apiKey.GroupID),
+					zap.Int64("api_key_id", currentAPIKey.ID),
+					zap.Any("group_id", currentAPIKey.GroupID),
 					zap.String("model", reqModel),
 					zap.Int64("account_id", account.ID),
 				).Error("openai_chat_completions.record_usage_failed", zap.Error(err))
 			}
-		})
+		}))
 		reqLog.Debug("openai_chat_completions.request_completed",
 			zap.Int64("account_id", account.ID),
 			zap.Int("switch_count", switchCount),
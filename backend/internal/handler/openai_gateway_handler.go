@@ -139,6 +139,8 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
 		return
 	}
+	// 分组请求改写规则，后续校验与转发基于改写后的请求
+	body, _ = transformGroupRequestBody(c, apiKey.Group, body, service.RequestTransformFormatOpenAIResponses, "", reqLog)
 
 	// 使用 gjson 只读提取字段做校验，避免完整 Unmarshal
 	modelResult := gjson.GetBytes(body, "model")
@@ -173,6 +175,8 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	}
 
 	setOpsRequestContext(c, reqModel, reqStream, body)
+	// 记录 API Key 调度优先级：非 interactive 请求不占用预留槽位，batch 请求只使用低负载账号
+	c.Request = c.Request.WithContext(service.WithRequestPriority(c.Request.Context(), apiKey.EffectivePriority()))
 
 	// 提前校验 function_call_output 是否具备可关联上下文，避免上游 400。
 	if !h.validateFunctionCallOutputRequest(c, body, reqLog) {
@@ -209,6 +213,8 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 
 	// Generate session hash (header first; fallback to prompt_cache_key)
 	sessionHash := h.gatewayService.GenerateSessionHash(c, sessionHashBody)
+	// 会话统计使用客户端会话标识，不含号池模式下生成的一次性重试键
+	usageSessionID := sessionHash
 
 	maxAccountSwitches := h.maxAccountSwitches
 	switchCount := 0
@@ -216,12 +222,42 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	sameAccountRetryCount := make(map[int64]int)
 	var lastFailoverErr *service.UpstreamFailoverError
 
+	currentAPIKey := apiKey
+	currentSubscription := subscription
+	// 分组模型降级链：调度失败（无可调度账号/全部限流）或上游过载时按顺序改用降级目标
+	modelFallback := service.NewModelFallbackChain(apiKey.Group, reqModel)
+	switchModelFallback := func(lastErr *service.UpstreamFailoverError) bool {
+		if modelFallback == nil || !service.IsModelFallbackTrigger(lastErr) {
+			return false
+		}
+		next, ok := nextModelFallback(c, h.gatewayService, h.billingCacheService, modelFallback, apiKey, subscription, body, reqLog)
+		if !ok {
+			return false
+		}
+		reqLog.Info("openai.model_fallback_switch",
+			zap.String("requested_model", modelFallback.RequestedModel()),
+			zap.String("from_model", reqModel),
+			zap.String("fallback_model", next.target.Model),
+			zap.Any("fallback_group_id", next.apiKey.GroupID),
+		)
+		applyModelFallbackSwitch(c, next)
+		body = next.body
+		reqModel = next.target.Model
+		currentAPIKey = next.apiKey
+		currentSubscription = next.subscription
+		switchCount = 0
+		failedAccountIDs = make(map[int64]struct{})
+		sameAccountRetryCount = make(map[int64]int)
+		lastFailoverErr = nil
+		return true
+	}
+
 	for {
 		// Select account supporting the requested model
 		reqLog.Debug("openai.account_selecting", zap.Int("excluded_account_count", len(failedAccountIDs)))
 		selection, scheduleDecision, err := h.gatewayService.SelectAccountWithScheduler(
 			c.Request.Context(),
-			apiKey.GroupID,
+			currentAPIKey.GroupID,
 			previousResponseID,
 			sessionHash,
 			reqModel,
@@ -234,9 +270,15 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				zap.Int("excluded_account_count", len(failedAccountIDs)),
 			)
 			if len(failedAccountIDs) == 0 {
+				if switchModelFallback(nil) {
+					continue
+				}
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "Service temporarily unavailable", streamStarted)
 				return
 			}
+			if switchModelFallback(lastFailoverErr) {
+				continue
+			}
 			if lastFailoverErr != nil {
 				h.handleFailoverExhausted(c, lastFailoverErr, streamStarted)
 			} else {
@@ -265,7 +307,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		reqLog.Debug("openai.account_selected", zap.Int64("account_id", account.ID), zap.String("account_name", account.Name))
 		setOpsSelectedAccount(c, account.ID, account.Platform)
 
-		accountReleaseFunc, acquired := h.acquireResponsesAccountSlot(c, apiKey.GroupID, sessionHash, selection, reqStream, &streamStarted, reqLog)
+		accountReleaseFunc, acquired := h.acquireResponsesAccountSlot(c, currentAPIKey.GroupID, sessionHash, selection, reqModel, reqStream, &streamStarted, reqLog)
 		if !acquired {
 			return
 		}
@@ -314,6 +356,9 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				failedAccountIDs[account.ID] = struct{}{}
 				lastFailoverErr = failoverErr
 				if switchCount >= maxAccountSwitches {
+					if switchModelFallback(failoverErr) {
+						continue
+					}
 					h.handleFailoverExhausted(c, failoverErr, streamStarted)
 					return
 				}
@@ -353,17 +398,19 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 		requestPayloadHash := service.HashUsageRequestPayload(body)
+		modelFallback.ApplyToOpenAIResult(result)
 
 		// 使用量记录通过有界 worker 池提交，避免请求热路径创建无界 goroutine。
-		h.submitUsageRecordTask(func(ctx context.Context) {
+		h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
 			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
 				Result:             result,
-				APIKey:             apiKey,
-				User:               apiKey.User,
+				APIKey:             currentAPIKey,
+				User:               currentAPIKey.User,
 				Account:            account,
-				Subscription:       subscription,
+				Subscription:       currentSubscription,
 				InboundEndpoint:    GetInboundEndpoint(c),
 				UpstreamEndpoint:   GetUpstreamEndpoint(c, account.Platform),
+				SessionID:          usageSessionID,
 				UserAgent:          userAgent,
 				IPAddress:          clientIP,
 				RequestPayloadHash: requestPayloadHash,
@@ -372,13 +419,13 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				logger.L().With(
 					zap.String("component", "handler.openai_gateway.responses"),
 					zap.Int64("user_id", subject.UserID),
-					zap.Int64("api_key_id", apiKey.ID),
-					zap.Any("group_id", apiKey.GroupID),
+					zap.Int64("api_key_id", currentAPIKey.ID),
+					zap.Any("group_id", currentAPIKey.GroupID),
 					zap.String("model", reqModel),
 					zap.Int64("account_id", account.ID),
 				).Error("openai.record_usage_failed", zap.Error(err))
 			}
-		})
+		}))
 		reqLog.Debug("openai.request_completed",
 			zap.Int64("account_id", account.ID),
 			zap.Int("switch_count", switchCount),
@@ -523,6 +570,8 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 		h.anthropicErrorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
 		return
 	}
+	// 分组请求改写规则，后续校验与转发基于改写后的请求
+	body, _ = transformGroupRequestBody(c, apiKey.Group, body, service.RequestTransformFormatAnthropic, "", reqLog)
 
 	modelResult := gjson.GetBytes(body, "model")
 	if !modelResult.Exists() || modelResult.Type != gjson.String || modelResult.String() == "" {
@@ -535,6 +584,8 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 	reqLog = reqLog.With(zap.String("model", reqModel), zap.Bool("stream", reqStream))
 
 	setOpsRequestContext(c, reqModel, reqStream, body)
+	// 记录 API Key 调度优先级：非 interactive 请求不占用预留槽位，batch 请求只使用低负载账号
+	c.Request = c.Request.WithContext(service.WithRequestPriority(c.Request.Context(), apiKey.EffectivePriority()))
 
 	// 绑定错误透传服务，允许 service 层在非 failover 错误场景复用规则。
 	if h.errorPassthroughService != nil {
@@ -578,6 +629,7 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 			}
 		}
 	}
+	usageSessionID := sessionHash
 
 	maxAccountSwitches := h.maxAccountSwitches
 	switchCount := 0
@@ -585,13 +637,43 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 	sameAccountRetryCount := make(map[int64]int)
 	var lastFailoverErr *service.UpstreamFailoverError
 
+	currentAPIKey := apiKey
+	currentSubscription := subscription
+	// 分组模型降级链：调度失败（无可调度账号/全部限流）或上游过载时按顺序改用降级目标
+	modelFallback := service.NewModelFallbackChain(apiKey.Group, reqModel)
+	switchModelFallback := func(lastErr *service.UpstreamFailoverError) bool {
+		if modelFallback == nil || !service.IsModelFallbackTrigger(lastErr) {
+			return false
+		}
+		next, ok := nextModelFallback(c, h.gatewayService, h.billingCacheService, modelFallback, apiKey, subscription, body, reqLog)
+		if !ok {
+			return false
+		}
+		reqLog.Info("openai_messages.model_fallback_switch",
+			zap.String("requested_model", modelFallback.RequestedModel()),
+			zap.String("from_model", reqModel),
+			zap.String("fallback_model", next.target.Model),
+			zap.Any("fallback_group_id", next.apiKey.GroupID),
+		)
+		applyModelFallbackSwitch(c, next)
+		body = next.body
+		reqModel = next.target.Model
+		currentAPIKey = next.apiKey
+		currentSubscription = next.subscription
+		switchCount = 0
+		failedAccountIDs = make(map[int64]struct{})
+		sameAccountRetryCount = make(map[int64]int)
+		lastFailoverErr = nil
+		return true
+	}
+
 	for {
 		// 清除上一次迭代的降级模型标记，避免残留影响本次迭代
 		c.Set("openai_messages_fallback_model", "")
 		reqLog.Debug("openai_messages.account_selecting", zap.Int("excluded_account_count", len(failedAccountIDs)))
 		selection, scheduleDecision, err := h.gatewayService.SelectAccountWithScheduler(
 			c.Request.Context(),
-			apiKey.GroupID,
+			currentAPIKey.GroupID,
 			"", // no previous_response_id
 			sessionHash,
 			reqModel,
@@ -606,8 +688,8 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 			// 首次调度失败 + 有默认映射模型 → 用默认模型重试
 			if len(failedAccountIDs) == 0 {
 				defaultModel := ""
-				if apiKey.Group != nil {
-					defaultModel = apiKey.Group.DefaultMappedModel
+				if currentAPIKey.Group != nil {
+					defaultModel = currentAPIKey.Group.DefaultMappedModel
 				}
 				if defaultModel != "" && defaultModel != reqModel {
 					reqLog.Info("openai_messages.fallback_to_default_model",
@@ -615,7 +697,7 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 					)
 					selection, scheduleDecision, err = h.gatewayService.SelectAccountWithScheduler(
 						c.Request.Context(),
-						apiKey.GroupID,
+						currentAPIKey.GroupID,
 						"",
 						sessionHash,
 						defaultModel,
@@ -627,10 +709,16 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 					}
 				}
 				if err != nil {
+					if switchModelFallback(nil) {
+						continue
+					}
 					h.anthropicStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "Service temporarily unavailable", streamStarted)
 					return
 				}
 			} else {
+				if switchModelFallback(lastFailoverErr) {
+					continue
+				}
 				if lastFailoverErr != nil {
 					h.handleAnthropicFailoverExhausted(c, lastFailoverErr, streamStarted)
 				} else {
@@ -649,7 +737,7 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 		_ = scheduleDecision
 		setOpsSelectedAccount(c, account.ID, account.Platform)
 
-		accountReleaseFunc, acquired := h.acquireResponsesAccountSlot(c, apiKey.GroupID, sessionHash, selection, reqStream, &streamStarted, reqLog)
+		accountReleaseFunc, acquired := h.acquireResponsesAccountSlot(c, currentAPIKey.GroupID, sessionHash, selection, scheduledOpenAIModel(c, "openai_messages_fallback_model", reqModel), reqStream, &streamStarted, reqLog)
 		if !acquired {
 			return
 		}
@@ -702,6 +790,9 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 				failedAccountIDs[account.ID] = struct{}{}
 				lastFailoverErr = failoverErr
 				if switchCount >= maxAccountSwitches {
+					if switchModelFallback(failoverErr) {
+						continue
+					}
 					h.handleAnthropicFailoverExhausted(c, failoverErr, streamStarted)
 					return
 				}
@@ -732,16 +823,18 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 		requestPayloadHash := service.HashUsageRequestPayload(body)
+		modelFallback.ApplyToOpenAIResult(result)
 
-		h.submitUsageRecordTask(func(ctx context.Context) {
+		h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
 			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
 				Result:             result,
-				APIKey:             apiKey,
-				User:               apiKey.User,
+				APIKey:             currentAPIKey,
+				User:               currentAPIKey.User,
 				Account:            account,
-				Subscription:       subscription,
+				Subscription:       currentSubscription,
 				InboundEndpoint:    GetInboundEndpoint(c),
 				UpstreamEndpoint:   GetUpstreamEndpoint(c, account.Platform),
+				SessionID:          usageSessionID,
 				UserAgent:          userAgent,
 				IPAddress:          clientIP,
 				RequestPayloadHash: requestPayloadHash,
@@ -750,13 +843,13 @@ func (h *OpenAIGatewayHandler) Messages(c *gin.Context) {
 				logger.L().With(
 					zap.String("component", "handler.openai_gateway.messages"),
 					zap.Int64("user_id", subject.UserID),
-					zap.Int64("api_key_id", apiKey.ID),
-					zap.Any("group_id", apiKey.GroupID),
+					zap.Int64("api_key_id", currentAPIKey.ID),
+					zap.Any("group_id", currentAPIKey.GroupID),
 					zap.String("model", reqModel),
 					zap.Int64("account_id", account.ID),
 				).Error("openai_messages.record_usage_failed", zap.Error(err))
 			}
-		})
+		}))
 		reqLog.Debug("openai_messages.request_completed",
 			zap.Int64("account_id", account.ID),
 			zap.Int("switch_count", switchCount),
@@ -904,11 +997,20 @@ func (h *OpenAIGatewayHandler) acquireResponsesUserSlot(
 	return wrapReleaseOnDone(ctx, userReleaseFunc), true
 }
 
+// scheduledOpenAIModel 返回本次调度使用的模型：触发分组默认映射模型降级时为降级模型
+func scheduledOpenAIModel(c *gin.Context, fallbackKey string, reqModel string) string {
+	if fallbackModel := c.GetString(fallbackKey); fallbackModel != "" {
+		return fallbackModel
+	}
+	return reqModel
+}
+
 func (h *OpenAIGatewayHandler) acquireResponsesAccountSlot(
 	c *gin.Context,
 	groupID *int64,
 	sessionHash string,
 	selection *service.AccountSelectionResult,
+	model string,
 	reqStream bool,
 	streamStarted *bool,
 	reqLog *zap.Logger,
@@ -928,10 +1030,12 @@ func (h *OpenAIGatewayHandler) acquireResponsesAccountSlot(
 		return nil, false
 	}
 
-	fastReleaseFunc, fastAcquired, err := h.concurrencyHelper.TryAcquireAccountSlot(
+	modelLimit := account.MatchModelLimit(model)
+	fastReleaseFunc, fastAcquired, err := h.concurrencyHelper.TryAcquireAccountModelSlot(
 		ctx,
 		account.ID,
 		selection.WaitPlan.MaxConcurrency,
+		modelLimit,
 	)
 	if err != nil {
 		reqLog.Warn("openai.account_slot_quick_acquire_failed", zap.Int64("account_id", account.ID), zap.Error(err))
@@ -945,13 +1049,14 @@ func (h *OpenAIGatewayHandler) acquireResponsesAccountSlot(
 		return wrapReleaseOnDone(ctx, fastReleaseFunc), true
 	}
 
-	canWait, waitErr := h.concurrencyHelper.IncrementAccountWaitCount(ctx, account.ID, selection.WaitPlan.MaxWaiting)
+	canWait, waitErr := h.concurrencyHelper.IncrementAccountPlanWaitCount(ctx, selection.WaitPlan)
 	if waitErr != nil {
 		reqLog.Warn("openai.account_wait_counter_increment_failed", zap.Int64("account_id", account.ID), zap.Error(waitErr))
 	} else if !canWait {
 		reqLog.Info("openai.account_wait_queue_full",
 			zap.Int64("account_id", account.ID),
 			zap.Int("max_waiting", selection.WaitPlan.MaxWaiting),
+			zap.Bool("batch", selection.WaitPlan.Batch),
 		)
 		h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", *streamStarted)
 		return nil, false
@@ -960,17 +1065,16 @@ func (h *OpenAIGatewayHandler) acquireResponsesAccountSlot(
 	accountWaitCounted := waitErr == nil && canWait
 	releaseWait := func() {
 		if accountWaitCounted {
-			h.concurrencyHelper.DecrementAccountWaitCount(ctx, account.ID)
+			h.concurrencyHelper.DecrementAccountPlanWaitCount(ctx, selection.WaitPlan)
 			accountWaitCounted = false
 		}
 	}
 	defer releaseWait()
 
-	accountReleaseFunc, err := h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+	accountReleaseFunc, err := h.concurrencyHelper.AcquireAccountSlotForWaitPlan(
 		c,
-		account.ID,
-		selection.WaitPlan.MaxConcurrency,
-		selection.WaitPlan.Timeout,
+		selection.WaitPlan,
+		modelLimit,
 		reqStream,
 		streamStarted,
 	)
@@ -1086,6 +1190,8 @@ func (h *OpenAIGatewayHandler) ResponsesWebSocket(c *gin.Context) {
 		zap.String("previous_response_id_kind", previousResponseIDKind),
 	)
 	setOpsRequestContext(c, reqModel, true, firstMessage)
+	// 记录 API Key 调度优先级：非 interactive 请求不占用预留槽位，batch 请求只使用低负载账号
+	ctx = service.WithRequestPriority(ctx, apiKey.EffectivePriority())
 
 	var currentUserRelease func()
 	var currentAccountRelease func()
@@ -1146,7 +1252,7 @@ func (h *OpenAIGatewayHandler) ResponsesWebSocket(c *gin.Context) {
 	}
 
 	account := selection.Account
-	accountMaxConcurrency := account.Concurrency
+	accountMaxConcurrency := h.gatewayService.AccountSlotLimit(ctx, account)
 	if selection.WaitPlan != nil && selection.WaitPlan.MaxConcurrency > 0 {
 		accountMaxConcurrency = selection.WaitPlan.MaxConcurrency
 	}
@@ -1156,10 +1262,11 @@ func (h *OpenAIGatewayHandler) ResponsesWebSocket(c *gin.Context) {
 			closeOpenAIClientWS(wsConn, coderws.StatusTryAgainLater, "account is busy, please retry later")
 			return
 		}
-		fastReleaseFunc, fastAcquired, err := h.concurrencyHelper.TryAcquireAccountSlot(
+		fastReleaseFunc, fastAcquired, err := h.concurrencyHelper.TryAcquireAccountModelSlot(
 			ctx,
 			account.ID,
 			selection.WaitPlan.MaxConcurrency,
+			account.MatchModelLimit(reqModel),
 		)
 		if err != nil {
 			reqLog.Warn("openai.websocket_account_slot_acquire_failed", zap.Int64("account_id", account.ID), zap.Error(err))
@@ -1206,7 +1313,7 @@ func (h *OpenAIGatewayHandler) ResponsesWebSocket(c *gin.Context) {
 			if !userAcquired {
 				return service.NewOpenAIWSClientCloseError(coderws.StatusTryAgainLater, "too many concurrent requests, please retry later", nil)
 			}
-			accountReleaseFunc, accountAcquired, err := h.concurrencyHelper.TryAcquireAccountSlot(ctx, account.ID, accountMaxConcurrency)
+			accountReleaseFunc, accountAcquired, err := h.concurrencyHelper.TryAcquireAccountModelSlot(ctx, account.ID, accountMaxConcurrency, account.MatchModelLimit(reqModel))
 			if err != nil {
 				if userReleaseFunc != nil {
 					userReleaseFunc()
@@ -1241,6 +1348,7 @@ func (h *OpenAIGatewayHandler) ResponsesWebSocket(c *gin.Context) {
 					Subscription:       subscription,
 					InboundEndpoint:    GetInboundEndpoint(c),
 					UpstreamEndpoint:   GetUpstreamEndpoint(c, account.Platform),
+					SessionID:          sessionHash,
 					UserAgent:          userAgent,
 					IPAddress:          clientIP,
 					RequestPayloadHash: service.HashUsageRequestPayload(firstMessage),
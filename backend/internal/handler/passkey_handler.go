@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// PasskeyHandler handles passkey management for the current user
+type PasskeyHandler struct {
+	passkeyService *service.PasskeyService
+}
+
+// NewPasskeyHandler creates a new PasskeyHandler
+func NewPasskeyHandler(passkeyService *service.PasskeyService) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+	}
+}
+
+// List returns the passkeys registered by the current user
+// GET /api/v1/user/passkeys
+func (h *PasskeyHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	list, err := h.passkeyService.List(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, list)
+}
+
+// PasskeyRegisterOptionsRequest starts a passkey registration; identity is verified like TOTP setup
+type PasskeyRegisterOptionsRequest struct {
+	Name      string `json:"name"`
+	EmailCode string `json:"email_code"`
+	Password  string `json:"password"`
+}
+
+// RegisterOptions verifies identity and returns WebAuthn credential creation options
+// POST /api/v1/user/passkeys/register/options
+func (h *PasskeyHandler) RegisterOptions(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req PasskeyRegisterOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	options, err := h.passkeyService.BeginRegistration(c.Request.Context(), subject.UserID, req.Name, req.EmailCode, req.Password)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, options)
+}
+
+// PasskeyRegisterRequest carries the authenticator's attestation response (PublicKeyCredential JSON)
+type PasskeyRegisterRequest struct {
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// Register verifies the attestation response and stores the passkey
+// POST /api/v1/user/passkeys/register
+func (h *PasskeyHandler) Register(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req PasskeyRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	passkey, err := h.passkeyService.FinishRegistration(c.Request.Context(), subject.UserID, req.Credential)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, passkey)
+}
+
+// PasskeyRenameRequest renames a passkey
+type PasskeyRenameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// Rename changes the display name of a passkey
+// PUT /api/v1/user/passkeys/:id
+func (h *PasskeyHandler) Rename(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid passkey ID")
+		return
+	}
+
+	var req PasskeyRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.passkeyService.Rename(c.Request.Context(), subject.UserID, id, req.Name); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"success": true})
+}
+
+// PasskeyDeleteRequest verifies identity before removing a passkey
+type PasskeyDeleteRequest struct {
+	EmailCode string `json:"email_code"`
+	Password  string `json:"password"`
+}
+
+// Delete removes a passkey
+// DELETE /api/v1/user/passkeys/:id
+func (h *PasskeyHandler) Delete(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid passkey ID")
+		return
+	}
+
+	var req PasskeyDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.passkeyService.Delete(c.Request.Context(), subject.UserID, id, req.EmailCode, req.Password); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"success": true})
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestCaptureHandler serves the user-facing capture viewer and the gateway
+// capture middleware.
+type RequestCaptureHandler struct {
+	captureService *service.RequestCaptureService
+}
+
+// NewRequestCaptureHandler creates a new RequestCaptureHandler
+func NewRequestCaptureHandler(captureService *service.RequestCaptureService) *RequestCaptureHandler {
+	return &RequestCaptureHandler{captureService: captureService}
+}
+
+// List handles listing captures of the current user's API keys
+// GET /api/v1/request-captures
+func (h *RequestCaptureHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	var filter service.RequestCaptureListFilter
+	if v := strings.TrimSpace(c.Query("api_key_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid api_key_id")
+			return
+		}
+		filter.APIKeyID = &id
+	}
+	filter.RequestID = strings.TrimSpace(c.Query("request_id"))
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	captures, result, err := h.captureService.ListForUser(c.Request.Context(), subject.UserID, filter, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, captures, result.Total, page, pageSize)
+}
+
+// GetByID handles getting one capture of the current user's API keys
+// GET /api/v1/request-captures/:id
+func (h *RequestCaptureHandler) GetByID(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	captureID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || captureID <= 0 {
+		response.BadRequest(c, "Invalid capture ID")
+		return
+	}
+	detail, err := h.captureService.GetForUser(c.Request.Context(), subject.UserID, captureID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, detail)
+}
+
+// Middleware captures the full request and response of gateway requests whose
+// API key or group is opted in. It must run after API key authentication.
+func (h *RequestCaptureHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h == nil || !h.captureService.Enabled() || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+		apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+		if !ok || !h.captureService.ShouldCapture(c.Request.Context(), apiKey) {
+			c.Next()
+			return
+		}
+
+		limit := h.captureService.MaxBodyBytes()
+		start := time.Now()
+		reqCapture := &captureReadCloser{ReadCloser: c.Request.Body, buf: limitedBuffer{limit: limit}}
+		if c.Request.Body != nil {
+			c.Request.Body = reqCapture
+		}
+		original := c.Writer
+		w := &captureResponseWriter{ResponseWriter: original, buf: limitedBuffer{limit: limit}}
+		c.Writer = w
+		defer func() {
+			if c.Writer == w {
+				c.Writer = original
+			}
+		}()
+
+		c.Next()
+
+		input := &service.RequestCaptureInput{
+			RequestID:     requestCaptureRequestID(c),
+			UserID:        apiKey.UserID,
+			APIKeyID:      apiKey.ID,
+			GroupID:       apiKey.GroupID,
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			StatusCode:    w.Status(),
+			Duration:      time.Since(start),
+			RequestBody:   reqCapture.buf.Bytes(),
+			ResponseBody:  w.buf.Bytes(),
+			RequestBytes:  reqCapture.buf.total,
+			ResponseBytes: w.buf.total,
+			Truncated:     reqCapture.buf.truncated() || w.buf.truncated(),
+			CreatedAt:     start,
+		}
+		if apiKey.Group != nil {
+			input.Platform = apiKey.Group.Platform
+		}
+		if v, ok := c.Get(opsModelKey); ok {
+			input.Model, _ = v.(string)
+		}
+		if v, ok := c.Get(opsStreamKey); ok {
+			input.Stream, _ = v.(bool)
+		}
+		if v, ok := c.Get(opsAccountIDKey); ok {
+			if id, ok := v.(int64); ok && id > 0 {
+				input.AccountID = &id
+			}
+		}
+		h.captureService.Submit(input)
+	}
+}
+
+// requestCaptureRequestID matches the request_id written to usage_logs (usage
+// record tasks carry the request identifiers via WithUsageRecordRequestContext)
+// so a capture can be looked up from a usage record.
+func requestCaptureRequestID(c *gin.Context) string {
+	return service.UsageRequestIDFromContext(c.Request.Context())
+}
+
+// limitedBuffer keeps the first limit bytes and counts the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+	total int
+}
+
+func (b *limitedBuffer) capture(p []byte) {
+	b.total += len(p)
+	if remaining := b.limit - b.Len(); remaining > 0 {
+		if len(p) > remaining {
+			p = p[:remaining]
+		}
+		_, _ = b.Write(p)
+	}
+}
+
+func (b *limitedBuffer) truncated() bool {
+	return b.total > b.Len()
+}
+
+type captureReadCloser struct {
+	io.ReadCloser
+	buf limitedBuffer
+}
+
+func (r *captureReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.buf.capture(p[:n])
+	}
+	return n, err
+}
+
+type captureResponseWriter struct {
+	gin.ResponseWriter
+	buf limitedBuffer
+}
+
+func (w *captureResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	if n > 0 {
+		w.buf.capture(p[:n])
+	}
+	return n, err
+}
+
+func (w *captureResponseWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	if n > 0 {
+		w.buf.capture([]byte(s[:n]))
+	}
+	return n, err
+}
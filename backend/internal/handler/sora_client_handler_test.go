@@ -1035,6 +1035,9 @@ func (r *stubAPIKeyRepoForHandler) UpdateLastUsed(context.Context, int64, time.T
 func (r *stubAPIKeyRepoForHandler) IncrementRateLimitUsage(context.Context, int64, float64) error {
 	return nil
 }
+func (r *stubAPIKeyRepoForHandler) UpdateStatus(context.Context, int64, string) error {
+	return nil
+}
 func (r *stubAPIKeyRepoForHandler) ResetRateLimitWindows(context.Context, int64) error {
 	return nil
 }
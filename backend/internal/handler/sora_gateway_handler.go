@@ -128,6 +128,8 @@ func (h *SoraGatewayHandler) ChatCompletions(c *gin.Context) {
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
 		return
 	}
+	// 分组请求改写规则，后续校验与转发基于改写后的请求
+	body, _ = transformGroupRequestBody(c, apiKey.Group, body, service.RequestTransformFormatOpenAIChat, "", reqLog)
 
 	// 使用 gjson 只读提取字段做校验，避免完整 Unmarshal
 	modelResult := gjson.GetBytes(body, "model")
@@ -223,15 +225,52 @@ func (h *SoraGatewayHandler) ChatCompletions(c *gin.Context) {
 	lastFailoverStatus := 0
 	var lastFailoverBody []byte
 	var lastFailoverHeaders http.Header
+	var lastFailoverErr *service.UpstreamFailoverError
+
+	currentAPIKey := apiKey
+	currentSubscription := subscription
+	// 分组模型降级链：调度失败（无可调度账号/全部限流）或上游过载时按顺序改用降级目标；
+	// 仅 Sora 分组启用，避免强制 Sora 平台时按其他平台分组的规则切换
+	var modelFallback *service.ModelFallbackChain
+	if apiKey.Group != nil && apiKey.Group.Platform == service.PlatformSora {
+		modelFallback = service.NewModelFallbackChain(apiKey.Group, reqModel)
+	}
+	switchModelFallback := func(lastErr *service.UpstreamFailoverError) bool {
+		if modelFallback == nil || !service.IsModelFallbackTrigger(lastErr) {
+			return false
+		}
+		next, ok := nextModelFallback(c, h.gatewayService, h.billingCacheService, modelFallback, apiKey, subscription, body, reqLog)
+		if !ok {
+			return false
+		}
+		reqLog.Info("sora.model_fallback_switch",
+			zap.String("requested_model", modelFallback.RequestedModel()),
+			zap.String("from_model", reqModel),
+			zap.String("fallback_model", next.target.Model),
+			zap.Any("fallback_group_id", next.apiKey.GroupID),
+		)
+		applyModelFallbackSwitch(c, next)
+		body = next.body
+		reqModel = next.target.Model
+		currentAPIKey = next.apiKey
+		currentSubscription = next.subscription
+		switchCount = 0
+		failedAccountIDs = make(map[int64]struct{})
+		lastFailoverErr = nil
+		return true
+	}
 
 	for {
-		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionHash, reqModel, failedAccountIDs, "")
+		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), currentAPIKey.GroupID, sessionHash, reqModel, failedAccountIDs, "")
 		if err != nil {
 			reqLog.Warn("sora.account_select_failed",
 				zap.Error(err),
 				zap.Int("excluded_account_count", len(failedAccountIDs)),
 			)
 			if len(failedAccountIDs) == 0 {
+				if switchModelFallback(nil) {
+					continue
+				}
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 				return
 			}
@@ -248,6 +287,9 @@ func (h *SoraGatewayHandler) ChatCompletions(c *gin.Context) {
 			if contentType != "" {
 				fields = append(fields, zap.String("last_upstream_content_type", contentType))
 			}
+			if switchModelFallback(lastFailoverErr) {
+				continue
+			}
 			reqLog.Warn("sora.failover_exhausted_no_available_accounts", fields...)
 			h.handleFailoverExhausted(c, lastFailoverStatus, lastFailoverHeaders, lastFailoverBody, streamStarted)
 			return
@@ -331,7 +373,11 @@ func (h *SoraGatewayHandler) ChatCompletions(c *gin.Context) {
 			var failoverErr *service.UpstreamFailoverError
 			if errors.As(err, &failoverErr) {
 				failedAccountIDs[account.ID] = struct{}{}
+				lastFailoverErr = failoverErr
 				if switchCount >= maxAccountSwitches {
+					if switchModelFallback(failoverErr) {
+						continue
+					}
 					lastFailoverStatus = failoverErr.StatusCode
 					lastFailoverHeaders = cloneHTTPHeaders(failoverErr.ResponseHeaders)
 					lastFailoverBody = failoverErr.ResponseBody
@@ -402,17 +448,19 @@ func (h *SoraGatewayHandler) ChatCompletions(c *gin.Context) {
 		requestPayloadHash := service.HashUsageRequestPayload(body)
 		inboundEndpoint := GetInboundEndpoint(c)
 		upstreamEndpoint := GetUpstreamEndpoint(c, account.Platform)
+		modelFallback.ApplyToResult(result)
 
 		// 使用量记录通过有界 worker 池提交，避免请求热路径创建无界 goroutine。
-		h.submitUsageRecordTask(func(ctx context.Context) {
+		h.submitUsageRecordTask(service.WithUsageRecordRequestContext(c.Request.Context(), func(ctx context.Context) {
 			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 				Result:             result,
-				APIKey:             apiKey,
-				User:               apiKey.User,
+				APIKey:             currentAPIKey,
+				User:               currentAPIKey.User,
 				Account:            account,
-				Subscription:       subscription,
+				Subscription:       currentSubscription,
 				InboundEndpoint:    inboundEndpoint,
 				UpstreamEndpoint:   upstreamEndpoint,
+				SessionID:          sessionHash,
 				UserAgent:          userAgent,
 				IPAddress:          clientIP,
 				RequestPayloadHash: requestPayloadHash,
@@ -420,13 +468,13 @@ func (h *SoraGatewayHandler) ChatCompletions(c *gin.Context) {
 				logger.L().With(
 					zap.String("component", "handler.sora_gateway.chat_completions"),
 					zap.Int64("user_id", subject.UserID),
-					zap.Int64("api_key_id", apiKey.ID),
-					zap.Any("group_id", apiKey.GroupID),
+					zap.Int64("api_key_id", currentAPIKey.ID),
+					zap.Any("group_id", currentAPIKey.GroupID),
 					zap.String("model", reqModel),
 					zap.Int64("account_id", account.ID),
 				).Error("sora.record_usage_failed", zap.Error(err))
 			}
-		})
+		}))
 		reqLog.Debug("sora.request_completed",
 			zap.Int64("account_id", account.ID),
 			zap.Int64("proxy_id", proxyID),
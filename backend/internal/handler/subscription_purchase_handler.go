@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionPurchaseHandler handles self-service subscription purchase from balance
+type SubscriptionPurchaseHandler struct {
+	purchaseService *service.SubscriptionPurchaseService
+}
+
+// NewSubscriptionPurchaseHandler creates a new SubscriptionPurchaseHandler
+func NewSubscriptionPurchaseHandler(purchaseService *service.SubscriptionPurchaseService) *SubscriptionPurchaseHandler {
+	return &SubscriptionPurchaseHandler{purchaseService: purchaseService}
+}
+
+// PurchaseSubscriptionRequest represents the purchase/quote payload
+type PurchaseSubscriptionRequest struct {
+	GroupID int64 `json:"group_id" binding:"required"`
+	// Periods is the number of renewal periods to buy (default 1)
+	Periods int `json:"periods"`
+	// UpgradeFromSubscriptionID replaces an active subscription; its remaining time is credited
+	UpgradeFromSubscriptionID *int64 `json:"upgrade_from_subscription_id"`
+	AutoRenew                 bool   `json:"auto_renew"`
+}
+
+func (r PurchaseSubscriptionRequest) toServiceInput() service.PurchaseSubscriptionInput {
+	return service.PurchaseSubscriptionInput{
+		GroupID:                   r.GroupID,
+		Periods:                   r.Periods,
+		UpgradeFromSubscriptionID: r.UpgradeFromSubscriptionID,
+		AutoRenew:                 r.AutoRenew,
+	}
+}
+
+// PurchaseSubscriptionResponse is the purchase result
+type PurchaseSubscriptionResponse struct {
+	Quote        *service.SubscriptionPurchaseQuote `json:"quote"`
+	Subscription *dto.UserSubscription              `json:"subscription"`
+	ChargeID     int64                              `json:"charge_id"`
+	Extended     bool                               `json:"extended"`
+}
+
+// ListPurchasable handles listing subscription groups available for purchase
+// GET /api/v1/subscriptions/purchasable
+func (h *SubscriptionPurchaseHandler) ListPurchasable(c *gin.Context) {
+	groups, err := h.purchaseService.ListPurchasableGroups(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.Group, 0, len(groups))
+	for i := range groups {
+		out = append(out, *dto.GroupFromService(&groups[i]))
+	}
+	response.Success(c, out)
+}
+
+// Quote handles previewing the price of a purchase or upgrade
+// POST /api/v1/subscriptions/purchase/quote
+func (h *SubscriptionPurchaseHandler) Quote(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	var req PurchaseSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	quote, err := h.purchaseService.Quote(c.Request.Context(), subject.UserID, req.toServiceInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, quote)
+}
+
+// Purchase handles buying (or upgrading to) a subscription with the user's balance.
+// Requires an Idempotency-Key header.
+// POST /api/v1/subscriptions/purchase
+func (h *SubscriptionPurchaseHandler) Purchase(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	var req PurchaseSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	executeUserIdempotentJSON(c, "user.subscriptions.purchase", req, service.DefaultWriteIdempotencyTTL(), func(ctx context.Context) (any, error) {
+		result, err := h.purchaseService.Purchase(ctx, subject.UserID, req.toServiceInput())
+		if err != nil {
+			return nil, err
+		}
+		return PurchaseSubscriptionResponse{
+			Quote:        result.Quote,
+			Subscription: dto.UserSubscriptionFromService(result.Subscription),
+			ChargeID:     result.Charge.ID,
+			Extended:     result.Extended,
+		}, nil
+	})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionRenewalHandler handles subscription auto-renewal for end users
+type SubscriptionRenewalHandler struct {
+	renewalService *service.SubscriptionRenewalService
+}
+
+// NewSubscriptionRenewalHandler creates a new SubscriptionRenewalHandler
+func NewSubscriptionRenewalHandler(renewalService *service.SubscriptionRenewalService) *SubscriptionRenewalHandler {
+	return &SubscriptionRenewalHandler{renewalService: renewalService}
+}
+
+// SetAutoRenewRequest represents the auto-renew toggle payload
+type SetAutoRenewRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// SetAutoRenew handles enabling/disabling auto-renewal of the current user's subscription
+// PUT /api/v1/subscriptions/:id/auto-renew
+func (h *SubscriptionRenewalHandler) SetAutoRenew(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+	var req SetAutoRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	sub, err := h.renewalService.SetAutoRenew(c.Request.Context(), subject.UserID, subscriptionID, *req.Enabled)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromService(sub))
+}
+
+// ListCharges handles listing the current user's subscription charges (renewal ledger)
+// GET /api/v1/subscriptions/charges
+func (h *SubscriptionRenewalHandler) ListCharges(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	charges, result, err := h.renewalService.ListCharges(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, charges, result.Total, page, pageSize)
+}
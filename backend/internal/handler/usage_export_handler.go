@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageExportHandler handles user self-service usage export jobs
+type UsageExportHandler struct {
+	exportService *service.UsageExportService
+	apiKeyService *service.APIKeyService
+}
+
+// NewUsageExportHandler creates a new UsageExportHandler
+func NewUsageExportHandler(exportService *service.UsageExportService, apiKeyService *service.APIKeyService) *UsageExportHandler {
+	return &UsageExportHandler{
+		exportService: exportService,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateUsageExportRequest represents a user export job creation request
+type CreateUsageExportRequest struct {
+	Format    string  `json:"format" binding:"required"`
+	StartDate string  `json:"start_date" binding:"required"`
+	EndDate   string  `json:"end_date" binding:"required"`
+	APIKeyID  *int64  `json:"api_key_id"`
+	GroupID   *int64  `json:"group_id"`
+	Model     *string `json:"model"`
+	Timezone  string  `json:"timezone"`
+}
+
+// Create handles creating a usage export job for the current user
+// POST /api/v1/usage/exports
+func (h *UsageExportHandler) Create(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreateUsageExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	startTime, err := timezone.ParseInUserLocation("2006-01-02", strings.TrimSpace(req.StartDate), req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+		return
+	}
+	endTime, err := timezone.ParseInUserLocation("2006-01-02", strings.TrimSpace(req.EndDate), req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+		return
+	}
+	// Use half-open range [start, end), move to next calendar day start (DST-safe).
+	endTime = endTime.AddDate(0, 0, 1)
+
+	if req.APIKeyID != nil && *req.APIKeyID > 0 {
+		apiKey, err := h.apiKeyService.GetByID(c.Request.Context(), *req.APIKeyID)
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		if apiKey.UserID != subject.UserID {
+			response.Forbidden(c, "Not authorized to access this API key's usage records")
+			return
+		}
+	}
+
+	job, err := h.exportService.CreateJob(c.Request.Context(), service.UsageExportRequest{
+		Format: req.Format,
+		Filters: service.UsageExportFilters{
+			StartTime: startTime,
+			EndTime:   endTime,
+			APIKeyID:  req.APIKeyID,
+			GroupID:   req.GroupID,
+			Model:     req.Model,
+		},
+		CreatedBy: subject.UserID,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, job)
+}
+
+// List handles listing the current user's export jobs
+// GET /api/v1/usage/exports
+func (h *UsageExportHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	userID := subject.UserID
+	jobs, result, err := h.exportService.ListJobs(c.Request.Context(), &userID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, jobs, result.Total, page, pageSize)
+}
+
+// Get handles getting a single export job of the current user
+// GET /api/v1/usage/exports/:id
+func (h *UsageExportHandler) Get(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || jobID <= 0 {
+		response.BadRequest(c, "Invalid export job id")
+		return
+	}
+	job, err := h.exportService.GetJob(c.Request.Context(), jobID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, job)
+}
+
+// Cancel handles canceling a pending or running export job
+// POST /api/v1/usage/exports/:id/cancel
+func (h *UsageExportHandler) Cancel(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || jobID <= 0 {
+		response.BadRequest(c, "Invalid export job id")
+		return
+	}
+	if err := h.exportService.CancelJob(c.Request.Context(), jobID, subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"id": jobID, "status": service.UsageExportStatusCanceled})
+}
+
+// Download handles downloading a finished export file.
+// Local storage streams the file; S3 storage returns a presigned URL.
+// GET /api/v1/usage/exports/:id/download
+func (h *UsageExportHandler) Download(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || jobID <= 0 {
+		response.BadRequest(c, "Invalid export job id")
+		return
+	}
+	download, err := h.exportService.GetDownload(c.Request.Context(), jobID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	serveUsageExportDownload(c, download)
+}
+
+// serveUsageExportDownload writes the download response: presigned URL as JSON, or the local file as attachment
+func serveUsageExportDownload(c *gin.Context, download *service.UsageExportDownload) {
+	if download.URL != "" {
+		response.Success(c, gin.H{"url": download.URL, "file_name": download.FileName})
+		return
+	}
+	c.Header("Content-Type", download.ContentType)
+	c.FileAttachment(download.LocalPath, download.FileName)
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageSessionHandler handles conversation-level usage analytics for the current user
+type UsageSessionHandler struct {
+	sessionService *service.UsageSessionService
+	apiKeyService  *service.APIKeyService
+}
+
+// NewUsageSessionHandler creates a new UsageSessionHandler
+func NewUsageSessionHandler(sessionService *service.UsageSessionService, apiKeyService *service.APIKeyService) *UsageSessionHandler {
+	return &UsageSessionHandler{
+		sessionService: sessionService,
+		apiKeyService:  apiKeyService,
+	}
+}
+
+// List handles listing the current user's sessions
+// GET /api/v1/usage/sessions
+func (h *UsageSessionHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	userID := subject.UserID
+	filters := service.UsageSessionFilters{
+		UserID: &userID,
+		Model:  c.Query("model"),
+		SortBy: c.Query("sort_by"),
+	}
+
+	if apiKeyIDStr := c.Query("api_key_id"); apiKeyIDStr != "" {
+		id, err := strconv.ParseInt(apiKeyIDStr, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid api_key_id")
+			return
+		}
+		apiKey, err := h.apiKeyService.GetByID(c.Request.Context(), id)
+		if err != nil {
+			response.NotFound(c, "API key not found")
+			return
+		}
+		if apiKey.UserID != subject.UserID {
+			response.Forbidden(c, "Not authorized to access this API key's usage records")
+			return
+		}
+		filters.APIKeyID = &id
+	}
+
+	startTime, endTime, ok := parseUsageSessionDateRange(c)
+	if !ok {
+		return
+	}
+	filters.StartTime = startTime
+	filters.EndTime = endTime
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	sessions, result, err := h.sessionService.ListSessions(c.Request.Context(), filters, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, sessions, result.Total, page, pageSize)
+}
+
+// Get handles getting a single session aggregate of the current user
+// GET /api/v1/usage/sessions/:session_id
+func (h *UsageSessionHandler) Get(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	userID := subject.UserID
+	session, err := h.sessionService.GetSession(c.Request.Context(), c.Param("session_id"), &userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, session)
+}
+
+// ListLogs handles listing the requests of a session of the current user
+// GET /api/v1/usage/sessions/:session_id/logs
+func (h *UsageSessionHandler) ListLogs(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	userID := subject.UserID
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	records, result, err := h.sessionService.ListSessionLogs(c.Request.Context(), c.Param("session_id"), &userID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.UsageLog, 0, len(records))
+	for i := range records {
+		out = append(out, *dto.UsageLogFromService(&records[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// parseUsageSessionDateRange parses optional start_date / end_date (YYYY-MM-DD) in the
+// user's timezone into a half-open range. It writes a 400 response and returns false on error.
+func parseUsageSessionDateRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var startTime, endTime *time.Time
+	userTZ := c.Query("timezone")
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+			return nil, nil, false
+		}
+		startTime = &t
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+			return nil, nil, false
+		}
+		// 与 SQL 条件 created_at < end 对齐，使用次日 00:00 作为上边界（DST-safe）。
+		t = t.AddDate(0, 0, 1)
+		endTime = &t
+	}
+	return startTime, endTime, true
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserNotificationHandler handles end-user notification preferences
+type UserNotificationHandler struct {
+	notificationService *service.UserNotificationService
+}
+
+// NewUserNotificationHandler creates a new UserNotificationHandler
+func NewUserNotificationHandler(notificationService *service.UserNotificationService) *UserNotificationHandler {
+	return &UserNotificationHandler{notificationService: notificationService}
+}
+
+// UpdateNotificationPreferenceRequest represents the update notification preference payload.
+// Omitted fields keep their current value.
+type UpdateNotificationPreferenceRequest struct {
+	EmailEnabled *bool   `json:"email_enabled"`
+	WebhookURL   *string `json:"webhook_url"`
+	// WebhookSecret signs webhook deliveries (HMAC-SHA256); empty string clears it
+	WebhookSecret *string `json:"webhook_secret"`
+	// BalanceThreshold notifies when balance drops below it; 0 disables
+	BalanceThreshold        *float64 `json:"balance_threshold"`
+	SubscriptionUsageAlerts *bool    `json:"subscription_usage_alerts"`
+	SubscriptionExpiryDays  *int     `json:"subscription_expiry_days"`
+	APIKeyQuotaPercent      *int     `json:"api_key_quota_percent"`
+	APIKeyExpiryDays        *int     `json:"api_key_expiry_days"`
+	// Locale selects the language of emails (e.g. "en", "zh"); empty string uses the site default
+	Locale *string `json:"locale"`
+}
+
+// NotificationPreferenceResponse is the notification preference view (the webhook secret is never returned)
+type NotificationPreferenceResponse struct {
+	EmailEnabled            bool      `json:"email_enabled"`
+	WebhookURL              string    `json:"webhook_url"`
+	HasWebhookSecret        bool      `json:"has_webhook_secret"`
+	BalanceThreshold        *float64  `json:"balance_threshold"`
+	SubscriptionUsageAlerts bool      `json:"subscription_usage_alerts"`
+	SubscriptionExpiryDays  int       `json:"subscription_expiry_days"`
+	APIKeyQuotaPercent      int       `json:"api_key_quota_percent"`
+	APIKeyExpiryDays        int       `json:"api_key_expiry_days"`
+	Locale                  string    `json:"locale"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+func notificationPreferenceResponse(pref *service.UserNotificationPreference) NotificationPreferenceResponse {
+	return NotificationPreferenceResponse{
+		EmailEnabled:            pref.EmailEnabled,
+		WebhookURL:              pref.WebhookURL,
+		HasWebhookSecret:        pref.WebhookSecret != "",
+		BalanceThreshold:        pref.BalanceThreshold,
+		SubscriptionUsageAlerts: pref.SubscriptionUsageAlerts,
+		SubscriptionExpiryDays:  pref.SubscriptionExpiryDays,
+		APIKeyQuotaPercent:      pref.APIKeyQuotaPercent,
+		APIKeyExpiryDays:        pref.APIKeyExpiryDays,
+		Locale:                  pref.Locale,
+		UpdatedAt:               pref.UpdatedAt,
+	}
+}
+
+// GetPreferences handles getting the current user's notification preferences
+// GET /api/v1/user/notifications/preferences
+func (h *UserNotificationHandler) GetPreferences(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	pref, err := h.notificationService.GetPreference(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, notificationPreferenceResponse(pref))
+}
+
+// UpdatePreferences handles updating the current user's notification preferences
+// PUT /api/v1/user/notifications/preferences
+func (h *UserNotificationHandler) UpdatePreferences(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	var req UpdateNotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	pref, err := h.notificationService.UpdatePreference(c.Request.Context(), subject.UserID, service.UpdateUserNotificationPreferenceInput{
+		EmailEnabled:            req.EmailEnabled,
+		WebhookURL:              req.WebhookURL,
+		WebhookSecret:           req.WebhookSecret,
+		BalanceThreshold:        req.BalanceThreshold,
+		SubscriptionUsageAlerts: req.SubscriptionUsageAlerts,
+		SubscriptionExpiryDays:  req.SubscriptionExpiryDays,
+		APIKeyQuotaPercent:      req.APIKeyQuotaPercent,
+		APIKeyExpiryDays:        req.APIKeyExpiryDays,
+		Locale:                  req.Locale,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, notificationPreferenceResponse(pref))
+}
+
+// ListEvents handles listing notifications sent to the current user
+// GET /api/v1/user/notifications/events
+func (h *UserNotificationHandler) ListEvents(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	events, result, err := h.notificationService.ListEvents(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, events, result.Total, page, pageSize)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserRateLimitHeadersHandler writes rate-limit response headers describing the
+// user's own limits instead of the upstream account's.
+type UserRateLimitHeadersHandler struct {
+	rateLimitHeaderService *service.UserRateLimitHeaderService
+}
+
+// NewUserRateLimitHeadersHandler creates a new UserRateLimitHeadersHandler
+func NewUserRateLimitHeadersHandler(rateLimitHeaderService *service.UserRateLimitHeaderService) *UserRateLimitHeadersHandler {
+	return &UserRateLimitHeadersHandler{rateLimitHeaderService: rateLimitHeaderService}
+}
+
+// Middleware sets the user's rate-limit headers in the inbound protocol's format
+// (anthropic-ratelimit-* for Messages, x-ratelimit-* for Chat Completions / Responses,
+// plus x-sub2api-concurrency-* for the user's concurrency slots)
+// before the gateway handler runs, so they are present on streaming, error and
+// locally rejected responses alike. It must run after API key authentication.
+func (h *UserRateLimitHeadersHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h == nil || !h.rateLimitHeaderService.Enabled() || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		endpoint := GetInboundEndpoint(c)
+		if endpoint != EndpointMessages && endpoint != EndpointChatCompletions && endpoint != EndpointResponses {
+			c.Next()
+			return
+		}
+		apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		concurrencyLimit := 0
+		if subject, ok := middleware2.GetAuthSubjectFromContext(c); ok {
+			concurrencyLimit = subject.Concurrency
+		}
+		subscription, _ := middleware2.GetSubscriptionFromContext(c)
+
+		state := h.rateLimitHeaderService.GetState(c.Request.Context(), apiKey, subscription, concurrencyLimit)
+		if endpoint == EndpointMessages {
+			state.WriteAnthropicHeaders(c.Writer.Header(), h.rateLimitHeaderService.WarningThreshold())
+		} else {
+			state.WriteOpenAIHeaders(c.Writer.Header(), time.Now())
+		}
+		c.Next()
+	}
+}
@@ -28,10 +28,18 @@ func ProvideAdminHandlers(
 	systemHandler *admin.SystemHandler,
 	subscriptionHandler *admin.SubscriptionHandler,
 	usageHandler *admin.UsageHandler,
+	usageExportHandler *admin.UsageExportHandler,
+	usageSessionHandler *admin.UsageSessionHandler,
+	usageLogArchiveHandler *admin.UsageLogArchiveHandler,
 	userAttributeHandler *admin.UserAttributeHandler,
 	errorPassthroughHandler *admin.ErrorPassthroughHandler,
 	apiKeyHandler *admin.AdminAPIKeyHandler,
 	scheduledTestHandler *admin.ScheduledTestHandler,
+	invoiceHandler *admin.InvoiceHandler,
+	requestCaptureHandler *admin.RequestCaptureHandler,
+	contentModerationHandler *admin.ContentModerationHandler,
+	emailTemplateHandler *admin.EmailTemplateHandler,
+	pricingHandler *admin.PricingHandler,
 ) *AdminHandlers {
 	return &AdminHandlers{
 		Dashboard:        dashboardHandler,
@@ -53,10 +61,19 @@ func ProvideAdminHandlers(
 		System:           systemHandler,
 		Subscription:     subscriptionHandler,
 		Usage:            usageHandler,
+		UsageExport:      usageExportHandler,
+		UsageSession:     usageSessionHandler,
+		UsageLogArchive:  usageLogArchiveHandler,
 		UserAttribute:    userAttributeHandler,
 		ErrorPassthrough: errorPassthroughHandler,
 		APIKey:           apiKeyHandler,
 		ScheduledTest:    scheduledTestHandler,
+		Invoice:          invoiceHandler,
+		RequestCapture:   requestCaptureHandler,
+		EmailTemplate:    emailTemplateHandler,
+		Pricing:          pricingHandler,
+
+		ContentModeration: contentModerationHandler,
 	}
 }
 
@@ -76,6 +93,8 @@ func ProvideHandlers(
 	userHandler *UserHandler,
 	apiKeyHandler *APIKeyHandler,
 	usageHandler *UsageHandler,
+	usageExportHandler *UsageExportHandler,
+	usageSessionHandler *UsageSessionHandler,
 	redeemHandler *RedeemHandler,
 	subscriptionHandler *SubscriptionHandler,
 	announcementHandler *AnnouncementHandler,
@@ -86,6 +105,14 @@ func ProvideHandlers(
 	soraClientHandler *SoraClientHandler,
 	settingHandler *SettingHandler,
 	totpHandler *TotpHandler,
+	passkeyHandler *PasskeyHandler,
+	invoiceHandler *InvoiceHandler,
+	userNotificationHandler *UserNotificationHandler,
+	subscriptionRenewalHandler *SubscriptionRenewalHandler,
+	subscriptionPurchaseHandler *SubscriptionPurchaseHandler,
+	requestCaptureHandler *RequestCaptureHandler,
+	contentModerationHandler *ContentModerationHandler,
+	userRateLimitHeadersHandler *UserRateLimitHeadersHandler,
 	_ *service.IdempotencyCoordinator,
 	_ *service.IdempotencyCleanupService,
 ) *Handlers {
@@ -94,6 +121,8 @@ func ProvideHandlers(
 		User:          userHandler,
 		APIKey:        apiKeyHandler,
 		Usage:         usageHandler,
+		UsageExport:   usageExportHandler,
+		UsageSession:  usageSessionHandler,
 		Redeem:        redeemHandler,
 		Subscription:  subscriptionHandler,
 		Announcement:  announcementHandler,
@@ -104,6 +133,15 @@ func ProvideHandlers(
 		SoraClient:    soraClientHandler,
 		Setting:       settingHandler,
 		Totp:          totpHandler,
+		Passkey:       passkeyHandler,
+		Invoice:       invoiceHandler,
+
+		UserNotification:     userNotificationHandler,
+		SubscriptionRenewal:  subscriptionRenewalHandler,
+		SubscriptionPurchase: subscriptionPurchaseHandler,
+		RequestCapture:       requestCaptureHandler,
+		ContentModeration:    contentModerationHandler,
+		UserRateLimitHeaders: userRateLimitHeadersHandler,
 	}
 }
 
@@ -114,6 +152,8 @@ var ProviderSet = wire.NewSet(
 	NewUserHandler,
 	NewAPIKeyHandler,
 	NewUsageHandler,
+	NewUsageExportHandler,
+	NewUsageSessionHandler,
 	NewRedeemHandler,
 	NewSubscriptionHandler,
 	NewAnnouncementHandler,
@@ -121,6 +161,14 @@ var ProviderSet = wire.NewSet(
 	NewOpenAIGatewayHandler,
 	NewSoraGatewayHandler,
 	NewTotpHandler,
+	NewPasskeyHandler,
+	NewInvoiceHandler,
+	NewUserNotificationHandler,
+	NewSubscriptionRenewalHandler,
+	NewSubscriptionPurchaseHandler,
+	NewRequestCaptureHandler,
+	NewContentModerationHandler,
+	NewUserRateLimitHeadersHandler,
 	ProvideSettingHandler,
 
 	// Admin handlers
@@ -143,10 +191,18 @@ var ProviderSet = wire.NewSet(
 	ProvideSystemHandler,
 	admin.NewSubscriptionHandler,
 	admin.NewUsageHandler,
+	admin.NewUsageExportHandler,
+	admin.NewUsageSessionHandler,
+	admin.NewUsageLogArchiveHandler,
 	admin.NewUserAttributeHandler,
 	admin.NewErrorPassthroughHandler,
 	admin.NewAdminAPIKeyHandler,
 	admin.NewScheduledTestHandler,
+	admin.NewInvoiceHandler,
+	admin.NewRequestCaptureHandler,
+	admin.NewContentModerationHandler,
+	admin.NewEmailTemplateHandler,
+	admin.NewPricingHandler,
 
 	// AdminHandlers and Handlers constructors
 	ProvideAdminHandlers,
@@ -0,0 +1,301 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Non-streaming: AnthropicResponse → GeminiResponse
+// ---------------------------------------------------------------------------
+
+// AnthropicToGeminiResponse converts an Anthropic Messages response into a
+// Gemini generateContent response. Thought text is only emitted when the
+// client asked for it (thinkingConfig.includeThoughts); signed thinking blocks
+// are always carried in the thoughtSignature of the following part so the
+// client can replay them.
+func AnthropicToGeminiResponse(resp *AnthropicResponse, model string, includeThoughts bool) *GeminiResponse {
+	parts := make([]GeminiPart, 0, len(resp.Content))
+	pendingSignature := ""
+	attach := func(p GeminiPart) {
+		p.ThoughtSignature = pendingSignature
+		pendingSignature = ""
+		parts = append(parts, p)
+	}
+
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "thinking":
+			if includeThoughts && block.Thinking != "" {
+				parts = append(parts, GeminiPart{Text: block.Thinking, Thought: true})
+			}
+			if block.Signature != "" {
+				pendingSignature = encodeAnthropicThinkingSignature(block.Thinking, block.Signature)
+			}
+		case "text":
+			if block.Text != "" {
+				attach(GeminiPart{Text: block.Text})
+			}
+		case "tool_use":
+			attach(GeminiPart{FunctionCall: &GeminiFunctionCall{ID: block.ID, Name: block.Name, Args: normalizeGeminiArgs(block.Input)}})
+		}
+	}
+	if pendingSignature != "" {
+		attach(GeminiPart{})
+	}
+
+	if model == "" {
+		model = resp.Model
+	}
+	return &GeminiResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      &GeminiContent{Role: "model", Parts: parts},
+			FinishReason: anthropicStopReasonToGeminiFinishReason(resp.StopReason),
+		}},
+		UsageMetadata: anthropicUsageToGemini(resp.Usage),
+		ModelVersion:  model,
+		ResponseID:    resp.ID,
+	}
+}
+
+// anthropicStopReasonToGeminiFinishReason maps stop_reason to finishReason.
+// Gemini reports STOP for tool calls as well.
+func anthropicStopReasonToGeminiFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens", "model_context_window_exceeded":
+		return "MAX_TOKENS"
+	case "refusal":
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
+// anthropicUsageToGemini maps Anthropic usage to usageMetadata. Gemini's
+// promptTokenCount includes cached tokens, so cache reads and writes are
+// folded back in.
+func anthropicUsageToGemini(u AnthropicUsage) *GeminiUsageMetadata {
+	prompt := u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens
+	return &GeminiUsageMetadata{
+		PromptTokenCount:        prompt,
+		CandidatesTokenCount:    u.OutputTokens,
+		CachedContentTokenCount: u.CacheReadInputTokens,
+		TotalTokenCount:         prompt + u.OutputTokens,
+	}
+}
+
+func normalizeGeminiArgs(input json.RawMessage) json.RawMessage {
+	if len(input) == 0 || string(input) == "null" {
+		return json.RawMessage(`{}`)
+	}
+	return input
+}
+
+// ---------------------------------------------------------------------------
+// Streaming: AnthropicStreamEvent → []GeminiResponse (stateful converter)
+// ---------------------------------------------------------------------------
+
+// AnthropicEventToGeminiState tracks state for converting a sequence of
+// Anthropic SSE events into streamGenerateContent chunks.
+type AnthropicEventToGeminiState struct {
+	Model           string
+	ResponseID      string
+	IncludeThoughts bool
+
+	Started bool
+	Done    bool
+
+	StopReason string
+	Usage      AnthropicUsage
+	Failed     *AnthropicError
+
+	// current holds the in-progress block for each open index.
+	current map[int]*anthropicToGeminiBlock
+
+	// pendingSignature is the encoded thinking block waiting to be attached
+	// to the next emitted part.
+	pendingSignature string
+}
+
+type anthropicToGeminiBlock struct {
+	blockType string // "text" | "thinking" | "tool_use"
+	id        string
+	name      string
+	input     json.RawMessage
+	buf       strings.Builder
+	signature string
+}
+
+// NewAnthropicEventToGeminiState returns an initialised stream state.
+// model overrides the upstream model name in emitted chunks when non-empty.
+func NewAnthropicEventToGeminiState(model string, includeThoughts bool) *AnthropicEventToGeminiState {
+	return &AnthropicEventToGeminiState{
+		Model:           model,
+		IncludeThoughts: includeThoughts,
+		current:         make(map[int]*anthropicToGeminiBlock),
+	}
+}
+
+// AnthropicEventToGeminiChunks converts a single Anthropic SSE event into
+// zero or more Gemini chunks, updating state as it goes. An upstream error
+// event sets state.Failed and emits nothing; the caller reports it.
+func AnthropicEventToGeminiChunks(evt *AnthropicStreamEvent, state *AnthropicEventToGeminiState) []GeminiResponse {
+	if state.Done {
+		return nil
+	}
+	switch evt.Type {
+	case "message_start":
+		state.Started = true
+		if evt.Message != nil {
+			state.ResponseID = evt.Message.ID
+			if state.Model == "" {
+				state.Model = evt.Message.Model
+			}
+			state.Usage = evt.Message.Usage
+		}
+		return nil
+	case "content_block_start":
+		if evt.Index == nil || evt.ContentBlock == nil {
+			return nil
+		}
+		switch evt.ContentBlock.Type {
+		case "text", "thinking", "tool_use":
+			block := &anthropicToGeminiBlock{blockType: evt.ContentBlock.Type, id: evt.ContentBlock.ID, name: evt.ContentBlock.Name}
+			if evt.ContentBlock.Type == "tool_use" {
+				block.input = evt.ContentBlock.Input
+			}
+			state.current[*evt.Index] = block
+			if evt.ContentBlock.Text != "" {
+				return state.emitText(evt.ContentBlock.Text)
+			}
+		}
+		return nil
+	case "content_block_delta":
+		if evt.Index == nil || evt.Delta == nil {
+			return nil
+		}
+		block := state.current[*evt.Index]
+		if block == nil {
+			return nil
+		}
+		switch evt.Delta.Type {
+		case "text_delta":
+			if evt.Delta.Text != "" {
+				return state.emitText(evt.Delta.Text)
+			}
+		case "thinking_delta":
+			block.buf.WriteString(evt.Delta.Thinking)
+			if state.IncludeThoughts && evt.Delta.Thinking != "" {
+				return []GeminiResponse{state.chunk(GeminiPart{Text: evt.Delta.Thinking, Thought: true})}
+			}
+		case "signature_delta":
+			block.signature += evt.Delta.Signature
+		case "input_json_delta":
+			block.buf.WriteString(evt.Delta.PartialJSON)
+		}
+		return nil
+	case "content_block_stop":
+		if evt.Index == nil {
+			return nil
+		}
+		block := state.current[*evt.Index]
+		delete(state.current, *evt.Index)
+		if block == nil {
+			return nil
+		}
+		switch block.blockType {
+		case "thinking":
+			if block.signature != "" {
+				state.pendingSignature = encodeAnthropicThinkingSignature(block.buf.String(), block.signature)
+			}
+		case "tool_use":
+			args := block.input
+			if block.buf.Len() > 0 {
+				args = json.RawMessage(block.buf.String())
+			}
+			return []GeminiResponse{state.chunk(state.withSignature(GeminiPart{
+				FunctionCall: &GeminiFunctionCall{ID: block.id, Name: block.name, Args: normalizeGeminiArgs(args)},
+			}))}
+		}
+		return nil
+	case "message_delta":
+		if evt.Delta != nil && evt.Delta.StopReason != "" {
+			state.StopReason = evt.Delta.StopReason
+		}
+		if evt.Usage != nil {
+			state.Usage.OutputTokens = evt.Usage.OutputTokens
+			if evt.Usage.InputTokens > 0 {
+				state.Usage.InputTokens = evt.Usage.InputTokens
+			}
+			if evt.Usage.CacheCreationInputTokens > 0 {
+				state.Usage.CacheCreationInputTokens = evt.Usage.CacheCreationInputTokens
+			}
+			if evt.Usage.CacheReadInputTokens > 0 {
+				state.Usage.CacheReadInputTokens = evt.Usage.CacheReadInputTokens
+			}
+		}
+		return nil
+	case "message_stop":
+		return state.finish()
+	case "error":
+		state.Failed = &AnthropicError{Type: "api_error", Message: "upstream stream error"}
+		if evt.Error != nil {
+			state.Failed = evt.Error
+		}
+		state.Done = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// FinalizeAnthropicGeminiStream emits the final chunk if the upstream stream
+// ended without message_stop.
+func FinalizeAnthropicGeminiStream(state *AnthropicEventToGeminiState) []GeminiResponse {
+	if !state.Started || state.Done {
+		return nil
+	}
+	return state.finish()
+}
+
+// GeminiChunkToSSE formats a chunk the way streamGenerateContent?alt=sse does.
+func GeminiChunkToSSE(chunk GeminiResponse) (string, error) {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("data: %s\n\n", data), nil
+}
+
+// --- internal helpers ---
+
+func (state *AnthropicEventToGeminiState) emitText(text string) []GeminiResponse {
+	return []GeminiResponse{state.chunk(state.withSignature(GeminiPart{Text: text}))}
+}
+
+func (state *AnthropicEventToGeminiState) withSignature(p GeminiPart) GeminiPart {
+	p.ThoughtSignature = state.pendingSignature
+	state.pendingSignature = ""
+	return p
+}
+
+func (state *AnthropicEventToGeminiState) chunk(parts ...GeminiPart) GeminiResponse {
+	return GeminiResponse{
+		Candidates:   []GeminiCandidate{{Content: &GeminiContent{Role: "model", Parts: parts}}},
+		ModelVersion: state.Model,
+		ResponseID:   state.ResponseID,
+	}
+}
+
+func (state *AnthropicEventToGeminiState) finish() []GeminiResponse {
+	state.Done = true
+	parts := []GeminiPart{}
+	if state.pendingSignature != "" {
+		parts = append(parts, state.withSignature(GeminiPart{}))
+	}
+	final := state.chunk(parts...)
+	final.Candidates[0].FinishReason = anthropicStopReasonToGeminiFinishReason(state.StopReason)
+	final.UsageMetadata = anthropicUsageToGemini(state.Usage)
+	return []GeminiResponse{final}
+}
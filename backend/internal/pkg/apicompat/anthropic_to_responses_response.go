@@ -0,0 +1,581 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Non-streaming: AnthropicResponse → ResponsesResponse
+// ---------------------------------------------------------------------------
+
+// AnthropicToResponsesResponse converts an Anthropic Messages response into a
+// Responses API response. Thinking blocks become reasoning items (the
+// signature is exposed as encrypted_content so the client can replay it),
+// tool_use blocks become function_call items and server-side web searches
+// become web_search_call items.
+func AnthropicToResponsesResponse(resp *AnthropicResponse, model string) *ResponsesResponse {
+	out := &ResponsesResponse{
+		ID:     toResponsesResponseID(resp.ID),
+		Object: "response",
+		Model:  model,
+		Output: []ResponsesOutput{},
+	}
+
+	var msg *ResponsesOutput
+	flushMessage := func() {
+		if msg != nil {
+			out.Output = append(out.Output, *msg)
+			msg = nil
+		}
+	}
+
+	for i, b := range resp.Content {
+		switch b.Type {
+		case "thinking":
+			flushMessage()
+			out.Output = append(out.Output, ResponsesOutput{
+				Type:             "reasoning",
+				ID:               fmt.Sprintf("rs_%s_%d", out.ID, i),
+				EncryptedContent: b.Signature,
+				Summary:          []ResponsesSummary{{Type: "summary_text", Text: b.Thinking}},
+			})
+		case "text":
+			if msg == nil {
+				msg = &ResponsesOutput{
+					Type:   "message",
+					ID:     fmt.Sprintf("msg_%s_%d", out.ID, i),
+					Role:   "assistant",
+					Status: "completed",
+				}
+			}
+			msg.Content = append(msg.Content, ResponsesContentPart{Type: "output_text", Text: b.Text})
+		case "tool_use":
+			flushMessage()
+			args := "{}"
+			if len(b.Input) > 0 {
+				args = string(b.Input)
+			}
+			out.Output = append(out.Output, ResponsesOutput{
+				Type:      "function_call",
+				ID:        toResponsesCallID(b.ID),
+				CallID:    b.ID,
+				Name:      b.Name,
+				Arguments: args,
+				Status:    "completed",
+			})
+		case "server_tool_use":
+			if b.Name != "web_search" {
+				continue
+			}
+			flushMessage()
+			out.Output = append(out.Output, ResponsesOutput{
+				Type:   "web_search_call",
+				ID:     toWebSearchCallID(b.ID),
+				Status: "completed",
+				Action: &WebSearchAction{Type: "search", Query: webSearchQueryFromInput(b.Input)},
+			})
+		}
+	}
+	flushMessage()
+
+	out.Status, out.IncompleteDetails = anthropicStopReasonToResponsesStatus(resp.StopReason)
+	out.Usage = anthropicUsageToResponses(resp.Usage)
+	return out
+}
+
+// anthropicStopReasonToResponsesStatus maps stop_reason to a Responses status.
+func anthropicStopReasonToResponsesStatus(stopReason string) (string, *ResponsesIncompleteDetails) {
+	switch stopReason {
+	case "max_tokens", "model_context_window_exceeded":
+		return "incomplete", &ResponsesIncompleteDetails{Reason: "max_output_tokens"}
+	case "refusal":
+		return "incomplete", &ResponsesIncompleteDetails{Reason: "content_filter"}
+	default:
+		return "completed", nil
+	}
+}
+
+// anthropicUsageToResponses converts Anthropic usage. OpenAI input_tokens
+// include cached tokens, while Anthropic reports cache reads/writes separately.
+func anthropicUsageToResponses(u AnthropicUsage) *ResponsesUsage {
+	input := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	usage := &ResponsesUsage{
+		InputTokens:  input,
+		OutputTokens: u.OutputTokens,
+		TotalTokens:  input + u.OutputTokens,
+	}
+	if u.CacheReadInputTokens > 0 {
+		usage.InputTokensDetails = &ResponsesInputTokensDetails{CachedTokens: u.CacheReadInputTokens}
+	}
+	return usage
+}
+
+// toResponsesResponseID derives a Responses-style ID ("resp_…") from an
+// Anthropic message ID ("msg_…").
+func toResponsesResponseID(id string) string {
+	if strings.HasPrefix(id, "resp_") {
+		return id
+	}
+	if after, ok := strings.CutPrefix(id, "msg_"); ok {
+		return "resp_" + after
+	}
+	if id == "" {
+		return fmt.Sprintf("resp_%d", time.Now().UnixNano())
+	}
+	return "resp_" + id
+}
+
+// toWebSearchCallID reverses the "srvtoolu_" prefix used by
+// resToAnthHandleWebSearchDone.
+func toWebSearchCallID(id string) string {
+	if after, ok := strings.CutPrefix(id, "srvtoolu_"); ok && strings.HasPrefix(after, "ws_") {
+		return after
+	}
+	return "ws_" + id
+}
+
+func webSearchQueryFromInput(input json.RawMessage) string {
+	var in struct {
+		Query string `json:"query"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &in) != nil {
+		return ""
+	}
+	return in.Query
+}
+
+// ---------------------------------------------------------------------------
+// Streaming: AnthropicStreamEvent → []ResponsesStreamEvent (stateful converter)
+// ---------------------------------------------------------------------------
+
+// AnthropicEventToResponsesState tracks state for converting a sequence of
+// Anthropic SSE events into Responses SSE events.
+type AnthropicEventToResponsesState struct {
+	CreatedSent   bool
+	CompletedSent bool
+
+	ResponseID string
+	Model      string
+	Created    int64
+
+	// Output accumulates finished output items; it becomes response.output
+	// of the terminal event.
+	Output []ResponsesOutput
+
+	// BlockToOutput maps an Anthropic content block index to its output
+	// index; blocks without a Responses counterpart are absent.
+	BlockToOutput map[int]int
+
+	// current holds the in-progress item for each open block.
+	current map[int]*anthropicToResponsesBlock
+
+	StopReason string
+	Usage      AnthropicUsage
+	Failed     *ResponsesError
+
+	seq int
+}
+
+type anthropicToResponsesBlock struct {
+	blockType string // "text" | "thinking" | "tool_use" | "web_search"
+	item      ResponsesOutput
+	buf       strings.Builder
+}
+
+// NewAnthropicEventToResponsesState returns an initialised stream state.
+// model overrides the upstream model name in emitted events when non-empty.
+func NewAnthropicEventToResponsesState(model string) *AnthropicEventToResponsesState {
+	return &AnthropicEventToResponsesState{
+		Model:         model,
+		Created:       time.Now().Unix(),
+		BlockToOutput: make(map[int]int),
+		current:       make(map[int]*anthropicToResponsesBlock),
+	}
+}
+
+// AnthropicEventToResponsesEvents converts a single Anthropic SSE event into
+// zero or more Responses SSE events, updating state as it goes.
+func AnthropicEventToResponsesEvents(
+	evt *AnthropicStreamEvent,
+	state *AnthropicEventToResponsesState,
+) []ResponsesStreamEvent {
+	switch evt.Type {
+	case "message_start":
+		return anthToResHandleMessageStart(evt, state)
+	case "content_block_start":
+		return anthToResHandleBlockStart(evt, state)
+	case "content_block_delta":
+		return anthToResHandleBlockDelta(evt, state)
+	case "content_block_stop":
+		return anthToResHandleBlockStop(evt, state)
+	case "message_delta":
+		if evt.Delta != nil && evt.Delta.StopReason != "" {
+			state.StopReason = evt.Delta.StopReason
+		}
+		if evt.Usage != nil {
+			state.Usage.OutputTokens = evt.Usage.OutputTokens
+			if evt.Usage.InputTokens > 0 {
+				state.Usage.InputTokens = evt.Usage.InputTokens
+			}
+			if evt.Usage.CacheCreationInputTokens > 0 {
+				state.Usage.CacheCreationInputTokens = evt.Usage.CacheCreationInputTokens
+			}
+			if evt.Usage.CacheReadInputTokens > 0 {
+				state.Usage.CacheReadInputTokens = evt.Usage.CacheReadInputTokens
+			}
+		}
+		return nil
+	case "message_stop":
+		return anthToResHandleCompleted(state)
+	case "error":
+		state.Failed = &ResponsesError{Code: "server_error", Message: "upstream stream error"}
+		if evt.Error != nil {
+			state.Failed = &ResponsesError{Code: evt.Error.Type, Message: evt.Error.Message}
+		}
+		return anthToResHandleCompleted(state)
+	default:
+		return nil
+	}
+}
+
+// FinalizeAnthropicResponsesStream emits a terminal event if the upstream
+// stream ended without message_stop.
+func FinalizeAnthropicResponsesStream(state *AnthropicEventToResponsesState) []ResponsesStreamEvent {
+	if !state.CreatedSent || state.CompletedSent {
+		return nil
+	}
+	if state.StopReason == "" {
+		state.StopReason = "end_turn"
+	}
+	return anthToResHandleCompleted(state)
+}
+
+// FinalResponse returns the response object carried by the terminal event.
+func (state *AnthropicEventToResponsesState) FinalResponse() *ResponsesResponse {
+	resp := state.baseResponse()
+	resp.Output = append([]ResponsesOutput{}, state.Output...)
+	resp.Usage = anthropicUsageToResponses(state.Usage)
+	if state.Failed != nil {
+		resp.Status = "failed"
+		resp.Error = state.Failed
+		return resp
+	}
+	resp.Status, resp.IncompleteDetails = anthropicStopReasonToResponsesStatus(state.StopReason)
+	return resp
+}
+
+// ResponsesEventToSSE formats a ResponsesStreamEvent as an SSE line pair.
+func ResponsesEventToSSE(evt ResponsesStreamEvent) (string, error) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("event: %s\ndata: %s\n\n", evt.Type, data), nil
+}
+
+// --- internal handlers ---
+
+func (state *AnthropicEventToResponsesState) baseResponse() *ResponsesResponse {
+	return &ResponsesResponse{
+		ID:     state.ResponseID,
+		Object: "response",
+		Model:  state.Model,
+		Status: "in_progress",
+		Output: []ResponsesOutput{},
+	}
+}
+
+func (state *AnthropicEventToResponsesState) event(evt ResponsesStreamEvent) ResponsesStreamEvent {
+	state.seq++
+	evt.SequenceNumber = state.seq
+	return evt
+}
+
+func anthToResHandleMessageStart(evt *AnthropicStreamEvent, state *AnthropicEventToResponsesState) []ResponsesStreamEvent {
+	if evt.Message != nil {
+		state.ResponseID = toResponsesResponseID(evt.Message.ID)
+		if state.Model == "" {
+			state.Model = evt.Message.Model
+		}
+		state.Usage = evt.Message.Usage
+	}
+	if state.ResponseID == "" {
+		state.ResponseID = toResponsesResponseID("")
+	}
+	if state.CreatedSent {
+		return nil
+	}
+	state.CreatedSent = true
+	return []ResponsesStreamEvent{
+		state.event(ResponsesStreamEvent{Type: "response.created", Response: state.baseResponse()}),
+		state.event(ResponsesStreamEvent{Type: "response.in_progress", Response: state.baseResponse()}),
+	}
+}
+
+func anthToResHandleBlockStart(evt *AnthropicStreamEvent, state *AnthropicEventToResponsesState) []ResponsesStreamEvent {
+	if evt.Index == nil || evt.ContentBlock == nil {
+		return nil
+	}
+	idx := *evt.Index
+	cb := evt.ContentBlock
+	outputIndex := len(state.Output)
+
+	block := &anthropicToResponsesBlock{}
+	switch cb.Type {
+	case "text":
+		block.blockType = "text"
+		block.item = ResponsesOutput{
+			Type:    "message",
+			ID:      fmt.Sprintf("msg_%s_%d", state.ResponseID, idx),
+			Role:    "assistant",
+			Status:  "in_progress",
+			Content: []ResponsesContentPart{},
+		}
+	case "thinking":
+		block.blockType = "thinking"
+		block.item = ResponsesOutput{
+			Type:    "reasoning",
+			ID:      fmt.Sprintf("rs_%s_%d", state.ResponseID, idx),
+			Summary: []ResponsesSummary{},
+		}
+	case "tool_use":
+		block.blockType = "tool_use"
+		block.item = ResponsesOutput{
+			Type:   "function_call",
+			ID:     toResponsesCallID(cb.ID),
+			CallID: cb.ID,
+			Name:   cb.Name,
+			Status: "in_progress",
+		}
+	case "server_tool_use":
+		if cb.Name != "web_search" {
+			return nil
+		}
+		block.blockType = "web_search"
+		block.item = ResponsesOutput{
+			Type:   "web_search_call",
+			ID:     toWebSearchCallID(cb.ID),
+			Status: "in_progress",
+		}
+	default:
+		// web_search_tool_result, redacted_thinking, etc. have no Responses
+		// counterpart that clients can consume.
+		return nil
+	}
+
+	state.BlockToOutput[idx] = outputIndex
+	state.current[idx] = block
+	// Reserve the slot so later blocks get increasing output indexes.
+	state.Output = append(state.Output, block.item)
+
+	item := block.item
+	events := []ResponsesStreamEvent{state.event(ResponsesStreamEvent{
+		Type:        "response.output_item.added",
+		OutputIndex: outputIndex,
+		Item:        &item,
+	})}
+	switch block.blockType {
+	case "text":
+		events = append(events, state.event(ResponsesStreamEvent{
+			Type:         "response.content_part.added",
+			ItemID:       block.item.ID,
+			OutputIndex:  outputIndex,
+			ContentIndex: 0,
+			Part:         &ResponsesContentPart{Type: "output_text", Text: ""},
+		}))
+	case "thinking":
+		events = append(events, state.event(ResponsesStreamEvent{
+			Type:         "response.reasoning_summary_part.added",
+			ItemID:       block.item.ID,
+			OutputIndex:  outputIndex,
+			SummaryIndex: 0,
+			Part:         &ResponsesContentPart{Type: "summary_text", Text: ""},
+		}))
+	case "web_search":
+		events = append(events, state.event(ResponsesStreamEvent{
+			Type:        "response.web_search_call.in_progress",
+			ItemID:      block.item.ID,
+			OutputIndex: outputIndex,
+		}))
+	}
+	return events
+}
+
+func anthToResHandleBlockDelta(evt *AnthropicStreamEvent, state *AnthropicEventToResponsesState) []ResponsesStreamEvent {
+	if evt.Index == nil || evt.Delta == nil {
+		return nil
+	}
+	block, ok := state.current[*evt.Index]
+	if !ok {
+		return nil
+	}
+	outputIndex := state.BlockToOutput[*evt.Index]
+
+	switch evt.Delta.Type {
+	case "text_delta":
+		if evt.Delta.Text == "" {
+			return nil
+		}
+		block.buf.WriteString(evt.Delta.Text)
+		return []ResponsesStreamEvent{state.event(ResponsesStreamEvent{
+			Type:         "response.output_text.delta",
+			ItemID:       block.item.ID,
+			OutputIndex:  outputIndex,
+			ContentIndex: 0,
+			Delta:        evt.Delta.Text,
+		})}
+	case "thinking_delta":
+		if evt.Delta.Thinking == "" {
+			return nil
+		}
+		block.buf.WriteString(evt.Delta.Thinking)
+		return []ResponsesStreamEvent{state.event(ResponsesStreamEvent{
+			Type:         "response.reasoning_summary_text.delta",
+			ItemID:       block.item.ID,
+			OutputIndex:  outputIndex,
+			SummaryIndex: 0,
+			Delta:        evt.Delta.Thinking,
+		})}
+	case "signature_delta":
+		block.item.EncryptedContent += evt.Delta.Signature
+		return nil
+	case "input_json_delta":
+		if evt.Delta.PartialJSON == "" {
+			return nil
+		}
+		block.buf.WriteString(evt.Delta.PartialJSON)
+		if block.blockType != "tool_use" {
+			return nil
+		}
+		return []ResponsesStreamEvent{state.event(ResponsesStreamEvent{
+			Type:        "response.function_call_arguments.delta",
+			ItemID:      block.item.ID,
+			OutputIndex: outputIndex,
+			Delta:       evt.Delta.PartialJSON,
+		})}
+	}
+	return nil
+}
+
+func anthToResHandleBlockStop(evt *AnthropicStreamEvent, state *AnthropicEventToResponsesState) []ResponsesStreamEvent {
+	if evt.Index == nil {
+		return nil
+	}
+	block, ok := state.current[*evt.Index]
+	if !ok {
+		return nil
+	}
+	delete(state.current, *evt.Index)
+	return closeResponsesBlock(state, block, state.BlockToOutput[*evt.Index])
+}
+
+func closeResponsesBlock(state *AnthropicEventToResponsesState, block *anthropicToResponsesBlock, outputIndex int) []ResponsesStreamEvent {
+	text := block.buf.String()
+	var events []ResponsesStreamEvent
+
+	switch block.blockType {
+	case "text":
+		block.item.Status = "completed"
+		part := ResponsesContentPart{Type: "output_text", Text: text}
+		block.item.Content = []ResponsesContentPart{part}
+		events = append(events,
+			state.event(ResponsesStreamEvent{
+				Type:         "response.output_text.done",
+				ItemID:       block.item.ID,
+				OutputIndex:  outputIndex,
+				ContentIndex: 0,
+				Text:         text,
+			}),
+			state.event(ResponsesStreamEvent{
+				Type:         "response.content_part.done",
+				ItemID:       block.item.ID,
+				OutputIndex:  outputIndex,
+				ContentIndex: 0,
+				Part:         &part,
+			}),
+		)
+	case "thinking":
+		part := ResponsesContentPart{Type: "summary_text", Text: text}
+		block.item.Summary = []ResponsesSummary{{Type: "summary_text", Text: text}}
+		events = append(events,
+			state.event(ResponsesStreamEvent{
+				Type:         "response.reasoning_summary_text.done",
+				ItemID:       block.item.ID,
+				OutputIndex:  outputIndex,
+				SummaryIndex: 0,
+				Text:         text,
+			}),
+			state.event(ResponsesStreamEvent{
+				Type:         "response.reasoning_summary_part.done",
+				ItemID:       block.item.ID,
+				OutputIndex:  outputIndex,
+				SummaryIndex: 0,
+				Part:         &part,
+			}),
+		)
+	case "tool_use":
+		args := text
+		if strings.TrimSpace(args) == "" {
+			args = "{}"
+		}
+		block.item.Arguments = args
+		block.item.Status = "completed"
+		events = append(events, state.event(ResponsesStreamEvent{
+			Type:        "response.function_call_arguments.done",
+			ItemID:      block.item.ID,
+			OutputIndex: outputIndex,
+			Arguments:   args,
+		}))
+	case "web_search":
+		block.item.Status = "completed"
+		block.item.Action = &WebSearchAction{Type: "search", Query: webSearchQueryFromInput(json.RawMessage(text))}
+		events = append(events, state.event(ResponsesStreamEvent{
+			Type:        "response.web_search_call.completed",
+			ItemID:      block.item.ID,
+			OutputIndex: outputIndex,
+		}))
+	}
+
+	state.Output[outputIndex] = block.item
+	item := block.item
+	events = append(events, state.event(ResponsesStreamEvent{
+		Type:        "response.output_item.done",
+		OutputIndex: outputIndex,
+		Item:        &item,
+	}))
+	return events
+}
+
+func anthToResHandleCompleted(state *AnthropicEventToResponsesState) []ResponsesStreamEvent {
+	if state.CompletedSent {
+		return nil
+	}
+	var events []ResponsesStreamEvent
+	if !state.CreatedSent {
+		events = append(events, anthToResHandleMessageStart(&AnthropicStreamEvent{Type: "message_start"}, state)...)
+	}
+	// Close blocks left open by a truncated stream, in output order.
+	for outputIndex := range state.Output {
+		for idx, block := range state.current {
+			if state.BlockToOutput[idx] == outputIndex {
+				delete(state.current, idx)
+				events = append(events, closeResponsesBlock(state, block, outputIndex)...)
+			}
+		}
+	}
+
+	resp := state.FinalResponse()
+	eventType := "response.completed"
+	switch resp.Status {
+	case "incomplete":
+		eventType = "response.incomplete"
+	case "failed":
+		eventType = "response.failed"
+	}
+	events = append(events, state.event(ResponsesStreamEvent{Type: eventType, Response: resp}))
+	state.CompletedSent = true
+	return events
+}
@@ -0,0 +1,312 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// GeminiToAnthropicRequest tests
+// ---------------------------------------------------------------------------
+
+func parseGeminiRequest(t *testing.T, body string) *GeminiRequest {
+	t.Helper()
+	var req GeminiRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+	return &req
+}
+
+func TestGeminiToAnthropicRequest_TextAndSystem(t *testing.T) {
+	req := parseGeminiRequest(t, `{
+		"systemInstruction":{"parts":[{"text":"Be brief."}]},
+		"contents":[
+			{"role":"user","parts":[{"text":"Hi"}]},
+			{"role":"model","parts":[{"text":"Hello"}]},
+			{"role":"user","parts":[{"text":"How are you?"}]},
+			{"role":"user","parts":[{"text":"Answer please."}]}
+		],
+		"generationConfig":{"maxOutputTokens":512,"temperature":0.3,"stopSequences":["END"]}
+	}`)
+
+	out, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", true)
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-5", out.Model)
+	assert.True(t, out.Stream)
+	assert.Equal(t, 512, out.MaxTokens)
+	require.NotNil(t, out.Temperature)
+	assert.InDelta(t, 0.3, *out.Temperature, 1e-9)
+	assert.Equal(t, []string{"END"}, out.StopSeqs)
+	assert.JSONEq(t, `"Be brief."`, string(out.System))
+	require.Len(t, out.Messages, 3)
+	assert.Equal(t, "assistant", out.Messages[1].Role)
+	assert.JSONEq(t, `[{"type":"text","text":"How are you?"},{"type":"text","text":"Answer please."}]`, string(out.Messages[2].Content))
+}
+
+func TestGeminiToAnthropicRequest_FunctionCallRoundTrip(t *testing.T) {
+	req := parseGeminiRequest(t, `{
+		"contents":[
+			{"role":"user","parts":[{"text":"Weather?"}]},
+			{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"}}}]},
+			{"role":"user","parts":[{"text":"thanks"},{"functionResponse":{"name":"get_weather","response":{"output":"sunny"}}}]}
+		],
+		"tools":[{"functionDeclarations":[{"name":"get_weather","description":"Get weather","parameters":{"type":"OBJECT","properties":{"city":{"type":"STRING"}},"required":["city"]}}]},{"googleSearch":{}}],
+		"toolConfig":{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["get_weather"]}}
+	}`)
+
+	out, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+	require.NoError(t, err)
+	require.Len(t, out.Tools, 1)
+	assert.JSONEq(t, `{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`, string(out.Tools[0].InputSchema))
+	assert.JSONEq(t, `{"type":"tool","name":"get_weather"}`, string(out.ToolChoice))
+
+	require.Len(t, out.Messages, 3)
+	var assistant []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[1].Content, &assistant))
+	require.Len(t, assistant, 1)
+	assert.Equal(t, "tool_use", assistant[0].Type)
+	assert.Equal(t, "toolu_gemini_1", assistant[0].ID)
+
+	var user []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[2].Content, &user))
+	require.Len(t, user, 2)
+	assert.Equal(t, "tool_result", user[0].Type, "tool_result must lead the user turn")
+	assert.Equal(t, "toolu_gemini_1", user[0].ToolUseID)
+	assert.JSONEq(t, `"sunny"`, string(user[0].Content))
+	assert.Equal(t, "text", user[1].Type)
+}
+
+func TestGeminiToAnthropicRequest_FunctionResponseError(t *testing.T) {
+	req := parseGeminiRequest(t, `{"contents":[
+		{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"run","args":{}}}]},
+		{"role":"user","parts":[{"functionResponse":{"id":"call_1","name":"run","response":{"error":"boom"}}}]}
+	]}`)
+
+	out, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+	require.NoError(t, err)
+	var user []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[1].Content, &user))
+	assert.Equal(t, "call_1", user[0].ToolUseID)
+	assert.True(t, user[0].IsError)
+}
+
+func TestGeminiToAnthropicRequest_UnmatchedFunctionResponse(t *testing.T) {
+	req := parseGeminiRequest(t, `{"contents":[
+		{"role":"user","parts":[{"functionResponse":{"name":"run","response":{"output":"x"}}}]}
+	]}`)
+
+	_, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+	require.Error(t, err)
+}
+
+func TestGeminiToAnthropicRequest_InlineImageAndFileData(t *testing.T) {
+	req := parseGeminiRequest(t, `{"contents":[{"role":"user","parts":[
+		{"inlineData":{"mimeType":"image/png","data":"iVBORw0KGgo="}},
+		{"inlineData":{"mimeType":"application/pdf","data":"JVBERi0="}},
+		{"fileData":{"mimeType":"image/jpeg","fileUri":"https://example.com/cat.jpg"}},
+		{"text":"Describe"}
+	]}]}`)
+
+	out, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}},
+		{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERi0="}},
+		{"type":"image","source":{"type":"url","url":"https://example.com/cat.jpg"}},
+		{"type":"text","text":"Describe"}
+	]`, string(out.Messages[0].Content))
+}
+
+func TestGeminiToAnthropicRequest_UnsupportedFileURI(t *testing.T) {
+	req := parseGeminiRequest(t, `{"contents":[{"role":"user","parts":[
+		{"fileData":{"mimeType":"image/png","fileUri":"gs://bucket/cat.png"}}
+	]}]}`)
+
+	_, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+	require.Error(t, err)
+}
+
+func TestGeminiToAnthropicRequest_ThinkingConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		budget    int
+		maxTokens int
+	}{
+		{"explicit budget", `{"thinkingBudget":4096}`, 4096, 4096 + 1000},
+		{"small budget clamps", `{"thinkingBudget":100}`, 1024, 1024 + 1000},
+		{"dynamic budget", `{"thinkingBudget":-1}`, 8192, 8192 + 1000},
+		{"level", `{"thinkingLevel":"HIGH"}`, 16384, 16384 + 1000},
+		{"disabled", `{"thinkingBudget":0}`, 0, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := parseGeminiRequest(t, `{"contents":[{"role":"user","parts":[{"text":"Hi"}]}],
+				"generationConfig":{"maxOutputTokens":1000,"temperature":0.5,"thinkingConfig":`+tt.config+`}}`)
+			out, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+			require.NoError(t, err)
+			if tt.budget == 0 {
+				assert.Nil(t, out.Thinking)
+				assert.NotNil(t, out.Temperature)
+			} else {
+				require.NotNil(t, out.Thinking)
+				assert.Equal(t, tt.budget, out.Thinking.BudgetTokens)
+				assert.Nil(t, out.Temperature)
+			}
+			assert.Equal(t, tt.maxTokens, out.MaxTokens)
+		})
+	}
+}
+
+func TestGeminiToAnthropicRequest_ThoughtSignatureRestoresThinking(t *testing.T) {
+	sig := encodeAnthropicThinkingSignature("let me think", "sig_abc")
+	req := parseGeminiRequest(t, `{"contents":[
+		{"role":"user","parts":[{"text":"Run it"}]},
+		{"role":"model","parts":[
+			{"text":"let me think","thought":true},
+			{"functionCall":{"id":"toolu_1","name":"run","args":{}},"thoughtSignature":"`+sig+`"}
+		]},
+		{"role":"user","parts":[{"functionResponse":{"id":"toolu_1","name":"run","response":{"output":"ok"}}}]}
+	],"generationConfig":{"thinkingConfig":{"thinkingBudget":2048}}}`)
+
+	out, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+	require.NoError(t, err)
+	require.NotNil(t, out.Thinking, "signed history keeps thinking enabled")
+	assert.JSONEq(t, `[
+		{"type":"thinking","thinking":"let me think","signature":"sig_abc"},
+		{"type":"tool_use","id":"toolu_1","name":"run","input":{}}
+	]`, string(out.Messages[1].Content))
+}
+
+func TestGeminiToAnthropicRequest_UnsignedToolTurnDisablesThinking(t *testing.T) {
+	req := parseGeminiRequest(t, `{"contents":[
+		{"role":"user","parts":[{"text":"Run it"}]},
+		{"role":"model","parts":[{"functionCall":{"name":"run","args":{}}}]},
+		{"role":"user","parts":[{"functionResponse":{"name":"run","response":{"output":"ok"}}}]}
+	],"generationConfig":{"maxOutputTokens":1000,"thinkingConfig":{"thinkingBudget":2048}}}`)
+
+	out, err := GeminiToAnthropicRequest(req, "claude-sonnet-4-5", false)
+	require.NoError(t, err)
+	assert.Nil(t, out.Thinking)
+	assert.Equal(t, 1000, out.MaxTokens)
+}
+
+// ---------------------------------------------------------------------------
+// AnthropicToGeminiResponse tests
+// ---------------------------------------------------------------------------
+
+func TestAnthropicToGeminiResponse_Basic(t *testing.T) {
+	resp := &AnthropicResponse{
+		ID:    "msg_1",
+		Model: "claude-sonnet-4-5",
+		Content: []AnthropicContentBlock{
+			{Type: "thinking", Thinking: "hmm", Signature: "sig"},
+			{Type: "text", Text: "Calling tool"},
+			{Type: "tool_use", ID: "toolu_1", Name: "run", Input: json.RawMessage(`{"a":1}`)},
+		},
+		StopReason: "tool_use",
+		Usage:      AnthropicUsage{InputTokens: 10, OutputTokens: 5, CacheReadInputTokens: 20, CacheCreationInputTokens: 3},
+	}
+
+	out := AnthropicToGeminiResponse(resp, "", true)
+	require.Len(t, out.Candidates, 1)
+	cand := out.Candidates[0]
+	assert.Equal(t, "STOP", cand.FinishReason)
+	require.Len(t, cand.Content.Parts, 3)
+	assert.True(t, cand.Content.Parts[0].Thought)
+	assert.Equal(t, "hmm", cand.Content.Parts[0].Text)
+	assert.Equal(t, "Calling tool", cand.Content.Parts[1].Text)
+	assert.Equal(t, encodeAnthropicThinkingSignature("hmm", "sig"), cand.Content.Parts[1].ThoughtSignature)
+	assert.Equal(t, "run", cand.Content.Parts[2].FunctionCall.Name)
+	assert.Equal(t, "toolu_1", cand.Content.Parts[2].FunctionCall.ID)
+	assert.Equal(t, "claude-sonnet-4-5", out.ModelVersion)
+	assert.Equal(t, &GeminiUsageMetadata{PromptTokenCount: 33, CandidatesTokenCount: 5, CachedContentTokenCount: 20, TotalTokenCount: 38}, out.UsageMetadata)
+}
+
+func TestAnthropicToGeminiResponse_HidesThoughtsAndMapsMaxTokens(t *testing.T) {
+	resp := &AnthropicResponse{
+		Content:    []AnthropicContentBlock{{Type: "thinking", Thinking: "hmm", Signature: "sig"}},
+		StopReason: "max_tokens",
+	}
+
+	out := AnthropicToGeminiResponse(resp, "alias", false)
+	cand := out.Candidates[0]
+	assert.Equal(t, "MAX_TOKENS", cand.FinishReason)
+	require.Len(t, cand.Content.Parts, 1)
+	assert.False(t, cand.Content.Parts[0].Thought)
+	assert.Empty(t, cand.Content.Parts[0].Text)
+	assert.NotEmpty(t, cand.Content.Parts[0].ThoughtSignature)
+	assert.Equal(t, "alias", out.ModelVersion)
+}
+
+// ---------------------------------------------------------------------------
+// AnthropicEventToGeminiChunks tests
+// ---------------------------------------------------------------------------
+
+func TestAnthropicEventToGeminiChunks_Stream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":7}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"plan"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"run","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"a\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"1}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":4}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	state := NewAnthropicEventToGeminiState("", false)
+	var chunks []GeminiResponse
+	for _, raw := range events {
+		var evt AnthropicStreamEvent
+		require.NoError(t, json.Unmarshal([]byte(raw), &evt))
+		chunks = append(chunks, AnthropicEventToGeminiChunks(&evt, state)...)
+	}
+	assert.Empty(t, FinalizeAnthropicGeminiStream(state))
+
+	require.Len(t, chunks, 3)
+	text := chunks[0].Candidates[0].Content.Parts[0]
+	assert.Equal(t, "Hi", text.Text)
+	assert.Equal(t, encodeAnthropicThinkingSignature("plan", "sig"), text.ThoughtSignature)
+	assert.Equal(t, "msg_1", chunks[0].ResponseID)
+	assert.Equal(t, "claude-sonnet-4-5", chunks[0].ModelVersion)
+
+	call := chunks[1].Candidates[0].Content.Parts[0].FunctionCall
+	require.NotNil(t, call)
+	assert.JSONEq(t, `{"a":1}`, string(call.Args))
+
+	final := chunks[2]
+	assert.Equal(t, "STOP", final.Candidates[0].FinishReason)
+	require.NotNil(t, final.UsageMetadata)
+	assert.Equal(t, 7, final.UsageMetadata.PromptTokenCount)
+	assert.Equal(t, 4, final.UsageMetadata.CandidatesTokenCount)
+}
+
+func TestAnthropicEventToGeminiChunks_ErrorAndFinalize(t *testing.T) {
+	state := NewAnthropicEventToGeminiState("gemini-alias", true)
+	AnthropicEventToGeminiChunks(&AnthropicStreamEvent{Type: "message_start", Message: &AnthropicResponse{ID: "msg_1"}}, state)
+	idx := 0
+	AnthropicEventToGeminiChunks(&AnthropicStreamEvent{Type: "content_block_start", Index: &idx, ContentBlock: &AnthropicContentBlock{Type: "thinking"}}, state)
+	chunks := AnthropicEventToGeminiChunks(&AnthropicStreamEvent{Type: "content_block_delta", Index: &idx, Delta: &AnthropicDelta{Type: "thinking_delta", Thinking: "x"}}, state)
+	require.Len(t, chunks, 1)
+	assert.True(t, chunks[0].Candidates[0].Content.Parts[0].Thought)
+
+	final := FinalizeAnthropicGeminiStream(state)
+	require.Len(t, final, 1)
+	assert.Equal(t, "STOP", final[0].Candidates[0].FinishReason)
+
+	failed := NewAnthropicEventToGeminiState("", false)
+	AnthropicEventToGeminiChunks(&AnthropicStreamEvent{Type: "message_start"}, failed)
+	assert.Empty(t, AnthropicEventToGeminiChunks(&AnthropicStreamEvent{Type: "error", Error: &AnthropicError{Type: "overloaded_error", Message: "busy"}}, failed))
+	require.NotNil(t, failed.Failed)
+	assert.Equal(t, "overloaded_error", failed.Failed.Type)
+	assert.Empty(t, FinalizeAnthropicGeminiStream(failed))
+}
@@ -0,0 +1,396 @@
+package apicompat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// anthropicThinkingSignaturePrefix marks a Gemini thoughtSignature that
+// carries a complete Anthropic thinking block. Anthropic only accepts a
+// thinking block back when both its text and signature are unchanged, so the
+// whole block travels through the Gemini client as the opaque signature.
+const anthropicThinkingSignaturePrefix = "anthropic-thinking:"
+
+var anthropicToolIDSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// GeminiToAnthropicRequest converts a Gemini generateContent request into an
+// Anthropic Messages request. model comes from the request URL
+// (/v1beta/models/{model}:generateContent) and stream from the action.
+func GeminiToAnthropicRequest(req *GeminiRequest, model string, stream bool) (*AnthropicRequest, error) {
+	messages, err := convertGeminiContentsToAnthropic(req.Contents)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &AnthropicRequest{
+		Model:     model,
+		Messages:  messages,
+		Stream:    stream,
+		MaxTokens: defaultAnthropicMaxTokens,
+	}
+
+	if req.SystemInstruction != nil {
+		texts := make([]string, 0, len(req.SystemInstruction.Parts))
+		for _, p := range req.SystemInstruction.Parts {
+			if strings.TrimSpace(p.Text) != "" {
+				texts = append(texts, p.Text)
+			}
+		}
+		if len(texts) > 0 {
+			system, err := json.Marshal(strings.Join(texts, "\n"))
+			if err != nil {
+				return nil, err
+			}
+			out.System = system
+		}
+	}
+
+	cfg := req.GenerationConfig
+	if cfg != nil {
+		if cfg.MaxOutputTokens > 0 {
+			out.MaxTokens = cfg.MaxOutputTokens
+		}
+		out.Temperature = cfg.Temperature
+		out.TopP = cfg.TopP
+		out.StopSeqs = cfg.StopSequences
+	}
+
+	tools, err := convertGeminiToolsToAnthropic(req.Tools)
+	if err != nil {
+		return nil, err
+	}
+	out.Tools = tools
+	if len(out.Tools) > 0 && req.ToolConfig != nil && req.ToolConfig.FunctionCallingConfig != nil {
+		tc, err := convertGeminiToolConfigToAnthropic(req.ToolConfig.FunctionCallingConfig)
+		if err != nil {
+			return nil, fmt.Errorf("convert toolConfig: %w", err)
+		}
+		out.ToolChoice = tc
+	}
+
+	if cfg != nil && cfg.ThinkingConfig != nil {
+		if budget := mapGeminiThinkingConfigToBudget(cfg.ThinkingConfig); budget > 0 {
+			out.Thinking = &AnthropicThinking{Type: "enabled", BudgetTokens: budget}
+			// max_tokens must exceed the thinking budget; treat the client's
+			// limit as the visible-output budget on top of thinking.
+			if out.MaxTokens <= budget {
+				out.MaxTokens += budget
+			}
+			// Extended thinking rejects custom sampling parameters.
+			out.Temperature = nil
+			out.TopP = nil
+		}
+	}
+	if out.Thinking != nil && !assistantToolTurnHasThinking(messages) {
+		// History replayed without our thought signatures cannot continue a
+		// tool loop with thinking enabled; fall back to no thinking.
+		out.Thinking = nil
+		out.MaxTokens = defaultAnthropicMaxTokens
+		if cfg.MaxOutputTokens > 0 {
+			out.MaxTokens = cfg.MaxOutputTokens
+		}
+		out.Temperature = cfg.Temperature
+		out.TopP = cfg.TopP
+	}
+
+	return out, nil
+}
+
+// mapGeminiThinkingConfigToBudget converts a Gemini thinkingConfig to an
+// Anthropic extended-thinking budget. 0 disables thinking.
+//
+//	thinkingBudget 0    → 0
+//	thinkingBudget -1   → 8192 (dynamic)
+//	thinkingBudget N    → max(N, 1024)
+//	thinkingLevel       → same budgets as Responses reasoning effort
+func mapGeminiThinkingConfigToBudget(cfg *GeminiThinkingConfig) int {
+	if cfg.ThinkingBudget != nil {
+		switch budget := *cfg.ThinkingBudget; {
+		case budget == 0:
+			return 0
+		case budget < 0:
+			return mapResponsesEffortToThinkingBudget("medium")
+		case budget < 1024:
+			return 1024
+		default:
+			return budget
+		}
+	}
+	return mapResponsesEffortToThinkingBudget(strings.ToLower(cfg.ThinkingLevel))
+}
+
+// convertGeminiContentsToAnthropic maps Gemini turns to Anthropic messages.
+// Function calls without an id get a generated one; function responses
+// without an id are matched to the oldest unanswered call of the same name.
+func convertGeminiContentsToAnthropic(contents []GeminiContent) ([]AnthropicMessage, error) {
+	type turn struct {
+		role   string
+		blocks []AnthropicContentBlock
+	}
+	turns := make([]turn, 0, len(contents))
+	pendingCalls := make(map[string][]string)
+	generated := 0
+
+	for _, content := range contents {
+		role := "user"
+		if content.Role == "model" {
+			role = "assistant"
+		}
+
+		toolResults := make([]AnthropicContentBlock, 0)
+		blocks := make([]AnthropicContentBlock, 0, len(content.Parts))
+		for _, part := range content.Parts {
+			if thinking, ok := decodeAnthropicThinkingSignature(part.ThoughtSignature); ok && role == "assistant" {
+				blocks = append(blocks, thinking)
+			}
+			switch {
+			case part.Thought:
+				// Thought text is display-only; the signed block (if any) was
+				// restored from thoughtSignature above.
+			case part.FunctionCall != nil:
+				id := sanitizeAnthropicToolID(part.FunctionCall.ID)
+				if id == "" {
+					generated++
+					id = fmt.Sprintf("toolu_gemini_%d", generated)
+				}
+				pendingCalls[part.FunctionCall.Name] = append(pendingCalls[part.FunctionCall.Name], id)
+				input := part.FunctionCall.Args
+				if len(input) == 0 || string(input) == "null" {
+					input = json.RawMessage(`{}`)
+				}
+				blocks = append(blocks, AnthropicContentBlock{Type: "tool_use", ID: id, Name: part.FunctionCall.Name, Input: input})
+			case part.FunctionResponse != nil:
+				fr := part.FunctionResponse
+				id := sanitizeAnthropicToolID(fr.ID)
+				queue := pendingCalls[fr.Name]
+				if id == "" && len(queue) > 0 {
+					id = queue[0]
+				}
+				for i, pending := range queue {
+					if pending == id {
+						pendingCalls[fr.Name] = append(queue[:i:i], queue[i+1:]...)
+						break
+					}
+				}
+				if id == "" {
+					return nil, fmt.Errorf("functionResponse %q has no matching functionCall", fr.Name)
+				}
+				text, isError := geminiFunctionResponseText(fr.Response)
+				resultContent, err := json.Marshal(text)
+				if err != nil {
+					return nil, err
+				}
+				toolResults = append(toolResults, AnthropicContentBlock{Type: "tool_result", ToolUseID: id, Content: resultContent, IsError: isError})
+			case part.InlineData != nil:
+				block, err := geminiInlineDataToAnthropic(part.InlineData)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, block)
+			case part.FileData != nil:
+				block, err := geminiFileDataToAnthropic(part.FileData)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, block)
+			case part.Text != "":
+				blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: part.Text})
+			}
+		}
+		// Anthropic requires tool_result blocks to lead the user turn.
+		blocks = append(toolResults, blocks...)
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(turns); n > 0 && turns[n-1].role == role {
+			turns[n-1].blocks = append(turns[n-1].blocks, blocks...)
+			continue
+		}
+		turns = append(turns, turn{role: role, blocks: blocks})
+	}
+
+	messages := make([]AnthropicMessage, 0, len(turns))
+	for _, t := range turns {
+		content, err := json.Marshal(t.blocks)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, AnthropicMessage{Role: t.role, Content: content})
+	}
+	return messages, nil
+}
+
+// geminiFunctionResponseText flattens a functionResponse.response object into
+// tool_result text. {"output": "..."} / {"content": "..."} unwrap to the
+// string; a lone {"error": ...} marks the result as an error.
+func geminiFunctionResponseText(raw json.RawMessage) (string, bool) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return string(raw), false
+	}
+	if len(obj) == 1 {
+		for _, key := range []string{"output", "content", "result", "error"} {
+			value, ok := obj[key]
+			if !ok {
+				continue
+			}
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				s = string(value)
+			}
+			return s, key == "error"
+		}
+	}
+	return string(raw), false
+}
+
+func geminiInlineDataToAnthropic(blob *GeminiBlob) (AnthropicContentBlock, error) {
+	mimeType := strings.ToLower(strings.TrimSpace(blob.MimeType))
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return AnthropicContentBlock{Type: "image", Source: &AnthropicImageSource{Type: "base64", MediaType: mimeType, Data: blob.Data}}, nil
+	case mimeType == "application/pdf":
+		return AnthropicContentBlock{Type: "document", Source: &AnthropicImageSource{Type: "base64", MediaType: mimeType, Data: blob.Data}}, nil
+	case strings.HasPrefix(mimeType, "text/"):
+		decoded, err := base64.StdEncoding.DecodeString(blob.Data)
+		if err != nil {
+			return AnthropicContentBlock{}, fmt.Errorf("decode inlineData: %w", err)
+		}
+		return AnthropicContentBlock{Type: "text", Text: string(decoded)}, nil
+	default:
+		return AnthropicContentBlock{}, fmt.Errorf("unsupported inlineData mimeType %q", blob.MimeType)
+	}
+}
+
+func geminiFileDataToAnthropic(file *GeminiFileData) (AnthropicContentBlock, error) {
+	uri := strings.TrimSpace(file.FileURI)
+	if !strings.HasPrefix(uri, "https://") && !strings.HasPrefix(uri, "http://") {
+		return AnthropicContentBlock{}, fmt.Errorf("unsupported fileData uri %q: only http(s) URLs are supported", uri)
+	}
+	mimeType := strings.ToLower(strings.TrimSpace(file.MimeType))
+	if mimeType == "application/pdf" {
+		return AnthropicContentBlock{Type: "document", Source: &AnthropicImageSource{Type: "url", URL: uri}}, nil
+	}
+	if mimeType == "" || strings.HasPrefix(mimeType, "image/") {
+		return AnthropicContentBlock{Type: "image", Source: &AnthropicImageSource{Type: "url", URL: uri}}, nil
+	}
+	return AnthropicContentBlock{}, fmt.Errorf("unsupported fileData mimeType %q", file.MimeType)
+}
+
+// convertGeminiToolsToAnthropic converts function declarations. Built-in
+// Gemini tools (googleSearch, codeExecution, ...) have no Anthropic
+// equivalent and are ignored.
+func convertGeminiToolsToAnthropic(tools []GeminiTool) ([]AnthropicTool, error) {
+	var out []AnthropicTool
+	for _, tool := range tools {
+		for _, fd := range tool.FunctionDeclarations {
+			schema := fd.ParametersJSONSchema
+			if len(schema) == 0 && len(fd.Parameters) > 0 {
+				normalized, err := normalizeGeminiSchema(fd.Parameters)
+				if err != nil {
+					return nil, fmt.Errorf("function %s parameters: %w", fd.Name, err)
+				}
+				schema = normalized
+			}
+			if len(schema) == 0 || string(schema) == "null" {
+				schema = json.RawMessage(`{"type":"object","properties":{}}`)
+			}
+			out = append(out, AnthropicTool{Name: fd.Name, Description: fd.Description, InputSchema: schema})
+		}
+	}
+	return out, nil
+}
+
+// normalizeGeminiSchema lowercases the OpenAPI-style type names Gemini uses
+// ("OBJECT", "STRING", ...) so the schema is valid JSON Schema.
+func normalizeGeminiSchema(raw json.RawMessage) (json.RawMessage, error) {
+	var schema any
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+	return json.Marshal(lowercaseSchemaTypes(schema))
+}
+
+func lowercaseSchemaTypes(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		for key, value := range node {
+			if key == "type" {
+				switch t := value.(type) {
+				case string:
+					node[key] = strings.ToLower(t)
+					continue
+				case []any:
+					for i, item := range t {
+						if s, ok := item.(string); ok {
+							t[i] = strings.ToLower(s)
+						}
+					}
+					continue
+				}
+			}
+			node[key] = lowercaseSchemaTypes(value)
+		}
+		return node
+	case []any:
+		for i, item := range node {
+			node[i] = lowercaseSchemaTypes(item)
+		}
+		return node
+	default:
+		return v
+	}
+}
+
+// convertGeminiToolConfigToAnthropic maps functionCallingConfig.mode:
+//
+//	AUTO / VALIDATED           → {"type":"auto"}
+//	ANY                        → {"type":"any"}
+//	ANY + one allowed function → {"type":"tool","name":...}
+//	NONE                       → {"type":"none"}
+func convertGeminiToolConfigToAnthropic(cfg *GeminiFunctionCallingConfig) (json.RawMessage, error) {
+	switch strings.ToUpper(cfg.Mode) {
+	case "ANY":
+		if len(cfg.AllowedFunctionNames) == 1 {
+			return json.Marshal(map[string]string{"type": "tool", "name": cfg.AllowedFunctionNames[0]})
+		}
+		return json.Marshal(map[string]string{"type": "any"})
+	case "NONE":
+		return json.Marshal(map[string]string{"type": "none"})
+	default:
+		return json.Marshal(map[string]string{"type": "auto"})
+	}
+}
+
+func sanitizeAnthropicToolID(id string) string {
+	return anthropicToolIDSanitizer.ReplaceAllString(strings.TrimSpace(id), "_")
+}
+
+// encodeAnthropicThinkingSignature packs a signed thinking block into a
+// Gemini thoughtSignature.
+func encodeAnthropicThinkingSignature(thinking, signature string) string {
+	payload, err := json.Marshal(AnthropicContentBlock{Type: "thinking", Thinking: thinking, Signature: signature})
+	if err != nil {
+		return ""
+	}
+	return anthropicThinkingSignaturePrefix + base64.StdEncoding.EncodeToString(payload)
+}
+
+func decodeAnthropicThinkingSignature(sig string) (AnthropicContentBlock, bool) {
+	encoded, ok := strings.CutPrefix(sig, anthropicThinkingSignaturePrefix)
+	if !ok {
+		return AnthropicContentBlock{}, false
+	}
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return AnthropicContentBlock{}, false
+	}
+	var block AnthropicContentBlock
+	if err := json.Unmarshal(payload, &block); err != nil || block.Type != "thinking" || block.Signature == "" {
+		return AnthropicContentBlock{}, false
+	}
+	return block, true
+}
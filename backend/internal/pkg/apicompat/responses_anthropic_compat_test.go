@@ -0,0 +1,547 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// ResponsesToAnthropicRequest tests
+// ---------------------------------------------------------------------------
+
+func TestResponsesToAnthropicRequest_StringInput(t *testing.T) {
+	req := &ResponsesRequest{
+		Model:        "claude-sonnet-4-5",
+		Input:        json.RawMessage(`"Hello"`),
+		Instructions: "You are a coding agent.",
+		Stream:       true,
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-5", out.Model)
+	assert.True(t, out.Stream)
+	assert.Equal(t, defaultAnthropicMaxTokens, out.MaxTokens)
+	assert.JSONEq(t, `"You are a coding agent."`, string(out.System))
+	require.Len(t, out.Messages, 1)
+	assert.Equal(t, "user", out.Messages[0].Role)
+	assert.JSONEq(t, `[{"type":"text","text":"Hello"}]`, string(out.Messages[0].Content))
+}
+
+func TestResponsesToAnthropicRequest_SystemAndDeveloperMessages(t *testing.T) {
+	req := &ResponsesRequest{
+		Model:        "claude-sonnet-4-5",
+		Instructions: "Base instructions.",
+		Input: json.RawMessage(`[
+			{"role":"developer","content":[{"type":"input_text","text":"Developer note."}]},
+			{"role":"user","content":"Hi"}
+		]`),
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"Base instructions.\n\nDeveloper note."`, string(out.System))
+	require.Len(t, out.Messages, 1)
+	assert.Equal(t, "user", out.Messages[0].Role)
+}
+
+func TestResponsesToAnthropicRequest_FunctionCallLoop(t *testing.T) {
+	req := &ResponsesRequest{
+		Model: "claude-sonnet-4-5",
+		Input: json.RawMessage(`[
+			{"role":"user","content":"List files"},
+			{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Running ls."}]},
+			{"type":"function_call","call_id":"fc_toolu_01","name":"shell","arguments":"{\"cmd\":\"ls\"}"},
+			{"type":"function_call_output","call_id":"fc_toolu_01","output":"a.go\nb.go"}
+		]`),
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+	require.Len(t, out.Messages, 3)
+
+	var assistant []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[1].Content, &assistant))
+	assert.Equal(t, "assistant", out.Messages[1].Role)
+	require.Len(t, assistant, 2)
+	assert.Equal(t, "text", assistant[0].Type)
+	assert.Equal(t, "tool_use", assistant[1].Type)
+	assert.Equal(t, "toolu_01", assistant[1].ID)
+	assert.Equal(t, "shell", assistant[1].Name)
+	assert.JSONEq(t, `{"cmd":"ls"}`, string(assistant[1].Input))
+
+	var user []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[2].Content, &user))
+	assert.Equal(t, "user", out.Messages[2].Role)
+	require.Len(t, user, 1)
+	assert.Equal(t, "tool_result", user[0].Type)
+	assert.Equal(t, "toolu_01", user[0].ToolUseID)
+}
+
+func TestResponsesToAnthropicRequest_SanitizesCallID(t *testing.T) {
+	req := &ResponsesRequest{
+		Model: "claude-sonnet-4-5",
+		Input: json.RawMessage(`[
+			{"role":"user","content":"go"},
+			{"type":"function_call","call_id":"call.abc:1","name":"f","arguments":"not json"},
+			{"type":"function_call_output","call_id":"call.abc:1","output":""}
+		]`),
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+	require.Len(t, out.Messages, 3)
+
+	var assistant []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[1].Content, &assistant))
+	assert.Equal(t, "call_abc_1", assistant[0].ID)
+	assert.JSONEq(t, `{}`, string(assistant[0].Input))
+
+	var user []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[2].Content, &user))
+	assert.Equal(t, "call_abc_1", user[0].ToolUseID)
+	assert.JSONEq(t, `"(empty)"`, string(user[0].Content))
+}
+
+func TestResponsesToAnthropicRequest_ReasoningReplay(t *testing.T) {
+	req := &ResponsesRequest{
+		Model:     "claude-sonnet-4-5",
+		Reasoning: &ResponsesReasoning{Effort: "medium"},
+		Input: json.RawMessage(`[
+			{"role":"user","content":"Fix the bug"},
+			{"type":"reasoning","id":"rs_1","encrypted_content":"sig-abc","summary":[{"type":"summary_text","text":"Thinking..."}]},
+			{"type":"function_call","call_id":"fc_toolu_02","name":"read","arguments":"{}"},
+			{"type":"function_call_output","call_id":"fc_toolu_02","output":"contents"}
+		]`),
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+	require.NotNil(t, out.Thinking)
+	assert.Equal(t, "enabled", out.Thinking.Type)
+	assert.Equal(t, 8192, out.Thinking.BudgetTokens)
+	assert.Greater(t, out.MaxTokens, out.Thinking.BudgetTokens)
+
+	var assistant []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[1].Content, &assistant))
+	require.Len(t, assistant, 2)
+	assert.Equal(t, "thinking", assistant[0].Type)
+	assert.Equal(t, "Thinking...", assistant[0].Thinking)
+	assert.Equal(t, "sig-abc", assistant[0].Signature)
+	assert.Equal(t, "tool_use", assistant[1].Type)
+}
+
+func TestResponsesToAnthropicRequest_ThinkingFallbackWithoutSignature(t *testing.T) {
+	temp := 0.5
+	maxOut := 4096
+	req := &ResponsesRequest{
+		Model:           "claude-sonnet-4-5",
+		Reasoning:       &ResponsesReasoning{Effort: "high"},
+		Temperature:     &temp,
+		MaxOutputTokens: &maxOut,
+		Input: json.RawMessage(`[
+			{"role":"user","content":"Fix the bug"},
+			{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"from another provider"}]},
+			{"type":"function_call","call_id":"fc_toolu_03","name":"read","arguments":"{}"},
+			{"type":"function_call_output","call_id":"fc_toolu_03","output":"contents"}
+		]`),
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+	assert.Nil(t, out.Thinking)
+	assert.Equal(t, 4096, out.MaxTokens)
+	require.NotNil(t, out.Temperature)
+	assert.Equal(t, 0.5, *out.Temperature)
+}
+
+func TestResponsesToAnthropicRequest_EffortBudgets(t *testing.T) {
+	tests := []struct {
+		effort string
+		budget int
+	}{
+		{"minimal", 0},
+		{"low", 2048},
+		{"medium", 8192},
+		{"high", 16384},
+		{"xhigh", 32000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.effort, func(t *testing.T) {
+			temp := 0.7
+			req := &ResponsesRequest{
+				Model:       "claude-sonnet-4-5",
+				Input:       json.RawMessage(`"Hi"`),
+				Reasoning:   &ResponsesReasoning{Effort: tt.effort},
+				Temperature: &temp,
+			}
+			out, err := ResponsesToAnthropicRequest(req)
+			require.NoError(t, err)
+			if tt.budget == 0 {
+				assert.Nil(t, out.Thinking)
+				assert.NotNil(t, out.Temperature)
+				return
+			}
+			require.NotNil(t, out.Thinking)
+			assert.Equal(t, tt.budget, out.Thinking.BudgetTokens)
+			assert.Greater(t, out.MaxTokens, tt.budget)
+			assert.Nil(t, out.Temperature)
+		})
+	}
+}
+
+func TestResponsesToAnthropicRequest_Tools(t *testing.T) {
+	req := &ResponsesRequest{
+		Model: "claude-sonnet-4-5",
+		Input: json.RawMessage(`"Search"`),
+		Tools: []ResponsesTool{
+			{Type: "function", Name: "shell", Description: "Run a command", Parameters: json.RawMessage(`{"type":"object","properties":{"cmd":{"type":"string"}}}`)},
+			{Type: "function", Name: "noop"},
+			{Type: "web_search_preview"},
+			{Type: "local_shell"},
+		},
+		ToolChoice: json.RawMessage(`{"type":"function","name":"shell"}`),
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+	require.Len(t, out.Tools, 3)
+	assert.Equal(t, "shell", out.Tools[0].Name)
+	assert.Equal(t, "Run a command", out.Tools[0].Description)
+	assert.JSONEq(t, `{"type":"object","properties":{}}`, string(out.Tools[1].InputSchema))
+	assert.Equal(t, "web_search_20250305", out.Tools[2].Type)
+	assert.Equal(t, "web_search", out.Tools[2].Name)
+	assert.JSONEq(t, `{"type":"tool","name":"shell"}`, string(out.ToolChoice))
+}
+
+func TestResponsesToAnthropicRequest_ToolChoiceStrings(t *testing.T) {
+	tests := map[string]string{
+		`"auto"`:     `{"type":"auto"}`,
+		`"required"`: `{"type":"any"}`,
+		`"none"`:     `{"type":"none"}`,
+	}
+	for in, want := range tests {
+		got, err := convertResponsesToolChoiceToAnthropic(json.RawMessage(in))
+		require.NoError(t, err)
+		assert.JSONEq(t, want, string(got), in)
+	}
+}
+
+func TestResponsesToAnthropicRequest_Images(t *testing.T) {
+	req := &ResponsesRequest{
+		Model: "claude-sonnet-4-5",
+		Input: json.RawMessage(`[{"role":"user","content":[
+			{"type":"input_text","text":"What is this?"},
+			{"type":"input_image","image_url":"data:image/png;base64,iVBORw0KGgo="},
+			{"type":"input_image","image_url":"https://example.com/cat.jpg"}
+		]}]`),
+	}
+
+	out, err := ResponsesToAnthropicRequest(req)
+	require.NoError(t, err)
+
+	var blocks []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[0].Content, &blocks))
+	require.Len(t, blocks, 3)
+	require.NotNil(t, blocks[1].Source)
+	assert.Equal(t, "base64", blocks[1].Source.Type)
+	assert.Equal(t, "image/png", blocks[1].Source.MediaType)
+	assert.Equal(t, "iVBORw0KGgo=", blocks[1].Source.Data)
+	require.NotNil(t, blocks[2].Source)
+	assert.Equal(t, "url", blocks[2].Source.Type)
+	assert.Equal(t, "https://example.com/cat.jpg", blocks[2].Source.URL)
+}
+
+func TestResponsesOutputToInputItems_RoundTrip(t *testing.T) {
+	outputs := []ResponsesOutput{
+		{Type: "reasoning", ID: "rs_1", EncryptedContent: "sig", Summary: []ResponsesSummary{{Type: "summary_text", Text: "t"}}},
+		{Type: "message", ID: "msg_1", Role: "assistant", Content: []ResponsesContentPart{{Type: "output_text", Text: "Done"}}},
+		{Type: "function_call", ID: "fc_toolu_1", CallID: "toolu_1", Name: "shell", Arguments: `{"cmd":"ls"}`},
+		{Type: "web_search_call", ID: "ws_1"},
+	}
+
+	items := ResponsesOutputToInputItems(outputs)
+	require.Len(t, items, 3)
+	assert.Equal(t, "reasoning", items[0].Type)
+	assert.Equal(t, "sig", items[0].EncryptedContent)
+	assert.Equal(t, "message", items[1].Type)
+	assert.Equal(t, "assistant", items[1].Role)
+	assert.Equal(t, "function_call", items[2].Type)
+	assert.Equal(t, "toolu_1", items[2].CallID)
+}
+
+// ---------------------------------------------------------------------------
+// AnthropicToResponsesResponse tests
+// ---------------------------------------------------------------------------
+
+func TestAnthropicToResponsesResponse_Mixed(t *testing.T) {
+	resp := &AnthropicResponse{
+		ID:   "msg_123",
+		Type: "message",
+		Role: "assistant",
+		Content: []AnthropicContentBlock{
+			{Type: "thinking", Thinking: "Let me check.", Signature: "sig-1"},
+			{Type: "server_tool_use", ID: "srvtoolu_ws_9", Name: "web_search", Input: json.RawMessage(`{"query":"go generics"}`)},
+			{Type: "web_search_tool_result"},
+			{Type: "text", Text: "Here is "},
+			{Type: "text", Text: "the answer."},
+			{Type: "tool_use", ID: "toolu_1", Name: "shell", Input: json.RawMessage(`{"cmd":"ls"}`)},
+		},
+		StopReason: "tool_use",
+		Usage:      AnthropicUsage{InputTokens: 10, OutputTokens: 20, CacheReadInputTokens: 5, CacheCreationInputTokens: 3},
+	}
+
+	out := AnthropicToResponsesResponse(resp, "claude-sonnet-4-5")
+	assert.Equal(t, "resp_123", out.ID)
+	assert.Equal(t, "response", out.Object)
+	assert.Equal(t, "claude-sonnet-4-5", out.Model)
+	assert.Equal(t, "completed", out.Status)
+	require.Len(t, out.Output, 4)
+
+	assert.Equal(t, "reasoning", out.Output[0].Type)
+	assert.Equal(t, "sig-1", out.Output[0].EncryptedContent)
+	require.Len(t, out.Output[0].Summary, 1)
+	assert.Equal(t, "Let me check.", out.Output[0].Summary[0].Text)
+
+	assert.Equal(t, "web_search_call", out.Output[1].Type)
+	assert.Equal(t, "ws_9", out.Output[1].ID)
+	require.NotNil(t, out.Output[1].Action)
+	assert.Equal(t, "go generics", out.Output[1].Action.Query)
+
+	assert.Equal(t, "message", out.Output[2].Type)
+	require.Len(t, out.Output[2].Content, 2)
+	assert.Equal(t, "the answer.", out.Output[2].Content[1].Text)
+
+	assert.Equal(t, "function_call", out.Output[3].Type)
+	assert.Equal(t, "toolu_1", out.Output[3].CallID)
+	assert.Equal(t, "fc_toolu_1", out.Output[3].ID)
+	assert.Equal(t, `{"cmd":"ls"}`, out.Output[3].Arguments)
+
+	require.NotNil(t, out.Usage)
+	assert.Equal(t, 18, out.Usage.InputTokens)
+	assert.Equal(t, 20, out.Usage.OutputTokens)
+	assert.Equal(t, 38, out.Usage.TotalTokens)
+	require.NotNil(t, out.Usage.InputTokensDetails)
+	assert.Equal(t, 5, out.Usage.InputTokensDetails.CachedTokens)
+}
+
+func TestAnthropicToResponsesResponse_StopReasons(t *testing.T) {
+	out := AnthropicToResponsesResponse(&AnthropicResponse{ID: "msg_1", StopReason: "max_tokens"}, "m")
+	assert.Equal(t, "incomplete", out.Status)
+	require.NotNil(t, out.IncompleteDetails)
+	assert.Equal(t, "max_output_tokens", out.IncompleteDetails.Reason)
+
+	out = AnthropicToResponsesResponse(&AnthropicResponse{ID: "msg_1", StopReason: "refusal"}, "m")
+	assert.Equal(t, "incomplete", out.Status)
+	assert.Equal(t, "content_filter", out.IncompleteDetails.Reason)
+
+	out = AnthropicToResponsesResponse(&AnthropicResponse{ID: "msg_1", StopReason: "end_turn"}, "m")
+	assert.Equal(t, "completed", out.Status)
+	assert.Nil(t, out.IncompleteDetails)
+}
+
+// ---------------------------------------------------------------------------
+// AnthropicEventToResponsesEvents tests
+// ---------------------------------------------------------------------------
+
+func feedAnthropicEvents(t *testing.T, state *AnthropicEventToResponsesState, raw ...string) []ResponsesStreamEvent {
+	t.Helper()
+	var events []ResponsesStreamEvent
+	for _, r := range raw {
+		var evt AnthropicStreamEvent
+		require.NoError(t, json.Unmarshal([]byte(r), &evt))
+		events = append(events, AnthropicEventToResponsesEvents(&evt, state)...)
+	}
+	return events
+}
+
+func responsesEventTypes(events []ResponsesStreamEvent) []string {
+	types := make([]string, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestAnthropicEventToResponses_TextStream(t *testing.T) {
+	state := NewAnthropicEventToResponsesState("claude-sonnet-4-5")
+	events := feedAnthropicEvents(t, state,
+		`{"type":"message_start","message":{"id":"msg_abc","type":"message","role":"assistant","model":"claude-x","content":[],"usage":{"input_tokens":12,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	assert.Equal(t, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.content_part.added",
+		"response.output_text.delta",
+		"response.output_text.delta",
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.completed",
+	}, responsesEventTypes(events))
+
+	for i, e := range events {
+		assert.Equal(t, i+1, e.SequenceNumber)
+	}
+	assert.Equal(t, "Hello world", events[6].Text)
+
+	final := events[len(events)-1].Response
+	require.NotNil(t, final)
+	assert.Equal(t, "resp_abc", final.ID)
+	assert.Equal(t, "claude-sonnet-4-5", final.Model)
+	assert.Equal(t, "completed", final.Status)
+	require.Len(t, final.Output, 1)
+	assert.Equal(t, "Hello world", final.Output[0].Content[0].Text)
+	assert.Equal(t, 12, final.Usage.InputTokens)
+	assert.Equal(t, 7, final.Usage.OutputTokens)
+	assert.True(t, state.CompletedSent)
+}
+
+func TestAnthropicEventToResponses_ThinkingAndToolUse(t *testing.T) {
+	state := NewAnthropicEventToResponsesState("claude-sonnet-4-5")
+	events := feedAnthropicEvents(t, state,
+		`{"type":"message_start","message":{"id":"msg_t","type":"message","role":"assistant","content":[],"usage":{"input_tokens":5}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Plan"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"xyz"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_9","name":"shell","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"cmd\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"ls\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	assert.Equal(t, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.reasoning_summary_part.added",
+		"response.reasoning_summary_text.delta",
+		"response.reasoning_summary_text.done",
+		"response.reasoning_summary_part.done",
+		"response.output_item.done",
+		"response.output_item.added",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.done",
+		"response.output_item.done",
+		"response.completed",
+	}, responsesEventTypes(events))
+
+	final := state.FinalResponse()
+	require.Len(t, final.Output, 2)
+	assert.Equal(t, "reasoning", final.Output[0].Type)
+	assert.Equal(t, "sig-xyz", final.Output[0].EncryptedContent)
+	assert.Equal(t, "Plan", final.Output[0].Summary[0].Text)
+	assert.Equal(t, "function_call", final.Output[1].Type)
+	assert.Equal(t, "toolu_9", final.Output[1].CallID)
+	assert.Equal(t, 1, events[8].OutputIndex)
+	assert.Equal(t, `{"cmd":"ls"}`, final.Output[1].Arguments)
+	assert.Equal(t, "completed", final.Status)
+}
+
+func TestAnthropicEventToResponses_WebSearch(t *testing.T) {
+	state := NewAnthropicEventToResponsesState("m")
+	events := feedAnthropicEvents(t, state,
+		`{"type":"message_start","message":{"id":"msg_w","type":"message","role":"assistant","content":[]}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"server_tool_use","id":"srvtoolu_01","name":"web_search","input":{}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\":\"weather\"}"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"web_search_tool_result","tool_use_id":"srvtoolu_01","content":[]}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"Sunny"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_stop"}`,
+	)
+
+	types := responsesEventTypes(events)
+	assert.Contains(t, types, "response.web_search_call.in_progress")
+	assert.Contains(t, types, "response.web_search_call.completed")
+	assert.NotContains(t, types, "response.function_call_arguments.delta")
+
+	final := state.FinalResponse()
+	require.Len(t, final.Output, 2)
+	assert.Equal(t, "web_search_call", final.Output[0].Type)
+	assert.Equal(t, "ws_srvtoolu_01", final.Output[0].ID)
+	require.NotNil(t, final.Output[0].Action)
+	assert.Equal(t, "weather", final.Output[0].Action.Query)
+	assert.Equal(t, "message", final.Output[1].Type)
+}
+
+func TestAnthropicEventToResponses_MaxTokensIncomplete(t *testing.T) {
+	state := NewAnthropicEventToResponsesState("m")
+	events := feedAnthropicEvents(t, state,
+		`{"type":"message_start","message":{"id":"msg_m","type":"message","role":"assistant","content":[]}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"cut"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"max_tokens"}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	last := events[len(events)-1]
+	assert.Equal(t, "response.incomplete", last.Type)
+	require.NotNil(t, last.Response.IncompleteDetails)
+	assert.Equal(t, "max_output_tokens", last.Response.IncompleteDetails.Reason)
+}
+
+func TestAnthropicEventToResponses_ErrorEvent(t *testing.T) {
+	state := NewAnthropicEventToResponsesState("m")
+	events := feedAnthropicEvents(t, state,
+		`{"type":"message_start","message":{"id":"msg_e","type":"message","role":"assistant","content":[]}}`,
+		`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+	)
+
+	last := events[len(events)-1]
+	assert.Equal(t, "response.failed", last.Type)
+	require.NotNil(t, last.Response.Error)
+	assert.Equal(t, "overloaded_error", last.Response.Error.Code)
+	assert.Equal(t, "Overloaded", last.Response.Error.Message)
+	assert.Nil(t, FinalizeAnthropicResponsesStream(state))
+}
+
+func TestFinalizeAnthropicResponsesStream_TruncatedStream(t *testing.T) {
+	state := NewAnthropicEventToResponsesState("m")
+	feedAnthropicEvents(t, state,
+		`{"type":"message_start","message":{"id":"msg_x","type":"message","role":"assistant","content":[]}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"partial"}}`,
+	)
+
+	events := FinalizeAnthropicResponsesStream(state)
+	assert.Equal(t, []string{
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.completed",
+	}, responsesEventTypes(events))
+	assert.Equal(t, "partial", events[0].Text)
+	assert.True(t, state.CompletedSent)
+	assert.Nil(t, FinalizeAnthropicResponsesStream(state))
+}
+
+func TestFinalizeAnthropicResponsesStream_NotStarted(t *testing.T) {
+	state := NewAnthropicEventToResponsesState("m")
+	assert.Nil(t, FinalizeAnthropicResponsesStream(state))
+}
+
+func TestResponsesEventToSSE(t *testing.T) {
+	sse, err := ResponsesEventToSSE(ResponsesStreamEvent{Type: "response.output_text.delta", Delta: "hi", SequenceNumber: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"hi\",\"sequence_number\":3}\n\n", sse)
+}
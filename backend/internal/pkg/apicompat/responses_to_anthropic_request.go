@@ -0,0 +1,445 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultAnthropicMaxTokens is used when a Responses request does not set
+// max_output_tokens; Anthropic requires max_tokens on every request.
+const defaultAnthropicMaxTokens = 8192
+
+// ResponsesToAnthropicRequest converts a Responses API request into an
+// Anthropic Messages request. It is the inverse of AnthropicToResponses and
+// lets Responses-only clients (e.g. Codex CLI) be served by Claude accounts.
+//
+// previous_response_id is NOT resolved here: callers that emulate server-side
+// conversation state must splice the stored items into req.Input first.
+func ResponsesToAnthropicRequest(req *ResponsesRequest) (*AnthropicRequest, error) {
+	items, err := ParseResponsesInput(req.Input)
+	if err != nil {
+		return nil, fmt.Errorf("parse input: %w", err)
+	}
+
+	systemParts := make([]string, 0, 2)
+	if strings.TrimSpace(req.Instructions) != "" {
+		systemParts = append(systemParts, req.Instructions)
+	}
+	messages, extraSystem, err := convertResponsesInputToAnthropic(items)
+	if err != nil {
+		return nil, err
+	}
+	systemParts = append(systemParts, extraSystem...)
+
+	out := &AnthropicRequest{
+		Model:       req.Model,
+		Messages:    messages,
+		Stream:      req.Stream,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   defaultAnthropicMaxTokens,
+	}
+	if req.MaxOutputTokens != nil && *req.MaxOutputTokens > 0 {
+		out.MaxTokens = *req.MaxOutputTokens
+	}
+	if len(systemParts) > 0 {
+		system, err := json.Marshal(strings.Join(systemParts, "\n\n"))
+		if err != nil {
+			return nil, err
+		}
+		out.System = system
+	}
+
+	if len(req.Tools) > 0 {
+		out.Tools = convertResponsesToolsToAnthropic(req.Tools)
+	}
+	if len(req.ToolChoice) > 0 && len(out.Tools) > 0 {
+		tc, err := convertResponsesToolChoiceToAnthropic(req.ToolChoice)
+		if err != nil {
+			return nil, fmt.Errorf("convert tool_choice: %w", err)
+		}
+		out.ToolChoice = tc
+	}
+
+	if req.Reasoning != nil {
+		if budget := mapResponsesEffortToThinkingBudget(req.Reasoning.Effort); budget > 0 {
+			out.Thinking = &AnthropicThinking{Type: "enabled", BudgetTokens: budget}
+			// max_tokens must exceed the thinking budget; treat the client's
+			// limit as the visible-output budget on top of thinking.
+			if out.MaxTokens <= budget {
+				out.MaxTokens += budget
+			}
+			// Extended thinking rejects custom sampling parameters.
+			out.Temperature = nil
+			out.TopP = nil
+		}
+	}
+	if out.Thinking != nil && !assistantToolTurnHasThinking(messages) {
+		// Anthropic rejects a tool-use continuation whose assistant turn does
+		// not start with a signed thinking block (e.g. history replayed
+		// without reasoning.encrypted_content), so fall back to no thinking.
+		out.Thinking = nil
+		out.MaxTokens = defaultAnthropicMaxTokens
+		if req.MaxOutputTokens != nil && *req.MaxOutputTokens > 0 {
+			out.MaxTokens = *req.MaxOutputTokens
+		}
+		out.Temperature = req.Temperature
+		out.TopP = req.TopP
+	}
+
+	return out, nil
+}
+
+// assistantToolTurnHasThinking reports whether a trailing tool-use loop (last
+// message is a user turn carrying tool_result) starts its assistant turn with
+// a thinking block. Conversations that do not end in a tool loop return true.
+func assistantToolTurnHasThinking(messages []AnthropicMessage) bool {
+	n := len(messages)
+	if n < 2 || messages[n-1].Role != "user" || messages[n-2].Role != "assistant" {
+		return true
+	}
+	var userBlocks []AnthropicContentBlock
+	if err := json.Unmarshal(messages[n-1].Content, &userBlocks); err != nil {
+		return true
+	}
+	hasToolResult := false
+	for _, b := range userBlocks {
+		if b.Type == "tool_result" {
+			hasToolResult = true
+			break
+		}
+	}
+	if !hasToolResult {
+		return true
+	}
+	var assistantBlocks []AnthropicContentBlock
+	if err := json.Unmarshal(messages[n-2].Content, &assistantBlocks); err != nil || len(assistantBlocks) == 0 {
+		return true
+	}
+	return assistantBlocks[0].Type == "thinking"
+}
+
+// ParseResponsesInput handles the Responses input field which can be a plain
+// string or an array of input items.
+func ParseResponsesInput(raw json.RawMessage) ([]ResponsesInputItem, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		content, _ := json.Marshal(s)
+		return []ResponsesInputItem{{Role: "user", Content: content}}, nil
+	}
+	var items []ResponsesInputItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// convertResponsesInputToAnthropic maps Responses input items to Anthropic
+// messages. system/developer messages are returned separately so they can be
+// folded into the Anthropic system prompt. Consecutive items that map to the
+// same role are merged into one message because Anthropic requires strictly
+// alternating turns.
+func convertResponsesInputToAnthropic(items []ResponsesInputItem) ([]AnthropicMessage, []string, error) {
+	var (
+		system []string
+		turns  []anthropicTurn
+	)
+	appendBlocks := func(role string, blocks ...AnthropicContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(turns); n > 0 && turns[n-1].role == role {
+			turns[n-1].blocks = append(turns[n-1].blocks, blocks...)
+			return
+		}
+		turns = append(turns, anthropicTurn{role: role, blocks: blocks})
+	}
+
+	for _, item := range items {
+		switch item.Type {
+		case "", "message":
+			switch item.Role {
+			case "system", "developer":
+				text, err := responsesContentText(item.Content)
+				if err != nil {
+					return nil, nil, err
+				}
+				if text != "" {
+					system = append(system, text)
+				}
+			case "assistant":
+				blocks, err := responsesContentToAnthropicBlocks(item.Content)
+				if err != nil {
+					return nil, nil, err
+				}
+				appendBlocks("assistant", blocks...)
+			default:
+				blocks, err := responsesContentToAnthropicBlocks(item.Content)
+				if err != nil {
+					return nil, nil, err
+				}
+				appendBlocks("user", blocks...)
+			}
+		case "reasoning":
+			// Replaying thinking requires the original signature; reasoning
+			// items produced by other providers cannot be verified and are dropped.
+			if item.EncryptedContent == "" {
+				continue
+			}
+			var sb strings.Builder
+			for _, s := range item.Summary {
+				sb.WriteString(s.Text)
+			}
+			appendBlocks("assistant", AnthropicContentBlock{
+				Type:      "thinking",
+				Thinking:  sb.String(),
+				Signature: item.EncryptedContent,
+			})
+		case "function_call":
+			input := json.RawMessage(item.Arguments)
+			if strings.TrimSpace(item.Arguments) == "" || !json.Valid(input) {
+				input = json.RawMessage("{}")
+			}
+			appendBlocks("assistant", AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    toAnthropicToolUseID(item.CallID),
+				Name:  item.Name,
+				Input: input,
+			})
+		case "function_call_output":
+			output := item.Output
+			if output == "" {
+				output = "(empty)"
+			}
+			content, _ := json.Marshal(output)
+			appendBlocks("user", AnthropicContentBlock{
+				Type:      "tool_result",
+				ToolUseID: toAnthropicToolUseID(item.CallID),
+				Content:   content,
+			})
+		default:
+			// web_search_call and other hosted-tool items carry no content
+			// Anthropic can replay; the model's text already reflects them.
+			continue
+		}
+	}
+
+	messages := make([]AnthropicMessage, 0, len(turns))
+	for _, t := range turns {
+		content, err := json.Marshal(t.blocks)
+		if err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, AnthropicMessage{Role: t.role, Content: content})
+	}
+	return messages, system, nil
+}
+
+type anthropicTurn struct {
+	role   string
+	blocks []AnthropicContentBlock
+}
+
+// responsesContentToAnthropicBlocks converts a Responses message content
+// (string or typed parts) into Anthropic text/image blocks.
+func responsesContentToAnthropicBlocks(raw json.RawMessage) ([]AnthropicContentBlock, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s == "" {
+			return nil, nil
+		}
+		return []AnthropicContentBlock{{Type: "text", Text: s}}, nil
+	}
+	var parts []ResponsesContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, err
+	}
+	var blocks []AnthropicContentBlock
+	for _, p := range parts {
+		switch p.Type {
+		case "input_text", "output_text", "text":
+			if p.Text != "" {
+				blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: p.Text})
+			}
+		case "input_image":
+			if src := dataURIToAnthropicImageSource(p.ImageURL); src != nil {
+				blocks = append(blocks, AnthropicContentBlock{Type: "image", Source: src})
+			}
+		}
+	}
+	return blocks, nil
+}
+
+// responsesContentText joins the text of a Responses message content.
+func responsesContentText(raw json.RawMessage) (string, error) {
+	blocks, err := responsesContentToAnthropicBlocks(raw)
+	if err != nil {
+		return "", err
+	}
+	return extractAnthropicTextFromBlocks(blocks), nil
+}
+
+// dataURIToAnthropicImageSource reverses anthropicImageToDataURI. Plain
+// http(s) URLs are passed through as URL image sources.
+func dataURIToAnthropicImageSource(uri string) *AnthropicImageSource {
+	if uri == "" {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(uri, "data:"); ok {
+		meta, data, found := strings.Cut(rest, ",")
+		if !found || !strings.HasSuffix(meta, ";base64") {
+			return nil
+		}
+		return &AnthropicImageSource{
+			Type:      "base64",
+			MediaType: strings.TrimSuffix(meta, ";base64"),
+			Data:      data,
+		}
+	}
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		return &AnthropicImageSource{Type: "url", URL: uri}
+	}
+	return nil
+}
+
+// toAnthropicToolUseID maps a Responses call_id to an Anthropic tool_use ID.
+// Anthropic only accepts [a-zA-Z0-9_-], so other characters are replaced.
+func toAnthropicToolUseID(callID string) string {
+	id := fromResponsesCallID(callID)
+	if id == "" {
+		return "toolu_empty"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// convertResponsesToolsToAnthropic maps Responses tools to Anthropic tools.
+// Hosted tools other than web_search have no Anthropic equivalent and are
+// dropped.
+func convertResponsesToolsToAnthropic(tools []ResponsesTool) []AnthropicTool {
+	var out []AnthropicTool
+	for _, t := range tools {
+		switch {
+		case t.Type == "function":
+			schema := t.Parameters
+			if len(schema) == 0 || string(schema) == "null" {
+				schema = json.RawMessage(`{"type":"object","properties":{}}`)
+			}
+			out = append(out, AnthropicTool{
+				Name:        t.Name,
+				Description: t.Description,
+				InputSchema: schema,
+			})
+		case strings.HasPrefix(t.Type, "web_search"):
+			out = append(out, AnthropicTool{Type: "web_search_20250305", Name: "web_search"})
+		}
+	}
+	return out
+}
+
+// convertResponsesToolChoiceToAnthropic reverses convertAnthropicToolChoiceToResponses.
+//
+//	"auto"                                  → {"type":"auto"}
+//	"required"                              → {"type":"any"}
+//	"none"                                  → {"type":"none"}
+//	{"type":"function","name":"X"}          → {"type":"tool","name":"X"}
+//	{"type":"function","function":{"name"}} → {"type":"tool","name":"X"}
+func convertResponsesToolChoiceToAnthropic(raw json.RawMessage) (json.RawMessage, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		switch s {
+		case "required":
+			return json.Marshal(map[string]string{"type": "any"})
+		case "none":
+			return json.Marshal(map[string]string{"type": "none"})
+		default:
+			return json.Marshal(map[string]string{"type": "auto"})
+		}
+	}
+
+	var tc struct {
+		Type     string `json:"type"`
+		Name     string `json:"name"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &tc); err != nil {
+		return nil, err
+	}
+	name := tc.Name
+	if name == "" {
+		name = tc.Function.Name
+	}
+	if tc.Type == "function" && name != "" {
+		return json.Marshal(map[string]string{"type": "tool", "name": name})
+	}
+	return json.Marshal(map[string]string{"type": "auto"})
+}
+
+// mapResponsesEffortToThinkingBudget converts a Responses reasoning effort to
+// an Anthropic extended-thinking budget. 0 disables thinking.
+//
+//	minimal/none → 0
+//	low          → 2048
+//	medium       → 8192
+//	high         → 16384
+//	xhigh        → 32000
+func mapResponsesEffortToThinkingBudget(effort string) int {
+	switch effort {
+	case "low":
+		return 2048
+	case "medium":
+		return 8192
+	case "high":
+		return 16384
+	case "xhigh":
+		return 32000
+	default:
+		return 0
+	}
+}
+
+// ResponsesOutputToInputItems converts response output items into input items
+// so a finished turn can be replayed as conversation history (used to emulate
+// previous_response_id). Hosted-tool items that cannot be replayed are skipped.
+func ResponsesOutputToInputItems(outputs []ResponsesOutput) []ResponsesInputItem {
+	items := make([]ResponsesInputItem, 0, len(outputs))
+	for _, o := range outputs {
+		switch o.Type {
+		case "message":
+			content, err := json.Marshal(o.Content)
+			if err != nil {
+				continue
+			}
+			items = append(items, ResponsesInputItem{Type: "message", Role: "assistant", Content: content})
+		case "reasoning":
+			items = append(items, ResponsesInputItem{
+				Type:             "reasoning",
+				ID:               o.ID,
+				EncryptedContent: o.EncryptedContent,
+				Summary:          o.Summary,
+			})
+		case "function_call":
+			items = append(items, ResponsesInputItem{
+				Type:      "function_call",
+				ID:        o.ID,
+				CallID:    o.CallID,
+				Name:      o.Name,
+				Arguments: o.Arguments,
+			})
+		}
+	}
+	return items
+}
@@ -6,6 +6,7 @@ package timezone
 import (
 	"fmt"
 	"log"
+	"sync"
 	"time"
 )
 
@@ -159,3 +160,23 @@ func StartOfDayInUserLocation(t time.Time, userTZ string) time.Time {
 	t = t.In(loc)
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
 }
+
+// locationCache caches named locations loaded via LoadLocation.
+var locationCache sync.Map
+
+// LoadLocation returns the named IANA location, caching the result.
+// An empty name returns the configured server timezone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return Location(), nil
+	}
+	if cached, ok := locationCache.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
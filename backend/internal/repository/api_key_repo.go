@@ -48,6 +48,9 @@ func (r *apiKeyRepository) Create(ctx context.Context, key *service.APIKey) erro
 		SetRateLimit1d(key.RateLimit1d).
 		SetRateLimit7d(key.RateLimit7d)
 
+	if key.Priority != "" {
+		builder.SetPriority(key.Priority)
+	}
 	if len(key.IPWhitelist) > 0 {
 		builder.SetIPWhitelist(key.IPWhitelist)
 	}
@@ -130,6 +133,7 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 			apikey.FieldRateLimit5h,
 			apikey.FieldRateLimit1d,
 			apikey.FieldRateLimit7d,
+			apikey.FieldPriority,
 		).
 		WithUser(func(q *dbent.UserQuery) {
 			q.Select(
@@ -138,6 +142,8 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				user.FieldRole,
 				user.FieldBalance,
 				user.FieldConcurrency,
+				user.FieldQueueWeight,
+				user.FieldQueuePriority,
 			)
 		}).
 		WithGroup(func(q *dbent.GroupQuery) {
@@ -163,10 +169,18 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				group.FieldFallbackGroupIDOnInvalidRequest,
 				group.FieldModelRoutingEnabled,
 				group.FieldModelRouting,
+				group.FieldModelFallbacks,
+				group.FieldRequestTransforms,
 				group.FieldMcpXMLInject,
 				group.FieldSupportedModelScopes,
 				group.FieldAllowMessagesDispatch,
 				group.FieldDefaultMappedModel,
+				group.FieldQueueWeight,
+				group.FieldQueuePriority,
+				group.FieldSchedulingStrategy,
+				group.FieldHedgeEnabled,
+				group.FieldHedgePercentile,
+				group.FieldHedgeLoserBilling,
 			)
 		}).
 		Only(ctx)
@@ -200,6 +214,9 @@ func (r *apiKeyRepository) Update(ctx context.Context, key *service.APIKey) erro
 		SetUsage1d(key.Usage1d).
 		SetUsage7d(key.Usage7d).
 		SetUpdatedAt(now)
+	if key.Priority != "" {
+		builder.SetPriority(key.Priority)
+	}
 	if key.GroupID != nil {
 		builder.SetGroupID(*key.GroupID)
 	} else {
@@ -510,6 +527,22 @@ func (r *apiKeyRepository) IncrementRateLimitUsage(ctx context.Context, id int64
 	return err
 }
 
+// UpdateStatus updates only the status of an API key, leaving other fields untouched.
+func (r *apiKeyRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	affected, err := r.client.APIKey.Update().
+		Where(apikey.IDEQ(id), apikey.DeletedAtIsNil()).
+		SetStatus(status).
+		SetUpdatedAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
 // ResetRateLimitWindows resets expired rate limit windows atomically.
 func (r *apiKeyRepository) ResetRateLimitWindows(ctx context.Context, id int64) error {
 	_, err := r.sql.ExecContext(ctx, `
@@ -579,6 +612,7 @@ func apiKeyEntityToService(m *dbent.APIKey) *service.APIKey {
 		Window5hStart: m.Window5hStart,
 		Window1dStart: m.Window1dStart,
 		Window7dStart: m.Window7dStart,
+		Priority:      m.Priority,
 	}
 	if m.Edges.User != nil {
 		out.User = userEntityToService(m.Edges.User)
@@ -605,6 +639,8 @@ func userEntityToService(u *dbent.User) *service.User {
 		Status:                u.Status,
 		SoraStorageQuotaBytes: u.SoraStorageQuotaBytes,
 		SoraStorageUsedBytes:  u.SoraStorageUsedBytes,
+		QueueWeight:           u.QueueWeight,
+		QueuePriority:         u.QueuePriority,
 		TotpSecretEncrypted:   u.TotpSecretEncrypted,
 		TotpEnabled:           u.TotpEnabled,
 		TotpEnabledAt:         u.TotpEnabledAt,
@@ -644,11 +680,21 @@ func groupEntityToService(g *dbent.Group) *service.Group {
 		FallbackGroupIDOnInvalidRequest: g.FallbackGroupIDOnInvalidRequest,
 		ModelRouting:                    g.ModelRouting,
 		ModelRoutingEnabled:             g.ModelRoutingEnabled,
+		ModelFallbacks:                  g.ModelFallbacks,
+		RequestTransforms:               g.RequestTransforms,
 		MCPXMLInject:                    g.McpXMLInject,
 		SupportedModelScopes:            g.SupportedModelScopes,
 		SortOrder:                       g.SortOrder,
 		AllowMessagesDispatch:           g.AllowMessagesDispatch,
 		DefaultMappedModel:              g.DefaultMappedModel,
+		Price:                           g.Price,
+		RenewalPeriodDays:               g.RenewalPeriodDays,
+		QueueWeight:                     g.QueueWeight,
+		QueuePriority:                   g.QueuePriority,
+		SchedulingStrategy:              g.SchedulingStrategy,
+		HedgeEnabled:                    g.HedgeEnabled,
+		HedgePercentile:                 g.HedgePercentile,
+		HedgeLoserBilling:               g.HedgeLoserBilling,
 		CreatedAt:                       g.CreatedAt,
 		UpdatedAt:                       g.UpdatedAt,
 	}
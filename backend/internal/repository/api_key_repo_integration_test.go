@@ -387,6 +387,28 @@ func (s *APIKeyRepoSuite) mustCreateApiKey(userID int64, key, name string, group
 	return k
 }
 
+// --- UpdateStatus ---
+
+func (s *APIKeyRepoSuite) TestUpdateStatus_KeepsUsageCounters() {
+	user := s.mustCreateUser("status-only@test.com")
+	key := s.mustCreateApiKey(user.ID, "sk-status-only", "Status", nil)
+
+	// 模拟先读取整行、期间发生并发计费的场景
+	stale, err := s.repo.GetByID(s.ctx, key.ID)
+	s.Require().NoError(err, "GetByID")
+	_, err = s.repo.IncrementQuotaUsed(s.ctx, key.ID, 2.5)
+	s.Require().NoError(err, "IncrementQuotaUsed")
+
+	s.Require().NoError(s.repo.UpdateStatus(s.ctx, stale.ID, service.StatusAPIKeyDisabled), "UpdateStatus")
+
+	got, err := s.repo.GetByID(s.ctx, key.ID)
+	s.Require().NoError(err, "GetByID after UpdateStatus")
+	s.Require().Equal(service.StatusAPIKeyDisabled, got.Status)
+	s.Require().Equal(2.5, got.QuotaUsed, "仅更新状态，不应覆盖并发写入的用量")
+
+	s.Require().ErrorIs(s.repo.UpdateStatus(s.ctx, 999999, service.StatusAPIKeyDisabled), service.ErrAPIKeyNotFound)
+}
+
 // --- IncrementQuotaUsed ---
 
 func (s *APIKeyRepoSuite) TestIncrementQuotaUsed_Basic() {
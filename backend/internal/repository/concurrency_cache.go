@@ -27,10 +27,19 @@ const (
 	accountSlotKeyPrefix = "concurrency:account:"
 	// 格式: concurrency:user:{userID}
 	userSlotKeyPrefix = "concurrency:user:"
+	// 账号内模型级槽位格式: concurrency:account:{accountID}:model:{pattern}
+	accountModelSlotKeyInfix = ":model:"
+	// 账号内模型级 RPM 滑动窗口格式: rpm:account:{accountID}:model:{pattern}
+	accountModelRPMKeyPrefix = "rpm:account:"
+	// 模型级 RPM 滑动窗口长度（秒）
+	accountModelRPMWindowSeconds = 60
 	// 等待队列计数器格式: concurrency:wait:{userID}
 	waitQueueKeyPrefix = "concurrency:wait:"
 	// 账号级等待队列计数器格式: wait:account:{accountID}
 	accountWaitKeyPrefix = "wait:account:"
+	// batch 优先级请求的独立等待队列计数器格式: wait:account:batch:{accountID}
+	// 与账号级等待计数共用前缀，启动清理时一并删除
+	accountBatchWaitKeyPrefix = accountWaitKeyPrefix + "batch:"
 
 	// 默认槽位过期时间（分钟），可通过配置覆盖
 	defaultSlotTTLMinutes = 15
@@ -76,6 +85,30 @@ var (
 		return 0
 	`)
 
+	// acquireRPMScript 滑动窗口 RPM：窗口内请求数未达上限时记录本次请求
+	// 使用 Redis TIME（毫秒精度）作为分数，避免同一秒内的请求互相覆盖窗口边界
+	// KEYS[1] = 有序集合键 (rpm:account:{id}:model:{pattern})
+	// ARGV[1] = maxRPM
+	// ARGV[2] = 窗口长度（秒）
+	// ARGV[3] = requestID
+	acquireRPMScript = redis.NewScript(`
+		local key = KEYS[1]
+		local maxRPM = tonumber(ARGV[1])
+		local window = tonumber(ARGV[2])
+		local requestID = ARGV[3]
+
+		local timeResult = redis.call('TIME')
+		local nowMs = tonumber(timeResult[1]) * 1000 + math.floor(tonumber(timeResult[2]) / 1000)
+		redis.call('ZREMRANGEBYSCORE', key, '-inf', nowMs - window * 1000)
+
+		if redis.call('ZCARD', key) < maxRPM then
+			redis.call('ZADD', key, nowMs, requestID)
+			redis.call('EXPIRE', key, window)
+			return 1
+		end
+		return 0
+	`)
+
 	// getCountScript 统计有序集合中的槽位数量并清理过期条目
 	// 使用 Redis TIME 命令获取服务器时间
 	// KEYS[1] = 有序集合键
@@ -230,6 +263,10 @@ func accountWaitKey(accountID int64) string {
 	return fmt.Sprintf("%s%d", accountWaitKeyPrefix, accountID)
 }
 
+func accountBatchWaitKey(accountID int64) string {
+	return fmt.Sprintf("%s%d", accountBatchWaitKeyPrefix, accountID)
+}
+
 // Account slot operations
 
 func (c *concurrencyCache) AcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error) {
@@ -294,6 +331,88 @@ func (c *concurrencyCache) GetAccountConcurrencyBatch(ctx context.Context, accou
 	return result, nil
 }
 
+// Account model-scoped slot operations
+
+func accountModelSlotKey(scope service.AccountModelScope) string {
+	return accountSlotKey(scope.AccountID) + accountModelSlotKeyInfix + scope.Pattern
+}
+
+func accountModelRPMKey(scope service.AccountModelScope) string {
+	return fmt.Sprintf("%s%d%s%s", accountModelRPMKeyPrefix, scope.AccountID, accountModelSlotKeyInfix, scope.Pattern)
+}
+
+func (c *concurrencyCache) AcquireAccountModelSlot(ctx context.Context, scope service.AccountModelScope, maxConcurrency int, requestID string) (bool, error) {
+	key := accountModelSlotKey(scope)
+	result, err := acquireScript.Run(ctx, c.rdb, []string{key}, maxConcurrency, c.slotTTLSeconds, requestID).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (c *concurrencyCache) ReleaseAccountModelSlot(ctx context.Context, scope service.AccountModelScope, requestID string) error {
+	return c.rdb.ZRem(ctx, accountModelSlotKey(scope), requestID).Err()
+}
+
+func (c *concurrencyCache) AcquireAccountModelRPM(ctx context.Context, scope service.AccountModelScope, maxRPM int, requestID string) (bool, error) {
+	key := accountModelRPMKey(scope)
+	result, err := acquireRPMScript.Run(ctx, c.rdb, []string{key}, maxRPM, accountModelRPMWindowSeconds, requestID).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (c *concurrencyCache) ReleaseAccountModelRPM(ctx context.Context, scope service.AccountModelScope, requestID string) error {
+	return c.rdb.ZRem(ctx, accountModelRPMKey(scope), requestID).Err()
+}
+
+func (c *concurrencyCache) GetAccountModelLoadBatch(ctx context.Context, scopes []service.AccountModelScope) (map[int64]*service.AccountModelLoadInfo, error) {
+	if len(scopes) == 0 {
+		return map[int64]*service.AccountModelLoadInfo{}, nil
+	}
+
+	// 与 GetAccountsLoadBatch 一致使用 Pipeline，兼容 Redis Cluster
+	now, err := c.rdb.Time(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis TIME: %w", err)
+	}
+	slotCutoff := strconv.FormatInt(now.Unix()-int64(c.slotTTLSeconds), 10)
+	rpmCutoff := strconv.FormatInt(now.UnixMilli()-accountModelRPMWindowSeconds*1000, 10)
+
+	pipe := c.rdb.Pipeline()
+	type scopeCmds struct {
+		accountID int64
+		slotCmd   *redis.IntCmd
+		rpmCmd    *redis.IntCmd
+	}
+	cmds := make([]scopeCmds, 0, len(scopes))
+	for _, scope := range scopes {
+		slotKey := accountModelSlotKey(scope)
+		rpmKey := accountModelRPMKey(scope)
+		pipe.ZRemRangeByScore(ctx, slotKey, "-inf", slotCutoff)
+		pipe.ZRemRangeByScore(ctx, rpmKey, "-inf", rpmCutoff)
+		cmds = append(cmds, scopeCmds{
+			accountID: scope.AccountID,
+			slotCmd:   pipe.ZCard(ctx, slotKey),
+			rpmCmd:    pipe.ZCard(ctx, rpmKey),
+		})
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("pipeline exec: %w", err)
+	}
+
+	result := make(map[int64]*service.AccountModelLoadInfo, len(cmds))
+	for _, cmd := range cmds {
+		result[cmd.accountID] = &service.AccountModelLoadInfo{
+			CurrentConcurrency: int(cmd.slotCmd.Val()),
+			CurrentRPM:         int(cmd.rpmCmd.Val()),
+		}
+	}
+	return result, nil
+}
+
 // User slot operations
 
 func (c *concurrencyCache) AcquireUserSlot(ctx context.Context, userID int64, maxConcurrency int, requestID string) (bool, error) {
@@ -355,6 +474,21 @@ func (c *concurrencyCache) DecrementAccountWaitCount(ctx context.Context, accoun
 	return err
 }
 
+func (c *concurrencyCache) IncrementAccountBatchWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error) {
+	key := accountBatchWaitKey(accountID)
+	result, err := incrementAccountWaitScript.Run(ctx, c.rdb, []string{key}, maxWait, c.waitQueueTTLSeconds).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (c *concurrencyCache) DecrementAccountBatchWaitCount(ctx context.Context, accountID int64) error {
+	key := accountBatchWaitKey(accountID)
+	_, err := decrementWaitScript.Run(ctx, c.rdb, []string{key}).Result()
+	return err
+}
+
 func (c *concurrencyCache) GetAccountWaitingCount(ctx context.Context, accountID int64) (int, error) {
 	key := accountWaitKey(accountID)
 	val, err := c.rdb.Get(ctx, key).Int()
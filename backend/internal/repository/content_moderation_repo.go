@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+const contentModerationRuleColumns = `
+	id, name, enabled, priority, kind, action, group_ids, patterns, case_sensitive,
+	account_id, moderation_model, categories, webhook_url, webhook_secret, refusal_message,
+	description, created_at, updated_at
+`
+
+type contentModerationRepository struct {
+	db *sql.DB
+}
+
+func NewContentModerationRepository(db *sql.DB) service.ContentModerationRepository {
+	return &contentModerationRepository{db: db}
+}
+
+func (r *contentModerationRepository) ListRules(ctx context.Context) ([]*service.ContentModerationRule, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+contentModerationRuleColumns+" FROM content_moderation_rules ORDER BY priority ASC, id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	rules := make([]*service.ContentModerationRule, 0)
+	for rows.Next() {
+		rule, err := scanContentModerationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *contentModerationRepository) GetRule(ctx context.Context, id int64) (*service.ContentModerationRule, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+contentModerationRuleColumns+" FROM content_moderation_rules WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrContentModerationRuleNotFound
+	}
+	rule, err := scanContentModerationRule(rows)
+	if err != nil {
+		return nil, err
+	}
+	return rule, rows.Err()
+}
+
+func (r *contentModerationRepository) CreateRule(ctx context.Context, rule *service.ContentModerationRule) error {
+	if rule == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.db, `
+		INSERT INTO content_moderation_rules (
+			name, enabled, priority, kind, action, group_ids, patterns, case_sensitive,
+			account_id, moderation_model, categories, webhook_url, webhook_secret, refusal_message, description
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id, created_at, updated_at
+	`, contentModerationRuleArgs(rule), &rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+func (r *contentModerationRepository) UpdateRule(ctx context.Context, rule *service.ContentModerationRule) error {
+	if rule == nil {
+		return nil
+	}
+	err := scanSingleRow(ctx, r.db, `
+		UPDATE content_moderation_rules SET
+			name = $1, enabled = $2, priority = $3, kind = $4, action = $5, group_ids = $6,
+			patterns = $7, case_sensitive = $8, account_id = $9, moderation_model = $10,
+			categories = $11, webhook_url = $12, webhook_secret = $13, refusal_message = $14,
+			description = $15, updated_at = NOW()
+		WHERE id = $16
+		RETURNING created_at, updated_at
+	`, append(contentModerationRuleArgs(rule), rule.ID), &rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrContentModerationRuleNotFound
+	}
+	return err
+}
+
+func (r *contentModerationRepository) DeleteRule(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM content_moderation_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrContentModerationRuleNotFound
+	}
+	return nil
+}
+
+func (r *contentModerationRepository) CreateViolation(ctx context.Context, v *service.ContentModerationViolation) error {
+	if v == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.db, `
+		INSERT INTO content_moderation_violations (
+			user_id, api_key_id, group_id, rule_id, rule_name, rule_kind, action,
+			endpoint, model, reason, excerpt, key_suspended
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`, []any{
+		v.UserID,
+		v.APIKeyID,
+		nullInt64(v.GroupID),
+		nullInt64(v.RuleID),
+		v.RuleName,
+		v.RuleKind,
+		v.Action,
+		v.Endpoint,
+		v.Model,
+		v.Reason,
+		v.Excerpt,
+		v.KeySuspended,
+	}, &v.ID, &v.CreatedAt)
+}
+
+func (r *contentModerationRepository) CountBlockedByAPIKeySince(ctx context.Context, apiKeyID int64, since time.Time) (int64, error) {
+	var count int64
+	err := scanSingleRow(ctx, r.db, `
+		SELECT COUNT(*) FROM content_moderation_violations
+		WHERE api_key_id = $1 AND action = $2 AND created_at >= $3
+	`, []any{apiKeyID, service.ContentModerationActionBlock, since}, &count)
+	return count, err
+}
+
+func (r *contentModerationRepository) ListViolations(ctx context.Context, filter service.ContentModerationViolationFilter, params pagination.PaginationParams) ([]service.ContentModerationViolation, *pagination.PaginationResult, error) {
+	conditions := make([]string, 0, 6)
+	args := make([]any, 0, 8)
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, *filter.UserID)
+	}
+	if filter.APIKeyID != nil {
+		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)+1))
+		args = append(args, *filter.APIKeyID)
+	}
+	if filter.GroupID != nil {
+		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)+1))
+		args = append(args, *filter.GroupID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)+1))
+		args = append(args, filter.Action)
+	}
+	if filter.StartTime != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)+1))
+		args = append(args, *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)+1))
+		args = append(args, *filter.EndTime)
+	}
+	whereClause := buildWhere(conditions)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM content_moderation_violations "+whereClause, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.ContentModerationViolation{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, api_key_id, group_id, rule_id, rule_name, rule_kind, action,
+			endpoint, model, reason, excerpt, key_suspended, created_at
+		FROM content_moderation_violations %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, len(args)+1, len(args)+2)
+	args = append(args, params.Limit(), params.Offset())
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	violations := make([]service.ContentModerationViolation, 0)
+	for rows.Next() {
+		var (
+			v       service.ContentModerationViolation
+			groupID sql.NullInt64
+			ruleID  sql.NullInt64
+		)
+		if err := rows.Scan(
+			&v.ID, &v.UserID, &v.APIKeyID, &groupID, &ruleID, &v.RuleName, &v.RuleKind, &v.Action,
+			&v.Endpoint, &v.Model, &v.Reason, &v.Excerpt, &v.KeySuspended, &v.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		if groupID.Valid {
+			v.GroupID = &groupID.Int64
+		}
+		if ruleID.Valid {
+			v.RuleID = &ruleID.Int64
+		}
+		violations = append(violations, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return violations, paginationResultFromTotal(total, params), nil
+}
+
+func (r *contentModerationRepository) ListUserStats(ctx context.Context, since time.Time, params pagination.PaginationParams) ([]service.ContentModerationUserStat, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.db, `
+		SELECT COUNT(DISTINCT user_id) FROM content_moderation_violations WHERE created_at >= $1
+	`, []any{since}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.ContentModerationUserStat{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT v.user_id, COALESCE(u.email, ''),
+			COUNT(*) FILTER (WHERE v.action = $2),
+			COUNT(*) FILTER (WHERE v.action = $3),
+			MAX(v.created_at)
+		FROM content_moderation_violations v
+		LEFT JOIN users u ON u.id = v.user_id
+		WHERE v.created_at >= $1
+		GROUP BY v.user_id, u.email
+		ORDER BY COUNT(*) FILTER (WHERE v.action = $2) DESC, MAX(v.created_at) DESC
+		LIMIT $4 OFFSET $5
+	`, since, service.ContentModerationActionBlock, service.ContentModerationActionFlag, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := make([]service.ContentModerationUserStat, 0)
+	for rows.Next() {
+		var s service.ContentModerationUserStat
+		if err := rows.Scan(&s.UserID, &s.Email, &s.BlockedCount, &s.FlaggedCount, &s.LastViolationAt); err != nil {
+			return nil, nil, err
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return stats, paginationResultFromTotal(total, params), nil
+}
+
+func contentModerationRuleArgs(rule *service.ContentModerationRule) []any {
+	groupIDs := rule.GroupIDs
+	if groupIDs == nil {
+		groupIDs = []int64{}
+	}
+	patterns := rule.Patterns
+	if patterns == nil {
+		patterns = []string{}
+	}
+	categories := rule.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	return []any{
+		rule.Name,
+		rule.Enabled,
+		rule.Priority,
+		rule.Kind,
+		rule.Action,
+		pq.Array(groupIDs),
+		pq.Array(patterns),
+		rule.CaseSensitive,
+		nullInt64(rule.AccountID),
+		rule.ModerationModel,
+		pq.Array(categories),
+		rule.WebhookURL,
+		rule.WebhookSecret,
+		rule.RefusalMessage,
+		rule.Description,
+	}
+}
+
+func scanContentModerationRule(scanner interface{ Scan(...any) error }) (*service.ContentModerationRule, error) {
+	var (
+		rule      service.ContentModerationRule
+		groupIDs  pq.Int64Array
+		patterns  pq.StringArray
+		category  pq.StringArray
+		accountID sql.NullInt64
+	)
+	if err := scanner.Scan(
+		&rule.ID, &rule.Name, &rule.Enabled, &rule.Priority, &rule.Kind, &rule.Action,
+		&groupIDs, &patterns, &rule.CaseSensitive, &accountID, &rule.ModerationModel, &category,
+		&rule.WebhookURL, &rule.WebhookSecret, &rule.RefusalMessage, &rule.Description,
+		&rule.CreatedAt, &rule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	rule.GroupIDs = []int64(groupIDs)
+	rule.Patterns = []string(patterns)
+	rule.Categories = []string(category)
+	if rule.GroupIDs == nil {
+		rule.GroupIDs = []int64{}
+	}
+	if rule.Patterns == nil {
+		rule.Patterns = []string{}
+	}
+	if rule.Categories == nil {
+		rule.Categories = []string{}
+	}
+	if accountID.Valid {
+		rule.AccountID = &accountID.Int64
+	}
+	rule.WebhookSecretConfigured = rule.WebhookSecret != ""
+	return &rule, nil
+}
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/lib/pq"
+)
+
+const dbReadReplicaCheckTimeout = 3 * time.Second
+
+// 副本延迟：WAL 接收进程处于 streaming 且已全部回放时视为 0（主库空闲时 pg_last_xact_replay_timestamp 不会前进）；
+// 接收进程断开时已接收的 WAL 同样会全部回放完，此时改用最近一次回放时间计算延迟，
+// 无法得出延迟（从未回放过事务）时返回 NULL，副本不参与路由。在主库上执行时返回 0。
+// 读取 pg_stat_wal_receiver.status 需要 pg_read_all_stats（pg_monitor）权限，否则按回放时间计算。
+const dbReadReplicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()
+			AND EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming') THEN 0
+		ELSE EXTRACT(EPOCH FROM (NOW() - pg_last_xact_replay_timestamp()))
+	END::double precision
+`
+
+var (
+	dbReadReplicaHostRe = regexp.MustCompile(`(?:^|\s)host=(\S+)`)
+	dbReadReplicaPortRe = regexp.MustCompile(`(?:^|\s)port=(\S+)`)
+	dbReadReplicaTZRe   = regexp.MustCompile(`(?i)(?:^|\s)timezone=`)
+)
+
+// dbQueryer 只读查询接口，sqlDBQueryer 与 *DBReadRouter 均实现
+type dbQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) dbRowScanner
+}
+
+// dbRowScanner 单行查询结果，错误延迟到 Scan 时返回（与 *sql.Row 一致）
+type dbRowScanner interface {
+	Scan(dest ...any) error
+}
+
+// sqlDBQueryer 让 *sql.DB 满足 dbQueryer（未配置只读路由时使用）
+type sqlDBQueryer struct {
+	*sql.DB
+}
+
+func (q sqlDBQueryer) QueryRowContext(ctx context.Context, query string, args ...any) dbRowScanner {
+	return q.DB.QueryRowContext(ctx, query, args...)
+}
+
+// DBReadRouter 将仪表盘、运维与统计类只读查询路由到只读副本。
+//
+// 路由规则：
+//   - 副本按轮询选择，仅选择最近一次检测可用且复制延迟不超过 max_lag_seconds 的副本；
+//   - 无可用副本（或未配置副本）时使用主库；
+//   - 副本查询出现连接类错误时标记为不可用，并在主库上重试一次；
+//   - ExecContext 始终走主库。
+//
+// 对延迟敏感的读取（调度、限额、计费）不应使用该路由。
+type DBReadRouter struct {
+	primary  *sql.DB
+	replicas []*dbReadReplica
+	maxLag   time.Duration
+	interval time.Duration
+
+	next      atomic.Uint64
+	fallbacks atomic.Int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type dbReadReplica struct {
+	name string
+	db   *sql.DB
+
+	mu        sync.RWMutex
+	healthy   bool
+	lag       *float64
+	checkedAt time.Time
+	lastErr   string
+
+	routed atomic.Int64
+}
+
+// NewDBReadRouter 根据 database.read_replicas 配置创建只读路由，并启动后台健康检测。
+// 未配置副本时所有查询走主库。
+func NewDBReadRouter(cfg *config.Config, primary *sql.DB) (*DBReadRouter, error) {
+	router := &DBReadRouter{
+		primary:  primary,
+		maxLag:   30 * time.Second,
+		interval: 10 * time.Second,
+		stopCh:   make(chan struct{}),
+	}
+	if cfg == nil || len(cfg.Database.ReadReplicas.DSNs) == 0 {
+		return router, nil
+	}
+
+	rc := cfg.Database.ReadReplicas
+	if rc.MaxLagSeconds > 0 {
+		router.maxLag = time.Duration(rc.MaxLagSeconds) * time.Second
+	}
+	if rc.HealthCheckIntervalSeconds > 0 {
+		router.interval = time.Duration(rc.HealthCheckIntervalSeconds) * time.Second
+	}
+	for i, raw := range rc.DSNs {
+		dsn, name, err := normalizeReadReplicaDSN(raw, cfg.Timezone)
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("database.read_replicas.dsns[%d]: %w", i, err)
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("open read replica %s: %w", name, err)
+		}
+		db.SetMaxOpenConns(rc.MaxOpenConns)
+		db.SetMaxIdleConns(rc.MaxIdleConns)
+		db.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetimeMinutes) * time.Minute)
+		db.SetConnMaxIdleTime(time.Duration(cfg.Database.ConnMaxIdleTimeMinutes) * time.Minute)
+		router.replicas = append(router.replicas, &dbReadReplica{name: name, db: db})
+	}
+
+	// 启动时同步检测一次，避免首批查询全部回退主库
+	router.checkAll()
+	router.wg.Add(1)
+	go router.loop()
+	logger.LegacyPrintf("repository.db_read_router", "[DBReadRouter] started (replicas=%d max_lag=%s interval=%s)", len(router.replicas), router.maxLag, router.interval)
+	return router, nil
+}
+
+// ProvideDBReadRouter 为依赖注入提供只读路由
+func ProvideDBReadRouter(cfg *config.Config, db *sql.DB) (*DBReadRouter, error) {
+	return NewDBReadRouter(cfg, db)
+}
+
+// Close 停止健康检测并关闭副本连接（不关闭主库）
+func (r *DBReadRouter) Close() {
+	if r == nil {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+		r.wg.Wait()
+		for _, replica := range r.replicas {
+			_ = replica.db.Close()
+		}
+	})
+}
+
+func (r *DBReadRouter) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	replica := r.pick()
+	if replica == nil {
+		return r.primary.QueryContext(ctx, query, args...)
+	}
+	rows, err := replica.db.QueryContext(ctx, query, args...)
+	if err == nil || ctx.Err() != nil || !isReadReplicaConnError(err) {
+		return rows, err
+	}
+	replica.markUnhealthy(err)
+	r.fallbacks.Add(1)
+	return r.primary.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext 查询延迟到 Scan 时执行，经由 QueryContext 路由，副本连接类错误同样回退主库重试
+func (r *DBReadRouter) QueryRowContext(ctx context.Context, query string, args ...any) dbRowScanner {
+	return &dbReadRouterRow{router: r, ctx: ctx, query: query, args: args}
+}
+
+type dbReadRouterRow struct {
+	router *DBReadRouter
+	ctx    context.Context
+	query  string
+	args   []any
+}
+
+func (row *dbReadRouterRow) Scan(dest ...any) error {
+	return scanSingleRow(row.ctx, row.router, row.query, row.args, dest...)
+}
+
+func (r *DBReadRouter) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// ReadReplicaStatus 返回副本健康与延迟状态（运维面板展示），未配置副本时返回 nil
+func (r *DBReadRouter) ReadReplicaStatus() *service.OpsReadReplicaOverview {
+	if r == nil || len(r.replicas) == 0 {
+		return nil
+	}
+	overview := &service.OpsReadReplicaOverview{
+		MaxLagSeconds:    int(r.maxLag / time.Second),
+		PrimaryFallbacks: r.fallbacks.Load(),
+		Replicas:         make([]*service.OpsReadReplicaStatus, 0, len(r.replicas)),
+	}
+	for _, replica := range r.replicas {
+		replica.mu.RLock()
+		status := &service.OpsReadReplicaStatus{
+			Name:          replica.name,
+			Healthy:       replica.healthy,
+			Routable:      replica.routableLocked(r.maxLag),
+			LagSeconds:    replica.lag,
+			RoutedQueries: replica.routed.Load(),
+			LastError:     replica.lastErr,
+		}
+		if !replica.checkedAt.IsZero() {
+			checkedAt := replica.checkedAt
+			status.CheckedAt = &checkedAt
+		}
+		replica.mu.RUnlock()
+		overview.Replicas = append(overview.Replicas, status)
+	}
+	return overview
+}
+
+func (r *DBReadRouter) pick() *dbReadReplica {
+	if r == nil {
+		return nil
+	}
+	n := len(r.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := 0; i < n; i++ {
+		replica := r.replicas[(start+uint64(i))%uint64(n)]
+		if replica.routable(r.maxLag) {
+			replica.routed.Add(1)
+			return replica
+		}
+	}
+	r.fallbacks.Add(1)
+	return nil
+}
+
+func (r *DBReadRouter) loop() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			r.checkAll()
+		}
+	}
+}
+
+func (r *DBReadRouter) checkAll() {
+	var wg sync.WaitGroup
+	for _, replica := range r.replicas {
+		wg.Add(1)
+		go func(replica *dbReadReplica) {
+			defer wg.Done()
+			replica.check(r.maxLag)
+		}(replica)
+	}
+	wg.Wait()
+}
+
+func (p *dbReadReplica) check(maxLag time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbReadReplicaCheckTimeout)
+	defer cancel()
+
+	var lag sql.NullFloat64
+	err := p.db.QueryRowContext(ctx, dbReadReplicaLagQuery).Scan(&lag)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	wasRoutable := p.routableLocked(maxLag)
+	p.checkedAt = time.Now().UTC()
+	switch {
+	case err != nil:
+		p.healthy = false
+		p.lag = nil
+		p.lastErr = err.Error()
+	case !lag.Valid:
+		// 连接正常但无法确定复制进度，不参与路由
+		p.healthy = true
+		p.lag = nil
+		p.lastErr = "replication lag unknown"
+	default:
+		p.healthy = true
+		p.lag = &lag.Float64
+		p.lastErr = ""
+	}
+	if routable := p.routableLocked(maxLag); routable != wasRoutable {
+		if routable {
+			logger.LegacyPrintf("repository.db_read_router", "[DBReadRouter] replica %s back in rotation (lag=%.1fs)", p.name, lag.Float64)
+		} else {
+			logger.LegacyPrintf("repository.db_read_router", "[DBReadRouter] replica %s removed from rotation (lag=%v err=%v)", p.name, formatReadReplicaLag(p.lag), err)
+		}
+	}
+}
+
+func (p *dbReadReplica) routable(maxLag time.Duration) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.routableLocked(maxLag)
+}
+
+func (p *dbReadReplica) routableLocked(maxLag time.Duration) bool {
+	return p.healthy && p.lag != nil && *p.lag <= maxLag.Seconds()
+}
+
+func (p *dbReadReplica) markUnhealthy(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.healthy {
+		logger.LegacyPrintf("repository.db_read_router", "[DBReadRouter] replica %s query failed, falling back to primary: %v", p.name, err)
+	}
+	p.healthy = false
+	p.lastErr = err.Error()
+}
+
+func formatReadReplicaLag(lag *float64) string {
+	if lag == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%.1fs", *lag)
+}
+
+// isReadReplicaConnError 判断是否为副本连接层错误（可在主库重试）；SQL 语义错误直接返回
+func isReadReplicaConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// 08: connection exception; 57P: operator intervention（如 admin_shutdown / cannot_connect_now）
+		// 40001: 副本上查询与 WAL 回放冲突被取消
+		code := string(pqErr.Code)
+		return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "57P") || code == "40001"
+	}
+	return true
+}
+
+// normalizeReadReplicaDSN 统一为 key=value 形式并补齐 TimeZone（与主库会话时区一致，保证按日统计正确），
+// 同时返回不含密码的展示名。
+func normalizeReadReplicaDSN(raw, tz string) (string, string, error) {
+	dsn := strings.TrimSpace(raw)
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		converted, err := pq.ParseURL(dsn)
+		if err != nil {
+			return "", "", err
+		}
+		dsn = converted
+	}
+	if dsn == "" {
+		return "", "", errors.New("empty dsn")
+	}
+	if tz == "" {
+		tz = "Asia/Shanghai"
+	}
+	if !dbReadReplicaTZRe.MatchString(dsn) {
+		dsn += " TimeZone=" + tz
+	}
+
+	host := "localhost"
+	if m := dbReadReplicaHostRe.FindStringSubmatch(dsn); m != nil {
+		host = strings.Trim(m[1], "'")
+	}
+	port := "5432"
+	if m := dbReadReplicaPortRe.FindStringSubmatch(dsn); m != nil {
+		port = strings.Trim(m[1], "'")
+	}
+	return dsn, host + ":" + port, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type emailTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewEmailTemplateRepository(db *sql.DB) service.EmailTemplateRepository {
+	return &emailTemplateRepository{db: db}
+}
+
+func (r *emailTemplateRepository) List(ctx context.Context) ([]*service.EmailTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT template_key, locale, subject, html_body, text_body, updated_at
+		FROM email_templates
+		ORDER BY template_key ASC, locale ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]*service.EmailTemplate, 0)
+	for rows.Next() {
+		tpl := &service.EmailTemplate{Customized: true}
+		var updatedAt time.Time
+		if err := rows.Scan(&tpl.Key, &tpl.Locale, &tpl.Subject, &tpl.HTMLBody, &tpl.TextBody, &updatedAt); err != nil {
+			return nil, err
+		}
+		tpl.UpdatedAt = &updatedAt
+		out = append(out, tpl)
+	}
+	return out, rows.Err()
+}
+
+func (r *emailTemplateRepository) Upsert(ctx context.Context, tpl *service.EmailTemplate) error {
+	var updatedAt time.Time
+	err := scanSingleRow(ctx, r.db, `
+		INSERT INTO email_templates (template_key, locale, subject, html_body, text_body, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (template_key, locale) DO UPDATE SET
+			subject = EXCLUDED.subject,
+			html_body = EXCLUDED.html_body,
+			text_body = EXCLUDED.text_body,
+			updated_at = NOW()
+		RETURNING updated_at
+	`, []any{tpl.Key, tpl.Locale, tpl.Subject, tpl.HTMLBody, tpl.TextBody}, &updatedAt)
+	if err != nil {
+		return err
+	}
+	tpl.UpdatedAt = &updatedAt
+	return nil
+}
+
+func (r *emailTemplateRepository) Delete(ctx context.Context, key, locale string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM email_templates WHERE template_key = $1 AND locale = $2", key, locale)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrEmailTemplateNotFound
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis Key 模式（使用 hash tag 确保 Redis Cluster 下同一账号的 key 落入同一 slot）
+// 格式: fair_queue:{accountID} / fair_queue:{accountID}:tags / :vclock / :hb
+const (
+	fairQueueKeyPrefix    = "fair_queue:"
+	fairQueueTagsSuffix   = ":tags"   // HASH principal -> 下一次入队的虚拟开始时间
+	fairQueueClockSuffix  = ":vclock" // STRING 队列虚拟时钟（最近放行者的开始时间）
+	fairQueueBeatSuffix   = ":hb"     // ZSET member -> 最近心跳时间（秒）
+	fairQueueActiveKey    = "fair_queue:active"
+	fairQueueHeartbeatTTL = 30 // 秒；等待者每次轮询都会刷新心跳
+	// fairQueueActiveTTL 活跃账号索引保留时长
+	fairQueueActiveTTL = time.Hour
+)
+
+// 分数 = (9 - priority) * 1e12 + 虚拟开始时间，高档位永远排在低档位之前。
+// 单次放行的虚拟时间代价为 fairQueueCostUnit / weight。
+const fairQueueCostUnit = 1000000
+
+// Lua 脚本：清理心跳超时的等待者
+const fairQueuePurgeLua = `
+local function purge(q, hb, tags, clock, now, ttl)
+    local stale = redis.call('ZRANGEBYSCORE', hb, '-inf', now - ttl)
+    for _, m in ipairs(stale) do
+        redis.call('ZREM', q, m)
+    end
+    if #stale > 0 then
+        redis.call('ZREMRANGEBYSCORE', hb, '-inf', now - ttl)
+    end
+    if redis.call('ZCARD', q) == 0 then
+        redis.call('DEL', tags, clock, hb)
+    end
+end
+`
+
+// Lua 脚本：入队（Start-time Fair Queuing）
+// KEYS[1] = 队列, KEYS[2] = tags, KEYS[3] = vclock, KEYS[4] = hb
+// ARGV[1] = member, ARGV[2] = principal, ARGV[3] = cost, ARGV[4] = priority, ARGV[5] = 心跳 TTL（秒）
+var fairQueueEnqueueScript = redis.NewScript(fairQueuePurgeLua + `
+local now = tonumber(redis.call('TIME')[1])
+local ttl = tonumber(ARGV[5])
+purge(KEYS[1], KEYS[4], KEYS[2], KEYS[3], now, ttl)
+
+local rank = redis.call('ZRANK', KEYS[1], ARGV[1])
+if rank == false then
+    local vclock = tonumber(redis.call('GET', KEYS[3]) or '0')
+    local last = tonumber(redis.call('HGET', KEYS[2], ARGV[2]) or '0')
+    local start = math.max(vclock, last)
+    redis.call('HSET', KEYS[2], ARGV[2], start + tonumber(ARGV[3]))
+    local score = (9 - tonumber(ARGV[4])) * 1e12 + start
+    redis.call('ZADD', KEYS[1], score, ARGV[1])
+    rank = redis.call('ZRANK', KEYS[1], ARGV[1])
+end
+redis.call('ZADD', KEYS[4], now, ARGV[1])
+for i = 1, 4 do
+    redis.call('EXPIRE', KEYS[i], ttl * 10)
+end
+return rank + 1
+`)
+
+// Lua 脚本：刷新心跳并返回位置（0 表示不在队列中）
+// KEYS 同入队脚本；ARGV[1] = member, ARGV[2] = 心跳 TTL（秒）
+var fairQueuePositionScript = redis.NewScript(fairQueuePurgeLua + `
+local now = tonumber(redis.call('TIME')[1])
+local ttl = tonumber(ARGV[2])
+purge(KEYS[1], KEYS[4], KEYS[2], KEYS[3], now, ttl)
+
+local rank = redis.call('ZRANK', KEYS[1], ARGV[1])
+if rank == false then
+    return 0
+end
+redis.call('ZADD', KEYS[4], now, ARGV[1])
+return rank + 1
+`)
+
+// Lua 脚本：离开队列，放行时将虚拟时钟推进到该等待者的开始时间
+// KEYS 同入队脚本；ARGV[1] = member, ARGV[2] = admitted(1/0), ARGV[3] = 心跳 TTL（秒）
+var fairQueueLeaveScript = redis.NewScript(`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[4], ARGV[1])
+if score and ARGV[2] == '1' then
+    local start = math.fmod(tonumber(score), 1e12)
+    local vclock = tonumber(redis.call('GET', KEYS[3]) or '0')
+    if start > vclock then
+        redis.call('SET', KEYS[3], start, 'EX', tonumber(ARGV[3]) * 10)
+    end
+end
+if redis.call('ZCARD', KEYS[1]) == 0 then
+    redis.call('DEL', KEYS[2], KEYS[3], KEYS[4])
+end
+return 1
+`)
+
+type fairQueueCache struct {
+	rdb *redis.Client
+}
+
+// NewFairQueueCache 创建账号公平队列缓存
+func NewFairQueueCache(rdb *redis.Client) service.FairQueueCache {
+	return &fairQueueCache{rdb: rdb}
+}
+
+func fairQueueKeys(accountID int64) []string {
+	base := fairQueueKeyPrefix + "{" + strconv.FormatInt(accountID, 10) + "}"
+	return []string{base, base + fairQueueTagsSuffix, base + fairQueueClockSuffix, base + fairQueueBeatSuffix}
+}
+
+// fairQueueMember 成员格式: {userID}|{principal}|{requestID}
+func fairQueueMember(ticket *service.FairQueueTicket) string {
+	return strconv.FormatInt(ticket.UserID, 10) + "|" + ticket.Principal + "|" + ticket.RequestID
+}
+
+func parseFairQueueMember(member string) (userID int64, principal string, ok bool) {
+	parts := strings.SplitN(member, "|", 3)
+	if len(parts) != 3 {
+		return 0, "", false
+	}
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return userID, parts[1], true
+}
+
+func (c *fairQueueCache) Enqueue(ctx context.Context, ticket *service.FairQueueTicket) (int, error) {
+	if ticket == nil {
+		return 0, errors.New("nil fair queue ticket")
+	}
+	weight := ticket.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	position, err := fairQueueEnqueueScript.Run(ctx, c.rdb, fairQueueKeys(ticket.AccountID),
+		fairQueueMember(ticket), ticket.Principal, fairQueueCostUnit/weight, ticket.Priority, fairQueueHeartbeatTTL,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("fair queue enqueue: %w", err)
+	}
+	// 活跃账号索引仅供 ops 视图使用，失败不影响排队
+	_ = c.rdb.ZAdd(ctx, fairQueueActiveKey, redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: strconv.FormatInt(ticket.AccountID, 10),
+	}).Err()
+	return position, nil
+}
+
+func (c *fairQueueCache) Position(ctx context.Context, ticket *service.FairQueueTicket) (int, error) {
+	if ticket == nil {
+		return 0, errors.New("nil fair queue ticket")
+	}
+	position, err := fairQueuePositionScript.Run(ctx, c.rdb, fairQueueKeys(ticket.AccountID),
+		fairQueueMember(ticket), fairQueueHeartbeatTTL,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("fair queue position: %w", err)
+	}
+	return position, nil
+}
+
+func (c *fairQueueCache) Leave(ctx context.Context, ticket *service.FairQueueTicket, admitted bool) error {
+	if ticket == nil {
+		return nil
+	}
+	admittedArg := "0"
+	if admitted {
+		admittedArg = "1"
+	}
+	if err := fairQueueLeaveScript.Run(ctx, c.rdb, fairQueueKeys(ticket.AccountID),
+		fairQueueMember(ticket), admittedArg, fairQueueHeartbeatTTL,
+	).Err(); err != nil {
+		return fmt.Errorf("fair queue leave: %w", err)
+	}
+	return nil
+}
+
+func (c *fairQueueCache) GetSnapshot(ctx context.Context, maxPerAccount int) ([]service.FairQueueAccountSnapshot, error) {
+	cutoff := strconv.FormatInt(time.Now().Add(-fairQueueActiveTTL).Unix(), 10)
+	if err := c.rdb.ZRemRangeByScore(ctx, fairQueueActiveKey, "-inf", cutoff).Err(); err != nil {
+		return nil, fmt.Errorf("fair queue prune active: %w", err)
+	}
+	members, err := c.rdb.ZRange(ctx, fairQueueActiveKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fair queue list active: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	if maxPerAccount <= 0 {
+		maxPerAccount = 1
+	}
+
+	// 使用 Pipeline 逐账号读取，兼容 Redis Cluster
+	type accountCmds struct {
+		id       int64
+		depthCmd *redis.IntCmd
+		rangeCmd *redis.StringSliceCmd
+	}
+	pipe := c.rdb.Pipeline()
+	cmds := make([]accountCmds, 0, len(members))
+	for _, m := range members {
+		accountID, err := strconv.ParseInt(m, 10, 64)
+		if err != nil || accountID <= 0 {
+			continue
+		}
+		key := fairQueueKeys(accountID)[0]
+		cmds = append(cmds, accountCmds{
+			id:       accountID,
+			depthCmd: pipe.ZCard(ctx, key),
+			rangeCmd: pipe.ZRange(ctx, key, 0, int64(maxPerAccount-1)),
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("pipeline exec: %w", err)
+	}
+
+	out := make([]service.FairQueueAccountSnapshot, 0, len(cmds))
+	for _, ac := range cmds {
+		depth := int(ac.depthCmd.Val())
+		if depth == 0 {
+			continue
+		}
+		snap := service.FairQueueAccountSnapshot{AccountID: ac.id, Depth: depth}
+		for i, member := range ac.rangeCmd.Val() {
+			userID, principal, ok := parseFairQueueMember(member)
+			if !ok {
+				continue
+			}
+			snap.Waiters = append(snap.Waiters, service.FairQueueWaiter{
+				AccountID: ac.id,
+				UserID:    userID,
+				Principal: principal,
+				Position:  i + 1,
+			})
+		}
+		out = append(out, snap)
+	}
+	return out, nil
+}
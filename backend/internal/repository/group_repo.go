@@ -61,13 +61,31 @@ func (r *groupRepository) Create(ctx context.Context, groupIn *service.Group) er
 		SetMcpXMLInject(groupIn.MCPXMLInject).
 		SetSoraStorageQuotaBytes(groupIn.SoraStorageQuotaBytes).
 		SetAllowMessagesDispatch(groupIn.AllowMessagesDispatch).
-		SetDefaultMappedModel(groupIn.DefaultMappedModel)
+		SetDefaultMappedModel(groupIn.DefaultMappedModel).
+		SetNillablePrice(groupIn.Price).
+		SetRenewalPeriodDays(groupIn.RenewalPeriodDays).
+		SetQueueWeight(groupIn.QueueWeight).
+		SetQueuePriority(groupIn.QueuePriority).
+		SetSchedulingStrategy(groupIn.SchedulingStrategy).
+		SetHedgeEnabled(groupIn.HedgeEnabled).
+		SetHedgePercentile(groupIn.HedgePercentile).
+		SetHedgeLoserBilling(groupIn.HedgeLoserBilling)
 
 	// 设置模型路由配置
 	if groupIn.ModelRouting != nil {
 		builder = builder.SetModelRouting(groupIn.ModelRouting)
 	}
 
+	// 设置模型降级链
+	if groupIn.ModelFallbacks != nil {
+		builder = builder.SetModelFallbacks(groupIn.ModelFallbacks)
+	}
+
+	// 设置请求改写规则
+	if groupIn.RequestTransforms != nil {
+		builder = builder.SetRequestTransforms(groupIn.RequestTransforms)
+	}
+
 	// 设置支持的模型系列（始终设置，空数组表示不限制）
 	builder = builder.SetSupportedModelScopes(groupIn.SupportedModelScopes)
 
@@ -130,7 +148,14 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		SetMcpXMLInject(groupIn.MCPXMLInject).
 		SetSoraStorageQuotaBytes(groupIn.SoraStorageQuotaBytes).
 		SetAllowMessagesDispatch(groupIn.AllowMessagesDispatch).
-		SetDefaultMappedModel(groupIn.DefaultMappedModel)
+		SetDefaultMappedModel(groupIn.DefaultMappedModel).
+		SetRenewalPeriodDays(groupIn.RenewalPeriodDays).
+		SetQueueWeight(groupIn.QueueWeight).
+		SetQueuePriority(groupIn.QueuePriority).
+		SetSchedulingStrategy(groupIn.SchedulingStrategy).
+		SetHedgeEnabled(groupIn.HedgeEnabled).
+		SetHedgePercentile(groupIn.HedgePercentile).
+		SetHedgeLoserBilling(groupIn.HedgeLoserBilling)
 
 	// 显式处理可空字段：nil 需要 clear，非 nil 需要 set。
 	if groupIn.DailyLimitUSD != nil {
@@ -163,6 +188,11 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 	} else {
 		builder = builder.ClearImagePrice4k()
 	}
+	if groupIn.Price != nil {
+		builder = builder.SetPrice(*groupIn.Price)
+	} else {
+		builder = builder.ClearPrice()
+	}
 
 	// 处理 FallbackGroupID：nil 时清除，否则设置
 	if groupIn.FallbackGroupID != nil {
@@ -184,6 +214,20 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		builder = builder.ClearModelRouting()
 	}
 
+	// 处理 ModelFallbacks：nil 时清除，否则设置
+	if groupIn.ModelFallbacks != nil {
+		builder = builder.SetModelFallbacks(groupIn.ModelFallbacks)
+	} else {
+		builder = builder.ClearModelFallbacks()
+	}
+
+	// 处理 RequestTransforms：nil 时清除，否则设置
+	if groupIn.RequestTransforms != nil {
+		builder = builder.SetRequestTransforms(groupIn.RequestTransforms)
+	} else {
+		builder = builder.ClearRequestTransforms()
+	}
+
 	// 处理 SupportedModelScopes（始终设置，空数组表示不限制）
 	builder = builder.SetSupportedModelScopes(groupIn.SupportedModelScopes)
 
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const invoiceSelectColumns = `
+	id, invoice_number, user_id, period_start, period_end, currency,
+	topup_amount, subscription_amount, usage_total_cost, usage_actual_cost, request_count,
+	content, content_hash, issued_at, created_at
+`
+
+type invoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) service.InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) CreateWithNextNumber(ctx context.Context, invoice *service.Invoice, numberPrefix string) (err error) {
+	if invoice == nil {
+		return nil
+	}
+	contentJSON, err := json.Marshal(invoice.Content)
+	if err != nil {
+		return fmt.Errorf("marshal invoice content: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	year := invoice.PeriodStart.Year()
+	scope := fmt.Sprintf("%s-%04d", numberPrefix, year)
+	var seq int64
+	if err = scanSingleRow(ctx, tx, `
+		INSERT INTO invoice_number_sequences (scope, last_value, updated_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (scope) DO UPDATE
+		SET last_value = invoice_number_sequences.last_value + 1,
+			updated_at = NOW()
+		RETURNING last_value
+	`, []any{scope}, &seq); err != nil {
+		return err
+	}
+	number := fmt.Sprintf("%s-%06d", scope, seq)
+
+	err = scanSingleRow(ctx, tx, `
+		INSERT INTO invoices (
+			invoice_number, user_id, period_start, period_end, currency,
+			topup_amount, subscription_amount, usage_total_cost, usage_actual_cost, request_count,
+			content, content_hash
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (user_id, period_start, period_end) DO NOTHING
+		RETURNING id, issued_at, created_at
+	`, []any{
+		number,
+		invoice.UserID,
+		invoice.PeriodStart,
+		invoice.PeriodEnd,
+		invoice.Currency,
+		invoice.TopupAmount,
+		invoice.SubscriptionAmount,
+		invoice.UsageTotalCost,
+		invoice.UsageActualCost,
+		invoice.RequestCount,
+		contentJSON,
+		invoice.ContentHash,
+	}, &invoice.ID, &invoice.IssuedAt, &invoice.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 回滚以释放已分配的编号
+		err = service.ErrInvoiceAlreadyExists
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	invoice.InvoiceNumber = number
+	return nil
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id int64) (*service.Invoice, error) {
+	return r.getOne(ctx, "SELECT "+invoiceSelectColumns+" FROM invoices WHERE id = $1", id)
+}
+
+func (r *invoiceRepository) GetByUserPeriod(ctx context.Context, userID int64, periodStart, periodEnd time.Time) (*service.Invoice, error) {
+	return r.getOne(ctx, "SELECT "+invoiceSelectColumns+" FROM invoices WHERE user_id = $1 AND period_start = $2 AND period_end = $3", userID, periodStart, periodEnd)
+}
+
+func (r *invoiceRepository) List(ctx context.Context, filter service.InvoiceListFilter, params pagination.PaginationParams) ([]service.Invoice, *pagination.PaginationResult, error) {
+	conditions := make([]string, 0, 2)
+	args := make([]any, 0, 4)
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, *filter.UserID)
+	}
+	if filter.PeriodStart != nil {
+		conditions = append(conditions, fmt.Sprintf("period_start = $%d", len(args)+1))
+		args = append(args, *filter.PeriodStart)
+	}
+	whereClause := buildWhere(conditions)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM invoices "+whereClause, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.Invoice{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM invoices %s ORDER BY period_start DESC, id DESC LIMIT $%d OFFSET $%d",
+		invoiceSelectColumns, whereClause, len(args)+1, len(args)+2)
+	args = append(args, params.Limit(), params.Offset())
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	invoices := make([]service.Invoice, 0)
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return invoices, paginationResultFromTotal(total, params), nil
+}
+
+func (r *invoiceRepository) ListBillableUserIDs(ctx context.Context, start, end time.Time) ([]int64, error) {
+	query := `
+		SELECT user_id FROM usage_logs WHERE created_at >= $1 AND created_at < $2
+		UNION
+		SELECT used_by FROM redeem_codes WHERE used_by IS NOT NULL AND used_at >= $1 AND used_at < $2 AND type IN ($3, $4, $5)
+		UNION
+		SELECT user_id FROM promo_code_usages WHERE used_at >= $1 AND used_at < $2
+		UNION
+		SELECT user_id FROM subscription_charges WHERE created_at >= $1 AND created_at < $2 AND status = $6
+		ORDER BY 1
+	`
+	rows, err := r.db.QueryContext(ctx, query, start, end,
+		service.RedeemTypeBalance, service.AdjustmentTypeAdminBalance, service.RedeemTypeSubscription,
+		service.SubscriptionChargeStatusSucceeded)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *invoiceRepository) ListBalanceEntries(ctx context.Context, userID int64, start, end time.Time) ([]service.InvoiceBalanceEntry, error) {
+	query := `
+		SELECT rc.type, rc.code, rc.value, rc.group_id, COALESCE(g.name, ''), rc.validity_days, COALESCE(rc.notes, ''), rc.used_at
+		FROM redeem_codes rc
+		LEFT JOIN groups g ON g.id = rc.group_id
+		WHERE rc.used_by = $1 AND rc.used_at >= $2 AND rc.used_at < $3 AND rc.type IN ($4, $5, $6)
+		UNION ALL
+		SELECT 'promo', pc.code, pu.bonus_amount, NULL, '', 0, '', pu.used_at
+		FROM promo_code_usages pu
+		JOIN promo_codes pc ON pc.id = pu.promo_code_id
+		WHERE pu.user_id = $1 AND pu.used_at >= $2 AND pu.used_at < $3
+		UNION ALL
+		SELECT sc.charge_type, '', sc.amount, sc.group_id, COALESCE(g.name, ''), sc.period_days, '', sc.created_at
+		FROM subscription_charges sc
+		LEFT JOIN groups g ON g.id = sc.group_id
+		WHERE sc.user_id = $1 AND sc.created_at >= $2 AND sc.created_at < $3 AND sc.status = $7
+		ORDER BY 8 ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, start, end,
+		service.RedeemTypeBalance, service.AdjustmentTypeAdminBalance, service.RedeemTypeSubscription,
+		service.SubscriptionChargeStatusSucceeded)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := make([]service.InvoiceBalanceEntry, 0)
+	for rows.Next() {
+		var (
+			codeType     string
+			code         string
+			entry        service.InvoiceBalanceEntry
+			groupID      sql.NullInt64
+			validityDays sql.NullInt64
+		)
+		if err := rows.Scan(&codeType, &code, &entry.Amount, &groupID, &entry.GroupName, &validityDays, &entry.Notes, &entry.OccurredAt); err != nil {
+			return nil, err
+		}
+		switch codeType {
+		case service.RedeemTypeBalance:
+			entry.Type = service.InvoiceEntryTypeRedeem
+			entry.Reference = maskInvoiceReference(code)
+		case service.AdjustmentTypeAdminBalance:
+			entry.Type = service.InvoiceEntryTypeAdminAdjustment
+		case service.RedeemTypeSubscription:
+			entry.Type = service.InvoiceEntryTypeSubscription
+			entry.Reference = maskInvoiceReference(code)
+		case service.SubscriptionChargeTypeRenewal, service.SubscriptionChargeTypePurchase, service.SubscriptionChargeTypeUpgrade:
+			// 余额扣费订阅：金额为实际扣除的余额
+			entry.Type = service.InvoiceEntryTypeSubscription
+			entry.Notes = subscriptionChargeInvoiceNote(codeType)
+		default:
+			entry.Type = service.InvoiceEntryTypePromo
+			entry.Reference = code
+		}
+		if groupID.Valid {
+			v := groupID.Int64
+			entry.GroupID = &v
+		}
+		if validityDays.Valid {
+			entry.ValidityDays = int(validityDays.Int64)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func subscriptionChargeInvoiceNote(chargeType string) string {
+	switch chargeType {
+	case service.SubscriptionChargeTypeRenewal:
+		return "auto-renewal"
+	case service.SubscriptionChargeTypeUpgrade:
+		return "upgrade (prorated)"
+	default:
+		return "purchase"
+	}
+}
+
+func (r *invoiceRepository) AggregateUsage(ctx context.Context, userID int64, start, end time.Time) ([]service.InvoiceUsageLine, error) {
+	query := `
+		SELECT
+			ul.group_id,
+			COALESCE(g.name, ''),
+			ul.model,
+			ul.rate_multiplier,
+			COUNT(*),
+			COALESCE(SUM(ul.input_tokens), 0),
+			COALESCE(SUM(ul.output_tokens), 0),
+			COALESCE(SUM(ul.cache_creation_tokens), 0),
+			COALESCE(SUM(ul.cache_read_tokens), 0),
+			COALESCE(SUM(ul.total_cost), 0),
+			COALESCE(SUM(ul.actual_cost), 0)
+		FROM usage_logs ul
+		LEFT JOIN groups g ON g.id = ul.group_id
+		WHERE ul.user_id = $1 AND ul.created_at >= $2 AND ul.created_at < $3
+		GROUP BY ul.group_id, g.name, ul.model, ul.rate_multiplier
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	lines := make([]service.InvoiceUsageLine, 0)
+	for rows.Next() {
+		var line service.InvoiceUsageLine
+		var groupID sql.NullInt64
+		if err := rows.Scan(
+			&groupID,
+			&line.GroupName,
+			&line.Model,
+			&line.RateMultiplier,
+			&line.RequestCount,
+			&line.InputTokens,
+			&line.OutputTokens,
+			&line.CacheCreationTokens,
+			&line.CacheReadTokens,
+			&line.TotalCost,
+			&line.ActualCost,
+		); err != nil {
+			return nil, err
+		}
+		if groupID.Valid {
+			v := groupID.Int64
+			line.GroupID = &v
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (r *invoiceRepository) getOne(ctx context.Context, query string, args ...any) (*service.Invoice, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrInvoiceNotFound
+	}
+	invoice, err := scanInvoice(rows)
+	if err != nil {
+		return nil, err
+	}
+	return invoice, rows.Err()
+}
+
+func scanInvoice(scanner interface{ Scan(...any) error }) (*service.Invoice, error) {
+	var invoice service.Invoice
+	var contentJSON []byte
+	if err := scanner.Scan(
+		&invoice.ID,
+		&invoice.InvoiceNumber,
+		&invoice.UserID,
+		&invoice.PeriodStart,
+		&invoice.PeriodEnd,
+		&invoice.Currency,
+		&invoice.TopupAmount,
+		&invoice.SubscriptionAmount,
+		&invoice.UsageTotalCost,
+		&invoice.UsageActualCost,
+		&invoice.RequestCount,
+		&contentJSON,
+		&invoice.ContentHash,
+		&invoice.IssuedAt,
+		&invoice.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(contentJSON, &invoice.Content); err != nil {
+		return nil, fmt.Errorf("parse invoice content: %w", err)
+	}
+	return &invoice, nil
+}
+
+// maskInvoiceReference 兑换码仅保留末 4 位，避免在账单中暴露完整兑换码
+func maskInvoiceReference(code string) string {
+	code = strings.TrimSpace(code)
+	if len(code) <= 4 {
+		return code
+	}
+	return "****" + code[len(code)-4:]
+}
@@ -14,6 +14,8 @@ import (
 
 type opsRepository struct {
 	db *sql.DB
+	// readRouter 仪表盘类只读查询的副本路由（可选）
+	readRouter *DBReadRouter
 }
 
 const insertOpsErrorLogSQL = `
@@ -60,8 +62,21 @@ INSERT INTO ops_error_logs (
   $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34,$35,$36,$37,$38
 )`
 
-func NewOpsRepository(db *sql.DB) service.OpsRepository {
-	return &opsRepository{db: db}
+func NewOpsRepository(db *sql.DB, readRouter *DBReadRouter) service.OpsRepository {
+	return &opsRepository{db: db, readRouter: readRouter}
+}
+
+// reader 返回仪表盘 / 趋势类只读查询使用的连接：配置只读副本时路由到副本，否则使用主库
+func (r *opsRepository) reader() dbQueryer {
+	if r.readRouter != nil {
+		return r.readRouter
+	}
+	return sqlDBQueryer{r.db}
+}
+
+// ReadReplicaStatus 实现 service.OpsReadReplicaStatusProvider
+func (r *opsRepository) ReadReplicaStatus() *service.OpsReadReplicaOverview {
+	return r.readRouter.ReadReplicaStatus()
 }
 
 func (r *opsRepository) InsertErrorLog(ctx context.Context, input *service.OpsInsertErrorLogInput) (int64, error) {
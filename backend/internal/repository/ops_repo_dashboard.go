@@ -434,7 +434,7 @@ FROM ops_metrics_hourly
 WHERE ` + where + `
 ORDER BY bucket_start ASC`
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.reader().QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -666,7 +666,7 @@ func (r *opsRepository) rawOpsDataExists(ctx context.Context, filter *service.Op
 		join, where, args, _ := buildUsageWhere(filter, start, end, 1)
 		q := `SELECT EXISTS(SELECT 1 FROM usage_logs ul ` + join + ` ` + where + ` LIMIT 1)`
 		var exists bool
-		if err := r.db.QueryRowContext(ctx, q, args...).Scan(&exists); err != nil {
+		if err := r.reader().QueryRowContext(ctx, q, args...).Scan(&exists); err != nil {
 			return false, err
 		}
 		if exists {
@@ -678,7 +678,7 @@ func (r *opsRepository) rawOpsDataExists(ctx context.Context, filter *service.Op
 		where, args, _ := buildErrorWhere(filter, start, end, 1)
 		q := `SELECT EXISTS(SELECT 1 FROM ops_error_logs ` + where + ` LIMIT 1)`
 		var exists bool
-		if err := r.db.QueryRowContext(ctx, q, args...).Scan(&exists); err != nil {
+		if err := r.reader().QueryRowContext(ctx, q, args...).Scan(&exists); err != nil {
 			return false, err
 		}
 		return exists, nil
@@ -785,7 +785,7 @@ FROM usage_logs ul
 ` + where
 
 	var tokens sql.NullInt64
-	if err := r.db.QueryRowContext(ctx, q, args...).Scan(&successCount, &tokens); err != nil {
+	if err := r.reader().QueryRowContext(ctx, q, args...).Scan(&successCount, &tokens); err != nil {
 		return 0, 0, err
 	}
 	if tokens.Valid {
@@ -820,7 +820,7 @@ FROM usage_logs ul
 	var tP50, tP90, tP95, tP99 sql.NullFloat64
 	var tAvg sql.NullFloat64
 	var tMax sql.NullInt64
-	if err := r.db.QueryRowContext(ctx, q, args...).Scan(
+	if err := r.reader().QueryRowContext(ctx, q, args...).Scan(
 		&dP50, &dP90, &dP95, &dP99, &dAvg, &dMax,
 		&tP50, &tP90, &tP95, &tP99, &tAvg, &tMax,
 	); err != nil {
@@ -872,7 +872,7 @@ SELECT
 FROM ops_error_logs
 ` + where
 
-	if err := r.db.QueryRowContext(ctx, q, args...).Scan(
+	if err := r.reader().QueryRowContext(ctx, q, args...).Scan(
 		&errorTotal,
 		&businessLimited,
 		&errorCountSLA,
@@ -939,7 +939,7 @@ FROM combined`
 	args := append(usageArgs, errorArgs...)
 
 	var maxReqPerMinute, maxTokensPerMinute sql.NullInt64
-	if err := r.db.QueryRowContext(ctx, q, args...).Scan(&maxReqPerMinute, &maxTokensPerMinute); err != nil {
+	if err := r.reader().QueryRowContext(ctx, q, args...).Scan(&maxReqPerMinute, &maxTokensPerMinute); err != nil {
 		return 0, 0, err
 	}
 	if maxReqPerMinute.Valid && maxReqPerMinute.Int64 > 0 {
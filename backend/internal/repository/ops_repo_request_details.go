@@ -137,7 +137,7 @@ WITH combined AS (
 
 	countQuery := fmt.Sprintf(`%s SELECT COUNT(1) FROM combined %s`, cte, where)
 	var total int64
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := r.reader().QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		if err == sql.ErrNoRows {
 			total = 0
 		} else {
@@ -183,7 +183,7 @@ LIMIT $%d OFFSET $%d
 `, cte, where, sort, len(args)+1, len(args)+2)
 
 	listArgs := append(append([]any{}, args...), pageSize, offset)
-	rows, err := r.db.QueryContext(ctx, listQuery, listArgs...)
+	rows, err := r.reader().QueryContext(ctx, listQuery, listArgs...)
 	if err != nil {
 		return nil, 0, err
 	}
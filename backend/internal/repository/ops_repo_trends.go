@@ -99,7 +99,7 @@ ORDER BY bucket ASC`
 
 	args := append(usageArgs, errorArgs...)
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.reader().QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -218,7 +218,7 @@ FROM combined
 WHERE platform IS NOT NULL AND platform <> ''
 ORDER BY request_count DESC`
 
-	rows, err := r.db.QueryContext(ctx, q, start, end)
+	rows, err := r.reader().QueryContext(ctx, q, start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -295,7 +295,7 @@ WHERE group_id IS NOT NULL
 ORDER BY request_count DESC
 LIMIT $4`
 
-	rows, err := r.db.QueryContext(ctx, q, start, end, platform, limit)
+	rows, err := r.reader().QueryContext(ctx, q, start, end, platform, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -462,7 +462,7 @@ FROM ops_error_logs
 GROUP BY 1
 ORDER BY 1 ASC`
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.reader().QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -573,7 +573,7 @@ GROUP BY 1
 ORDER BY total DESC
 LIMIT 20`
 
-	rows, err := r.db.QueryContext(ctx, q, args...)
+	rows, err := r.reader().QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
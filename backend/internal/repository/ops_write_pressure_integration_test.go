@@ -15,7 +15,7 @@ func TestOpsRepositoryBatchInsertErrorLogs(t *testing.T) {
 	ctx := context.Background()
 	_, _ = integrationDB.ExecContext(ctx, "TRUNCATE ops_error_logs RESTART IDENTITY")
 
-	repo := NewOpsRepository(integrationDB).(*opsRepository)
+	repo := NewOpsRepository(integrationDB, nil).(*opsRepository)
 	now := time.Now().UTC()
 	inserted, err := repo.BatchInsertErrorLogs(ctx, []*service.OpsInsertErrorLogInput{
 		{
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const passkeyChallengeKeyPrefix = "passkey:challenge:"
+
+// PasskeyCache implements service.PasskeyCache using Redis
+type PasskeyCache struct {
+	rdb *redis.Client
+}
+
+// NewPasskeyCache creates a new passkey challenge cache
+func NewPasskeyCache(rdb *redis.Client) service.PasskeyCache {
+	return &PasskeyCache{rdb: rdb}
+}
+
+// SetChallenge stores a pending WebAuthn challenge
+func (c *PasskeyCache) SetChallenge(ctx context.Context, key string, challenge *service.PasskeyChallenge, ttl time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("marshal passkey challenge: %w", err)
+	}
+
+	if err := c.rdb.Set(ctx, passkeyChallengeKeyPrefix+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("set passkey challenge: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeChallenge reads and deletes a challenge so that it can only be used once
+func (c *PasskeyCache) ConsumeChallenge(ctx context.Context, key string) (*service.PasskeyChallenge, error) {
+	data, err := c.rdb.GetDel(ctx, passkeyChallengeKeyPrefix+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get passkey challenge: %w", err)
+	}
+
+	var challenge service.PasskeyChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, fmt.Errorf("unmarshal passkey challenge: %w", err)
+	}
+
+	return &challenge, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type passkeyRepository struct {
+	db *sql.DB
+}
+
+func NewPasskeyRepository(db *sql.DB) service.PasskeyRepository {
+	return &passkeyRepository{db: db}
+}
+
+func (r *passkeyRepository) ListByUser(ctx context.Context, userID int64) ([]*service.UserPasskey, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, clone_warning,
+			transports, backup_eligible, backup_state, name, last_used_at, created_at
+		FROM user_passkeys
+		WHERE user_id = $1
+		ORDER BY id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]*service.UserPasskey, 0)
+	for rows.Next() {
+		pk := &service.UserPasskey{}
+		var (
+			signCount  int64
+			transports string
+			lastUsedAt sql.NullTime
+		)
+		if err := rows.Scan(&pk.ID, &pk.UserID, &pk.CredentialID, &pk.PublicKey, &pk.AttestationType, &pk.AAGUID, &signCount,
+			&pk.CloneWarning, &transports, &pk.BackupEligible, &pk.BackupState, &pk.Name, &lastUsedAt, &pk.CreatedAt); err != nil {
+			return nil, err
+		}
+		pk.SignCount = uint32(signCount)
+		pk.Transports = splitPasskeyTransports(transports)
+		if lastUsedAt.Valid {
+			t := lastUsedAt.Time
+			pk.LastUsedAt = &t
+		}
+		out = append(out, pk)
+	}
+	return out, rows.Err()
+}
+
+func (r *passkeyRepository) CountByUser(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM user_passkeys WHERE user_id = $1", []any{userID}, &count)
+	return count, err
+}
+
+func (r *passkeyRepository) Create(ctx context.Context, pk *service.UserPasskey) error {
+	err := scanSingleRow(ctx, r.db, `
+		INSERT INTO user_passkeys (user_id, credential_id, public_key, attestation_type, aaguid, sign_count,
+			transports, backup_eligible, backup_state, name, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		RETURNING id, created_at
+	`, []any{pk.UserID, pk.CredentialID, pk.PublicKey, pk.AttestationType, pk.AAGUID, int64(pk.SignCount),
+		strings.Join(pk.Transports, ","), pk.BackupEligible, pk.BackupState, pk.Name}, &pk.ID, &pk.CreatedAt)
+	return translatePersistenceError(err, nil, service.ErrPasskeyAlreadyRegistered)
+}
+
+func (r *passkeyRepository) UpdateUsage(ctx context.Context, id int64, signCount uint32, cloneWarning, backupState bool, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_passkeys
+		SET sign_count = $2, clone_warning = clone_warning OR $3, backup_state = $4, last_used_at = $5
+		WHERE id = $1
+	`, id, int64(signCount), cloneWarning, backupState, usedAt)
+	return err
+}
+
+func (r *passkeyRepository) Rename(ctx context.Context, userID, id int64, name string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE user_passkeys SET name = $3 WHERE id = $1 AND user_id = $2", id, userID, name)
+	return passkeyAffected(res, err)
+}
+
+func (r *passkeyRepository) Delete(ctx context.Context, userID, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM user_passkeys WHERE id = $1 AND user_id = $2", id, userID)
+	return passkeyAffected(res, err)
+}
+
+func passkeyAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrPasskeyNotFound
+	}
+	return nil
+}
+
+func splitPasskeyTransports(raw string) []string {
+	out := make([]string, 0)
+	for _, t := range strings.Split(raw, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
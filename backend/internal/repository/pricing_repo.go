@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const pricingRevisionMetaColumns = `
+	id, status, snapshot_hash, sources, base_revision_id, model_count,
+	added_count, changed_count, removed_count, reviewed_by, reviewed_at, created_at
+`
+
+type pricingRepository struct {
+	db *sql.DB
+}
+
+func NewPricingRepository(db *sql.DB) service.PricingRepository {
+	return &pricingRepository{db: db}
+}
+
+func (r *pricingRepository) GetRevision(ctx context.Context, id int64) (*service.PricingRevision, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+pricingRevisionMetaColumns+", snapshot, diff FROM model_pricing_revisions WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrPricingRevisionNotFound
+	}
+	var snapshotJSON, diffJSON []byte
+	rev, err := scanPricingRevision(rows, &snapshotJSON, &diffJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshotJSON, &rev.Snapshot); err != nil {
+		return nil, fmt.Errorf("unmarshal pricing snapshot: %w", err)
+	}
+	if err := json.Unmarshal(diffJSON, &rev.Diff); err != nil {
+		return nil, fmt.Errorf("unmarshal pricing diff: %w", err)
+	}
+	return rev, rows.Err()
+}
+
+func (r *pricingRepository) ListRevisions(ctx context.Context, filter service.PricingRevisionListFilter, params pagination.PaginationParams) ([]service.PricingRevision, *pagination.PaginationResult, error) {
+	conditions := make([]string, 0, 1)
+	args := make([]any, 0, 3)
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)+1))
+		args = append(args, filter.Status)
+	}
+	whereClause := buildWhere(conditions)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM model_pricing_revisions "+whereClause, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.PricingRevision{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM model_pricing_revisions %s ORDER BY id DESC LIMIT $%d OFFSET $%d",
+		pricingRevisionMetaColumns, whereClause, len(args)+1, len(args)+2)
+	args = append(args, params.Limit(), params.Offset())
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	revisions := make([]service.PricingRevision, 0)
+	for rows.Next() {
+		rev, err := scanPricingRevision(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return revisions, paginationResultFromTotal(total, params), nil
+}
+
+func (r *pricingRepository) CreateRevision(ctx context.Context, rev *service.PricingRevision, history []service.ModelPriceHistory) (err error) {
+	if rev == nil {
+		return nil
+	}
+	snapshotJSON, err := json.Marshal(rev.Snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal pricing snapshot: %w", err)
+	}
+	diff := rev.Diff
+	if diff == nil {
+		diff = []service.PricingDiffEntry{}
+	}
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("marshal pricing diff: %w", err)
+	}
+	sourcesJSON, err := json.Marshal(rev.Sources)
+	if err != nil {
+		return fmt.Errorf("marshal pricing sources: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if rev.Status == service.PricingRevisionStatusPending {
+		// 同一时间只保留一个待审核修订
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE model_pricing_revisions SET status = $1 WHERE status = $2
+		`, service.PricingRevisionStatusSuperseded, service.PricingRevisionStatusPending); err != nil {
+			return err
+		}
+	}
+
+	if err = scanSingleRow(ctx, tx, `
+		INSERT INTO model_pricing_revisions (
+			status, snapshot_hash, snapshot, diff, sources, base_revision_id, model_count,
+			added_count, changed_count, removed_count, reviewed_by, reviewed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`, []any{
+		rev.Status,
+		rev.SnapshotHash,
+		snapshotJSON,
+		diffJSON,
+		sourcesJSON,
+		nullInt64(rev.BaseRevisionID),
+		rev.ModelCount,
+		rev.AddedCount,
+		rev.ChangedCount,
+		rev.RemovedCount,
+		nullInt64(rev.ReviewedBy),
+		rev.ReviewedAt,
+	}, &rev.ID, &rev.CreatedAt); err != nil {
+		return err
+	}
+
+	if rev.Status == service.PricingRevisionStatusApplied {
+		if err = insertPriceHistory(ctx, tx, history, &rev.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *pricingRepository) ReviewRevision(ctx context.Context, id int64, status string, reviewerID *int64, history []service.ModelPriceHistory) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE model_pricing_revisions
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $3 AND status = $4
+	`, status, nullInt64(reviewerID), id, service.PricingRevisionStatusPending)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var exists bool
+		if err = scanSingleRow(ctx, tx, "SELECT EXISTS(SELECT 1 FROM model_pricing_revisions WHERE id = $1)", []any{id}, &exists); err != nil {
+			return err
+		}
+		if !exists {
+			err = service.ErrPricingRevisionNotFound
+			return err
+		}
+		err = service.ErrPricingRevisionNotPending
+		return err
+	}
+
+	if status == service.PricingRevisionStatusApplied {
+		if err = insertPriceHistory(ctx, tx, history, &id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *pricingRepository) ListOverrides(ctx context.Context) ([]service.ModelPriceOverride, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT model, pricing, note, updated_by, created_at, updated_at
+		FROM model_price_overrides
+		ORDER BY model
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	overrides := make([]service.ModelPriceOverride, 0)
+	for rows.Next() {
+		var (
+			o           service.ModelPriceOverride
+			pricingJSON []byte
+			updatedBy   sql.NullInt64
+		)
+		if err := rows.Scan(&o.Model, &pricingJSON, &o.Note, &updatedBy, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(pricingJSON, &o.Pricing); err != nil {
+			return nil, fmt.Errorf("unmarshal override pricing: %w", err)
+		}
+		if updatedBy.Valid {
+			v := updatedBy.Int64
+			o.UpdatedBy = &v
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+func (r *pricingRepository) UpsertOverride(ctx context.Context, override *service.ModelPriceOverride, history *service.ModelPriceHistory) (err error) {
+	if override == nil {
+		return nil
+	}
+	pricingJSON, err := json.Marshal(override.Pricing)
+	if err != nil {
+		return fmt.Errorf("marshal override pricing: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = scanSingleRow(ctx, tx, `
+		INSERT INTO model_price_overrides (model, pricing, note, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (model) DO UPDATE
+		SET pricing = EXCLUDED.pricing,
+			note = EXCLUDED.note,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, []any{override.Model, pricingJSON, override.Note, nullInt64(override.UpdatedBy)}, &override.CreatedAt, &override.UpdatedAt); err != nil {
+		return err
+	}
+	if history != nil {
+		if err = insertPriceHistory(ctx, tx, []service.ModelPriceHistory{*history}, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *pricingRepository) DeleteOverride(ctx context.Context, model string, history *service.ModelPriceHistory) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM model_price_overrides WHERE model = $1", model)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		err = service.ErrPriceOverrideNotFound
+		return err
+	}
+	if history != nil {
+		if err = insertPriceHistory(ctx, tx, []service.ModelPriceHistory{*history}, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *pricingRepository) ListHistory(ctx context.Context, model string, params pagination.PaginationParams) ([]service.ModelPriceHistory, *pagination.PaginationResult, error) {
+	conditions := make([]string, 0, 1)
+	args := make([]any, 0, 3)
+	if model != "" {
+		conditions = append(conditions, fmt.Sprintf("model = $%d", len(args)+1))
+		args = append(args, model)
+	}
+	whereClause := buildWhere(conditions)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM model_price_history "+whereClause, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.ModelPriceHistory{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, model, change_type, old_pricing, new_pricing, revision_id, actor_id, created_at
+		FROM model_price_history %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, len(args)+1, len(args)+2)
+	args = append(args, params.Limit(), params.Offset())
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	history := make([]service.ModelPriceHistory, 0)
+	for rows.Next() {
+		var (
+			h                   service.ModelPriceHistory
+			oldJSON, newJSON    []byte
+			revisionID, actorID sql.NullInt64
+		)
+		if err := rows.Scan(&h.ID, &h.Model, &h.ChangeType, &oldJSON, &newJSON, &revisionID, &actorID, &h.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		if h.OldPricing, err = unmarshalNullablePricing(oldJSON); err != nil {
+			return nil, nil, err
+		}
+		if h.NewPricing, err = unmarshalNullablePricing(newJSON); err != nil {
+			return nil, nil, err
+		}
+		if revisionID.Valid {
+			v := revisionID.Int64
+			h.RevisionID = &v
+		}
+		if actorID.Valid {
+			v := actorID.Int64
+			h.ActorID = &v
+		}
+		history = append(history, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return history, paginationResultFromTotal(total, params), nil
+}
+
+// insertPriceHistory 批量写入价格历史；revisionID 非空时覆盖条目自带的 revision_id
+func insertPriceHistory(ctx context.Context, tx *sql.Tx, history []service.ModelPriceHistory, revisionID *int64) error {
+	if len(history) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO model_price_history (model, change_type, old_pricing, new_pricing, revision_id, actor_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for i := range history {
+		h := &history[i]
+		oldJSON, err := marshalNullablePricing(h.OldPricing)
+		if err != nil {
+			return err
+		}
+		newJSON, err := marshalNullablePricing(h.NewPricing)
+		if err != nil {
+			return err
+		}
+		revID := h.RevisionID
+		if revisionID != nil {
+			revID = revisionID
+		}
+		if _, err := stmt.ExecContext(ctx, h.Model, h.ChangeType, oldJSON, newJSON, nullInt64(revID), nullInt64(h.ActorID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanPricingRevision(rows *sql.Rows, extra ...any) (*service.PricingRevision, error) {
+	var (
+		rev         service.PricingRevision
+		sourcesJSON []byte
+		baseID      sql.NullInt64
+		reviewedBy  sql.NullInt64
+		reviewedAt  sql.NullTime
+	)
+	dest := []any{
+		&rev.ID, &rev.Status, &rev.SnapshotHash, &sourcesJSON, &baseID, &rev.ModelCount,
+		&rev.AddedCount, &rev.ChangedCount, &rev.RemovedCount, &reviewedBy, &reviewedAt, &rev.CreatedAt,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(sourcesJSON, &rev.Sources); err != nil {
+		return nil, fmt.Errorf("unmarshal pricing sources: %w", err)
+	}
+	if baseID.Valid {
+		v := baseID.Int64
+		rev.BaseRevisionID = &v
+	}
+	if reviewedBy.Valid {
+		v := reviewedBy.Int64
+		rev.ReviewedBy = &v
+	}
+	if reviewedAt.Valid {
+		t := reviewedAt.Time
+		rev.ReviewedAt = &t
+	}
+	return &rev, nil
+}
+
+func marshalNullablePricing(p *service.LiteLLMModelPricing) (any, error) {
+	if p == nil {
+		return nil, nil
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshal pricing: %w", err)
+	}
+	return body, nil
+}
+
+func unmarshalNullablePricing(body []byte) (*service.LiteLLMModelPricing, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	var p service.LiteLLMModelPricing
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("unmarshal pricing: %w", err)
+	}
+	return &p, nil
+}
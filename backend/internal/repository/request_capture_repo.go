@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const requestCaptureMetaColumns = `
+	id, request_id, user_id, api_key_id, group_id, account_id, platform, model, method, path,
+	stream, status_code, duration_ms, request_bytes, response_bytes, truncated, encrypted,
+	created_at, expires_at
+`
+
+type requestCaptureRepository struct {
+	db *sql.DB
+}
+
+func NewRequestCaptureRepository(db *sql.DB) service.RequestCaptureRepository {
+	return &requestCaptureRepository{db: db}
+}
+
+func (r *requestCaptureRepository) Create(ctx context.Context, record *service.RequestCaptureRecord) error {
+	if record == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.db, `
+		INSERT INTO request_captures (
+			request_id, user_id, api_key_id, group_id, account_id, platform, model, method, path,
+			stream, status_code, duration_ms, request_bytes, response_bytes, truncated, encrypted,
+			request_body, response_body, response_text, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+		RETURNING id
+	`, []any{
+		record.RequestID,
+		record.UserID,
+		record.APIKeyID,
+		nullInt64(record.GroupID),
+		nullInt64(record.AccountID),
+		record.Platform,
+		record.Model,
+		record.Method,
+		record.Path,
+		record.Stream,
+		record.StatusCode,
+		record.DurationMs,
+		record.RequestBytes,
+		record.ResponseBytes,
+		record.Truncated,
+		record.Encrypted,
+		record.RequestBody,
+		record.ResponseBody,
+		record.ResponseText,
+		record.CreatedAt,
+		record.ExpiresAt,
+	}, &record.ID)
+}
+
+func (r *requestCaptureRepository) GetByID(ctx context.Context, id int64) (*service.RequestCaptureRecord, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+requestCaptureMetaColumns+", request_body, response_body, response_text FROM request_captures WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrRequestCaptureNotFound
+	}
+	var record service.RequestCaptureRecord
+	meta, err := scanRequestCapture(rows, &record.RequestBody, &record.ResponseBody, &record.ResponseText)
+	if err != nil {
+		return nil, err
+	}
+	record.RequestCapture = *meta
+	return &record, rows.Err()
+}
+
+func (r *requestCaptureRepository) List(ctx context.Context, filter service.RequestCaptureListFilter, params pagination.PaginationParams) ([]service.RequestCapture, *pagination.PaginationResult, error) {
+	conditions := make([]string, 0, 6)
+	args := make([]any, 0, 8)
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, *filter.UserID)
+	}
+	if filter.APIKeyID != nil {
+		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)+1))
+		args = append(args, *filter.APIKeyID)
+	}
+	if filter.GroupID != nil {
+		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)+1))
+		args = append(args, *filter.GroupID)
+	}
+	if requestID := strings.TrimSpace(filter.RequestID); requestID != "" {
+		conditions = append(conditions, fmt.Sprintf("request_id = $%d", len(args)+1))
+		args = append(args, requestID)
+	}
+	if filter.StartTime != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)+1))
+		args = append(args, *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)+1))
+		args = append(args, *filter.EndTime)
+	}
+	whereClause := buildWhere(conditions)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM request_captures "+whereClause, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.RequestCapture{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM request_captures %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		requestCaptureMetaColumns, whereClause, len(args)+1, len(args)+2)
+	args = append(args, params.Limit(), params.Offset())
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	captures := make([]service.RequestCapture, 0)
+	for rows.Next() {
+		capture, err := scanRequestCapture(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		captures = append(captures, *capture)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return captures, paginationResultFromTotal(total, params), nil
+}
+
+func (r *requestCaptureRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	if limit <= 0 {
+		limit = 1000
+	}
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM request_captures
+		WHERE id IN (
+			SELECT id FROM request_captures
+			WHERE expires_at <= $1
+			ORDER BY expires_at
+			LIMIT $2
+		)
+	`, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanRequestCapture(scanner interface{ Scan(...any) error }, extra ...any) (*service.RequestCapture, error) {
+	var (
+		capture   service.RequestCapture
+		groupID   sql.NullInt64
+		accountID sql.NullInt64
+	)
+	dest := []any{
+		&capture.ID,
+		&capture.RequestID,
+		&capture.UserID,
+		&capture.APIKeyID,
+		&groupID,
+		&accountID,
+		&capture.Platform,
+		&capture.Model,
+		&capture.Method,
+		&capture.Path,
+		&capture.Stream,
+		&capture.StatusCode,
+		&capture.DurationMs,
+		&capture.RequestBytes,
+		&capture.ResponseBytes,
+		&capture.Truncated,
+		&capture.Encrypted,
+		&capture.CreatedAt,
+		&capture.ExpiresAt,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service.ErrRequestCaptureNotFound
+		}
+		return nil, err
+	}
+	if groupID.Valid {
+		capture.GroupID = &groupID.Int64
+	}
+	if accountID.Valid {
+		capture.AccountID = &accountID.Int64
+	}
+	return &capture, nil
+}
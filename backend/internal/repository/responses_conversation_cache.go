@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const responsesConversationKeyPrefix = "responses:conv:"
+
+// ResponsesConversationCache implements service.ResponsesConversationCache using Redis
+type ResponsesConversationCache struct {
+	rdb *redis.Client
+}
+
+// NewResponsesConversationCache creates a new Responses conversation cache
+func NewResponsesConversationCache(rdb *redis.Client) service.ResponsesConversationCache {
+	return &ResponsesConversationCache{rdb: rdb}
+}
+
+func responsesConversationKey(apiKeyID int64, responseID string) string {
+	return fmt.Sprintf("%s%d:%s", responsesConversationKeyPrefix, apiKeyID, responseID)
+}
+
+// GetConversation retrieves a stored conversation, returning nil when absent
+func (c *ResponsesConversationCache) GetConversation(ctx context.Context, apiKeyID int64, responseID string) ([]byte, error) {
+	data, err := c.rdb.Get(ctx, responsesConversationKey(apiKeyID, responseID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetConversation stores a conversation with the given TTL
+func (c *ResponsesConversationCache) SetConversation(ctx context.Context, apiKeyID int64, responseID string, data []byte, ttl time.Duration) error {
+	return c.rdb.Set(ctx, responsesConversationKey(apiKeyID, responseID), data, ttl).Err()
+}
@@ -20,6 +20,8 @@ const (
 	schedulerVersionPrefix      = "sched:ver:"
 	schedulerSnapshotPrefix     = "sched:"
 	schedulerLockPrefix         = "sched:lock:"
+
+	schedulerAvailabilityLockPrefix = "sched:lock:availability:"
 )
 
 type schedulerCache struct {
@@ -209,6 +211,11 @@ func (c *schedulerCache) TryLockBucket(ctx context.Context, bucket service.Sched
 	return c.rdb.SetNX(ctx, key, time.Now().UnixNano(), ttl).Result()
 }
 
+func (c *schedulerCache) TryLockAvailabilityCheck(ctx context.Context, minute time.Time, ttl time.Duration) (bool, error) {
+	key := schedulerAvailabilityLockPrefix + strconv.FormatInt(minute.Unix(), 10)
+	return c.rdb.SetNX(ctx, key, time.Now().UnixNano(), ttl).Result()
+}
+
 func (c *schedulerCache) ListBuckets(ctx context.Context) ([]service.SchedulerBucket, error) {
 	raw, err := c.rdb.SMembers(ctx, schedulerBucketSetKey).Result()
 	if err != nil {
@@ -66,3 +66,21 @@ func TestSchedulerSnapshotOutboxReplay(t *testing.T) {
 		return cached.LastUsedAt.Unix() == expectedUnix
 	}, 5*time.Second, 100*time.Millisecond)
 }
+
+func TestSchedulerCacheAvailabilityCheckLock(t *testing.T) {
+	ctx := context.Background()
+	cache := NewSchedulerCache(testRedis(t))
+	minute := time.Now().Truncate(time.Minute)
+
+	ok, err := cache.TryLockAvailabilityCheck(ctx, minute, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = cache.TryLockAvailabilityCheck(ctx, minute, time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok, "同一分钟只允许一个实例处理")
+
+	ok, err = cache.TryLockAvailabilityCheck(ctx, minute.Add(time.Minute), time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type subscriptionChargeRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionChargeRepository(db *sql.DB) service.SubscriptionChargeRepository {
+	return &subscriptionChargeRepository{db: db}
+}
+
+// executor 优先使用 context 中的事务，使扣费与订阅延期保持原子性
+func (r *subscriptionChargeRepository) executor(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.db
+}
+
+func (r *subscriptionChargeRepository) ListRenewalCandidates(ctx context.Context, renewBefore, expiredAfter, retryBefore time.Time, limit int) ([]service.SubscriptionRenewalCandidate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT us.id, us.user_id, us.group_id, g.name, us.expires_at,
+			g.price, g.renewal_period_days, g.default_validity_days
+		FROM user_subscriptions us
+		JOIN groups g ON g.id = us.group_id
+		JOIN users u ON u.id = us.user_id
+		WHERE us.auto_renew = TRUE
+			AND us.deleted_at IS NULL
+			AND us.status IN ($1, $2)
+			AND us.expires_at <= $3
+			AND us.expires_at > $4
+			AND g.deleted_at IS NULL
+			AND g.status = $5
+			AND g.price IS NOT NULL
+			AND u.deleted_at IS NULL
+			AND u.status = $5
+			AND NOT EXISTS (
+				SELECT 1 FROM subscription_charges c
+				WHERE c.subscription_id = us.id
+					AND c.charge_type = $6
+					AND c.period_expires_at = us.expires_at
+					AND (c.status = $7 OR c.created_at > $8)
+			)
+		ORDER BY us.expires_at
+		LIMIT $9
+	`, service.SubscriptionStatusActive, service.SubscriptionStatusExpired, renewBefore, expiredAfter,
+		service.StatusActive, service.SubscriptionChargeTypeRenewal, service.SubscriptionChargeStatusSucceeded, retryBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	candidates := make([]service.SubscriptionRenewalCandidate, 0)
+	for rows.Next() {
+		var c service.SubscriptionRenewalCandidate
+		if err := rows.Scan(&c.SubscriptionID, &c.UserID, &c.GroupID, &c.GroupName, &c.ExpiresAt,
+			&c.Price, &c.RenewalPeriodDays, &c.DefaultValidityDays); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+func (r *subscriptionChargeRepository) DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error) {
+	res, err := r.executor(ctx).ExecContext(ctx, `
+		UPDATE users SET balance = balance - $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND balance >= $2
+	`, userID, amount)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *subscriptionChargeRepository) Create(ctx context.Context, charge *service.SubscriptionCharge) error {
+	if charge == nil {
+		return nil
+	}
+	err := scanSingleRow(ctx, r.executor(ctx), `
+		INSERT INTO subscription_charges (
+			user_id, subscription_id, group_id, charge_type, status, amount, period_days,
+			period_expires_at, new_expires_at, credit_amount, upgraded_from_subscription_id, error_message
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`, []any{
+		charge.UserID,
+		charge.SubscriptionID,
+		charge.GroupID,
+		charge.ChargeType,
+		charge.Status,
+		charge.Amount,
+		charge.PeriodDays,
+		charge.PeriodExpiresAt,
+		charge.NewExpiresAt,
+		charge.CreditAmount,
+		charge.UpgradedFromSubscriptionID,
+		charge.ErrorMessage,
+	}, &charge.ID, &charge.CreatedAt)
+	if isUniqueConstraintViolation(err) {
+		return service.ErrSubscriptionAlreadyRenewed
+	}
+	return err
+}
+
+func (r *subscriptionChargeRepository) SetAutoRenew(ctx context.Context, userID, subscriptionID int64, enabled bool) error {
+	res, err := r.executor(ctx).ExecContext(ctx, `
+		UPDATE user_subscriptions SET auto_renew = $3, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`, subscriptionID, userID, enabled)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *subscriptionChargeRepository) TerminateSubscription(ctx context.Context, userID, subscriptionID int64, at time.Time) (bool, error) {
+	res, err := r.executor(ctx).ExecContext(ctx, `
+		UPDATE user_subscriptions
+		SET expires_at = $3, status = $4, auto_renew = FALSE, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND status = $5 AND expires_at > $3
+	`, subscriptionID, userID, at, service.SubscriptionStatusExpired, service.SubscriptionStatusActive)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *subscriptionChargeRepository) SumPaidBySubscription(ctx context.Context, subscriptionID int64) (*service.SubscriptionPaidSummary, error) {
+	var summary service.SubscriptionPaidSummary
+	if err := scanSingleRow(ctx, r.executor(ctx), `
+		SELECT COALESCE(SUM(amount + credit_amount), 0), COALESCE(SUM(period_days), 0)
+		FROM subscription_charges
+		WHERE subscription_id = $1 AND status = $2
+	`, []any{subscriptionID, service.SubscriptionChargeStatusSucceeded}, &summary.Amount, &summary.Days); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (r *subscriptionChargeRepository) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]service.SubscriptionCharge, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM subscription_charges WHERE user_id = $1", []any{userID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.SubscriptionCharge{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.id, c.user_id, c.subscription_id, c.group_id, COALESCE(g.name, ''), c.charge_type, c.status,
+			c.amount, c.period_days, c.period_expires_at, c.new_expires_at,
+			c.credit_amount, c.upgraded_from_subscription_id, c.error_message, c.created_at
+		FROM subscription_charges c
+		LEFT JOIN groups g ON g.id = c.group_id
+		WHERE c.user_id = $1
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	charges := make([]service.SubscriptionCharge, 0)
+	for rows.Next() {
+		var (
+			charge          service.SubscriptionCharge
+			subscriptionID  sql.NullInt64
+			periodExpiresAt sql.NullTime
+			newExpiresAt    sql.NullTime
+			upgradedFrom    sql.NullInt64
+		)
+		if err := rows.Scan(
+			&charge.ID, &charge.UserID, &subscriptionID, &charge.GroupID, &charge.GroupName, &charge.ChargeType, &charge.Status,
+			&charge.Amount, &charge.PeriodDays, &periodExpiresAt, &newExpiresAt,
+			&charge.CreditAmount, &upgradedFrom, &charge.ErrorMessage, &charge.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		if subscriptionID.Valid {
+			v := subscriptionID.Int64
+			charge.SubscriptionID = &v
+		}
+		if periodExpiresAt.Valid {
+			v := periodExpiresAt.Time
+			charge.PeriodExpiresAt = &v
+		}
+		if newExpiresAt.Valid {
+			v := newExpiresAt.Time
+			charge.NewExpiresAt = &v
+		}
+		if upgradedFrom.Valid {
+			v := upgradedFrom.Int64
+			charge.UpgradedFromSubscriptionID = &v
+		}
+		charges = append(charges, charge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return charges, paginationResultFromTotal(total, params), nil
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 上游熔断状态：每个作用域一个 Hash，key 为 upstream_cb:{scope}
+//   - open_until: 熔断截止时间（毫秒时间戳），不存在表示 closed
+//   - probe / probe_until: 半开探测占用者（请求 ID）与占用截止时间
+//   - host: 账号作用域最近一次请求的上游主机
+//   - b{slot}:i / :t / :f / :s: 滑动窗口环形分桶（桶序号、请求数、错误数、慢调用数）
+const (
+	upstreamCircuitKeyPrefix = "upstream_cb:"
+	upstreamCircuitBuckets   = 10
+)
+
+// upstreamCircuitRecordScript 记录一次请求结果并更新熔断状态
+// 返回值：0 无变化，1 熔断，2 探测成功恢复，3 探测失败重新熔断
+var upstreamCircuitRecordScript = redis.NewScript(`
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local bucketMs = tonumber(ARGV[2])
+	local buckets = tonumber(ARGV[3])
+	local failure = tonumber(ARGV[4])
+	local slow = tonumber(ARGV[5])
+	local minRequests = tonumber(ARGV[6])
+	local errorThreshold = tonumber(ARGV[7])
+	local slowThreshold = tonumber(ARGV[8])
+	local openMs = tonumber(ARGV[9])
+	local token = ARGV[10]
+	local host = ARGV[11]
+	local ttl = tonumber(ARGV[12])
+
+	if host ~= '' then
+		redis.call('HSET', key, 'host', host)
+	end
+
+	local result = 0
+	local openUntil = tonumber(redis.call('HGET', key, 'open_until') or '0')
+	if openUntil > 0 then
+		-- 熔断期间仅处理半开探测请求的结果，其余结果（熔断前发出的请求）忽略
+		if token ~= '' and redis.call('HGET', key, 'probe') == token then
+			if failure == 1 or slow == 1 then
+				redis.call('HSET', key, 'open_until', now + openMs)
+				redis.call('HDEL', key, 'probe', 'probe_until')
+				result = 3
+			else
+				local lastHost = redis.call('HGET', key, 'host')
+				redis.call('DEL', key)
+				if lastHost then
+					redis.call('HSET', key, 'host', lastHost)
+				end
+				result = 2
+			end
+		end
+		redis.call('EXPIRE', key, ttl)
+		return result
+	end
+
+	local idx = math.floor(now / bucketMs)
+	local prefix = 'b' .. (idx % buckets)
+	if tonumber(redis.call('HGET', key, prefix .. ':i') or '-1') ~= idx then
+		redis.call('HSET', key, prefix .. ':i', idx, prefix .. ':t', 0, prefix .. ':f', 0, prefix .. ':s', 0)
+	end
+	redis.call('HINCRBY', key, prefix .. ':t', 1)
+	if failure == 1 then
+		redis.call('HINCRBY', key, prefix .. ':f', 1)
+	end
+	if slow == 1 then
+		redis.call('HINCRBY', key, prefix .. ':s', 1)
+	end
+
+	local total, failures, slows = 0, 0, 0
+	for i = 0, buckets - 1 do
+		local p = 'b' .. i
+		local vals = redis.call('HMGET', key, p .. ':i', p .. ':t', p .. ':f', p .. ':s')
+		local bucketIdx = tonumber(vals[1] or '-1')
+		if bucketIdx > idx - buckets then
+			total = total + tonumber(vals[2] or '0')
+			failures = failures + tonumber(vals[3] or '0')
+			slows = slows + tonumber(vals[4] or '0')
+		end
+	end
+
+	if total >= minRequests and (failures * 100 >= errorThreshold * total or (slowThreshold > 0 and slows * 100 >= slowThreshold * total)) then
+		for i = 0, buckets - 1 do
+			local p = 'b' .. i
+			redis.call('HDEL', key, p .. ':i', p .. ':t', p .. ':f', p .. ':s')
+		end
+		redis.call('HSET', key, 'open_until', now + openMs)
+		result = 1
+	end
+	redis.call('EXPIRE', key, ttl)
+	return result
+`)
+
+// upstreamCircuitClaimProbeScript 为半开作用域占用探测名额
+// 返回值：1 允许（closed 或已由 token 占用 / 占用成功），0 拒绝
+var upstreamCircuitClaimProbeScript = redis.NewScript(`
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local token = ARGV[2]
+	local probeMs = tonumber(ARGV[3])
+
+	local vals = redis.call('HMGET', key, 'open_until', 'probe', 'probe_until')
+	local openUntil = tonumber(vals[1] or '0')
+	if openUntil == 0 then
+		return 1
+	end
+	if now < openUntil then
+		return 0
+	end
+	if vals[2] and tonumber(vals[3] or '0') > now then
+		if vals[2] == token then
+			return 1
+		end
+		return 0
+	end
+	redis.call('HSET', key, 'probe', token, 'probe_until', now + probeMs)
+	return 1
+`)
+
+type upstreamCircuitCache struct {
+	rdb *redis.Client
+}
+
+// NewUpstreamCircuitCache 创建上游熔断状态缓存
+func NewUpstreamCircuitCache(rdb *redis.Client) service.UpstreamCircuitCache {
+	return &upstreamCircuitCache{rdb: rdb}
+}
+
+func upstreamCircuitKey(scope string) string {
+	return upstreamCircuitKeyPrefix + scope
+}
+
+func circuitTimeFromMs(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// GetCircuitSnapshots 使用 Pipeline 批量读取作用域状态
+func (c *upstreamCircuitCache) GetCircuitSnapshots(ctx context.Context, scopes []string) (map[string]*service.UpstreamCircuitSnapshot, error) {
+	result := make(map[string]*service.UpstreamCircuitSnapshot, len(scopes))
+	if len(scopes) == 0 {
+		return result, nil
+	}
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(scopes))
+	for i, scope := range scopes {
+		cmds[i] = pipe.HMGet(ctx, upstreamCircuitKey(scope), "open_until", "probe", "probe_until", "host")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("get circuit snapshots: %w", err)
+	}
+	for i, scope := range scopes {
+		vals, err := cmds[i].Result()
+		if err != nil || len(vals) != 4 {
+			continue
+		}
+		snap := &service.UpstreamCircuitSnapshot{
+			OpenUntil:  circuitTimeFromMs(circuitInt64(vals[0])),
+			ProbeToken: circuitString(vals[1]),
+			ProbeUntil: circuitTimeFromMs(circuitInt64(vals[2])),
+			Host:       circuitString(vals[3]),
+		}
+		if snap.OpenUntil.IsZero() && snap.Host == "" {
+			continue
+		}
+		result[scope] = snap
+	}
+	return result, nil
+}
+
+// RecordCircuitOutcome 原子地记录一次请求结果并更新熔断状态
+func (c *upstreamCircuitCache) RecordCircuitOutcome(ctx context.Context, scope string, outcome service.UpstreamCircuitOutcome, policy service.UpstreamCircuitPolicy) (service.UpstreamCircuitTransition, error) {
+	bucketMs := policy.Window.Milliseconds() / upstreamCircuitBuckets
+	if bucketMs <= 0 {
+		bucketMs = 1000
+	}
+	ttl := int64((policy.Window + policy.OpenDuration + policy.ProbeTimeout).Seconds())
+	if ttl < 60 {
+		ttl = 60
+	}
+	res, err := upstreamCircuitRecordScript.Run(ctx, c.rdb, []string{upstreamCircuitKey(scope)},
+		outcome.At.UnixMilli(),
+		bucketMs,
+		upstreamCircuitBuckets,
+		circuitFlag(outcome.Failure),
+		circuitFlag(outcome.Slow),
+		policy.MinRequests,
+		policy.ErrorRateThreshold,
+		policy.SlowCallRateThreshold,
+		policy.OpenDuration.Milliseconds(),
+		outcome.ProbeToken,
+		outcome.Host,
+		ttl,
+	).Int()
+	if err != nil {
+		return service.CircuitTransitionNone, fmt.Errorf("record circuit outcome: %w", err)
+	}
+	return service.UpstreamCircuitTransition(res), nil
+}
+
+// ClaimCircuitProbe 为半开作用域占用探测名额
+func (c *upstreamCircuitCache) ClaimCircuitProbe(ctx context.Context, scope, token string, now time.Time, probeTimeout time.Duration) (bool, error) {
+	res, err := upstreamCircuitClaimProbeScript.Run(ctx, c.rdb, []string{upstreamCircuitKey(scope)},
+		now.UnixMilli(), token, probeTimeout.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("claim circuit probe: %w", err)
+	}
+	return res == 1, nil
+}
+
+func circuitInt64(v any) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+func circuitString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func circuitFlag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
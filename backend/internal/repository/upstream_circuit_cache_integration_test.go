@@ -0,0 +1,115 @@
+//go:build integration
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type UpstreamCircuitCacheSuite struct {
+	IntegrationRedisSuite
+	cache service.UpstreamCircuitCache
+}
+
+func (s *UpstreamCircuitCacheSuite) SetupTest() {
+	s.IntegrationRedisSuite.SetupTest()
+	s.cache = NewUpstreamCircuitCache(s.rdb)
+}
+
+func testCircuitPolicy() service.UpstreamCircuitPolicy {
+	return service.UpstreamCircuitPolicy{
+		Window:             time.Minute,
+		MinRequests:        4,
+		ErrorRateThreshold: 50,
+		OpenDuration:       30 * time.Second,
+		ProbeTimeout:       time.Minute,
+	}
+}
+
+func (s *UpstreamCircuitCacheSuite) record(scope string, at time.Time, failure bool, token string) service.UpstreamCircuitTransition {
+	transition, err := s.cache.RecordCircuitOutcome(s.ctx, scope, service.UpstreamCircuitOutcome{
+		Failure:    failure,
+		ProbeToken: token,
+		Host:       "api.example.com",
+		At:         at,
+	}, testCircuitPolicy())
+	require.NoError(s.T(), err)
+	return transition
+}
+
+func (s *UpstreamCircuitCacheSuite) TestOpensAfterErrorRateThreshold() {
+	scope := "account:1"
+	now := time.Now()
+
+	require.Equal(s.T(), service.CircuitTransitionNone, s.record(scope, now, false, ""))
+	require.Equal(s.T(), service.CircuitTransitionNone, s.record(scope, now, true, ""))
+	require.Equal(s.T(), service.CircuitTransitionNone, s.record(scope, now, false, ""))
+	require.Equal(s.T(), service.CircuitTransitionOpened, s.record(scope, now, true, ""), "4 次请求中 2 次失败达到 50% 阈值")
+
+	snapshots, err := s.cache.GetCircuitSnapshots(s.ctx, []string{scope, "account:2"})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), snapshots, 1)
+	snap := snapshots[scope]
+	require.Equal(s.T(), service.CircuitStateOpen, snap.State(now))
+	require.Equal(s.T(), "api.example.com", snap.Host)
+	require.WithinDuration(s.T(), now.Add(30*time.Second), snap.OpenUntil, time.Second)
+}
+
+func (s *UpstreamCircuitCacheSuite) TestOldBucketsLeaveWindow() {
+	scope := "host:api.example.com"
+	start := time.Now()
+	s.record(scope, start, true, "")
+	s.record(scope, start, true, "")
+	s.record(scope, start, true, "")
+
+	// 窗口外的失败不再计入，新窗口内 1 次成功不足以触发熔断
+	require.Equal(s.T(), service.CircuitTransitionNone, s.record(scope, start.Add(2*time.Minute), false, ""))
+	snapshots, err := s.cache.GetCircuitSnapshots(s.ctx, []string{scope})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.CircuitStateClosed, snapshots[scope].State(start.Add(2*time.Minute)))
+}
+
+func (s *UpstreamCircuitCacheSuite) TestHalfOpenProbe() {
+	scope := "account:3"
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		s.record(scope, now, true, "")
+	}
+
+	claimed, err := s.cache.ClaimCircuitProbe(s.ctx, scope, "req-1", now, time.Minute)
+	require.NoError(s.T(), err)
+	require.False(s.T(), claimed, "熔断期内不允许探测")
+
+	halfOpen := now.Add(31 * time.Second)
+	claimed, err = s.cache.ClaimCircuitProbe(s.ctx, scope, "req-1", halfOpen, time.Minute)
+	require.NoError(s.T(), err)
+	require.True(s.T(), claimed)
+	claimed, err = s.cache.ClaimCircuitProbe(s.ctx, scope, "req-2", halfOpen, time.Minute)
+	require.NoError(s.T(), err)
+	require.False(s.T(), claimed, "半开状态只允许一个探测请求")
+
+	// 非探测请求的结果被忽略
+	require.Equal(s.T(), service.CircuitTransitionNone, s.record(scope, halfOpen, false, "req-2"))
+	// 探测失败重新熔断
+	require.Equal(s.T(), service.CircuitTransitionReopened, s.record(scope, halfOpen, true, "req-1"))
+
+	later := halfOpen.Add(31 * time.Second)
+	claimed, err = s.cache.ClaimCircuitProbe(s.ctx, scope, "req-3", later, time.Minute)
+	require.NoError(s.T(), err)
+	require.True(s.T(), claimed)
+	require.Equal(s.T(), service.CircuitTransitionClosed, s.record(scope, later, false, "req-3"))
+
+	snapshots, err := s.cache.GetCircuitSnapshots(s.ctx, []string{scope})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.CircuitStateClosed, snapshots[scope].State(later))
+	require.Equal(s.T(), "api.example.com", snapshots[scope].Host, "恢复后保留最近的上游主机")
+}
+
+func TestUpstreamCircuitCacheSuite(t *testing.T) {
+	suite.Run(t, new(UpstreamCircuitCacheSuite))
+}
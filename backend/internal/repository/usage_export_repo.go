@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const usageExportJobColumns = `
+	id, status, format, filters, created_by, admin_scope, storage, object_key, file_name,
+	file_size, exported_rows, error_message, started_at, finished_at, expires_at, created_at, updated_at
+`
+
+type usageExportRepository struct {
+	sql sqlExecutor
+}
+
+func NewUsageExportRepository(sqlDB *sql.DB) service.UsageExportRepository {
+	return &usageExportRepository{sql: sqlDB}
+}
+
+func (r *usageExportRepository) CreateJob(ctx context.Context, job *service.UsageExportJob) error {
+	if job == nil {
+		return nil
+	}
+	filtersJSON, err := json.Marshal(job.Filters)
+	if err != nil {
+		return fmt.Errorf("marshal export filters: %w", err)
+	}
+	query := `
+		INSERT INTO usage_export_jobs (
+			status,
+			format,
+			filters,
+			created_by,
+			admin_scope,
+			storage
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	return scanSingleRow(ctx, r.sql, query,
+		[]any{job.Status, job.Format, filtersJSON, job.CreatedBy, job.AdminScope, job.Storage},
+		&job.ID, &job.CreatedAt, &job.UpdatedAt,
+	)
+}
+
+func (r *usageExportRepository) GetJob(ctx context.Context, id int64) (*service.UsageExportJob, error) {
+	query := "SELECT " + usageExportJobColumns + " FROM usage_export_jobs WHERE id = $1"
+	rows, err := r.sql.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrUsageExportNotFound
+	}
+	job, err := scanUsageExportJob(rows)
+	if err != nil {
+		return nil, err
+	}
+	return job, rows.Err()
+}
+
+func (r *usageExportRepository) ListJobs(ctx context.Context, filter service.UsageExportListFilter, params pagination.PaginationParams) ([]service.UsageExportJob, *pagination.PaginationResult, error) {
+	where := ""
+	args := make([]any, 0, 3)
+	if filter.CreatedBy != nil {
+		where = "WHERE created_by = $1"
+		args = append(args, *filter.CreatedBy)
+	}
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_export_jobs "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UsageExportJob{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM usage_export_jobs
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, usageExportJobColumns, where, len(args)+1, len(args)+2)
+	args = append(args, params.Limit(), params.Offset())
+	jobs, err := r.queryJobs(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return jobs, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usageExportRepository) CountActiveJobs(ctx context.Context, createdBy int64) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM usage_export_jobs WHERE created_by = $1 AND status IN ($2, $3)"
+	err := scanSingleRow(ctx, r.sql, query, []any{createdBy, service.UsageExportStatusPending, service.UsageExportStatusRunning}, &count)
+	return count, err
+}
+
+func (r *usageExportRepository) ClaimNextPendingJob(ctx context.Context, staleRunningAfterSeconds int64) (*service.UsageExportJob, error) {
+	if staleRunningAfterSeconds <= 0 {
+		staleRunningAfterSeconds = 3600
+	}
+	query := `
+		WITH next AS (
+			SELECT id
+			FROM usage_export_jobs
+			WHERE status = $1
+				OR (
+					status = $2
+					AND started_at IS NOT NULL
+					AND started_at < NOW() - ($3 * interval '1 second')
+				)
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE usage_export_jobs AS jobs
+		SET status = $4,
+			started_at = NOW(),
+			finished_at = NULL,
+			error_message = NULL,
+			exported_rows = 0,
+			updated_at = NOW()
+		FROM next
+		WHERE jobs.id = next.id
+		RETURNING jobs.id, jobs.status, jobs.format, jobs.filters, jobs.created_by, jobs.admin_scope, jobs.storage,
+			jobs.object_key, jobs.file_name, jobs.file_size, jobs.exported_rows, jobs.error_message,
+			jobs.started_at, jobs.finished_at, jobs.expires_at, jobs.created_at, jobs.updated_at
+	`
+	jobs, err := r.queryJobs(ctx, query,
+		service.UsageExportStatusPending,
+		service.UsageExportStatusRunning,
+		staleRunningAfterSeconds,
+		service.UsageExportStatusRunning,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+func (r *usageExportRepository) UpdateJobProgress(ctx context.Context, id int64, exportedRows int64) error {
+	query := `
+		UPDATE usage_export_jobs
+		SET exported_rows = $1,
+			updated_at = NOW()
+		WHERE id = $2
+	`
+	_, err := r.sql.ExecContext(ctx, query, exportedRows, id)
+	return err
+}
+
+func (r *usageExportRepository) CancelJob(ctx context.Context, id int64) (bool, error) {
+	query := `
+		UPDATE usage_export_jobs
+		SET status = $1,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2
+			AND status IN ($3, $4)
+		RETURNING id
+	`
+	var canceledID int64
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		service.UsageExportStatusCanceled,
+		id,
+		service.UsageExportStatusPending,
+		service.UsageExportStatusRunning,
+	}, &canceledID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *usageExportRepository) MarkJobSucceeded(ctx context.Context, id int64, result service.UsageExportResult) error {
+	query := `
+		UPDATE usage_export_jobs
+		SET status = $1,
+			object_key = $2,
+			file_name = $3,
+			file_size = $4,
+			exported_rows = $5,
+			expires_at = $6,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $7
+	`
+	_, err := r.sql.ExecContext(ctx, query,
+		service.UsageExportStatusSucceeded,
+		result.ObjectKey,
+		result.FileName,
+		result.FileSize,
+		result.ExportedRows,
+		result.ExpiresAt,
+		id,
+	)
+	return err
+}
+
+func (r *usageExportRepository) MarkJobFailed(ctx context.Context, id int64, exportedRows int64, errorMsg string) error {
+	query := `
+		UPDATE usage_export_jobs
+		SET status = $1,
+			exported_rows = $2,
+			error_message = $3,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $4
+	`
+	_, err := r.sql.ExecContext(ctx, query, service.UsageExportStatusFailed, exportedRows, errorMsg, id)
+	return err
+}
+
+func (r *usageExportRepository) ListExpiredJobs(ctx context.Context, now time.Time, limit int) ([]service.UsageExportJob, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM usage_export_jobs
+		WHERE status = $1
+			AND expires_at IS NOT NULL
+			AND expires_at <= $2
+		ORDER BY expires_at ASC
+		LIMIT $3
+	`, usageExportJobColumns)
+	return r.queryJobs(ctx, query, service.UsageExportStatusSucceeded, now, limit)
+}
+
+func (r *usageExportRepository) MarkJobExpired(ctx context.Context, id int64) error {
+	query := `
+		UPDATE usage_export_jobs
+		SET status = $1,
+			updated_at = NOW()
+		WHERE id = $2
+			AND status = $3
+	`
+	_, err := r.sql.ExecContext(ctx, query, service.UsageExportStatusExpired, id, service.UsageExportStatusSucceeded)
+	return err
+}
+
+func (r *usageExportRepository) queryJobs(ctx context.Context, query string, args ...any) ([]service.UsageExportJob, error) {
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	jobs := make([]service.UsageExportJob, 0)
+	for rows.Next() {
+		job, err := scanUsageExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func scanUsageExportJob(scanner interface{ Scan(...any) error }) (*service.UsageExportJob, error) {
+	var job service.UsageExportJob
+	var filtersJSON []byte
+	var errMsg sql.NullString
+	var startedAt sql.NullTime
+	var finishedAt sql.NullTime
+	var expiresAt sql.NullTime
+	if err := scanner.Scan(
+		&job.ID,
+		&job.Status,
+		&job.Format,
+		&filtersJSON,
+		&job.CreatedBy,
+		&job.AdminScope,
+		&job.Storage,
+		&job.ObjectKey,
+		&job.FileName,
+		&job.FileSize,
+		&job.ExportedRows,
+		&errMsg,
+		&startedAt,
+		&finishedAt,
+		&expiresAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(filtersJSON) > 0 {
+		if err := json.Unmarshal(filtersJSON, &job.Filters); err != nil {
+			return nil, fmt.Errorf("parse export filters: %w", err)
+		}
+	}
+	if errMsg.Valid {
+		job.ErrorMsg = &errMsg.String
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	if expiresAt.Valid {
+		job.ExpiresAt = &expiresAt.Time
+	}
+	return &job, nil
+}
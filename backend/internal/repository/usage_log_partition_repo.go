@@ -0,0 +1,458 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+const usageLogArchiveColumns = `
+	id, range_start, range_end, partition_name, status, format, storage, object_key, file_name,
+	file_size, row_count, checksum, error_message, archived_at, restored_at, restored_rows, created_at, updated_at
+`
+
+// usageLogImportBatchSize 单条 INSERT 的行数上限（每行 39 个参数，需低于 PostgreSQL 65535 参数限制）
+const usageLogImportBatchSize = 1000
+
+// usageLogsPartitionBoundRe 解析 pg_get_expr(relpartbound) 的输出，
+// 例如 FOR VALUES FROM ('2026-10-01 00:00:00+00') TO ('2026-11-01 00:00:00+00') 或 FROM (MINVALUE) TO (...)
+var usageLogsPartitionBoundRe = regexp.MustCompile(`FROM \((.+?)\) TO \((.+?)\)`)
+
+type usageLogPartitionRepository struct {
+	sql sqlExecutor
+}
+
+func NewUsageLogPartitionRepository(sqlDB *sql.DB) service.UsageLogPartitionRepository {
+	return &usageLogPartitionRepository{sql: sqlDB}
+}
+
+func (r *usageLogPartitionRepository) IsUsageLogsPartitioned(ctx context.Context) (bool, error) {
+	return isUsageLogsPartitioned(ctx, r.sql)
+}
+
+func (r *usageLogPartitionRepository) ListUsageLogPartitions(ctx context.Context) ([]service.UsageLogPartition, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT
+			c.relname,
+			pg_get_expr(c.relpartbound, c.oid),
+			GREATEST(c.reltuples, 0)::bigint,
+			pg_total_relation_size(c.oid)
+		FROM pg_inherits
+		JOIN pg_class c ON c.oid = pg_inherits.inhrelid
+		JOIN pg_class p ON p.oid = pg_inherits.inhparent
+		WHERE p.relname = 'usage_logs'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	partitions := make([]service.UsageLogPartition, 0)
+	for rows.Next() {
+		var name, bound string
+		var estimatedRows, sizeBytes int64
+		if err := rows.Scan(&name, &bound, &estimatedRows, &sizeBytes); err != nil {
+			return nil, err
+		}
+		start, end, ok := parseUsageLogsPartitionBound(bound)
+		if !ok {
+			// DEFAULT 分区或无法识别的边界不参与维护
+			continue
+		}
+		partitions = append(partitions, service.UsageLogPartition{
+			Name:          name,
+			RangeStart:    start,
+			RangeEnd:      end,
+			EstimatedRows: estimatedRows,
+			SizeBytes:     sizeBytes,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].RangeEnd.Before(partitions[j].RangeEnd)
+	})
+	return partitions, nil
+}
+
+func (r *usageLogPartitionRepository) EnsureUsageLogPartition(ctx context.Context, month time.Time) error {
+	return createUsageLogsMonthPartition(ctx, r.sql, month)
+}
+
+func (r *usageLogPartitionRepository) DropUsageLogPartition(ctx context.Context, name string) error {
+	if !strings.HasPrefix(name, "usage_logs_") {
+		return fmt.Errorf("refuse to drop non usage_logs partition: %s", name)
+	}
+	_, err := r.sql.ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(name))
+	return err
+}
+
+func (r *usageLogPartitionRepository) MinUsageLogCreatedAt(ctx context.Context, partition string) (*time.Time, error) {
+	var minAt sql.NullTime
+	query := "SELECT MIN(created_at) FROM " + pq.QuoteIdentifier(partition)
+	if err := scanSingleRow(ctx, r.sql, query, nil, &minAt); err != nil {
+		return nil, err
+	}
+	if !minAt.Valid {
+		return nil, nil
+	}
+	t := minAt.Time.UTC()
+	return &t, nil
+}
+
+func (r *usageLogPartitionRepository) DeleteUsageLogsRange(ctx context.Context, start, end time.Time) (int64, error) {
+	// 分区表上 ctid 不唯一，按 id 分批删除
+	var total int64
+	for {
+		res, err := r.sql.ExecContext(ctx, `
+			DELETE FROM usage_logs
+			WHERE created_at >= $1 AND created_at < $2
+				AND id IN (
+					SELECT id
+					FROM usage_logs
+					WHERE created_at >= $1 AND created_at < $2
+					LIMIT $3
+				)
+		`, start.UTC(), end.UTC(), usageLogsCleanupBatchSize)
+		if err != nil {
+			return total, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += affected
+		if affected < usageLogsCleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (r *usageLogPartitionRepository) ImportUsageLogs(ctx context.Context, logs []service.UsageLog) (int64, error) {
+	var inserted int64
+	for start := 0; start < len(logs); start += usageLogImportBatchSize {
+		end := start + usageLogImportBatchSize
+		if end > len(logs) {
+			end = len(logs)
+		}
+		preparedList := make([]usageLogInsertPrepared, 0, end-start)
+		for i := start; i < end; i++ {
+			preparedList = append(preparedList, prepareUsageLogInsert(&logs[i]))
+		}
+		query, args := buildUsageLogBestEffortInsertQuery(preparedList)
+		res, err := r.sql.ExecContext(ctx, query, args...)
+		if err != nil {
+			return inserted, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return inserted, err
+		}
+		inserted += affected
+	}
+	return inserted, nil
+}
+
+func (r *usageLogPartitionRepository) UpsertArchive(ctx context.Context, archive *service.UsageLogArchive) error {
+	if archive == nil {
+		return nil
+	}
+	query := `
+		INSERT INTO usage_log_archives (
+			range_start,
+			range_end,
+			partition_name,
+			status,
+			format,
+			storage,
+			object_key,
+			file_name,
+			file_size,
+			row_count,
+			checksum,
+			error_message,
+			archived_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (range_start) DO UPDATE SET
+			range_end = EXCLUDED.range_end,
+			partition_name = EXCLUDED.partition_name,
+			status = EXCLUDED.status,
+			format = EXCLUDED.format,
+			storage = EXCLUDED.storage,
+			object_key = EXCLUDED.object_key,
+			file_name = EXCLUDED.file_name,
+			file_size = EXCLUDED.file_size,
+			row_count = EXCLUDED.row_count,
+			checksum = EXCLUDED.checksum,
+			error_message = EXCLUDED.error_message,
+			archived_at = EXCLUDED.archived_at,
+			restored_at = NULL,
+			restored_rows = 0,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		archive.RangeStart.UTC(),
+		archive.RangeEnd.UTC(),
+		archive.PartitionName,
+		archive.Status,
+		archive.Format,
+		archive.Storage,
+		archive.ObjectKey,
+		archive.FileName,
+		archive.FileSize,
+		archive.RowCount,
+		archive.Checksum,
+		archive.ErrorMsg,
+		archive.ArchivedAt,
+	}, &archive.ID, &archive.CreatedAt, &archive.UpdatedAt)
+}
+
+func (r *usageLogPartitionRepository) GetArchive(ctx context.Context, id int64) (*service.UsageLogArchive, error) {
+	query := "SELECT " + usageLogArchiveColumns + " FROM usage_log_archives WHERE id = $1"
+	archives, err := r.queryArchives(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, service.ErrUsageLogArchiveNotFound
+	}
+	return &archives[0], nil
+}
+
+func (r *usageLogPartitionRepository) GetArchiveByRangeStart(ctx context.Context, rangeStart time.Time) (*service.UsageLogArchive, error) {
+	query := "SELECT " + usageLogArchiveColumns + " FROM usage_log_archives WHERE range_start = $1"
+	archives, err := r.queryArchives(ctx, query, rangeStart.UTC())
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, nil
+	}
+	return &archives[0], nil
+}
+
+func (r *usageLogPartitionRepository) ListArchives(ctx context.Context, params pagination.PaginationParams) ([]service.UsageLogArchive, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_log_archives", nil, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UsageLogArchive{}, paginationResultFromTotal(0, params), nil
+	}
+	query := "SELECT " + usageLogArchiveColumns + " FROM usage_log_archives ORDER BY range_start DESC LIMIT $1 OFFSET $2"
+	archives, err := r.queryArchives(ctx, query, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	return archives, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usageLogPartitionRepository) TransitionArchiveStatus(ctx context.Context, id int64, from, to string) (bool, error) {
+	query := `
+		UPDATE usage_log_archives
+		SET status = $1,
+			updated_at = NOW()
+		WHERE id = $2
+			AND status = $3
+		RETURNING id
+	`
+	var updatedID int64
+	err := scanSingleRow(ctx, r.sql, query, []any{to, id, from}, &updatedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *usageLogPartitionRepository) MarkArchiveRestored(ctx context.Context, id int64, restoredRows int64) error {
+	query := `
+		UPDATE usage_log_archives
+		SET status = $1,
+			restored_rows = $2,
+			restored_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.sql.ExecContext(ctx, query, service.UsageLogArchiveStatusRestored, restoredRows, id)
+	return err
+}
+
+func (r *usageLogPartitionRepository) queryArchives(ctx context.Context, query string, args ...any) ([]service.UsageLogArchive, error) {
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	archives := make([]service.UsageLogArchive, 0)
+	for rows.Next() {
+		var a service.UsageLogArchive
+		var errMsg sql.NullString
+		var archivedAt, restoredAt sql.NullTime
+		if err := rows.Scan(
+			&a.ID,
+			&a.RangeStart,
+			&a.RangeEnd,
+			&a.PartitionName,
+			&a.Status,
+			&a.Format,
+			&a.Storage,
+			&a.ObjectKey,
+			&a.FileName,
+			&a.FileSize,
+			&a.RowCount,
+			&a.Checksum,
+			&errMsg,
+			&archivedAt,
+			&restoredAt,
+			&a.RestoredRows,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if errMsg.Valid {
+			a.ErrorMsg = &errMsg.String
+		}
+		if archivedAt.Valid {
+			a.ArchivedAt = &archivedAt.Time
+		}
+		if restoredAt.Valid {
+			a.RestoredAt = &restoredAt.Time
+		}
+		archives = append(archives, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return archives, nil
+}
+
+func isUsageLogsPartitioned(ctx context.Context, sqlq sqlExecutor) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM pg_partitioned_table pt
+			JOIN pg_class c ON c.oid = pt.partrelid
+			WHERE c.relname = 'usage_logs'
+		)
+	`
+	var partitioned bool
+	if err := scanSingleRow(ctx, sqlq, query, nil, &partitioned); err != nil {
+		return false, err
+	}
+	return partitioned, nil
+}
+
+// createUsageLogsMonthPartition 创建 month 所在月份（UTC）的 usage_logs 分区及分区级唯一索引。
+// 分区表无法建立不含分区键的全局唯一索引，id 与 (request_id, api_key_id) 的唯一性在各分区内保证，
+// 跨分区的重复请求由写入时的 NOT EXISTS 检查拦截。
+// 分区与唯一索引在同一事务内创建，避免分区已可写入而唯一索引尚未建立的窗口。
+// 该范围已被其他分区（如迁移生成的 usage_logs_legacy）覆盖时视为已存在。
+func createUsageLogsMonthPartition(ctx context.Context, sqlq sqlExecutor, month time.Time) error {
+	if db, ok := sqlq.(*sql.DB); ok {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := createUsageLogsMonthPartitionInTx(ctx, tx, month); err != nil {
+			_ = tx.Rollback()
+			if isPartitionOverlapError(err) {
+				return nil
+			}
+			return err
+		}
+		return tx.Commit()
+	}
+	if err := createUsageLogsMonthPartitionInTx(ctx, sqlq, month); err != nil && !isPartitionOverlapError(err) {
+		return err
+	}
+	return nil
+}
+
+func createUsageLogsMonthPartitionInTx(ctx context.Context, sqlq sqlExecutor, month time.Time) error {
+	monthStart := truncateToMonthUTC(month)
+	nextMonth := monthStart.AddDate(0, 1, 0)
+	name := usageLogsPartitionName(monthStart)
+	for _, stmt := range []string{
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s PARTITION OF usage_logs FOR VALUES FROM (%s) TO (%s)",
+			pq.QuoteIdentifier(name),
+			pq.QuoteLiteral(monthStart.Format("2006-01-02 15:04:05")+"+00"),
+			pq.QuoteLiteral(nextMonth.Format("2006-01-02 15:04:05")+"+00"),
+		),
+		fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (id)",
+			pq.QuoteIdentifier(name+"_id_uidx"), pq.QuoteIdentifier(name)),
+		fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (request_id, api_key_id)",
+			pq.QuoteIdentifier(name+"_request_api_key_uidx"), pq.QuoteIdentifier(name)),
+	} {
+		if _, err := sqlq.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func usageLogsPartitionName(monthStart time.Time) string {
+	return fmt.Sprintf("usage_logs_%s", monthStart.UTC().Format("200601"))
+}
+
+// isPartitionOverlapError 判断是否为分区范围重叠错误（42P17 invalid_object_definition）
+func isPartitionOverlapError(err error) bool {
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "42P17" && strings.Contains(pgErr.Message, "overlap")
+	}
+	return false
+}
+
+// parseUsageLogsPartitionBound 解析 RANGE 分区边界；MINVALUE 下界返回 nil
+func parseUsageLogsPartitionBound(bound string) (*time.Time, time.Time, bool) {
+	m := usageLogsPartitionBoundRe.FindStringSubmatch(bound)
+	if m == nil {
+		return nil, time.Time{}, false
+	}
+	end, ok := parseUsageLogsPartitionBoundValue(m[2])
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	if strings.EqualFold(strings.TrimSpace(m[1]), "MINVALUE") {
+		return nil, end, true
+	}
+	start, ok := parseUsageLogsPartitionBoundValue(m[1])
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return &start, end, true
+}
+
+func parseUsageLogsPartitionBoundValue(raw string) (time.Time, bool) {
+	value := strings.Trim(strings.TrimSpace(raw), "'")
+	for _, layout := range []string{
+		"2006-01-02 15:04:05-07",
+		"2006-01-02 15:04:05-07:00",
+		"2006-01-02 15:04:05.999999-07",
+		"2006-01-02 15:04:05.999999-07:00",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,30 @@
+//go:build unit
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUsageLogsPartitionBound(t *testing.T) {
+	start, end, ok := parseUsageLogsPartitionBound("FOR VALUES FROM ('2026-03-01 08:00:00+08') TO ('2026-04-01 08:00:00+08')")
+	require.True(t, ok)
+	require.NotNil(t, start)
+	require.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), *start)
+	require.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), end)
+
+	start, end, ok = parseUsageLogsPartitionBound("FOR VALUES FROM (MINVALUE) TO ('2026-02-01 00:00:00+00')")
+	require.True(t, ok)
+	require.Nil(t, start)
+	require.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), end)
+
+	_, _, ok = parseUsageLogsPartitionBound("DEFAULT")
+	require.False(t, ok)
+}
+
+func TestUsageLogsPartitionName(t *testing.T) {
+	require.Equal(t, "usage_logs_202612", usageLogsPartitionName(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)))
+}
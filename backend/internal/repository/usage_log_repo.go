@@ -28,7 +28,7 @@ import (
 	gocache "github.com/patrickmn/go-cache"
 )
 
-const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, request_type, stream, openai_ws_mode, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, media_type, service_tier, reasoning_effort, inbound_endpoint, upstream_endpoint, cache_ttl_overridden, created_at"
+const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, request_type, stream, openai_ws_mode, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, media_type, service_tier, reasoning_effort, inbound_endpoint, upstream_endpoint, session_id, served_model, cache_ttl_overridden, created_at"
 
 var usageLogInsertArgTypes = [...]string{
 	"bigint",
@@ -67,10 +67,52 @@ var usageLogInsertArgTypes = [...]string{
 	"text",
 	"text",
 	"text",
+	"text",
+	"text",
 	"boolean",
 	"timestamptz",
 }
 
+// usage_logs 按月分区后，(request_id, api_key_id) 唯一索引只在单个分区内生效。
+// 写入前检查 created_at 前后 1 天内是否已有同一请求的记录，避免跨月重试产生重复行；
+// 时间窗口使探测只落在当前分区与相邻分区（运行时分区裁剪），开销不随分区数量增长。
+// 同一分区内的并发写入仍由分区唯一索引与 ON CONFLICT DO NOTHING 兜底。
+//
+// 已知竞态：同一请求的两次并发写入恰好跨月落在不同分区时，彼此看不到未提交的行，可能都写入成功。
+// 该情况仅影响明细行，计费幂等由不分区的 usage_billing_dedup 保证。
+const (
+	usageLogInputNotExistsSQL = `NOT EXISTS (
+		SELECT 1 FROM usage_logs dup
+		WHERE dup.request_id = input.request_id AND dup.api_key_id = input.api_key_id
+			AND dup.created_at >= input.created_at - INTERVAL '1 day'
+			AND dup.created_at < input.created_at + INTERVAL '1 day'
+	)`
+	// 单行写入中 $2 为 api_key_id，$4 为 request_id，$40 为 created_at
+	usageLogParamsNotExistsSQL = `NOT EXISTS (
+		SELECT 1 FROM usage_logs dup
+		WHERE dup.request_id = $4::text AND dup.api_key_id = $2::bigint
+			AND dup.created_at >= $40::timestamptz - INTERVAL '1 day'
+			AND dup.created_at < $40::timestamptz + INTERVAL '1 day'
+	)`
+	// usageLogExistingJoinSQL 批量写入中回查已存在记录，使用与去重探测相同的时间窗口
+	usageLogExistingJoinSQL = `LEFT JOIN usage_logs existing
+				ON existing.request_id = input.request_id
+				AND existing.api_key_id = input.api_key_id
+				AND existing.created_at >= input.created_at - INTERVAL '1 day'
+				AND existing.created_at < input.created_at + INTERVAL '1 day'`
+)
+
+// usageLogInsertSelectList 单行写入的 SELECT 列表（INSERT ... SELECT 需显式类型转换）
+var usageLogInsertSelectList = buildUsageLogInsertSelectList()
+
+func buildUsageLogInsertSelectList() string {
+	parts := make([]string, len(usageLogInsertArgTypes))
+	for i, typ := range usageLogInsertArgTypes {
+		parts[i] = "$" + strconv.Itoa(i+1) + "::" + typ
+	}
+	return strings.Join(parts, ", ")
+}
+
 // dateFormatWhitelist 将 granularity 参数映射为 PostgreSQL TO_CHAR 格式字符串，防止外部输入直接拼入 SQL
 var dateFormatWhitelist = map[string]string{
 	"hour":  "YYYY-MM-DD HH24:00",
@@ -91,6 +133,8 @@ type usageLogRepository struct {
 	client *dbent.Client
 	sql    sqlExecutor
 	db     *sql.DB
+	// read 仪表盘 / 统计 / 管理端列表等只读查询使用的连接（配置只读副本时为 DBReadRouter）
+	read sqlExecutor
 
 	createBatchOnce     sync.Once
 	createBatchCh       chan usageLogCreateRequest
@@ -161,8 +205,20 @@ const (
 	usageLogCreateStateCanceled
 )
 
-func NewUsageLogRepository(client *dbent.Client, sqlDB *sql.DB) service.UsageLogRepository {
-	return newUsageLogRepositoryWithSQL(client, sqlDB)
+func NewUsageLogRepository(client *dbent.Client, sqlDB *sql.DB, readRouter *DBReadRouter) service.UsageLogRepository {
+	repo := newUsageLogRepositoryWithSQL(client, sqlDB)
+	if readRouter != nil {
+		repo.read = readRouter
+	}
+	return repo
+}
+
+// reader 返回延迟不敏感的只读查询使用的连接；调度、限额、计费相关读取必须使用 r.sql
+func (r *usageLogRepository) reader() sqlExecutor {
+	if r.read != nil {
+		return r.read
+	}
+	return r.sql
 }
 
 func newUsageLogRepositoryWithSQL(client *dbent.Client, sqlq sqlExecutor) *usageLogRepository {
@@ -308,24 +364,25 @@ func (r *usageLogRepository) createSingle(ctx context.Context, sqlq sqlExecutor,
 			reasoning_effort,
 			inbound_endpoint,
 			upstream_endpoint,
+			session_id,
+			served_model,
 			cache_ttl_overridden,
 			created_at
-		) VALUES (
-			$1, $2, $3, $4, $5,
-			$6, $7,
-			$8, $9, $10, $11,
-			$12, $13,
-			$14, $15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38
 		)
-		ON CONFLICT (request_id, api_key_id) DO NOTHING
+		SELECT ` + usageLogInsertSelectList + `
+		WHERE ` + usageLogParamsNotExistsSQL + `
+		ON CONFLICT DO NOTHING
 		RETURNING id, created_at
 	`
 
 	if err := scanSingleRow(ctx, sqlq, query, prepared.args, &log.ID, &log.CreatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) && prepared.requestID != "" {
-			selectQuery := "SELECT id, created_at FROM usage_logs WHERE request_id = $1 AND api_key_id = $2"
-			if err := scanSingleRow(ctx, sqlq, selectQuery, []any{prepared.requestID, log.APIKeyID}, &log.ID, &log.CreatedAt); err != nil {
+			selectQuery := `SELECT id, created_at FROM usage_logs
+				WHERE request_id = $1 AND api_key_id = $2
+					AND created_at >= $3::timestamptz - INTERVAL '1 day'
+					AND created_at < $3::timestamptz + INTERVAL '1 day'
+				LIMIT 1`
+			if err := scanSingleRow(ctx, sqlq, selectQuery, []any{prepared.requestID, log.APIKeyID, prepared.createdAt}, &log.ID, &log.CreatedAt); err != nil {
 				return false, err
 			}
 			log.RateMultiplier = prepared.rateMultiplier
@@ -738,11 +795,13 @@ func buildUsageLogBatchInsertQuery(keys []string, preparedByKey map[string]usage
 			reasoning_effort,
 			inbound_endpoint,
 			upstream_endpoint,
+			session_id,
+			served_model,
 			cache_ttl_overridden,
 			created_at
 		) AS (VALUES `)
 
-	args := make([]any, 0, len(keys)*38)
+	args := make([]any, 0, len(keys)*40)
 	argPos := 1
 	for idx, key := range keys {
 		if idx > 0 {
@@ -807,6 +866,8 @@ func buildUsageLogBatchInsertQuery(keys []string, preparedByKey map[string]usage
 				reasoning_effort,
 				inbound_endpoint,
 				upstream_endpoint,
+				session_id,
+				served_model,
 				cache_ttl_overridden,
 				created_at
 			)
@@ -847,10 +908,13 @@ func buildUsageLogBatchInsertQuery(keys []string, preparedByKey map[string]usage
 				reasoning_effort,
 				inbound_endpoint,
 				upstream_endpoint,
+				session_id,
+				served_model,
 				cache_ttl_overridden,
 				created_at
 			FROM input
-			ON CONFLICT (request_id, api_key_id) DO NOTHING
+			WHERE ` + usageLogInputNotExistsSQL + `
+			ON CONFLICT DO NOTHING
 			RETURNING request_id, api_key_id, id, created_at
 		),
 		resolved AS (
@@ -865,9 +929,7 @@ func buildUsageLogBatchInsertQuery(keys []string, preparedByKey map[string]usage
 			LEFT JOIN inserted
 				ON inserted.request_id = input.request_id
 				AND inserted.api_key_id = input.api_key_id
-			LEFT JOIN usage_logs existing
-				ON existing.request_id = input.request_id
-				AND existing.api_key_id = input.api_key_id
+			` + usageLogExistingJoinSQL + `
 		)
 		SELECT COALESCE(
 			json_agg(
@@ -927,11 +989,13 @@ func buildUsageLogBestEffortInsertQuery(preparedList []usageLogInsertPrepared) (
 			reasoning_effort,
 			inbound_endpoint,
 			upstream_endpoint,
+			session_id,
+			served_model,
 			cache_ttl_overridden,
 			created_at
 		) AS (VALUES `)
 
-	args := make([]any, 0, len(preparedList)*38)
+	args := make([]any, 0, len(preparedList)*40)
 	argPos := 1
 	for idx, prepared := range preparedList {
 		if idx > 0 {
@@ -993,6 +1057,8 @@ func buildUsageLogBestEffortInsertQuery(preparedList []usageLogInsertPrepared) (
 			reasoning_effort,
 			inbound_endpoint,
 			upstream_endpoint,
+			session_id,
+			served_model,
 			cache_ttl_overridden,
 			created_at
 		)
@@ -1033,17 +1099,20 @@ func buildUsageLogBestEffortInsertQuery(preparedList []usageLogInsertPrepared) (
 			reasoning_effort,
 			inbound_endpoint,
 			upstream_endpoint,
+			session_id,
+			served_model,
 			cache_ttl_overridden,
 			created_at
 		FROM input
-		ON CONFLICT (request_id, api_key_id) DO NOTHING
+		WHERE ` + usageLogInputNotExistsSQL + `
+		ON CONFLICT DO NOTHING
 	`)
 
 	return query.String(), args
 }
 
 func execUsageLogInsertNoResult(ctx context.Context, sqlq sqlExecutor, prepared usageLogInsertPrepared) error {
-	_, err := sqlq.ExecContext(ctx, `
+	query := `
 		INSERT INTO usage_logs (
 			user_id,
 			api_key_id,
@@ -1081,18 +1150,16 @@ func execUsageLogInsertNoResult(ctx context.Context, sqlq sqlExecutor, prepared
 			reasoning_effort,
 			inbound_endpoint,
 			upstream_endpoint,
+			session_id,
+			served_model,
 			cache_ttl_overridden,
 			created_at
-		) VALUES (
-			$1, $2, $3, $4, $5,
-			$6, $7,
-			$8, $9, $10, $11,
-			$12, $13,
-			$14, $15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38
 		)
-		ON CONFLICT (request_id, api_key_id) DO NOTHING
-	`, prepared.args...)
+		SELECT ` + usageLogInsertSelectList + `
+		WHERE ` + usageLogParamsNotExistsSQL + `
+		ON CONFLICT DO NOTHING
+	`
+	_, err := sqlq.ExecContext(ctx, query, prepared.args...)
 	return err
 }
 
@@ -1121,6 +1188,8 @@ func prepareUsageLogInsert(log *service.UsageLog) usageLogInsertPrepared {
 	reasoningEffort := nullString(log.ReasoningEffort)
 	inboundEndpoint := nullString(log.InboundEndpoint)
 	upstreamEndpoint := nullString(log.UpstreamEndpoint)
+	sessionID := nullString(log.SessionID)
+	servedModel := nullString(log.ServedModel)
 
 	var requestIDArg any
 	if requestID != "" {
@@ -1169,6 +1238,8 @@ func prepareUsageLogInsert(log *service.UsageLog) usageLogInsertPrepared {
 			reasoningEffort,
 			inboundEndpoint,
 			upstreamEndpoint,
+			sessionID,
+			servedModel,
 			log.CacheTTLOverridden,
 			createdAt,
 		},
@@ -1228,11 +1299,11 @@ func (r *usageLogRepository) GetByID(ctx context.Context, id int64) (log *servic
 }
 
 func (r *usageLogRepository) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]service.UsageLog, *pagination.PaginationResult, error) {
-	return r.listUsageLogsWithPagination(ctx, "WHERE user_id = $1", []any{userID}, params)
+	return r.listUsageLogsWithPagination(ctx, r.sql, "WHERE user_id = $1", []any{userID}, params)
 }
 
 func (r *usageLogRepository) ListByAPIKey(ctx context.Context, apiKeyID int64, params pagination.PaginationParams) ([]service.UsageLog, *pagination.PaginationResult, error) {
-	return r.listUsageLogsWithPagination(ctx, "WHERE api_key_id = $1", []any{apiKeyID}, params)
+	return r.listUsageLogsWithPagination(ctx, r.sql, "WHERE api_key_id = $1", []any{apiKeyID}, params)
 }
 
 // UserStats 用户使用统计
@@ -1339,7 +1410,7 @@ func (r *usageLogRepository) fillDashboardEntityStats(ctx context.Context, stats
 	`
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		userStatsQuery,
 		[]any{todayUTC},
 		&stats.TotalUsers,
@@ -1357,7 +1428,7 @@ func (r *usageLogRepository) fillDashboardEntityStats(ctx context.Context, stats
 	`
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		apiKeyStatsQuery,
 		[]any{service.StatusActive},
 		&stats.TotalAPIKeys,
@@ -1378,7 +1449,7 @@ func (r *usageLogRepository) fillDashboardEntityStats(ctx context.Context, stats
 	`
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		accountStatsQuery,
 		[]any{service.StatusActive, service.StatusError, now, now},
 		&stats.TotalAccounts,
@@ -1409,7 +1480,7 @@ func (r *usageLogRepository) fillDashboardUsageStatsAggregated(ctx context.Conte
 	var totalDurationMs int64
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		totalStatsQuery,
 		nil,
 		&stats.TotalRequests,
@@ -1443,7 +1514,7 @@ func (r *usageLogRepository) fillDashboardUsageStatsAggregated(ctx context.Conte
 	`
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		todayStatsQuery,
 		[]any{todayUTC},
 		&stats.TodayRequests,
@@ -1467,7 +1538,7 @@ func (r *usageLogRepository) fillDashboardUsageStatsAggregated(ctx context.Conte
 		WHERE bucket_start = $1
 	`
 	hourStart := now.In(timezone.Location()).Truncate(time.Hour)
-	if err := scanSingleRow(ctx, r.sql, hourlyActiveQuery, []any{hourStart}, &stats.HourlyActiveUsers); err != nil {
+	if err := scanSingleRow(ctx, r.reader(), hourlyActiveQuery, []any{hourStart}, &stats.HourlyActiveUsers); err != nil {
 		if err != sql.ErrNoRows {
 			return err
 		}
@@ -1514,7 +1585,7 @@ func (r *usageLogRepository) fillDashboardUsageStatsFromUsageLogs(ctx context.Co
 	var totalDurationMs int64
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		combinedStatsQuery,
 		[]any{startUTC, endUTC, todayUTC, todayEnd},
 		&stats.TotalRequests,
@@ -1556,7 +1627,7 @@ func (r *usageLogRepository) fillDashboardUsageStatsFromUsageLogs(ctx context.Co
 			COUNT(DISTINCT CASE WHEN created_at >= $3::timestamptz AND created_at < $4::timestamptz THEN user_id END) AS hourly_active_users
 		FROM scoped
 	`
-	if err := scanSingleRow(ctx, r.sql, activeUsersQuery, []any{todayUTC, todayEnd, hourStart, hourEnd}, &stats.ActiveUsers, &stats.HourlyActiveUsers); err != nil {
+	if err := scanSingleRow(ctx, r.reader(), activeUsersQuery, []any{todayUTC, todayEnd, hourStart, hourEnd}, &stats.ActiveUsers, &stats.HourlyActiveUsers); err != nil {
 		return err
 	}
 
@@ -1564,12 +1635,12 @@ func (r *usageLogRepository) fillDashboardUsageStatsFromUsageLogs(ctx context.Co
 }
 
 func (r *usageLogRepository) ListByAccount(ctx context.Context, accountID int64, params pagination.PaginationParams) ([]service.UsageLog, *pagination.PaginationResult, error) {
-	return r.listUsageLogsWithPagination(ctx, "WHERE account_id = $1", []any{accountID}, params)
+	return r.listUsageLogsWithPagination(ctx, r.sql, "WHERE account_id = $1", []any{accountID}, params)
 }
 
 func (r *usageLogRepository) ListByUserAndTimeRange(ctx context.Context, userID int64, startTime, endTime time.Time) ([]service.UsageLog, *pagination.PaginationResult, error) {
 	query := "SELECT " + usageLogSelectColumns + " FROM usage_logs WHERE user_id = $1 AND created_at >= $2 AND created_at < $3 ORDER BY id DESC LIMIT 10000"
-	logs, err := r.queryUsageLogs(ctx, query, userID, startTime, endTime)
+	logs, err := r.queryUsageLogs(ctx, r.sql, query, userID, startTime, endTime)
 	return logs, nil, err
 }
 
@@ -1815,19 +1886,19 @@ func resolveUsageStatsTimezone() string {
 
 func (r *usageLogRepository) ListByAPIKeyAndTimeRange(ctx context.Context, apiKeyID int64, startTime, endTime time.Time) ([]service.UsageLog, *pagination.PaginationResult, error) {
 	query := "SELECT " + usageLogSelectColumns + " FROM usage_logs WHERE api_key_id = $1 AND created_at >= $2 AND created_at < $3 ORDER BY id DESC LIMIT 10000"
-	logs, err := r.queryUsageLogs(ctx, query, apiKeyID, startTime, endTime)
+	logs, err := r.queryUsageLogs(ctx, r.sql, query, apiKeyID, startTime, endTime)
 	return logs, nil, err
 }
 
 func (r *usageLogRepository) ListByAccountAndTimeRange(ctx context.Context, accountID int64, startTime, endTime time.Time) ([]service.UsageLog, *pagination.PaginationResult, error) {
 	query := "SELECT " + usageLogSelectColumns + " FROM usage_logs WHERE account_id = $1 AND created_at >= $2 AND created_at < $3 ORDER BY id DESC LIMIT 10000"
-	logs, err := r.queryUsageLogs(ctx, query, accountID, startTime, endTime)
+	logs, err := r.queryUsageLogs(ctx, r.sql, query, accountID, startTime, endTime)
 	return logs, nil, err
 }
 
 func (r *usageLogRepository) ListByModelAndTimeRange(ctx context.Context, modelName string, startTime, endTime time.Time) ([]service.UsageLog, *pagination.PaginationResult, error) {
 	query := "SELECT " + usageLogSelectColumns + " FROM usage_logs WHERE model = $1 AND created_at >= $2 AND created_at < $3 ORDER BY id DESC LIMIT 10000"
-	logs, err := r.queryUsageLogs(ctx, query, modelName, startTime, endTime)
+	logs, err := r.queryUsageLogs(ctx, r.sql, query, modelName, startTime, endTime)
 	return logs, nil, err
 }
 
@@ -2049,7 +2120,7 @@ func (r *usageLogRepository) GetAPIKeyUsageTrend(ctx context.Context, startTime,
 		ORDER BY date ASC, tokens DESC
 	`, dateFormat)
 
-	rows, err := r.sql.QueryContext(ctx, query, startTime, endTime, limit, startTime, endTime)
+	rows, err := r.reader().QueryContext(ctx, query, startTime, endTime, limit, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
@@ -2107,7 +2178,7 @@ func (r *usageLogRepository) GetUserUsageTrend(ctx context.Context, startTime, e
 		ORDER BY date ASC, tokens DESC
 	`, dateFormat)
 
-	rows, err := r.sql.QueryContext(ctx, query, startTime, endTime, limit, startTime, endTime)
+	rows, err := r.reader().QueryContext(ctx, query, startTime, endTime, limit, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
@@ -2181,7 +2252,7 @@ func (r *usageLogRepository) GetUserSpendingRanking(ctx context.Context, startTi
 		ORDER BY actual_cost DESC, tokens DESC, user_id ASC
 	`
 
-	rows, err := r.sql.QueryContext(ctx, query, startTime, endTime, limit)
+	rows, err := r.reader().QueryContext(ctx, query, startTime, endTime, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -2546,9 +2617,9 @@ func (r *usageLogRepository) ListWithFilters(ctx context.Context, params paginat
 		err  error
 	)
 	if shouldUseFastUsageLogTotal(filters) {
-		logs, page, err = r.listUsageLogsWithFastPagination(ctx, whereClause, args, params)
+		logs, page, err = r.listUsageLogsWithFastPagination(ctx, r.reader(), whereClause, args, params)
 	} else {
-		logs, page, err = r.listUsageLogsWithPagination(ctx, whereClause, args, params)
+		logs, page, err = r.listUsageLogsWithPagination(ctx, r.reader(), whereClause, args, params)
 	}
 	if err != nil {
 		return nil, nil, err
@@ -2568,6 +2639,42 @@ func shouldUseFastUsageLogTotal(filters UsageLogFilters) bool {
 	return filters.UserID == 0 && filters.APIKeyID == 0 && filters.AccountID == 0
 }
 
+// ListUsageLogsAfterID 按 id 升序 keyset 分页读取使用记录（导出任务使用，避免深分页 OFFSET）。
+// 时间范围为左闭右开 [StartTime, EndTime)。
+func (r *usageLogRepository) ListUsageLogsAfterID(ctx context.Context, filters service.UsageExportFilters, afterID int64, limit int) ([]service.UsageLog, error) {
+	if filters.StartTime.IsZero() || filters.EndTime.IsZero() {
+		return nil, fmt.Errorf("export filters missing time range")
+	}
+	if limit <= 0 {
+		limit = 1000
+	}
+	conditions := []string{"created_at >= $1", "created_at < $2", "id > $3"}
+	args := []any{filters.StartTime, filters.EndTime, afterID}
+	if filters.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, *filters.UserID)
+	}
+	if filters.APIKeyID != nil {
+		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)+1))
+		args = append(args, *filters.APIKeyID)
+	}
+	if filters.AccountID != nil {
+		conditions = append(conditions, fmt.Sprintf("account_id = $%d", len(args)+1))
+		args = append(args, *filters.AccountID)
+	}
+	if filters.GroupID != nil {
+		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)+1))
+		args = append(args, *filters.GroupID)
+	}
+	if filters.Model != nil && *filters.Model != "" {
+		conditions = append(conditions, fmt.Sprintf("model = $%d", len(args)+1))
+		args = append(args, *filters.Model)
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf("SELECT %s FROM usage_logs %s ORDER BY id ASC LIMIT $%d", usageLogSelectColumns, buildWhere(conditions), len(args))
+	return r.queryUsageLogs(ctx, r.sql, query, args...)
+}
+
 // UsageStats represents usage statistics
 type UsageStats = usagestats.UsageStats
 
@@ -2625,7 +2732,7 @@ func (r *usageLogRepository) GetBatchUserUsageStats(ctx context.Context, userIDs
 		GROUP BY user_id
 	`
 	today := timezone.Today()
-	rows, err := r.sql.QueryContext(ctx, query, pq.Array(normalizedUserIDs), startTime, endTime, today)
+	rows, err := r.reader().QueryContext(ctx, query, pq.Array(normalizedUserIDs), startTime, endTime, today)
 	if err != nil {
 		return nil, err
 	}
@@ -2687,7 +2794,7 @@ func (r *usageLogRepository) GetBatchAPIKeyUsageStats(ctx context.Context, apiKe
 		GROUP BY api_key_id
 	`
 	today := timezone.Today()
-	rows, err := r.sql.QueryContext(ctx, query, pq.Array(normalizedAPIKeyIDs), startTime, endTime, today)
+	rows, err := r.reader().QueryContext(ctx, query, pq.Array(normalizedAPIKeyIDs), startTime, endTime, today)
 	if err != nil {
 		return nil, err
 	}
@@ -2768,7 +2875,7 @@ func (r *usageLogRepository) GetUsageTrendWithFilters(ctx context.Context, start
 	}
 	query += " GROUP BY date ORDER BY date ASC"
 
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+	rows, err := r.reader().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2844,7 +2951,7 @@ func (r *usageLogRepository) getUsageTrendFromAggregates(ctx context.Context, st
 		return nil, nil
 	}
 
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+	rows, err := r.reader().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2909,7 +3016,7 @@ func (r *usageLogRepository) GetModelStatsWithFilters(ctx context.Context, start
 	}
 	query += " GROUP BY model ORDER BY total_tokens DESC"
 
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+	rows, err := r.reader().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -2968,7 +3075,7 @@ func (r *usageLogRepository) GetGroupStatsWithFilters(ctx context.Context, start
 	}
 	query += " GROUP BY ul.group_id, g.name ORDER BY total_tokens DESC"
 
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+	rows, err := r.reader().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -3035,7 +3142,7 @@ func (r *usageLogRepository) GetUserBreakdownStats(ctx context.Context, startTim
 		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
 
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+	rows, err := r.reader().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -3083,7 +3190,7 @@ func (r *usageLogRepository) GetAllGroupUsageSummary(ctx context.Context, todayS
 		GROUP BY g.id
 	`
 
-	rows, err := r.sql.QueryContext(ctx, query, todayStart)
+	rows, err := r.reader().QueryContext(ctx, query, todayStart)
 	if err != nil {
 		return nil, err
 	}
@@ -3132,7 +3239,7 @@ func (r *usageLogRepository) GetGlobalStats(ctx context.Context, startTime, endT
 	stats := &UsageStats{}
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		query,
 		[]any{startTime, endTime},
 		&stats.TotalRequests,
@@ -3206,7 +3313,7 @@ func (r *usageLogRepository) GetStatsWithFilters(ctx context.Context, filters Us
 	var totalAccountCost float64
 	if err := scanSingleRow(
 		ctx,
-		r.sql,
+		r.reader(),
 		query,
 		args,
 		&stats.TotalRequests,
@@ -3313,7 +3420,7 @@ func (r *usageLogRepository) getEndpointStatsByColumnWithFilters(ctx context.Con
 	}
 	query += " GROUP BY endpoint ORDER BY requests DESC"
 
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+	rows, err := r.reader().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -3387,7 +3494,7 @@ func (r *usageLogRepository) getEndpointPathStatsWithFilters(ctx context.Context
 	}
 	query += " GROUP BY endpoint ORDER BY requests DESC"
 
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+	rows, err := r.reader().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -3443,7 +3550,7 @@ func (r *usageLogRepository) GetAccountUsageStats(ctx context.Context, accountID
 		ORDER BY date ASC
 	`
 
-	rows, err := r.sql.QueryContext(ctx, query, accountID, startTime, endTime)
+	rows, err := r.reader().QueryContext(ctx, query, accountID, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
@@ -3509,7 +3616,7 @@ func (r *usageLogRepository) GetAccountUsageStats(ctx context.Context, accountID
 
 	avgQuery := "SELECT COALESCE(AVG(duration_ms), 0) as avg_duration_ms FROM usage_logs WHERE account_id = $1 AND created_at >= $2 AND created_at < $3"
 	var avgDuration float64
-	if err := scanSingleRow(ctx, r.sql, avgQuery, []any{accountID, startTime, endTime}, &avgDuration); err != nil {
+	if err := scanSingleRow(ctx, r.reader(), avgQuery, []any{accountID, startTime, endTime}, &avgDuration); err != nil {
 		return nil, err
 	}
 
@@ -3605,10 +3712,10 @@ func (r *usageLogRepository) GetAccountUsageStats(ctx context.Context, accountID
 	return resp, nil
 }
 
-func (r *usageLogRepository) listUsageLogsWithPagination(ctx context.Context, whereClause string, args []any, params pagination.PaginationParams) ([]service.UsageLog, *pagination.PaginationResult, error) {
+func (r *usageLogRepository) listUsageLogsWithPagination(ctx context.Context, sqlq sqlExecutor, whereClause string, args []any, params pagination.PaginationParams) ([]service.UsageLog, *pagination.PaginationResult, error) {
 	countQuery := "SELECT COUNT(*) FROM usage_logs " + whereClause
 	var total int64
-	if err := scanSingleRow(ctx, r.sql, countQuery, args, &total); err != nil {
+	if err := scanSingleRow(ctx, sqlq, countQuery, args, &total); err != nil {
 		return nil, nil, err
 	}
 
@@ -3616,14 +3723,14 @@ func (r *usageLogRepository) listUsageLogsWithPagination(ctx context.Context, wh
 	offsetPos := len(args) + 2
 	listArgs := append(append([]any{}, args...), params.Limit(), params.Offset())
 	query := fmt.Sprintf("SELECT %s FROM usage_logs %s ORDER BY id DESC LIMIT $%d OFFSET $%d", usageLogSelectColumns, whereClause, limitPos, offsetPos)
-	logs, err := r.queryUsageLogs(ctx, query, listArgs...)
+	logs, err := r.queryUsageLogs(ctx, sqlq, query, listArgs...)
 	if err != nil {
 		return nil, nil, err
 	}
 	return logs, paginationResultFromTotal(total, params), nil
 }
 
-func (r *usageLogRepository) listUsageLogsWithFastPagination(ctx context.Context, whereClause string, args []any, params pagination.PaginationParams) ([]service.UsageLog, *pagination.PaginationResult, error) {
+func (r *usageLogRepository) listUsageLogsWithFastPagination(ctx context.Context, sqlq sqlExecutor, whereClause string, args []any, params pagination.PaginationParams) ([]service.UsageLog, *pagination.PaginationResult, error) {
 	limit := params.Limit()
 	offset := params.Offset()
 
@@ -3632,7 +3739,7 @@ func (r *usageLogRepository) listUsageLogsWithFastPagination(ctx context.Context
 	listArgs := append(append([]any{}, args...), limit+1, offset)
 	query := fmt.Sprintf("SELECT %s FROM usage_logs %s ORDER BY id DESC LIMIT $%d OFFSET $%d", usageLogSelectColumns, whereClause, limitPos, offsetPos)
 
-	logs, err := r.queryUsageLogs(ctx, query, listArgs...)
+	logs, err := r.queryUsageLogs(ctx, sqlq, query, listArgs...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -3652,8 +3759,8 @@ func (r *usageLogRepository) listUsageLogsWithFastPagination(ctx context.Context
 	return logs, paginationResultFromTotal(total, params), nil
 }
 
-func (r *usageLogRepository) queryUsageLogs(ctx context.Context, query string, args ...any) (logs []service.UsageLog, err error) {
-	rows, err := r.sql.QueryContext(ctx, query, args...)
+func (r *usageLogRepository) queryUsageLogs(ctx context.Context, sqlq sqlExecutor, query string, args ...any) (logs []service.UsageLog, err error) {
+	rows, err := sqlq.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -3885,6 +3992,8 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		reasoningEffort       sql.NullString
 		inboundEndpoint       sql.NullString
 		upstreamEndpoint      sql.NullString
+		sessionID             sql.NullString
+		servedModel           sql.NullString
 		cacheTTLOverridden    bool
 		createdAt             time.Time
 	)
@@ -3927,6 +4036,8 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		&reasoningEffort,
 		&inboundEndpoint,
 		&upstreamEndpoint,
+		&sessionID,
+		&servedModel,
 		&cacheTTLOverridden,
 		&createdAt,
 	); err != nil {
@@ -4008,6 +4119,12 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 	if upstreamEndpoint.Valid {
 		log.UpstreamEndpoint = &upstreamEndpoint.String
 	}
+	if sessionID.Valid {
+		log.SessionID = &sessionID.String
+	}
+	if servedModel.Valid {
+		log.ServedModel = &servedModel.String
+	}
 
 	return log, nil
 }
@@ -87,6 +87,29 @@ func (s *UsageLogRepoSuite) TestCreate() {
 	s.Require().NotZero(log.ID)
 }
 
+func (s *UsageLogRepoSuite) TestCreate_DuplicateRequestIDAcrossPartitions() {
+	user := mustCreateUser(s.T(), s.client, &service.User{Email: "dup-partition@test.com"})
+	apiKey := mustCreateApiKey(s.T(), s.client, &service.APIKey{UserID: user.ID, Key: "sk-dup-partition", Name: "k"})
+	account := mustCreateAccount(s.T(), s.client, &service.Account{Name: "acc-dup-partition"})
+
+	now := time.Now().UTC()
+	first := &service.UsageLog{UserID: user.ID, APIKeyID: apiKey.ID, AccountID: account.ID, RequestID: "req-dup-partition", Model: "claude-3", CreatedAt: now}
+	inserted, err := s.repo.Create(s.ctx, first)
+	s.Require().NoError(err, "Create first")
+	s.Require().True(inserted)
+
+	// 下个月的重试落在另一个分区，分区唯一索引无法拦截
+	retry := &service.UsageLog{UserID: user.ID, APIKeyID: apiKey.ID, AccountID: account.ID, RequestID: "req-dup-partition", Model: "claude-3", CreatedAt: now.AddDate(0, 1, 0)}
+	inserted, err = s.repo.Create(s.ctx, retry)
+	s.Require().NoError(err, "Create retry")
+	s.Require().False(inserted)
+	s.Require().Equal(first.ID, retry.ID)
+
+	var count int
+	s.Require().NoError(scanSingleRow(s.ctx, s.tx, "SELECT COUNT(*) FROM usage_logs WHERE api_key_id = $1", []any{apiKey.ID}, &count))
+	s.Require().Equal(1, count)
+}
+
 func TestUsageLogRepositoryCreate_BatchPathConcurrent(t *testing.T) {
 	ctx := context.Background()
 	client := testEntClient(t)
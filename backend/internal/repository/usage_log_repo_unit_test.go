@@ -62,6 +62,14 @@ func TestBuildUsageLogBatchInsertQuery_UsesConflictDoNothing(t *testing.T) {
 		usageLogBatchKey(log.RequestID, log.APIKeyID): prepared,
 	})
 
-	require.Contains(t, query, "ON CONFLICT (request_id, api_key_id) DO NOTHING")
+	require.Contains(t, query, "ON CONFLICT DO NOTHING")
+	require.Contains(t, query, usageLogInputNotExistsSQL, "分区唯一索引不跨分区，需显式去重")
+	require.Contains(t, usageLogInputNotExistsSQL, "dup.created_at >= input.created_at - INTERVAL '1 day'", "去重探测限定在相邻分区的时间窗口内")
+	require.Contains(t, usageLogParamsNotExistsSQL, "dup.created_at < $40::timestamptz + INTERVAL '1 day'")
 	require.NotContains(t, strings.ToUpper(query), "DO UPDATE")
 }
+
+func TestUsageLogInsertSelectList_MatchesArgTypes(t *testing.T) {
+	require.True(t, strings.HasPrefix(usageLogInsertSelectList, "$1::bigint, $2::bigint, $3::bigint, $4::text,"))
+	require.True(t, strings.HasSuffix(usageLogInsertSelectList, "$40::timestamptz"))
+}
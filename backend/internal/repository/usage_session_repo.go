@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+// usageSessionAggregateSelect 聚合 scoped 子查询中的请求行。
+// account_switch 由窗口函数在 scoped 中按会话内时间顺序计算。
+const usageSessionAggregateSelect = `
+	SELECT
+		session_id,
+		MIN(user_id) AS user_id,
+		(ARRAY_AGG(api_key_id ORDER BY created_at, id))[1] AS api_key_id,
+		COUNT(*) AS turns,
+		COALESCE(SUM(input_tokens), 0) AS input_tokens,
+		COALESCE(SUM(output_tokens), 0) AS output_tokens,
+		COALESCE(SUM(cache_creation_tokens), 0) AS cache_creation_tokens,
+		COALESCE(SUM(cache_read_tokens), 0) AS cache_read_tokens,
+		COALESCE(SUM(total_cost), 0) AS total_cost,
+		COALESCE(SUM(actual_cost), 0) AS actual_cost,
+		ARRAY_AGG(DISTINCT model ORDER BY model) AS models,
+		COALESCE(SUM(account_switch), 0) AS account_switches,
+		COUNT(DISTINCT account_id) AS account_count,
+		MIN(started_at) AS started_at,
+		MAX(created_at) AS last_active_at
+	FROM scoped
+	GROUP BY session_id
+`
+
+// usageSessionScopedColumns 为每个请求附加开始时间与账号切换标记
+const usageSessionScopedColumns = `
+	session_id, user_id, api_key_id, account_id, model,
+	input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens,
+	total_cost, actual_cost, id, created_at,
+	created_at - COALESCE(duration_ms, 0) * INTERVAL '1 millisecond' AS started_at,
+	CASE
+		WHEN LAG(account_id) OVER w IS NOT NULL AND LAG(account_id) OVER w <> account_id THEN 1
+		ELSE 0
+	END AS account_switch
+`
+
+var usageSessionOrderBy = map[string]string{
+	service.UsageSessionSortLastActive: "last_active_at DESC, session_id",
+	service.UsageSessionSortStartedAt:  "started_at DESC, session_id",
+	service.UsageSessionSortActualCost: "actual_cost DESC, last_active_at DESC, session_id",
+	service.UsageSessionSortTotalCost:  "total_cost DESC, last_active_at DESC, session_id",
+	service.UsageSessionSortTurns:      "turns DESC, last_active_at DESC, session_id",
+}
+
+type usageSessionRepository struct {
+	sql sqlExecutor
+}
+
+func NewUsageSessionRepository(sqlDB *sql.DB) service.UsageSessionRepository {
+	return &usageSessionRepository{sql: sqlDB}
+}
+
+func (r *usageSessionRepository) ListSessions(ctx context.Context, filters service.UsageSessionFilters, params pagination.PaginationParams) ([]service.UsageSession, *pagination.PaginationResult, error) {
+	where, having, args := buildUsageSessionConditions(filters)
+
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*) FROM (
+			SELECT session_id FROM usage_logs
+			WHERE %s
+			GROUP BY session_id
+			%s
+		) s
+	`, where, having)
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, countQuery, args, &total); err != nil {
+		return nil, nil, err
+	}
+
+	orderBy, ok := usageSessionOrderBy[filters.SortBy]
+	if !ok {
+		orderBy = usageSessionOrderBy[service.UsageSessionSortLastActive]
+	}
+	limitPos := len(args) + 1
+	offsetPos := len(args) + 2
+	listArgs := append(append([]any{}, args...), params.Limit(), params.Offset())
+	query := fmt.Sprintf(`
+		WITH matched AS (
+			SELECT session_id FROM usage_logs
+			WHERE %s
+			GROUP BY session_id
+			%s
+		),
+		scoped AS (
+			SELECT %s
+			FROM usage_logs
+			WHERE session_id IN (SELECT session_id FROM matched) AND %s
+			WINDOW w AS (PARTITION BY session_id ORDER BY created_at, id)
+		)
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, where, having, usageSessionScopedColumns, where, usageSessionAggregateSelect, orderBy, limitPos, offsetPos)
+
+	sessions, err := r.querySessions(ctx, query, listArgs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sessions, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usageSessionRepository) GetSession(ctx context.Context, sessionID string, userID *int64) (*service.UsageSession, error) {
+	where, args := usageSessionDetailConditions(sessionID, userID)
+	query := fmt.Sprintf(`
+		WITH scoped AS (
+			SELECT %s
+			FROM usage_logs
+			WHERE %s
+			WINDOW w AS (PARTITION BY session_id ORDER BY created_at, id)
+		)
+		%s
+	`, usageSessionScopedColumns, where, usageSessionAggregateSelect)
+
+	sessions, err := r.querySessions(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, service.ErrUsageSessionNotFound
+	}
+	return &sessions[0], nil
+}
+
+func (r *usageSessionRepository) ListSessionLogs(ctx context.Context, sessionID string, userID *int64, params pagination.PaginationParams) (logs []service.UsageLog, result *pagination.PaginationResult, err error) {
+	where, args := usageSessionDetailConditions(sessionID, userID)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_logs WHERE "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+
+	limitPos := len(args) + 1
+	offsetPos := len(args) + 2
+	listArgs := append(append([]any{}, args...), params.Limit(), params.Offset())
+	query := fmt.Sprintf("SELECT %s FROM usage_logs WHERE %s ORDER BY created_at ASC, id ASC LIMIT $%d OFFSET $%d", usageLogSelectColumns, where, limitPos, offsetPos)
+	rows, err := r.sql.QueryContext(ctx, query, listArgs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			logs = nil
+			result = nil
+		}
+	}()
+
+	logs = make([]service.UsageLog, 0)
+	for rows.Next() {
+		log, scanErr := scanUsageLog(rows)
+		if scanErr != nil {
+			return nil, nil, scanErr
+		}
+		logs = append(logs, *log)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return logs, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usageSessionRepository) querySessions(ctx context.Context, query string, args ...any) (sessions []service.UsageSession, err error) {
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			sessions = nil
+		}
+	}()
+
+	sessions = make([]service.UsageSession, 0)
+	for rows.Next() {
+		var s service.UsageSession
+		var models []string
+		if err = rows.Scan(
+			&s.SessionID,
+			&s.UserID,
+			&s.APIKeyID,
+			&s.Turns,
+			&s.InputTokens,
+			&s.OutputTokens,
+			&s.CacheCreationTokens,
+			&s.CacheReadTokens,
+			&s.TotalCost,
+			&s.ActualCost,
+			pq.Array(&models),
+			&s.AccountSwitches,
+			&s.AccountCount,
+			&s.StartedAt,
+			&s.LastActiveAt,
+		); err != nil {
+			return nil, err
+		}
+		if models == nil {
+			models = []string{}
+		}
+		s.Models = models
+		s.DurationMs = s.LastActiveAt.Sub(s.StartedAt).Milliseconds()
+		sessions = append(sessions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// buildUsageSessionConditions 构建会话列表的行级 WHERE 与会话级 HAVING 条件。
+// 账号与模型条件放在 HAVING 中，避免截断会话内其他请求的聚合。
+func buildUsageSessionConditions(filters service.UsageSessionFilters) (string, string, []any) {
+	conditions := []string{"session_id IS NOT NULL"}
+	args := make([]any, 0, 6)
+	if filters.StartTime != nil {
+		args = append(args, *filters.StartTime)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filters.EndTime != nil {
+		args = append(args, *filters.EndTime)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if filters.UserID != nil {
+		args = append(args, *filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filters.APIKeyID != nil {
+		args = append(args, *filters.APIKeyID)
+		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)))
+	}
+	if filters.GroupID != nil {
+		args = append(args, *filters.GroupID)
+		conditions = append(conditions, fmt.Sprintf("group_id = $%d", len(args)))
+	}
+
+	havingConditions := make([]string, 0, 2)
+	if filters.AccountID != nil {
+		args = append(args, *filters.AccountID)
+		havingConditions = append(havingConditions, fmt.Sprintf("BOOL_OR(account_id = $%d)", len(args)))
+	}
+	if filters.Model != "" {
+		args = append(args, filters.Model)
+		havingConditions = append(havingConditions, fmt.Sprintf("BOOL_OR(model = $%d)", len(args)))
+	}
+	having := ""
+	if len(havingConditions) > 0 {
+		having = "HAVING " + strings.Join(havingConditions, " AND ")
+	}
+	return strings.Join(conditions, " AND "), having, args
+}
+
+func usageSessionDetailConditions(sessionID string, userID *int64) (string, []any) {
+	where := "session_id = $1"
+	args := []any{sessionID}
+	if userID != nil {
+		args = append(args, *userID)
+		where += " AND user_id = $2"
+	}
+	return where, args
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type userNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewUserNotificationRepository(db *sql.DB) service.UserNotificationRepository {
+	return &userNotificationRepository{db: db}
+}
+
+func (r *userNotificationRepository) GetPreference(ctx context.Context, userID int64) (*service.UserNotificationPreference, error) {
+	pref := &service.UserNotificationPreference{UserID: userID}
+	var threshold sql.NullFloat64
+	err := scanSingleRow(ctx, r.db, `
+		SELECT email_enabled, webhook_url, webhook_secret, balance_threshold,
+			subscription_usage_alerts, subscription_expiry_days, api_key_quota_percent, api_key_expiry_days, locale, updated_at
+		FROM user_notification_preferences
+		WHERE user_id = $1
+	`, []any{userID},
+		&pref.EmailEnabled, &pref.WebhookURL, &pref.WebhookSecret, &threshold,
+		&pref.SubscriptionUsageAlerts, &pref.SubscriptionExpiryDays, &pref.APIKeyQuotaPercent, &pref.APIKeyExpiryDays, &pref.Locale, &pref.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if threshold.Valid {
+		v := threshold.Float64
+		pref.BalanceThreshold = &v
+	}
+	return pref, nil
+}
+
+func (r *userNotificationRepository) UpsertPreference(ctx context.Context, pref *service.UserNotificationPreference) error {
+	if pref == nil {
+		return nil
+	}
+	var threshold any
+	if pref.BalanceThreshold != nil {
+		threshold = *pref.BalanceThreshold
+	}
+	return scanSingleRow(ctx, r.db, `
+		INSERT INTO user_notification_preferences (
+			user_id, email_enabled, webhook_url, webhook_secret, balance_threshold,
+			subscription_usage_alerts, subscription_expiry_days, api_key_quota_percent, api_key_expiry_days, locale,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = EXCLUDED.email_enabled,
+			webhook_url = EXCLUDED.webhook_url,
+			webhook_secret = EXCLUDED.webhook_secret,
+			balance_threshold = EXCLUDED.balance_threshold,
+			subscription_usage_alerts = EXCLUDED.subscription_usage_alerts,
+			subscription_expiry_days = EXCLUDED.subscription_expiry_days,
+			api_key_quota_percent = EXCLUDED.api_key_quota_percent,
+			api_key_expiry_days = EXCLUDED.api_key_expiry_days,
+			locale = EXCLUDED.locale,
+			updated_at = NOW()
+		RETURNING updated_at
+	`, []any{
+		pref.UserID,
+		pref.EmailEnabled,
+		pref.WebhookURL,
+		pref.WebhookSecret,
+		threshold,
+		pref.SubscriptionUsageAlerts,
+		pref.SubscriptionExpiryDays,
+		pref.APIKeyQuotaPercent,
+		pref.APIKeyExpiryDays,
+		pref.Locale,
+	}, &pref.UpdatedAt)
+}
+
+func (r *userNotificationRepository) CreateEventIfAbsent(ctx context.Context, event *service.UserNotificationEvent) (bool, error) {
+	if event == nil {
+		return false, nil
+	}
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return false, fmt.Errorf("marshal notification payload: %w", err)
+	}
+	err = scanSingleRow(ctx, r.db, `
+		INSERT INTO user_notification_events (user_id, event_type, subject_key, period_key, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, event_type, subject_key, period_key) DO NOTHING
+		RETURNING id, created_at
+	`, []any{event.UserID, event.EventType, event.SubjectKey, event.PeriodKey, payload}, &event.ID, &event.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *userNotificationRepository) UpdateEventDelivery(ctx context.Context, id int64, emailStatus, webhookStatus, webhookError string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_notification_events
+		SET email_status = $2, webhook_status = $3, webhook_error = $4
+		WHERE id = $1
+	`, id, emailStatus, webhookStatus, webhookError)
+	return err
+}
+
+func (r *userNotificationRepository) ListEvents(ctx context.Context, userID int64, params pagination.PaginationParams) ([]service.UserNotificationEvent, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM user_notification_events WHERE user_id = $1", []any{userID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UserNotificationEvent{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, event_type, subject_key, period_key, payload,
+			email_status, webhook_status, webhook_error, created_at
+		FROM user_notification_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	events := make([]service.UserNotificationEvent, 0)
+	for rows.Next() {
+		var (
+			event   service.UserNotificationEvent
+			payload []byte
+		)
+		if err := rows.Scan(
+			&event.ID, &event.UserID, &event.EventType, &event.SubjectKey, &event.PeriodKey, &payload,
+			&event.EmailStatus, &event.WebhookStatus, &event.WebhookError, &event.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		if len(payload) > 0 {
+			if err := json.Unmarshal(payload, &event.Payload); err != nil {
+				return nil, nil, fmt.Errorf("unmarshal notification payload: %w", err)
+			}
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return events, paginationResultFromTotal(total, params), nil
+}
+
+func (r *userNotificationRepository) ListExpiringSubscriptions(ctx context.Context, now time.Time) ([]service.UserNotificationExpiringItem, error) {
+	return r.listExpiring(ctx, `
+		SELECT us.user_id, us.id, COALESCE(g.name, ''), us.expires_at
+		FROM user_subscriptions us
+		JOIN user_notification_preferences p ON p.user_id = us.user_id
+		LEFT JOIN groups g ON g.id = us.group_id
+		WHERE p.subscription_expiry_days > 0
+			AND us.deleted_at IS NULL
+			AND us.status = 'active'
+			AND us.expires_at > $1
+			AND us.expires_at <= $1::timestamptz + make_interval(days => p.subscription_expiry_days)
+		ORDER BY us.expires_at
+	`, now)
+}
+
+func (r *userNotificationRepository) ListExpiringAPIKeys(ctx context.Context, now time.Time) ([]service.UserNotificationExpiringItem, error) {
+	return r.listExpiring(ctx, `
+		SELECT k.user_id, k.id, k.name, k.expires_at
+		FROM api_keys k
+		JOIN user_notification_preferences p ON p.user_id = k.user_id
+		WHERE p.api_key_expiry_days > 0
+			AND k.deleted_at IS NULL
+			AND k.status = 'active'
+			AND k.expires_at IS NOT NULL
+			AND k.expires_at > $1
+			AND k.expires_at <= $1::timestamptz + make_interval(days => p.api_key_expiry_days)
+		ORDER BY k.expires_at
+	`, now)
+}
+
+func (r *userNotificationRepository) listExpiring(ctx context.Context, query string, now time.Time) ([]service.UserNotificationExpiringItem, error) {
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := make([]service.UserNotificationExpiringItem, 0)
+	for rows.Next() {
+		var item service.UserNotificationExpiringItem
+		if err := rows.Scan(&item.UserID, &item.SubjectID, &item.Name, &item.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -62,6 +62,8 @@ func (r *userRepository) Create(ctx context.Context, userIn *service.User) error
 		SetConcurrency(userIn.Concurrency).
 		SetStatus(userIn.Status).
 		SetSoraStorageQuotaBytes(userIn.SoraStorageQuotaBytes).
+		SetQueueWeight(userIn.QueueWeight).
+		SetQueuePriority(userIn.QueuePriority).
 		Save(ctx)
 	if err != nil {
 		return translatePersistenceError(err, nil, service.ErrEmailExists)
@@ -146,6 +148,8 @@ func (r *userRepository) Update(ctx context.Context, userIn *service.User) error
 		SetStatus(userIn.Status).
 		SetSoraStorageQuotaBytes(userIn.SoraStorageQuotaBytes).
 		SetSoraStorageUsedBytes(userIn.SoraStorageUsedBytes).
+		SetQueueWeight(userIn.QueueWeight).
+		SetQueuePriority(userIn.QueuePriority).
 		Save(ctx)
 	if err != nil {
 		return translatePersistenceError(err, service.ErrUserNotFound, service.ErrEmailExists)
@@ -445,6 +445,7 @@ func userSubscriptionEntityToService(m *dbent.UserSubscription) *service.UserSub
 		AssignedBy:         m.AssignedBy,
 		AssignedAt:         m.AssignedAt,
 		Notes:              derefString(m.Notes),
+		AutoRenew:          m.AutoRenew,
 		CreatedAt:          m.CreatedAt,
 		UpdatedAt:          m.UpdatedAt,
 	}
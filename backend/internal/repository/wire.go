@@ -65,6 +65,17 @@ var ProviderSet = wire.NewSet(
 	NewUsageBillingRepository,
 	NewIdempotencyRepository,
 	NewUsageCleanupRepository,
+	NewUsageExportRepository,
+	NewUsageSessionRepository,
+	NewUsageLogPartitionRepository,
+	NewInvoiceRepository,
+	NewRequestCaptureRepository,
+	NewPricingRepository,
+	NewContentModerationRepository,
+	NewEmailTemplateRepository,
+	NewPasskeyRepository,
+	NewUserNotificationRepository,
+	NewSubscriptionChargeRepository,
 	NewDashboardAggregationRepository,
 	NewSettingRepository,
 	NewOpsRepository,
@@ -81,8 +92,10 @@ var ProviderSet = wire.NewSet(
 	NewTempUnschedCache,
 	NewTimeoutCounterCache,
 	ProvideConcurrencyCache,
+	NewFairQueueCache,
 	ProvideSessionLimitCache,
 	NewRPMCache,
+	NewUpstreamCircuitCache,
 	NewUserMsgQueueCache,
 	NewDashboardCache,
 	NewEmailCache,
@@ -94,8 +107,10 @@ var ProviderSet = wire.NewSet(
 	NewSchedulerOutboxRepository,
 	NewProxyLatencyCache,
 	NewTotpCache,
+	NewPasskeyCache,
 	NewRefreshTokenCache,
 	NewErrorPassthroughCache,
+	NewResponsesConversationCache,
 
 	// Encryptors
 	NewAESEncryptor,
@@ -119,6 +134,7 @@ var ProviderSet = wire.NewSet(
 
 	ProvideEnt,
 	ProvideSQLDB,
+	ProvideDBReadRouter,
 	ProvideRedis,
 )
 
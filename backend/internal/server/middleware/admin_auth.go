@@ -16,18 +16,20 @@ func NewAdminAuthMiddleware(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	passkeyService *service.PasskeyService,
 ) AdminAuthMiddleware {
-	return AdminAuthMiddleware(adminAuth(authService, userService, settingService))
+	return AdminAuthMiddleware(adminAuth(authService, userService, settingService, passkeyService))
 }
 
 // adminAuth 管理员认证中间件实现
 // 支持两种认证方式（通过不同的 header 区分）：
 // 1. Admin API Key: x-api-key: <admin-api-key>
-// 2. JWT Token: Authorization: Bearer <jwt-token> (需要管理员角色)
+// 2. JWT Token: Authorization: Bearer <jwt-token> (需要管理员角色；开启 admin_2fa_required 时还需已设置 2FA)
 func adminAuth(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	passkeyService *service.PasskeyService,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// WebSocket upgrade requests cannot set Authorization headers in browsers.
@@ -36,7 +38,7 @@ func adminAuth(
 		//   Sec-WebSocket-Protocol: sub2api-admin, jwt.<token>
 		if isWebSocketUpgradeRequest(c) {
 			if token := extractJWTFromWebSocketSubprotocol(c); token != "" {
-				if !validateJWTForAdmin(c, token, authService, userService) {
+				if !validateJWTForAdmin(c, token, authService, userService, settingService, passkeyService) {
 					return
 				}
 				c.Next()
@@ -64,7 +66,7 @@ func adminAuth(
 					AbortWithError(c, 401, "UNAUTHORIZED", "Authorization required")
 					return
 				}
-				if !validateJWTForAdmin(c, token, authService, userService) {
+				if !validateJWTForAdmin(c, token, authService, userService, settingService, passkeyService) {
 					return
 				}
 				c.Next()
@@ -156,6 +158,8 @@ func validateJWTForAdmin(
 	token string,
 	authService *service.AuthService,
 	userService *service.UserService,
+	settingService *service.SettingService,
+	passkeyService *service.PasskeyService,
 ) bool {
 	// 验证 JWT token
 	claims, err := authService.ValidateToken(token)
@@ -193,6 +197,12 @@ func validateJWTForAdmin(
 		return false
 	}
 
+	// 强制管理员 2FA：未设置 TOTP 或通行密钥的管理员只能访问用户侧接口完成设置
+	if service.AdminRequiresSecondFactorSetup(c.Request.Context(), settingService, passkeyService, user) {
+		AbortWithError(c, 403, service.ErrAdmin2FARequired.Reason, service.ErrAdmin2FARequired.Message)
+		return false
+	}
+
 	c.Set(string(ContextKeyUser), AuthSubject{
 		UserID:      user.ID,
 		Concurrency: user.Concurrency,
@@ -98,6 +98,9 @@ func (f fakeAPIKeyRepo) UpdateLastUsed(ctx context.Context, id int64, usedAt tim
 func (f fakeAPIKeyRepo) IncrementRateLimitUsage(ctx context.Context, id int64, cost float64) error {
 	return nil
 }
+func (f fakeAPIKeyRepo) UpdateStatus(ctx context.Context, id int64, status string) error {
+	return nil
+}
 func (f fakeAPIKeyRepo) ResetRateLimitWindows(ctx context.Context, id int64) error {
 	return nil
 }
@@ -73,6 +73,21 @@ func RegisterAdminRoutes(
 		// 使用记录管理
 		registerUsageRoutes(admin, h)
 
+		// 月度账单
+		registerInvoiceRoutes(admin, h)
+
+		// 请求/响应抓取
+		registerRequestCaptureRoutes(admin, h)
+
+		// 内容审核
+		registerContentModerationRoutes(admin, h)
+
+		// 模型价格（数据源同步审核、覆盖、历史）
+		registerPricingRoutes(admin, h)
+
+		// 邮件模板
+		registerEmailTemplateRoutes(admin, h)
+
 		// 用户属性管理
 		registerUserAttributeRoutes(admin, h)
 
@@ -230,6 +245,7 @@ func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		groups.GET("/usage-summary", h.Admin.Group.GetUsageSummary)
 		groups.GET("/capacity-summary", h.Admin.Group.GetCapacitySummary)
 		groups.PUT("/sort-order", h.Admin.Group.UpdateSortOrder)
+		groups.POST("/request-transforms/dry-run", h.Admin.Group.DryRunRequestTransforms)
 		groups.GET("/:id", h.Admin.Group.GetByID)
 		groups.POST("", h.Admin.Group.Create)
 		groups.PUT("/:id", h.Admin.Group.Update)
@@ -511,6 +527,83 @@ func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		usage.GET("/cleanup-tasks", h.Admin.Usage.ListCleanupTasks)
 		usage.POST("/cleanup-tasks", h.Admin.Usage.CreateCleanupTask)
 		usage.POST("/cleanup-tasks/:id/cancel", h.Admin.Usage.CancelCleanupTask)
+		usage.GET("/exports", h.Admin.UsageExport.List)
+		usage.POST("/exports", h.Admin.UsageExport.Create)
+		usage.GET("/exports/:id", h.Admin.UsageExport.Get)
+		usage.POST("/exports/:id/cancel", h.Admin.UsageExport.Cancel)
+		usage.GET("/exports/:id/download", h.Admin.UsageExport.Download)
+		usage.GET("/sessions", h.Admin.UsageSession.List)
+		usage.GET("/sessions/:session_id", h.Admin.UsageSession.Get)
+		usage.GET("/sessions/:session_id/logs", h.Admin.UsageSession.ListLogs)
+		usage.GET("/partitions", h.Admin.UsageLogArchive.ListPartitions)
+		usage.GET("/archives", h.Admin.UsageLogArchive.ListArchives)
+		usage.GET("/archives/:id", h.Admin.UsageLogArchive.GetArchive)
+		usage.GET("/archives/:id/records", h.Admin.UsageLogArchive.ListRecords)
+		usage.GET("/archives/:id/download", h.Admin.UsageLogArchive.Download)
+		usage.POST("/archives/:id/restore", h.Admin.UsageLogArchive.Restore)
+		usage.DELETE("/archives/:id/restore", h.Admin.UsageLogArchive.Release)
+	}
+}
+
+func registerInvoiceRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	invoices := admin.Group("/invoices")
+	{
+		invoices.GET("", h.Admin.Invoice.List)
+		invoices.POST("/generate", h.Admin.Invoice.Generate)
+		invoices.GET("/:id", h.Admin.Invoice.GetByID)
+		invoices.GET("/:id/download", h.Admin.Invoice.Download)
+	}
+}
+
+func registerRequestCaptureRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	captures := admin.Group("/request-captures")
+	{
+		captures.GET("/policy", h.Admin.RequestCapture.GetPolicy)
+		captures.PUT("/policy", h.Admin.RequestCapture.UpdatePolicy)
+		captures.GET("", h.Admin.RequestCapture.List)
+		captures.GET("/:id", h.Admin.RequestCapture.GetByID)
+	}
+}
+
+func registerPricingRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	pricing := admin.Group("/pricing")
+	{
+		pricing.GET("/status", h.Admin.Pricing.GetStatus)
+		pricing.POST("/sync", h.Admin.Pricing.Sync)
+		pricing.GET("/revisions", h.Admin.Pricing.ListRevisions)
+		pricing.GET("/revisions/:id", h.Admin.Pricing.GetRevision)
+		pricing.POST("/revisions/:id/approve", h.Admin.Pricing.ApproveRevision)
+		pricing.POST("/revisions/:id/reject", h.Admin.Pricing.RejectRevision)
+		pricing.GET("/overrides", h.Admin.Pricing.ListOverrides)
+		pricing.PUT("/overrides", h.Admin.Pricing.SetOverride)
+		pricing.DELETE("/overrides", h.Admin.Pricing.DeleteOverride)
+		pricing.GET("/history", h.Admin.Pricing.ListHistory)
+	}
+}
+
+func registerContentModerationRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	moderation := admin.Group("/content-moderation")
+	{
+		moderation.GET("/rules", h.Admin.ContentModeration.ListRules)
+		moderation.GET("/rules/:id", h.Admin.ContentModeration.GetRule)
+		moderation.POST("/rules", h.Admin.ContentModeration.CreateRule)
+		moderation.PUT("/rules/:id", h.Admin.ContentModeration.UpdateRule)
+		moderation.DELETE("/rules/:id", h.Admin.ContentModeration.DeleteRule)
+		moderation.GET("/violations", h.Admin.ContentModeration.ListViolations)
+		moderation.GET("/violations/users", h.Admin.ContentModeration.ListUserStats)
+	}
+}
+
+func registerEmailTemplateRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	templates := admin.Group("/email-templates")
+	{
+		templates.GET("", h.Admin.EmailTemplate.List)
+		templates.PUT("/default-locale", h.Admin.EmailTemplate.UpdateDefaultLocale)
+		templates.GET("/:key/:locale", h.Admin.EmailTemplate.Get)
+		templates.PUT("/:key/:locale", h.Admin.EmailTemplate.Update)
+		templates.DELETE("/:key/:locale", h.Admin.EmailTemplate.Reset)
+		templates.POST("/:key/preview", h.Admin.EmailTemplate.Preview)
+		templates.POST("/:key/test-send", h.Admin.EmailTemplate.TestSend)
 	}
 }
 
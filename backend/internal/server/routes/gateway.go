@@ -31,6 +31,9 @@ func RegisterGatewayRoutes(
 	clientRequestID := middleware.ClientRequestID()
 	opsErrorLogger := handler.OpsErrorLoggerMiddleware(opsService)
 	endpointNorm := handler.InboundEndpointMiddleware()
+	requestCapture := h.RequestCapture.Middleware()
+	contentModeration := h.ContentModeration.Middleware()
+	userRateLimitHeaders := h.UserRateLimitHeaders.Middleware()
 
 	// 未分组 Key 拦截中间件（按协议格式区分错误响应）
 	requireGroupAnthropic := middleware.RequireGroupAssignment(settingService, middleware.AnthropicErrorWriter)
@@ -44,6 +47,9 @@ func RegisterGatewayRoutes(
 	gateway.Use(endpointNorm)
 	gateway.Use(gin.HandlerFunc(apiKeyAuth))
 	gateway.Use(requireGroupAnthropic)
+	gateway.Use(requestCapture)
+	gateway.Use(contentModeration)
+	gateway.Use(userRateLimitHeaders)
 	{
 		// /v1/messages: auto-route based on group platform
 		gateway.POST("/messages", func(c *gin.Context) {
@@ -69,10 +75,10 @@ func RegisterGatewayRoutes(
 		})
 		gateway.GET("/models", h.Gateway.Models)
 		gateway.GET("/usage", h.Gateway.Usage)
-		// OpenAI Responses API
-		gateway.POST("/responses", h.OpenAIGateway.Responses)
-		gateway.POST("/responses/*subpath", h.OpenAIGateway.Responses)
-		gateway.GET("/responses", h.OpenAIGateway.ResponsesWebSocket)
+		// /v1/responses: anthropic groups are converted to Messages
+		gateway.POST("/responses", responsesHandler(h))
+		gateway.POST("/responses/*subpath", openAIOnlyResponsesHandler(h, h.OpenAIGateway.Responses))
+		gateway.GET("/responses", openAIOnlyResponsesHandler(h, h.OpenAIGateway.ResponsesWebSocket))
 		// OpenAI Chat Completions API
 		gateway.POST("/chat/completions", h.OpenAIGateway.ChatCompletions)
 	}
@@ -85,19 +91,21 @@ func RegisterGatewayRoutes(
 	gemini.Use(endpointNorm)
 	gemini.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	gemini.Use(requireGroupGoogle)
+	gemini.Use(requestCapture)
+	gemini.Use(contentModeration)
 	{
 		gemini.GET("/models", h.Gateway.GeminiV1BetaListModels)
 		gemini.GET("/models/:model", h.Gateway.GeminiV1BetaGetModel)
 		// Gin treats ":" as a param marker, but Gemini uses "{model}:{action}" in the same segment.
-		gemini.POST("/models/*modelAction", h.Gateway.GeminiV1BetaModels)
+		gemini.POST("/models/*modelAction", geminiModelsHandler(h))
 	}
 
 	// OpenAI Responses API（不带v1前缀的别名）
-	r.POST("/responses", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, h.OpenAIGateway.Responses)
-	r.POST("/responses/*subpath", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, h.OpenAIGateway.Responses)
-	r.GET("/responses", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, h.OpenAIGateway.ResponsesWebSocket)
+	r.POST("/responses", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestCapture, contentModeration, userRateLimitHeaders, responsesHandler(h))
+	r.POST("/responses/*subpath", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestCapture, contentModeration, userRateLimitHeaders, openAIOnlyResponsesHandler(h, h.OpenAIGateway.Responses))
+	r.GET("/responses", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, openAIOnlyResponsesHandler(h, h.OpenAIGateway.ResponsesWebSocket))
 	// OpenAI Chat Completions API（不带v1前缀的别名）
-	r.POST("/chat/completions", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, h.OpenAIGateway.ChatCompletions)
+	r.POST("/chat/completions", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestCapture, contentModeration, userRateLimitHeaders, h.OpenAIGateway.ChatCompletions)
 
 	// Antigravity 模型列表
 	r.GET("/antigravity/models", gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, h.Gateway.AntigravityModels)
@@ -111,6 +119,9 @@ func RegisterGatewayRoutes(
 	antigravityV1.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1.Use(gin.HandlerFunc(apiKeyAuth))
 	antigravityV1.Use(requireGroupAnthropic)
+	antigravityV1.Use(requestCapture)
+	antigravityV1.Use(contentModeration)
+	antigravityV1.Use(userRateLimitHeaders)
 	{
 		antigravityV1.POST("/messages", h.Gateway.Messages)
 		antigravityV1.POST("/messages/count_tokens", h.Gateway.CountTokens)
@@ -126,6 +137,8 @@ func RegisterGatewayRoutes(
 	antigravityV1Beta.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1Beta.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	antigravityV1Beta.Use(requireGroupGoogle)
+	antigravityV1Beta.Use(requestCapture)
+	antigravityV1Beta.Use(contentModeration)
 	{
 		antigravityV1Beta.GET("/models", h.Gateway.GeminiV1BetaListModels)
 		antigravityV1Beta.GET("/models/:model", h.Gateway.GeminiV1BetaGetModel)
@@ -141,6 +154,8 @@ func RegisterGatewayRoutes(
 	soraV1.Use(middleware.ForcePlatform(service.PlatformSora))
 	soraV1.Use(gin.HandlerFunc(apiKeyAuth))
 	soraV1.Use(requireGroupAnthropic)
+	soraV1.Use(requestCapture)
+	soraV1.Use(contentModeration)
 	{
 		soraV1.POST("/chat/completions", h.SoraGateway.ChatCompletions)
 		soraV1.GET("/models", h.Gateway.Models)
@@ -156,6 +171,46 @@ func RegisterGatewayRoutes(
 	r.GET("/sora/media-signed/*filepath", h.SoraGateway.MediaProxySigned)
 }
 
+// responsesHandler /v1/responses: auto-route based on group platform
+func responsesHandler(h *handler.Handlers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if getGroupPlatform(c) == service.PlatformAnthropic {
+			h.Gateway.Responses(c)
+			return
+		}
+		h.OpenAIGateway.Responses(c)
+	}
+}
+
+// openAIOnlyResponsesHandler guards Responses sub-resources and WebSocket mode:
+// anthropic groups cannot be converted there and get a Responses-style 404
+// instead of being sent to the OpenAI gateway.
+func openAIOnlyResponsesHandler(h *handler.Handlers, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if getGroupPlatform(c) == service.PlatformAnthropic {
+			h.Gateway.ResponsesUnsupported(c)
+			return
+		}
+		next(c)
+	}
+}
+
+// geminiModelsHandler dispatches /v1beta/models/* by group platform:
+// anthropic and openai groups are converted to Messages, everything else
+// uses the native Gemini handler.
+func geminiModelsHandler(h *handler.Handlers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch getGroupPlatform(c) {
+		case service.PlatformAnthropic:
+			h.Gateway.GeminiV1BetaCompat(c, h.Gateway.Messages, h.Gateway.CountTokens)
+		case service.PlatformOpenAI:
+			h.Gateway.GeminiV1BetaCompat(c, h.OpenAIGateway.Messages, nil)
+		default:
+			h.Gateway.GeminiV1BetaModels(c)
+		}
+	}
+}
+
 // getGroupPlatform extracts the group platform from the API Key stored in context.
 func getGroupPlatform(c *gin.Context) string {
 	apiKey, ok := middleware.GetAPIKeyFromContext(c)
@@ -9,11 +9,18 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/handler"
 	servermiddleware "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
 
 func newGatewayRoutesTestRouter() *gin.Engine {
+	return newGatewayRoutesTestRouterWithAuth(func(c *gin.Context) {
+		c.Next()
+	})
+}
+
+func newGatewayRoutesTestRouterWithAuth(auth gin.HandlerFunc) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
@@ -24,9 +31,7 @@ func newGatewayRoutesTestRouter() *gin.Engine {
 			OpenAIGateway: &handler.OpenAIGatewayHandler{},
 			SoraGateway:   &handler.SoraGatewayHandler{},
 		},
-		servermiddleware.APIKeyAuthMiddleware(func(c *gin.Context) {
-			c.Next()
-		}),
+		servermiddleware.APIKeyAuthMiddleware(auth),
 		nil,
 		nil,
 		nil,
@@ -49,3 +54,33 @@ func TestGatewayRoutesOpenAIResponsesCompactPathIsRegistered(t *testing.T) {
 		require.NotEqual(t, http.StatusNotFound, w.Code, "path=%s should hit OpenAI responses handler", path)
 	}
 }
+
+func TestGatewayRoutesAnthropicGroupRejectsResponsesSubpathsAndWebSocket(t *testing.T) {
+	router := newGatewayRoutesTestRouterWithAuth(func(c *gin.Context) {
+		groupID := int64(1)
+		c.Set(string(servermiddleware.ContextKeyAPIKey), &service.APIKey{
+			GroupID: &groupID,
+			Group:   &service.Group{ID: groupID, Platform: service.PlatformAnthropic},
+		})
+		c.Next()
+	})
+
+	cases := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/v1/responses/compact"},
+		{http.MethodPost, "/responses/compact"},
+		{http.MethodGet, "/v1/responses"},
+		{http.MethodGet, "/responses"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"model":"claude-sonnet-4-5"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code, "%s %s", tc.method, tc.path)
+		require.Contains(t, w.Body.String(), `"not_found_error"`, "%s %s", tc.method, tc.path)
+	}
+}
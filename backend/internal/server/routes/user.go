@@ -36,6 +36,24 @@ func RegisterUserRoutes(
 				totp.POST("/enable", h.Totp.Enable)
 				totp.POST("/disable", h.Totp.Disable)
 			}
+
+			// WebAuthn 通行密钥（身份校验复用 TOTP 的验证方式与验证码接口）
+			passkeys := user.Group("/passkeys")
+			{
+				passkeys.GET("", h.Passkey.List)
+				passkeys.POST("/register/options", h.Passkey.RegisterOptions)
+				passkeys.POST("/register", h.Passkey.Register)
+				passkeys.PUT("/:id", h.Passkey.Rename)
+				passkeys.DELETE("/:id", h.Passkey.Delete)
+			}
+
+			// 余额/额度提醒
+			notifications := user.Group("/notifications")
+			{
+				notifications.GET("/preferences", h.UserNotification.GetPreferences)
+				notifications.PUT("/preferences", h.UserNotification.UpdatePreferences)
+				notifications.GET("/events", h.UserNotification.ListEvents)
+			}
 		}
 
 		// API Key管理
@@ -66,6 +84,18 @@ func RegisterUserRoutes(
 			usage.GET("/dashboard/trend", h.Usage.DashboardTrend)
 			usage.GET("/dashboard/models", h.Usage.DashboardModels)
 			usage.POST("/dashboard/api-keys-usage", h.Usage.DashboardAPIKeysUsage)
+
+			// 使用记录异步导出
+			usage.POST("/exports", h.UsageExport.Create)
+			usage.GET("/exports", h.UsageExport.List)
+			usage.GET("/exports/:id", h.UsageExport.Get)
+			usage.POST("/exports/:id/cancel", h.UsageExport.Cancel)
+			usage.GET("/exports/:id/download", h.UsageExport.Download)
+
+			// 会话（对话）维度用量分析
+			usage.GET("/sessions", h.UsageSession.List)
+			usage.GET("/sessions/:session_id", h.UsageSession.Get)
+			usage.GET("/sessions/:session_id/logs", h.UsageSession.ListLogs)
 		}
 
 		// 公告（用户可见）
@@ -89,6 +119,30 @@ func RegisterUserRoutes(
 			subscriptions.GET("/active", h.Subscription.GetActive)
 			subscriptions.GET("/progress", h.Subscription.GetProgress)
 			subscriptions.GET("/summary", h.Subscription.GetSummary)
+
+			// 自动续费
+			subscriptions.PUT("/:id/auto-renew", h.SubscriptionRenewal.SetAutoRenew)
+			subscriptions.GET("/charges", h.SubscriptionRenewal.ListCharges)
+
+			// 余额自助购买/升级
+			subscriptions.GET("/purchasable", h.SubscriptionPurchase.ListPurchasable)
+			subscriptions.POST("/purchase/quote", h.SubscriptionPurchase.Quote)
+			subscriptions.POST("/purchase", h.SubscriptionPurchase.Purchase)
+		}
+
+		// 月度账单
+		invoices := authenticated.Group("/invoices")
+		{
+			invoices.GET("", h.Invoice.List)
+			invoices.GET("/:id", h.Invoice.GetByID)
+			invoices.GET("/:id/download", h.Invoice.Download)
+		}
+
+		// 请求抓取查看（需管理员开启 allow_user_view）
+		requestCaptures := authenticated.Group("/request-captures")
+		{
+			requestCaptures.GET("", h.RequestCapture.List)
+			requestCaptures.GET("/:id", h.RequestCapture.GetByID)
 		}
 	}
 }
@@ -63,6 +63,18 @@ type Account struct {
 	modelMappingCacheRawPtr         uintptr
 	modelMappingCacheRawLen         int
 	modelMappingCacheRawSig         uint64
+
+	// availability_schedule 热路径缓存（非持久化字段）
+	availabilityScheduleCache       *AvailabilitySchedule
+	availabilityScheduleCacheReady  bool
+	availabilityScheduleCacheRawPtr uintptr
+	availabilityScheduleCacheRawLen int
+
+	// model_limits 热路径缓存（非持久化字段）
+	modelLimitsCache       []AccountModelLimit
+	modelLimitsCacheReady  bool
+	modelLimitsCacheRawPtr uintptr
+	modelLimitsCacheRawLen int
 }
 
 type TempUnschedulableRule struct {
@@ -120,6 +132,9 @@ func (a *Account) IsSchedulable() bool {
 	if a.TempUnschedulableUntil != nil && now.Before(*a.TempUnschedulableUntil) {
 		return false
 	}
+	if !a.IsWithinAvailabilityWindow(now) {
+		return false
+	}
 	return true
 }
 
@@ -455,6 +470,13 @@ func mapPtr(m map[string]any) uintptr {
 	return reflect.ValueOf(m).Pointer()
 }
 
+func slicePtr(s []any) uintptr {
+	if s == nil {
+		return 0
+	}
+	return reflect.ValueOf(s).Pointer()
+}
+
 func modelMappingSignature(rawMapping map[string]any) uint64 {
 	if len(rawMapping) == 0 {
 		return 0
@@ -1550,34 +1572,34 @@ func (a *Account) IsQuotaExceeded() bool {
 		return true
 	}
 	// 日额度（周期过期视为未超限，下次 increment 会重置）
-	if limit := a.GetQuotaDailyLimit(); limit > 0 {
-		start := a.getExtraTime("quota_daily_start")
-		var expired bool
-		if a.GetQuotaDailyResetMode() == "fixed" {
-			expired = a.isFixedDailyPeriodExpired(start)
-		} else {
-			expired = isPeriodExpired(start, 24*time.Hour)
-		}
-		if !expired && a.GetQuotaDailyUsed() >= limit {
-			return true
-		}
+	if limit := a.GetQuotaDailyLimit(); limit > 0 && !a.isQuotaDailyPeriodExpired() && a.GetQuotaDailyUsed() >= limit {
+		return true
 	}
 	// 周额度
-	if limit := a.GetQuotaWeeklyLimit(); limit > 0 {
-		start := a.getExtraTime("quota_weekly_start")
-		var expired bool
-		if a.GetQuotaWeeklyResetMode() == "fixed" {
-			expired = a.isFixedWeeklyPeriodExpired(start)
-		} else {
-			expired = isPeriodExpired(start, 7*24*time.Hour)
-		}
-		if !expired && a.GetQuotaWeeklyUsed() >= limit {
-			return true
-		}
+	if limit := a.GetQuotaWeeklyLimit(); limit > 0 && !a.isQuotaWeeklyPeriodExpired() && a.GetQuotaWeeklyUsed() >= limit {
+		return true
 	}
 	return false
 }
 
+// isQuotaDailyPeriodExpired 日额度周期是否已过期（过期后已用额度视为 0）
+func (a *Account) isQuotaDailyPeriodExpired() bool {
+	start := a.getExtraTime("quota_daily_start")
+	if a.GetQuotaDailyResetMode() == "fixed" {
+		return a.isFixedDailyPeriodExpired(start)
+	}
+	return isPeriodExpired(start, 24*time.Hour)
+}
+
+// isQuotaWeeklyPeriodExpired 周额度周期是否已过期（过期后已用额度视为 0）
+func (a *Account) isQuotaWeeklyPeriodExpired() bool {
+	start := a.getExtraTime("quota_weekly_start")
+	if a.GetQuotaWeeklyResetMode() == "fixed" {
+		return a.isFixedWeeklyPeriodExpired(start)
+	}
+	return isPeriodExpired(start, 7*24*time.Hour)
+}
+
 // GetWindowCostLimit 获取 5h 窗口费用阈值（美元）
 // 返回 0 表示未启用
 func (a *Account) GetWindowCostLimit() float64 {
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+// 账号可用时间窗口
+//
+// 配置存放在 extra.availability_schedule 中，例如：
+//
+//	{"timezone": "Asia/Shanghai", "windows": [{"days": [1,2,3,4,5], "start": "20:00", "end": "08:00"}, {"days": [0,6], "start": "00:00", "end": "24:00"}]}
+//
+// days 为星期（0=周日 … 6=周六），start/end 为 HH:MM；end 不晚于 start 时表示跨零点到次日。
+// 未配置或 windows 为空时账号全天可用；配置后窗口外的账号视为不可调度。
+const (
+	availabilityScheduleExtraKey = "availability_schedule"
+
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// AvailabilityWindow 单个可用时间段
+type AvailabilityWindow struct {
+	Days  []int  `json:"days"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// AvailabilitySchedule 账号可用时间表
+type AvailabilitySchedule struct {
+	// Timezone IANA 时区名，空表示服务器时区
+	Timezone string               `json:"timezone,omitempty"`
+	Windows  []AvailabilityWindow `json:"windows"`
+
+	loc    *time.Location
+	ranges [][2]int // 按周内分钟表示的 [start, end)，end 可能超过一周（跨周日零点）
+}
+
+// GetAvailabilitySchedule 解析账号的可用时间表；未配置返回 nil
+func (a *Account) GetAvailabilitySchedule() (*AvailabilitySchedule, error) {
+	if a == nil || a.Extra == nil {
+		return nil, nil
+	}
+	raw, ok := a.Extra[availabilityScheduleExtraKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	return parseAvailabilitySchedule(raw)
+}
+
+// cachedAvailabilitySchedule 返回解析后的可用时间表，按 extra 中原始配置的 map 指针缓存，
+// 调度热路径上同一账号实例只解析一次；配置非法时缓存为 nil（配置在保存时已校验）。
+func (a *Account) cachedAvailabilitySchedule() *AvailabilitySchedule {
+	if a == nil {
+		return nil
+	}
+	rawSchedule, _ := a.Extra[availabilityScheduleExtraKey].(map[string]any)
+	rawPtr := mapPtr(rawSchedule)
+	rawLen := len(rawSchedule)
+	if a.availabilityScheduleCacheReady &&
+		a.availabilityScheduleCacheRawPtr == rawPtr &&
+		a.availabilityScheduleCacheRawLen == rawLen {
+		return a.availabilityScheduleCache
+	}
+
+	var schedule *AvailabilitySchedule
+	if rawSchedule != nil {
+		schedule, _ = parseAvailabilitySchedule(rawSchedule)
+	}
+	a.availabilityScheduleCache = schedule
+	a.availabilityScheduleCacheReady = true
+	a.availabilityScheduleCacheRawPtr = rawPtr
+	a.availabilityScheduleCacheRawLen = rawLen
+	return schedule
+}
+
+// IsWithinAvailabilityWindow 账号在 now 时刻是否处于可用时间窗口内。
+// 未配置或配置非法时视为可用（配置在保存时已校验）。
+func (a *Account) IsWithinAvailabilityWindow(now time.Time) bool {
+	schedule := a.cachedAvailabilitySchedule()
+	if schedule == nil {
+		return true
+	}
+	return schedule.IsAvailableAt(now)
+}
+
+// NextAvailabilityChange 返回账号在 now 之后下一次可用状态变化的时间；无时间表或全天可用时返回 false
+func (a *Account) NextAvailabilityChange(now time.Time) (time.Time, bool) {
+	schedule := a.cachedAvailabilitySchedule()
+	if schedule == nil {
+		return time.Time{}, false
+	}
+	return schedule.NextChange(now)
+}
+
+// ValidateAvailabilitySchedule 校验 extra 中的可用时间表配置
+func ValidateAvailabilitySchedule(extra map[string]any) error {
+	if extra == nil {
+		return nil
+	}
+	raw, ok := extra[availabilityScheduleExtraKey]
+	if !ok || raw == nil {
+		return nil
+	}
+	_, err := parseAvailabilitySchedule(raw)
+	return err
+}
+
+func parseAvailabilitySchedule(raw any) (*AvailabilitySchedule, error) {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("availability_schedule must be an object")
+	}
+	schedule := &AvailabilitySchedule{}
+	if tz, ok := m["timezone"].(string); ok {
+		schedule.Timezone = strings.TrimSpace(tz)
+	}
+	loc, err := timezone.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, errors.New("invalid availability_schedule.timezone: must be a valid IANA timezone name")
+	}
+	schedule.loc = loc
+
+	rawWindows, _ := m["windows"].([]any)
+	if m["windows"] != nil && rawWindows == nil {
+		return nil, errors.New("availability_schedule.windows must be an array")
+	}
+	for i, rw := range rawWindows {
+		wm, ok := rw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("availability_schedule.windows[%d] must be an object", i)
+		}
+		window, err := parseAvailabilityWindow(wm)
+		if err != nil {
+			return nil, fmt.Errorf("availability_schedule.windows[%d]: %w", i, err)
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	schedule.compile()
+	return schedule, nil
+}
+
+func parseAvailabilityWindow(m map[string]any) (AvailabilityWindow, error) {
+	var window AvailabilityWindow
+	rawDays, ok := m["days"].([]any)
+	if !ok || len(rawDays) == 0 {
+		return window, errors.New("days must be a non-empty array of weekdays (0-6)")
+	}
+	for _, rd := range rawDays {
+		v, ok := rd.(float64)
+		if !ok || v != float64(int(v)) || v < 0 || v > 6 {
+			return window, errors.New("days must contain weekdays between 0 (Sunday) and 6 (Saturday)")
+		}
+		window.Days = append(window.Days, int(v))
+	}
+	window.Start, _ = m["start"].(string)
+	window.End, _ = m["end"].(string)
+	start, err := parseClockMinutes(window.Start)
+	if err != nil || start == minutesPerDay {
+		return window, errors.New("start must be HH:MM between 00:00 and 23:59")
+	}
+	end, err := parseClockMinutes(window.End)
+	if err != nil {
+		return window, errors.New("end must be HH:MM between 00:00 and 24:00")
+	}
+	if start == end {
+		return window, errors.New("start and end must differ")
+	}
+	return window, nil
+}
+
+// parseClockMinutes 解析 HH:MM 为当天分钟数，允许 24:00
+func parseClockMinutes(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, errors.New("invalid clock")
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil {
+		return 0, err
+	}
+	if h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, errors.New("invalid clock")
+	}
+	return h*60 + m, nil
+}
+
+func (s *AvailabilitySchedule) compile() {
+	s.ranges = s.ranges[:0]
+	for _, w := range s.Windows {
+		start, _ := parseClockMinutes(w.Start)
+		end, _ := parseClockMinutes(w.End)
+		if end <= start {
+			end += minutesPerDay
+		}
+		for _, d := range w.Days {
+			s.ranges = append(s.ranges, [2]int{d*minutesPerDay + start, d*minutesPerDay + end})
+		}
+	}
+}
+
+func (s *AvailabilitySchedule) location() *time.Location {
+	if s.loc != nil {
+		return s.loc
+	}
+	return timezone.Location()
+}
+
+// weekMinute 返回 t 在时间表时区下的周内分钟数（周日 00:00 为 0）
+func (s *AvailabilitySchedule) weekMinute(t time.Time) int {
+	local := t.In(s.location())
+	return int(local.Weekday())*minutesPerDay + local.Hour()*60 + local.Minute()
+}
+
+// IsAvailableAt 判断 t 是否落在任一可用时间段内；没有时间段时全天可用
+func (s *AvailabilitySchedule) IsAvailableAt(t time.Time) bool {
+	if s == nil || len(s.ranges) == 0 {
+		return true
+	}
+	m := s.weekMinute(t)
+	for _, r := range s.ranges {
+		// 跨周日零点的时间段需要同时检查下一周的同一分钟
+		if (m >= r[0] && m < r[1]) || (m+minutesPerWeek >= r[0] && m+minutesPerWeek < r[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextChange 返回 t 之后第一次可用状态翻转的时间；状态永不变化时返回 false
+func (s *AvailabilitySchedule) NextChange(t time.Time) (time.Time, bool) {
+	if s == nil || len(s.ranges) == 0 {
+		return time.Time{}, false
+	}
+	current := s.IsAvailableAt(t)
+	m := s.weekMinute(t)
+
+	// 候选边界：所有时间段的起止点，按距离 t 的分钟数排序
+	deltas := make([]int, 0, len(s.ranges)*2)
+	for _, r := range s.ranges {
+		for _, b := range r {
+			delta := ((b-m)%minutesPerWeek + minutesPerWeek) % minutesPerWeek
+			if delta == 0 {
+				delta = minutesPerWeek
+			}
+			deltas = append(deltas, delta)
+		}
+	}
+	sort.Ints(deltas)
+
+	local := t.In(s.location())
+	for i, delta := range deltas {
+		if i > 0 && delta == deltas[i-1] {
+			continue
+		}
+		minuteOfDay := local.Hour()*60 + local.Minute() + delta
+		candidate := time.Date(local.Year(), local.Month(), local.Day(), 0, minuteOfDay, 0, 0, s.location())
+		if s.IsAvailableAt(candidate) != current {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
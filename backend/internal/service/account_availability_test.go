@@ -0,0 +1,288 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func availabilityTestAccount(schedule map[string]any) *Account {
+	return &Account{ID: 1, Status: StatusActive, Schedulable: true, Extra: map[string]any{availabilityScheduleExtraKey: schedule}}
+}
+
+func TestAccountAvailabilityWindow(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	// 2026-01-05 为周一
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 1, day, hour, minute, 0, 0, shanghai)
+	}
+	nightsAndWeekends := map[string]any{
+		"timezone": "Asia/Shanghai",
+		"windows": []any{
+			map[string]any{"days": []any{1.0, 2.0, 3.0, 4.0, 5.0}, "start": "20:00", "end": "08:00"},
+			map[string]any{"days": []any{0.0, 6.0}, "start": "00:00", "end": "24:00"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		schedule  map[string]any
+		now       time.Time
+		available bool
+		next      time.Time
+		hasNext   bool
+	}{
+		{
+			name:      "weekday office hours unavailable",
+			schedule:  nightsAndWeekends,
+			now:       at(5, 10, 0),
+			available: false,
+			next:      at(5, 20, 0),
+			hasNext:   true,
+		},
+		{
+			name:      "weekday night available until morning",
+			schedule:  nightsAndWeekends,
+			now:       at(5, 23, 30),
+			available: true,
+			next:      at(6, 8, 0),
+			hasNext:   true,
+		},
+		{
+			name:      "friday night runs through the weekend",
+			schedule:  nightsAndWeekends,
+			now:       at(9, 21, 0),
+			available: true,
+			next:      at(12, 0, 0),
+			hasNext:   true,
+		},
+		{
+			name:      "monday early morning is not covered by sunday",
+			schedule:  nightsAndWeekends,
+			now:       at(12, 3, 0),
+			available: false,
+			next:      at(12, 20, 0),
+			hasNext:   true,
+		},
+		{
+			name: "saturday overnight wraps across week boundary",
+			schedule: map[string]any{
+				"timezone": "UTC",
+				"windows":  []any{map[string]any{"days": []any{6.0}, "start": "22:00", "end": "02:00"}},
+			},
+			now:       time.Date(2026, 1, 11, 1, 0, 0, 0, time.UTC),
+			available: true,
+			next:      time.Date(2026, 1, 11, 2, 0, 0, 0, time.UTC),
+			hasNext:   true,
+		},
+		{
+			name: "timezone is respected",
+			schedule: map[string]any{
+				"timezone": "America/New_York",
+				"windows":  []any{map[string]any{"days": []any{1.0}, "start": "09:00", "end": "17:00"}},
+			},
+			now:       time.Date(2026, 1, 5, 15, 0, 0, 0, time.UTC),
+			available: true,
+			next:      time.Date(2026, 1, 5, 22, 0, 0, 0, time.UTC),
+			hasNext:   true,
+		},
+		{
+			name:      "empty windows always available",
+			schedule:  map[string]any{"timezone": "UTC", "windows": []any{}},
+			now:       at(5, 10, 0),
+			available: true,
+		},
+		{
+			name: "full week never changes",
+			schedule: map[string]any{
+				"windows": []any{map[string]any{"days": []any{0.0, 1.0, 2.0, 3.0, 4.0, 5.0, 6.0}, "start": "00:00", "end": "24:00"}},
+			},
+			now:       at(5, 10, 0),
+			available: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := availabilityTestAccount(tt.schedule)
+			require.Equal(t, tt.available, account.IsWithinAvailabilityWindow(tt.now))
+			next, ok := account.NextAvailabilityChange(tt.now)
+			require.Equal(t, tt.hasNext, ok)
+			if tt.hasNext {
+				require.True(t, tt.next.Equal(next), "want %s, got %s", tt.next, next)
+			}
+		})
+	}
+}
+
+func TestAccountIsSchedulable_OutsideAvailabilityWindow(t *testing.T) {
+	now := time.Now().UTC()
+	closed := availabilityTestAccount(map[string]any{
+		"timezone": "UTC",
+		"windows": []any{map[string]any{
+			"days":  []any{float64(now.Add(48 * time.Hour).Weekday())},
+			"start": "00:00",
+			"end":   "24:00",
+		}},
+	})
+	require.False(t, closed.IsSchedulable())
+
+	require.True(t, (&Account{Status: StatusActive, Schedulable: true}).IsSchedulable())
+}
+
+func TestValidateAvailabilitySchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   map[string]any
+		wantErr bool
+	}{
+		{name: "nil extra", extra: nil},
+		{name: "not configured", extra: map[string]any{"quota_limit": 1.0}},
+		{name: "valid", extra: map[string]any{availabilityScheduleExtraKey: map[string]any{
+			"timezone": "Europe/Berlin",
+			"windows":  []any{map[string]any{"days": []any{1.0}, "start": "18:00", "end": "24:00"}},
+		}}},
+		{name: "not an object", extra: map[string]any{availabilityScheduleExtraKey: "nights"}, wantErr: true},
+		{name: "bad timezone", extra: map[string]any{availabilityScheduleExtraKey: map[string]any{"timezone": "Mars/Olympus"}}, wantErr: true},
+		{name: "bad weekday", extra: map[string]any{availabilityScheduleExtraKey: map[string]any{
+			"windows": []any{map[string]any{"days": []any{7.0}, "start": "00:00", "end": "01:00"}},
+		}}, wantErr: true},
+		{name: "missing days", extra: map[string]any{availabilityScheduleExtraKey: map[string]any{
+			"windows": []any{map[string]any{"start": "00:00", "end": "01:00"}},
+		}}, wantErr: true},
+		{name: "bad clock", extra: map[string]any{availabilityScheduleExtraKey: map[string]any{
+			"windows": []any{map[string]any{"days": []any{1.0}, "start": "25:00", "end": "01:00"}},
+		}}, wantErr: true},
+		{name: "start equals end", extra: map[string]any{availabilityScheduleExtraKey: map[string]any{
+			"windows": []any{map[string]any{"days": []any{1.0}, "start": "08:00", "end": "08:00"}},
+		}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAvailabilitySchedule(tt.extra)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFilterAccountsByAvailability(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	accounts := []Account{
+		{ID: 1},
+		*availabilityTestAccount(map[string]any{
+			"timezone": "UTC",
+			"windows":  []any{map[string]any{"days": []any{1.0}, "start": "20:00", "end": "08:00"}},
+		}),
+	}
+	accounts[1].ID = 2
+
+	filtered := filterAccountsByAvailability(accounts, now)
+	require.Len(t, filtered, 1)
+	require.Equal(t, int64(1), filtered[0].ID)
+}
+
+func TestAccountAvailabilityScheduleParsedOnce(t *testing.T) {
+	account := availabilityTestAccount(map[string]any{
+		"windows": []any{map[string]any{"days": []any{0.0, 1.0, 2.0, 3.0, 4.0, 5.0, 6.0}, "start": "08:00", "end": "20:00"}},
+	})
+	first := account.cachedAvailabilitySchedule()
+	require.NotNil(t, first)
+	require.Same(t, first, account.cachedAvailabilitySchedule())
+
+	// extra 整体替换（例如重新加载或更新账号）后重新解析
+	account.Extra = map[string]any{availabilityScheduleExtraKey: map[string]any{"windows": []any{}}}
+	second := account.cachedAvailabilitySchedule()
+	require.NotSame(t, first, second)
+	require.True(t, account.IsWithinAvailabilityWindow(time.Now()))
+
+	account.Extra = nil
+	require.Nil(t, account.cachedAvailabilitySchedule())
+}
+
+// availabilityLockCache 模拟多实例共享的分钟级检查锁
+type availabilityLockCache struct {
+	SchedulerCache
+	mu      sync.Mutex
+	locked  map[int64]bool
+	lockErr error
+}
+
+func (c *availabilityLockCache) TryLockAvailabilityCheck(_ context.Context, minute time.Time, _ time.Duration) (bool, error) {
+	if c.lockErr != nil {
+		return false, c.lockErr
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.locked[minute.Unix()] {
+		return false, nil
+	}
+	c.locked[minute.Unix()] = true
+	return true, nil
+}
+
+type availabilityAccountRepo struct {
+	AccountRepository
+	accounts  []Account
+	listCalls int
+}
+
+func (r *availabilityAccountRepo) ListSchedulable(context.Context) ([]Account, error) {
+	r.listCalls++
+	return r.accounts, nil
+}
+
+type availabilityOutboxRepo struct {
+	SchedulerOutboxRepository
+	enqueued []int64
+}
+
+func (r *availabilityOutboxRepo) EnqueueAccountChanged(_ context.Context, accountID int64, _ []int64) error {
+	r.enqueued = append(r.enqueued, accountID)
+	return nil
+}
+
+func TestSchedulerAvailabilityBoundaryHandledOncePerMinute(t *testing.T) {
+	// 每天 08:00 - 20:00 可用（UTC）
+	account := availabilityTestAccount(map[string]any{
+		"timezone": "UTC",
+		"windows":  []any{map[string]any{"days": []any{0.0, 1.0, 2.0, 3.0, 4.0, 5.0, 6.0}, "start": "08:00", "end": "20:00"}},
+	})
+	boundary := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	cache := &availabilityLockCache{locked: map[int64]bool{}}
+	outbox := &availabilityOutboxRepo{}
+
+	// 两个实例共享锁：同一分钟边界只由一个实例查询账号并写入事件
+	repos := []*availabilityAccountRepo{{accounts: []Account{*account}}, {accounts: []Account{*account}}}
+	for _, repo := range repos {
+		svc := &SchedulerSnapshotService{cache: cache, accountRepo: repo, outboxRepo: outbox}
+		svc.handleAvailabilityMinute(boundary.Add(-time.Minute), boundary)
+	}
+	require.Equal(t, 1, repos[0].listCalls+repos[1].listCalls)
+	require.Equal(t, []int64{1}, outbox.enqueued)
+
+	// 未跨越边界的分钟不写入事件
+	svc := &SchedulerSnapshotService{cache: cache, accountRepo: repos[0], outboxRepo: outbox}
+	svc.handleAvailabilityMinute(boundary, boundary.Add(time.Minute))
+	require.Equal(t, []int64{1}, outbox.enqueued)
+
+	// 锁读写失败时仍然检查，避免错过边界
+	failing := &SchedulerSnapshotService{
+		cache:       &availabilityLockCache{lockErr: errors.New("redis down")},
+		accountRepo: repos[1],
+		outboxRepo:  outbox,
+	}
+	failing.handleAvailabilityMinute(boundary.Add(-time.Minute), boundary)
+	require.Equal(t, []int64{1, 1}, outbox.enqueued)
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// 账号内按模型的并发 / RPM 限制
+//
+// 上游对高价模型（如 opus、图像模型）的限制往往比账号整体更紧。配置存放在
+// extra.model_limits 中，例如：
+//
+//	[{"pattern": "claude-opus-*", "concurrency": 2, "rpm": 20}, {"pattern": "claude-3-5-haiku-20241022", "concurrency": 8}]
+//
+// pattern 支持精确匹配与末尾 * 通配，多个规则命中时取最长 pattern。
+// concurrency / rpm 为 0 表示该维度不限制；账号整体的 concurrency 仍然生效。
+const accountModelLimitsExtraKey = "model_limits"
+
+// AccountModelLimit 单条模型级限制规则
+type AccountModelLimit struct {
+	Pattern     string `json:"pattern"`
+	Concurrency int    `json:"concurrency,omitempty"`
+	RPM         int    `json:"rpm,omitempty"`
+}
+
+// AccountModelScope 模型级槽位的作用域（账号 + 命中的 pattern）
+type AccountModelScope struct {
+	AccountID int64
+	Pattern   string
+}
+
+// AccountModelLoadInfo 模型级槽位的实时占用
+type AccountModelLoadInfo struct {
+	CurrentConcurrency int
+	CurrentRPM         int
+}
+
+// LoadRate 按限制规则计算模型级负载率（取并发与 RPM 中较高者）
+func (l *AccountModelLimit) LoadRate(info *AccountModelLoadInfo) int {
+	if l == nil || info == nil {
+		return 0
+	}
+	rate := 0
+	if l.Concurrency > 0 {
+		rate = info.CurrentConcurrency * 100 / l.Concurrency
+	}
+	if l.RPM > 0 {
+		rate = max(rate, info.CurrentRPM*100/l.RPM)
+	}
+	return rate
+}
+
+// GetModelLimits 返回账号的模型级限制；未配置或配置非法返回 nil（配置在保存时已校验）。
+// 解析结果按 extra 中原始配置的切片指针缓存，调度热路径上同一账号实例只解析一次；返回的切片不可修改。
+func (a *Account) GetModelLimits() []AccountModelLimit {
+	if a == nil {
+		return nil
+	}
+	rawLimits, _ := a.Extra[accountModelLimitsExtraKey].([]any)
+	rawPtr := slicePtr(rawLimits)
+	rawLen := len(rawLimits)
+	if a.modelLimitsCacheReady &&
+		a.modelLimitsCacheRawPtr == rawPtr &&
+		a.modelLimitsCacheRawLen == rawLen {
+		return a.modelLimitsCache
+	}
+
+	var limits []AccountModelLimit
+	if len(rawLimits) > 0 {
+		parsed, err := parseAccountModelLimits(rawLimits)
+		if err == nil {
+			limits = parsed
+		}
+	}
+	a.modelLimitsCache = limits
+	a.modelLimitsCacheReady = true
+	a.modelLimitsCacheRawPtr = rawPtr
+	a.modelLimitsCacheRawLen = rawLen
+	return limits
+}
+
+// MatchModelLimit 返回命中请求模型的限制规则副本（最长 pattern 优先）；无命中返回 nil
+func (a *Account) MatchModelLimit(model string) *AccountModelLimit {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return nil
+	}
+	var matched *AccountModelLimit
+	for _, limit := range a.GetModelLimits() {
+		if !matchWildcard(limit.Pattern, model) {
+			continue
+		}
+		if matched == nil || len(limit.Pattern) > len(matched.Pattern) {
+			matched = &limit
+		}
+	}
+	return matched
+}
+
+// ValidateAccountModelLimits 校验 extra 中的模型级限制配置
+func ValidateAccountModelLimits(extra map[string]any) error {
+	if extra == nil {
+		return nil
+	}
+	raw, ok := extra[accountModelLimitsExtraKey]
+	if !ok || raw == nil {
+		return nil
+	}
+	_, err := parseAccountModelLimits(raw)
+	return err
+}
+
+func parseAccountModelLimits(raw any) ([]AccountModelLimit, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("model_limits must be an array")
+	}
+	limits := make([]AccountModelLimit, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("model_limits[%d] must be an object", i)
+		}
+		pattern, _ := m["pattern"].(string)
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			return nil, fmt.Errorf("model_limits[%d].pattern is required", i)
+		}
+		if idx := strings.Index(pattern, "*"); idx >= 0 && idx != len(pattern)-1 {
+			return nil, fmt.Errorf("model_limits[%d].pattern only supports a trailing *", i)
+		}
+		if _, dup := seen[pattern]; dup {
+			return nil, fmt.Errorf("model_limits[%d].pattern %q is duplicated", i, pattern)
+		}
+		seen[pattern] = struct{}{}
+
+		limit := AccountModelLimit{
+			Pattern:     pattern,
+			Concurrency: int(parseExtraFloat64(m["concurrency"])),
+			RPM:         int(parseExtraFloat64(m["rpm"])),
+		}
+		if limit.Concurrency < 0 || limit.RPM < 0 {
+			return nil, fmt.Errorf("model_limits[%d] concurrency and rpm must be non-negative", i)
+		}
+		if limit.Concurrency == 0 && limit.RPM == 0 {
+			return nil, fmt.Errorf("model_limits[%d] must set concurrency or rpm", i)
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
+
+// applyModelLoadRates 将命中 model_limits 的账号的模型级负载并入调度负载率。
+// 只有命中规则的请求模型才受模型级负载影响，廉价模型不会因昂贵模型槽位占满而被判为高负载。
+func (s *GatewayService) applyModelLoadRates(ctx context.Context, accounts []*Account, requestedModel string, loadMap map[int64]*AccountLoadInfo) map[int64]*AccountLoadInfo {
+	if s.concurrencyService == nil || requestedModel == "" {
+		return loadMap
+	}
+	limits := make(map[int64]*AccountModelLimit)
+	scopes := make([]AccountModelScope, 0)
+	for _, acc := range accounts {
+		if limit := acc.MatchModelLimit(requestedModel); limit != nil {
+			limits[acc.ID] = limit
+			scopes = append(scopes, AccountModelScope{AccountID: acc.ID, Pattern: limit.Pattern})
+		}
+	}
+	if len(scopes) == 0 {
+		return loadMap
+	}
+	modelLoads, err := s.concurrencyService.GetAccountModelLoadBatch(ctx, scopes)
+	if err != nil {
+		logger.LegacyPrintf("service.gateway", "[ModelLimits] model load batch failed: %v", err)
+		return loadMap
+	}
+	if loadMap == nil {
+		loadMap = make(map[int64]*AccountLoadInfo, len(limits))
+	}
+	for accountID, limit := range limits {
+		rate := limit.LoadRate(modelLoads[accountID])
+		info := loadMap[accountID]
+		if info == nil {
+			info = &AccountLoadInfo{AccountID: accountID}
+			loadMap[accountID] = info
+		}
+		info.LoadRate = max(info.LoadRate, rate)
+	}
+	return loadMap
+}
@@ -0,0 +1,130 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountMatchModelLimit(t *testing.T) {
+	account := &Account{Extra: map[string]any{
+		"model_limits": []any{
+			map[string]any{"pattern": "claude-*", "concurrency": float64(10)},
+			map[string]any{"pattern": "claude-opus-*", "concurrency": float64(2), "rpm": float64(20)},
+			map[string]any{"pattern": "claude-opus-4-1", "rpm": "5"},
+		},
+	}}
+
+	tests := []struct {
+		model   string
+		pattern string
+	}{
+		{model: "claude-opus-4-20250514", pattern: "claude-opus-*"},
+		{model: "claude-opus-4-1", pattern: "claude-opus-4-1"},
+		{model: "claude-3-5-haiku-20241022", pattern: "claude-*"},
+		{model: "gpt-4o", pattern: ""},
+		{model: "", pattern: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			limit := account.MatchModelLimit(tt.model)
+			if tt.pattern == "" {
+				require.Nil(t, limit)
+				return
+			}
+			require.NotNil(t, limit)
+			require.Equal(t, tt.pattern, limit.Pattern)
+		})
+	}
+
+	require.Equal(t, 5, account.MatchModelLimit("claude-opus-4-1").RPM)
+	require.Nil(t, (&Account{}).MatchModelLimit("claude-opus-4"))
+}
+
+func TestAccountModelLimitsParsedOnce(t *testing.T) {
+	account := &Account{Extra: map[string]any{
+		"model_limits": []any{map[string]any{"pattern": "claude-opus-*", "concurrency": float64(2)}},
+	}}
+	first := account.GetModelLimits()
+	require.Len(t, first, 1)
+	require.Same(t, &first[0], &account.GetModelLimits()[0])
+
+	// 返回副本，调用方修改不影响缓存
+	account.MatchModelLimit("claude-opus-4").Concurrency = 100
+	require.Equal(t, 2, account.MatchModelLimit("claude-opus-4").Concurrency)
+
+	// extra 整体替换（例如重新加载或更新账号）后重新解析
+	account.Extra = map[string]any{"model_limits": []any{map[string]any{"pattern": "claude-opus-*", "rpm": float64(5)}}}
+	require.Equal(t, 5, account.MatchModelLimit("claude-opus-4").RPM)
+
+	account.Extra = map[string]any{"model_limits": "invalid"}
+	require.Nil(t, account.GetModelLimits())
+	account.Extra = nil
+	require.Nil(t, account.MatchModelLimit("claude-opus-4"))
+}
+
+func TestValidateAccountModelLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		wantErr bool
+	}{
+		{name: "valid", raw: []any{map[string]any{"pattern": "claude-opus-*", "concurrency": 2}}},
+		{name: "not an array", raw: map[string]any{"pattern": "x"}, wantErr: true},
+		{name: "missing pattern", raw: []any{map[string]any{"concurrency": 2}}, wantErr: true},
+		{name: "inner wildcard", raw: []any{map[string]any{"pattern": "claude-*-opus", "concurrency": 2}}, wantErr: true},
+		{name: "duplicate pattern", raw: []any{
+			map[string]any{"pattern": "claude-opus-*", "concurrency": 2},
+			map[string]any{"pattern": "claude-opus-*", "rpm": 2},
+		}, wantErr: true},
+		{name: "negative concurrency", raw: []any{map[string]any{"pattern": "a", "concurrency": -1}}, wantErr: true},
+		{name: "no limit set", raw: []any{map[string]any{"pattern": "a"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAccountModelLimits(map[string]any{"model_limits": tt.raw})
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+	require.NoError(t, ValidateAccountModelLimits(nil))
+}
+
+func TestAccountModelLimitLoadRate(t *testing.T) {
+	limit := &AccountModelLimit{Pattern: "claude-opus-*", Concurrency: 4, RPM: 10}
+
+	require.Equal(t, 50, limit.LoadRate(&AccountModelLoadInfo{CurrentConcurrency: 2, CurrentRPM: 3}))
+	require.Equal(t, 100, limit.LoadRate(&AccountModelLoadInfo{CurrentConcurrency: 1, CurrentRPM: 10}))
+	require.Zero(t, limit.LoadRate(nil))
+	require.Equal(t, 200, (&AccountModelLimit{Concurrency: 1}).LoadRate(&AccountModelLoadInfo{CurrentConcurrency: 2, CurrentRPM: 50}))
+}
+
+func TestApplyModelLoadRates(t *testing.T) {
+	limited := &Account{ID: 1, Extra: map[string]any{
+		"model_limits": []any{map[string]any{"pattern": "claude-opus-*", "concurrency": 2}},
+	}}
+	unlimited := &Account{ID: 2}
+	cache := &stubConcurrencyCacheForTest{modelLoadBatch: map[int64]*AccountModelLoadInfo{
+		1: {CurrentConcurrency: 2},
+	}}
+	svc := &GatewayService{concurrencyService: NewConcurrencyService(cache)}
+
+	loads := map[int64]*AccountLoadInfo{
+		1: {AccountID: 1, LoadRate: 10},
+		2: {AccountID: 2, LoadRate: 30},
+	}
+	got := svc.applyModelLoadRates(context.Background(), []*Account{limited, unlimited}, "claude-opus-4", loads)
+	require.Equal(t, 100, got[1].LoadRate, "opus slots are full on the limited account")
+	require.Equal(t, 30, got[2].LoadRate)
+
+	// 未命中规则的模型（如 haiku）不受 opus 槽位占用影响
+	loads = map[int64]*AccountLoadInfo{1: {AccountID: 1, LoadRate: 10}}
+	got = svc.applyModelLoadRates(context.Background(), []*Account{limited}, "claude-3-5-haiku", loads)
+	require.Equal(t, 10, got[1].LoadRate)
+}
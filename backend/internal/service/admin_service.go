@@ -102,6 +102,9 @@ type CreateUserInput struct {
 	Concurrency           int
 	AllowedGroups         []int64
 	SoraStorageQuotaBytes int64
+	// 公平排队权重与优先级档位（0 表示使用分组配置）
+	QueueWeight   int
+	QueuePriority int
 }
 
 type UpdateUserInput struct {
@@ -117,6 +120,9 @@ type UpdateUserInput struct {
 	// map[groupID]*rate，nil 表示删除该分组的专属倍率
 	GroupRates            map[int64]*float64
 	SoraStorageQuotaBytes *int64
+	// 公平排队权重与优先级档位（0 表示使用分组配置）
+	QueueWeight   *int
+	QueuePriority *int
 }
 
 type CreateGroupInput struct {
@@ -145,7 +151,11 @@ type CreateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool // 是否启用模型路由
-	MCPXMLInject        *bool
+	// 模型降级链（pattern -> 有序降级目标）
+	ModelFallbacks map[string][]ModelFallbackTarget
+	// 请求改写规则（按顺序在转发前执行）
+	RequestTransforms []RequestTransformRule
+	MCPXMLInject      *bool
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string
 	// Sora 存储配额
@@ -153,6 +163,18 @@ type CreateGroupInput struct {
 	// OpenAI Messages 调度配置（仅 openai 平台使用）
 	AllowMessagesDispatch bool
 	DefaultMappedModel    string
+	// 订阅售价（nil/负数表示不可购买/续费）与续费周期（天，0 表示使用默认有效期）
+	Price             *float64
+	RenewalPeriodDays int
+	// 公平排队权重（0 表示默认 1）与优先级档位（0-9）
+	QueueWeight   int
+	QueuePriority int
+	// 账号调度策略（空表示默认，cost_aware 表示成本感知）
+	SchedulingStrategy string
+	// 对冲请求配置（分位数 0 表示默认 95，计费策略空表示 ignore）
+	HedgeEnabled      bool
+	HedgePercentile   int
+	HedgeLoserBilling string
 	// 从指定分组复制账号（创建分组后在同一事务内绑定）
 	CopyAccountsFromGroupIDs []int64
 }
@@ -184,7 +206,11 @@ type UpdateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled *bool // 是否启用模型路由
-	MCPXMLInject        *bool
+	// 模型降级链（nil 表示不修改，空 map 表示清空）
+	ModelFallbacks map[string][]ModelFallbackTarget
+	// 请求改写规则（nil 表示不修改，空数组表示清空）
+	RequestTransforms []RequestTransformRule
+	MCPXMLInject      *bool
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes *[]string
 	// Sora 存储配额
@@ -192,6 +218,18 @@ type UpdateGroupInput struct {
 	// OpenAI Messages 调度配置（仅 openai 平台使用）
 	AllowMessagesDispatch *bool
 	DefaultMappedModel    *string
+	// 订阅售价（负数表示清除，即不可购买/续费）与续费周期（天）
+	Price             *float64
+	RenewalPeriodDays *int
+	// 公平排队权重与优先级档位
+	QueueWeight   *int
+	QueuePriority *int
+	// 账号调度策略
+	SchedulingStrategy *string
+	// 对冲请求配置
+	HedgeEnabled      *bool
+	HedgePercentile   *int
+	HedgeLoserBilling *string
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64
 }
@@ -554,6 +592,9 @@ func (s *adminServiceImpl) GetUser(ctx context.Context, id int64) (*User, error)
 }
 
 func (s *adminServiceImpl) CreateUser(ctx context.Context, input *CreateUserInput) (*User, error) {
+	if err := validateFairQueueSettings(input.QueueWeight, input.QueuePriority); err != nil {
+		return nil, err
+	}
 	user := &User{
 		Email:                 input.Email,
 		Username:              input.Username,
@@ -564,6 +605,8 @@ func (s *adminServiceImpl) CreateUser(ctx context.Context, input *CreateUserInpu
 		Status:                StatusActive,
 		AllowedGroups:         input.AllowedGroups,
 		SoraStorageQuotaBytes: input.SoraStorageQuotaBytes,
+		QueueWeight:           input.QueueWeight,
+		QueuePriority:         input.QueuePriority,
 	}
 	if err := user.SetPassword(input.Password); err != nil {
 		return nil, err
@@ -606,6 +649,8 @@ func (s *adminServiceImpl) UpdateUser(ctx context.Context, id int64, input *Upda
 	oldConcurrency := user.Concurrency
 	oldStatus := user.Status
 	oldRole := user.Role
+	oldQueueWeight := user.QueueWeight
+	oldQueuePriority := user.QueuePriority
 
 	if input.Email != "" {
 		user.Email = input.Email
@@ -639,6 +684,16 @@ func (s *adminServiceImpl) UpdateUser(ctx context.Context, id int64, input *Upda
 		user.SoraStorageQuotaBytes = *input.SoraStorageQuotaBytes
 	}
 
+	if input.QueueWeight != nil {
+		user.QueueWeight = *input.QueueWeight
+	}
+	if input.QueuePriority != nil {
+		user.QueuePriority = *input.QueuePriority
+	}
+	if err := validateFairQueueSettings(user.QueueWeight, user.QueuePriority); err != nil {
+		return nil, err
+	}
+
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
@@ -651,7 +706,8 @@ func (s *adminServiceImpl) UpdateUser(ctx context.Context, id int64, input *Upda
 	}
 
 	if s.authCacheInvalidator != nil {
-		if user.Concurrency != oldConcurrency || user.Status != oldStatus || user.Role != oldRole {
+		if user.Concurrency != oldConcurrency || user.Status != oldStatus || user.Role != oldRole ||
+			user.QueueWeight != oldQueueWeight || user.QueuePriority != oldQueuePriority {
 			s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, user.ID)
 		}
 	}
@@ -845,6 +901,26 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 	soraImagePrice540 := normalizePrice(input.SoraImagePrice540)
 	soraVideoPrice := normalizePrice(input.SoraVideoPricePerRequest)
 	soraVideoPriceHD := normalizePrice(input.SoraVideoPricePerRequestHD)
+	if input.RenewalPeriodDays < 0 || input.RenewalPeriodDays > MaxValidityDays {
+		return nil, ErrInvalidRenewalPeriod
+	}
+	if err := validateFairQueueSettings(input.QueueWeight, input.QueuePriority); err != nil {
+		return nil, err
+	}
+	if !IsValidSchedulingStrategy(input.SchedulingStrategy) {
+		return nil, ErrInvalidSchedulingStrategy
+	}
+	hedgePercentile := input.HedgePercentile
+	if hedgePercentile == 0 {
+		hedgePercentile = DefaultHedgePercentile
+	}
+	hedgeLoserBilling := input.HedgeLoserBilling
+	if hedgeLoserBilling == "" {
+		hedgeLoserBilling = HedgeLoserBillingIgnore
+	}
+	if err := validateHedgeSettings(hedgePercentile, hedgeLoserBilling); err != nil {
+		return nil, err
+	}
 
 	// 校验降级分组
 	if input.FallbackGroupID != nil {
@@ -863,6 +939,18 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		}
 	}
 
+	// 校验模型降级链
+	modelFallbacks, err := s.validateModelFallbacks(ctx, 0, platform, input.ModelFallbacks)
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验请求改写规则
+	requestTransforms, err := NormalizeRequestTransforms(input.RequestTransforms)
+	if err != nil {
+		return nil, err
+	}
+
 	// MCPXMLInject：默认为 true，仅当显式传入 false 时关闭
 	mcpXMLInject := true
 	if input.MCPXMLInject != nil {
@@ -923,11 +1011,21 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		FallbackGroupID:                 input.FallbackGroupID,
 		FallbackGroupIDOnInvalidRequest: fallbackOnInvalidRequest,
 		ModelRouting:                    input.ModelRouting,
+		ModelFallbacks:                  modelFallbacks,
+		RequestTransforms:               requestTransforms,
 		MCPXMLInject:                    mcpXMLInject,
 		SupportedModelScopes:            input.SupportedModelScopes,
 		SoraStorageQuotaBytes:           input.SoraStorageQuotaBytes,
 		AllowMessagesDispatch:           input.AllowMessagesDispatch,
 		DefaultMappedModel:              input.DefaultMappedModel,
+		Price:                           normalizePrice(input.Price),
+		RenewalPeriodDays:               input.RenewalPeriodDays,
+		QueueWeight:                     input.QueueWeight,
+		QueuePriority:                   input.QueuePriority,
+		SchedulingStrategy:              input.SchedulingStrategy,
+		HedgeEnabled:                    input.HedgeEnabled,
+		HedgePercentile:                 hedgePercentile,
+		HedgeLoserBilling:               hedgeLoserBilling,
 	}
 	if err := s.groupRepo.Create(ctx, group); err != nil {
 		return nil, err
@@ -1029,6 +1127,48 @@ func (s *adminServiceImpl) validateFallbackGroupOnInvalidRequest(ctx context.Con
 	return nil
 }
 
+// validateModelFallbacks 校验并规范化模型降级链
+// currentGroupID: 当前分组 ID（新建时为 0）；指向当前分组的目标视为分组内降级
+// 跨分组目标必须存在、平台一致且不能是订阅分组（降级请求不携带目标分组的订阅）
+func (s *adminServiceImpl) validateModelFallbacks(ctx context.Context, currentGroupID int64, platform string, chains map[string][]ModelFallbackTarget) (map[string][]ModelFallbackTarget, error) {
+	normalized, err := normalizeModelFallbacks(chains)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) == 0 {
+		return normalized, nil
+	}
+
+	checked := make(map[int64]struct{})
+	for pattern, targets := range normalized {
+		for i := range targets {
+			groupID := targets[i].GroupID
+			if groupID == nil {
+				continue
+			}
+			if currentGroupID > 0 && *groupID == currentGroupID {
+				targets[i].GroupID = nil
+				continue
+			}
+			if _, ok := checked[*groupID]; ok {
+				continue
+			}
+			target, err := s.groupRepo.GetByIDLite(ctx, *groupID)
+			if err != nil {
+				return nil, infraerrors.Newf(http.StatusBadRequest, "MODEL_FALLBACK_INVALID", "model fallback group %d not found (pattern %s)", *groupID, pattern)
+			}
+			if target.Platform != platform {
+				return nil, infraerrors.Newf(http.StatusBadRequest, "MODEL_FALLBACK_INVALID", "model fallback group %d must be %s platform", *groupID, platform)
+			}
+			if target.IsSubscriptionType() {
+				return nil, infraerrors.Newf(http.StatusBadRequest, "MODEL_FALLBACK_INVALID", "model fallback group %d cannot be subscription type", *groupID)
+			}
+			checked[*groupID] = struct{}{}
+		}
+	}
+	return normalized, nil
+}
+
 func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *UpdateGroupInput) (*Group, error) {
 	group, err := s.groupRepo.GetByID(ctx, id)
 	if err != nil {
@@ -1127,6 +1267,27 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	if input.ModelRoutingEnabled != nil {
 		group.ModelRoutingEnabled = *input.ModelRoutingEnabled
 	}
+
+	// 模型降级链：平台可能在本次更新中变化，已有配置也需要重新校验
+	modelFallbacks := group.ModelFallbacks
+	if input.ModelFallbacks != nil {
+		modelFallbacks = input.ModelFallbacks
+	}
+	modelFallbacks, err = s.validateModelFallbacks(ctx, id, group.Platform, modelFallbacks)
+	if err != nil {
+		return nil, err
+	}
+	group.ModelFallbacks = modelFallbacks
+
+	// 请求改写规则（空数组表示清空）
+	if input.RequestTransforms != nil {
+		requestTransforms, err := NormalizeRequestTransforms(input.RequestTransforms)
+		if err != nil {
+			return nil, err
+		}
+		group.RequestTransforms = requestTransforms
+	}
+
 	if input.MCPXMLInject != nil {
 		group.MCPXMLInject = *input.MCPXMLInject
 	}
@@ -1144,6 +1305,50 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 		group.DefaultMappedModel = *input.DefaultMappedModel
 	}
 
+	// 订阅售价与续费周期
+	if input.Price != nil {
+		group.Price = normalizePrice(input.Price)
+	}
+	if input.RenewalPeriodDays != nil {
+		if *input.RenewalPeriodDays < 0 || *input.RenewalPeriodDays > MaxValidityDays {
+			return nil, ErrInvalidRenewalPeriod
+		}
+		group.RenewalPeriodDays = *input.RenewalPeriodDays
+	}
+
+	// 公平排队配置
+	if input.QueueWeight != nil {
+		group.QueueWeight = *input.QueueWeight
+	}
+	if input.QueuePriority != nil {
+		group.QueuePriority = *input.QueuePriority
+	}
+	if err := validateFairQueueSettings(group.QueueWeight, group.QueuePriority); err != nil {
+		return nil, err
+	}
+
+	if input.SchedulingStrategy != nil {
+		if !IsValidSchedulingStrategy(*input.SchedulingStrategy) {
+			return nil, ErrInvalidSchedulingStrategy
+		}
+		group.SchedulingStrategy = *input.SchedulingStrategy
+	}
+
+	if input.HedgeEnabled != nil {
+		group.HedgeEnabled = *input.HedgeEnabled
+	}
+	if input.HedgePercentile != nil {
+		group.HedgePercentile = *input.HedgePercentile
+	}
+	if input.HedgeLoserBilling != nil {
+		group.HedgeLoserBilling = *input.HedgeLoserBilling
+	}
+	if input.HedgePercentile != nil || input.HedgeLoserBilling != nil {
+		if err := validateHedgeSettings(group.HedgePercentile, group.HedgeLoserBilling); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
 	}
@@ -1462,6 +1667,12 @@ func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccou
 		if err := ValidateQuotaResetConfig(account.Extra); err != nil {
 			return nil, err
 		}
+		if err := ValidateAvailabilitySchedule(account.Extra); err != nil {
+			return nil, err
+		}
+		if err := ValidateAccountModelLimits(account.Extra); err != nil {
+			return nil, err
+		}
 		ComputeQuotaResetAt(account.Extra)
 	}
 	if input.ExpiresAt != nil && *input.ExpiresAt > 0 {
@@ -1555,6 +1766,12 @@ func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *U
 		if err := ValidateQuotaResetConfig(account.Extra); err != nil {
 			return nil, err
 		}
+		if err := ValidateAvailabilitySchedule(account.Extra); err != nil {
+			return nil, err
+		}
+		if err := ValidateAccountModelLimits(account.Extra); err != nil {
+			return nil, err
+		}
 		ComputeQuotaResetAt(account.Extra)
 	}
 	if input.ProxyID != nil {
@@ -1701,6 +1918,10 @@ func (s *adminServiceImpl) BulkUpdateAccounts(ctx context.Context, input *BulkUp
 			return nil, errors.New("rate_multiplier must be >= 0")
 		}
 	}
+	// extra 按顶层键合并，availability_schedule 会被整体替换，与单个更新使用同一校验
+	if err := ValidateAvailabilitySchedule(input.Extra); err != nil {
+		return nil, err
+	}
 
 	// Prepare bulk updates for columns and JSONB fields.
 	repoUpdates := AccountBulkUpdate{
@@ -146,6 +146,9 @@ func (s *apiKeyRepoStubForGroupUpdate) UpdateLastUsed(context.Context, int64, ti
 func (s *apiKeyRepoStubForGroupUpdate) IncrementRateLimitUsage(context.Context, int64, float64) error {
 	panic("unexpected")
 }
+func (s *apiKeyRepoStubForGroupUpdate) UpdateStatus(context.Context, int64, string) error {
+	panic("unexpected")
+}
 func (s *apiKeyRepoStubForGroupUpdate) ResetRateLimitWindows(context.Context, int64) error {
 	panic("unexpected")
 }
@@ -170,3 +170,21 @@ func TestAdminService_BulkUpdateAccounts_MixedChannelPreCheckBlocksOnExistingCon
 	// No BindGroups should have been called since the check runs before any write.
 	require.Empty(t, repo.bindGroupsCalls)
 }
+
+// TestAdminService_BulkUpdateAccounts_RejectsInvalidAvailabilitySchedule 批量更新与单个更新使用同一可用时间表校验。
+func TestAdminService_BulkUpdateAccounts_RejectsInvalidAvailabilitySchedule(t *testing.T) {
+	repo := &accountRepoStubForBulkUpdate{}
+	svc := &adminServiceImpl{accountRepo: repo}
+
+	result, err := svc.BulkUpdateAccounts(context.Background(), &BulkUpdateAccountsInput{
+		AccountIDs: []int64{1, 2},
+		Extra: map[string]any{
+			availabilityScheduleExtraKey: map[string]any{
+				"windows": []any{map[string]any{"days": []any{9.0}, "start": "08:00", "end": "20:00"}},
+			},
+		},
+	})
+	require.Error(t, err)
+	require.Nil(t, result)
+	require.Empty(t, repo.bulkUpdateIDs)
+}
@@ -785,3 +785,169 @@ func TestAdminService_UpdateGroup_InvalidRequestFallbackAllowsAntigravity(t *tes
 	require.NotNil(t, repo.updated)
 	require.Equal(t, fallbackID, *repo.updated.FallbackGroupIDOnInvalidRequest)
 }
+
+func TestAdminService_CreateGroup_ModelFallbacksValidatesTargetGroups(t *testing.T) {
+	sameGroup := int64(10)
+	subscriptionGroup := int64(11)
+	openaiGroup := int64(12)
+	repo := &groupRepoStubForInvalidRequestFallback{
+		groups: map[int64]*Group{
+			sameGroup:         {ID: sameGroup, Platform: PlatformAnthropic, SubscriptionType: SubscriptionTypeStandard},
+			subscriptionGroup: {ID: subscriptionGroup, Platform: PlatformAnthropic, SubscriptionType: SubscriptionTypeSubscription},
+			openaiGroup:       {ID: openaiGroup, Platform: PlatformOpenAI, SubscriptionType: SubscriptionTypeStandard},
+		},
+	}
+	svc := &adminServiceImpl{groupRepo: repo}
+
+	_, err := svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:     "g1",
+		Platform: PlatformAnthropic,
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"claude-opus-*": {{Model: "claude-sonnet-4-5", GroupID: &openaiGroup}},
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must be anthropic platform")
+
+	_, err = svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:     "g1",
+		Platform: PlatformAnthropic,
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"claude-opus-*": {{Model: "claude-sonnet-4-5", GroupID: &subscriptionGroup}},
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot be subscription type")
+
+	require.Nil(t, repo.created)
+
+	group, err := svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:     "g-openai",
+		Platform: PlatformOpenAI,
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"gpt-5*": {{Model: "gpt-5-mini", GroupID: &openaiGroup}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, openaiGroup, *group.ModelFallbacks["gpt-5*"][0].GroupID)
+
+	group, err = svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:     "g-sora",
+		Platform: PlatformSora,
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"sora-*": {{Model: "sora2-landscape-10s"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "sora2-landscape-10s", group.ModelFallbacks["sora-*"][0].Model)
+
+	group, err = svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:     "g-gemini",
+		Platform: PlatformGemini,
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"gemini-2.5-pro": {{Model: "gemini-2.5-flash"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "gemini-2.5-flash", group.ModelFallbacks["gemini-2.5-pro"][0].Model)
+
+	group, err = svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:     "g1",
+		Platform: PlatformAnthropic,
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"claude-opus-*": {{Model: " claude-sonnet-4-5 "}, {Model: "claude-sonnet-4-5", GroupID: &sameGroup}},
+		},
+	})
+	require.NoError(t, err)
+	chain := group.ModelFallbacks["claude-opus-*"]
+	require.Len(t, chain, 2)
+	require.Equal(t, "claude-sonnet-4-5", chain[0].Model)
+	require.Equal(t, sameGroup, *chain[1].GroupID)
+}
+
+func TestAdminService_UpdateGroup_ModelFallbacksTargetSelfBecomesLocal(t *testing.T) {
+	groupID := int64(10)
+	otherID := int64(11)
+	repo := &groupRepoStubForInvalidRequestFallback{
+		groups: map[int64]*Group{
+			groupID: {ID: groupID, Platform: PlatformAnthropic, SubscriptionType: SubscriptionTypeStandard, Status: StatusActive},
+			otherID: {ID: otherID, Platform: PlatformAnthropic, SubscriptionType: SubscriptionTypeStandard, Status: StatusActive},
+		},
+	}
+	svc := &adminServiceImpl{groupRepo: repo}
+
+	group, err := svc.UpdateGroup(context.Background(), groupID, &UpdateGroupInput{
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"claude-opus-*": {{Model: "claude-sonnet-4-5", GroupID: &groupID}, {Model: "claude-haiku-4-5", GroupID: &otherID}},
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, repo.updated)
+	require.Nil(t, group.ModelFallbacks["claude-opus-*"][0].GroupID)
+
+	// 平台变化后，已有降级链中的跨分组目标需与新平台一致
+	_, err = svc.UpdateGroup(context.Background(), groupID, &UpdateGroupInput{Platform: PlatformOpenAI})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must be openai platform")
+}
+
+func TestAdminService_CreateGroup_RequestTransformsValidated(t *testing.T) {
+	repo := &groupRepoStubForInvalidRequestFallback{}
+	svc := &adminServiceImpl{groupRepo: repo}
+	maxTokens := float64(8192)
+
+	_, err := svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:              "g1",
+		Platform:          PlatformAnthropic,
+		RequestTransforms: []RequestTransformRule{{Action: RequestTransformSetHeader, Header: "Authorization", Value: "x"}},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot be modified")
+
+	require.Nil(t, repo.created)
+
+	for _, platform := range []string{PlatformOpenAI, PlatformSora, PlatformGemini} {
+		group, err := svc.CreateGroup(context.Background(), &CreateGroupInput{
+			Name:              "g-" + platform,
+			Platform:          platform,
+			RequestTransforms: []RequestTransformRule{{Action: RequestTransformClamp, Path: "max_tokens", Max: &maxTokens}},
+		})
+		require.NoError(t, err, platform)
+		require.Len(t, group.RequestTransforms, 1, platform)
+	}
+
+	group, err := svc.CreateGroup(context.Background(), &CreateGroupInput{
+		Name:              "g1",
+		Platform:          PlatformAnthropic,
+		RequestTransforms: []RequestTransformRule{{Action: " Clamp ", Path: " max_tokens ", Max: &maxTokens, Models: []string{" claude-*", ""}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, group.RequestTransforms, 1)
+	require.Equal(t, RequestTransformClamp, group.RequestTransforms[0].Action)
+	require.Equal(t, "max_tokens", group.RequestTransforms[0].Path)
+	require.Equal(t, []string{"claude-*"}, group.RequestTransforms[0].Models)
+}
+
+func TestAdminService_UpdateGroup_RequestTransformsKeptOnPlatformChange(t *testing.T) {
+	groupID := int64(10)
+	repo := &groupRepoStubForInvalidRequestFallback{
+		groups: map[int64]*Group{
+			groupID: {
+				ID:                groupID,
+				Platform:          PlatformAnthropic,
+				SubscriptionType:  SubscriptionTypeStandard,
+				Status:            StatusActive,
+				RequestTransforms: []RequestTransformRule{{Action: RequestTransformRemove, Path: "metadata"}},
+			},
+		},
+	}
+	svc := &adminServiceImpl{groupRepo: repo}
+
+	group, err := svc.UpdateGroup(context.Background(), groupID, &UpdateGroupInput{Platform: PlatformOpenAI})
+	require.NoError(t, err)
+	require.Len(t, group.RequestTransforms, 1)
+
+	group, err = svc.UpdateGroup(context.Background(), groupID, &UpdateGroupInput{RequestTransforms: []RequestTransformRule{}})
+	require.NoError(t, err)
+	require.Empty(t, group.RequestTransforms)
+}
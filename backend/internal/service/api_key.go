@@ -60,6 +60,9 @@ type APIKey struct {
 	Window5hStart *time.Time // Start of current 5h window
 	Window1dStart *time.Time // Start of current 1d window
 	Window7dStart *time.Time // Start of current 7d window
+
+	// Priority 调度优先级（interactive / normal / batch）
+	Priority string
 }
 
 func (k *APIKey) IsActive() bool {
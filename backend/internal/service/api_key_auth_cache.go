@@ -24,6 +24,9 @@ type APIKeyAuthSnapshot struct {
 	RateLimit5h float64 `json:"rate_limit_5h"`
 	RateLimit1d float64 `json:"rate_limit_1d"`
 	RateLimit7d float64 `json:"rate_limit_7d"`
+
+	// Priority 调度优先级（interactive / normal / batch）
+	Priority string `json:"priority,omitempty"`
 }
 
 // APIKeyAuthUserSnapshot 用户快照
@@ -33,6 +36,10 @@ type APIKeyAuthUserSnapshot struct {
 	Role        string  `json:"role"`
 	Balance     float64 `json:"balance"`
 	Concurrency int     `json:"concurrency"`
+
+	// 公平排队配置在账号满载排队时由网关读取
+	QueueWeight   int `json:"queue_weight,omitempty"`
+	QueuePriority int `json:"queue_priority,omitempty"`
 }
 
 // APIKeyAuthGroupSnapshot 分组快照
@@ -63,12 +70,30 @@ type APIKeyAuthGroupSnapshot struct {
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 	MCPXMLInject        bool               `json:"mcp_xml_inject"`
 
+	// 模型降级链在调度失败/上游过载时由网关读取，同样需要进入快照
+	ModelFallbacks map[string][]ModelFallbackTarget `json:"model_fallbacks,omitempty"`
+
+	// 请求改写规则在网关转发前执行
+	RequestTransforms []RequestTransformRule `json:"request_transforms,omitempty"`
+
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string `json:"supported_model_scopes,omitempty"`
 
 	// OpenAI Messages 调度配置（仅 openai 平台使用）
 	AllowMessagesDispatch bool   `json:"allow_messages_dispatch"`
 	DefaultMappedModel    string `json:"default_mapped_model,omitempty"`
+
+	// 公平排队配置
+	QueueWeight   int `json:"queue_weight,omitempty"`
+	QueuePriority int `json:"queue_priority,omitempty"`
+
+	// 账号调度策略
+	SchedulingStrategy string `json:"scheduling_strategy,omitempty"`
+
+	// 对冲请求配置
+	HedgeEnabled      bool   `json:"hedge_enabled,omitempty"`
+	HedgePercentile   int    `json:"hedge_percentile,omitempty"`
+	HedgeLoserBilling string `json:"hedge_loser_billing,omitempty"`
 }
 
 // APIKeyAuthCacheEntry 缓存条目，支持负缓存
@@ -212,12 +212,15 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 		RateLimit5h: apiKey.RateLimit5h,
 		RateLimit1d: apiKey.RateLimit1d,
 		RateLimit7d: apiKey.RateLimit7d,
+		Priority:    apiKey.Priority,
 		User: APIKeyAuthUserSnapshot{
-			ID:          apiKey.User.ID,
-			Status:      apiKey.User.Status,
-			Role:        apiKey.User.Role,
-			Balance:     apiKey.User.Balance,
-			Concurrency: apiKey.User.Concurrency,
+			ID:            apiKey.User.ID,
+			Status:        apiKey.User.Status,
+			Role:          apiKey.User.Role,
+			Balance:       apiKey.User.Balance,
+			Concurrency:   apiKey.User.Concurrency,
+			QueueWeight:   apiKey.User.QueueWeight,
+			QueuePriority: apiKey.User.QueuePriority,
 		},
 	}
 	if apiKey.Group != nil {
@@ -243,10 +246,18 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 			FallbackGroupIDOnInvalidRequest: apiKey.Group.FallbackGroupIDOnInvalidRequest,
 			ModelRouting:                    apiKey.Group.ModelRouting,
 			ModelRoutingEnabled:             apiKey.Group.ModelRoutingEnabled,
+			ModelFallbacks:                  apiKey.Group.ModelFallbacks,
+			RequestTransforms:               apiKey.Group.RequestTransforms,
 			MCPXMLInject:                    apiKey.Group.MCPXMLInject,
 			SupportedModelScopes:            apiKey.Group.SupportedModelScopes,
 			AllowMessagesDispatch:           apiKey.Group.AllowMessagesDispatch,
 			DefaultMappedModel:              apiKey.Group.DefaultMappedModel,
+			QueueWeight:                     apiKey.Group.QueueWeight,
+			QueuePriority:                   apiKey.Group.QueuePriority,
+			SchedulingStrategy:              apiKey.Group.SchedulingStrategy,
+			HedgeEnabled:                    apiKey.Group.HedgeEnabled,
+			HedgePercentile:                 apiKey.Group.HedgePercentile,
+			HedgeLoserBilling:               apiKey.Group.HedgeLoserBilling,
 		}
 	}
 	return snapshot
@@ -270,12 +281,15 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 		RateLimit5h: snapshot.RateLimit5h,
 		RateLimit1d: snapshot.RateLimit1d,
 		RateLimit7d: snapshot.RateLimit7d,
+		Priority:    snapshot.Priority,
 		User: &User{
-			ID:          snapshot.User.ID,
-			Status:      snapshot.User.Status,
-			Role:        snapshot.User.Role,
-			Balance:     snapshot.User.Balance,
-			Concurrency: snapshot.User.Concurrency,
+			ID:            snapshot.User.ID,
+			Status:        snapshot.User.Status,
+			Role:          snapshot.User.Role,
+			Balance:       snapshot.User.Balance,
+			Concurrency:   snapshot.User.Concurrency,
+			QueueWeight:   snapshot.User.QueueWeight,
+			QueuePriority: snapshot.User.QueuePriority,
 		},
 	}
 	if snapshot.Group != nil {
@@ -302,10 +316,18 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 			FallbackGroupIDOnInvalidRequest: snapshot.Group.FallbackGroupIDOnInvalidRequest,
 			ModelRouting:                    snapshot.Group.ModelRouting,
 			ModelRoutingEnabled:             snapshot.Group.ModelRoutingEnabled,
+			ModelFallbacks:                  snapshot.Group.ModelFallbacks,
+			RequestTransforms:               snapshot.Group.RequestTransforms,
 			MCPXMLInject:                    snapshot.Group.MCPXMLInject,
 			SupportedModelScopes:            snapshot.Group.SupportedModelScopes,
 			AllowMessagesDispatch:           snapshot.Group.AllowMessagesDispatch,
 			DefaultMappedModel:              snapshot.Group.DefaultMappedModel,
+			QueueWeight:                     snapshot.Group.QueueWeight,
+			QueuePriority:                   snapshot.Group.QueuePriority,
+			SchedulingStrategy:              snapshot.Group.SchedulingStrategy,
+			HedgeEnabled:                    snapshot.Group.HedgeEnabled,
+			HedgePercentile:                 snapshot.Group.HedgePercentile,
+			HedgeLoserBilling:               snapshot.Group.HedgeLoserBilling,
 		}
 	}
 	s.compileAPIKeyIPRules(apiKey)
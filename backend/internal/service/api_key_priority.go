@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// API Key 调度优先级
+//
+// 交互式（IDE）使用与批量任务常共用同一分组。API Key 可标记为：
+//   - interactive: 可使用账号的全部槽位
+//   - normal: 默认；每个账号按 interactive_reserved_ratio 预留的槽位不可被占用
+//   - batch: 同 normal 不可占用预留槽位，且只调度到负载率低于 gateway.scheduling.batch_load_threshold 的账号（含粘性会话）；
+//     账号满载时进入独立的 batch 等待队列（更长的超时，不参与公平排队，避免挡在交互式请求之前）
+const (
+	APIKeyPriorityInteractive = "interactive"
+	APIKeyPriorityNormal      = "normal"
+	APIKeyPriorityBatch       = "batch"
+)
+
+var ErrInvalidAPIKeyPriority = infraerrors.BadRequest("INVALID_API_KEY_PRIORITY", "priority must be interactive, normal or batch")
+
+// NormalizeAPIKeyPriority 规范化并校验优先级，空值视为 normal
+func NormalizeAPIKeyPriority(priority string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(priority)); p {
+	case "":
+		return APIKeyPriorityNormal, nil
+	case APIKeyPriorityInteractive, APIKeyPriorityNormal, APIKeyPriorityBatch:
+		return p, nil
+	default:
+		return "", ErrInvalidAPIKeyPriority
+	}
+}
+
+// EffectivePriority 返回 API Key 的调度优先级，未设置或非法时视为 normal
+func (k *APIKey) EffectivePriority() string {
+	if k == nil {
+		return APIKeyPriorityNormal
+	}
+	p, err := NormalizeAPIKeyPriority(k.Priority)
+	if err != nil {
+		return APIKeyPriorityNormal
+	}
+	return p
+}
+
+// WithRequestPriority 在请求上下文中记录调度优先级（由网关 handler 根据 API Key 设置）
+func WithRequestPriority(ctx context.Context, priority string) context.Context {
+	return updateRequestMetadata(ctx, false, func(md *RequestMetadata) {
+		v := priority
+		md.RequestPriority = &v
+	}, nil)
+}
+
+// RequestPriorityFromContext 返回请求的调度优先级，未设置时为 normal
+func RequestPriorityFromContext(ctx context.Context) string {
+	if md := metadataFromContext(ctx); md != nil && md.RequestPriority != nil {
+		return *md.RequestPriority
+	}
+	return APIKeyPriorityNormal
+}
+
+// isBatchRequest 判断当前请求是否为 batch 优先级
+func isBatchRequest(ctx context.Context) bool {
+	return RequestPriorityFromContext(ctx) == APIKeyPriorityBatch
+}
+
+// reservedSlotLimit 返回非 interactive 请求在账号上可使用的槽位上限：扣除向上取整的预留槽位，至少保留 1 个。
+// concurrency <= 0（不限制）时原样返回。
+func reservedSlotLimit(concurrency int, reservedRatio float64) int {
+	if concurrency <= 0 || reservedRatio <= 0 {
+		return concurrency
+	}
+	reserved := int(math.Ceil(float64(concurrency) * reservedRatio))
+	return max(1, concurrency-reserved)
+}
+
+// prioritySlotLimit 返回当前请求在账号上可使用的并发上限，只有 interactive 请求可使用预留槽位
+func prioritySlotLimit(ctx context.Context, cfg config.GatewaySchedulingConfig, concurrency int) int {
+	if RequestPriorityFromContext(ctx) == APIKeyPriorityInteractive {
+		return concurrency
+	}
+	return reservedSlotLimit(concurrency, cfg.InteractiveReservedRatio)
+}
+
+// priorityLoadLimit 返回负载感知选择时账号负载率的上限（不含），batch 请求使用更低的阈值
+func priorityLoadLimit(ctx context.Context, cfg config.GatewaySchedulingConfig) int {
+	if !isBatchRequest(ctx) {
+		return 100
+	}
+	threshold := cfg.BatchLoadThreshold
+	if threshold <= 0 || threshold > 100 {
+		return 100
+	}
+	return threshold
+}
+
+// applyPriorityToWaitPlan 按请求优先级收紧等待计划的并发上限，batch 请求切换到独立的 batch 等待队列
+func applyPriorityToWaitPlan(ctx context.Context, cfg config.GatewaySchedulingConfig, result *AccountSelectionResult) {
+	if result == nil || result.WaitPlan == nil {
+		return
+	}
+	plan := result.WaitPlan
+	plan.MaxConcurrency = prioritySlotLimit(ctx, cfg, plan.MaxConcurrency)
+	if !isBatchRequest(ctx) {
+		return
+	}
+	plan.Batch = true
+	if cfg.BatchWaitTimeout > 0 {
+		plan.Timeout = cfg.BatchWaitTimeout
+	}
+	if cfg.BatchMaxWaiting > 0 {
+		plan.MaxWaiting = cfg.BatchMaxWaiting
+	}
+}
+
+// accountSlotLimit 返回当前请求在账号上可使用的并发上限
+func (s *GatewayService) accountSlotLimit(ctx context.Context, account *Account) int {
+	return prioritySlotLimit(ctx, s.schedulingConfig(), account.Concurrency)
+}
+
+// selectionLoadLimit 返回负载感知选择时账号负载率的上限（不含）
+func (s *GatewayService) selectionLoadLimit(ctx context.Context) int {
+	return priorityLoadLimit(ctx, s.schedulingConfig())
+}
+
+// applyPriorityWaitPlan 按请求优先级调整等待计划
+func (s *GatewayService) applyPriorityWaitPlan(ctx context.Context, result *AccountSelectionResult) {
+	applyPriorityToWaitPlan(ctx, s.schedulingConfig(), result)
+}
+
+// isStickyWithinLoadLimit 判断粘性账号负载率是否低于当前请求的负载上限；
+// 仅 batch 请求需要查询，查询失败时不阻断粘性会话。
+func (s *GatewayService) isStickyWithinLoadLimit(ctx context.Context, account *Account, requestedModel string, loadLimit int) bool {
+	if loadLimit >= 100 || s.concurrencyService == nil {
+		return true
+	}
+	accounts := []*Account{account}
+	loadMap, err := s.concurrencyService.GetAccountsLoadBatch(ctx, []AccountWithConcurrency{{ID: account.ID, MaxConcurrency: account.EffectiveLoadFactor()}})
+	if err != nil {
+		return true
+	}
+	loadMap = s.applyModelLoadRates(ctx, accounts, requestedModel, loadMap)
+	info := loadMap[account.ID]
+	return info == nil || info.LoadRate < loadLimit
+}
+
+// AccountSlotLimit 返回当前请求在账号上可使用的并发上限（供 handler 在 WebSocket 后续轮次重新抢占槽位时使用）
+func (s *OpenAIGatewayService) AccountSlotLimit(ctx context.Context, account *Account) int {
+	return prioritySlotLimit(ctx, s.schedulingConfig(), account.Concurrency)
+}
+
+// selectionLoadLimit 返回负载感知选择时账号负载率的上限（不含）
+func (s *OpenAIGatewayService) selectionLoadLimit(ctx context.Context) int {
+	return priorityLoadLimit(ctx, s.schedulingConfig())
+}
+
+// isStickyWithinLoadLimit 判断粘性账号负载率是否低于当前请求的负载上限；查询失败时不阻断粘性会话
+func (s *OpenAIGatewayService) isStickyWithinLoadLimit(ctx context.Context, account *Account, loadLimit int) bool {
+	if loadLimit >= 100 || s.concurrencyService == nil {
+		return true
+	}
+	loadMap, err := s.concurrencyService.GetAccountsLoadBatch(ctx, []AccountWithConcurrency{{ID: account.ID, MaxConcurrency: account.EffectiveLoadFactor()}})
+	if err != nil {
+		return true
+	}
+	info := loadMap[account.ID]
+	return info == nil || info.LoadRate < loadLimit
+}
@@ -0,0 +1,210 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAPIKeyPriority(t *testing.T) {
+	for input, want := range map[string]string{
+		"":              APIKeyPriorityNormal,
+		"normal":        APIKeyPriorityNormal,
+		" Interactive ": APIKeyPriorityInteractive,
+		"BATCH":         APIKeyPriorityBatch,
+	} {
+		got, err := NormalizeAPIKeyPriority(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want, got, input)
+	}
+
+	_, err := NormalizeAPIKeyPriority("urgent")
+	require.ErrorIs(t, err, ErrInvalidAPIKeyPriority)
+
+	require.Equal(t, APIKeyPriorityNormal, (&APIKey{Priority: "unknown"}).EffectivePriority())
+	require.Equal(t, APIKeyPriorityNormal, (*APIKey)(nil).EffectivePriority())
+	require.Equal(t, APIKeyPriorityBatch, (&APIKey{Priority: "batch"}).EffectivePriority())
+}
+
+func TestRequestPriorityFromContext(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, APIKeyPriorityNormal, RequestPriorityFromContext(ctx))
+
+	ctx = WithRequestPriority(ctx, APIKeyPriorityBatch)
+	require.Equal(t, APIKeyPriorityBatch, RequestPriorityFromContext(ctx))
+	require.True(t, isBatchRequest(ctx))
+
+	// 后续写入其它请求元数据不影响优先级
+	ctx = WithAccountSwitchCount(ctx, 1, false)
+	require.Equal(t, APIKeyPriorityBatch, RequestPriorityFromContext(ctx))
+}
+
+func TestReservedSlotLimit(t *testing.T) {
+	require.Equal(t, 8, reservedSlotLimit(10, 0.2))
+	require.Equal(t, 3, reservedSlotLimit(4, 0.2), "预留槽位向上取整")
+	require.Equal(t, 1, reservedSlotLimit(1, 0.2), "至少保留 1 个槽位")
+	require.Equal(t, 5, reservedSlotLimit(5, 0), "未配置预留比例时不扣减")
+	require.Equal(t, 0, reservedSlotLimit(0, 0.2), "不限并发的账号保持不变")
+}
+
+func TestSelectAccountWithLoadAwareness_BatchPriority(t *testing.T) {
+	newService := func(loads map[int64]*AccountLoadInfo, acquire map[int64]bool) *GatewayService {
+		repo := &mockAccountRepoForPlatform{
+			accounts: []Account{
+				{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+				{ID: 2, Platform: PlatformAnthropic, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			},
+			accountsByID: map[int64]*Account{},
+		}
+		for i := range repo.accounts {
+			repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+		}
+		cfg := testConfig()
+		cfg.Gateway.Scheduling.LoadBatchEnabled = true
+		cfg.Gateway.Scheduling.FallbackWaitTimeout = 30 * time.Second
+		cfg.Gateway.Scheduling.FallbackMaxWaiting = 100
+		cfg.Gateway.Scheduling.InteractiveReservedRatio = 0.2
+		cfg.Gateway.Scheduling.BatchLoadThreshold = 70
+		cfg.Gateway.Scheduling.BatchWaitTimeout = 5 * time.Minute
+		cfg.Gateway.Scheduling.BatchMaxWaiting = 200
+		return &GatewayService{
+			accountRepo:        repo,
+			cache:              &mockGatewayCacheForPlatform{},
+			cfg:                cfg,
+			concurrencyService: NewConcurrencyService(&mockConcurrencyCache{loadMap: loads, acquireResults: acquire}),
+		}
+	}
+	model := "claude-3-5-sonnet-20241022"
+
+	t.Run("normal 请求按优先级选择高负载账号", func(t *testing.T) {
+		svc := newService(map[int64]*AccountLoadInfo{
+			1: {AccountID: 1, LoadRate: 80},
+			2: {AccountID: 2, LoadRate: 10},
+		}, nil)
+		result, err := svc.SelectAccountWithLoadAwareness(context.Background(), nil, "", model, nil, "")
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		require.Equal(t, int64(1), result.Account.ID)
+	})
+
+	t.Run("batch 请求跳过超过负载阈值的账号", func(t *testing.T) {
+		svc := newService(map[int64]*AccountLoadInfo{
+			1: {AccountID: 1, LoadRate: 80},
+			2: {AccountID: 2, LoadRate: 10},
+		}, nil)
+		ctx := WithRequestPriority(context.Background(), APIKeyPriorityBatch)
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", model, nil, "")
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		require.Equal(t, int64(2), result.Account.ID)
+	})
+
+	t.Run("batch 请求无低负载账号时进入独立等待队列", func(t *testing.T) {
+		svc := newService(map[int64]*AccountLoadInfo{
+			1: {AccountID: 1, LoadRate: 80},
+			2: {AccountID: 2, LoadRate: 90},
+		}, nil)
+		ctx := WithRequestPriority(context.Background(), APIKeyPriorityBatch)
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", model, nil, "")
+		require.NoError(t, err)
+		require.False(t, result.Acquired)
+		require.NotNil(t, result.WaitPlan)
+		require.True(t, result.WaitPlan.Batch)
+		require.Equal(t, 4, result.WaitPlan.MaxConcurrency)
+		require.Equal(t, 5*time.Minute, result.WaitPlan.Timeout)
+		require.Equal(t, 200, result.WaitPlan.MaxWaiting)
+	})
+
+	t.Run("normal 请求等待计划不占用预留槽位", func(t *testing.T) {
+		svc := newService(map[int64]*AccountLoadInfo{
+			1: {AccountID: 1, LoadRate: 100},
+			2: {AccountID: 2, LoadRate: 100},
+		}, nil)
+		result, err := svc.SelectAccountWithLoadAwareness(context.Background(), nil, "", model, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, result.WaitPlan)
+		require.False(t, result.WaitPlan.Batch)
+		require.Equal(t, 4, result.WaitPlan.MaxConcurrency)
+		require.Equal(t, 30*time.Second, result.WaitPlan.Timeout)
+		require.Equal(t, 100, result.WaitPlan.MaxWaiting)
+	})
+
+	t.Run("interactive 请求可使用全部槽位", func(t *testing.T) {
+		svc := newService(map[int64]*AccountLoadInfo{
+			1: {AccountID: 1, LoadRate: 100},
+			2: {AccountID: 2, LoadRate: 100},
+		}, nil)
+		ctx := WithRequestPriority(context.Background(), APIKeyPriorityInteractive)
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", model, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, result.WaitPlan)
+		require.Equal(t, 5, result.WaitPlan.MaxConcurrency)
+	})
+
+	t.Run("batch 请求的粘性会话同样遵守负载阈值", func(t *testing.T) {
+		svc := newService(map[int64]*AccountLoadInfo{
+			1: {AccountID: 1, LoadRate: 80},
+			2: {AccountID: 2, LoadRate: 10},
+		}, nil)
+		svc.cache = &mockGatewayCacheForPlatform{sessionBindings: map[string]int64{"sticky": 1}}
+
+		result, err := svc.SelectAccountWithLoadAwareness(context.Background(), nil, "sticky", model, nil, "")
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		require.Equal(t, int64(1), result.Account.ID, "normal 请求保持粘性")
+
+		ctx := WithRequestPriority(context.Background(), APIKeyPriorityBatch)
+		result, err = svc.SelectAccountWithLoadAwareness(ctx, nil, "sticky", model, nil, "")
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		require.Equal(t, int64(2), result.Account.ID)
+	})
+}
+
+func TestOpenAISelectAccountWithLoadAwareness_BatchPriority(t *testing.T) {
+	newService := func() *OpenAIGatewayService {
+		cfg := &config.Config{}
+		cfg.Gateway.Scheduling.LoadBatchEnabled = true
+		cfg.Gateway.Scheduling.StickySessionMaxWaiting = 3
+		cfg.Gateway.Scheduling.StickySessionWaitTimeout = 45 * time.Second
+		cfg.Gateway.Scheduling.FallbackWaitTimeout = 30 * time.Second
+		cfg.Gateway.Scheduling.FallbackMaxWaiting = 100
+		cfg.Gateway.Scheduling.InteractiveReservedRatio = 0.2
+		cfg.Gateway.Scheduling.BatchLoadThreshold = 70
+		cfg.Gateway.Scheduling.BatchWaitTimeout = 5 * time.Minute
+		cfg.Gateway.Scheduling.BatchMaxWaiting = 200
+		return &OpenAIGatewayService{
+			accountRepo: stubOpenAIAccountRepo{accounts: []Account{
+				{ID: 1, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 5, Priority: 1},
+				{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 5, Priority: 2},
+			}},
+			cache: &stubGatewayCache{sessionBindings: map[string]int64{"openai:sticky": 1}},
+			cfg:   cfg,
+			concurrencyService: NewConcurrencyService(stubConcurrencyCache{loadMap: map[int64]*AccountLoadInfo{
+				1: {AccountID: 1, LoadRate: 80},
+				2: {AccountID: 2, LoadRate: 10},
+			}}),
+		}
+	}
+
+	selection, err := newService().SelectAccountWithLoadAwareness(context.Background(), nil, "sticky", "gpt-4", nil)
+	require.NoError(t, err)
+	require.True(t, selection.Acquired)
+	require.Equal(t, int64(1), selection.Account.ID, "normal 请求保持粘性")
+
+	ctx := WithRequestPriority(context.Background(), APIKeyPriorityBatch)
+	selection, err = newService().SelectAccountWithLoadAwareness(ctx, nil, "sticky", "gpt-4", nil)
+	require.NoError(t, err)
+	require.True(t, selection.Acquired)
+	require.Equal(t, int64(2), selection.Account.ID, "batch 请求跳过超过负载阈值的粘性账号")
+
+	selection, _, err = newService().SelectAccountWithScheduler(ctx, nil, "", "sticky", "gpt-4", nil, OpenAIUpstreamTransportAny)
+	require.NoError(t, err)
+	require.True(t, selection.Acquired)
+	require.Equal(t, int64(2), selection.Account.ID, "调度器同样遵守 batch 负载阈值")
+}
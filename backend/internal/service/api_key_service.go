@@ -70,6 +70,8 @@ type APIKeyRepository interface {
 	// Quota methods
 	IncrementQuotaUsed(ctx context.Context, id int64, amount float64) (float64, error)
 	UpdateLastUsed(ctx context.Context, id int64, usedAt time.Time) error
+	// UpdateStatus 仅更新状态字段，避免整行回写覆盖并发更新的用量计数
+	UpdateStatus(ctx context.Context, id int64, status string) error
 
 	// Rate limit methods
 	IncrementRateLimitUsage(ctx context.Context, id int64, cost float64) error
@@ -161,6 +163,9 @@ type CreateAPIKeyRequest struct {
 	RateLimit5h float64 `json:"rate_limit_5h"`
 	RateLimit1d float64 `json:"rate_limit_1d"`
 	RateLimit7d float64 `json:"rate_limit_7d"`
+
+	// 调度优先级（interactive / normal / batch，空值为 normal）
+	Priority string `json:"priority"`
 }
 
 // UpdateAPIKeyRequest 更新API Key请求
@@ -182,6 +187,9 @@ type UpdateAPIKeyRequest struct {
 	RateLimit1d         *float64 `json:"rate_limit_1d"`
 	RateLimit7d         *float64 `json:"rate_limit_7d"`
 	ResetRateLimitUsage *bool    `json:"reset_rate_limit_usage"` // Reset all usage counters to 0
+
+	// 调度优先级（nil = no change）
+	Priority *string `json:"priority"`
 }
 
 // APIKeyService API Key服务
@@ -345,6 +353,11 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		}
 	}
 
+	priority, err := NormalizeAPIKeyPriority(req.Priority)
+	if err != nil {
+		return nil, err
+	}
+
 	// 验证分组权限（如果指定了分组）
 	if req.GroupID != nil {
 		group, err := s.groupRepo.GetByID(ctx, *req.GroupID)
@@ -407,6 +420,7 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		RateLimit5h: req.RateLimit5h,
 		RateLimit1d: req.RateLimit1d,
 		RateLimit7d: req.RateLimit7d,
+		Priority:    priority,
 	}
 
 	// Set expiration time if specified
@@ -534,6 +548,14 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 		}
 	}
 
+	if req.Priority != nil {
+		priority, err := NormalizeAPIKeyPriority(*req.Priority)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.Priority = priority
+	}
+
 	// 更新字段
 	if req.Name != nil {
 		apiKey.Name = *req.Name
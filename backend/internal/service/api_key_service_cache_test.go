@@ -109,6 +109,9 @@ func (s *authRepoStub) UpdateLastUsed(ctx context.Context, id int64, usedAt time
 func (s *authRepoStub) IncrementRateLimitUsage(ctx context.Context, id int64, cost float64) error {
 	panic("unexpected IncrementRateLimitUsage call")
 }
+func (s *authRepoStub) UpdateStatus(ctx context.Context, id int64, status string) error {
+	panic("unexpected UpdateStatus call")
+}
 func (s *authRepoStub) ResetRateLimitWindows(ctx context.Context, id int64) error {
 	panic("unexpected ResetRateLimitWindows call")
 }
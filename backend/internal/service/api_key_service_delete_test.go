@@ -138,6 +138,10 @@ func (s *apiKeyRepoStub) IncrementRateLimitUsage(ctx context.Context, id int64,
 	panic("unexpected IncrementRateLimitUsage call")
 }
 
+func (s *apiKeyRepoStub) UpdateStatus(ctx context.Context, id int64, status string) error {
+	panic("unexpected UpdateStatus call")
+}
+
 func (s *apiKeyRepoStub) ResetRateLimitWindows(ctx context.Context, id int64) error {
 	panic("unexpected ResetRateLimitWindows call")
 }
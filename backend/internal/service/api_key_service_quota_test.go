@@ -140,6 +140,9 @@ func (s *quotaBaseAPIKeyRepoStub) UpdateLastUsed(context.Context, int64, time.Ti
 func (s *quotaBaseAPIKeyRepoStub) IncrementRateLimitUsage(context.Context, int64, float64) error {
 	panic("unexpected IncrementRateLimitUsage call")
 }
+func (s *quotaBaseAPIKeyRepoStub) UpdateStatus(context.Context, int64, string) error {
+	panic("unexpected UpdateStatus call")
+}
 func (s *quotaBaseAPIKeyRepoStub) ResetRateLimitWindows(context.Context, int64) error {
 	panic("unexpected ResetRateLimitWindows call")
 }
@@ -126,7 +126,7 @@ func newAuthService(repo *userRepoStub, settings map[string]string, emailCache E
 
 	var emailService *EmailService
 	if emailCache != nil {
-		emailService = NewEmailService(&settingRepoStub{values: settings}, emailCache)
+		emailService = NewEmailService(&settingRepoStub{values: settings}, emailCache, nil)
 	}
 
 	return NewAuthService(
@@ -956,6 +956,23 @@ func (s *BackupService) GetBackupDownloadURL(ctx context.Context, backupID strin
 	return url, nil
 }
 
+// SharedObjectStore 返回已配置的 S3 存储及其配置，供使用记录导出等其它模块复用备份存储。
+// 未配置 S3 时返回 ErrBackupS3NotConfigured。
+func (s *BackupService) SharedObjectStore(ctx context.Context) (BackupObjectStore, *BackupS3Config, error) {
+	s3Cfg, err := s.loadS3Config(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s3Cfg == nil {
+		return nil, nil, ErrBackupS3NotConfigured
+	}
+	objectStore, err := s.getOrCreateStore(ctx, s3Cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return objectStore, s3Cfg, nil
+}
+
 // ─── 内部方法 ───
 
 func (s *BackupService) loadS3Config(ctx context.Context) (*BackupS3Config, error) {
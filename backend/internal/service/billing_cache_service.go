@@ -502,28 +502,44 @@ func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID
 // resets expired windows in-memory and triggers async DB reset,
 // and returns an error if any window limit is exceeded.
 func (s *BillingCacheService) checkAPIKeyRateLimits(ctx context.Context, apiKey *APIKey) error {
+	data := s.loadAPIKeyRateLimitData(ctx, apiKey.ID)
+	if data == nil {
+		return nil // Don't block requests on DB errors
+	}
+	return s.evaluateRateLimits(ctx, apiKey, data.Usage5h, data.Usage1d, data.Usage7d,
+		data.Window5hStart, data.Window1dStart, data.Window7dStart)
+}
+
+// GetAPIKeyRateLimitData returns rate limit usage for an API key from cache (falling back to DB).
+// Returns nil when the data is unavailable. Expired windows are not reset here;
+// callers should use the EffectiveUsage* helpers.
+func (s *BillingCacheService) GetAPIKeyRateLimitData(ctx context.Context, apiKeyID int64) *APIKeyRateLimitData {
+	return s.loadAPIKeyRateLimitData(ctx, apiKeyID)
+}
+
+// loadAPIKeyRateLimitData loads rate limit usage from Redis cache, populating it from DB on cache miss.
+func (s *BillingCacheService) loadAPIKeyRateLimitData(ctx context.Context, apiKeyID int64) *APIKeyRateLimitData {
 	if s.cache == nil {
 		// No cache: fall back to reading from DB directly
 		if s.apiKeyRateLimitLoader == nil {
 			return nil
 		}
-		data, err := s.apiKeyRateLimitLoader.GetRateLimitData(ctx, apiKey.ID)
+		data, err := s.apiKeyRateLimitLoader.GetRateLimitData(ctx, apiKeyID)
 		if err != nil {
-			return nil // Don't block requests on DB errors
+			return nil
 		}
-		return s.evaluateRateLimits(ctx, apiKey, data.Usage5h, data.Usage1d, data.Usage7d,
-			data.Window5hStart, data.Window1dStart, data.Window7dStart)
+		return data
 	}
 
-	cacheData, err := s.cache.GetAPIKeyRateLimit(ctx, apiKey.ID)
+	cacheData, err := s.cache.GetAPIKeyRateLimit(ctx, apiKeyID)
 	if err != nil {
 		// Cache miss: load from DB and populate cache
 		if s.apiKeyRateLimitLoader == nil {
 			return nil
 		}
-		dbData, dbErr := s.apiKeyRateLimitLoader.GetRateLimitData(ctx, apiKey.ID)
+		dbData, dbErr := s.apiKeyRateLimitLoader.GetRateLimitData(ctx, apiKeyID)
 		if dbErr != nil {
-			return nil // Don't block requests on DB errors
+			return nil
 		}
 		// Build cache entry from DB data
 		cacheEntry := &APIKeyRateLimitCacheData{
@@ -540,24 +556,28 @@ func (s *BillingCacheService) checkAPIKeyRateLimits(ctx context.Context, apiKey
 		if dbData.Window7dStart != nil {
 			cacheEntry.Window7d = dbData.Window7dStart.Unix()
 		}
-		_ = s.cache.SetAPIKeyRateLimit(ctx, apiKey.ID, cacheEntry)
+		_ = s.cache.SetAPIKeyRateLimit(ctx, apiKeyID, cacheEntry)
 		cacheData = cacheEntry
 	}
 
-	var w5h, w1d, w7d *time.Time
+	data := &APIKeyRateLimitData{
+		Usage5h: cacheData.Usage5h,
+		Usage1d: cacheData.Usage1d,
+		Usage7d: cacheData.Usage7d,
+	}
 	if cacheData.Window5h > 0 {
 		t := time.Unix(cacheData.Window5h, 0)
-		w5h = &t
+		data.Window5hStart = &t
 	}
 	if cacheData.Window1d > 0 {
 		t := time.Unix(cacheData.Window1d, 0)
-		w1d = &t
+		data.Window1dStart = &t
 	}
 	if cacheData.Window7d > 0 {
 		t := time.Unix(cacheData.Window7d, 0)
-		w7d = &t
+		data.Window7dStart = &t
 	}
-	return s.evaluateRateLimits(ctx, apiKey, cacheData.Usage5h, cacheData.Usage1d, cacheData.Usage7d, w5h, w1d, w7d)
+	return data
 }
 
 // evaluateRateLimits checks usage against limits, triggering async resets for expired windows.
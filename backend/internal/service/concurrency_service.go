@@ -22,10 +22,22 @@ type ConcurrencyCache interface {
 	GetAccountConcurrency(ctx context.Context, accountID int64) (int, error)
 	GetAccountConcurrencyBatch(ctx context.Context, accountIDs []int64) (map[int64]int, error)
 
+	// 账号内模型级槽位（按 extra.model_limits 命中的 pattern 隔离）
+	// 并发键格式: concurrency:account:{accountID}:model:{pattern}（有序集合，成员为 requestID）
+	// RPM 键格式: rpm:account:{accountID}:model:{pattern}（有序集合，60 秒滑动窗口）
+	AcquireAccountModelSlot(ctx context.Context, scope AccountModelScope, maxConcurrency int, requestID string) (bool, error)
+	ReleaseAccountModelSlot(ctx context.Context, scope AccountModelScope, requestID string) error
+	AcquireAccountModelRPM(ctx context.Context, scope AccountModelScope, maxRPM int, requestID string) (bool, error)
+	ReleaseAccountModelRPM(ctx context.Context, scope AccountModelScope, requestID string) error
+	GetAccountModelLoadBatch(ctx context.Context, scopes []AccountModelScope) (map[int64]*AccountModelLoadInfo, error)
+
 	// 账号等待队列（账号级）
 	IncrementAccountWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error)
 	DecrementAccountWaitCount(ctx context.Context, accountID int64) error
 	GetAccountWaitingCount(ctx context.Context, accountID int64) (int, error)
+	// batch 优先级请求的独立等待队列（账号级），不占用普通等待队列名额
+	IncrementAccountBatchWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error)
+	DecrementAccountBatchWaitCount(ctx context.Context, accountID int64) error
 
 	// 用户槽位管理
 	// 键格式: concurrency:user:{userID}（有序集合，成员为 requestID）
@@ -86,6 +98,10 @@ const (
 // ConcurrencyService manages concurrent request limiting for accounts and users
 type ConcurrencyService struct {
 	cache ConcurrencyCache
+
+	// 账号满载时的公平排队（可选）
+	fairQueue          FairQueueCache
+	fairQueuePerAPIKey bool
 }
 
 // NewConcurrencyService creates a new ConcurrencyService
@@ -97,6 +113,23 @@ func NewConcurrencyService(cache ConcurrencyCache) *ConcurrencyService {
 type AcquireResult struct {
 	Acquired    bool
 	ReleaseFunc func() // Must be called when done (typically via defer)
+	// CancelFunc 在转发前放弃本次获取时使用（如会话数量限制未通过）：
+	// 释放槽位并退还已计入的模型级 RPM；为 nil 时等同 ReleaseFunc
+	CancelFunc func()
+}
+
+// Cancel 放弃已获取的槽位，请求未转发因此不计入模型级 RPM
+func (r *AcquireResult) Cancel() {
+	if r == nil {
+		return
+	}
+	if r.CancelFunc != nil {
+		r.CancelFunc()
+		return
+	}
+	if r.ReleaseFunc != nil {
+		r.ReleaseFunc()
+	}
 }
 
 type AccountWithConcurrency struct {
@@ -162,6 +195,80 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 	}, nil
 }
 
+// AcquireAccountModelSlot acquires an account slot plus the model-scoped slot for limit.
+// The model-scoped concurrency and RPM windows are checked after the account slot;
+// on rejection every slot taken so far is released. A nil limit behaves like AcquireAccountSlot.
+func (s *ConcurrencyService) AcquireAccountModelSlot(ctx context.Context, accountID int64, maxConcurrency int, limit *AccountModelLimit) (*AcquireResult, error) {
+	result, err := s.AcquireAccountSlot(ctx, accountID, maxConcurrency)
+	if err != nil || !result.Acquired || limit == nil || s.cache == nil {
+		return result, err
+	}
+	accountRelease := result.ReleaseFunc
+
+	scope := AccountModelScope{AccountID: accountID, Pattern: limit.Pattern}
+	requestID := generateRequestID()
+	modelRelease := func() {}
+	if limit.Concurrency > 0 {
+		acquired, err := s.cache.AcquireAccountModelSlot(ctx, scope, limit.Concurrency, requestID)
+		if err != nil {
+			accountRelease()
+			return nil, err
+		}
+		if !acquired {
+			accountRelease()
+			return &AcquireResult{Acquired: false}, nil
+		}
+		modelRelease = func() {
+			bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.cache.ReleaseAccountModelSlot(bgCtx, scope, requestID); err != nil {
+				logger.LegacyPrintf("service.concurrency", "Warning: failed to release model slot for account %d pattern %s (req=%s): %v", accountID, limit.Pattern, requestID, err)
+			}
+		}
+	}
+	// RPM 最后检查：通过即计入当前窗口，请求转发后不再回滚；转发前放弃（Cancel）时退还
+	rpmRelease := func() {}
+	if limit.RPM > 0 {
+		acquired, err := s.cache.AcquireAccountModelRPM(ctx, scope, limit.RPM, requestID)
+		if err != nil || !acquired {
+			modelRelease()
+			accountRelease()
+			if err != nil {
+				return nil, err
+			}
+			return &AcquireResult{Acquired: false}, nil
+		}
+		rpmRelease = func() {
+			bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.cache.ReleaseAccountModelRPM(bgCtx, scope, requestID); err != nil {
+				logger.LegacyPrintf("service.concurrency", "Warning: failed to release model rpm for account %d pattern %s (req=%s): %v", accountID, limit.Pattern, requestID, err)
+			}
+		}
+	}
+
+	release := func() {
+		modelRelease()
+		accountRelease()
+	}
+	return &AcquireResult{
+		Acquired:    true,
+		ReleaseFunc: release,
+		CancelFunc: func() {
+			rpmRelease()
+			release()
+		},
+	}, nil
+}
+
+// GetAccountModelLoadBatch returns model-scoped load for the given scopes, keyed by account ID.
+func (s *ConcurrencyService) GetAccountModelLoadBatch(ctx context.Context, scopes []AccountModelScope) (map[int64]*AccountModelLoadInfo, error) {
+	if s.cache == nil || len(scopes) == 0 {
+		return map[int64]*AccountModelLoadInfo{}, nil
+	}
+	return s.cache.GetAccountModelLoadBatch(ctx, scopes)
+}
+
 // AcquireUserSlot attempts to acquire a concurrency slot for a user.
 // If the user is at max concurrency, it waits until a slot is available or timeout.
 // Returns a release function that MUST be called when the request completes.
@@ -201,6 +308,14 @@ func (s *ConcurrencyService) AcquireUserSlot(ctx context.Context, userID int64,
 	}, nil
 }
 
+// GetUserConcurrency returns the number of in-flight requests holding a user slot.
+func (s *ConcurrencyService) GetUserConcurrency(ctx context.Context, userID int64) (int, error) {
+	if s.cache == nil {
+		return 0, nil
+	}
+	return s.cache.GetUserConcurrency(ctx, userID)
+}
+
 // ============================================
 // Wait Queue Count Methods
 // ============================================
@@ -267,6 +382,34 @@ func (s *ConcurrencyService) DecrementAccountWaitCount(ctx context.Context, acco
 	}
 }
 
+// IncrementAccountBatchWaitCount 递增账号的 batch 等待队列计数
+func (s *ConcurrencyService) IncrementAccountBatchWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error) {
+	if s.cache == nil {
+		return true, nil
+	}
+
+	result, err := s.cache.IncrementAccountBatchWaitCount(ctx, accountID, maxWait)
+	if err != nil {
+		logger.LegacyPrintf("service.concurrency", "Warning: increment batch wait count failed for account %d: %v", accountID, err)
+		return true, nil
+	}
+	return result, nil
+}
+
+// DecrementAccountBatchWaitCount 递减账号的 batch 等待队列计数
+func (s *ConcurrencyService) DecrementAccountBatchWaitCount(ctx context.Context, accountID int64) {
+	if s.cache == nil {
+		return
+	}
+
+	bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.cache.DecrementAccountBatchWaitCount(bgCtx, accountID); err != nil {
+		logger.LegacyPrintf("service.concurrency", "Warning: decrement batch wait count failed for account %d: %v", accountID, err)
+	}
+}
+
 // GetAccountWaitingCount gets current wait queue count for an account.
 func (s *ConcurrencyService) GetAccountWaitingCount(ctx context.Context, accountID int64) (int, error) {
 	if s.cache == nil {
@@ -28,10 +28,15 @@ type stubConcurrencyCacheForTest struct {
 	usersLoadBatch map[int64]*UserLoadInfo
 	usersLoadErr   error
 	cleanupErr     error
+	modelSlotFull  bool
+	modelRPMFull   bool
+	modelLoadBatch map[int64]*AccountModelLoadInfo
 
 	// 记录调用
-	releasedAccountIDs []int64
-	releasedRequestIDs []string
+	releasedAccountIDs  []int64
+	releasedRequestIDs  []string
+	releasedModelScopes []AccountModelScope
+	releasedRPMScopes   []AccountModelScope
 }
 
 var _ ConcurrencyCache = (*stubConcurrencyCacheForTest)(nil)
@@ -66,6 +71,12 @@ func (c *stubConcurrencyCacheForTest) DecrementAccountWaitCount(_ context.Contex
 func (c *stubConcurrencyCacheForTest) GetAccountWaitingCount(_ context.Context, _ int64) (int, error) {
 	return c.waitCount, c.waitCountErr
 }
+func (c *stubConcurrencyCacheForTest) IncrementAccountBatchWaitCount(_ context.Context, _ int64, _ int) (bool, error) {
+	return c.waitAllowed, c.waitErr
+}
+func (c *stubConcurrencyCacheForTest) DecrementAccountBatchWaitCount(_ context.Context, _ int64) error {
+	return nil
+}
 func (c *stubConcurrencyCacheForTest) AcquireUserSlot(_ context.Context, _ int64, _ int, _ string) (bool, error) {
 	return c.acquireResult, c.acquireErr
 }
@@ -94,6 +105,23 @@ func (c *stubConcurrencyCacheForTest) CleanupExpiredAccountSlots(_ context.Conte
 func (c *stubConcurrencyCacheForTest) CleanupStaleProcessSlots(_ context.Context, _ string) error {
 	return c.cleanupErr
 }
+func (c *stubConcurrencyCacheForTest) AcquireAccountModelSlot(_ context.Context, _ AccountModelScope, _ int, _ string) (bool, error) {
+	return !c.modelSlotFull, nil
+}
+func (c *stubConcurrencyCacheForTest) ReleaseAccountModelSlot(_ context.Context, scope AccountModelScope, _ string) error {
+	c.releasedModelScopes = append(c.releasedModelScopes, scope)
+	return nil
+}
+func (c *stubConcurrencyCacheForTest) AcquireAccountModelRPM(_ context.Context, _ AccountModelScope, _ int, _ string) (bool, error) {
+	return !c.modelRPMFull, nil
+}
+func (c *stubConcurrencyCacheForTest) ReleaseAccountModelRPM(_ context.Context, scope AccountModelScope, _ string) error {
+	c.releasedRPMScopes = append(c.releasedRPMScopes, scope)
+	return nil
+}
+func (c *stubConcurrencyCacheForTest) GetAccountModelLoadBatch(_ context.Context, _ []AccountModelScope) (map[int64]*AccountModelLoadInfo, error) {
+	return c.modelLoadBatch, nil
+}
 
 type trackingConcurrencyCache struct {
 	stubConcurrencyCacheForTest
@@ -174,6 +202,79 @@ func TestAcquireAccountSlot_ReleaseDecrements(t *testing.T) {
 	require.NotEmpty(t, cache.releasedRequestIDs[0], "requestID 不应为空")
 }
 
+func TestAcquireAccountModelSlot(t *testing.T) {
+	opus := &AccountModelLimit{Pattern: "claude-opus-*", Concurrency: 2, RPM: 10}
+
+	t.Run("nil limit behaves like account slot", func(t *testing.T) {
+		cache := &stubConcurrencyCacheForTest{acquireResult: true, modelSlotFull: true}
+		svc := NewConcurrencyService(cache)
+
+		result, err := svc.AcquireAccountModelSlot(context.Background(), 7, 5, nil)
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+	})
+
+	t.Run("releases both slots", func(t *testing.T) {
+		cache := &stubConcurrencyCacheForTest{acquireResult: true}
+		svc := NewConcurrencyService(cache)
+
+		result, err := svc.AcquireAccountModelSlot(context.Background(), 7, 5, opus)
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+
+		result.ReleaseFunc()
+		require.Equal(t, []int64{7}, cache.releasedAccountIDs)
+		require.Equal(t, []AccountModelScope{{AccountID: 7, Pattern: "claude-opus-*"}}, cache.releasedModelScopes)
+		require.Empty(t, cache.releasedRPMScopes, "已转发的请求保留 RPM 计数")
+	})
+
+	t.Run("cancel returns rpm token", func(t *testing.T) {
+		cache := &stubConcurrencyCacheForTest{acquireResult: true}
+		svc := NewConcurrencyService(cache)
+
+		result, err := svc.AcquireAccountModelSlot(context.Background(), 7, 5, opus)
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+
+		result.Cancel()
+		require.Equal(t, []int64{7}, cache.releasedAccountIDs)
+		require.Len(t, cache.releasedModelScopes, 1)
+		require.Equal(t, []AccountModelScope{{AccountID: 7, Pattern: "claude-opus-*"}}, cache.releasedRPMScopes)
+	})
+
+	t.Run("model slot full releases account slot", func(t *testing.T) {
+		cache := &stubConcurrencyCacheForTest{acquireResult: true, modelSlotFull: true}
+		svc := NewConcurrencyService(cache)
+
+		result, err := svc.AcquireAccountModelSlot(context.Background(), 7, 5, opus)
+		require.NoError(t, err)
+		require.False(t, result.Acquired)
+		require.Equal(t, []int64{7}, cache.releasedAccountIDs)
+		require.Empty(t, cache.releasedModelScopes)
+	})
+
+	t.Run("model rpm exhausted releases all slots", func(t *testing.T) {
+		cache := &stubConcurrencyCacheForTest{acquireResult: true, modelRPMFull: true}
+		svc := NewConcurrencyService(cache)
+
+		result, err := svc.AcquireAccountModelSlot(context.Background(), 7, 5, opus)
+		require.NoError(t, err)
+		require.False(t, result.Acquired)
+		require.Equal(t, []int64{7}, cache.releasedAccountIDs)
+		require.Len(t, cache.releasedModelScopes, 1)
+	})
+
+	t.Run("account slot full skips model slot", func(t *testing.T) {
+		cache := &stubConcurrencyCacheForTest{acquireResult: false}
+		svc := NewConcurrencyService(cache)
+
+		result, err := svc.AcquireAccountModelSlot(context.Background(), 7, 5, opus)
+		require.NoError(t, err)
+		require.False(t, result.Acquired)
+		require.Empty(t, cache.releasedAccountIDs)
+	})
+}
+
 func TestAcquireUserSlot_IndependentFromAccount(t *testing.T) {
 	cache := &stubConcurrencyCacheForTest{acquireResult: true}
 	svc := NewConcurrencyService(cache)
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/tidwall/gjson"
+)
+
+// 审核规则类型
+const (
+	ContentModerationKindKeyword = "keyword"
+	ContentModerationKindRegex   = "regex"
+	ContentModerationKindOpenAI  = "openai_moderation"
+	ContentModerationKindWebhook = "webhook"
+)
+
+// 审核命中后的动作
+const (
+	ContentModerationActionBlock = "block"
+	ContentModerationActionFlag  = "flag"
+)
+
+const (
+	defaultContentModerationModel   = "omni-moderation-latest"
+	defaultContentModerationRefusal = "Your request was rejected by the content policy of this service."
+	contentModerationExcerptRunes   = 200
+)
+
+// ContentModerationRule 入站提示词审核规则
+type ContentModerationRule struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Priority int    `json:"priority"` // 数值越小越先执行
+	Kind     string `json:"kind"`
+	Action   string `json:"action"`
+	// GroupIDs 生效分组，为空表示所有分组
+	GroupIDs []int64 `json:"group_ids"`
+	// Patterns 关键词（keyword）或正则表达式（regex）
+	Patterns      []string `json:"patterns"`
+	CaseSensitive bool     `json:"case_sensitive"`
+	// AccountID openai_moderation 使用的 OpenAI API Key 账号
+	AccountID       *int64 `json:"account_id,omitempty"`
+	ModerationModel string `json:"moderation_model"`
+	// Categories openai_moderation / webhook 仅在命中这些分类时生效，为空表示任意分类
+	Categories    []string `json:"categories"`
+	WebhookURL    string   `json:"webhook_url"`
+	WebhookSecret string   `json:"-"`
+	// WebhookSecretConfigured 仅用于展示是否已配置签名密钥
+	WebhookSecretConfigured bool      `json:"webhook_secret_configured"`
+	RefusalMessage          string    `json:"refusal_message"`
+	Description             string    `json:"description"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// AppliesToGroup 判断规则是否对该分组生效
+func (r *ContentModerationRule) AppliesToGroup(groupID *int64) bool {
+	if len(r.GroupIDs) == 0 {
+		return true
+	}
+	if groupID == nil {
+		return false
+	}
+	for _, id := range r.GroupIDs {
+		if id == *groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentModerationViolation 审核命中记录
+type ContentModerationViolation struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	APIKeyID     int64     `json:"api_key_id"`
+	GroupID      *int64    `json:"group_id,omitempty"`
+	RuleID       *int64    `json:"rule_id,omitempty"`
+	RuleName     string    `json:"rule_name"`
+	RuleKind     string    `json:"rule_kind"`
+	Action       string    `json:"action"`
+	Endpoint     string    `json:"endpoint"`
+	Model        string    `json:"model"`
+	Reason       string    `json:"reason"`
+	Excerpt      string    `json:"excerpt"`
+	KeySuspended bool      `json:"key_suspended"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ContentModerationViolationFilter 命中记录查询条件
+type ContentModerationViolationFilter struct {
+	UserID    *int64
+	APIKeyID  *int64
+	GroupID   *int64
+	Action    string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// ContentModerationUserStat 按用户汇总的违规计数
+type ContentModerationUserStat struct {
+	UserID          int64     `json:"user_id"`
+	Email           string    `json:"email"`
+	BlockedCount    int64     `json:"blocked_count"`
+	FlaggedCount    int64     `json:"flagged_count"`
+	LastViolationAt time.Time `json:"last_violation_at"`
+}
+
+type ContentModerationRepository interface {
+	ListRules(ctx context.Context) ([]*ContentModerationRule, error)
+	GetRule(ctx context.Context, id int64) (*ContentModerationRule, error)
+	CreateRule(ctx context.Context, rule *ContentModerationRule) error
+	UpdateRule(ctx context.Context, rule *ContentModerationRule) error
+	DeleteRule(ctx context.Context, id int64) error
+
+	CreateViolation(ctx context.Context, violation *ContentModerationViolation) error
+	// CountBlockedByAPIKeySince 统计 API Key 自 since 起被拦截的次数
+	CountBlockedByAPIKeySince(ctx context.Context, apiKeyID int64, since time.Time) (int64, error)
+	ListViolations(ctx context.Context, filter ContentModerationViolationFilter, params pagination.PaginationParams) ([]ContentModerationViolation, *pagination.PaginationResult, error)
+	ListUserStats(ctx context.Context, since time.Time, params pagination.PaginationParams) ([]ContentModerationUserStat, *pagination.PaginationResult, error)
+}
+
+// contentModerationTextRoots 参与审核的请求字段：
+// Anthropic Messages（system/messages）、OpenAI Chat Completions（messages）、
+// Responses（instructions/input）与 Gemini（contents/systemInstruction）。
+// tools 等结构化定义不参与审核。
+var contentModerationTextRoots = []string{
+	"system",
+	"instructions",
+	"systemInstruction",
+	"system_instruction",
+	"messages",
+	"input",
+	"contents",
+	"prompt",
+}
+
+// contentModerationTextKeys 在上述字段内部收集文本的键名
+var contentModerationTextKeys = map[string]struct{}{
+	"text":    {},
+	"content": {},
+	"input":   {},
+	"output":  {},
+}
+
+// ExtractModerationText 从网关请求体中提取需要审核的提示词文本。
+// 文本超过 maxBytes 时保留末尾部分（最新的对话内容）。
+func ExtractModerationText(body []byte, maxBytes int) string {
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return ""
+	}
+	var sb strings.Builder
+	for _, root := range contentModerationTextRoots {
+		collectModerationText(gjson.GetBytes(body, root), &sb)
+	}
+	text := sb.String()
+	if maxBytes > 0 && len(text) > maxBytes {
+		text = strings.ToValidUTF8(text[len(text)-maxBytes:], "")
+	}
+	return text
+}
+
+func collectModerationText(v gjson.Result, sb *strings.Builder) {
+	switch {
+	case v.Type == gjson.String:
+		if s := strings.TrimSpace(v.Str); s != "" {
+			sb.WriteString(s)
+			sb.WriteByte('\n')
+		}
+	case v.IsArray():
+		v.ForEach(func(_, item gjson.Result) bool {
+			collectModerationText(item, sb)
+			return true
+		})
+	case v.IsObject():
+		v.ForEach(func(key, item gjson.Result) bool {
+			if _, ok := contentModerationTextKeys[key.Str]; ok {
+				collectModerationText(item, sb)
+			} else if key.Str == "parts" {
+				collectModerationText(item, sb)
+			}
+			return true
+		})
+	}
+}
+
+// contentModerationMatcher 预编译的本地规则（关键词 / 正则）
+type contentModerationMatcher struct {
+	keywords []string
+	regexps  []*regexp.Regexp
+	fold     bool
+}
+
+func newContentModerationMatcher(rule *ContentModerationRule) (*contentModerationMatcher, error) {
+	m := &contentModerationMatcher{fold: !rule.CaseSensitive}
+	for _, p := range rule.Patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		switch rule.Kind {
+		case ContentModerationKindKeyword:
+			if m.fold {
+				p = strings.ToLower(p)
+			}
+			m.keywords = append(m.keywords, p)
+		case ContentModerationKindRegex:
+			if m.fold {
+				p = "(?i)" + p
+			}
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, err
+			}
+			m.regexps = append(m.regexps, re)
+		}
+	}
+	return m, nil
+}
+
+// Match 返回命中的片段；lower 为预先转小写的文本，避免每条规则重复转换
+func (m *contentModerationMatcher) Match(text, lower string) (string, bool) {
+	haystack := text
+	if m.fold {
+		haystack = lower
+	}
+	for _, kw := range m.keywords {
+		if idx := strings.Index(haystack, kw); idx >= 0 {
+			return moderationExcerpt(text, idx, len(kw)), true
+		}
+	}
+	for _, re := range m.regexps {
+		if loc := re.FindStringIndex(text); loc != nil {
+			return moderationExcerpt(text, loc[0], loc[1]-loc[0]), true
+		}
+	}
+	return "", false
+}
+
+// moderationExcerpt 截取命中位置附近的文本用于审计
+func moderationExcerpt(text string, start, length int) string {
+	if start < 0 || start > len(text) {
+		return truncateModerationExcerpt(text)
+	}
+	from := start - 60
+	if from < 0 {
+		from = 0
+	}
+	to := start + length + 60
+	if to > len(text) {
+		to = len(text)
+	}
+	return truncateModerationExcerpt(strings.ToValidUTF8(text[from:to], ""))
+}
+
+func truncateModerationExcerpt(s string) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) > contentModerationExcerptRunes {
+		return string(runes[:contentModerationExcerptRunes]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,677 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+)
+
+var (
+	ErrContentModerationRuleNotFound = infraerrors.NotFound("CONTENT_MODERATION_RULE_NOT_FOUND", "content moderation rule not found")
+	ErrContentModerationInvalidRule  = infraerrors.BadRequest("CONTENT_MODERATION_INVALID_RULE", "invalid content moderation rule")
+	ErrContentModerationUnavailable  = infraerrors.ServiceUnavailable("CONTENT_MODERATION_UNAVAILABLE", "content moderation is temporarily unavailable")
+)
+
+const (
+	contentModerationRuleCacheTTL   = 30 * time.Second
+	contentModerationRecordTimeout  = 5 * time.Second
+	contentModerationMaxResponseLen = 64 << 10
+)
+
+// compiledModerationRule 规则与预编译的本地匹配器
+type compiledModerationRule struct {
+	*ContentModerationRule
+	matcher *contentModerationMatcher
+}
+
+type cachedModerationRules struct {
+	rules     []*compiledModerationRule
+	expiresAt time.Time
+}
+
+// ContentModerationInput 网关中间件提交的一次待审核请求
+type ContentModerationInput struct {
+	APIKey   *APIKey
+	Endpoint string
+	Model    string
+	Body     []byte
+}
+
+// ContentModerationDecision 审核结果；Blocked 为 false 时请求继续（可能已记录 flag）
+type ContentModerationDecision struct {
+	Blocked  bool
+	RuleID   int64
+	RuleName string
+	// Message 返回给客户端的拒绝信息
+	Message string
+}
+
+// ContentModerationService 在选择账号前对入站提示词执行按分组配置的审核规则：
+// 本地关键词/正则、OpenAI Moderation（使用本站 OpenAI API Key 账号）或通用 HTTP Hook。
+// 命中 block 规则的请求被拒绝并计入违规次数，达到阈值后自动停用 API Key。
+type ContentModerationService struct {
+	repo                 ContentModerationRepository
+	accountRepo          AccountRepository
+	apiKeyRepo           APIKeyRepository
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	httpUpstream         HTTPUpstream
+	cfg                  *config.Config
+
+	rulesCache atomic.Pointer[cachedModerationRules]
+}
+
+func NewContentModerationService(
+	repo ContentModerationRepository,
+	accountRepo AccountRepository,
+	apiKeyRepo APIKeyRepository,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	httpUpstream HTTPUpstream,
+	cfg *config.Config,
+) *ContentModerationService {
+	return &ContentModerationService{
+		repo:                 repo,
+		accountRepo:          accountRepo,
+		apiKeyRepo:           apiKeyRepo,
+		authCacheInvalidator: authCacheInvalidator,
+		httpUpstream:         httpUpstream,
+		cfg:                  cfg,
+	}
+}
+
+// Enabled 配置总开关是否打开
+func (s *ContentModerationService) Enabled() bool {
+	return s != nil && s.repo != nil && s.cfg != nil && s.cfg.ContentModeration.Enabled
+}
+
+// HasRules 判断该分组是否存在启用的审核规则（网关热路径，用于跳过读取请求体）
+func (s *ContentModerationService) HasRules(ctx context.Context, groupID *int64) bool {
+	if !s.Enabled() {
+		return false
+	}
+	for _, rule := range s.cachedRules(ctx) {
+		if rule.AppliesToGroup(groupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 按优先级执行适用于该分组的规则：flag 规则仅记录并继续，block 规则命中即拒绝。
+// 外部审核失败且未配置 fail_open 时返回 ErrContentModerationUnavailable。
+func (s *ContentModerationService) Check(ctx context.Context, in *ContentModerationInput) (*ContentModerationDecision, error) {
+	if !s.Enabled() || in == nil || in.APIKey == nil {
+		return nil, nil
+	}
+	var rules []*compiledModerationRule
+	for _, rule := range s.cachedRules(ctx) {
+		if rule.AppliesToGroup(in.APIKey.GroupID) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	text := ExtractModerationText(in.Body, s.cfg.ContentModeration.MaxTextBytes)
+	if text == "" {
+		return nil, nil
+	}
+	lower := strings.ToLower(text)
+
+	for _, rule := range rules {
+		excerpt, reason, matched, err := s.evaluate(ctx, rule, in, text, lower)
+		if err != nil {
+			logger.LegacyPrintf("service.content_moderation", "[ContentModeration] rule %d (%s) failed: %v", rule.ID, rule.Kind, err)
+			if s.cfg.ContentModeration.FailOpen {
+				continue
+			}
+			return nil, ErrContentModerationUnavailable
+		}
+		if !matched {
+			continue
+		}
+		s.recordViolation(ctx, rule, in, reason, excerpt)
+		if rule.Action != ContentModerationActionBlock {
+			continue
+		}
+		message := rule.RefusalMessage
+		if message == "" {
+			message = defaultContentModerationRefusal
+		}
+		return &ContentModerationDecision{Blocked: true, RuleID: rule.ID, RuleName: rule.Name, Message: message}, nil
+	}
+	return nil, nil
+}
+
+func (s *ContentModerationService) evaluate(ctx context.Context, rule *compiledModerationRule, in *ContentModerationInput, text, lower string) (excerpt, reason string, matched bool, err error) {
+	switch rule.Kind {
+	case ContentModerationKindKeyword, ContentModerationKindRegex:
+		excerpt, matched = rule.matcher.Match(text, lower)
+		return excerpt, rule.Kind, matched, nil
+	case ContentModerationKindOpenAI:
+		categories, flagged, err := s.checkOpenAI(ctx, rule.ContentModerationRule, text)
+		if err != nil || !flagged {
+			return "", "", false, err
+		}
+		return truncateModerationExcerpt(text), "openai_moderation: " + strings.Join(categories, ", "), true, nil
+	case ContentModerationKindWebhook:
+		categories, hookReason, flagged, err := s.checkWebhook(ctx, rule.ContentModerationRule, in, text)
+		if err != nil || !flagged {
+			return "", "", false, err
+		}
+		reason = "webhook"
+		if len(categories) > 0 {
+			reason += ": " + strings.Join(categories, ", ")
+		}
+		if hookReason != "" {
+			reason += " (" + hookReason + ")"
+		}
+		return truncateModerationExcerpt(text), reason, true, nil
+	}
+	return "", "", false, nil
+}
+
+// recordViolation 记录命中；block 命中时累计违规次数并按阈值自动停用 API Key
+func (s *ContentModerationService) recordViolation(ctx context.Context, rule *compiledModerationRule, in *ContentModerationInput, reason, excerpt string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), contentModerationRecordTimeout)
+	defer cancel()
+
+	ruleID := rule.ID
+	violation := &ContentModerationViolation{
+		UserID:   in.APIKey.UserID,
+		APIKeyID: in.APIKey.ID,
+		GroupID:  in.APIKey.GroupID,
+		RuleID:   &ruleID,
+		RuleName: rule.Name,
+		RuleKind: rule.Kind,
+		Action:   rule.Action,
+		Endpoint: in.Endpoint,
+		Model:    truncateString(in.Model, 255),
+		Reason:   reason,
+		Excerpt:  excerpt,
+	}
+	if rule.Action == ContentModerationActionBlock {
+		violation.KeySuspended = s.maybeSuspendAPIKey(ctx, in.APIKey)
+	}
+	if err := s.repo.CreateViolation(ctx, violation); err != nil {
+		logger.LegacyPrintf("service.content_moderation", "[ContentModeration] record violation failed: api_key=%d err=%v", in.APIKey.ID, err)
+	}
+}
+
+// maybeSuspendAPIKey 统计窗口内已拦截次数（含本次）达到阈值时停用 API Key
+func (s *ContentModerationService) maybeSuspendAPIKey(ctx context.Context, apiKey *APIKey) bool {
+	threshold := s.cfg.ContentModeration.SuspendAfterViolations
+	if threshold <= 0 || s.apiKeyRepo == nil {
+		return false
+	}
+	since := time.Now().Add(-time.Duration(s.cfg.ContentModeration.ViolationWindowHours) * time.Hour)
+	count, err := s.repo.CountBlockedByAPIKeySince(ctx, apiKey.ID, since)
+	if err != nil {
+		logger.LegacyPrintf("service.content_moderation", "[ContentModeration] count violations failed: api_key=%d err=%v", apiKey.ID, err)
+		return false
+	}
+	if count+1 < int64(threshold) {
+		return false
+	}
+
+	key, err := s.apiKeyRepo.GetByID(ctx, apiKey.ID)
+	if err != nil {
+		logger.LegacyPrintf("service.content_moderation", "[ContentModeration] load api key failed: api_key=%d err=%v", apiKey.ID, err)
+		return false
+	}
+	if key.Status == StatusAPIKeyDisabled {
+		return false
+	}
+	if err := s.apiKeyRepo.UpdateStatus(ctx, key.ID, StatusAPIKeyDisabled); err != nil {
+		logger.LegacyPrintf("service.content_moderation", "[ContentModeration] suspend api key failed: api_key=%d err=%v", apiKey.ID, err)
+		return false
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByKey(ctx, key.Key)
+	}
+	logger.LegacyPrintf("service.content_moderation", "[ContentModeration] api key %d suspended after %d violations", apiKey.ID, count+1)
+	return true
+}
+
+type openAIModerationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// checkOpenAI 调用规则绑定的 OpenAI API Key 账号的 /v1/moderations
+func (s *ContentModerationService) checkOpenAI(ctx context.Context, rule *ContentModerationRule, text string) ([]string, bool, error) {
+	if rule.AccountID == nil || s.accountRepo == nil || s.httpUpstream == nil {
+		return nil, false, fmt.Errorf("moderation account not configured")
+	}
+	account, err := s.accountRepo.GetByID(ctx, *rule.AccountID)
+	if err != nil {
+		return nil, false, fmt.Errorf("load moderation account: %w", err)
+	}
+	apiKey := account.GetOpenAIApiKey()
+	if apiKey == "" {
+		return nil, false, fmt.Errorf("account %d is not an OpenAI API key account", account.ID)
+	}
+	baseURL, err := s.validateUpstreamBaseURL(account.GetOpenAIBaseURL())
+	if err != nil {
+		return nil, false, err
+	}
+	model := rule.ModerationModel
+	if model == "" {
+		model = defaultContentModerationModel
+	}
+	payload, err := json.Marshal(map[string]any{"model": model, "input": text})
+	if err != nil {
+		return nil, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.externalTimeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildOpenAIModerationsURL(baseURL), bytes.NewReader(payload))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+	resp, err := s.httpUpstream.Do(req, proxyURL, account.ID, account.Concurrency)
+	if err != nil {
+		return nil, false, fmt.Errorf("moderation request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, contentModerationMaxResponseLen))
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, false, fmt.Errorf("moderation upstream status %d", resp.StatusCode)
+	}
+	var parsed openAIModerationResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, false, fmt.Errorf("parse moderation response: %w", err)
+	}
+
+	var hit []string
+	for _, result := range parsed.Results {
+		if !result.Flagged {
+			continue
+		}
+		for category, flagged := range result.Categories {
+			if flagged && moderationCategoryWanted(rule.Categories, category) {
+				hit = append(hit, category)
+			}
+		}
+		if len(rule.Categories) == 0 && len(hit) == 0 {
+			hit = append(hit, "flagged")
+		}
+	}
+	sort.Strings(hit)
+	return hit, len(hit) > 0, nil
+}
+
+// buildOpenAIModerationsURL 组装 Moderation 端点：base 以 /v1 结尾时追加 /moderations，否则追加 /v1/moderations
+func buildOpenAIModerationsURL(base string) string {
+	normalized := strings.TrimRight(strings.TrimSpace(base), "/")
+	if strings.HasSuffix(normalized, "/v1") {
+		return normalized + "/moderations"
+	}
+	return normalized + "/v1/moderations"
+}
+
+type contentModerationWebhookRequest struct {
+	RuleID   int64  `json:"rule_id"`
+	UserID   int64  `json:"user_id"`
+	APIKeyID int64  `json:"api_key_id"`
+	GroupID  *int64 `json:"group_id,omitempty"`
+	Endpoint string `json:"endpoint"`
+	Model    string `json:"model"`
+	Input    string `json:"input"`
+}
+
+type contentModerationWebhookResponse struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories"`
+	Reason     string   `json:"reason"`
+}
+
+// checkWebhook 调用通用 HTTP 审核 Hook；配置了 secret 时附带
+// X-Sub2API-Signature: sha256=HMAC_SHA256(secret, "<timestamp>.<body>")，
+// Hook 返回 {"flagged": bool, "categories": [...], "reason": "..."}。
+func (s *ContentModerationService) checkWebhook(ctx context.Context, rule *ContentModerationRule, in *ContentModerationInput, text string) ([]string, string, bool, error) {
+	if _, err := s.validateWebhookURL(rule.WebhookURL); err != nil {
+		return nil, "", false, err
+	}
+	payload, err := json.Marshal(contentModerationWebhookRequest{
+		RuleID:   rule.ID,
+		UserID:   in.APIKey.UserID,
+		APIKeyID: in.APIKey.ID,
+		GroupID:  in.APIKey.GroupID,
+		Endpoint: in.Endpoint,
+		Model:    in.Model,
+		Input:    text,
+	})
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.externalTimeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, "", false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sub2API-Moderation")
+	req.Header.Set("X-Sub2API-Timestamp", timestamp)
+	if rule.WebhookSecret != "" {
+		req.Header.Set("X-Sub2API-Signature", "sha256="+signUserNotificationWebhook(rule.WebhookSecret, timestamp, payload))
+	}
+
+	client, err := s.webhookClient()
+	if err != nil {
+		return nil, "", false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("moderation webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, contentModerationMaxResponseLen))
+	if err != nil {
+		return nil, "", false, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", false, fmt.Errorf("moderation webhook status %d", resp.StatusCode)
+	}
+	var parsed contentModerationWebhookResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, "", false, fmt.Errorf("parse moderation webhook response: %w", err)
+	}
+	if !parsed.Flagged {
+		return nil, "", false, nil
+	}
+	if len(rule.Categories) == 0 {
+		return parsed.Categories, parsed.Reason, true, nil
+	}
+	var hit []string
+	for _, category := range parsed.Categories {
+		if moderationCategoryWanted(rule.Categories, category) {
+			hit = append(hit, category)
+		}
+	}
+	return hit, parsed.Reason, len(hit) > 0, nil
+}
+
+func moderationCategoryWanted(wanted []string, category string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, w := range wanted {
+		if strings.EqualFold(w, category) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ContentModerationService) externalTimeout() time.Duration {
+	if s.cfg != nil && s.cfg.ContentModeration.ExternalTimeoutSeconds > 0 {
+		return time.Duration(s.cfg.ContentModeration.ExternalTimeoutSeconds) * time.Second
+	}
+	return 5 * time.Second
+}
+
+func (s *ContentModerationService) webhookClient() (*http.Client, error) {
+	allowPrivate := s.cfg != nil && s.cfg.Security.URLAllowlist.AllowPrivateHosts
+	shared, err := httpclient.GetClient(httpclient.Options{
+		Timeout:            s.externalTimeout(),
+		ValidateResolvedIP: true,
+		AllowPrivateHosts:  allowPrivate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 复制共享客户端并禁止跟随重定向，避免绕过地址校验
+	client := *shared
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &client, nil
+}
+
+func (s *ContentModerationService) validateWebhookURL(raw string) (string, error) {
+	allowInsecureHTTP := false
+	allowPrivate := false
+	if s.cfg != nil {
+		allowInsecureHTTP = s.cfg.Security.URLAllowlist.AllowInsecureHTTP
+		allowPrivate = s.cfg.Security.URLAllowlist.AllowPrivateHosts
+	}
+	normalized, err := urlvalidator.ValidateHTTPURL(raw, allowInsecureHTTP, urlvalidator.ValidationOptions{
+		AllowPrivate: allowPrivate,
+	})
+	if err != nil {
+		return "", infraerrors.BadRequest(ErrContentModerationInvalidRule.Reason, "invalid webhook url: "+err.Error())
+	}
+	return normalized, nil
+}
+
+func (s *ContentModerationService) validateUpstreamBaseURL(raw string) (string, error) {
+	if s.cfg == nil || !s.cfg.Security.URLAllowlist.Enabled {
+		allowInsecureHTTP := s.cfg != nil && s.cfg.Security.URLAllowlist.AllowInsecureHTTP
+		normalized, err := urlvalidator.ValidateURLFormat(raw, allowInsecureHTTP)
+		if err != nil {
+			return "", fmt.Errorf("invalid base_url: %w", err)
+		}
+		return normalized, nil
+	}
+	normalized, err := urlvalidator.ValidateHTTPSURL(raw, urlvalidator.ValidationOptions{
+		AllowedHosts:     s.cfg.Security.URLAllowlist.UpstreamHosts,
+		RequireAllowlist: true,
+		AllowPrivate:     s.cfg.Security.URLAllowlist.AllowPrivateHosts,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid base_url: %w", err)
+	}
+	return normalized, nil
+}
+
+// cachedRules 网关热路径读取启用的规则（进程内缓存 30s），读取失败时沿用旧缓存
+func (s *ContentModerationService) cachedRules(ctx context.Context) []*compiledModerationRule {
+	entry := s.rulesCache.Load()
+	if entry != nil && time.Now().Before(entry.expiresAt) {
+		return entry.rules
+	}
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		logger.LegacyPrintf("service.content_moderation", "[ContentModeration] load rules failed: %v", err)
+		if entry != nil {
+			return entry.rules
+		}
+		rules = nil
+	}
+	return s.storeRulesCache(rules)
+}
+
+func (s *ContentModerationService) storeRulesCache(rules []*ContentModerationRule) []*compiledModerationRule {
+	compiled := make([]*compiledModerationRule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		matcher, err := newContentModerationMatcher(rule)
+		if err != nil {
+			logger.LegacyPrintf("service.content_moderation", "[ContentModeration] skip rule %d: %v", rule.ID, err)
+			continue
+		}
+		compiled = append(compiled, &compiledModerationRule{ContentModerationRule: rule, matcher: matcher})
+	}
+	sort.SliceStable(compiled, func(i, j int) bool {
+		if compiled[i].Priority != compiled[j].Priority {
+			return compiled[i].Priority < compiled[j].Priority
+		}
+		return compiled[i].ID < compiled[j].ID
+	})
+	s.rulesCache.Store(&cachedModerationRules{rules: compiled, expiresAt: time.Now().Add(contentModerationRuleCacheTTL)})
+	return compiled
+}
+
+func (s *ContentModerationService) invalidateRulesCache() {
+	s.rulesCache.Store(nil)
+}
+
+// ListRules 获取所有规则
+func (s *ContentModerationService) ListRules(ctx context.Context) ([]*ContentModerationRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+// GetRule 根据 ID 获取规则
+func (s *ContentModerationService) GetRule(ctx context.Context, id int64) (*ContentModerationRule, error) {
+	return s.repo.GetRule(ctx, id)
+}
+
+// CreateRule 校验并创建规则
+func (s *ContentModerationService) CreateRule(ctx context.Context, rule *ContentModerationRule) (*ContentModerationRule, error) {
+	if err := s.validateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.invalidateRulesCache()
+	return rule, nil
+}
+
+// UpdateRule 校验并更新规则
+func (s *ContentModerationService) UpdateRule(ctx context.Context, rule *ContentModerationRule) (*ContentModerationRule, error) {
+	if err := s.validateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.invalidateRulesCache()
+	return rule, nil
+}
+
+// DeleteRule 删除规则
+func (s *ContentModerationService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateRulesCache()
+	return nil
+}
+
+// ListViolations 查询命中记录
+func (s *ContentModerationService) ListViolations(ctx context.Context, filter ContentModerationViolationFilter, params pagination.PaginationParams) ([]ContentModerationViolation, *pagination.PaginationResult, error) {
+	return s.repo.ListViolations(ctx, filter, params)
+}
+
+// ListUserStats 按用户汇总最近 days 天的违规次数
+func (s *ContentModerationService) ListUserStats(ctx context.Context, days int, params pagination.PaginationParams) ([]ContentModerationUserStat, *pagination.PaginationResult, error) {
+	if days <= 0 {
+		days = 30
+	}
+	return s.repo.ListUserStats(ctx, time.Now().AddDate(0, 0, -days), params)
+}
+
+func invalidModerationRule(message string) error {
+	return infraerrors.BadRequest(ErrContentModerationInvalidRule.Reason, message)
+}
+
+func (s *ContentModerationService) validateRule(ctx context.Context, rule *ContentModerationRule) error {
+	if rule == nil {
+		return invalidModerationRule("rule is required")
+	}
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" || len(rule.Name) > 100 {
+		return invalidModerationRule("name is required and must be at most 100 characters")
+	}
+	switch rule.Action {
+	case "":
+		rule.Action = ContentModerationActionBlock
+	case ContentModerationActionBlock, ContentModerationActionFlag:
+	default:
+		return invalidModerationRule("action must be block or flag")
+	}
+	rule.GroupIDs = normalizePositiveIDs(rule.GroupIDs)
+	rule.Patterns = normalizeModerationStrings(rule.Patterns)
+	rule.Categories = normalizeModerationStrings(rule.Categories)
+
+	switch rule.Kind {
+	case ContentModerationKindKeyword, ContentModerationKindRegex:
+		if len(rule.Patterns) == 0 {
+			return invalidModerationRule("patterns are required for keyword and regex rules")
+		}
+		if _, err := newContentModerationMatcher(rule); err != nil {
+			return invalidModerationRule("invalid regex pattern: " + err.Error())
+		}
+	case ContentModerationKindOpenAI:
+		if rule.AccountID == nil || *rule.AccountID <= 0 {
+			return invalidModerationRule("account_id is required for openai_moderation rules")
+		}
+		if s.accountRepo != nil {
+			account, err := s.accountRepo.GetByID(ctx, *rule.AccountID)
+			if err != nil {
+				return invalidModerationRule("moderation account not found")
+			}
+			if !account.IsOpenAIApiKey() {
+				return invalidModerationRule("moderation account must be an OpenAI API key account")
+			}
+		}
+	case ContentModerationKindWebhook:
+		normalized, err := s.validateWebhookURL(rule.WebhookURL)
+		if err != nil {
+			return err
+		}
+		rule.WebhookURL = normalized
+	default:
+		return invalidModerationRule("kind must be keyword, regex, openai_moderation or webhook")
+	}
+	if rule.Kind != ContentModerationKindOpenAI {
+		rule.AccountID = nil
+		rule.ModerationModel = ""
+	}
+	if rule.Kind != ContentModerationKindWebhook {
+		rule.WebhookURL = ""
+		rule.WebhookSecret = ""
+	}
+	rule.WebhookSecretConfigured = rule.WebhookSecret != ""
+	return nil
+}
+
+func normalizeModerationStrings(values []string) []string {
+	out := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
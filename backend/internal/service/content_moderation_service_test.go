@@ -0,0 +1,270 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type contentModerationRepoStub struct {
+	rules      []*ContentModerationRule
+	violations []*ContentModerationViolation
+}
+
+func (s *contentModerationRepoStub) ListRules(context.Context) ([]*ContentModerationRule, error) {
+	return s.rules, nil
+}
+
+func (s *contentModerationRepoStub) GetRule(_ context.Context, id int64) (*ContentModerationRule, error) {
+	for _, r := range s.rules {
+		if r.ID == id {
+			cp := *r
+			return &cp, nil
+		}
+	}
+	return nil, ErrContentModerationRuleNotFound
+}
+
+func (s *contentModerationRepoStub) CreateRule(_ context.Context, rule *ContentModerationRule) error {
+	rule.ID = int64(len(s.rules) + 1)
+	s.rules = append(s.rules, rule)
+	return nil
+}
+
+func (s *contentModerationRepoStub) UpdateRule(context.Context, *ContentModerationRule) error {
+	panic("unexpected UpdateRule call")
+}
+
+func (s *contentModerationRepoStub) DeleteRule(context.Context, int64) error {
+	panic("unexpected DeleteRule call")
+}
+
+func (s *contentModerationRepoStub) CreateViolation(_ context.Context, v *ContentModerationViolation) error {
+	v.ID = int64(len(s.violations) + 1)
+	v.CreatedAt = time.Now()
+	s.violations = append(s.violations, v)
+	return nil
+}
+
+func (s *contentModerationRepoStub) CountBlockedByAPIKeySince(_ context.Context, apiKeyID int64, since time.Time) (int64, error) {
+	var n int64
+	for _, v := range s.violations {
+		if v.APIKeyID == apiKeyID && v.Action == ContentModerationActionBlock && !v.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *contentModerationRepoStub) ListViolations(context.Context, ContentModerationViolationFilter, pagination.PaginationParams) ([]ContentModerationViolation, *pagination.PaginationResult, error) {
+	panic("unexpected ListViolations call")
+}
+
+func (s *contentModerationRepoStub) ListUserStats(context.Context, time.Time, pagination.PaginationParams) ([]ContentModerationUserStat, *pagination.PaginationResult, error) {
+	panic("unexpected ListUserStats call")
+}
+
+type moderationAPIKeyRepoStub struct {
+	apiKeyRepoStub
+	disabled []int64
+}
+
+func (s *moderationAPIKeyRepoStub) UpdateStatus(_ context.Context, id int64, status string) error {
+	if status == StatusAPIKeyDisabled {
+		s.disabled = append(s.disabled, id)
+	}
+	return nil
+}
+
+type moderationAccountRepoStub struct {
+	accountRepoStub
+	account *Account
+}
+
+func (s *moderationAccountRepoStub) GetByID(context.Context, int64) (*Account, error) {
+	return s.account, nil
+}
+
+type moderationUpstreamStub struct {
+	body     string
+	requests []*http.Request
+	payloads []string
+}
+
+func (s *moderationUpstreamStub) Do(req *http.Request, _ string, _ int64, _ int) (*http.Response, error) {
+	payload, _ := io.ReadAll(req.Body)
+	s.requests = append(s.requests, req)
+	s.payloads = append(s.payloads, string(payload))
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(s.body))}, nil
+}
+
+func (s *moderationUpstreamStub) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, _ bool) (*http.Response, error) {
+	return s.Do(req, proxyURL, accountID, accountConcurrency)
+}
+
+type authCacheInvalidatorRecorder struct {
+	keys []string
+}
+
+func (r *authCacheInvalidatorRecorder) InvalidateAuthCacheByKey(_ context.Context, key string) {
+	r.keys = append(r.keys, key)
+}
+func (r *authCacheInvalidatorRecorder) InvalidateAuthCacheByUserID(context.Context, int64)  {}
+func (r *authCacheInvalidatorRecorder) InvalidateAuthCacheByGroupID(context.Context, int64) {}
+
+func newContentModerationTestConfig() *config.Config {
+	return &config.Config{ContentModeration: config.ContentModerationConfig{
+		Enabled:                true,
+		ExternalTimeoutSeconds: 5,
+		MaxTextBytes:           32 << 10,
+		ViolationWindowHours:   24,
+	}}
+}
+
+func TestExtractModerationText_Protocols(t *testing.T) {
+	anthropic := `{"model":"claude","system":[{"type":"text","text":"be nice"}],"messages":[{"role":"user","content":[{"type":"text","text":"hello"},{"type":"image","source":{"data":"AAAA"}}]}],"tools":[{"description":"secret tool"}]}`
+	text := ExtractModerationText([]byte(anthropic), 0)
+	require.Contains(t, text, "be nice")
+	require.Contains(t, text, "hello")
+	require.NotContains(t, text, "AAAA")
+	require.NotContains(t, text, "secret tool")
+
+	chat := `{"messages":[{"role":"system","content":"sys"},{"role":"user","content":[{"type":"text","text":"chat prompt"}]}]}`
+	require.Equal(t, "sys\nchat prompt\n", ExtractModerationText([]byte(chat), 0))
+
+	responses := `{"instructions":"inst","input":[{"role":"user","content":[{"type":"input_text","text":"resp prompt"}]}]}`
+	require.Equal(t, "inst\nresp prompt\n", ExtractModerationText([]byte(responses), 0))
+
+	gemini := `{"systemInstruction":{"parts":[{"text":"gsys"}]},"contents":[{"role":"user","parts":[{"text":"gemini prompt"}]}]}`
+	require.Equal(t, "gsys\ngemini prompt\n", ExtractModerationText([]byte(gemini), 0))
+
+	require.Equal(t, "prompt\n", ExtractModerationText([]byte(chat), 7), "truncation keeps the latest text")
+}
+
+func TestContentModerationService_KeywordRulesPerGroup(t *testing.T) {
+	groupA, groupB := int64(1), int64(2)
+	repo := &contentModerationRepoStub{rules: []*ContentModerationRule{
+		{ID: 1, Name: "flag-word", Enabled: true, Priority: 1, Kind: ContentModerationKindKeyword, Action: ContentModerationActionFlag, Patterns: []string{"suspicious"}},
+		{ID: 2, Name: "block-regex", Enabled: true, Priority: 2, Kind: ContentModerationKindRegex, Action: ContentModerationActionBlock, GroupIDs: []int64{groupA}, Patterns: []string{`forbidden\s+topic`}, RefusalMessage: "nope"},
+	}}
+	svc := NewContentModerationService(repo, nil, nil, nil, nil, newContentModerationTestConfig())
+	ctx := context.Background()
+	body := []byte(`{"messages":[{"role":"user","content":"a SUSPICIOUS Forbidden   Topic here"}]}`)
+
+	require.True(t, svc.HasRules(ctx, &groupB))
+
+	decision, err := svc.Check(ctx, &ContentModerationInput{APIKey: &APIKey{ID: 10, UserID: 5, GroupID: &groupB}, Endpoint: "/v1/messages", Body: body})
+	require.NoError(t, err)
+	require.Nil(t, decision, "group B only has the flag rule")
+	require.Len(t, repo.violations, 1)
+	require.Equal(t, ContentModerationActionFlag, repo.violations[0].Action)
+
+	decision, err = svc.Check(ctx, &ContentModerationInput{APIKey: &APIKey{ID: 11, UserID: 5, GroupID: &groupA}, Endpoint: "/v1/messages", Body: body})
+	require.NoError(t, err)
+	require.NotNil(t, decision)
+	require.True(t, decision.Blocked)
+	require.Equal(t, "nope", decision.Message)
+	require.Len(t, repo.violations, 3)
+	require.Equal(t, "block-regex", repo.violations[2].RuleName)
+	require.Contains(t, repo.violations[2].Excerpt, "Forbidden   Topic")
+}
+
+func TestContentModerationService_SuspendsKeyAfterThreshold(t *testing.T) {
+	repo := &contentModerationRepoStub{rules: []*ContentModerationRule{
+		{ID: 1, Name: "block", Enabled: true, Kind: ContentModerationKindKeyword, Action: ContentModerationActionBlock, Patterns: []string{"bad"}},
+	}}
+	keyRepo := &moderationAPIKeyRepoStub{apiKeyRepoStub: apiKeyRepoStub{apiKey: &APIKey{ID: 7, Key: "sk-test", Status: StatusAPIKeyActive}}}
+	invalidator := &authCacheInvalidatorRecorder{}
+	cfg := newContentModerationTestConfig()
+	cfg.ContentModeration.SuspendAfterViolations = 2
+	svc := NewContentModerationService(repo, nil, keyRepo, invalidator, nil, cfg)
+	in := &ContentModerationInput{APIKey: &APIKey{ID: 7, UserID: 3}, Body: []byte(`{"input":"bad"}`)}
+
+	_, err := svc.Check(context.Background(), in)
+	require.NoError(t, err)
+	require.Empty(t, keyRepo.disabled)
+	require.False(t, repo.violations[0].KeySuspended)
+
+	_, err = svc.Check(context.Background(), in)
+	require.NoError(t, err)
+	require.Equal(t, []int64{7}, keyRepo.disabled)
+	require.Equal(t, []string{"sk-test"}, invalidator.keys)
+	require.True(t, repo.violations[1].KeySuspended)
+}
+
+func TestContentModerationService_OpenAIModerationCategories(t *testing.T) {
+	accountID := int64(42)
+	repo := &contentModerationRepoStub{rules: []*ContentModerationRule{
+		{ID: 1, Name: "omni", Enabled: true, Kind: ContentModerationKindOpenAI, Action: ContentModerationActionBlock, AccountID: &accountID, Categories: []string{"violence"}},
+	}}
+	account := &Account{ID: accountID, Platform: PlatformOpenAI, Type: AccountTypeAPIKey, Concurrency: 1, Credentials: map[string]any{"api_key": "sk-mod", "base_url": "https://api.example.com/v1"}}
+	upstream := &moderationUpstreamStub{body: `{"results":[{"flagged":true,"categories":{"harassment":true,"violence":false}}]}`}
+	svc := NewContentModerationService(repo, &moderationAccountRepoStub{account: account}, nil, nil, upstream, newContentModerationTestConfig())
+	in := &ContentModerationInput{APIKey: &APIKey{ID: 1, UserID: 1}, Body: []byte(`{"input":"text"}`)}
+
+	decision, err := svc.Check(context.Background(), in)
+	require.NoError(t, err)
+	require.Nil(t, decision, "flagged category is not in the rule's categories")
+	require.Len(t, upstream.requests, 1)
+	require.Equal(t, "https://api.example.com/v1/moderations", upstream.requests[0].URL.String())
+	require.Equal(t, "Bearer sk-mod", upstream.requests[0].Header.Get("Authorization"))
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(upstream.payloads[0]), &payload))
+	require.Equal(t, defaultContentModerationModel, payload["model"])
+
+	upstream.body = `{"results":[{"flagged":true,"categories":{"violence":true}}]}`
+	decision, err = svc.Check(context.Background(), in)
+	require.NoError(t, err)
+	require.NotNil(t, decision)
+	require.True(t, decision.Blocked)
+	require.Equal(t, "openai_moderation: violence", repo.violations[0].Reason)
+}
+
+func TestContentModerationService_ExternalFailure(t *testing.T) {
+	accountID := int64(42)
+	repo := &contentModerationRepoStub{rules: []*ContentModerationRule{
+		{ID: 1, Name: "omni", Enabled: true, Kind: ContentModerationKindOpenAI, Action: ContentModerationActionBlock, AccountID: &accountID},
+	}}
+	account := &Account{ID: accountID, Platform: PlatformAnthropic, Type: AccountTypeAPIKey}
+	cfg := newContentModerationTestConfig()
+	svc := NewContentModerationService(repo, &moderationAccountRepoStub{account: account}, nil, nil, &moderationUpstreamStub{}, cfg)
+	in := &ContentModerationInput{APIKey: &APIKey{ID: 1, UserID: 1}, Body: []byte(`{"input":"text"}`)}
+
+	_, err := svc.Check(context.Background(), in)
+	require.ErrorIs(t, err, ErrContentModerationUnavailable)
+
+	cfg.ContentModeration.FailOpen = true
+	decision, err := svc.Check(context.Background(), in)
+	require.NoError(t, err)
+	require.Nil(t, decision)
+}
+
+func TestContentModerationService_CreateRuleValidation(t *testing.T) {
+	repo := &contentModerationRepoStub{}
+	svc := NewContentModerationService(repo, nil, nil, nil, nil, newContentModerationTestConfig())
+	ctx := context.Background()
+
+	_, err := svc.CreateRule(ctx, &ContentModerationRule{Name: "r", Kind: ContentModerationKindRegex, Patterns: []string{"("}})
+	require.ErrorIs(t, err, ErrContentModerationInvalidRule)
+
+	_, err = svc.CreateRule(ctx, &ContentModerationRule{Name: "r", Kind: "unknown", Patterns: []string{"x"}})
+	require.ErrorIs(t, err, ErrContentModerationInvalidRule)
+
+	rule, err := svc.CreateRule(ctx, &ContentModerationRule{Name: " kw ", Enabled: true, Kind: ContentModerationKindKeyword, Patterns: []string{"a", " a ", ""}, GroupIDs: []int64{3, 3, 0}, WebhookURL: "https://ignored"})
+	require.NoError(t, err)
+	require.Equal(t, "kw", rule.Name)
+	require.Equal(t, ContentModerationActionBlock, rule.Action)
+	require.Equal(t, []string{"a"}, rule.Patterns)
+	require.Equal(t, []int64{3}, rule.GroupIDs)
+	require.Empty(t, rule.WebhookURL)
+}
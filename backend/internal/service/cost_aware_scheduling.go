@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 分组账号调度策略
+//
+// 默认策略按 优先级 → 负载率 → LRU 选择账号，不考虑账号成本。
+// cost_aware 策略在同一优先级内先按成本评分筛选，再按负载率与 LRU 选择：
+// 1. 边际成本：预付费 OAuth/SetupToken 账号为 0，按量付费账号取账号倍率
+// 2. 即将过期的未用额度：5h 会话窗口越接近重置、窗口费用用得越少，越优先使用
+// 3. 剩余配额：总/日/周额度任一维度接近耗尽时降低优先级
+const (
+	// SchedulingStrategyDefault 默认调度策略（优先级 → 负载率 → LRU）
+	SchedulingStrategyDefault = ""
+	// SchedulingStrategyCostAware 成本感知调度策略
+	SchedulingStrategyCostAware = "cost_aware"
+
+	// costAwareScoreBucket 评分分桶粒度，差距小于该值的账号视为同一档，交给负载率与 LRU 决定
+	costAwareScoreBucket = 0.05
+	// costAwareLowHeadroom 剩余配额比例低于该值时开始惩罚
+	costAwareLowHeadroom = 0.1
+)
+
+var ErrInvalidSchedulingStrategy = infraerrors.BadRequest("INVALID_SCHEDULING_STRATEGY", "scheduling strategy must be empty or cost_aware")
+
+// IsValidSchedulingStrategy 校验分组调度策略
+func IsValidSchedulingStrategy(strategy string) bool {
+	switch strategy {
+	case SchedulingStrategyDefault, SchedulingStrategyCostAware:
+		return true
+	default:
+		return false
+	}
+}
+
+// costAwareAccountScore 计算账号的成本评分，分数越低越优先
+func costAwareAccountScore(ctx context.Context, account *Account, now time.Time) float64 {
+	if account == nil {
+		return math.Inf(1)
+	}
+	score := 0.0
+	if !account.IsOAuth() {
+		score = account.BillingRateMultiplier()
+	}
+	score -= costAwareExpiringCapacity(ctx, account, now)
+	if headroom := costAwareQuotaHeadroom(account); headroom < costAwareLowHeadroom {
+		score += (costAwareLowHeadroom - headroom) / costAwareLowHeadroom
+	}
+	return score
+}
+
+// costAwareExpiringCapacity 估算 5h 会话窗口内即将随重置作废的未用额度（0-1）
+// = 窗口已流逝比例 × 窗口费用未用比例（未配置窗口费用阈值时视为全部未用）
+func costAwareExpiringCapacity(ctx context.Context, account *Account, now time.Time) float64 {
+	if account.SessionWindowStart == nil || account.SessionWindowEnd == nil || !now.Before(*account.SessionWindowEnd) {
+		return 0
+	}
+	total := account.SessionWindowEnd.Sub(*account.SessionWindowStart)
+	if total <= 0 {
+		return 0
+	}
+	elapsed := clampUnit(float64(now.Sub(*account.SessionWindowStart)) / float64(total))
+
+	unused := 1.0
+	if limit := account.GetWindowCostLimit(); limit > 0 {
+		if cost, ok := windowCostFromPrefetchContext(ctx, account.ID); ok {
+			unused = clampUnit(1 - cost/limit)
+		}
+	}
+	return elapsed * unused
+}
+
+// costAwareQuotaHeadroom 返回总/日/周额度中最小的剩余比例（0-1），未配置额度时返回 1
+func costAwareQuotaHeadroom(account *Account) float64 {
+	headroom := 1.0
+	consider := func(limit, used float64) {
+		if limit > 0 {
+			headroom = math.Min(headroom, clampUnit(1-used/limit))
+		}
+	}
+	consider(account.GetQuotaLimit(), account.GetQuotaUsed())
+	if !account.isQuotaDailyPeriodExpired() {
+		consider(account.GetQuotaDailyLimit(), account.GetQuotaDailyUsed())
+	}
+	if !account.isQuotaWeeklyPeriodExpired() {
+		consider(account.GetQuotaWeeklyLimit(), account.GetQuotaWeeklyUsed())
+	}
+	return headroom
+}
+
+func clampUnit(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// filterByMinCostScore 过滤出成本评分最低档的账号集合
+func filterByMinCostScore(ctx context.Context, accounts []accountWithLoad, now time.Time) []accountWithLoad {
+	if len(accounts) <= 1 {
+		return accounts
+	}
+	buckets := make([]float64, len(accounts))
+	minBucket := math.Inf(1)
+	for i, acc := range accounts {
+		buckets[i] = math.Round(costAwareAccountScore(ctx, acc.account, now) / costAwareScoreBucket)
+		if buckets[i] < minBucket {
+			minBucket = buckets[i]
+		}
+	}
+	result := make([]accountWithLoad, 0, len(accounts))
+	for i, acc := range accounts {
+		if buckets[i] == minBucket {
+			result = append(result, acc)
+		}
+	}
+	return result
+}
@@ -0,0 +1,197 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostAwareAccountScore(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	windowAt := func(elapsed time.Duration) (*time.Time, *time.Time) {
+		start := now.Add(-elapsed)
+		end := start.Add(5 * time.Hour)
+		return &start, &end
+	}
+	rate := func(v float64) *float64 { return &v }
+	lateStart, lateEnd := windowAt(4 * time.Hour)
+	earlyStart, earlyEnd := windowAt(time.Hour)
+	staleStart, staleEnd := windowAt(6 * time.Hour)
+
+	tests := []struct {
+		name       string
+		account    *Account
+		windowCost map[int64]float64
+		want       float64
+	}{
+		{
+			name:    "oauth without window is free",
+			account: &Account{ID: 1, Type: AccountTypeOAuth},
+			want:    0,
+		},
+		{
+			name:    "api key uses rate multiplier",
+			account: &Account{ID: 2, Type: AccountTypeAPIKey, RateMultiplier: rate(1.5)},
+			want:    1.5,
+		},
+		{
+			name:    "api key without multiplier defaults to 1",
+			account: &Account{ID: 3, Type: AccountTypeAPIKey},
+			want:    1,
+		},
+		{
+			name:    "window about to reset with unused budget",
+			account: &Account{ID: 4, Type: AccountTypeOAuth, SessionWindowStart: lateStart, SessionWindowEnd: lateEnd},
+			want:    -0.8,
+		},
+		{
+			name:    "window just started",
+			account: &Account{ID: 5, Type: AccountTypeSetupToken, SessionWindowStart: earlyStart, SessionWindowEnd: earlyEnd},
+			want:    -0.2,
+		},
+		{
+			name:    "expired window is ignored",
+			account: &Account{ID: 6, Type: AccountTypeOAuth, SessionWindowStart: staleStart, SessionWindowEnd: staleEnd},
+			want:    0,
+		},
+		{
+			name: "window cost consumed reduces expiring bonus",
+			account: &Account{
+				ID: 7, Type: AccountTypeOAuth, SessionWindowStart: lateStart, SessionWindowEnd: lateEnd,
+				Extra: map[string]any{"window_cost_limit": 100.0},
+			},
+			windowCost: map[int64]float64{7: 75},
+			want:       -0.2,
+		},
+		{
+			name: "low total quota headroom is penalized",
+			account: &Account{
+				ID: 8, Type: AccountTypeAPIKey,
+				Extra: map[string]any{"quota_limit": 100.0, "quota_used": 95.0},
+			},
+			want: 1.5,
+		},
+		{
+			name: "low daily quota headroom is penalized",
+			account: &Account{
+				ID: 9, Type: AccountTypeAPIKey, RateMultiplier: rate(0.5),
+				Extra: map[string]any{
+					"quota_daily_limit": 10.0,
+					"quota_daily_used":  10.0,
+					"quota_daily_start": time.Now().Add(-time.Hour).Format(time.RFC3339),
+				},
+			},
+			want: 1.5,
+		},
+		{
+			name: "expired daily period restores headroom",
+			account: &Account{
+				ID: 10, Type: AccountTypeAPIKey, RateMultiplier: rate(0.5),
+				Extra: map[string]any{
+					"quota_daily_limit": 10.0,
+					"quota_daily_used":  10.0,
+					"quota_daily_start": time.Now().Add(-25 * time.Hour).Format(time.RFC3339),
+				},
+			},
+			want: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.windowCost != nil {
+				ctx = context.WithValue(ctx, windowCostPrefetchContextKey, tt.windowCost)
+			}
+			require.InDelta(t, tt.want, costAwareAccountScore(ctx, tt.account, now), 1e-9)
+		})
+	}
+}
+
+func TestFilterByMinCostScore(t *testing.T) {
+	now := time.Now()
+	windowStart := now.Add(-4 * time.Hour)
+	windowEnd := windowStart.Add(5 * time.Hour)
+	rate := func(v float64) *float64 { return &v }
+	withLoad := func(accounts ...*Account) []accountWithLoad {
+		out := make([]accountWithLoad, 0, len(accounts))
+		for _, acc := range accounts {
+			out = append(out, accountWithLoad{account: acc, loadInfo: &AccountLoadInfo{}})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		accounts []accountWithLoad
+		wantIDs  []int64
+	}{
+		{
+			name:     "empty",
+			accounts: nil,
+			wantIDs:  nil,
+		},
+		{
+			name: "prepaid oauth before pay as you go",
+			accounts: withLoad(
+				&Account{ID: 1, Type: AccountTypeAPIKey},
+				&Account{ID: 2, Type: AccountTypeOAuth},
+			),
+			wantIDs: []int64{2},
+		},
+		{
+			name: "expiring window wins among oauth",
+			accounts: withLoad(
+				&Account{ID: 1, Type: AccountTypeOAuth},
+				&Account{ID: 2, Type: AccountTypeOAuth, SessionWindowStart: &windowStart, SessionWindowEnd: &windowEnd},
+			),
+			wantIDs: []int64{2},
+		},
+		{
+			name: "cheaper api key account wins",
+			accounts: withLoad(
+				&Account{ID: 1, Type: AccountTypeAPIKey, RateMultiplier: rate(1)},
+				&Account{ID: 2, Type: AccountTypeAPIKey, RateMultiplier: rate(0.8)},
+			),
+			wantIDs: []int64{2},
+		},
+		{
+			name: "near-equal scores share a bucket",
+			accounts: withLoad(
+				&Account{ID: 1, Type: AccountTypeAPIKey, RateMultiplier: rate(1)},
+				&Account{ID: 2, Type: AccountTypeAPIKey, RateMultiplier: rate(1.01)},
+				&Account{ID: 3, Type: AccountTypeAPIKey, RateMultiplier: rate(2)},
+			),
+			wantIDs: []int64{1, 2},
+		},
+		{
+			name: "nearly exhausted quota falls behind",
+			accounts: withLoad(
+				&Account{ID: 1, Type: AccountTypeAPIKey, RateMultiplier: rate(0.5), Extra: map[string]any{"quota_limit": 10.0, "quota_used": 9.9}},
+				&Account{ID: 2, Type: AccountTypeAPIKey, RateMultiplier: rate(1)},
+			),
+			wantIDs: []int64{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := filterByMinCostScore(context.Background(), tt.accounts, now)
+			var ids []int64
+			for _, acc := range result {
+				ids = append(ids, acc.account.ID)
+			}
+			require.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestIsValidSchedulingStrategy(t *testing.T) {
+	require.True(t, IsValidSchedulingStrategy(SchedulingStrategyDefault))
+	require.True(t, IsValidSchedulingStrategy(SchedulingStrategyCostAware))
+	require.False(t, IsValidSchedulingStrategy("cheapest"))
+}
@@ -92,6 +92,9 @@ const (
 	SettingKeySMTPFromName = "smtp_from_name" // 发件人名称
 	SettingKeySMTPUseTLS   = "smtp_use_tls"   // 是否使用TLS
 
+	// SettingKeyEmailDefaultLocale 邮件模板默认语言（用户未设置语言偏好时使用，空值=en）
+	SettingKeyEmailDefaultLocale = "email_default_locale"
+
 	// Cloudflare Turnstile 设置
 	SettingKeyTurnstileEnabled   = "turnstile_enabled"    // 是否启用 Turnstile 验证
 	SettingKeyTurnstileSiteKey   = "turnstile_site_key"   // Turnstile Site Key
@@ -100,6 +103,10 @@ const (
 	// TOTP 双因素认证设置
 	SettingKeyTotpEnabled = "totp_enabled" // 是否启用 TOTP 2FA 功能
 
+	// WebAuthn 通行密钥与 2FA 策略
+	SettingKeyPasskeyEnabled   = "passkey_enabled"    // 是否启用通行密钥（无密码登录与第二因素）
+	SettingKeyAdmin2FARequired = "admin_2fa_required" // 管理员必须设置 2FA（TOTP 或通行密钥）才能访问管理后台
+
 	// LinuxDo Connect OAuth 登录设置
 	SettingKeyLinuxDoConnectEnabled      = "linuxdo_connect_enabled"
 	SettingKeyLinuxDoConnectClientID     = "linuxdo_connect_client_id"
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/subtle"
@@ -9,9 +10,14 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net/smtp"
+	"net/textproto"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
@@ -84,13 +90,15 @@ type SMTPConfig struct {
 type EmailService struct {
 	settingRepo SettingRepository
 	cache       EmailCache
+	templates   *EmailTemplateService
 }
 
-// NewEmailService 创建邮件服务实例
-func NewEmailService(settingRepo SettingRepository, cache EmailCache) *EmailService {
+// NewEmailService 创建邮件服务实例；templates 为空时只使用内置模板
+func NewEmailService(settingRepo SettingRepository, cache EmailCache, templates *EmailTemplateService) *EmailService {
 	return &EmailService{
 		settingRepo: settingRepo,
 		cache:       cache,
+		templates:   templates,
 	}
 }
 
@@ -136,33 +144,93 @@ func (s *EmailService) GetSMTPConfig(ctx context.Context) (*SMTPConfig, error) {
 	}, nil
 }
 
-// SendEmail 发送邮件（使用数据库中保存的配置）
+// SendEmail 发送邮件（使用数据库中保存的配置）；纯文本版本由 HTML 正文自动生成
 func (s *EmailService) SendEmail(ctx context.Context, to, subject, body string) error {
+	return s.SendRenderedEmail(ctx, to, &RenderedEmail{Subject: subject, HTML: body, Text: htmlToPlainText(body)})
+}
+
+// SendTemplateEmail 渲染邮件模板并发送；locale 为空时使用收件人的语言偏好
+func (s *EmailService) SendTemplateEmail(ctx context.Context, to, key, locale string, data any) error {
+	if strings.TrimSpace(locale) == "" {
+		locale = s.templates.ResolveLocale(ctx, to)
+	}
+	msg, err := s.templates.Render(ctx, key, locale, data)
+	if err != nil {
+		return fmt.Errorf("render email template %s: %w", key, err)
+	}
+	return s.SendRenderedEmail(ctx, to, msg)
+}
+
+// SendRenderedEmail 发送已渲染的邮件（HTML + 纯文本）
+func (s *EmailService) SendRenderedEmail(ctx context.Context, to string, msg *RenderedEmail) error {
 	config, err := s.GetSMTPConfig(ctx)
 	if err != nil {
 		return err
 	}
-	return s.SendEmailWithConfig(config, to, subject, body)
+	return s.sendWithConfig(config, to, msg)
 }
 
 // SendEmailWithConfig 使用指定配置发送邮件
 func (s *EmailService) SendEmailWithConfig(config *SMTPConfig, to, subject, body string) error {
-	from := config.From
-	if config.FromName != "" {
-		from = fmt.Sprintf("%s <%s>", config.FromName, config.From)
-	}
+	return s.sendWithConfig(config, to, &RenderedEmail{Subject: subject, HTML: body, Text: htmlToPlainText(body)})
+}
 
-	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s",
-		from, to, subject, body)
+func (s *EmailService) sendWithConfig(config *SMTPConfig, to string, email *RenderedEmail) error {
+	msg, err := buildMIMEMessage(config, to, email)
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
 
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	auth := smtp.PlainAuth("", config.Username, config.Password, config.Host)
 
 	if config.UseTLS {
-		return s.sendMailTLS(addr, auth, config.From, to, []byte(msg), config.Host)
+		return s.sendMailTLS(addr, auth, config.From, to, msg, config.Host)
+	}
+
+	return smtp.SendMail(addr, auth, config.From, []string{to}, msg)
+}
+
+// buildMIMEMessage 构建 multipart/alternative 邮件：纯文本在前、HTML 在后（客户端优先显示最后一个可渲染的版本）
+func buildMIMEMessage(config *SMTPConfig, to string, email *RenderedEmail) ([]byte, error) {
+	from := config.From
+	if config.FromName != "" {
+		from = fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("UTF-8", config.FromName), config.From)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", email.Text},
+		{"text/html; charset=UTF-8", email.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
 	}
 
-	return smtp.SendMail(addr, auth, config.From, []string{to}, []byte(msg))
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/alternative; boundary=%q\r\n\r\n",
+		from, to, mime.QEncoding.Encode("UTF-8", email.Subject), time.Now().Format(time.RFC1123Z), mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
 }
 
 // sendMailTLS 使用TLS发送邮件
@@ -258,12 +326,9 @@ func (s *EmailService) SendVerifyCode(ctx context.Context, email, siteName strin
 		return fmt.Errorf("save verify code: %w", err)
 	}
 
-	// 构建邮件内容
-	subject := fmt.Sprintf("[%s] Email Verification Code", siteName)
-	body := s.buildVerifyCodeEmailBody(code, siteName)
-
-	// 发送邮件
-	if err := s.SendEmail(ctx, email, subject, body); err != nil {
+	// 按收件人语言渲染模板并发送
+	mailData := VerifyCodeEmailData{SiteName: siteName, Code: code, ExpireMinutes: int(verifyCodeTTL / time.Minute)}
+	if err := s.SendTemplateEmail(ctx, email, EmailTemplateVerifyCode, "", mailData); err != nil {
 		return fmt.Errorf("send email: %w", err)
 	}
 
@@ -301,46 +366,6 @@ func (s *EmailService) VerifyCode(ctx context.Context, email, code string) error
 	return nil
 }
 
-// buildVerifyCodeEmailBody 构建验证码邮件HTML内容
-func (s *EmailService) buildVerifyCodeEmailBody(code, siteName string) string {
-	return fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif; background-color: #f5f5f5; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background-color: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 8px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); color: white; padding: 30px; text-align: center; }
-        .header h1 { margin: 0; font-size: 24px; }
-        .content { padding: 40px 30px; text-align: center; }
-        .code { font-size: 36px; font-weight: bold; letter-spacing: 8px; color: #333; background-color: #f8f9fa; padding: 20px 30px; border-radius: 8px; display: inline-block; margin: 20px 0; font-family: monospace; }
-        .info { color: #666; font-size: 14px; line-height: 1.6; margin-top: 20px; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #999; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>%s</h1>
-        </div>
-        <div class="content">
-            <p style="font-size: 18px; color: #333;">Your verification code is:</p>
-            <div class="code">%s</div>
-            <div class="info">
-                <p>This code will expire in <strong>15 minutes</strong>.</p>
-                <p>If you did not request this code, please ignore this email.</p>
-            </div>
-        </div>
-        <div class="footer">
-            <p>This is an automated message, please do not reply.</p>
-        </div>
-    </div>
-</body>
-</html>
-`, siteName, code)
-}
-
 // TestSMTPConnectionWithConfig 使用指定配置测试SMTP连接
 func (s *EmailService) TestSMTPConnectionWithConfig(config *SMTPConfig) error {
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
@@ -429,12 +454,9 @@ func (s *EmailService) SendPasswordResetEmail(ctx context.Context, email, siteNa
 	// Build full reset URL with URL-encoded token and email
 	fullResetURL := fmt.Sprintf("%s?email=%s&token=%s", resetURL, url.QueryEscape(email), url.QueryEscape(token))
 
-	// Build email content
-	subject := fmt.Sprintf("[%s] 密码重置请求", siteName)
-	body := s.buildPasswordResetEmailBody(fullResetURL, siteName)
-
-	// Send email
-	if err := s.SendEmail(ctx, email, subject, body); err != nil {
+	// Render the template in the recipient's locale and send
+	mailData := PasswordResetEmailData{SiteName: siteName, ResetURL: fullResetURL, ExpireMinutes: int(passwordResetTokenTTL / time.Minute)}
+	if err := s.SendTemplateEmail(ctx, email, EmailTemplatePasswordReset, "", mailData); err != nil {
 		return fmt.Errorf("send email: %w", err)
 	}
 
@@ -491,51 +513,3 @@ func (s *EmailService) ConsumePasswordResetToken(ctx context.Context, email, tok
 	}
 	return nil
 }
-
-// buildPasswordResetEmailBody builds the HTML content for password reset email
-func (s *EmailService) buildPasswordResetEmailBody(resetURL, siteName string) string {
-	return fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif; background-color: #f5f5f5; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background-color: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 8px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); color: white; padding: 30px; text-align: center; }
-        .header h1 { margin: 0; font-size: 24px; }
-        .content { padding: 40px 30px; text-align: center; }
-        .button { display: inline-block; background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); color: white; padding: 14px 32px; text-decoration: none; border-radius: 8px; font-size: 16px; font-weight: 600; margin: 20px 0; }
-        .button:hover { opacity: 0.9; }
-        .info { color: #666; font-size: 14px; line-height: 1.6; margin-top: 20px; }
-        .link-fallback { color: #666; font-size: 12px; word-break: break-all; margin-top: 20px; padding: 15px; background-color: #f8f9fa; border-radius: 4px; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #999; font-size: 12px; }
-        .warning { color: #e74c3c; font-weight: 500; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>%s</h1>
-        </div>
-        <div class="content">
-            <p style="font-size: 18px; color: #333;">密码重置请求</p>
-            <p style="color: #666;">您已请求重置密码。请点击下方按钮设置新密码：</p>
-            <a href="%s" class="button">重置密码</a>
-            <div class="info">
-                <p>此链接将在 <strong>30 分钟</strong>后失效。</p>
-                <p class="warning">如果您没有请求重置密码，请忽略此邮件。您的密码将保持不变。</p>
-            </div>
-            <div class="link-fallback">
-                <p>如果按钮无法点击，请复制以下链接到浏览器中打开：</p>
-                <p>%s</p>
-            </div>
-        </div>
-        <div class="footer">
-            <p>这是一封自动发送的邮件，请勿回复。</p>
-        </div>
-    </div>
-</body>
-</html>
-`, siteName, resetURL, resetURL)
-}
@@ -0,0 +1,695 @@
+package service
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 邮件模板类型
+const (
+	EmailTemplateVerifyCode             = "verify_code"
+	EmailTemplatePasswordReset          = "password_reset"
+	EmailTemplateOpsAlert               = "ops_alert"
+	EmailTemplateOpsReportSummary       = "ops_report_summary"
+	EmailTemplateOpsReportErrorDigest   = "ops_report_error_digest"
+	EmailTemplateOpsReportAccountHealth = "ops_report_account_health"
+	EmailTemplateUserNotification       = "user_notification"
+)
+
+// 内置模板提供的语言；其他语言可由管理员自行添加覆盖
+const (
+	EmailLocaleEN = "en"
+	EmailLocaleZH = "zh"
+)
+
+// EmailTemplate 邮件模板（管理员覆盖版本，或合并内置模板后的生效版本）
+type EmailTemplate struct {
+	Key        string     `json:"key"`
+	Locale     string     `json:"locale"`
+	Subject    string     `json:"subject"`
+	HTMLBody   string     `json:"html_body"`
+	TextBody   string     `json:"text_body"`
+	Customized bool       `json:"customized"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// EmailTemplateInfo 模板类型概览（供管理端列表展示）
+type EmailTemplateInfo struct {
+	Key            string   `json:"key"`
+	Description    string   `json:"description"`
+	BuiltinLocales []string `json:"builtin_locales"`
+	CustomLocales  []string `json:"custom_locales"`
+	// SampleData 预览/测试发送使用的示例数据，其字段名即模板可用变量
+	SampleData any `json:"sample_data"`
+}
+
+// RenderedEmail 渲染后的邮件：HTML 与纯文本两个版本以 multipart/alternative 发送
+type RenderedEmail struct {
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// EmailTemplateRepository 邮件模板覆盖的持久层接口
+type EmailTemplateRepository interface {
+	List(ctx context.Context) ([]*EmailTemplate, error)
+	Upsert(ctx context.Context, tpl *EmailTemplate) error
+	Delete(ctx context.Context, key, locale string) error
+}
+
+// VerifyCodeEmailData 验证码邮件模板数据
+type VerifyCodeEmailData struct {
+	SiteName      string
+	Code          string
+	ExpireMinutes int
+}
+
+// PasswordResetEmailData 密码重置邮件模板数据
+type PasswordResetEmailData struct {
+	SiteName      string
+	ResetURL      string
+	ExpireMinutes int
+}
+
+// OpsAlertEmailData 运维告警邮件模板数据
+type OpsAlertEmailData struct {
+	RuleName    string
+	Severity    string
+	Status      string
+	Metric      string
+	Operator    string
+	Value       string
+	Threshold   string
+	FiredAt     string
+	Description string
+}
+
+// OpsReportSummaryEmailData 运维日报/周报模板数据；比率为百分数，已保留两位小数
+type OpsReportSummaryEmailData struct {
+	Title       string
+	PeriodStart string
+	PeriodEnd   string
+	HasData     bool
+
+	TotalRequests        int64
+	SuccessCount         int64
+	ErrorCountSLA        int64
+	BusinessLimitedCount int64
+	SLA                  string
+	ErrorRate            string
+	UpstreamErrorRate    string
+
+	UpstreamErrorsExcl429529 int64
+	Upstream429Count         int64
+	Upstream529Count         int64
+
+	LatencyP50 string
+	LatencyP99 string
+	TTFTP50    string
+	TTFTP99    string
+
+	TokenConsumed int64
+	QPSCurrent    string
+	QPSPeak       string
+	QPSAvg        string
+	TPSCurrent    string
+	TPSPeak       string
+	TPSAvg        string
+}
+
+// OpsReportErrorItem 错误摘要中的单条错误
+type OpsReportErrorItem struct {
+	Time       string
+	Platform   string
+	StatusCode int
+	Message    string
+}
+
+// OpsReportErrorDigestEmailData 错误摘要报告模板数据
+type OpsReportErrorDigestEmailData struct {
+	Title       string
+	PeriodStart string
+	PeriodEnd   string
+	TotalErrors int
+	Errors      []OpsReportErrorItem
+}
+
+// OpsReportAccountHealthEmailData 账号健康报告模板数据
+type OpsReportAccountHealthEmailData struct {
+	Title         string
+	PeriodStart   string
+	PeriodEnd     string
+	TotalAccounts int
+	Available     int
+	RateLimited   int
+	Errors        int
+}
+
+// UserNotificationEmailData 用户提醒邮件模板数据；Title/Message 为英文默认文案，
+// 自定义模板可按 EventType 与 Payload 自行组织多语言内容
+type UserNotificationEmailData struct {
+	SiteName  string
+	EventType string
+	Title     string
+	Message   string
+	Payload   map[string]any
+}
+
+type emailTemplateContent struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+type emailTemplateDefinition struct {
+	key         string
+	description string
+	sample      func() any
+	builtin     map[string]emailTemplateContent
+}
+
+var emailTemplateDefinitions = []*emailTemplateDefinition{
+	{
+		key:         EmailTemplateVerifyCode,
+		description: "Email verification code (registration, TOTP)",
+		sample: func() any {
+			return VerifyCodeEmailData{SiteName: "Sub2API", Code: "123456", ExpireMinutes: int(verifyCodeTTL / time.Minute)}
+		},
+		builtin: map[string]emailTemplateContent{
+			EmailLocaleEN: {
+				Subject: "[{{.SiteName}}] Email Verification Code",
+				HTML: styledEmailHTML(`
+            <p style="font-size: 18px; color: #333;">Your verification code is:</p>
+            <div class="code">{{.Code}}</div>
+            <div class="info">
+                <p>This code will expire in <strong>{{.ExpireMinutes}} minutes</strong>.</p>
+                <p>If you did not request this code, please ignore this email.</p>
+            </div>`, "This is an automated message, please do not reply."),
+				Text: `{{.SiteName}}
+
+Your verification code is: {{.Code}}
+
+This code will expire in {{.ExpireMinutes}} minutes.
+If you did not request this code, please ignore this email.
+
+This is an automated message, please do not reply.
+`,
+			},
+			EmailLocaleZH: {
+				Subject: "[{{.SiteName}}] 邮箱验证码",
+				HTML: styledEmailHTML(`
+            <p style="font-size: 18px; color: #333;">您的验证码是：</p>
+            <div class="code">{{.Code}}</div>
+            <div class="info">
+                <p>验证码将在 <strong>{{.ExpireMinutes}} 分钟</strong>后失效。</p>
+                <p>如果这不是您本人的操作，请忽略此邮件。</p>
+            </div>`, "这是一封自动发送的邮件，请勿回复。"),
+				Text: `{{.SiteName}}
+
+您的验证码是：{{.Code}}
+
+验证码将在 {{.ExpireMinutes}} 分钟后失效。
+如果这不是您本人的操作，请忽略此邮件。
+
+这是一封自动发送的邮件，请勿回复。
+`,
+			},
+		},
+	},
+	{
+		key:         EmailTemplatePasswordReset,
+		description: "Password reset link",
+		sample: func() any {
+			return PasswordResetEmailData{SiteName: "Sub2API", ResetURL: "https://example.com/reset-password?email=user%40example.com&token=sample", ExpireMinutes: int(passwordResetTokenTTL / time.Minute)}
+		},
+		builtin: map[string]emailTemplateContent{
+			EmailLocaleEN: {
+				Subject: "[{{.SiteName}}] Password Reset Request",
+				HTML: styledEmailHTML(`
+            <p style="font-size: 18px; color: #333;">Password Reset Request</p>
+            <p style="color: #666;">You requested a password reset. Click the button below to set a new password:</p>
+            <a href="{{.ResetURL}}" class="button">Reset Password</a>
+            <div class="info">
+                <p>This link will expire in <strong>{{.ExpireMinutes}} minutes</strong>.</p>
+                <p class="warning">If you did not request a password reset, please ignore this email. Your password will remain unchanged.</p>
+            </div>
+            <div class="link-fallback">
+                <p>If the button does not work, copy the link below into your browser:</p>
+                <p>{{.ResetURL}}</p>
+            </div>`, "This is an automated message, please do not reply."),
+				Text: `{{.SiteName}}
+
+Password Reset Request
+
+You requested a password reset. Open the link below to set a new password:
+{{.ResetURL}}
+
+This link will expire in {{.ExpireMinutes}} minutes.
+If you did not request a password reset, please ignore this email. Your password will remain unchanged.
+
+This is an automated message, please do not reply.
+`,
+			},
+			EmailLocaleZH: {
+				Subject: "[{{.SiteName}}] 密码重置请求",
+				HTML: styledEmailHTML(`
+            <p style="font-size: 18px; color: #333;">密码重置请求</p>
+            <p style="color: #666;">您已请求重置密码。请点击下方按钮设置新密码：</p>
+            <a href="{{.ResetURL}}" class="button">重置密码</a>
+            <div class="info">
+                <p>此链接将在 <strong>{{.ExpireMinutes}} 分钟</strong>后失效。</p>
+                <p class="warning">如果您没有请求重置密码，请忽略此邮件。您的密码将保持不变。</p>
+            </div>
+            <div class="link-fallback">
+                <p>如果按钮无法点击，请复制以下链接到浏览器中打开：</p>
+                <p>{{.ResetURL}}</p>
+            </div>`, "这是一封自动发送的邮件，请勿回复。"),
+				Text: `{{.SiteName}}
+
+密码重置请求
+
+您已请求重置密码。请打开以下链接设置新密码：
+{{.ResetURL}}
+
+此链接将在 {{.ExpireMinutes}} 分钟后失效。
+如果您没有请求重置密码，请忽略此邮件。您的密码将保持不变。
+
+这是一封自动发送的邮件，请勿回复。
+`,
+			},
+		},
+	},
+	{
+		key:         EmailTemplateOpsAlert,
+		description: "Ops alert fired by an alert rule",
+		sample: func() any {
+			return OpsAlertEmailData{
+				RuleName: "High error rate", Severity: "P1", Status: "firing", Metric: "error_rate", Operator: ">",
+				Value: "12.50", Threshold: "5.00", FiredAt: "2026-01-01T00:00:00Z", Description: "error rate above threshold",
+			}
+		},
+		builtin: map[string]emailTemplateContent{
+			EmailLocaleEN: {
+				Subject: "[Ops Alert][{{.Severity}}] {{.RuleName}}",
+				HTML: `
+<h2>Ops Alert</h2>
+<p><b>Rule</b>: {{.RuleName}}</p>
+<p><b>Severity</b>: {{.Severity}}</p>
+<p><b>Status</b>: {{.Status}}</p>
+<p><b>Metric</b>: {{.Metric}} {{.Operator}} {{.Value}} (threshold {{.Threshold}})</p>
+<p><b>Fired at</b>: {{.FiredAt}}</p>
+<p><b>Description</b>: {{.Description}}</p>
+`,
+				Text: `Ops Alert
+
+Rule: {{.RuleName}}
+Severity: {{.Severity}}
+Status: {{.Status}}
+Metric: {{.Metric}} {{.Operator}} {{.Value}} (threshold {{.Threshold}})
+Fired at: {{.FiredAt}}
+Description: {{.Description}}
+`,
+			},
+			EmailLocaleZH: {
+				Subject: "[运维告警][{{.Severity}}] {{.RuleName}}",
+				HTML: `
+<h2>运维告警</h2>
+<p><b>规则</b>：{{.RuleName}}</p>
+<p><b>级别</b>：{{.Severity}}</p>
+<p><b>状态</b>：{{.Status}}</p>
+<p><b>指标</b>：{{.Metric}} {{.Operator}} {{.Value}}（阈值 {{.Threshold}}）</p>
+<p><b>触发时间</b>：{{.FiredAt}}</p>
+<p><b>描述</b>：{{.Description}}</p>
+`,
+				Text: `运维告警
+
+规则：{{.RuleName}}
+级别：{{.Severity}}
+状态：{{.Status}}
+指标：{{.Metric}} {{.Operator}} {{.Value}}（阈值 {{.Threshold}}）
+触发时间：{{.FiredAt}}
+描述：{{.Description}}
+`,
+			},
+		},
+	},
+	{
+		key:         EmailTemplateOpsReportSummary,
+		description: "Scheduled ops report: daily/weekly summary",
+		sample: func() any {
+			return OpsReportSummaryEmailData{
+				Title: "Daily Summary", PeriodStart: "2026-01-01T00:00:00Z", PeriodEnd: "2026-01-02T00:00:00Z", HasData: true,
+				TotalRequests: 12000, SuccessCount: 11800, ErrorCountSLA: 150, BusinessLimitedCount: 50,
+				SLA: "98.75", ErrorRate: "1.25", UpstreamErrorRate: "0.80",
+				UpstreamErrorsExcl429529: 96, Upstream429Count: 20, Upstream529Count: 4,
+				LatencyP50: "820ms", LatencyP99: "9200ms", TTFTP50: "640ms", TTFTP99: "3100ms",
+				TokenConsumed: 35000000, QPSCurrent: "0.2", QPSPeak: "3.5", QPSAvg: "0.1", TPSCurrent: "410.0", TPSPeak: "9800.0", TPSAvg: "405.1",
+			}
+		},
+		builtin: map[string]emailTemplateContent{
+			EmailLocaleEN: {
+				Subject: "[Ops Report] {{.Title}}",
+				HTML: `
+<h2>{{.Title}}</h2>
+{{if .HasData}}<p><b>Period</b>: {{.PeriodStart}} ~ {{.PeriodEnd}} (UTC)</p>
+<ul>
+  <li><b>Total Requests</b>: {{.TotalRequests}}</li>
+  <li><b>Success</b>: {{.SuccessCount}}</li>
+  <li><b>Errors (SLA)</b>: {{.ErrorCountSLA}}</li>
+  <li><b>Business Limited</b>: {{.BusinessLimitedCount}}</li>
+  <li><b>SLA</b>: {{.SLA}}%</li>
+  <li><b>Error Rate</b>: {{.ErrorRate}}%</li>
+  <li><b>Upstream Error Rate (excl 429/529)</b>: {{.UpstreamErrorRate}}%</li>
+  <li><b>Upstream Errors</b>: excl429/529={{.UpstreamErrorsExcl429529}}, 429={{.Upstream429Count}}, 529={{.Upstream529Count}}</li>
+  <li><b>Latency</b>: p50={{.LatencyP50}}, p99={{.LatencyP99}}</li>
+  <li><b>TTFT</b>: p50={{.TTFTP50}}, p99={{.TTFTP99}}</li>
+  <li><b>Tokens</b>: {{.TokenConsumed}}</li>
+  <li><b>QPS</b>: current={{.QPSCurrent}}, peak={{.QPSPeak}}, avg={{.QPSAvg}}</li>
+  <li><b>TPS</b>: current={{.TPSCurrent}}, peak={{.TPSPeak}}, avg={{.TPSAvg}}</li>
+</ul>{{else}}<p>No data.</p>{{end}}
+`,
+				Text: `{{.Title}}
+{{if .HasData}}Period: {{.PeriodStart}} ~ {{.PeriodEnd}} (UTC)
+
+- Total Requests: {{.TotalRequests}}
+- Success: {{.SuccessCount}}
+- Errors (SLA): {{.ErrorCountSLA}}
+- Business Limited: {{.BusinessLimitedCount}}
+- SLA: {{.SLA}}%
+- Error Rate: {{.ErrorRate}}%
+- Upstream Error Rate (excl 429/529): {{.UpstreamErrorRate}}%
+- Upstream Errors: excl429/529={{.UpstreamErrorsExcl429529}}, 429={{.Upstream429Count}}, 529={{.Upstream529Count}}
+- Latency: p50={{.LatencyP50}}, p99={{.LatencyP99}}
+- TTFT: p50={{.TTFTP50}}, p99={{.TTFTP99}}
+- Tokens: {{.TokenConsumed}}
+- QPS: current={{.QPSCurrent}}, peak={{.QPSPeak}}, avg={{.QPSAvg}}
+- TPS: current={{.TPSCurrent}}, peak={{.TPSPeak}}, avg={{.TPSAvg}}
+{{else}}No data.
+{{end}}`,
+			},
+			EmailLocaleZH: {
+				Subject: "[运维报告] {{.Title}}",
+				HTML: `
+<h2>{{.Title}}</h2>
+{{if .HasData}}<p><b>统计区间</b>：{{.PeriodStart}} ~ {{.PeriodEnd}}（UTC）</p>
+<ul>
+  <li><b>请求总数</b>：{{.TotalRequests}}</li>
+  <li><b>成功</b>：{{.SuccessCount}}</li>
+  <li><b>错误（SLA）</b>：{{.ErrorCountSLA}}</li>
+  <li><b>业务限制</b>：{{.BusinessLimitedCount}}</li>
+  <li><b>SLA</b>：{{.SLA}}%</li>
+  <li><b>错误率</b>：{{.ErrorRate}}%</li>
+  <li><b>上游错误率（不含 429/529）</b>：{{.UpstreamErrorRate}}%</li>
+  <li><b>上游错误</b>：不含429/529={{.UpstreamErrorsExcl429529}}，429={{.Upstream429Count}}，529={{.Upstream529Count}}</li>
+  <li><b>延迟</b>：p50={{.LatencyP50}}，p99={{.LatencyP99}}</li>
+  <li><b>首字延迟</b>：p50={{.TTFTP50}}，p99={{.TTFTP99}}</li>
+  <li><b>Token 消耗</b>：{{.TokenConsumed}}</li>
+  <li><b>QPS</b>：当前={{.QPSCurrent}}，峰值={{.QPSPeak}}，平均={{.QPSAvg}}</li>
+  <li><b>TPS</b>：当前={{.TPSCurrent}}，峰值={{.TPSPeak}}，平均={{.TPSAvg}}</li>
+</ul>{{else}}<p>暂无数据。</p>{{end}}
+`,
+				Text: `{{.Title}}
+{{if .HasData}}统计区间：{{.PeriodStart}} ~ {{.PeriodEnd}}（UTC）
+
+- 请求总数：{{.TotalRequests}}
+- 成功：{{.SuccessCount}}
+- 错误（SLA）：{{.ErrorCountSLA}}
+- 业务限制：{{.BusinessLimitedCount}}
+- SLA：{{.SLA}}%
+- 错误率：{{.ErrorRate}}%
+- 上游错误率（不含 429/529）：{{.UpstreamErrorRate}}%
+- 上游错误：不含429/529={{.UpstreamErrorsExcl429529}}，429={{.Upstream429Count}}，529={{.Upstream529Count}}
+- 延迟：p50={{.LatencyP50}}，p99={{.LatencyP99}}
+- 首字延迟：p50={{.TTFTP50}}，p99={{.TTFTP99}}
+- Token 消耗：{{.TokenConsumed}}
+- QPS：当前={{.QPSCurrent}}，峰值={{.QPSPeak}}，平均={{.QPSAvg}}
+- TPS：当前={{.TPSCurrent}}，峰值={{.TPSPeak}}，平均={{.TPSAvg}}
+{{else}}暂无数据。
+{{end}}`,
+			},
+		},
+	},
+	{
+		key:         EmailTemplateOpsReportErrorDigest,
+		description: "Scheduled ops report: error digest",
+		sample: func() any {
+			return OpsReportErrorDigestEmailData{
+				Title: "Error Digest", PeriodStart: "2026-01-01T00:00:00Z", PeriodEnd: "2026-01-02T00:00:00Z", TotalErrors: 2,
+				Errors: []OpsReportErrorItem{
+					{Time: "2026-01-01T08:00:00Z", Platform: "anthropic", StatusCode: 529, Message: "Overloaded"},
+					{Time: "2026-01-01T09:30:00Z", Platform: "openai", StatusCode: 429, Message: "Rate limit reached"},
+				},
+			}
+		},
+		builtin: map[string]emailTemplateContent{
+			EmailLocaleEN: {
+				Subject: "[Ops Report] {{.Title}}",
+				HTML: `
+<h2>{{.Title}}</h2>
+<p><b>Period</b>: {{.PeriodStart}} ~ {{.PeriodEnd}} (UTC)</p>
+<p><b>Total Errors</b>: {{.TotalErrors}}</p>
+<h3>Recent</h3>
+<table border="1" cellpadding="6" cellspacing="0" style="border-collapse:collapse;">
+  <thead><tr><th>Time</th><th>Platform</th><th>Status</th><th>Message</th></tr></thead>
+  <tbody>{{range .Errors}}<tr><td>{{.Time}}</td><td>{{.Platform}}</td><td>{{.StatusCode}}</td><td>{{.Message}}</td></tr>{{else}}<tr><td colspan="4">No recent errors.</td></tr>{{end}}</tbody>
+</table>
+`,
+				Text: `{{.Title}}
+Period: {{.PeriodStart}} ~ {{.PeriodEnd}} (UTC)
+Total Errors: {{.TotalErrors}}
+
+Recent:
+{{range .Errors}}- {{.Time}} [{{.Platform}}] {{.StatusCode}} {{.Message}}
+{{else}}No recent errors.
+{{end}}`,
+			},
+			EmailLocaleZH: {
+				Subject: "[运维报告] {{.Title}}",
+				HTML: `
+<h2>{{.Title}}</h2>
+<p><b>统计区间</b>：{{.PeriodStart}} ~ {{.PeriodEnd}}（UTC）</p>
+<p><b>错误总数</b>：{{.TotalErrors}}</p>
+<h3>最近错误</h3>
+<table border="1" cellpadding="6" cellspacing="0" style="border-collapse:collapse;">
+  <thead><tr><th>时间</th><th>平台</th><th>状态码</th><th>信息</th></tr></thead>
+  <tbody>{{range .Errors}}<tr><td>{{.Time}}</td><td>{{.Platform}}</td><td>{{.StatusCode}}</td><td>{{.Message}}</td></tr>{{else}}<tr><td colspan="4">暂无错误。</td></tr>{{end}}</tbody>
+</table>
+`,
+				Text: `{{.Title}}
+统计区间：{{.PeriodStart}} ~ {{.PeriodEnd}}（UTC）
+错误总数：{{.TotalErrors}}
+
+最近错误：
+{{range .Errors}}- {{.Time}} [{{.Platform}}] {{.StatusCode}} {{.Message}}
+{{else}}暂无错误。
+{{end}}`,
+			},
+		},
+	},
+	{
+		key:         EmailTemplateOpsReportAccountHealth,
+		description: "Scheduled ops report: account health",
+		sample: func() any {
+			return OpsReportAccountHealthEmailData{
+				Title: "Account Health", PeriodStart: "2026-01-01T00:00:00Z", PeriodEnd: "2026-01-02T00:00:00Z",
+				TotalAccounts: 20, Available: 17, RateLimited: 2, Errors: 1,
+			}
+		},
+		builtin: map[string]emailTemplateContent{
+			EmailLocaleEN: {
+				Subject: "[Ops Report] {{.Title}}",
+				HTML: `
+<h2>{{.Title}}</h2>
+<p><b>Period</b>: {{.PeriodStart}} ~ {{.PeriodEnd}} (UTC)</p>
+<ul>
+  <li><b>Total Accounts</b>: {{.TotalAccounts}}</li>
+  <li><b>Available</b>: {{.Available}}</li>
+  <li><b>Rate Limited</b>: {{.RateLimited}}</li>
+  <li><b>Error</b>: {{.Errors}}</li>
+</ul>
+<p>Note: This report currently reflects account availability status only.</p>
+`,
+				Text: `{{.Title}}
+Period: {{.PeriodStart}} ~ {{.PeriodEnd}} (UTC)
+
+- Total Accounts: {{.TotalAccounts}}
+- Available: {{.Available}}
+- Rate Limited: {{.RateLimited}}
+- Error: {{.Errors}}
+
+Note: This report currently reflects account availability status only.
+`,
+			},
+			EmailLocaleZH: {
+				Subject: "[运维报告] {{.Title}}",
+				HTML: `
+<h2>{{.Title}}</h2>
+<p><b>统计区间</b>：{{.PeriodStart}} ~ {{.PeriodEnd}}（UTC）</p>
+<ul>
+  <li><b>账号总数</b>：{{.TotalAccounts}}</li>
+  <li><b>可用</b>：{{.Available}}</li>
+  <li><b>限流中</b>：{{.RateLimited}}</li>
+  <li><b>异常</b>：{{.Errors}}</li>
+</ul>
+<p>说明：本报告目前仅反映账号可用状态。</p>
+`,
+				Text: `{{.Title}}
+统计区间：{{.PeriodStart}} ~ {{.PeriodEnd}}（UTC）
+
+- 账号总数：{{.TotalAccounts}}
+- 可用：{{.Available}}
+- 限流中：{{.RateLimited}}
+- 异常：{{.Errors}}
+
+说明：本报告目前仅反映账号可用状态。
+`,
+			},
+		},
+	},
+	{
+		key:         EmailTemplateUserNotification,
+		description: "End-user notification (low balance, quota, expiry, renewal)",
+		sample: func() any {
+			return UserNotificationEmailData{
+				SiteName: "Sub2API", EventType: UserNotificationBalanceLow, Title: "Low balance",
+				Message: "Your balance is $1.5, below your alert threshold of $5. Please top up to avoid service interruption.",
+				Payload: map[string]any{"balance": 1.5, "threshold": 5},
+			}
+		},
+		builtin: map[string]emailTemplateContent{
+			EmailLocaleEN: {
+				Subject: "[{{.SiteName}}] {{.Title}}",
+				HTML: styledEmailHTML(`
+            <p><strong>{{.Title}}</strong></p>
+            <p>{{.Message}}</p>`, "You receive this email because notifications are enabled in your account preferences."),
+				Text: `{{.SiteName}}
+
+{{.Title}}
+
+{{.Message}}
+
+You receive this email because notifications are enabled in your account preferences.
+`,
+			},
+		},
+	},
+}
+
+// styledEmailHTML 内置用户邮件的统一外观：站点名标题 + 正文 + 页脚
+func styledEmailHTML(content, footer string) string {
+	return `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif; background-color: #f5f5f5; margin: 0; padding: 20px; }
+        .container { max-width: 600px; margin: 0 auto; background-color: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 8px rgba(0,0,0,0.1); }
+        .header { background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); color: white; padding: 30px; text-align: center; }
+        .header h1 { margin: 0; font-size: 24px; }
+        .content { padding: 40px 30px; text-align: center; color: #333; font-size: 15px; line-height: 1.6; }
+        .code { font-size: 36px; font-weight: bold; letter-spacing: 8px; color: #333; background-color: #f8f9fa; padding: 20px 30px; border-radius: 8px; display: inline-block; margin: 20px 0; font-family: monospace; }
+        .button { display: inline-block; background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); color: white; padding: 14px 32px; text-decoration: none; border-radius: 8px; font-size: 16px; font-weight: 600; margin: 20px 0; }
+        .info { color: #666; font-size: 14px; line-height: 1.6; margin-top: 20px; }
+        .link-fallback { color: #666; font-size: 12px; word-break: break-all; margin-top: 20px; padding: 15px; background-color: #f8f9fa; border-radius: 4px; }
+        .warning { color: #e74c3c; font-weight: 500; }
+        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #999; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{.SiteName}}</h1>
+        </div>
+        <div class="content">` + content + `
+        </div>
+        <div class="footer">
+            <p>` + footer + `</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+}
+
+func findEmailTemplateDefinition(key string) *emailTemplateDefinition {
+	for _, def := range emailTemplateDefinitions {
+		if def.key == key {
+			return def
+		}
+	}
+	return nil
+}
+
+var emailLocalePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// NormalizeEmailLocale 规范化语言标签（zh_CN -> zh-cn）；不合法时返回空字符串
+func NormalizeEmailLocale(locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if !emailLocalePattern.MatchString(locale) {
+		return ""
+	}
+	return locale
+}
+
+// emailLocaleCandidates 返回按优先级排列的候选语言（去重）
+func emailLocaleCandidates(locale, defaultLocale string) []string {
+	out := make([]string, 0, 5)
+	add := func(l string) {
+		if l == "" {
+			return
+		}
+		for _, v := range out {
+			if v == l {
+				return
+			}
+		}
+		out = append(out, l)
+	}
+	for _, l := range []string{NormalizeEmailLocale(locale), NormalizeEmailLocale(defaultLocale), EmailLocaleEN} {
+		add(l)
+		if base, _, ok := strings.Cut(l, "-"); ok {
+			add(base)
+		}
+	}
+	return out
+}
+
+var (
+	plainTextDropBlocks = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	plainTextLinks      = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*"([^"]*)"[^>]*>(.*?)</a>`)
+	plainTextBreaks     = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr|table|ul|ol)>`)
+	plainTextListItems  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	plainTextCells      = regexp.MustCompile(`(?i)</t[dh]>`)
+	plainTextTags       = regexp.MustCompile(`(?s)<[^>]*>`)
+	plainTextBlankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToPlainText 从 HTML 正文生成纯文本版本（用于未提供纯文本模板的邮件）
+func htmlToPlainText(s string) string {
+	s = plainTextDropBlocks.ReplaceAllString(s, "")
+	s = plainTextLinks.ReplaceAllStringFunc(s, func(m string) string {
+		sub := plainTextLinks.FindStringSubmatch(m)
+		text := strings.TrimSpace(plainTextTags.ReplaceAllString(sub[2], ""))
+		href := strings.TrimSpace(sub[1])
+		if text == "" || text == href {
+			return href
+		}
+		return text + " (" + href + ")"
+	})
+	s = plainTextBreaks.ReplaceAllString(s, "\n")
+	s = plainTextListItems.ReplaceAllString(s, "- ")
+	s = plainTextCells.ReplaceAllString(s, " ")
+	s = plainTextTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	s = plainTextBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	htmltemplate "html/template"
+	"strings"
+	"sync/atomic"
+	texttemplate "text/template"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+var (
+	ErrEmailTemplateNotFound = infraerrors.NotFound("EMAIL_TEMPLATE_NOT_FOUND", "email template not found")
+	ErrEmailTemplateInvalid  = infraerrors.BadRequest("EMAIL_TEMPLATE_INVALID", "invalid email template")
+	ErrEmailLocaleInvalid    = infraerrors.BadRequest("EMAIL_LOCALE_INVALID", "invalid locale, use a language tag such as en or zh-cn")
+)
+
+const (
+	emailTemplateCacheTTL = 30 * time.Second
+	emailTemplateMaxBytes = 256 << 10
+)
+
+type cachedEmailTemplates struct {
+	overrides     map[string]*EmailTemplate // key + "/" + locale
+	defaultLocale string
+	expiresAt     time.Time
+}
+
+func emailTemplateCacheKey(key, locale string) string {
+	return key + "/" + locale
+}
+
+// EmailTemplateService 邮件模板：内置模板 + 管理员覆盖，按收件人语言渲染 HTML 与纯文本两个版本。
+// 覆盖与站点默认语言进程内缓存 30s，修改后立即失效。
+type EmailTemplateService struct {
+	repo        EmailTemplateRepository
+	settingRepo SettingRepository
+	userRepo    UserRepository
+	prefRepo    UserNotificationRepository
+
+	cache atomic.Pointer[cachedEmailTemplates]
+}
+
+func NewEmailTemplateService(
+	repo EmailTemplateRepository,
+	settingRepo SettingRepository,
+	userRepo UserRepository,
+	prefRepo UserNotificationRepository,
+) *EmailTemplateService {
+	return &EmailTemplateService{
+		repo:        repo,
+		settingRepo: settingRepo,
+		userRepo:    userRepo,
+		prefRepo:    prefRepo,
+	}
+}
+
+// snapshot 返回当前的覆盖模板与默认语言；服务未初始化或加载失败时只使用内置模板
+func (s *EmailTemplateService) snapshot(ctx context.Context) *cachedEmailTemplates {
+	if s == nil {
+		return &cachedEmailTemplates{}
+	}
+	if cached := s.cache.Load(); cached != nil && time.Now().Before(cached.expiresAt) {
+		return cached
+	}
+
+	snap := &cachedEmailTemplates{
+		overrides: make(map[string]*EmailTemplate),
+		expiresAt: time.Now().Add(emailTemplateCacheTTL),
+	}
+	if s.settingRepo != nil {
+		if v, err := s.settingRepo.GetValue(ctx, SettingKeyEmailDefaultLocale); err == nil {
+			snap.defaultLocale = NormalizeEmailLocale(v)
+		} else if !errors.Is(err, ErrSettingNotFound) {
+			logger.LegacyPrintf("service.email_template", "[EmailTemplate] load default locale failed: %v", err)
+		}
+	}
+	if s.repo != nil {
+		list, err := s.repo.List(ctx)
+		if err != nil {
+			// 不缓存失败结果，下次发送时重试
+			logger.LegacyPrintf("service.email_template", "[EmailTemplate] load templates failed, using built-in templates: %v", err)
+			return snap
+		}
+		for _, tpl := range list {
+			snap.overrides[emailTemplateCacheKey(tpl.Key, tpl.Locale)] = tpl
+		}
+	}
+	s.cache.Store(snap)
+	return snap
+}
+
+func (s *EmailTemplateService) invalidate() {
+	if s != nil {
+		s.cache.Store(nil)
+	}
+}
+
+// ResolveLocale 根据收件人邮箱查找已注册用户的语言偏好；未注册或未设置时返回空字符串
+func (s *EmailTemplateService) ResolveLocale(ctx context.Context, email string) string {
+	if s == nil || s.userRepo == nil || s.prefRepo == nil || strings.TrimSpace(email) == "" {
+		return ""
+	}
+	user, err := s.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil || user == nil {
+		return ""
+	}
+	pref, err := s.prefRepo.GetPreference(ctx, user.ID)
+	if err != nil || pref == nil {
+		return ""
+	}
+	return pref.Locale
+}
+
+// Render 按语言回退顺序选取模板（每一级先覆盖、后内置）并渲染
+func (s *EmailTemplateService) Render(ctx context.Context, key, locale string, data any) (*RenderedEmail, error) {
+	def := findEmailTemplateDefinition(key)
+	if def == nil {
+		return nil, ErrEmailTemplateNotFound
+	}
+	snap := s.snapshot(ctx)
+	resolvedLocale, content := resolveEmailTemplate(def, snap, locale)
+	rendered, err := renderEmailTemplate(key, content, data)
+	if err != nil {
+		return nil, err
+	}
+	rendered.Locale = resolvedLocale
+	return rendered, nil
+}
+
+func resolveEmailTemplate(def *emailTemplateDefinition, snap *cachedEmailTemplates, locale string) (string, emailTemplateContent) {
+	for _, candidate := range emailLocaleCandidates(locale, snap.defaultLocale) {
+		if tpl, ok := snap.overrides[emailTemplateCacheKey(def.key, candidate)]; ok {
+			return candidate, emailTemplateContent{Subject: tpl.Subject, HTML: tpl.HTMLBody, Text: tpl.TextBody}
+		}
+		if content, ok := def.builtin[candidate]; ok {
+			return candidate, content
+		}
+	}
+	// 每个模板都内置了英文版本，正常情况下不会走到这里
+	return EmailLocaleEN, def.builtin[EmailLocaleEN]
+}
+
+func renderEmailTemplate(key string, content emailTemplateContent, data any) (*RenderedEmail, error) {
+	subjectTpl, err := texttemplate.New(key + ".subject").Parse(content.Subject)
+	if err != nil {
+		return nil, err
+	}
+	htmlTpl, err := htmltemplate.New(key + ".html").Parse(content.HTML)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, htmlBody bytes.Buffer
+	if err := subjectTpl.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := htmlTpl.Execute(&htmlBody, data); err != nil {
+		return nil, err
+	}
+
+	out := &RenderedEmail{
+		// 主题不允许换行，避免邮件头注入
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		HTML:    htmlBody.String(),
+	}
+	if strings.TrimSpace(content.Text) == "" {
+		out.Text = htmlToPlainText(out.HTML)
+		return out, nil
+	}
+	textTpl, err := texttemplate.New(key + ".text").Parse(content.Text)
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	if err := textTpl.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	out.Text = text.String()
+	return out, nil
+}
+
+// validateEmailTemplate 解析模板并用示例数据试渲染，提前发现语法错误与不存在的变量
+func validateEmailTemplate(def *emailTemplateDefinition, content emailTemplateContent) error {
+	if strings.TrimSpace(content.Subject) == "" || strings.TrimSpace(content.HTML) == "" {
+		return infraerrors.BadRequest(ErrEmailTemplateInvalid.Reason, "subject and html_body are required")
+	}
+	if len(content.Subject)+len(content.HTML)+len(content.Text) > emailTemplateMaxBytes {
+		return infraerrors.BadRequest(ErrEmailTemplateInvalid.Reason, "email template is too large")
+	}
+	if _, err := renderEmailTemplate(def.key, content, def.sample()); err != nil {
+		return infraerrors.BadRequest(ErrEmailTemplateInvalid.Reason, "invalid email template: "+err.Error())
+	}
+	return nil
+}
+
+// List 列出全部模板类型及其内置/已覆盖的语言
+func (s *EmailTemplateService) List(ctx context.Context) ([]EmailTemplateInfo, error) {
+	custom := make(map[string][]string)
+	if s.repo != nil {
+		list, err := s.repo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, tpl := range list {
+			custom[tpl.Key] = append(custom[tpl.Key], tpl.Locale)
+		}
+	}
+	out := make([]EmailTemplateInfo, 0, len(emailTemplateDefinitions))
+	for _, def := range emailTemplateDefinitions {
+		builtin := make([]string, 0, len(def.builtin))
+		for _, l := range []string{EmailLocaleEN, EmailLocaleZH} {
+			if _, ok := def.builtin[l]; ok {
+				builtin = append(builtin, l)
+			}
+		}
+		customLocales := custom[def.key]
+		if customLocales == nil {
+			customLocales = []string{}
+		}
+		out = append(out, EmailTemplateInfo{
+			Key:            def.key,
+			Description:    def.description,
+			BuiltinLocales: builtin,
+			CustomLocales:  customLocales,
+			SampleData:     def.sample(),
+		})
+	}
+	return out, nil
+}
+
+// Get 返回指定语言的生效模板；没有覆盖时返回回退链上的内置模板（Customized=false），便于以此为底稿编辑
+func (s *EmailTemplateService) Get(ctx context.Context, key, locale string) (*EmailTemplate, error) {
+	def := findEmailTemplateDefinition(key)
+	if def == nil {
+		return nil, ErrEmailTemplateNotFound
+	}
+	normalized := NormalizeEmailLocale(locale)
+	if normalized == "" {
+		return nil, ErrEmailLocaleInvalid
+	}
+	if s.repo != nil {
+		list, err := s.repo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, tpl := range list {
+			if tpl.Key == key && tpl.Locale == normalized {
+				out := *tpl
+				out.Customized = true
+				return &out, nil
+			}
+		}
+	}
+	// 底稿只取内置模板，不引用其他语言的覆盖
+	_, content := resolveEmailTemplate(def, &cachedEmailTemplates{}, normalized)
+	return &EmailTemplate{
+		Key:      key,
+		Locale:   normalized,
+		Subject:  content.Subject,
+		HTMLBody: content.HTML,
+		TextBody: content.Text,
+	}, nil
+}
+
+// Save 校验并保存模板覆盖
+func (s *EmailTemplateService) Save(ctx context.Context, tpl *EmailTemplate) (*EmailTemplate, error) {
+	def := findEmailTemplateDefinition(tpl.Key)
+	if def == nil {
+		return nil, ErrEmailTemplateNotFound
+	}
+	tpl.Locale = NormalizeEmailLocale(tpl.Locale)
+	if tpl.Locale == "" {
+		return nil, ErrEmailLocaleInvalid
+	}
+	if err := validateEmailTemplate(def, emailTemplateContent{Subject: tpl.Subject, HTML: tpl.HTMLBody, Text: tpl.TextBody}); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Upsert(ctx, tpl); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	tpl.Customized = true
+	return tpl, nil
+}
+
+// Reset 删除模板覆盖，恢复内置模板
+func (s *EmailTemplateService) Reset(ctx context.Context, key, locale string) error {
+	if findEmailTemplateDefinition(key) == nil {
+		return ErrEmailTemplateNotFound
+	}
+	normalized := NormalizeEmailLocale(locale)
+	if normalized == "" {
+		return ErrEmailLocaleInvalid
+	}
+	if err := s.repo.Delete(ctx, key, normalized); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Preview 使用示例数据渲染模板；draft 非空时渲染草稿（不保存），否则渲染当前生效的模板
+func (s *EmailTemplateService) Preview(ctx context.Context, key, locale string, draft *EmailTemplate) (*RenderedEmail, error) {
+	def := findEmailTemplateDefinition(key)
+	if def == nil {
+		return nil, ErrEmailTemplateNotFound
+	}
+	if strings.TrimSpace(locale) != "" && NormalizeEmailLocale(locale) == "" {
+		return nil, ErrEmailLocaleInvalid
+	}
+	if draft == nil {
+		return s.Render(ctx, key, locale, def.sample())
+	}
+	content := emailTemplateContent{Subject: draft.Subject, HTML: draft.HTMLBody, Text: draft.TextBody}
+	if err := validateEmailTemplate(def, content); err != nil {
+		return nil, err
+	}
+	rendered, err := renderEmailTemplate(key, content, def.sample())
+	if err != nil {
+		return nil, err
+	}
+	rendered.Locale = NormalizeEmailLocale(locale)
+	return rendered, nil
+}
+
+// GetDefaultLocale 返回站点默认邮件语言（未设置时为 en）
+func (s *EmailTemplateService) GetDefaultLocale(ctx context.Context) string {
+	if locale := s.snapshot(ctx).defaultLocale; locale != "" {
+		return locale
+	}
+	return EmailLocaleEN
+}
+
+// SetDefaultLocale 设置站点默认邮件语言
+func (s *EmailTemplateService) SetDefaultLocale(ctx context.Context, locale string) error {
+	normalized := NormalizeEmailLocale(locale)
+	if normalized == "" {
+		return ErrEmailLocaleInvalid
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyEmailDefaultLocale, normalized); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
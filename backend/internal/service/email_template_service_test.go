@@ -0,0 +1,225 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type emailTemplateRepoStub struct {
+	templates map[string]*EmailTemplate
+}
+
+func (s *emailTemplateRepoStub) List(context.Context) ([]*EmailTemplate, error) {
+	out := make([]*EmailTemplate, 0, len(s.templates))
+	for _, tpl := range s.templates {
+		cp := *tpl
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (s *emailTemplateRepoStub) Upsert(_ context.Context, tpl *EmailTemplate) error {
+	cp := *tpl
+	s.templates[emailTemplateCacheKey(tpl.Key, tpl.Locale)] = &cp
+	return nil
+}
+
+func (s *emailTemplateRepoStub) Delete(_ context.Context, key, locale string) error {
+	if _, ok := s.templates[emailTemplateCacheKey(key, locale)]; !ok {
+		return ErrEmailTemplateNotFound
+	}
+	delete(s.templates, emailTemplateCacheKey(key, locale))
+	return nil
+}
+
+type emailTemplateUserRepoStub struct {
+	userRepoStub
+	byEmail map[string]*User
+}
+
+func (s *emailTemplateUserRepoStub) GetByEmail(_ context.Context, email string) (*User, error) {
+	if u, ok := s.byEmail[email]; ok {
+		return u, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+func newEmailTemplateServiceForTest() (*EmailTemplateService, *emailTemplateRepoStub, *mockSettingRepo) {
+	repo := &emailTemplateRepoStub{templates: map[string]*EmailTemplate{}}
+	settings := newMockSettingRepo()
+	users := &emailTemplateUserRepoStub{byEmail: map[string]*User{"zh@example.com": {ID: 7, Email: "zh@example.com"}}}
+	prefs := &userNotificationRepoStub{pref: &UserNotificationPreference{UserID: 7, Locale: "zh-cn"}}
+	return NewEmailTemplateService(repo, settings, users, prefs), repo, settings
+}
+
+func TestEmailTemplates_BuiltinsRenderWithSampleData(t *testing.T) {
+	for _, def := range emailTemplateDefinitions {
+		require.Contains(t, def.builtin, EmailLocaleEN, def.key)
+		for locale, content := range def.builtin {
+			msg, err := renderEmailTemplate(def.key, content, def.sample())
+			require.NoError(t, err, "%s/%s", def.key, locale)
+			require.NotEmpty(t, msg.Subject, "%s/%s", def.key, locale)
+			require.NotContains(t, msg.HTML, "<no value>", "%s/%s", def.key, locale)
+			require.NotContains(t, msg.Text, "<no value>", "%s/%s", def.key, locale)
+			require.NotEmpty(t, strings.TrimSpace(msg.Text), "%s/%s", def.key, locale)
+		}
+	}
+}
+
+func TestEmailTemplateService_LocaleFallback(t *testing.T) {
+	svc, _, settings := newEmailTemplateServiceForTest()
+	ctx := context.Background()
+	data := VerifyCodeEmailData{SiteName: "Site", Code: "654321", ExpireMinutes: 15}
+
+	msg, err := svc.Render(ctx, EmailTemplateVerifyCode, svc.ResolveLocale(ctx, "zh@example.com"), data)
+	require.NoError(t, err)
+	require.Equal(t, EmailLocaleZH, msg.Locale, "zh-cn falls back to its base language")
+	require.Equal(t, "[Site] 邮箱验证码", msg.Subject)
+	require.Contains(t, msg.Text, "654321")
+
+	msg, err = svc.Render(ctx, EmailTemplateVerifyCode, svc.ResolveLocale(ctx, "unknown@example.com"), data)
+	require.NoError(t, err)
+	require.Equal(t, EmailLocaleEN, msg.Locale)
+
+	require.NoError(t, svc.SetDefaultLocale(ctx, "ZH"))
+	require.Equal(t, "zh", settings.data[SettingKeyEmailDefaultLocale])
+	msg, err = svc.Render(ctx, EmailTemplateVerifyCode, "fr", data)
+	require.NoError(t, err)
+	require.Equal(t, EmailLocaleZH, msg.Locale, "unknown locale falls back to the site default")
+
+	require.ErrorIs(t, svc.SetDefaultLocale(ctx, "not a locale"), ErrEmailLocaleInvalid)
+	_, err = svc.Render(ctx, "missing", "en", data)
+	require.ErrorIs(t, err, ErrEmailTemplateNotFound)
+}
+
+func TestEmailTemplateService_OverrideLifecycle(t *testing.T) {
+	svc, repo, _ := newEmailTemplateServiceForTest()
+	ctx := context.Background()
+	data := PasswordResetEmailData{SiteName: "Site", ResetURL: "https://example.com/r?t=1", ExpireMinutes: 30}
+
+	draft, err := svc.Get(ctx, EmailTemplatePasswordReset, "fr")
+	require.NoError(t, err)
+	require.False(t, draft.Customized)
+	require.Equal(t, "fr", draft.Locale)
+	require.Equal(t, emailTemplateDefinitions[1].builtin[EmailLocaleEN].Subject, draft.Subject)
+
+	// 预热缓存，确认保存后立即失效
+	_, err = svc.Render(ctx, EmailTemplatePasswordReset, "fr", data)
+	require.NoError(t, err)
+
+	saved, err := svc.Save(ctx, &EmailTemplate{
+		Key:      EmailTemplatePasswordReset,
+		Locale:   "FR",
+		Subject:  "[{{.SiteName}}] Réinitialisation",
+		HTMLBody: `<p>Cliquez <a href="{{.ResetURL}}">ici</a> &amp; valable {{.ExpireMinutes}} min</p>`,
+	})
+	require.NoError(t, err)
+	require.True(t, saved.Customized)
+	require.Equal(t, "fr", saved.Locale)
+
+	msg, err := svc.Render(ctx, EmailTemplatePasswordReset, "fr", data)
+	require.NoError(t, err)
+	require.Equal(t, "fr", msg.Locale)
+	require.Equal(t, "[Site] Réinitialisation", msg.Subject)
+	require.Equal(t, "Cliquez ici (https://example.com/r?t=1) & valable 30 min\n", msg.Text, "plaintext is derived from HTML")
+
+	got, err := svc.Get(ctx, EmailTemplatePasswordReset, "fr")
+	require.NoError(t, err)
+	require.True(t, got.Customized)
+
+	infos, err := svc.List(ctx)
+	require.NoError(t, err)
+	for _, info := range infos {
+		if info.Key == EmailTemplatePasswordReset {
+			require.Equal(t, []string{"fr"}, info.CustomLocales)
+			require.Equal(t, []string{EmailLocaleEN, EmailLocaleZH}, info.BuiltinLocales)
+		}
+	}
+
+	require.NoError(t, svc.Reset(ctx, EmailTemplatePasswordReset, "fr"))
+	require.ErrorIs(t, svc.Reset(ctx, EmailTemplatePasswordReset, "fr"), ErrEmailTemplateNotFound)
+	require.Empty(t, repo.templates)
+	msg, err = svc.Render(ctx, EmailTemplatePasswordReset, "fr", data)
+	require.NoError(t, err)
+	require.Equal(t, EmailLocaleEN, msg.Locale)
+}
+
+func TestEmailTemplateService_ValidatesTemplates(t *testing.T) {
+	svc, repo, _ := newEmailTemplateServiceForTest()
+	ctx := context.Background()
+
+	_, err := svc.Save(ctx, &EmailTemplate{Key: EmailTemplateVerifyCode, Locale: "en", Subject: "x", HTMLBody: "{{.Nope}}"})
+	require.ErrorIs(t, err, ErrEmailTemplateInvalid)
+	_, err = svc.Save(ctx, &EmailTemplate{Key: EmailTemplateVerifyCode, Locale: "en", Subject: "x", HTMLBody: "{{if .Code}}"})
+	require.ErrorIs(t, err, ErrEmailTemplateInvalid)
+	_, err = svc.Save(ctx, &EmailTemplate{Key: EmailTemplateVerifyCode, Locale: "en", Subject: "", HTMLBody: "{{.Code}}"})
+	require.ErrorIs(t, err, ErrEmailTemplateInvalid)
+	_, err = svc.Save(ctx, &EmailTemplate{Key: EmailTemplateVerifyCode, Locale: "../en", Subject: "x", HTMLBody: "{{.Code}}"})
+	require.ErrorIs(t, err, ErrEmailLocaleInvalid)
+	require.Empty(t, repo.templates)
+
+	preview, err := svc.Preview(ctx, EmailTemplateOpsReportErrorDigest, "zh", nil)
+	require.NoError(t, err)
+	require.Contains(t, preview.HTML, "Overloaded")
+	require.Contains(t, preview.Text, "- 2026-01-01T08:00:00Z [anthropic] 529 Overloaded")
+
+	preview, err = svc.Preview(ctx, EmailTemplateVerifyCode, "en", &EmailTemplate{Subject: "Code\r\nBcc: x@example.com", HTMLBody: "<b>{{.Code}}</b>", TextBody: "code={{.Code}}"})
+	require.NoError(t, err)
+	require.Equal(t, "Code Bcc: x@example.com", preview.Subject)
+	require.Equal(t, "code=123456", preview.Text)
+}
+
+func TestBuildMIMEMessage_MultipartAlternative(t *testing.T) {
+	raw, err := buildMIMEMessage(&SMTPConfig{From: "noreply@example.com", FromName: "站点"}, "user@example.com", &RenderedEmail{
+		Subject: "[站点] 邮箱验证码",
+		HTML:    "<p>验证码 <b>123456</b></p>",
+		Text:    "验证码 123456\n",
+	})
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "[站点] 邮箱验证码", subject)
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	require.NoError(t, err)
+	require.Equal(t, "站点", from.Name)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var types, bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		b, err := io.ReadAll(part) // quoted-printable is decoded by the reader
+		require.NoError(t, err)
+		types = append(types, part.Header.Get("Content-Type"))
+		bodies = append(bodies, string(b))
+	}
+	require.Equal(t, []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}, types)
+	require.Equal(t, "验证码 123456\r\n", bodies[0], "line breaks are canonicalized to CRLF")
+	require.Equal(t, "<p>验证码 <b>123456</b></p>", bodies[1])
+}
+
+func TestHTMLToPlainText(t *testing.T) {
+	in := `<html><head><style>.x{color:red}</style></head><body><h2>Title</h2><ul><li>One</li><li>Two &lt;2&gt;</li></ul>
+<table><tr><td>a</td><td>b</td></tr></table><p>Visit <a href="https://example.com">https://example.com</a></p></body></html>`
+	require.Equal(t, "Title\n- One\n- Two <2>\n\na b\n\nVisit https://example.com\n", htmlToPlainText(in))
+}
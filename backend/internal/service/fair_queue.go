@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// 公平排队（Start-time Fair Queuing）
+//
+// 账号满载时，原实现由等待者各自按退避轮询抢占槽位，并发数高的用户可以挤占其他用户。
+// 公平排队在 Redis 中为每个账号维护一个有序队列（多副本共享）：
+// 1. 优先级档位高的等待者总是排在低档位之前
+// 2. 同一档位内按主体（用户或 API Key）的虚拟开始时间排序，权重越大推进越慢，实现加权轮转
+// 3. 只有排名落在当前空闲槽位数以内的等待者才会尝试获取槽位
+const (
+	// FairQueueMaxPriority 优先级档位上限（0-9）
+	FairQueueMaxPriority = 9
+	// FairQueueMaxWeight 权重上限
+	FairQueueMaxWeight = 100
+
+	// FairQueuePrincipalUser 按用户排队
+	FairQueuePrincipalUser = "user"
+	// FairQueuePrincipalAPIKey 按 API Key 排队
+	FairQueuePrincipalAPIKey = "api_key"
+
+	// fairQueueSnapshotLimit ops 实时视图每个账号读取的最大等待者数
+	fairQueueSnapshotLimit = 200
+)
+
+var (
+	ErrInvalidQueueWeight   = infraerrors.BadRequest("INVALID_QUEUE_WEIGHT", "queue weight must be between 0 and 100")
+	ErrInvalidQueuePriority = infraerrors.BadRequest("INVALID_QUEUE_PRIORITY", "queue priority must be between 0 and 9")
+)
+
+// validateFairQueueSettings 校验管理员配置的排队权重与优先级档位（0 表示继承/默认）
+func validateFairQueueSettings(weight, priority int) error {
+	if weight < 0 || weight > FairQueueMaxWeight {
+		return ErrInvalidQueueWeight
+	}
+	if priority < 0 || priority > FairQueueMaxPriority {
+		return ErrInvalidQueuePriority
+	}
+	return nil
+}
+
+// FairQueueTicket 一个等待者在账号公平队列中的凭证
+type FairQueueTicket struct {
+	AccountID int64
+	RequestID string
+	UserID    int64
+	// Principal 排队主体标识，如 "u:1" / "k:2"
+	Principal string
+	Weight    int
+	Priority  int
+}
+
+// FairQueuePrincipal 请求方的排队主体与配置
+type FairQueuePrincipal struct {
+	UserID   int64
+	APIKeyID int64
+	Weight   int
+	Priority int
+}
+
+// FairQueueWaiter ops 视图中的一个等待者
+type FairQueueWaiter struct {
+	AccountID int64
+	UserID    int64
+	Principal string
+	Position  int
+}
+
+// FairQueueAccountSnapshot 单个账号公平队列快照
+type FairQueueAccountSnapshot struct {
+	AccountID int64
+	Depth     int
+	Waiters   []FairQueueWaiter
+}
+
+// FairQueueCache 账号公平队列缓存接口
+// 键格式: fair_queue:{accountID}（有序集合，分数由优先级档位与虚拟开始时间组成）
+type FairQueueCache interface {
+	// Enqueue 加入队列，返回入队后的位置（从 1 开始）
+	Enqueue(ctx context.Context, ticket *FairQueueTicket) (int, error)
+	// Position 刷新心跳并返回当前位置（从 1 开始），0 表示已不在队列中（如心跳超时被清理）
+	Position(ctx context.Context, ticket *FairQueueTicket) (int, error)
+	// Leave 离开队列；admitted 为 true 时推进队列的虚拟时钟
+	Leave(ctx context.Context, ticket *FairQueueTicket, admitted bool) error
+	// GetSnapshot 读取所有活跃队列的快照（每个账号最多 maxPerAccount 个等待者）
+	GetSnapshot(ctx context.Context, maxPerAccount int) ([]FairQueueAccountSnapshot, error)
+}
+
+// ResolveFairQueuePrincipal 从 API Key 解析排队主体配置：用户配置优先，其次分组配置
+func ResolveFairQueuePrincipal(apiKey *APIKey) FairQueuePrincipal {
+	p := FairQueuePrincipal{Weight: 1}
+	if apiKey == nil {
+		return p
+	}
+	p.APIKeyID = apiKey.ID
+	p.UserID = apiKey.UserID
+
+	weight, priority := 0, 0
+	if apiKey.Group != nil {
+		weight = apiKey.Group.QueueWeight
+		priority = apiKey.Group.QueuePriority
+	}
+	if apiKey.User != nil {
+		if p.UserID == 0 {
+			p.UserID = apiKey.User.ID
+		}
+		if apiKey.User.QueueWeight > 0 {
+			weight = apiKey.User.QueueWeight
+		}
+		if apiKey.User.QueuePriority > 0 {
+			priority = apiKey.User.QueuePriority
+		}
+	}
+	p.Weight = normalizeFairQueueWeight(weight)
+	p.Priority = normalizeFairQueuePriority(priority)
+	return p
+}
+
+func normalizeFairQueueWeight(weight int) int {
+	if weight <= 0 {
+		return 1
+	}
+	if weight > FairQueueMaxWeight {
+		return FairQueueMaxWeight
+	}
+	return weight
+}
+
+func normalizeFairQueuePriority(priority int) int {
+	if priority < 0 {
+		return 0
+	}
+	if priority > FairQueueMaxPriority {
+		return FairQueueMaxPriority
+	}
+	return priority
+}
+
+// SetFairQueue 启用账号级公平排队；cache 为 nil 时保持原有的先到先得轮询
+func (s *ConcurrencyService) SetFairQueue(cache FairQueueCache, principalMode string) {
+	if s == nil {
+		return
+	}
+	s.fairQueue = cache
+	s.fairQueuePerAPIKey = principalMode == FairQueuePrincipalAPIKey
+}
+
+// FairQueueEnabled 返回是否启用了公平排队
+func (s *ConcurrencyService) FairQueueEnabled() bool {
+	return s != nil && s.fairQueue != nil
+}
+
+// EnterAccountQueue 在账号满载时加入公平队列。
+// 返回 nil 凭证表示未启用或 Redis 异常（fail open，调用方退回原有轮询方式）。
+func (s *ConcurrencyService) EnterAccountQueue(ctx context.Context, accountID int64, principal FairQueuePrincipal) (*FairQueueTicket, int) {
+	if !s.FairQueueEnabled() || accountID <= 0 {
+		return nil, 0
+	}
+	ticket := &FairQueueTicket{
+		AccountID: accountID,
+		RequestID: generateRequestID(),
+		UserID:    principal.UserID,
+		Principal: "u:" + strconv.FormatInt(principal.UserID, 10),
+		Weight:    normalizeFairQueueWeight(principal.Weight),
+		Priority:  normalizeFairQueuePriority(principal.Priority),
+	}
+	if s.fairQueuePerAPIKey && principal.APIKeyID > 0 {
+		ticket.Principal = "k:" + strconv.FormatInt(principal.APIKeyID, 10)
+	}
+	position, err := s.fairQueue.Enqueue(ctx, ticket)
+	if err != nil {
+		logger.LegacyPrintf("service.concurrency", "Warning: fair queue enqueue failed for account %d: %v", accountID, err)
+		return nil, 0
+	}
+	return ticket, position
+}
+
+// CheckAccountQueueAdmission 判断等待者是否轮到尝试获取槽位。
+// 排名落在空闲槽位数以内（至少队首）即可放行；返回当前位置供客户端展示。
+func (s *ConcurrencyService) CheckAccountQueueAdmission(ctx context.Context, ticket *FairQueueTicket, maxConcurrency int) (bool, int) {
+	if ticket == nil || !s.FairQueueEnabled() {
+		return true, 0
+	}
+	position, err := s.fairQueue.Position(ctx, ticket)
+	if err != nil {
+		logger.LegacyPrintf("service.concurrency", "Warning: fair queue position failed for account %d: %v", ticket.AccountID, err)
+		return true, 0
+	}
+	if position == 0 {
+		// 心跳超时被清理：重新入队，排到当前队尾
+		if position, err = s.fairQueue.Enqueue(ctx, ticket); err != nil {
+			return true, 0
+		}
+	}
+
+	free := 1
+	if s.cache != nil && maxConcurrency > 0 {
+		if current, err := s.cache.GetAccountConcurrency(ctx, ticket.AccountID); err == nil && maxConcurrency-current > free {
+			free = maxConcurrency - current
+		}
+	}
+	return position <= free, position
+}
+
+// LeaveAccountQueue 离开公平队列；admitted 表示是否因获取到槽位而离开
+func (s *ConcurrencyService) LeaveAccountQueue(ticket *FairQueueTicket, admitted bool) {
+	if ticket == nil || !s.FairQueueEnabled() {
+		return
+	}
+	// 使用独立 context，确保客户端断开时也能出队
+	bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.fairQueue.Leave(bgCtx, ticket, admitted); err != nil {
+		logger.LegacyPrintf("service.concurrency", "Warning: fair queue leave failed for account %d (req=%s): %v", ticket.AccountID, ticket.RequestID, err)
+	}
+}
+
+// GetFairQueueSnapshot 返回所有活跃公平队列的快照（ops 实时视图使用）
+func (s *ConcurrencyService) GetFairQueueSnapshot(ctx context.Context) ([]FairQueueAccountSnapshot, error) {
+	if !s.FairQueueEnabled() {
+		return nil, nil
+	}
+	return s.fairQueue.GetSnapshot(ctx, fairQueueSnapshotLimit)
+}
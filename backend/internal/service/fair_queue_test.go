@@ -0,0 +1,153 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stubFairQueueCache 按入队顺序模拟队列位置
+type stubFairQueueCache struct {
+	queue      []string
+	enqueueErr error
+	positionFn func(ticket *FairQueueTicket) int
+	left       map[string]bool
+}
+
+func (c *stubFairQueueCache) Enqueue(_ context.Context, ticket *FairQueueTicket) (int, error) {
+	if c.enqueueErr != nil {
+		return 0, c.enqueueErr
+	}
+	c.queue = append(c.queue, ticket.RequestID)
+	return len(c.queue), nil
+}
+
+func (c *stubFairQueueCache) Position(_ context.Context, ticket *FairQueueTicket) (int, error) {
+	if c.positionFn != nil {
+		return c.positionFn(ticket), nil
+	}
+	for i, id := range c.queue {
+		if id == ticket.RequestID {
+			return i + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (c *stubFairQueueCache) Leave(_ context.Context, ticket *FairQueueTicket, admitted bool) error {
+	if c.left == nil {
+		c.left = map[string]bool{}
+	}
+	c.left[ticket.RequestID] = admitted
+	return nil
+}
+
+func (c *stubFairQueueCache) GetSnapshot(_ context.Context, _ int) ([]FairQueueAccountSnapshot, error) {
+	return nil, nil
+}
+
+func TestResolveFairQueuePrincipal_UserOverridesGroup(t *testing.T) {
+	apiKey := &APIKey{
+		ID:     7,
+		UserID: 3,
+		User:   &User{ID: 3, QueueWeight: 5},
+		Group:  &Group{QueueWeight: 2, QueuePriority: 4},
+	}
+	p := ResolveFairQueuePrincipal(apiKey)
+	require.Equal(t, int64(3), p.UserID)
+	require.Equal(t, int64(7), p.APIKeyID)
+	require.Equal(t, 5, p.Weight)
+	require.Equal(t, 4, p.Priority, "用户未配置档位时继承分组")
+}
+
+func TestResolveFairQueuePrincipal_DefaultsAndClamp(t *testing.T) {
+	require.Equal(t, FairQueuePrincipal{Weight: 1}, ResolveFairQueuePrincipal(nil))
+
+	p := ResolveFairQueuePrincipal(&APIKey{
+		UserID: 1,
+		User:   &User{ID: 1, QueueWeight: 1000, QueuePriority: 42},
+	})
+	require.Equal(t, FairQueueMaxWeight, p.Weight)
+	require.Equal(t, FairQueueMaxPriority, p.Priority)
+}
+
+func TestEnterAccountQueue_PrincipalMode(t *testing.T) {
+	cache := &stubFairQueueCache{}
+	svc := NewConcurrencyService(nil)
+	svc.SetFairQueue(cache, FairQueuePrincipalAPIKey)
+
+	ticket, position := svc.EnterAccountQueue(context.Background(), 10, FairQueuePrincipal{UserID: 1, APIKeyID: 9, Weight: 2})
+	require.NotNil(t, ticket)
+	require.Equal(t, 1, position)
+	require.Equal(t, "k:9", ticket.Principal)
+
+	svc.SetFairQueue(cache, FairQueuePrincipalUser)
+	ticket, position = svc.EnterAccountQueue(context.Background(), 10, FairQueuePrincipal{UserID: 1, APIKeyID: 9})
+	require.NotNil(t, ticket)
+	require.Equal(t, 2, position)
+	require.Equal(t, "u:1", ticket.Principal)
+	require.Equal(t, 1, ticket.Weight)
+}
+
+func TestEnterAccountQueue_FailOpen(t *testing.T) {
+	svc := NewConcurrencyService(nil)
+	ticket, _ := svc.EnterAccountQueue(context.Background(), 10, FairQueuePrincipal{UserID: 1})
+	require.Nil(t, ticket, "未启用公平排队时不入队")
+
+	svc.SetFairQueue(&stubFairQueueCache{enqueueErr: errors.New("redis down")}, FairQueuePrincipalUser)
+	ticket, _ = svc.EnterAccountQueue(context.Background(), 10, FairQueuePrincipal{UserID: 1})
+	require.Nil(t, ticket)
+}
+
+func TestCheckAccountQueueAdmission_UsesFreeSlots(t *testing.T) {
+	cache := &stubFairQueueCache{}
+	svc := NewConcurrencyService(&stubConcurrencyCacheForTest{concurrency: 3})
+	svc.SetFairQueue(cache, FairQueuePrincipalUser)
+
+	ctx := context.Background()
+	var tickets []*FairQueueTicket
+	for i := 0; i < 3; i++ {
+		ticket, _ := svc.EnterAccountQueue(ctx, 10, FairQueuePrincipal{UserID: int64(i + 1)})
+		tickets = append(tickets, ticket)
+	}
+
+	// maxConcurrency=5, 已用 3：前两名可以尝试获取槽位
+	ok, pos := svc.CheckAccountQueueAdmission(ctx, tickets[1], 5)
+	require.True(t, ok)
+	require.Equal(t, 2, pos)
+	ok, pos = svc.CheckAccountQueueAdmission(ctx, tickets[2], 5)
+	require.False(t, ok)
+	require.Equal(t, 3, pos)
+
+	// 账号已满时仍允许队首尝试
+	ok, _ = svc.CheckAccountQueueAdmission(ctx, tickets[0], 3)
+	require.True(t, ok)
+	ok, _ = svc.CheckAccountQueueAdmission(ctx, tickets[1], 3)
+	require.False(t, ok)
+
+	svc.LeaveAccountQueue(tickets[0], true)
+	require.True(t, cache.left[tickets[0].RequestID])
+}
+
+func TestCheckAccountQueueAdmission_RequeuesExpiredTicket(t *testing.T) {
+	cache := &stubFairQueueCache{positionFn: func(*FairQueueTicket) int { return 0 }}
+	svc := NewConcurrencyService(nil)
+	svc.SetFairQueue(cache, FairQueuePrincipalUser)
+
+	ticket := &FairQueueTicket{AccountID: 1, RequestID: "r1", UserID: 1, Principal: "u:1", Weight: 1}
+	cache.queue = []string{"r0", "r9"}
+	ok, pos := svc.CheckAccountQueueAdmission(context.Background(), ticket, 1)
+	require.False(t, ok)
+	require.Equal(t, 3, pos)
+}
+
+func TestValidateFairQueueSettings(t *testing.T) {
+	require.NoError(t, validateFairQueueSettings(0, 0))
+	require.NoError(t, validateFairQueueSettings(FairQueueMaxWeight, FairQueueMaxPriority))
+	require.ErrorIs(t, validateFairQueueSettings(-1, 0), ErrInvalidQueueWeight)
+	require.ErrorIs(t, validateFairQueueSettings(0, 10), ErrInvalidQueuePriority)
+}
@@ -1931,6 +1931,14 @@ func (m *mockConcurrencyCache) GetAccountWaitingCount(ctx context.Context, accou
 	return 0, nil
 }
 
+func (m *mockConcurrencyCache) IncrementAccountBatchWaitCount(ctx context.Context, accountID int64, maxWait int) (bool, error) {
+	return true, nil
+}
+
+func (m *mockConcurrencyCache) DecrementAccountBatchWaitCount(ctx context.Context, accountID int64) error {
+	return nil
+}
+
 func (m *mockConcurrencyCache) AcquireUserSlot(ctx context.Context, userID int64, maxConcurrency int, requestID string) (bool, error) {
 	return true, nil
 }
@@ -1990,6 +1998,25 @@ func (m *mockConcurrencyCache) CleanupStaleProcessSlots(ctx context.Context, act
 	return nil
 }
 
+func (m *mockConcurrencyCache) AcquireAccountModelSlot(ctx context.Context, scope AccountModelScope, maxConcurrency int, requestID string) (bool, error) {
+	return true, nil
+}
+
+func (m *mockConcurrencyCache) ReleaseAccountModelSlot(ctx context.Context, scope AccountModelScope, requestID string) error {
+	return nil
+}
+
+func (m *mockConcurrencyCache) AcquireAccountModelRPM(ctx context.Context, scope AccountModelScope, maxRPM int, requestID string) (bool, error) {
+	return true, nil
+}
+func (m *mockConcurrencyCache) ReleaseAccountModelRPM(ctx context.Context, scope AccountModelScope, requestID string) error {
+	return nil
+}
+
+func (m *mockConcurrencyCache) GetAccountModelLoadBatch(ctx context.Context, scopes []AccountModelScope) (map[int64]*AccountModelLoadInfo, error) {
+	return map[int64]*AccountModelLoadInfo{}, nil
+}
+
 func (m *mockConcurrencyCache) GetUsersLoadBatch(ctx context.Context, users []UserWithConcurrency) (map[int64]*UserLoadInfo, error) {
 	result := make(map[int64]*UserLoadInfo, len(users))
 	for _, user := range users {
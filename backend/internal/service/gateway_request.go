@@ -69,6 +69,48 @@ type ParsedRequest struct {
 	OnUpstreamAccepted func()
 }
 
+// Clone 返回深拷贝：Body、System、Messages 与 SessionContext 均不与原请求共享，
+// 供并发转发（如对冲请求）各自独立修改。
+func (p *ParsedRequest) Clone() *ParsedRequest {
+	if p == nil {
+		return nil
+	}
+	cloned := *p
+	cloned.Body = bytes.Clone(p.Body)
+	cloned.System = deepCopyJSONValue(p.System)
+	if p.Messages != nil {
+		cloned.Messages = make([]any, len(p.Messages))
+		for i, msg := range p.Messages {
+			cloned.Messages[i] = deepCopyJSONValue(msg)
+		}
+	}
+	if p.SessionContext != nil {
+		sessionContext := *p.SessionContext
+		cloned.SessionContext = &sessionContext
+	}
+	return &cloned
+}
+
+// deepCopyJSONValue 深拷贝 json.Unmarshal 产生的 map/slice 结构，其余值按值返回
+func deepCopyJSONValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = deepCopyJSONValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = deepCopyJSONValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 // ParseGatewayRequest 解析网关请求体并返回结构化结果。
 // protocol 指定请求协议格式（domain.PlatformAnthropic / domain.PlatformGemini），
 // 不同协议使用不同的 system/messages 字段名。
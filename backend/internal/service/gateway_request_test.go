@@ -25,6 +25,30 @@ func TestParseGatewayRequest(t *testing.T) {
 	require.False(t, parsed.ThinkingEnabled)
 }
 
+func TestParsedRequestClone_DeepCopiesMutableFields(t *testing.T) {
+	body := []byte(`{"model":"claude-3-7-sonnet","system":[{"type":"text","text":"sys"}],"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+	parsed, err := ParseGatewayRequest(body, "")
+	require.NoError(t, err)
+	parsed.SessionContext = &SessionContext{ClientIP: "1.1.1.1"}
+
+	cloned := parsed.Clone()
+	cloned.Body[0] = ' '
+	cloned.System.([]any)[0].(map[string]any)["text"] = "changed"
+	cloned.Messages[0].(map[string]any)["content"].([]any)[0].(map[string]any)["text"] = "changed"
+	cloned.Messages = append(cloned.Messages, "extra")
+	cloned.SessionContext.ClientIP = "2.2.2.2"
+
+	require.Equal(t, byte('{'), parsed.Body[0])
+	require.Equal(t, "sys", parsed.System.([]any)[0].(map[string]any)["text"])
+	require.Equal(t, "hi", parsed.Messages[0].(map[string]any)["content"].([]any)[0].(map[string]any)["text"])
+	require.Len(t, parsed.Messages, 1)
+	require.Equal(t, "1.1.1.1", parsed.SessionContext.ClientIP)
+	require.Equal(t, parsed.Model, cloned.Model)
+
+	var nilParsed *ParsedRequest
+	require.Nil(t, nilParsed.Clone())
+}
+
 func TestParseGatewayRequest_ThinkingEnabled(t *testing.T) {
 	body := []byte(`{"model":"claude-sonnet-4-5","thinking":{"type":"enabled"},"messages":[{"content":"hi"}]}`)
 	parsed, err := ParseGatewayRequest(body, "")
@@ -51,6 +51,7 @@ type GeminiMessagesCompatService struct {
 	tokenProvider             *GeminiTokenProvider
 	rateLimitService          *RateLimitService
 	httpUpstream              HTTPUpstream
+	circuitBreaker            *UpstreamCircuitBreaker // 上游熔断（可选依赖）
 	antigravityGatewayService *AntigravityGatewayService
 	cfg                       *config.Config
 	responseHeaderFilter      *responseheaders.CompiledHeaderFilter
@@ -91,6 +92,20 @@ func (s *GeminiMessagesCompatService) SelectAccountForModel(ctx context.Context,
 }
 
 func (s *GeminiMessagesCompatService) SelectAccountForModelWithExclusions(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*Account, error) {
+	for {
+		account, err := s.selectAccountForModelWithExclusions(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+		if err != nil {
+			return nil, err
+		}
+		// 熔断半开的账号仅在最终选中时占用探测名额，名额已被其它请求占用则重新选择
+		if s.circuitBreaker.ClaimProbe(ctx, account) {
+			return account, nil
+		}
+		excludedIDs = excludeCircuitProbeAccount(excludedIDs, account.ID)
+	}
+}
+
+func (s *GeminiMessagesCompatService) selectAccountForModelWithExclusions(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*Account, error) {
 	// 1. 确定目标平台和调度模式
 	// Determine target platform and scheduling mode
 	platform, useMixedScheduling, hasForcePlatform, err := s.resolvePlatformAndSchedulingMode(ctx, groupID)
@@ -214,6 +229,9 @@ func (s *GeminiMessagesCompatService) tryStickySessionHit(
 	if !s.isAccountUsableForRequest(ctx, account, requestedModel, platform, useMixedScheduling) {
 		return nil
 	}
+	if !s.circuitBreaker.AllowAccount(ctx, account) {
+		return nil
+	}
 
 	// 刷新会话 TTL 并返回账号
 	// Refresh session TTL and return account
@@ -416,7 +434,16 @@ func (s *GeminiMessagesCompatService) getSchedulableAccount(ctx context.Context,
 	return s.accountRepo.GetByID(ctx, accountID)
 }
 
+// listSchedulableAccountsOnce 列出可调度账号，并过滤上游熔断中的账号
 func (s *GeminiMessagesCompatService) listSchedulableAccountsOnce(ctx context.Context, groupID *int64, platform string, hasForcePlatform bool) ([]Account, error) {
+	accounts, err := s.listSchedulableAccountsFromSource(ctx, groupID, platform, hasForcePlatform)
+	if err != nil {
+		return accounts, err
+	}
+	return s.circuitBreaker.FilterAccounts(ctx, accounts), nil
+}
+
+func (s *GeminiMessagesCompatService) listSchedulableAccountsFromSource(ctx context.Context, groupID *int64, platform string, hasForcePlatform bool) ([]Account, error) {
 	if s.schedulerSnapshot != nil {
 		accounts, _, err := s.schedulerSnapshot.ListSchedulableAccounts(ctx, groupID, platform, hasForcePlatform)
 		return accounts, err
@@ -715,6 +742,7 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 			return nil, s.writeClaudeError(c, http.StatusBadGateway, "upstream_error", err.Error())
 		}
 		requestIDHeader = idHeader
+		applyRequestHeaderTransforms(ctx, upstreamReq)
 
 		// Capture upstream request body for ops retry of this attempt.
 		if c != nil {
@@ -1214,6 +1242,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 			return nil, s.writeGoogleError(c, http.StatusBadGateway, err.Error())
 		}
 		requestIDHeader = idHeader
+		applyRequestHeaderTransforms(ctx, upstreamReq)
 
 		// Capture upstream request body for ops retry of this attempt.
 		if c != nil {
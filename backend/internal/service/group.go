@@ -47,6 +47,14 @@ type Group struct {
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool
 
+	// 模型降级链配置
+	// key: 模型匹配模式（支持 * 通配符）
+	// value: 有序降级目标，调度失败或上游过载时依次尝试
+	ModelFallbacks map[string][]ModelFallbackTarget
+
+	// 请求改写规则，转发前按顺序执行
+	RequestTransforms []RequestTransformRule
+
 	// MCP XML 协议注入开关（仅 antigravity 平台使用）
 	MCPXMLInject bool
 
@@ -61,6 +69,23 @@ type Group struct {
 	AllowMessagesDispatch bool
 	DefaultMappedModel    string
 
+	// 订阅售价（从余额扣除），nil 表示不可购买/续费
+	Price *float64
+	// 每次购买/续费延长的天数，0 表示使用 DefaultValidityDays
+	RenewalPeriodDays int
+
+	// 公平排队配置：账号满载排队时按权重加权轮转、按优先级档位分层放行
+	QueueWeight   int // 0 表示默认权重 1
+	QueuePriority int // 0-9，数值越大越优先
+
+	// 账号调度策略：空表示默认（优先级 → 负载率 → LRU），cost_aware 表示成本感知
+	SchedulingStrategy string
+
+	// 对冲请求配置（仅非流式请求生效）
+	HedgeEnabled      bool
+	HedgePercentile   int    // 触发对冲的延迟分位数（50-99）
+	HedgeLoserBilling string // ignore / bill
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -161,6 +186,32 @@ func (g *Group) GetRoutingAccountIDs(requestedModel string) []int64 {
 	return nil
 }
 
+// GetModelFallbackChain 根据请求模型获取降级链
+// 精确匹配优先，其次按通配符匹配；没有匹配规则时返回 nil
+func (g *Group) GetModelFallbackChain(requestedModel string) []ModelFallbackTarget {
+	if len(g.ModelFallbacks) == 0 || requestedModel == "" {
+		return nil
+	}
+
+	if targets, ok := g.ModelFallbacks[requestedModel]; ok && len(targets) > 0 {
+		return targets
+	}
+
+	// 通配符匹配：取最长前缀，避免 map 遍历顺序导致结果不稳定
+	var matched []ModelFallbackTarget
+	matchedLen := -1
+	for pattern, targets := range g.ModelFallbacks {
+		if len(targets) == 0 || !matchModelPattern(pattern, requestedModel) {
+			continue
+		}
+		if len(pattern) > matchedLen {
+			matched = targets
+			matchedLen = len(pattern)
+		}
+	}
+	return matched
+}
+
 // matchModelPattern 检查模型是否匹配模式
 // 支持 * 通配符，如 "claude-opus-*" 匹配 "claude-opus-4-20250514"
 func matchModelPattern(pattern, model string) bool {
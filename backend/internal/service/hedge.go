@@ -0,0 +1,209 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 对冲请求（Hedged Requests）
+//
+// 非流式短请求的尾延迟主要来自偶发的慢账号。分组开启对冲后，若首个上游在
+// 响应头延迟的分位数内仍未返回响应头，则在另一个账号上并发发起第二个请求，取先成功者，
+// 另一个请求被取消；落败请求已完成的用量按分组策略计费或忽略。
+const (
+	// DefaultHedgePercentile 默认触发对冲的延迟分位数
+	DefaultHedgePercentile = 95
+	// HedgeMinPercentile / HedgeMaxPercentile 分位数取值范围
+	HedgeMinPercentile = 50
+	HedgeMaxPercentile = 99
+
+	// HedgeLoserBillingIgnore 落败请求不计费
+	HedgeLoserBillingIgnore = "ignore"
+	// HedgeLoserBillingBill 落败请求若已完成则按用量计费
+	HedgeLoserBillingBill = "bill"
+
+	// hedgeDelayRefreshInterval 分位延迟重新计算间隔
+	hedgeDelayRefreshInterval = time.Minute
+	// hedgeMinSamples 统计窗口内样本数低于该值时使用默认延迟
+	hedgeMinSamples = 20
+)
+
+var (
+	ErrInvalidHedgePercentile   = infraerrors.BadRequest("INVALID_HEDGE_PERCENTILE", "hedge percentile must be between 50 and 99")
+	ErrInvalidHedgeLoserBilling = infraerrors.BadRequest("INVALID_HEDGE_LOSER_BILLING", "hedge loser billing must be ignore or bill")
+)
+
+// validateHedgeSettings 校验分组对冲配置
+func validateHedgeSettings(percentile int, loserBilling string) error {
+	if percentile < HedgeMinPercentile || percentile > HedgeMaxPercentile {
+		return ErrInvalidHedgePercentile
+	}
+	if loserBilling != HedgeLoserBillingIgnore && loserBilling != HedgeLoserBillingBill {
+		return ErrInvalidHedgeLoserBilling
+	}
+	return nil
+}
+
+// ShouldBillHedgeLoser 返回分组是否对落败的对冲请求计费
+func ShouldBillHedgeLoser(group *Group) bool {
+	return group != nil && group.HedgeLoserBilling == HedgeLoserBillingBill
+}
+
+// hedgeMaxSamples 每个分组保留的响应头延迟样本数上限
+const hedgeMaxSamples = 512
+
+// hedgeLatencySample 一次上游响应头延迟（time to headers）样本。
+// censored 表示请求在收到响应头前被取消（对冲落败），实际延迟不小于 latency。
+type hedgeLatencySample struct {
+	latency  time.Duration
+	censored bool
+	at       time.Time
+}
+
+type hedgeDelayKey struct {
+	groupID    int64
+	percentile int
+}
+
+type hedgeDelayEntry struct {
+	delay     time.Duration
+	fetchedAt time.Time
+}
+
+// HedgeDelayService 按分组统计上游响应头延迟并计算对冲延迟。
+// 样本由对冲转发在进程内记录（非流式请求的总耗时包含生成时间，不能用于判断是否已收到响应头）。
+type HedgeDelayService struct {
+	cfg config.GatewayHedgeConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	samples map[int64][]hedgeLatencySample // 按分组的环形缓冲
+	next    map[int64]int
+	entries map[hedgeDelayKey]*hedgeDelayEntry
+}
+
+// NewHedgeDelayService 创建对冲延迟服务
+func NewHedgeDelayService(cfg *config.Config) *HedgeDelayService {
+	s := &HedgeDelayService{
+		now:     time.Now,
+		samples: make(map[int64][]hedgeLatencySample),
+		next:    make(map[int64]int),
+		entries: make(map[hedgeDelayKey]*hedgeDelayEntry),
+	}
+	if cfg != nil {
+		s.cfg = cfg.Gateway.Hedge
+	}
+	return s
+}
+
+// ShouldHedge 判断请求是否适用对冲：分组开启、非流式、max_tokens 在阈值内
+func (s *HedgeDelayService) ShouldHedge(group *Group, stream bool, maxTokens int) bool {
+	if s == nil || group == nil || !group.HedgeEnabled || stream {
+		return false
+	}
+	return s.cfg.MaxTokens <= 0 || (maxTokens > 0 && maxTokens <= s.cfg.MaxTokens)
+}
+
+// ObserveHeaderLatency 记录一次上游响应头延迟；censored 表示请求在收到响应头前被取消
+func (s *HedgeDelayService) ObserveHeaderLatency(group *Group, latency time.Duration, censored bool) {
+	if s == nil || group == nil || latency < 0 {
+		return
+	}
+	sample := hedgeLatencySample{latency: latency, censored: censored, at: s.now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf := s.samples[group.ID]
+	if len(buf) < hedgeMaxSamples {
+		s.samples[group.ID] = append(buf, sample)
+		return
+	}
+	i := s.next[group.ID]
+	buf[i] = sample
+	s.next[group.ID] = (i + 1) % hedgeMaxSamples
+}
+
+// Delay 返回分组的对冲延迟：取统计窗口内响应头延迟的分位数，每分钟重新计算一次。
+// 样本不足或分位落在被取消请求（延迟未知）的尾部时使用默认延迟。
+func (s *HedgeDelayService) Delay(group *Group) time.Duration {
+	if s == nil || group == nil {
+		return 0
+	}
+	percentile := group.HedgePercentile
+	if percentile < HedgeMinPercentile || percentile > HedgeMaxPercentile {
+		percentile = DefaultHedgePercentile
+	}
+	key := hedgeDelayKey{groupID: group.ID, percentile: percentile}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[key]
+	if entry != nil && now.Sub(entry.fetchedAt) < hedgeDelayRefreshInterval {
+		return entry.delay
+	}
+	delay := s.defaultDelay()
+	if d, ok := hedgeDelayFromSamples(s.samples[group.ID], now.Add(-s.statsWindow()), percentile); ok {
+		delay = s.clampDelay(d)
+	}
+	s.entries[key] = &hedgeDelayEntry{delay: delay, fetchedAt: now}
+	return delay
+}
+
+func (s *HedgeDelayService) defaultDelay() time.Duration {
+	ms := s.cfg.DefaultDelayMs
+	if ms <= 0 {
+		ms = 1500
+	}
+	return s.clampDelay(time.Duration(ms) * time.Millisecond)
+}
+
+func (s *HedgeDelayService) clampDelay(d time.Duration) time.Duration {
+	if minDelay := time.Duration(s.cfg.MinDelayMs) * time.Millisecond; minDelay > 0 && d < minDelay {
+		d = minDelay
+	}
+	if maxDelay := time.Duration(s.cfg.MaxDelayMs) * time.Millisecond; maxDelay > 0 && d > maxDelay {
+		d = maxDelay
+	}
+	return d
+}
+
+func (s *HedgeDelayService) statsWindow() time.Duration {
+	if s.cfg.StatsWindowMinutes <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(s.cfg.StatsWindowMinutes) * time.Minute
+}
+
+// hedgeDelayFromSamples 计算 since 之后样本的响应头延迟分位数。
+// 被取消的样本实际延迟未知，按无穷大排在尾部；分位落在其中时返回 false，由调用方回退到默认延迟。
+func hedgeDelayFromSamples(samples []hedgeLatencySample, since time.Time, percentile int) (time.Duration, bool) {
+	observed := make([]time.Duration, 0, len(samples))
+	total := 0
+	for _, sample := range samples {
+		if sample.at.Before(since) {
+			continue
+		}
+		total++
+		if !sample.censored {
+			observed = append(observed, sample.latency)
+		}
+	}
+	if total < hedgeMinSamples {
+		return 0, false
+	}
+	rank := int(math.Ceil(float64(total)*float64(percentile)/100)) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(observed) {
+		return 0, false
+	}
+	sort.Slice(observed, func(i, j int) bool { return observed[i] < observed[j] })
+	return observed[rank], true
+}
@@ -0,0 +1,141 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgeDelayFromSamples(t *testing.T) {
+	now := time.Now()
+	observed := func(ms ...int) []hedgeLatencySample {
+		out := make([]hedgeLatencySample, 0, len(ms))
+		for _, v := range ms {
+			out = append(out, hedgeLatencySample{latency: time.Duration(v) * time.Millisecond, at: now})
+		}
+		return out
+	}
+	censored := func(n int, ms int) []hedgeLatencySample {
+		out := make([]hedgeLatencySample, 0, n)
+		for i := 0; i < n; i++ {
+			out = append(out, hedgeLatencySample{latency: time.Duration(ms) * time.Millisecond, censored: true, at: now})
+		}
+		return out
+	}
+	// 1..100ms 各一个样本
+	hundred := make([]int, 0, 100)
+	for i := 100; i >= 1; i-- {
+		hundred = append(hundred, i)
+	}
+
+	stale := observed(hundred[:50]...)
+	for i := range stale {
+		stale[i].at = now.Add(-time.Hour)
+	}
+
+	tests := []struct {
+		name       string
+		samples    []hedgeLatencySample
+		percentile int
+		want       time.Duration
+		ok         bool
+	}{
+		{name: "median", samples: observed(hundred...), percentile: 50, want: 50 * time.Millisecond, ok: true},
+		{name: "p95", samples: observed(hundred...), percentile: 95, want: 95 * time.Millisecond, ok: true},
+		{name: "censored samples rank above observed", samples: append(observed(hundred[10:]...), censored(10, 5)...), percentile: 90, want: 90 * time.Millisecond, ok: true},
+		{name: "percentile in censored tail", samples: append(observed(hundred[10:]...), censored(10, 5)...), percentile: 95},
+		{name: "stale samples pruned", samples: append(stale, observed(1, 2, 3)...), percentile: 50},
+		{name: "too few samples", samples: observed(1, 2, 3), percentile: 50},
+		{name: "no samples", percentile: 95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := hedgeDelayFromSamples(tt.samples, now.Add(-time.Minute), tt.percentile)
+			require.Equal(t, tt.ok, ok)
+			if tt.ok {
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateHedgeSettings(t *testing.T) {
+	require.NoError(t, validateHedgeSettings(DefaultHedgePercentile, HedgeLoserBillingIgnore))
+	require.NoError(t, validateHedgeSettings(HedgeMinPercentile, HedgeLoserBillingBill))
+	require.ErrorIs(t, validateHedgeSettings(HedgeMaxPercentile+1, HedgeLoserBillingIgnore), ErrInvalidHedgePercentile)
+	require.ErrorIs(t, validateHedgeSettings(HedgeMinPercentile-1, HedgeLoserBillingIgnore), ErrInvalidHedgePercentile)
+	require.ErrorIs(t, validateHedgeSettings(DefaultHedgePercentile, "refund"), ErrInvalidHedgeLoserBilling)
+}
+
+func TestHedgeDelayServiceShouldHedge(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.Hedge.MaxTokens = 1024
+	svc := NewHedgeDelayService(cfg)
+	enabled := &Group{ID: 1, HedgeEnabled: true}
+
+	require.True(t, svc.ShouldHedge(enabled, false, 512))
+	require.False(t, svc.ShouldHedge(enabled, true, 512), "streaming requests are never hedged")
+	require.False(t, svc.ShouldHedge(enabled, false, 4096), "long generations are not hedged")
+	require.False(t, svc.ShouldHedge(enabled, false, 0), "unknown max_tokens is not hedged")
+	require.False(t, svc.ShouldHedge(&Group{ID: 2}, false, 512))
+	require.False(t, svc.ShouldHedge(nil, false, 512))
+
+	var nilSvc *HedgeDelayService
+	require.False(t, nilSvc.ShouldHedge(enabled, false, 512))
+}
+
+func TestHedgeDelayServiceDelayUsesClampedDefault(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.Hedge.DefaultDelayMs = 100
+	cfg.Gateway.Hedge.MinDelayMs = 300
+	cfg.Gateway.Hedge.MaxDelayMs = 5000
+	svc := NewHedgeDelayService(cfg)
+
+	require.Equal(t, 300*time.Millisecond, svc.Delay(&Group{ID: 1, HedgeEnabled: true, HedgePercentile: 95}))
+}
+
+func TestHedgeDelayServiceDelayFromObservedLatency(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.Hedge.DefaultDelayMs = 400
+	cfg.Gateway.Hedge.MinDelayMs = 200
+	cfg.Gateway.Hedge.MaxDelayMs = 800
+	svc := NewHedgeDelayService(cfg)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	group := &Group{ID: 1, HedgeEnabled: true, HedgePercentile: 50}
+
+	for i := 1; i <= hedgeMinSamples; i++ {
+		svc.ObserveHeaderLatency(group, time.Duration(i)*100*time.Millisecond, false)
+	}
+	// 中位数 1000ms 超过上限，截断为 800ms
+	require.Equal(t, 800*time.Millisecond, svc.Delay(group))
+
+	// 结果缓存一分钟；之后被取消的样本占据尾部，分位落入其中时回退到默认延迟
+	for i := 0; i < 3*hedgeMinSamples; i++ {
+		svc.ObserveHeaderLatency(group, 50*time.Millisecond, true)
+	}
+	require.Equal(t, 800*time.Millisecond, svc.Delay(group))
+	now = now.Add(hedgeDelayRefreshInterval)
+	require.Equal(t, 400*time.Millisecond, svc.Delay(group))
+
+	// 其他分组样本不足，使用默认延迟
+	require.Equal(t, 400*time.Millisecond, svc.Delay(&Group{ID: 2, HedgeEnabled: true, HedgePercentile: 50}))
+}
+
+func TestHedgeDelayServiceObserveKeepsBoundedSamples(t *testing.T) {
+	svc := NewHedgeDelayService(&config.Config{})
+	group := &Group{ID: 1}
+	for i := 0; i < hedgeMaxSamples+10; i++ {
+		svc.ObserveHeaderLatency(group, time.Duration(i)*time.Millisecond, false)
+	}
+	require.Len(t, svc.samples[group.ID], hedgeMaxSamples)
+	require.Equal(t, 10, svc.next[group.ID])
+	require.Equal(t, time.Duration(hedgeMaxSamples)*time.Millisecond, svc.samples[group.ID][0].latency)
+
+	var nilSvc *HedgeDelayService
+	nilSvc.ObserveHeaderLatency(group, time.Second, false)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 账单明细中的资金变动类型
+const (
+	InvoiceEntryTypeRedeem          = "redeem"           // 兑换码充值
+	InvoiceEntryTypePromo           = "promo"            // 优惠码赠送
+	InvoiceEntryTypeAdminAdjustment = "admin_adjustment" // 管理员调整余额
+	InvoiceEntryTypeSubscription    = "subscription"     // 订阅开通/续期
+)
+
+// Invoice 表示一张已出具的月度账单，出具后不可修改
+type Invoice struct {
+	ID                 int64          `json:"id"`
+	InvoiceNumber      string         `json:"invoice_number"`
+	UserID             int64          `json:"user_id"`
+	PeriodStart        time.Time      `json:"period_start"`
+	PeriodEnd          time.Time      `json:"period_end"`
+	Currency           string         `json:"currency"`
+	TopupAmount        float64        `json:"topup_amount"`
+	SubscriptionAmount float64        `json:"subscription_amount"`
+	UsageTotalCost     float64        `json:"usage_total_cost"`
+	UsageActualCost    float64        `json:"usage_actual_cost"`
+	RequestCount       int64          `json:"request_count"`
+	Content            InvoiceContent `json:"content"`
+	ContentHash        string         `json:"content_hash"`
+	IssuedAt           time.Time      `json:"issued_at"`
+	CreatedAt          time.Time      `json:"created_at"`
+}
+
+// InvoiceContent 出账时的完整快照
+type InvoiceContent struct {
+	Issuer        InvoiceIssuer         `json:"issuer"`
+	Customer      InvoiceCustomer       `json:"customer"`
+	Entries       []InvoiceBalanceEntry `json:"entries"`
+	Subscriptions []InvoiceBalanceEntry `json:"subscriptions"`
+	Usage         []InvoiceUsageLine    `json:"usage"`
+	Summary       InvoiceSummary        `json:"summary"`
+}
+
+// InvoiceIssuer 账单开具方
+type InvoiceIssuer struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
+
+// InvoiceCustomer 账单归属用户（出账时快照）
+type InvoiceCustomer struct {
+	UserID   int64  `json:"user_id"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+}
+
+// InvoiceBalanceEntry 周期内的一笔资金变动或订阅开通
+type InvoiceBalanceEntry struct {
+	Type         string    `json:"type"`
+	Reference    string    `json:"reference"`
+	Amount       float64   `json:"amount"`
+	GroupID      *int64    `json:"group_id,omitempty"`
+	GroupName    string    `json:"group_name,omitempty"`
+	ValidityDays int       `json:"validity_days,omitempty"`
+	Notes        string    `json:"notes,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// InvoiceUsageLine 按分组/模型/倍率汇总的用量
+type InvoiceUsageLine struct {
+	GroupID             *int64  `json:"group_id,omitempty"`
+	GroupName           string  `json:"group_name"`
+	Model               string  `json:"model"`
+	RateMultiplier      float64 `json:"rate_multiplier"`
+	RequestCount        int64   `json:"request_count"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	TotalCost           float64 `json:"total_cost"`
+	ActualCost          float64 `json:"actual_cost"`
+}
+
+// InvoiceSummary 账单汇总
+type InvoiceSummary struct {
+	RedeemAmount          float64 `json:"redeem_amount"`
+	PromoAmount           float64 `json:"promo_amount"`
+	AdminAdjustmentAmount float64 `json:"admin_adjustment_amount"`
+	TopupAmount           float64 `json:"topup_amount"`
+	SubscriptionAmount    float64 `json:"subscription_amount"`
+	UsageTotalCost        float64 `json:"usage_total_cost"`
+	UsageActualCost       float64 `json:"usage_actual_cost"`
+	RequestCount          int64   `json:"request_count"`
+}
+
+// InvoiceListFilter 账单列表过滤；UserID 为 nil 表示全部用户（管理员视图）
+type InvoiceListFilter struct {
+	UserID      *int64
+	PeriodStart *time.Time
+}
+
+// InvoiceRepository 账单持久层接口（只提供新增与查询，不提供修改/删除）
+type InvoiceRepository interface {
+	// CreateWithNextNumber 在同一事务内分配编号并写入账单。
+	// 编号格式为 <numberPrefix>-<YYYY>-<6 位序号>，按前缀+账期年份连续递增；
+	// 同一用户同一账期已存在账单时返回 ErrInvoiceAlreadyExists（编号不会被消耗）。
+	CreateWithNextNumber(ctx context.Context, invoice *Invoice, numberPrefix string) error
+	GetByID(ctx context.Context, id int64) (*Invoice, error)
+	GetByUserPeriod(ctx context.Context, userID int64, periodStart, periodEnd time.Time) (*Invoice, error)
+	List(ctx context.Context, filter InvoiceListFilter, params pagination.PaginationParams) ([]Invoice, *pagination.PaginationResult, error)
+
+	// ListBillableUserIDs 返回周期内有用量或资金变动的用户
+	ListBillableUserIDs(ctx context.Context, start, end time.Time) ([]int64, error)
+	// ListBalanceEntries 返回周期内的充值、优惠码、管理员调整与订阅开通记录（按时间升序）
+	ListBalanceEntries(ctx context.Context, userID int64, start, end time.Time) ([]InvoiceBalanceEntry, error)
+	// AggregateUsage 按分组/模型/倍率汇总周期内的使用记录
+	AggregateUsage(ctx context.Context, userID int64, start, end time.Time) ([]InvoiceUsageLine, error)
+}
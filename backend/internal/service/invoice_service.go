@@ -0,0 +1,346 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/robfig/cron/v3"
+)
+
+var invoiceCronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+var (
+	ErrInvoiceNotFound       = infraerrors.NotFound("INVOICE_NOT_FOUND", "invoice not found")
+	ErrInvoiceAlreadyExists  = infraerrors.Conflict("INVOICE_ALREADY_EXISTS", "invoice already exists for this period")
+	ErrInvoicePeriodNotEnded = infraerrors.BadRequest("INVOICE_PERIOD_NOT_ENDED", "billing period has not ended yet")
+)
+
+// InvoiceCloseResult 一次结算的结果
+type InvoiceCloseResult struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Generated   int       `json:"generated"`
+	Existing    int       `json:"existing"`
+	Failed      int       `json:"failed"`
+}
+
+// InvoiceService 负责按月结算并出具账单
+type InvoiceService struct {
+	repo     InvoiceRepository
+	userRepo UserRepository
+	cfg      *config.Config
+
+	cron      *cron.Cron
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+func NewInvoiceService(repo InvoiceRepository, userRepo UserRepository, cfg *config.Config) *InvoiceService {
+	return &InvoiceService{
+		repo:     repo,
+		userRepo: userRepo,
+		cfg:      cfg,
+	}
+}
+
+func (s *InvoiceService) Start() {
+	if s == nil || s.cfg == nil || s.repo == nil {
+		return
+	}
+	if !s.cfg.Invoice.Enabled {
+		logger.LegacyPrintf("service.invoice", "[Invoice] scheduler not started (disabled)")
+		return
+	}
+	s.startOnce.Do(func() {
+		schedule := strings.TrimSpace(s.cfg.Invoice.Schedule)
+		loc := timezone.Location()
+		c := cron.New(cron.WithParser(invoiceCronParser), cron.WithLocation(loc))
+		if _, err := c.AddFunc(schedule, s.closePreviousMonth); err != nil {
+			logger.LegacyPrintf("service.invoice", "[Invoice] scheduler not started (invalid schedule=%q): %v", schedule, err)
+			return
+		}
+		s.cron = c
+		s.cron.Start()
+		logger.LegacyPrintf("service.invoice", "[Invoice] scheduler started (schedule=%q tz=%s)", schedule, loc.String())
+	})
+}
+
+func (s *InvoiceService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.cron != nil {
+			ctx := s.cron.Stop()
+			select {
+			case <-ctx.Done():
+			case <-time.After(3 * time.Second):
+				logger.LegacyPrintf("service.invoice", "[Invoice] cron stop timed out")
+			}
+		}
+	})
+}
+
+func (s *InvoiceService) closePreviousMonth() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	prev := timezone.StartOfMonth(timezone.Now()).AddDate(0, -1, 0)
+	result, err := s.CloseMonth(ctx, prev)
+	if err != nil {
+		logger.LegacyPrintf("service.invoice", "[Invoice] monthly close failed: month=%s err=%v", prev.Format("2006-01"), err)
+		return
+	}
+	logger.LegacyPrintf("service.invoice", "[Invoice] monthly close finished: month=%s generated=%d existing=%d failed=%d",
+		prev.Format("2006-01"), result.Generated, result.Existing, result.Failed)
+}
+
+// InvoiceMonthPeriod 返回 month 所在自然月（服务器时区）的左闭右开区间
+func InvoiceMonthPeriod(month time.Time) (time.Time, time.Time) {
+	start := timezone.StartOfMonth(month)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// CloseMonth 为 month 所在自然月内有活动的所有用户出具账单；已出具的账单保持不变
+func (s *InvoiceService) CloseMonth(ctx context.Context, month time.Time) (*InvoiceCloseResult, error) {
+	start, end := InvoiceMonthPeriod(month)
+	if end.After(time.Now()) {
+		return nil, ErrInvoicePeriodNotEnded
+	}
+	userIDs, err := s.repo.ListBillableUserIDs(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("list billable users: %w", err)
+	}
+	result := &InvoiceCloseResult{PeriodStart: start, PeriodEnd: end}
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		_, created, err := s.GenerateForUser(ctx, userID, start, end)
+		switch {
+		case err != nil:
+			result.Failed++
+			logger.LegacyPrintf("service.invoice", "[Invoice] generate failed: user=%d period=%s err=%v", userID, start.Format("2006-01"), err)
+		case created:
+			result.Generated++
+		default:
+			result.Existing++
+		}
+	}
+	return result, nil
+}
+
+// GenerateForUser 出具指定用户指定周期的账单；已存在时直接返回已有账单（created=false）
+func (s *InvoiceService) GenerateForUser(ctx context.Context, userID int64, start, end time.Time) (*Invoice, bool, error) {
+	if !end.After(start) {
+		return nil, false, infraerrors.BadRequest("INVOICE_INVALID_PERIOD", "period end must be after period start")
+	}
+	if end.After(time.Now()) {
+		return nil, false, ErrInvoicePeriodNotEnded
+	}
+	if existing, err := s.repo.GetByUserPeriod(ctx, userID, start, end); err == nil {
+		return existing, false, nil
+	} else if !errors.Is(err, ErrInvoiceNotFound) {
+		return nil, false, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	entries, err := s.repo.ListBalanceEntries(ctx, userID, start, end)
+	if err != nil {
+		return nil, false, fmt.Errorf("list balance entries: %w", err)
+	}
+	usage, err := s.repo.AggregateUsage(ctx, userID, start, end)
+	if err != nil {
+		return nil, false, fmt.Errorf("aggregate usage: %w", err)
+	}
+
+	content := buildInvoiceContent(entries, usage)
+	content.Issuer = s.issuer()
+	content.Customer = InvoiceCustomer{UserID: user.ID, Email: user.Email, Username: user.Username}
+	hash, err := invoiceContentHash(&content)
+	if err != nil {
+		return nil, false, err
+	}
+
+	invoice := &Invoice{
+		UserID:             userID,
+		PeriodStart:        start,
+		PeriodEnd:          end,
+		Currency:           s.currency(),
+		TopupAmount:        content.Summary.TopupAmount,
+		SubscriptionAmount: content.Summary.SubscriptionAmount,
+		UsageTotalCost:     content.Summary.UsageTotalCost,
+		UsageActualCost:    content.Summary.UsageActualCost,
+		RequestCount:       content.Summary.RequestCount,
+		Content:            content,
+		ContentHash:        hash,
+	}
+	if err := s.repo.CreateWithNextNumber(ctx, invoice, s.numberPrefix()); err != nil {
+		if errors.Is(err, ErrInvoiceAlreadyExists) {
+			// 并发出账：另一实例已写入，返回已有账单
+			existing, getErr := s.repo.GetByUserPeriod(ctx, userID, start, end)
+			if getErr != nil {
+				return nil, false, getErr
+			}
+			return existing, false, nil
+		}
+		return nil, false, err
+	}
+	logger.LegacyPrintf("service.invoice", "[Invoice] issued: number=%s user=%d period=%s..%s", invoice.InvoiceNumber, userID, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	return invoice, true, nil
+}
+
+// List 列出账单；userID 为 nil 时返回全部用户
+func (s *InvoiceService) List(ctx context.Context, filter InvoiceListFilter, params pagination.PaginationParams) ([]Invoice, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, filter, params)
+}
+
+// Get 获取账单；requesterID > 0 时校验归属（不属于本人时按不存在处理）
+func (s *InvoiceService) Get(ctx context.Context, id int64, requesterID int64) (*Invoice, error) {
+	invoice, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if requesterID > 0 && invoice.UserID != requesterID {
+		return nil, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// buildInvoiceContent 将原始明细归类并计算汇总
+func buildInvoiceContent(entries []InvoiceBalanceEntry, usage []InvoiceUsageLine) InvoiceContent {
+	content := InvoiceContent{
+		Entries:       make([]InvoiceBalanceEntry, 0, len(entries)),
+		Subscriptions: make([]InvoiceBalanceEntry, 0),
+		Usage:         usage,
+	}
+	if content.Usage == nil {
+		content.Usage = []InvoiceUsageLine{}
+	}
+	sum := &content.Summary
+	for _, entry := range entries {
+		switch entry.Type {
+		case InvoiceEntryTypeSubscription:
+			content.Subscriptions = append(content.Subscriptions, entry)
+			sum.SubscriptionAmount += entry.Amount
+			continue
+		case InvoiceEntryTypeRedeem:
+			sum.RedeemAmount += entry.Amount
+		case InvoiceEntryTypePromo:
+			sum.PromoAmount += entry.Amount
+		case InvoiceEntryTypeAdminAdjustment:
+			sum.AdminAdjustmentAmount += entry.Amount
+		}
+		content.Entries = append(content.Entries, entry)
+	}
+	sum.TopupAmount = sum.RedeemAmount + sum.PromoAmount + sum.AdminAdjustmentAmount
+
+	sort.SliceStable(content.Usage, func(i, j int) bool {
+		a, b := content.Usage[i], content.Usage[j]
+		if a.GroupName != b.GroupName {
+			return a.GroupName < b.GroupName
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.RateMultiplier < b.RateMultiplier
+	})
+	for _, line := range content.Usage {
+		sum.UsageTotalCost += line.TotalCost
+		sum.UsageActualCost += line.ActualCost
+		sum.RequestCount += line.RequestCount
+	}
+
+	sum.RedeemAmount = roundInvoiceAmount(sum.RedeemAmount, 8)
+	sum.PromoAmount = roundInvoiceAmount(sum.PromoAmount, 8)
+	sum.AdminAdjustmentAmount = roundInvoiceAmount(sum.AdminAdjustmentAmount, 8)
+	sum.TopupAmount = roundInvoiceAmount(sum.TopupAmount, 8)
+	sum.SubscriptionAmount = roundInvoiceAmount(sum.SubscriptionAmount, 8)
+	sum.UsageTotalCost = roundInvoiceAmount(sum.UsageTotalCost, 10)
+	sum.UsageActualCost = roundInvoiceAmount(sum.UsageActualCost, 10)
+	return content
+}
+
+func invoiceContentHash(content *InvoiceContent) (string, error) {
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("marshal invoice content: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyInvoiceContent 校验账单快照与出账时记录的哈希一致
+func VerifyInvoiceContent(invoice *Invoice) bool {
+	if invoice == nil {
+		return false
+	}
+	hash, err := invoiceContentHash(&invoice.Content)
+	return err == nil && hash == invoice.ContentHash
+}
+
+func roundInvoiceAmount(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Round(v*p) / p
+}
+
+// RenderHTML 将账单渲染为可打印（可直接转 PDF）的 HTML 文档
+func (s *InvoiceService) RenderHTML(invoice *Invoice) ([]byte, error) {
+	var buf bytes.Buffer
+	data := invoiceTemplateData{
+		Invoice:  invoice,
+		Verified: VerifyInvoiceContent(invoice),
+		Location: timezone.Location(),
+	}
+	if err := invoiceHTMLTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render invoice: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// InvoiceFileName 下载文件名
+func InvoiceFileName(invoice *Invoice) string {
+	return fmt.Sprintf("%s.html", invoice.InvoiceNumber)
+}
+
+func (s *InvoiceService) issuer() InvoiceIssuer {
+	if s.cfg == nil {
+		return InvoiceIssuer{}
+	}
+	return InvoiceIssuer{
+		Name:    strings.TrimSpace(s.cfg.Invoice.IssuerName),
+		Address: strings.TrimSpace(s.cfg.Invoice.IssuerAddress),
+		Email:   strings.TrimSpace(s.cfg.Invoice.IssuerEmail),
+	}
+}
+
+func (s *InvoiceService) currency() string {
+	if s.cfg != nil && strings.TrimSpace(s.cfg.Invoice.Currency) != "" {
+		return strings.ToUpper(strings.TrimSpace(s.cfg.Invoice.Currency))
+	}
+	return "USD"
+}
+
+func (s *InvoiceService) numberPrefix() string {
+	if s.cfg != nil && strings.TrimSpace(s.cfg.Invoice.NumberPrefix) != "" {
+		return strings.TrimSpace(s.cfg.Invoice.NumberPrefix)
+	}
+	return "INV"
+}
@@ -0,0 +1,201 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type invoiceRepoStub struct {
+	invoices    []*Invoice
+	sequences   map[string]int64
+	entries     []InvoiceBalanceEntry
+	usage       []InvoiceUsageLine
+	billable    []int64
+	createCalls int
+}
+
+func newInvoiceRepoStub() *invoiceRepoStub {
+	return &invoiceRepoStub{sequences: map[string]int64{}}
+}
+
+func (s *invoiceRepoStub) CreateWithNextNumber(_ context.Context, invoice *Invoice, numberPrefix string) error {
+	s.createCalls++
+	for _, existing := range s.invoices {
+		if existing.UserID == invoice.UserID && existing.PeriodStart.Equal(invoice.PeriodStart) && existing.PeriodEnd.Equal(invoice.PeriodEnd) {
+			return ErrInvoiceAlreadyExists
+		}
+	}
+	scope := fmt.Sprintf("%s-%04d", numberPrefix, invoice.PeriodStart.Year())
+	s.sequences[scope]++
+	invoice.ID = int64(len(s.invoices) + 1)
+	invoice.InvoiceNumber = fmt.Sprintf("%s-%06d", scope, s.sequences[scope])
+	invoice.IssuedAt = time.Now()
+	invoice.CreatedAt = invoice.IssuedAt
+	cp := *invoice
+	s.invoices = append(s.invoices, &cp)
+	return nil
+}
+
+func (s *invoiceRepoStub) GetByID(_ context.Context, id int64) (*Invoice, error) {
+	for _, inv := range s.invoices {
+		if inv.ID == id {
+			cp := *inv
+			return &cp, nil
+		}
+	}
+	return nil, ErrInvoiceNotFound
+}
+
+func (s *invoiceRepoStub) GetByUserPeriod(_ context.Context, userID int64, periodStart, periodEnd time.Time) (*Invoice, error) {
+	for _, inv := range s.invoices {
+		if inv.UserID == userID && inv.PeriodStart.Equal(periodStart) && inv.PeriodEnd.Equal(periodEnd) {
+			cp := *inv
+			return &cp, nil
+		}
+	}
+	return nil, ErrInvoiceNotFound
+}
+
+func (s *invoiceRepoStub) List(context.Context, InvoiceListFilter, pagination.PaginationParams) ([]Invoice, *pagination.PaginationResult, error) {
+	panic("unexpected List call")
+}
+
+func (s *invoiceRepoStub) ListBillableUserIDs(context.Context, time.Time, time.Time) ([]int64, error) {
+	return s.billable, nil
+}
+
+func (s *invoiceRepoStub) ListBalanceEntries(context.Context, int64, time.Time, time.Time) ([]InvoiceBalanceEntry, error) {
+	return s.entries, nil
+}
+
+func (s *invoiceRepoStub) AggregateUsage(context.Context, int64, time.Time, time.Time) ([]InvoiceUsageLine, error) {
+	return s.usage, nil
+}
+
+func newInvoiceServiceForTest(repo *invoiceRepoStub) *InvoiceService {
+	cfg := &config.Config{}
+	cfg.Invoice.NumberPrefix = "INV"
+	cfg.Invoice.Currency = "usd"
+	cfg.Invoice.IssuerName = "Example <Co>"
+	userRepo := &userRepoStub{user: &User{ID: 7, Email: "alice@example.com", Username: "alice"}}
+	return NewInvoiceService(repo, userRepo, cfg)
+}
+
+func TestBuildInvoiceContent_ClassifiesEntriesAndSums(t *testing.T) {
+	entries := []InvoiceBalanceEntry{
+		{Type: InvoiceEntryTypeRedeem, Amount: 10},
+		{Type: InvoiceEntryTypePromo, Amount: 2.5},
+		{Type: InvoiceEntryTypeAdminAdjustment, Amount: -1},
+		{Type: InvoiceEntryTypeSubscription, Amount: 30, ValidityDays: 30},
+	}
+	usage := []InvoiceUsageLine{
+		{GroupName: "b", Model: "m1", RequestCount: 3, TotalCost: 0.3, ActualCost: 0.6},
+		{GroupName: "a", Model: "m2", RequestCount: 2, TotalCost: 0.1, ActualCost: 0.1},
+	}
+
+	content := buildInvoiceContent(entries, usage)
+
+	require.Len(t, content.Entries, 3)
+	require.Len(t, content.Subscriptions, 1)
+	require.Equal(t, "a", content.Usage[0].GroupName)
+	require.InDelta(t, 10, content.Summary.RedeemAmount, 1e-9)
+	require.InDelta(t, 2.5, content.Summary.PromoAmount, 1e-9)
+	require.InDelta(t, -1, content.Summary.AdminAdjustmentAmount, 1e-9)
+	require.InDelta(t, 11.5, content.Summary.TopupAmount, 1e-9)
+	require.InDelta(t, 30, content.Summary.SubscriptionAmount, 1e-9)
+	require.InDelta(t, 0.4, content.Summary.UsageTotalCost, 1e-9)
+	require.InDelta(t, 0.7, content.Summary.UsageActualCost, 1e-9)
+	require.Equal(t, int64(5), content.Summary.RequestCount)
+}
+
+func TestInvoiceService_GenerateForUser_IsIdempotentAndSequential(t *testing.T) {
+	repo := newInvoiceRepoStub()
+	repo.entries = []InvoiceBalanceEntry{{Type: InvoiceEntryTypeRedeem, Amount: 5}}
+	svc := newInvoiceServiceForTest(repo)
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	first, created, err := svc.GenerateForUser(context.Background(), 7, start, end)
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, "INV-2024-000001", first.InvoiceNumber)
+	require.Equal(t, "USD", first.Currency)
+	require.Equal(t, "alice@example.com", first.Content.Customer.Email)
+	require.True(t, VerifyInvoiceContent(first))
+
+	again, created, err := svc.GenerateForUser(context.Background(), 7, start, end)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, first.InvoiceNumber, again.InvoiceNumber)
+	require.Equal(t, 1, repo.createCalls)
+
+	next, created, err := svc.GenerateForUser(context.Background(), 7, end, end.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, "INV-2024-000002", next.InvoiceNumber)
+}
+
+func TestInvoiceService_GenerateForUser_RejectsOpenPeriod(t *testing.T) {
+	svc := newInvoiceServiceForTest(newInvoiceRepoStub())
+	start := time.Now().Add(-time.Hour)
+
+	_, _, err := svc.GenerateForUser(context.Background(), 7, start, start.Add(48*time.Hour))
+	require.ErrorIs(t, err, ErrInvoicePeriodNotEnded)
+}
+
+func TestInvoiceService_Get_HidesOtherUsersInvoices(t *testing.T) {
+	repo := newInvoiceRepoStub()
+	svc := newInvoiceServiceForTest(repo)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	inv, _, err := svc.GenerateForUser(context.Background(), 7, start, start.AddDate(0, 1, 0))
+	require.NoError(t, err)
+
+	_, err = svc.Get(context.Background(), inv.ID, 8)
+	require.ErrorIs(t, err, ErrInvoiceNotFound)
+
+	got, err := svc.Get(context.Background(), inv.ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, inv.InvoiceNumber, got.InvoiceNumber)
+}
+
+func TestVerifyInvoiceContent_DetectsTampering(t *testing.T) {
+	repo := newInvoiceRepoStub()
+	repo.entries = []InvoiceBalanceEntry{{Type: InvoiceEntryTypeRedeem, Amount: 5}}
+	svc := newInvoiceServiceForTest(repo)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	inv, _, err := svc.GenerateForUser(context.Background(), 7, start, start.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.True(t, VerifyInvoiceContent(inv))
+
+	inv.Content.Summary.TopupAmount = 500
+	require.False(t, VerifyInvoiceContent(inv))
+}
+
+func TestInvoiceService_RenderHTML(t *testing.T) {
+	repo := newInvoiceRepoStub()
+	repo.usage = []InvoiceUsageLine{{GroupName: "<script>", Model: "claude", RequestCount: 1, ActualCost: 0.01}}
+	svc := newInvoiceServiceForTest(repo)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	inv, _, err := svc.GenerateForUser(context.Background(), 7, start, start.AddDate(0, 1, 0))
+	require.NoError(t, err)
+
+	body, err := svc.RenderHTML(inv)
+	require.NoError(t, err)
+	html := string(body)
+	require.Contains(t, html, inv.InvoiceNumber)
+	require.Contains(t, html, "Example &lt;Co&gt;")
+	require.NotContains(t, html, "<script>")
+	require.NotContains(t, html, "digest mismatch")
+	require.Equal(t, inv.InvoiceNumber+".html", InvoiceFileName(inv))
+	require.False(t, strings.Contains(html, "{{"))
+}
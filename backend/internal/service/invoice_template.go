@@ -0,0 +1,142 @@
+package service
+
+import (
+	"html/template"
+	"strconv"
+	"time"
+)
+
+type invoiceTemplateData struct {
+	Invoice  *Invoice
+	Verified bool
+	Location *time.Location
+}
+
+var invoiceTemplateFuncs = template.FuncMap{
+	"money": func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+	"cost":  func(v float64) string { return strconv.FormatFloat(v, 'f', 6, 64) },
+	"rate":  func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) },
+	"date": func(t time.Time, loc *time.Location) string {
+		if loc != nil {
+			t = t.In(loc)
+		}
+		return t.Format("2006-01-02")
+	},
+	"datetime": func(t time.Time, loc *time.Location) string {
+		if loc != nil {
+			t = t.In(loc)
+		}
+		return t.Format("2006-01-02 15:04")
+	},
+	// periodLast 显示账期最后一天（period_end 为开区间）
+	"periodLast": func(t time.Time, loc *time.Location) string {
+		if loc != nil {
+			t = t.In(loc)
+		}
+		return t.Add(-time.Nanosecond).Format("2006-01-02")
+	},
+	"entryLabel": func(entryType string) string {
+		switch entryType {
+		case InvoiceEntryTypeRedeem:
+			return "Top-up (redeem code)"
+		case InvoiceEntryTypePromo:
+			return "Promo bonus"
+		case InvoiceEntryTypeAdminAdjustment:
+			return "Balance adjustment"
+		case InvoiceEntryTypeSubscription:
+			return "Subscription"
+		default:
+			return entryType
+		}
+	},
+}
+
+// invoiceHTMLTemplate 账单 HTML 模板，包含打印样式，浏览器"打印为 PDF"即可得到 PDF 版本
+var invoiceHTMLTemplate = template.Must(template.New("invoice").Funcs(invoiceTemplateFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Invoice.InvoiceNumber}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2937; margin: 32px; font-size: 13px; }
+  h1 { font-size: 22px; margin: 0 0 4px; }
+  h2 { font-size: 15px; margin: 28px 0 8px; border-bottom: 1px solid #e5e7eb; padding-bottom: 4px; }
+  .header { display: flex; justify-content: space-between; gap: 24px; }
+  .muted { color: #6b7280; }
+  table { width: 100%; border-collapse: collapse; }
+  th, td { padding: 6px 8px; border-bottom: 1px solid #f3f4f6; text-align: left; }
+  th { background: #f9fafb; font-weight: 600; }
+  td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+  .summary td { border: none; padding: 3px 8px; }
+  .summary tr.total td { font-weight: 700; border-top: 1px solid #d1d5db; }
+  .footer { margin-top: 32px; font-size: 11px; }
+  @media print { body { margin: 12mm; } h2 { page-break-after: avoid; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<div class="header">
+  <div>
+    <h1>Invoice / Statement</h1>
+    <div>No. <strong>{{.Invoice.InvoiceNumber}}</strong></div>
+    <div class="muted">Period: {{date .Invoice.PeriodStart .Location}} – {{periodLast .Invoice.PeriodEnd .Location}}</div>
+    <div class="muted">Issued: {{datetime .Invoice.IssuedAt .Location}}</div>
+  </div>
+  <div>
+    {{with .Invoice.Content.Issuer}}{{if .Name}}<div><strong>{{.Name}}</strong></div>{{end}}{{if .Address}}<div class="muted">{{.Address}}</div>{{end}}{{if .Email}}<div class="muted">{{.Email}}</div>{{end}}{{end}}
+  </div>
+</div>
+
+<h2>Billed to</h2>
+<div>{{.Invoice.Content.Customer.Email}}{{if .Invoice.Content.Customer.Username}} ({{.Invoice.Content.Customer.Username}}){{end}}</div>
+<div class="muted">User ID: {{.Invoice.Content.Customer.UserID}}</div>
+
+<h2>Summary ({{.Invoice.Currency}})</h2>
+{{with .Invoice.Content.Summary}}
+<table class="summary">
+  <tr><td>Top-ups (redeem codes)</td><td class="num">{{money .RedeemAmount}}</td></tr>
+  <tr><td>Promo bonuses</td><td class="num">{{money .PromoAmount}}</td></tr>
+  <tr><td>Balance adjustments</td><td class="num">{{money .AdminAdjustmentAmount}}</td></tr>
+  <tr class="total"><td>Total balance credited</td><td class="num">{{money .TopupAmount}}</td></tr>
+  <tr><td>Subscription charges</td><td class="num">{{money .SubscriptionAmount}}</td></tr>
+  <tr><td>Usage at standard price</td><td class="num">{{cost .UsageTotalCost}}</td></tr>
+  <tr class="total"><td>Usage charged ({{.RequestCount}} requests)</td><td class="num">{{cost .UsageActualCost}}</td></tr>
+</table>
+{{end}}
+
+<h2>Balance activity</h2>
+{{if .Invoice.Content.Entries}}
+<table>
+  <tr><th>Date</th><th>Type</th><th>Reference</th><th>Notes</th><th class="num">Amount</th></tr>
+  {{range .Invoice.Content.Entries}}
+  <tr><td>{{datetime .OccurredAt $.Location}}</td><td>{{entryLabel .Type}}</td><td>{{.Reference}}</td><td>{{.Notes}}</td><td class="num">{{money .Amount}}</td></tr>
+  {{end}}
+</table>
+{{else}}<div class="muted">No balance activity in this period.</div>{{end}}
+
+<h2>Subscriptions</h2>
+{{if .Invoice.Content.Subscriptions}}
+<table>
+  <tr><th>Date</th><th>Group</th><th>Reference</th><th class="num">Days</th><th class="num">Amount</th></tr>
+  {{range .Invoice.Content.Subscriptions}}
+  <tr><td>{{datetime .OccurredAt $.Location}}</td><td>{{.GroupName}}</td><td>{{.Reference}}</td><td class="num">{{.ValidityDays}}</td><td class="num">{{money .Amount}}</td></tr>
+  {{end}}
+</table>
+{{else}}<div class="muted">No subscription charges in this period.</div>{{end}}
+
+<h2>Usage by group and model</h2>
+{{if .Invoice.Content.Usage}}
+<table>
+  <tr><th>Group</th><th>Model</th><th class="num">Rate</th><th class="num">Requests</th><th class="num">Input</th><th class="num">Output</th><th class="num">Cache write</th><th class="num">Cache read</th><th class="num">Standard</th><th class="num">Charged</th></tr>
+  {{range .Invoice.Content.Usage}}
+  <tr><td>{{if .GroupName}}{{.GroupName}}{{else}}-{{end}}</td><td>{{.Model}}</td><td class="num">×{{rate .RateMultiplier}}</td><td class="num">{{.RequestCount}}</td><td class="num">{{.InputTokens}}</td><td class="num">{{.OutputTokens}}</td><td class="num">{{.CacheCreationTokens}}</td><td class="num">{{.CacheReadTokens}}</td><td class="num">{{cost .TotalCost}}</td><td class="num">{{cost .ActualCost}}</td></tr>
+  {{end}}
+</table>
+{{else}}<div class="muted">No usage in this period.</div>{{end}}
+
+<div class="footer muted">
+  This invoice was issued automatically and cannot be modified.
+  Content digest (SHA-256): {{.Invoice.ContentHash}}{{if not .Verified}} — <strong>digest mismatch</strong>{{end}}
+</div>
+</body>
+</html>
+`))
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var ErrModelFallbackInvalid = infraerrors.BadRequest("MODEL_FALLBACK_INVALID", "invalid model fallback configuration")
+
+// ModelFallbackTarget 分组模型降级链中的一个目标
+type ModelFallbackTarget = domain.ModelFallbackTarget
+
+// maxModelFallbackTargets 单条降级链允许的最大目标数
+const maxModelFallbackTargets = 8
+
+// ModelFallbackChain 记录一次请求在分组降级链上的推进状态。
+// 降级目标只取自 API Key 原始分组的配置，切换到其他分组后不会再展开目标分组的降级链，避免级联与环路。
+type ModelFallbackChain struct {
+	requestedModel string
+	targets        []ModelFallbackTarget
+	next           int
+	current        *ModelFallbackTarget
+}
+
+// NewModelFallbackChain 根据分组配置为请求模型创建降级链；无匹配规则时返回 nil
+func NewModelFallbackChain(group *Group, requestedModel string) *ModelFallbackChain {
+	if group == nil {
+		return nil
+	}
+	targets := group.GetModelFallbackChain(requestedModel)
+	if len(targets) == 0 {
+		return nil
+	}
+	return &ModelFallbackChain{requestedModel: requestedModel, targets: targets}
+}
+
+// RequestedModel 返回客户端请求的原始模型
+func (c *ModelFallbackChain) RequestedModel() string {
+	if c == nil {
+		return ""
+	}
+	return c.requestedModel
+}
+
+// Next 推进到下一个降级目标；降级链耗尽时返回 false
+func (c *ModelFallbackChain) Next() (ModelFallbackTarget, bool) {
+	if c == nil || c.next >= len(c.targets) {
+		return ModelFallbackTarget{}, false
+	}
+	target := c.targets[c.next]
+	c.next++
+	c.current = &target
+	return target, true
+}
+
+// Active 表示当前是否正在使用降级目标
+func (c *ModelFallbackChain) Active() bool {
+	return c != nil && c.current != nil
+}
+
+// ApplyToResult 在降级成功后改写转发结果：Model 恢复为请求模型，ServedModel 记录实际服务的模型
+func (c *ModelFallbackChain) ApplyToResult(result *ForwardResult) {
+	if !c.Active() || result == nil {
+		return
+	}
+	served := strings.TrimSpace(result.Model)
+	if served == "" {
+		served = c.current.Model
+	}
+	result.ServedModel = served
+	result.Model = c.requestedModel
+}
+
+// ApplyToOpenAIResult 与 ApplyToResult 相同，用于 OpenAI 网关的转发结果；
+// 实际服务的模型优先取 BillingModel（Messages 转换路径映射后的上游模型）
+func (c *ModelFallbackChain) ApplyToOpenAIResult(result *OpenAIForwardResult) {
+	if !c.Active() || result == nil {
+		return
+	}
+	served := strings.TrimSpace(result.BillingModel)
+	if served == "" {
+		served = strings.TrimSpace(result.Model)
+	}
+	if served == "" {
+		served = c.current.Model
+	}
+	result.ServedModel = served
+	result.Model = c.requestedModel
+}
+
+// IsModelFallbackTrigger 判断失败是否应触发模型降级：
+// 调度失败（failoverErr 为 nil，即无可调度账号或全部限流）、上游 429 限流、529/overloaded 过载。
+func IsModelFallbackTrigger(failoverErr *UpstreamFailoverError) bool {
+	if failoverErr == nil {
+		return true
+	}
+	switch failoverErr.StatusCode {
+	case http.StatusTooManyRequests, 529:
+		return true
+	case http.StatusServiceUnavailable:
+		return bytes.Contains(bytes.ToLower(failoverErr.ResponseBody), []byte("overloaded"))
+	}
+	return false
+}
+
+// normalizeModelFallbacks 清理降级链配置：去除空白、空模式与空目标，保留目标顺序
+func normalizeModelFallbacks(chains map[string][]ModelFallbackTarget) (map[string][]ModelFallbackTarget, error) {
+	if chains == nil {
+		return nil, nil
+	}
+	normalized := make(map[string][]ModelFallbackTarget, len(chains))
+	for pattern, targets := range chains {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			return nil, ErrModelFallbackInvalid
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		if len(targets) > maxModelFallbackTargets {
+			return nil, ErrModelFallbackInvalid
+		}
+		cleaned := make([]ModelFallbackTarget, 0, len(targets))
+		for _, target := range targets {
+			model := strings.TrimSpace(target.Model)
+			if model == "" || strings.Contains(model, "*") {
+				return nil, ErrModelFallbackInvalid
+			}
+			if target.GroupID != nil && *target.GroupID <= 0 {
+				return nil, ErrModelFallbackInvalid
+			}
+			cleaned = append(cleaned, ModelFallbackTarget{Model: model, GroupID: target.GroupID})
+		}
+		normalized[pattern] = cleaned
+	}
+	return normalized, nil
+}
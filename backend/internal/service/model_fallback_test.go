@@ -0,0 +1,120 @@
+//go:build unit
+
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupGetModelFallbackChain(t *testing.T) {
+	otherGroup := int64(7)
+	group := &Group{
+		ModelFallbacks: map[string][]ModelFallbackTarget{
+			"claude-opus-4-6": {{Model: "claude-opus-4-5"}},
+			"claude-opus-*":   {{Model: "claude-sonnet-4-5"}, {Model: "claude-sonnet-4-5", GroupID: &otherGroup}},
+			"claude-*":        {{Model: "claude-haiku-4-5"}},
+		},
+	}
+
+	require.Equal(t, "claude-opus-4-5", group.GetModelFallbackChain("claude-opus-4-6")[0].Model)
+
+	// 通配符取最长前缀
+	chain := group.GetModelFallbackChain("claude-opus-4-1")
+	require.Len(t, chain, 2)
+	require.Equal(t, "claude-sonnet-4-5", chain[0].Model)
+	require.Equal(t, otherGroup, *chain[1].GroupID)
+
+	require.Equal(t, "claude-haiku-4-5", group.GetModelFallbackChain("claude-sonnet-4-5")[0].Model)
+	require.Nil(t, group.GetModelFallbackChain("gpt-5"))
+	require.Nil(t, (&Group{}).GetModelFallbackChain("claude-opus-4-6"))
+}
+
+func TestModelFallbackChainApplyToResult(t *testing.T) {
+	group := &Group{ModelFallbacks: map[string][]ModelFallbackTarget{
+		"claude-opus-*": {{Model: "claude-sonnet-4-5"}, {Model: "claude-haiku-4-5"}},
+	}}
+	require.Nil(t, NewModelFallbackChain(group, "gpt-5"))
+
+	chain := NewModelFallbackChain(group, "claude-opus-4-6")
+	require.NotNil(t, chain)
+	require.False(t, chain.Active())
+
+	// 未降级时不改写结果
+	result := &ForwardResult{Model: "claude-opus-4-6"}
+	chain.ApplyToResult(result)
+	require.Equal(t, "claude-opus-4-6", result.BillingModel())
+	require.Empty(t, result.ServedModel)
+
+	target, ok := chain.Next()
+	require.True(t, ok)
+	require.Equal(t, "claude-sonnet-4-5", target.Model)
+	target, ok = chain.Next()
+	require.True(t, ok)
+	require.Equal(t, "claude-haiku-4-5", target.Model)
+	_, ok = chain.Next()
+	require.False(t, ok)
+
+	result = &ForwardResult{Model: "claude-haiku-4-5"}
+	chain.ApplyToResult(result)
+	require.Equal(t, "claude-opus-4-6", result.Model)
+	require.Equal(t, "claude-haiku-4-5", result.ServedModel)
+	require.Equal(t, "claude-haiku-4-5", result.BillingModel())
+}
+
+func TestModelFallbackChainApplyToOpenAIResult(t *testing.T) {
+	chain := NewModelFallbackChain(&Group{ModelFallbacks: map[string][]ModelFallbackTarget{
+		"gpt-5.1*": {{Model: "gpt-5-mini"}},
+	}}, "gpt-5.1-codex")
+	require.NotNil(t, chain)
+
+	result := &OpenAIForwardResult{Model: "gpt-5.1-codex"}
+	chain.ApplyToOpenAIResult(result)
+	require.Empty(t, result.ServedModel)
+
+	_, ok := chain.Next()
+	require.True(t, ok)
+
+	// Messages 转换路径：BillingModel 为映射后的上游模型
+	result = &OpenAIForwardResult{Model: "gpt-5-mini", BillingModel: "gpt-5-mini-2025"}
+	chain.ApplyToOpenAIResult(result)
+	require.Equal(t, "gpt-5.1-codex", result.Model)
+	require.Equal(t, "gpt-5-mini-2025", result.ServedModel)
+
+	result = &OpenAIForwardResult{}
+	chain.ApplyToOpenAIResult(result)
+	require.Equal(t, "gpt-5-mini", result.ServedModel)
+}
+
+func TestIsModelFallbackTrigger(t *testing.T) {
+	require.True(t, IsModelFallbackTrigger(nil))
+	require.True(t, IsModelFallbackTrigger(&UpstreamFailoverError{StatusCode: 529}))
+	require.True(t, IsModelFallbackTrigger(&UpstreamFailoverError{StatusCode: http.StatusTooManyRequests}))
+	require.True(t, IsModelFallbackTrigger(&UpstreamFailoverError{
+		StatusCode:   http.StatusServiceUnavailable,
+		ResponseBody: []byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`),
+	}))
+	require.False(t, IsModelFallbackTrigger(&UpstreamFailoverError{StatusCode: http.StatusServiceUnavailable}))
+	require.False(t, IsModelFallbackTrigger(&UpstreamFailoverError{StatusCode: http.StatusUnauthorized}))
+}
+
+func TestNormalizeModelFallbacks(t *testing.T) {
+	groupID := int64(3)
+	normalized, err := normalizeModelFallbacks(map[string][]ModelFallbackTarget{
+		" claude-opus-* ": {{Model: " claude-sonnet-4-5 "}, {Model: "claude-sonnet-4-5", GroupID: &groupID}},
+		"claude-haiku-*":  {},
+	})
+	require.NoError(t, err)
+	require.Len(t, normalized, 1)
+	require.Equal(t, "claude-sonnet-4-5", normalized["claude-opus-*"][0].Model)
+	require.Equal(t, groupID, *normalized["claude-opus-*"][1].GroupID)
+
+	_, err = normalizeModelFallbacks(map[string][]ModelFallbackTarget{"claude-*": {{Model: "claude-*"}}})
+	require.ErrorIs(t, err, ErrModelFallbackInvalid)
+
+	zero := int64(0)
+	_, err = normalizeModelFallbacks(map[string][]ModelFallbackTarget{"claude-*": {{Model: "m", GroupID: &zero}}})
+	require.ErrorIs(t, err, ErrModelFallbackInvalid)
+}
@@ -330,8 +330,16 @@ func (s *defaultOpenAIAccountScheduler) selectBySessionHash(
 		_ = s.service.deleteStickySessionAccountID(ctx, req.GroupID, sessionHash)
 		return nil, nil
 	}
+	// 熔断中的粘性账号交给负载均衡层，保留绑定待恢复后继续使用
+	if !s.service.circuitBreaker.AllowAccount(ctx, account) {
+		return nil, nil
+	}
+	// batch 请求的负载阈值同样适用于粘性会话：超过阈值时交给负载均衡层，保留绑定
+	if !s.service.isStickyWithinLoadLimit(ctx, account, s.service.selectionLoadLimit(ctx)) {
+		return nil, nil
+	}
 
-	result, acquireErr := s.service.tryAcquireAccountSlot(ctx, accountID, account.Concurrency)
+	result, acquireErr := s.service.tryAcquireAccountModelSlot(ctx, account, req.RequestedModel)
 	if acquireErr == nil && result.Acquired {
 		_ = s.service.refreshStickySessionTTL(ctx, req.GroupID, sessionHash, s.service.openAIWSSessionStickyTTL())
 		return &AccountSelectionResult{
@@ -685,13 +693,18 @@ func (s *defaultOpenAIAccountScheduler) selectByLoadBalance(
 	rankedCandidates := selectTopKOpenAICandidates(candidates, topK)
 	selectionOrder := buildOpenAIWeightedSelectionOrder(rankedCandidates, req)
 
+	// batch 优先级请求只直接占用负载率低于阈值的账号，其余账号仅作为等待计划候选
+	loadLimit := s.service.selectionLoadLimit(ctx)
 	for i := 0; i < len(selectionOrder); i++ {
 		candidate := selectionOrder[i]
+		if candidate.loadInfo.LoadRate >= loadLimit {
+			continue
+		}
 		fresh := s.service.resolveFreshSchedulableOpenAIAccount(ctx, candidate.account, req.RequestedModel)
 		if fresh == nil || !s.isAccountTransportCompatible(fresh, req.RequiredTransport) {
 			continue
 		}
-		result, acquireErr := s.service.tryAcquireAccountSlot(ctx, fresh.ID, fresh.Concurrency)
+		result, acquireErr := s.service.tryAcquireAccountModelSlot(ctx, fresh, req.RequestedModel)
 		if acquireErr != nil {
 			return nil, len(candidates), topK, loadSkew, acquireErr
 		}
@@ -822,15 +835,26 @@ func (s *OpenAIGatewayService) SelectAccountWithScheduler(
 		}
 	}
 
-	return scheduler.Select(ctx, OpenAIAccountScheduleRequest{
-		GroupID:            groupID,
-		SessionHash:        sessionHash,
-		StickyAccountID:    stickyAccountID,
-		PreviousResponseID: previousResponseID,
-		RequestedModel:     requestedModel,
-		RequiredTransport:  requiredTransport,
-		ExcludedIDs:        excludedIDs,
-	})
+	for {
+		selection, decision, err := scheduler.Select(ctx, OpenAIAccountScheduleRequest{
+			GroupID:            groupID,
+			SessionHash:        sessionHash,
+			StickyAccountID:    stickyAccountID,
+			PreviousResponseID: previousResponseID,
+			RequestedModel:     requestedModel,
+			RequiredTransport:  requiredTransport,
+			ExcludedIDs:        excludedIDs,
+		})
+		if err != nil {
+			return nil, decision, err
+		}
+		if s.claimSelectionCircuitProbe(ctx, selection) {
+			applyPriorityToWaitPlan(ctx, s.schedulingConfig(), selection)
+			return selection, decision, nil
+		}
+		// 半开探测名额已被其它请求占用：排除该账号重新调度
+		excludedIDs = excludeCircuitProbeAccount(excludedIDs, selection.Account.ID)
+	}
 }
 
 func (s *OpenAIGatewayService) ReportOpenAIAccountScheduleResult(accountID int64, success bool, firstTokenMs *int) {
@@ -136,6 +136,7 @@ func newOpenAIRecordUsageServiceForTest(usageRepo UsageLogRepository, userRepo U
 		subRepo,
 		rateRepo,
 		nil,
+		nil,
 		cfg,
 		nil,
 		nil,
@@ -891,6 +892,39 @@ func TestOpenAIGatewayServiceRecordUsage_UsesBillingModelAndMetadataFields(t *te
 	require.Equal(t, 1, userRepo.deductCalls)
 }
 
+func TestOpenAIGatewayServiceRecordUsage_ModelFallbackLogsRequestedModelAndBillsServedModel(t *testing.T) {
+	record := func(result *OpenAIForwardResult) *UsageLog {
+		usageRepo := &openAIRecordUsageLogRepoStub{inserted: true}
+		svc := newOpenAIRecordUsageServiceForTest(usageRepo, &openAIRecordUsageUserRepoStub{}, &openAIRecordUsageSubRepoStub{}, nil)
+		err := svc.RecordUsage(context.Background(), &OpenAIRecordUsageInput{
+			Result:  result,
+			APIKey:  &APIKey{ID: 10, GroupID: i64p(11), Group: &Group{ID: 11, RateMultiplier: 1}},
+			User:    &User{ID: 20},
+			Account: &Account{ID: 30},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, usageRepo.lastLog)
+		return usageRepo.lastLog
+	}
+	usage := OpenAIUsage{InputTokens: 1000, OutputTokens: 500}
+
+	direct := record(&OpenAIForwardResult{RequestID: "resp_direct", Model: "gpt-5-mini", Usage: usage})
+	fallback := &OpenAIForwardResult{RequestID: "resp_fallback", Model: "gpt-5-mini", Usage: usage}
+	chain := NewModelFallbackChain(&Group{ModelFallbacks: map[string][]ModelFallbackTarget{
+		"gpt-5.1": {{Model: "gpt-5-mini"}},
+	}}, "gpt-5.1")
+	_, ok := chain.Next()
+	require.True(t, ok)
+	chain.ApplyToOpenAIResult(fallback)
+	log := record(fallback)
+
+	require.Equal(t, "gpt-5.1", log.Model)
+	require.NotNil(t, log.ServedModel)
+	require.Equal(t, "gpt-5-mini", *log.ServedModel)
+	require.Equal(t, direct.TotalCost, log.TotalCost)
+	require.Nil(t, direct.ServedModel)
+}
+
 func TestOpenAIGatewayServiceRecordUsage_SubscriptionBillingSetsSubscriptionFields(t *testing.T) {
 	usageRepo := &openAIRecordUsageLogRepoStub{inserted: true}
 	userRepo := &openAIRecordUsageUserRepoStub{}
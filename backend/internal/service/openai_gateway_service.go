@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
 	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
@@ -216,6 +217,8 @@ type OpenAIForwardResult struct {
 	// This is set by the Anthropic Messages conversion path where
 	// the mapped upstream model differs from the client-facing model.
 	BillingModel string
+	// ServedModel 分组模型降级时实际服务的模型（为空表示由 Model 直接服务），计费按此模型
+	ServedModel string
 	// ServiceTier records the OpenAI Responses API service tier, e.g. "priority" / "flex".
 	// Nil means the request did not specify a recognized tier.
 	ServiceTier *string
@@ -305,6 +308,7 @@ type OpenAIGatewayService struct {
 	usageBillingRepo      UsageBillingRepository
 	userRepo              UserRepository
 	userSubRepo           UserSubscriptionRepository
+	groupRepo             GroupRepository
 	cache                 GatewayCache
 	cfg                   *config.Config
 	codexDetector         CodexClientRestrictionDetector
@@ -315,7 +319,9 @@ type OpenAIGatewayService struct {
 	billingCacheService   *BillingCacheService
 	userGroupRateResolver *userGroupRateResolver
 	httpUpstream          HTTPUpstream
+	circuitBreaker        *UpstreamCircuitBreaker // 上游熔断（可选依赖）
 	deferredService       *DeferredService
+	usageNotifier         UsageBillingNotifier
 	openAITokenProvider   *OpenAITokenProvider
 	toolCorrector         *CodexToolCorrector
 	openaiWSResolver      OpenAIWSProtocolResolver
@@ -344,6 +350,7 @@ func NewOpenAIGatewayService(
 	userRepo UserRepository,
 	userSubRepo UserSubscriptionRepository,
 	userGroupRateRepo UserGroupRateRepository,
+	groupRepo GroupRepository,
 	cache GatewayCache,
 	cfg *config.Config,
 	schedulerSnapshot *SchedulerSnapshotService,
@@ -361,6 +368,7 @@ func NewOpenAIGatewayService(
 		usageBillingRepo:    usageBillingRepo,
 		userRepo:            userRepo,
 		userSubRepo:         userSubRepo,
+		groupRepo:           groupRepo,
 		cache:               cache,
 		cfg:                 cfg,
 		codexDetector:       NewOpenAICodexClientRestrictionDetector(cfg),
@@ -402,9 +410,15 @@ func (s *OpenAIGatewayService) billingDeps() *billingDeps {
 		userSubRepo:         s.userSubRepo,
 		billingCacheService: s.billingCacheService,
 		deferredService:     s.deferredService,
+		usageNotifier:       s.usageNotifier,
 	}
 }
 
+// SetUsageNotifier 设置扣费后的用户提醒回调（可选依赖）
+func (s *OpenAIGatewayService) SetUsageNotifier(notifier UsageBillingNotifier) {
+	s.usageNotifier = notifier
+}
+
 // CloseOpenAIWSPool 关闭 OpenAI WebSocket 连接池的后台 worker 和空闲连接。
 // 应在应用优雅关闭时调用。
 func (s *OpenAIGatewayService) CloseOpenAIWSPool() {
@@ -1035,6 +1049,21 @@ func (s *OpenAIGatewayService) ExtractSessionID(c *gin.Context, body []byte) str
 	return sessionID
 }
 
+// ResolveGroupByID 解析分组（优先使用请求上下文中的分组），供模型降级切换目标分组
+func (s *OpenAIGatewayService) ResolveGroupByID(ctx context.Context, groupID int64) (*Group, error) {
+	if group, ok := ctx.Value(ctxkey.Group).(*Group); ok && IsGroupContextValid(group) && group.ID == groupID {
+		return group, nil
+	}
+	if s.groupRepo == nil {
+		return nil, errors.New("group repository not configured")
+	}
+	group, err := s.groupRepo.GetByIDLite(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("get group failed: %w", err)
+	}
+	return group, nil
+}
+
 // GenerateSessionHash generates a sticky-session hash for OpenAI requests.
 //
 // Priority:
@@ -1118,7 +1147,17 @@ func (s *OpenAIGatewayService) SelectAccountForModel(ctx context.Context, groupI
 // SelectAccountForModelWithExclusions selects an account supporting the requested model while excluding specified accounts.
 // SelectAccountForModelWithExclusions 选择支持指定模型的账号，同时排除指定的账号。
 func (s *OpenAIGatewayService) SelectAccountForModelWithExclusions(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*Account, error) {
-	return s.selectAccountForModelWithExclusions(ctx, groupID, sessionHash, requestedModel, excludedIDs, 0)
+	for {
+		account, err := s.selectAccountForModelWithExclusions(ctx, groupID, sessionHash, requestedModel, excludedIDs, 0)
+		if err != nil {
+			return nil, err
+		}
+		// 熔断半开的账号仅在最终选中时占用探测名额，名额已被其它请求占用则重新选择
+		if s.circuitBreaker.ClaimProbe(ctx, account) {
+			return account, nil
+		}
+		excludedIDs = excludeCircuitProbeAccount(excludedIDs, account.ID)
+	}
 }
 
 func (s *OpenAIGatewayService) selectAccountForModelWithExclusions(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}, stickyAccountID int64) (*Account, error) {
@@ -1198,6 +1237,9 @@ func (s *OpenAIGatewayService) tryStickySessionHit(ctx context.Context, groupID
 	if requestedModel != "" && !account.IsModelSupported(requestedModel) {
 		return nil
 	}
+	if !s.circuitBreaker.AllowAccount(ctx, account) {
+		return nil
+	}
 
 	// 刷新会话 TTL 并返回账号
 	// Refresh session TTL and return account
@@ -1277,7 +1319,32 @@ func (s *OpenAIGatewayService) isBetterAccount(candidate, current *Account) bool
 
 // SelectAccountWithLoadAwareness selects an account with load-awareness and wait plan.
 func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+	for {
+		result, err := s.selectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+		if err != nil {
+			return nil, err
+		}
+		if s.claimSelectionCircuitProbe(ctx, result) {
+			applyPriorityToWaitPlan(ctx, s.schedulingConfig(), result)
+			return result, nil
+		}
+		excludedIDs = excludeCircuitProbeAccount(excludedIDs, result.Account.ID)
+	}
+}
+
+// claimSelectionCircuitProbe 为等待计划选中的账号占用熔断半开探测名额（已获取槽位的账号在获取时已占用）；
+// 名额已被其它请求占用时返回 false，调用方应排除该账号重新选择
+func (s *OpenAIGatewayService) claimSelectionCircuitProbe(ctx context.Context, result *AccountSelectionResult) bool {
+	if result == nil || result.Acquired || result.Account == nil {
+		return true
+	}
+	return s.circuitBreaker.ClaimProbe(ctx, result.Account)
+}
+
+func (s *OpenAIGatewayService) selectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
 	cfg := s.schedulingConfig()
+	// batch 优先级请求只使用负载率低于阈值的账号
+	loadLimit := s.selectionLoadLimit(ctx)
 	var stickyAccountID int64
 	if sessionHash != "" && s.cache != nil {
 		if accountID, err := s.getStickySessionAccountID(ctx, groupID, sessionHash); err == nil {
@@ -1289,7 +1356,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 		if err != nil {
 			return nil, err
 		}
-		result, err := s.tryAcquireAccountSlot(ctx, account.ID, account.Concurrency)
+		result, err := s.tryAcquireAccountModelSlot(ctx, account, requestedModel)
 		if err == nil && result.Acquired {
 			return &AccountSelectionResult{
 				Account:     account,
@@ -1349,8 +1416,10 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 					_ = s.deleteStickySessionAccountID(ctx, groupID, sessionHash)
 				}
 				if !clearSticky && account.IsSchedulable() && account.IsOpenAI() &&
-					(requestedModel == "" || account.IsModelSupported(requestedModel)) {
-					result, err := s.tryAcquireAccountSlot(ctx, accountID, account.Concurrency)
+					(requestedModel == "" || account.IsModelSupported(requestedModel)) &&
+					s.circuitBreaker.AllowAccount(ctx, account) &&
+					s.isStickyWithinLoadLimit(ctx, account, loadLimit) {
+					result, err := s.tryAcquireAccountModelSlot(ctx, account, requestedModel)
 					if err == nil && result.Acquired {
 						_ = s.refreshStickySessionTTL(ctx, groupID, sessionHash, openaiStickySessionTTL)
 						return &AccountSelectionResult{
@@ -1417,7 +1486,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 			if fresh == nil {
 				continue
 			}
-			result, err := s.tryAcquireAccountSlot(ctx, fresh.ID, fresh.Concurrency)
+			result, err := s.tryAcquireAccountModelSlot(ctx, fresh, requestedModel)
 			if err == nil && result.Acquired {
 				if sessionHash != "" {
 					_ = s.setStickySessionAccountID(ctx, groupID, sessionHash, fresh.ID, openaiStickySessionTTL)
@@ -1436,7 +1505,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 			if loadInfo == nil {
 				loadInfo = &AccountLoadInfo{AccountID: acc.ID}
 			}
-			if loadInfo.LoadRate < 100 {
+			if loadInfo.LoadRate < loadLimit {
 				available = append(available, accountWithLoad{
 					account:  acc,
 					loadInfo: loadInfo,
@@ -1471,7 +1540,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 				if fresh == nil {
 					continue
 				}
-				result, err := s.tryAcquireAccountSlot(ctx, fresh.ID, fresh.Concurrency)
+				result, err := s.tryAcquireAccountModelSlot(ctx, fresh, requestedModel)
 				if err == nil && result.Acquired {
 					if sessionHash != "" {
 						_ = s.setStickySessionAccountID(ctx, groupID, sessionHash, fresh.ID, openaiStickySessionTTL)
@@ -1507,10 +1576,14 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 	return nil, ErrNoAvailableAccounts
 }
 
+// listSchedulableAccounts 列出可调度的 OpenAI 账号，并过滤上游熔断中的账号（调度器各层候选均来自此列表）
 func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, groupID *int64) ([]Account, error) {
 	if s.schedulerSnapshot != nil {
 		accounts, _, err := s.schedulerSnapshot.ListSchedulableAccounts(ctx, groupID, PlatformOpenAI, false)
-		return accounts, err
+		if err != nil {
+			return nil, err
+		}
+		return s.circuitBreaker.FilterAccounts(ctx, accounts), nil
 	}
 	var accounts []Account
 	var err error
@@ -1524,14 +1597,17 @@ func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, grou
 	if err != nil {
 		return nil, fmt.Errorf("query accounts failed: %w", err)
 	}
-	return accounts, nil
+	return s.circuitBreaker.FilterAccounts(ctx, accounts), nil
 }
 
-func (s *OpenAIGatewayService) tryAcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int) (*AcquireResult, error) {
+// tryAcquireAccountModelSlot 获取账号槽位，并按请求模型命中的 model_limits 同时占用模型级槽位；
+// 熔断半开的账号在获取槽位后占用探测名额
+func (s *OpenAIGatewayService) tryAcquireAccountModelSlot(ctx context.Context, account *Account, requestedModel string) (*AcquireResult, error) {
 	if s.concurrencyService == nil {
 		return &AcquireResult{Acquired: true, ReleaseFunc: func() {}}, nil
 	}
-	return s.concurrencyService.AcquireAccountSlot(ctx, accountID, maxConcurrency)
+	result, err := s.concurrencyService.AcquireAccountModelSlot(ctx, account.ID, s.AccountSlotLimit(ctx, account), account.MatchModelLimit(requestedModel))
+	return s.circuitBreaker.claimAcquired(ctx, account, result, err)
 }
 
 func (s *OpenAIGatewayService) resolveFreshSchedulableOpenAIAccount(ctx context.Context, account *Account, requestedModel string) *Account {
@@ -1583,6 +1659,10 @@ func (s *OpenAIGatewayService) schedulingConfig() config.GatewaySchedulingConfig
 		StickySessionWaitTimeout: 45 * time.Second,
 		FallbackWaitTimeout:      30 * time.Second,
 		FallbackMaxWaiting:       100,
+		InteractiveReservedRatio: 0.2,
+		BatchLoadThreshold:       70,
+		BatchWaitTimeout:         300 * time.Second,
+		BatchMaxWaiting:          200,
 		LoadBatchEnabled:         true,
 		SlotCleanupInterval:      30 * time.Second,
 	}
@@ -2569,6 +2649,8 @@ func (s *OpenAIGatewayService) buildUpstreamRequestOpenAIPassthrough(
 		req.Header.Set("content-type", "application/json")
 	}
 
+	applyRequestHeaderTransforms(ctx, req)
+
 	return req, nil
 }
 
@@ -2952,6 +3034,8 @@ func (s *OpenAIGatewayService) buildUpstreamRequest(ctx context.Context, c *gin.
 		req.Header.Set("content-type", "application/json")
 	}
 
+	applyRequestHeaderTransforms(ctx, req)
+
 	return req, nil
 }
 
@@ -4059,6 +4143,7 @@ type OpenAIRecordUsageInput struct {
 	Subscription       *UserSubscription
 	InboundEndpoint    string
 	UpstreamEndpoint   string
+	SessionID          string // 粘性会话标识，用于会话维度统计（可选）
 	UserAgent          string // 请求的 User-Agent
 	IPAddress          string // 请求的客户端 IP 地址
 	RequestPayloadHash string
@@ -4109,6 +4194,12 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	if result.BillingModel != "" {
 		billingModel = result.BillingModel
 	}
+	// 模型降级：usage 记录请求模型，按实际服务的模型计费
+	logModel := billingModel
+	if result.ServedModel != "" {
+		billingModel = result.ServedModel
+		logModel = result.Model
+	}
 	serviceTier := ""
 	if result.ServiceTier != nil {
 		serviceTier = strings.TrimSpace(*result.ServiceTier)
@@ -4134,11 +4225,13 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		APIKeyID:              apiKey.ID,
 		AccountID:             account.ID,
 		RequestID:             requestID,
-		Model:                 billingModel,
+		Model:                 logModel,
+		ServedModel:           optionalTrimmedStringPtr(result.ServedModel),
 		ServiceTier:           result.ServiceTier,
 		ReasoningEffort:       result.ReasoningEffort,
 		InboundEndpoint:       optionalTrimmedStringPtr(input.InboundEndpoint),
 		UpstreamEndpoint:      optionalTrimmedStringPtr(input.UpstreamEndpoint),
+		SessionID:             NormalizeUsageSessionID(input.SessionID),
 		InputTokens:           actualInputTokens,
 		OutputTokens:          result.Usage.OutputTokens,
 		CacheCreationTokens:   result.Usage.CacheCreationInputTokens,
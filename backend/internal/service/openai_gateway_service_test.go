@@ -1452,6 +1452,30 @@ func TestOpenAIBuildUpstreamRequestCompactForcesJSONAcceptForOAuth(t *testing.T)
 	require.NotEmpty(t, req.Header.Get("Session_Id"))
 }
 
+func TestOpenAIBuildUpstreamRequestAppliesGroupHeaderTransforms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", bytes.NewReader([]byte(`{"model":"gpt-5"}`)))
+
+	ctx := WithRequestHeaderTransforms(c.Request.Context(), []RequestHeaderTransform{
+		{Name: "X-Team", Value: "team-a"},
+		{Name: "Authorization", Value: "Bearer stolen"},
+	})
+	svc := &OpenAIGatewayService{}
+	account := &Account{Type: AccountTypeAPIKey}
+
+	req, err := svc.buildUpstreamRequest(ctx, c, account, []byte(`{"model":"gpt-5"}`), "token", false, "", false)
+	require.NoError(t, err)
+	require.Equal(t, "team-a", req.Header.Get("X-Team"))
+	require.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+
+	req, err = svc.buildUpstreamRequestOpenAIPassthrough(ctx, c, account, []byte(`{"model":"gpt-5"}`), "token")
+	require.NoError(t, err)
+	require.Equal(t, "team-a", req.Header.Get("X-Team"))
+	require.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+}
+
 func TestOpenAIBuildUpstreamRequestPreservesCompactPathForAPIKeyBaseURL(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	rec := httptest.NewRecorder()
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 )
 
 // GetAccountAvailabilityStats returns current account availability stats.
@@ -41,6 +43,8 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 	now := time.Now()
 	collectedAt := now
 
+	circuitStatuses := s.loadAccountCircuitStatuses(ctx, accounts)
+
 	platform := make(map[string]*PlatformAvailability)
 	group := make(map[int64]*GroupAvailability)
 	account := make(map[int64]*AccountAvailability)
@@ -65,7 +69,10 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 			isOverloaded = false
 		}
 
-		isAvailable := acc.Status == StatusActive && acc.Schedulable && !isRateLimited && !isOverloaded && !isTempUnsched
+		circuit := circuitStatuses[acc.ID]
+		isCircuitOpen := circuit != nil && circuit.State == CircuitStateOpen
+
+		isAvailable := acc.Status == StatusActive && acc.Schedulable && !isRateLimited && !isOverloaded && !isTempUnsched && !isCircuitOpen
 
 		if acc.Platform != "" {
 			if _, ok := platform[acc.Platform]; !ok {
@@ -150,6 +157,12 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 		if isTempUnsched && acc.TempUnschedulableUntil != nil {
 			item.TempUnschedulableUntil = acc.TempUnschedulableUntil
 		}
+		if circuit != nil {
+			item.CircuitState = circuit.State
+			item.CircuitScope = circuit.Scope
+			item.CircuitHost = circuit.Host
+			item.CircuitOpenUntil = circuit.OpenUntil
+		}
 
 		account[acc.ID] = item
 	}
@@ -157,6 +170,25 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 	return platform, group, account, &collectedAt, nil
 }
 
+// loadAccountCircuitStatuses 批量读取账号的上游熔断状态；熔断器未启用或读取失败时返回空
+func (s *OpsService) loadAccountCircuitStatuses(ctx context.Context, accounts []Account) map[int64]*AccountCircuitStatus {
+	if !s.circuitBreaker.Enabled() || len(accounts) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		if acc.ID > 0 {
+			ids = append(ids, acc.ID)
+		}
+	}
+	statuses, err := s.circuitBreaker.GetAccountStatuses(ctx, ids)
+	if err != nil {
+		logger.LegacyPrintf("service.ops", "[OpsAvailability] load circuit statuses failed: %v", err)
+		return nil
+	}
+	return statuses
+}
+
 type OpsAccountAvailability struct {
 	Group       *GroupAvailability
 	Accounts    map[int64]*AccountAvailability
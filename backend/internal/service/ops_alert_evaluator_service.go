@@ -674,8 +674,7 @@ func (s *OpsAlertEvaluatorService) maybeSendAlertEmail(ctx context.Context, runt
 	// Apply/update rate limiter.
 	s.emailLimiter.SetLimit(emailCfg.Alert.RateLimitPerHour)
 
-	subject := fmt.Sprintf("[Ops Alert][%s] %s", strings.TrimSpace(rule.Severity), strings.TrimSpace(rule.Name))
-	body := buildOpsAlertEmailBody(rule, event)
+	data := buildOpsAlertEmailData(rule, event)
 
 	anySent := false
 	for _, to := range emailCfg.Alert.Recipients {
@@ -686,7 +685,7 @@ func (s *OpsAlertEvaluatorService) maybeSendAlertEmail(ctx context.Context, runt
 		if !s.emailLimiter.Allow(time.Now().UTC()) {
 			continue
 		}
-		if err := s.emailService.SendEmail(ctx, addr, subject, body); err != nil {
+		if err := s.emailService.SendTemplateEmail(ctx, addr, EmailTemplateOpsAlert, "", data); err != nil {
 			// Ignore per-recipient failures; continue best-effort.
 			continue
 		}
@@ -699,11 +698,10 @@ func (s *OpsAlertEvaluatorService) maybeSendAlertEmail(ctx context.Context, runt
 	return anySent
 }
 
-func buildOpsAlertEmailBody(rule *OpsAlertRule, event *OpsAlertEvent) string {
+func buildOpsAlertEmailData(rule *OpsAlertRule, event *OpsAlertEvent) OpsAlertEmailData {
 	if rule == nil || event == nil {
-		return ""
+		return OpsAlertEmailData{}
 	}
-	metric := strings.TrimSpace(rule.MetricType)
 	value := "-"
 	threshold := fmt.Sprintf("%.2f", rule.Threshold)
 	if event.MetricValue != nil {
@@ -712,24 +710,17 @@ func buildOpsAlertEmailBody(rule *OpsAlertRule, event *OpsAlertEvent) string {
 	if event.ThresholdValue != nil {
 		threshold = fmt.Sprintf("%.2f", *event.ThresholdValue)
 	}
-	return fmt.Sprintf(`
-<h2>Ops Alert</h2>
-<p><b>Rule</b>: %s</p>
-<p><b>Severity</b>: %s</p>
-<p><b>Status</b>: %s</p>
-<p><b>Metric</b>: %s %s %s</p>
-<p><b>Fired at</b>: %s</p>
-<p><b>Description</b>: %s</p>
-`,
-		htmlEscape(rule.Name),
-		htmlEscape(rule.Severity),
-		htmlEscape(event.Status),
-		htmlEscape(metric),
-		htmlEscape(rule.Operator),
-		htmlEscape(fmt.Sprintf("%s (threshold %s)", value, threshold)),
-		event.FiredAt.Format(time.RFC3339),
-		htmlEscape(event.Description),
-	)
+	return OpsAlertEmailData{
+		RuleName:    strings.TrimSpace(rule.Name),
+		Severity:    strings.TrimSpace(rule.Severity),
+		Status:      event.Status,
+		Metric:      strings.TrimSpace(rule.MetricType),
+		Operator:    rule.Operator,
+		Value:       value,
+		Threshold:   threshold,
+		FiredAt:     event.FiredAt.Format(time.RFC3339),
+		Description: event.Description,
+	}
 }
 
 func shouldSendOpsAlertEmailByMinSeverity(minSeverity string, ruleSeverity string) bool {
@@ -904,17 +895,6 @@ func (s *OpsAlertEvaluatorService) recordHeartbeatError(runAt time.Time, duratio
 	})
 }
 
-func htmlEscape(s string) string {
-	replacer := strings.NewReplacer(
-		"&", "&amp;",
-		"<", "&lt;",
-		">", "&gt;",
-		`"`, "&quot;",
-		"'", "&#39;",
-	)
-	return replacer.Replace(s)
-}
-
 type slidingWindowLimiter struct {
 	mu     sync.Mutex
 	limit  int
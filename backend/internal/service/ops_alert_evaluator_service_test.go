@@ -210,3 +210,21 @@ func TestComputeRuleMetricNewIndicators(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildOpsAlertEmailData(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, OpsAlertEmailData{}, buildOpsAlertEmailData(nil, &OpsAlertEvent{}))
+	require.Equal(t, OpsAlertEmailData{}, buildOpsAlertEmailData(&OpsAlertRule{}, nil))
+
+	value := 12.5
+	firedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := buildOpsAlertEmailData(
+		&OpsAlertRule{Name: " error rate ", Severity: "P1", MetricType: "error_rate", Operator: ">", Threshold: 5},
+		&OpsAlertEvent{Status: "firing", MetricValue: &value, FiredAt: firedAt},
+	)
+	require.Equal(t, "error rate", data.RuleName)
+	require.Equal(t, "12.50", data.Value)
+	require.Equal(t, "5.00", data.Threshold)
+	require.Equal(t, "2025-01-02T03:04:05Z", data.FiredAt)
+}
@@ -101,6 +101,24 @@ func (s *OpsService) getAccountsLoadMapBestEffort(ctx context.Context, accounts
 	return out
 }
 
+// getFairQueueSnapshotBestEffort returns fair queue snapshots keyed by account ID.
+func (s *OpsService) getFairQueueSnapshotBestEffort(ctx context.Context) map[int64]FairQueueAccountSnapshot {
+	if s == nil || s.concurrencyService == nil {
+		return map[int64]FairQueueAccountSnapshot{}
+	}
+	snaps, err := s.concurrencyService.GetFairQueueSnapshot(ctx)
+	if err != nil {
+		// Best-effort: fair queue details are optional in the ops UI.
+		log.Printf("[Ops] GetFairQueueSnapshot failed: %v", err)
+		return map[int64]FairQueueAccountSnapshot{}
+	}
+	out := make(map[int64]FairQueueAccountSnapshot, len(snaps))
+	for _, snap := range snaps {
+		out[snap.AccountID] = snap
+	}
+	return out
+}
+
 // GetConcurrencyStats returns real-time concurrency usage aggregated by platform/group/account.
 //
 // Optional filters:
@@ -122,6 +140,7 @@ func (s *OpsService) GetConcurrencyStats(
 
 	collectedAt := time.Now()
 	loadMap := s.getAccountsLoadMapBestEffort(ctx, accounts)
+	fairQueues := s.getFairQueueSnapshotBestEffort(ctx)
 
 	platform := make(map[string]*PlatformConcurrencyInfo)
 	group := make(map[int64]*GroupConcurrencyInfo)
@@ -179,6 +198,9 @@ func (s *OpsService) GetConcurrencyStats(
 				MaxCapacity:    int64(acc.Concurrency),
 				WaitingInQueue: waiting,
 			}
+			if snap, ok := fairQueues[acc.ID]; ok {
+				info.FairQueueDepth = int64(snap.Depth)
+			}
 			if info.MaxCapacity > 0 {
 				info.LoadPercentage = float64(info.CurrentInUse) / float64(info.MaxCapacity) * 100
 			}
@@ -361,6 +383,20 @@ func (s *OpsService) GetUserConcurrencyStats(ctx context.Context) (map[int64]*Us
 
 	collectedAt := time.Now()
 	loadMap := s.getUsersLoadMapBestEffort(ctx, users)
+	queued := make(map[int64]*UserConcurrencyInfo)
+	for _, snap := range s.getFairQueueSnapshotBestEffort(ctx) {
+		for _, w := range snap.Waiters {
+			q, ok := queued[w.UserID]
+			if !ok {
+				q = &UserConcurrencyInfo{}
+				queued[w.UserID] = q
+			}
+			q.QueuedRequests++
+			if q.BestQueuePosition == 0 || int64(w.Position) < q.BestQueuePosition {
+				q.BestQueuePosition = int64(w.Position)
+			}
+		}
+	}
 
 	result := make(map[int64]*UserConcurrencyInfo)
 
@@ -377,8 +413,10 @@ func (s *OpsService) GetUserConcurrencyStats(ctx context.Context) (map[int64]*Us
 			waiting = int64(load.WaitingCount)
 		}
 
+		q := queued[u.ID]
+
 		// Skip users with no concurrency activity
-		if currentInUse == 0 && waiting == 0 {
+		if currentInUse == 0 && waiting == 0 && q == nil {
 			continue
 		}
 
@@ -390,6 +428,10 @@ func (s *OpsService) GetUserConcurrencyStats(ctx context.Context) (map[int64]*Us
 			MaxCapacity:    int64(u.Concurrency),
 			WaitingInQueue: waiting,
 		}
+		if q != nil {
+			info.QueuedRequests = q.QueuedRequests
+			info.BestQueuePosition = q.BestQueuePosition
+		}
 		if info.MaxCapacity > 0 {
 			info.LoadPercentage = float64(info.CurrentInUse) / float64(info.MaxCapacity) * 100
 		}
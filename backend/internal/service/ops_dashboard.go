@@ -65,6 +65,10 @@ func (s *OpsService) GetDashboardOverview(ctx context.Context, filter *OpsDashbo
 		log.Printf("[Ops] ListJobHeartbeats failed: %v", err)
 	}
 
+	if provider, ok := s.opsRepo.(OpsReadReplicaStatusProvider); ok {
+		overview.ReadReplicas = provider.ReadReplicaStatus()
+	}
+
 	overview.HealthScore = computeDashboardHealthScore(time.Now().UTC(), overview)
 
 	return overview, nil
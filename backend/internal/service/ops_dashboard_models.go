@@ -45,6 +45,9 @@ type OpsDashboardOverview struct {
 	// Background jobs health (heartbeats).
 	JobHeartbeats []*OpsJobHeartbeat `json:"job_heartbeats"`
 
+	// Read replica routing status (nil when no replicas are configured).
+	ReadReplicas *OpsReadReplicaOverview `json:"read_replicas,omitempty"`
+
 	SuccessCount         int64 `json:"success_count"`
 	ErrorCountTotal      int64 `json:"error_count_total"`
 	BusinessLimitedCount int64 `json:"business_limited_count"`
@@ -85,3 +88,22 @@ type OpsLatencyHistogramResponse struct {
 	TotalRequests int64                        `json:"total_requests"`
 	Buckets       []*OpsLatencyHistogramBucket `json:"buckets"`
 }
+
+// OpsReadReplicaOverview describes read-replica routing for dashboard / reporting queries.
+type OpsReadReplicaOverview struct {
+	MaxLagSeconds int `json:"max_lag_seconds"`
+	// PrimaryFallbacks counts read queries served by the primary because no replica was routable.
+	PrimaryFallbacks int64                   `json:"primary_fallbacks"`
+	Replicas         []*OpsReadReplicaStatus `json:"replicas"`
+}
+
+type OpsReadReplicaStatus struct {
+	Name string `json:"name"`
+	// Healthy is the result of the last health check; Routable also requires lag <= MaxLagSeconds.
+	Healthy       bool       `json:"healthy"`
+	Routable      bool       `json:"routable"`
+	LagSeconds    *float64   `json:"lag_seconds"`
+	RoutedQueries int64      `json:"routed_queries"`
+	CheckedAt     *time.Time `json:"checked_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
@@ -210,6 +210,12 @@ type OpsInsertSystemMetricsInput struct {
 	ConcurrencyQueueDepth *int
 }
 
+// OpsReadReplicaStatusProvider is optionally implemented by the ops repository when
+// dashboard / reporting reads are routed to read replicas.
+type OpsReadReplicaStatusProvider interface {
+	ReadReplicaStatus() *OpsReadReplicaOverview
+}
+
 type OpsInsertSystemLogInput struct {
 	CreatedAt       time.Time
 	Level           string
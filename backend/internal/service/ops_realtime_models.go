@@ -35,6 +35,8 @@ type AccountConcurrencyInfo struct {
 	MaxCapacity    int64   `json:"max_capacity"`
 	LoadPercentage float64 `json:"load_percentage"`
 	WaitingInQueue int64   `json:"waiting_in_queue"`
+	// FairQueueDepth 公平队列中的等待者数量（未启用公平排队时为 0）
+	FairQueueDepth int64 `json:"fair_queue_depth"`
 }
 
 // UserConcurrencyInfo represents real-time concurrency usage for a single user.
@@ -46,6 +48,10 @@ type UserConcurrencyInfo struct {
 	MaxCapacity    int64   `json:"max_capacity"`
 	LoadPercentage float64 `json:"load_percentage"`
 	WaitingInQueue int64   `json:"waiting_in_queue"`
+	// QueuedRequests 该用户在账号公平队列中的等待请求数
+	QueuedRequests int64 `json:"queued_requests"`
+	// BestQueuePosition 该用户在各账号公平队列中的最靠前位置（0 表示未排队）
+	BestQueuePosition int64 `json:"best_queue_position"`
 }
 
 // PlatformAvailability aggregates account availability by platform.
@@ -89,4 +95,10 @@ type AccountAvailability struct {
 	OverloadRemainingSec   *int64     `json:"overload_remaining_sec"`
 	ErrorMessage           string     `json:"error_message"`
 	TempUnschedulableUntil *time.Time `json:"temp_unschedulable_until,omitempty"`
+
+	// 上游熔断状态（open / half_open），closed 时为空
+	CircuitState     string     `json:"circuit_state,omitempty"`
+	CircuitScope     string     `json:"circuit_scope,omitempty"`
+	CircuitHost      string     `json:"circuit_host,omitempty"`
+	CircuitOpenUntil *time.Time `json:"circuit_open_until,omitempty"`
 }
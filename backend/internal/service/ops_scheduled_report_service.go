@@ -316,11 +316,11 @@ func (s *OpsScheduledReportService) runReport(ctx context.Context, report *opsSc
 	// Mark as "run" up-front so a broken SMTP config doesn't spam retries every minute.
 	s.setLastRunAt(ctx, report.ReportType, now)
 
-	content, err := s.generateReportHTML(ctx, report, now)
+	templateKey, data, err := s.generateReportEmail(ctx, report, now)
 	if err != nil {
 		return 0, err
 	}
-	if strings.TrimSpace(content) == "" {
+	if templateKey == "" {
 		// Skip sending when the report decides not to emit content (e.g., digest below min count).
 		return 0, nil
 	}
@@ -336,8 +336,6 @@ func (s *OpsScheduledReportService) runReport(ctx context.Context, report *opsSc
 		return 0, nil
 	}
 
-	subject := fmt.Sprintf("[Ops Report] %s", strings.TrimSpace(report.Name))
-
 	attempts := 0
 	for _, to := range recipients {
 		addr := strings.TrimSpace(to)
@@ -345,7 +343,7 @@ func (s *OpsScheduledReportService) runReport(ctx context.Context, report *opsSc
 			continue
 		}
 		attempts++
-		if err := s.emailService.SendEmail(ctx, addr, subject, content); err != nil {
+		if err := s.emailService.SendTemplateEmail(ctx, addr, templateKey, "", data); err != nil {
 			// Ignore per-recipient failures; continue best-effort.
 			continue
 		}
@@ -353,12 +351,13 @@ func (s *OpsScheduledReportService) runReport(ctx context.Context, report *opsSc
 	return attempts, nil
 }
 
-func (s *OpsScheduledReportService) generateReportHTML(ctx context.Context, report *opsScheduledReport, now time.Time) (string, error) {
+// generateReportEmail returns the email template key and its data; an empty key means nothing to send.
+func (s *OpsScheduledReportService) generateReportEmail(ctx context.Context, report *opsScheduledReport, now time.Time) (string, any, error) {
 	if s == nil || s.opsService == nil || report == nil {
-		return "", fmt.Errorf("service not initialized")
+		return "", nil, fmt.Errorf("service not initialized")
 	}
 	if report.TimeRange <= 0 {
-		return "", fmt.Errorf("invalid time range")
+		return "", nil, fmt.Errorf("invalid time range")
 	}
 
 	end := now.UTC()
@@ -385,10 +384,10 @@ func (s *OpsScheduledReportService) generateReportHTML(ctx context.Context, repo
 				})
 			}
 			if err != nil {
-				return "", err
+				return "", nil, err
 			}
 		}
-		return buildOpsSummaryEmailHTML(report.Name, start, end, overview), nil
+		return EmailTemplateOpsReportSummary, buildOpsSummaryEmailData(report.Name, start, end, overview), nil
 	case "error_digest":
 		// Lightweight digest: list recent errors (status>=400) and breakdown by type.
 		startTime := start
@@ -401,183 +400,116 @@ func (s *OpsScheduledReportService) generateReportHTML(ctx context.Context, repo
 		}
 		out, err := s.opsService.GetErrorLogs(ctx, filter)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		if report.ErrorDigestMinCount > 0 && out != nil && out.Total < report.ErrorDigestMinCount {
-			return "", nil
+			return "", nil, nil
 		}
-		return buildOpsErrorDigestEmailHTML(report.Name, start, end, out), nil
+		return EmailTemplateOpsReportErrorDigest, buildOpsErrorDigestEmailData(report.Name, start, end, out), nil
 	case "account_health":
 		// Best-effort: use account availability (not error rate yet).
 		avail, err := s.opsService.GetAccountAvailability(ctx, "", nil)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		_ = report.AccountHealthErrorRateThreshold // reserved for future per-account error rate report
-		return buildOpsAccountHealthEmailHTML(report.Name, start, end, avail), nil
+		return EmailTemplateOpsReportAccountHealth, buildOpsAccountHealthEmailData(report.Name, start, end, avail), nil
 	default:
-		return "", fmt.Errorf("unknown report type: %s", report.ReportType)
+		return "", nil, fmt.Errorf("unknown report type: %s", report.ReportType)
 	}
 }
 
-func buildOpsSummaryEmailHTML(title string, start, end time.Time, overview *OpsDashboardOverview) string {
+func buildOpsSummaryEmailData(title string, start, end time.Time, overview *OpsDashboardOverview) OpsReportSummaryEmailData {
+	data := OpsReportSummaryEmailData{
+		Title:       strings.TrimSpace(title),
+		PeriodStart: start.UTC().Format(time.RFC3339),
+		PeriodEnd:   end.UTC().Format(time.RFC3339),
+	}
 	if overview == nil {
-		return fmt.Sprintf("<h2>%s</h2><p>No data.</p>", htmlEscape(title))
-	}
-
-	latP50 := "-"
-	latP99 := "-"
-	if overview.Duration.P50 != nil {
-		latP50 = fmt.Sprintf("%dms", *overview.Duration.P50)
-	}
-	if overview.Duration.P99 != nil {
-		latP99 = fmt.Sprintf("%dms", *overview.Duration.P99)
-	}
-
-	ttftP50 := "-"
-	ttftP99 := "-"
-	if overview.TTFT.P50 != nil {
-		ttftP50 = fmt.Sprintf("%dms", *overview.TTFT.P50)
-	}
-	if overview.TTFT.P99 != nil {
-		ttftP99 = fmt.Sprintf("%dms", *overview.TTFT.P99)
-	}
-
-	return fmt.Sprintf(`
-<h2>%s</h2>
-<p><b>Period</b>: %s ~ %s (UTC)</p>
-<ul>
-  <li><b>Total Requests</b>: %d</li>
-  <li><b>Success</b>: %d</li>
-  <li><b>Errors (SLA)</b>: %d</li>
-  <li><b>Business Limited</b>: %d</li>
-  <li><b>SLA</b>: %.2f%%</li>
-  <li><b>Error Rate</b>: %.2f%%</li>
-  <li><b>Upstream Error Rate (excl 429/529)</b>: %.2f%%</li>
-  <li><b>Upstream Errors</b>: excl429/529=%d, 429=%d, 529=%d</li>
-  <li><b>Latency</b>: p50=%s, p99=%s</li>
-  <li><b>TTFT</b>: p50=%s, p99=%s</li>
-  <li><b>Tokens</b>: %d</li>
-  <li><b>QPS</b>: current=%.1f, peak=%.1f, avg=%.1f</li>
-  <li><b>TPS</b>: current=%.1f, peak=%.1f, avg=%.1f</li>
-</ul>
-`,
-		htmlEscape(strings.TrimSpace(title)),
-		htmlEscape(start.UTC().Format(time.RFC3339)),
-		htmlEscape(end.UTC().Format(time.RFC3339)),
-		overview.RequestCountTotal,
-		overview.SuccessCount,
-		overview.ErrorCountSLA,
-		overview.BusinessLimitedCount,
-		overview.SLA*100,
-		overview.ErrorRate*100,
-		overview.UpstreamErrorRate*100,
-		overview.UpstreamErrorCountExcl429529,
-		overview.Upstream429Count,
-		overview.Upstream529Count,
-		htmlEscape(latP50),
-		htmlEscape(latP99),
-		htmlEscape(ttftP50),
-		htmlEscape(ttftP99),
-		overview.TokenConsumed,
-		overview.QPS.Current,
-		overview.QPS.Peak,
-		overview.QPS.Avg,
-		overview.TPS.Current,
-		overview.TPS.Peak,
-		overview.TPS.Avg,
-	)
+		return data
+	}
+
+	percentile := func(v *int) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%dms", *v)
+	}
+	rate := func(v float64) string { return fmt.Sprintf("%.1f", v) }
+
+	data.HasData = true
+	data.TotalRequests = overview.RequestCountTotal
+	data.SuccessCount = overview.SuccessCount
+	data.ErrorCountSLA = overview.ErrorCountSLA
+	data.BusinessLimitedCount = overview.BusinessLimitedCount
+	data.SLA = fmt.Sprintf("%.2f", overview.SLA*100)
+	data.ErrorRate = fmt.Sprintf("%.2f", overview.ErrorRate*100)
+	data.UpstreamErrorRate = fmt.Sprintf("%.2f", overview.UpstreamErrorRate*100)
+	data.UpstreamErrorsExcl429529 = overview.UpstreamErrorCountExcl429529
+	data.Upstream429Count = overview.Upstream429Count
+	data.Upstream529Count = overview.Upstream529Count
+	data.LatencyP50 = percentile(overview.Duration.P50)
+	data.LatencyP99 = percentile(overview.Duration.P99)
+	data.TTFTP50 = percentile(overview.TTFT.P50)
+	data.TTFTP99 = percentile(overview.TTFT.P99)
+	data.TokenConsumed = overview.TokenConsumed
+	data.QPSCurrent, data.QPSPeak, data.QPSAvg = rate(overview.QPS.Current), rate(overview.QPS.Peak), rate(overview.QPS.Avg)
+	data.TPSCurrent, data.TPSPeak, data.TPSAvg = rate(overview.TPS.Current), rate(overview.TPS.Peak), rate(overview.TPS.Avg)
+	return data
 }
 
-func buildOpsErrorDigestEmailHTML(title string, start, end time.Time, list *OpsErrorLogList) string {
-	total := 0
+func buildOpsErrorDigestEmailData(title string, start, end time.Time, list *OpsErrorLogList) OpsReportErrorDigestEmailData {
+	data := OpsReportErrorDigestEmailData{
+		Title:       strings.TrimSpace(title),
+		PeriodStart: start.UTC().Format(time.RFC3339),
+		PeriodEnd:   end.UTC().Format(time.RFC3339),
+	}
 	recent := []*OpsErrorLog{}
 	if list != nil {
-		total = list.Total
+		data.TotalErrors = list.Total
 		recent = list.Errors
 	}
 	if len(recent) > 10 {
 		recent = recent[:10]
 	}
-
-	rows := ""
 	for _, item := range recent {
 		if item == nil {
 			continue
 		}
-		rows += fmt.Sprintf(
-			"<tr><td>%s</td><td>%s</td><td>%d</td><td>%s</td></tr>",
-			htmlEscape(item.CreatedAt.UTC().Format(time.RFC3339)),
-			htmlEscape(item.Platform),
-			item.StatusCode,
-			htmlEscape(truncateString(item.Message, 180)),
-		)
-	}
-	if rows == "" {
-		rows = "<tr><td colspan=\"4\">No recent errors.</td></tr>"
-	}
-
-	return fmt.Sprintf(`
-<h2>%s</h2>
-<p><b>Period</b>: %s ~ %s (UTC)</p>
-<p><b>Total Errors</b>: %d</p>
-<h3>Recent</h3>
-<table border="1" cellpadding="6" cellspacing="0" style="border-collapse:collapse;">
-  <thead><tr><th>Time</th><th>Platform</th><th>Status</th><th>Message</th></tr></thead>
-  <tbody>%s</tbody>
-</table>
-`,
-		htmlEscape(strings.TrimSpace(title)),
-		htmlEscape(start.UTC().Format(time.RFC3339)),
-		htmlEscape(end.UTC().Format(time.RFC3339)),
-		total,
-		rows,
-	)
+		data.Errors = append(data.Errors, OpsReportErrorItem{
+			Time:       item.CreatedAt.UTC().Format(time.RFC3339),
+			Platform:   item.Platform,
+			StatusCode: item.StatusCode,
+			Message:    truncateString(item.Message, 180),
+		})
+	}
+	return data
 }
 
-func buildOpsAccountHealthEmailHTML(title string, start, end time.Time, avail *OpsAccountAvailability) string {
-	total := 0
-	available := 0
-	rateLimited := 0
-	hasError := 0
-
+func buildOpsAccountHealthEmailData(title string, start, end time.Time, avail *OpsAccountAvailability) OpsReportAccountHealthEmailData {
+	data := OpsReportAccountHealthEmailData{
+		Title:       strings.TrimSpace(title),
+		PeriodStart: start.UTC().Format(time.RFC3339),
+		PeriodEnd:   end.UTC().Format(time.RFC3339),
+	}
 	if avail != nil && avail.Accounts != nil {
 		for _, a := range avail.Accounts {
 			if a == nil {
 				continue
 			}
-			total++
+			data.TotalAccounts++
 			if a.IsAvailable {
-				available++
+				data.Available++
 			}
 			if a.IsRateLimited {
-				rateLimited++
+				data.RateLimited++
 			}
 			if a.HasError {
-				hasError++
+				data.Errors++
 			}
 		}
 	}
-
-	return fmt.Sprintf(`
-<h2>%s</h2>
-<p><b>Period</b>: %s ~ %s (UTC)</p>
-<ul>
-  <li><b>Total Accounts</b>: %d</li>
-  <li><b>Available</b>: %d</li>
-  <li><b>Rate Limited</b>: %d</li>
-  <li><b>Error</b>: %d</li>
-</ul>
-<p>Note: This report currently reflects account availability status only.</p>
-`,
-		htmlEscape(strings.TrimSpace(title)),
-		htmlEscape(start.UTC().Format(time.RFC3339)),
-		htmlEscape(end.UTC().Format(time.RFC3339)),
-		total,
-		available,
-		rateLimited,
-		hasError,
-	)
+	return data
 }
 
 func (s *OpsScheduledReportService) tryAcquireLeaderLock(ctx context.Context) (func(), bool) {
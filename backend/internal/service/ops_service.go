@@ -54,6 +54,7 @@ type OpsService struct {
 	geminiCompatService       *GeminiMessagesCompatService
 	antigravityGatewayService *AntigravityGatewayService
 	systemLogSink             *OpsSystemLogSink
+	circuitBreaker            *UpstreamCircuitBreaker
 }
 
 func NewOpsService(
@@ -88,6 +89,11 @@ func NewOpsService(
 	return svc
 }
 
+// SetUpstreamCircuitBreaker 设置上游熔断器，用于在账号可用性中展示熔断状态（可选依赖）
+func (s *OpsService) SetUpstreamCircuitBreaker(b *UpstreamCircuitBreaker) {
+	s.circuitBreaker = b
+}
+
 func (s *OpsService) RequireMonitoringEnabled(ctx context.Context) error {
 	if s.IsMonitoringEnabled(ctx) {
 		return nil
@@ -0,0 +1,554 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrPasskeyNotEnabled        = infraerrors.BadRequest("PASSKEY_NOT_ENABLED", "passkey feature is not enabled")
+	ErrPasskeyNotConfigured     = infraerrors.BadRequest("PASSKEY_NOT_CONFIGURED", "frontend url must be configured before passkeys can be used")
+	ErrPasskeyNotFound          = infraerrors.NotFound("PASSKEY_NOT_FOUND", "passkey not found")
+	ErrPasskeyAlreadyRegistered = infraerrors.Conflict("PASSKEY_ALREADY_REGISTERED", "this passkey is already registered")
+	ErrPasskeyLimitReached      = infraerrors.BadRequest("PASSKEY_LIMIT_REACHED", "maximum number of passkeys reached")
+	ErrPasskeyChallengeExpired  = infraerrors.BadRequest("PASSKEY_CHALLENGE_EXPIRED", "passkey challenge expired, please try again")
+	ErrPasskeyVerifyFailed      = infraerrors.BadRequest("PASSKEY_VERIFY_FAILED", "passkey verification failed")
+	ErrPasskeyNameInvalid       = infraerrors.BadRequest("PASSKEY_NAME_INVALID", "passkey name must be 1-100 characters")
+	ErrAdmin2FARequired         = infraerrors.Forbidden("ADMIN_2FA_REQUIRED", "two-factor authentication must be set up before using the admin console")
+)
+
+// UserPasskey is a registered WebAuthn credential
+type UserPasskey struct {
+	ID              int64      `json:"id"`
+	UserID          int64      `json:"-"`
+	CredentialID    []byte     `json:"-"`
+	PublicKey       []byte     `json:"-"`
+	AttestationType string     `json:"-"`
+	AAGUID          []byte     `json:"-"`
+	SignCount       uint32     `json:"-"`
+	CloneWarning    bool       `json:"clone_warning"`
+	Transports      []string   `json:"transports"`
+	BackupEligible  bool       `json:"backup_eligible"`
+	BackupState     bool       `json:"backup_state"`
+	Name            string     `json:"name"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// PasskeyRepository persists registered passkeys
+type PasskeyRepository interface {
+	ListByUser(ctx context.Context, userID int64) ([]*UserPasskey, error)
+	CountByUser(ctx context.Context, userID int64) (int, error)
+	Create(ctx context.Context, passkey *UserPasskey) error
+	// UpdateUsage records a successful assertion (sign count, flags and last used time)
+	UpdateUsage(ctx context.Context, id int64, signCount uint32, cloneWarning, backupState bool, usedAt time.Time) error
+	Rename(ctx context.Context, userID, id int64, name string) error
+	Delete(ctx context.Context, userID, id int64) error
+}
+
+// PasskeyChallenge is a pending WebAuthn ceremony stored in cache
+type PasskeyChallenge struct {
+	UserID  int64
+	Name    string // passkey name chosen at registration
+	Session webauthn.SessionData
+}
+
+// PasskeyCache stores pending WebAuthn challenges
+type PasskeyCache interface {
+	SetChallenge(ctx context.Context, key string, challenge *PasskeyChallenge, ttl time.Duration) error
+	// ConsumeChallenge atomically reads and deletes a challenge; returns nil when missing or expired
+	ConsumeChallenge(ctx context.Context, key string) (*PasskeyChallenge, error)
+}
+
+// PasskeyList is the passkey overview for the current user
+type PasskeyList struct {
+	FeatureEnabled bool           `json:"feature_enabled"`
+	Passkeys       []*UserPasskey `json:"passkeys"`
+}
+
+// PasskeyLoginOptions is returned when starting a passwordless login
+type PasskeyLoginOptions struct {
+	ChallengeToken string                        `json:"challenge_token"`
+	Options        *protocol.CredentialAssertion `json:"options"`
+}
+
+const (
+	passkeyChallengeTTL = 5 * time.Minute
+	maxPasskeysPerUser  = 10
+	maxPasskeyNameLen   = 100
+
+	passkeyChallengeRegister = "reg:"
+	passkeyChallengeLogin    = "login:"
+	passkeyChallenge2FA      = "2fa:"
+)
+
+// PasskeyService handles WebAuthn passkey registration and authentication
+type PasskeyService struct {
+	repo           PasskeyRepository
+	cache          PasskeyCache
+	userRepo       UserRepository
+	settingService *SettingService
+	emailService   *EmailService
+}
+
+// NewPasskeyService creates a new passkey service
+func NewPasskeyService(
+	repo PasskeyRepository,
+	cache PasskeyCache,
+	userRepo UserRepository,
+	settingService *SettingService,
+	emailService *EmailService,
+) *PasskeyService {
+	return &PasskeyService{
+		repo:           repo,
+		cache:          cache,
+		userRepo:       userRepo,
+		settingService: settingService,
+		emailService:   emailService,
+	}
+}
+
+// passkeyUser adapts a user and its passkeys to webauthn.User
+type passkeyUser struct {
+	user     *User
+	passkeys []*UserPasskey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte { return passkeyUserHandle(u.user.ID) }
+
+func (u *passkeyUser) WebAuthnName() string { return u.user.Email }
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if strings.TrimSpace(u.user.Username) != "" {
+		return u.user.Username
+	}
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	out := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, pk := range u.passkeys {
+		out = append(out, pk.credential())
+	}
+	return out
+}
+
+func (u *passkeyUser) find(credentialID []byte) *UserPasskey {
+	for _, pk := range u.passkeys {
+		if string(pk.CredentialID) == string(credentialID) {
+			return pk
+		}
+	}
+	return nil
+}
+
+func (pk *UserPasskey) credential() webauthn.Credential {
+	flags := protocol.FlagUserPresent
+	if pk.BackupEligible {
+		flags |= protocol.FlagBackupEligible
+	}
+	if pk.BackupState {
+		flags |= protocol.FlagBackupState
+	}
+	transports := make([]protocol.AuthenticatorTransport, 0, len(pk.Transports))
+	for _, t := range pk.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(t))
+	}
+	return webauthn.Credential{
+		ID:              pk.CredentialID,
+		PublicKey:       pk.PublicKey,
+		AttestationType: pk.AttestationType,
+		Transport:       transports,
+		Flags:           webauthn.NewCredentialFlags(flags),
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       pk.AAGUID,
+			SignCount:    pk.SignCount,
+			CloneWarning: pk.CloneWarning,
+		},
+	}
+}
+
+// passkeyUserHandle 用户句柄只包含用户 ID，不含邮箱等个人信息
+func passkeyUserHandle(userID int64) []byte {
+	return []byte(strconv.FormatInt(userID, 10))
+}
+
+func parsePasskeyUserHandle(handle []byte) (int64, error) {
+	return strconv.ParseInt(string(handle), 10, 64)
+}
+
+// relyingParty 根据前端地址构造 WebAuthn 依赖方配置（RP ID 为前端域名，Origin 为前端源）
+func (s *PasskeyService) relyingParty(ctx context.Context) (*webauthn.WebAuthn, error) {
+	u, err := url.Parse(strings.TrimSpace(s.settingService.GetFrontendURL(ctx)))
+	if err != nil || u.Hostname() == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, ErrPasskeyNotConfigured
+	}
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: s.settingService.GetSiteName(ctx),
+		RPOrigins:     []string{u.Scheme + "://" + u.Host},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyChallengeTTL, TimeoutUVD: passkeyChallengeTTL},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyChallengeTTL, TimeoutUVD: passkeyChallengeTTL},
+		},
+	})
+	if err != nil {
+		slog.Warn("passkey_relying_party_invalid", "error", err)
+		return nil, ErrPasskeyNotConfigured
+	}
+	return wa, nil
+}
+
+func (s *PasskeyService) checkEnabled(ctx context.Context) (*webauthn.WebAuthn, error) {
+	if !s.settingService.IsPasskeyEnabled(ctx) {
+		return nil, ErrPasskeyNotEnabled
+	}
+	return s.relyingParty(ctx)
+}
+
+func (s *PasskeyService) loadUser(ctx context.Context, userID int64) (*passkeyUser, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	passkeys, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list passkeys: %w", err)
+	}
+	return &passkeyUser{user: user, passkeys: passkeys}, nil
+}
+
+// List returns the passkeys registered by a user
+func (s *PasskeyService) List(ctx context.Context, userID int64) (*PasskeyList, error) {
+	passkeys, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list passkeys: %w", err)
+	}
+	return &PasskeyList{
+		FeatureEnabled: s.settingService.IsPasskeyEnabled(ctx),
+		Passkeys:       passkeys,
+	}, nil
+}
+
+// verifyIdentity 与 TOTP 设置一致：开启邮箱验证时校验邮箱验证码，否则校验密码
+func (s *PasskeyService) verifyIdentity(ctx context.Context, user *User, emailCode, password string) error {
+	if s.settingService.IsEmailVerifyEnabled(ctx) {
+		if emailCode == "" {
+			return ErrVerifyCodeRequired
+		}
+		return s.emailService.VerifyCode(ctx, user.Email, emailCode)
+	}
+	if password == "" {
+		return ErrPasswordRequired
+	}
+	if !user.CheckPassword(password) {
+		return ErrPasswordIncorrect
+	}
+	return nil
+}
+
+func normalizePasskeyName(name string, fallback string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = fallback
+	}
+	if name == "" || len([]rune(name)) > maxPasskeyNameLen {
+		return "", ErrPasskeyNameInvalid
+	}
+	return name, nil
+}
+
+// BeginRegistration verifies the user's identity and returns credential creation options
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID int64, name, emailCode, password string) (*protocol.CredentialCreation, error) {
+	wa, err := s.checkEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pu, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pu.passkeys) >= maxPasskeysPerUser {
+		return nil, ErrPasskeyLimitReached
+	}
+	name, err = normalizePasskeyName(name, fmt.Sprintf("Passkey %d", len(pu.passkeys)+1))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyIdentity(ctx, pu.user, emailCode, password); err != nil {
+		return nil, err
+	}
+
+	creation, session, err := wa.BeginRegistration(pu,
+		webauthn.WithExclusions(webauthn.Credentials(pu.WebAuthnCredentials()).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+		webauthn.WithConveyancePreference(protocol.PreferNoAttestation),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("begin passkey registration: %w", err)
+	}
+	challenge := &PasskeyChallenge{UserID: userID, Name: name, Session: *session}
+	if err := s.cache.SetChallenge(ctx, passkeyChallengeKey(passkeyChallengeRegister, strconv.FormatInt(userID, 10)), challenge, passkeyChallengeTTL); err != nil {
+		return nil, fmt.Errorf("store passkey challenge: %w", err)
+	}
+	return creation, nil
+}
+
+// FinishRegistration verifies the authenticator's attestation response and stores the passkey
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID int64, response []byte) (*UserPasskey, error) {
+	wa, err := s.checkEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.cache.ConsumeChallenge(ctx, passkeyChallengeKey(passkeyChallengeRegister, strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("load passkey challenge: %w", err)
+	}
+	if challenge == nil || challenge.UserID != userID {
+		return nil, ErrPasskeyChallengeExpired
+	}
+	pu, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pu.passkeys) >= maxPasskeysPerUser {
+		return nil, ErrPasskeyLimitReached
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		slog.Debug("passkey_registration_parse_failed", "user_id", userID, "error", err)
+		return nil, ErrPasskeyVerifyFailed
+	}
+	cred, err := wa.CreateCredential(pu, challenge.Session, parsed)
+	if err != nil {
+		slog.Debug("passkey_registration_verify_failed", "user_id", userID, "error", err)
+		return nil, ErrPasskeyVerifyFailed
+	}
+
+	transports := make([]string, 0, len(cred.Transport))
+	for _, t := range cred.Transport {
+		transports = append(transports, string(t))
+	}
+	passkey := &UserPasskey{
+		UserID:          userID,
+		CredentialID:    cred.ID,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  cred.Flags.BackupEligible,
+		BackupState:     cred.Flags.BackupState,
+		Name:            challenge.Name,
+	}
+	if err := s.repo.Create(ctx, passkey); err != nil {
+		return nil, err
+	}
+	slog.Info("passkey_registered", "user_id", userID, "passkey_id", passkey.ID)
+	return passkey, nil
+}
+
+// Rename changes the display name of a passkey
+func (s *PasskeyService) Rename(ctx context.Context, userID, id int64, name string) error {
+	name, err := normalizePasskeyName(name, "")
+	if err != nil {
+		return err
+	}
+	return s.repo.Rename(ctx, userID, id, name)
+}
+
+// Delete removes a passkey after verifying the user's identity
+func (s *PasskeyService) Delete(ctx context.Context, userID, id int64, emailCode, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyIdentity(ctx, user, emailCode, password); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	slog.Info("passkey_deleted", "user_id", userID, "passkey_id", id)
+	return nil
+}
+
+// BeginLogin starts a passwordless (discoverable credential) login
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*PasskeyLoginOptions, error) {
+	wa, err := s.checkEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	assertion, session, err := wa.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, fmt.Errorf("begin passkey login: %w", err)
+	}
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("generate challenge token: %w", err)
+	}
+	if err := s.cache.SetChallenge(ctx, passkeyChallengeKey(passkeyChallengeLogin, token), &PasskeyChallenge{Session: *session}, passkeyChallengeTTL); err != nil {
+		return nil, fmt.Errorf("store passkey challenge: %w", err)
+	}
+	return &PasskeyLoginOptions{ChallengeToken: token, Options: assertion}, nil
+}
+
+// FinishLogin verifies a passwordless assertion and returns the authenticated user
+func (s *PasskeyService) FinishLogin(ctx context.Context, challengeToken string, response []byte) (*User, error) {
+	wa, err := s.checkEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.cache.ConsumeChallenge(ctx, passkeyChallengeKey(passkeyChallengeLogin, challengeToken))
+	if err != nil {
+		return nil, fmt.Errorf("load passkey challenge: %w", err)
+	}
+	if challenge == nil {
+		return nil, ErrPasskeyChallengeExpired
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		slog.Debug("passkey_login_parse_failed", "error", err)
+		return nil, ErrPasskeyVerifyFailed
+	}
+
+	var pu *passkeyUser
+	handler := func(_, userHandle []byte) (webauthn.User, error) {
+		userID, err := parsePasskeyUserHandle(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		pu, err = s.loadUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return pu, nil
+	}
+	_, cred, err := wa.ValidatePasskeyLogin(handler, challenge.Session, parsed)
+	if err != nil {
+		slog.Debug("passkey_login_verify_failed", "error", err)
+		return nil, ErrPasskeyVerifyFailed
+	}
+	if err := s.recordUsage(ctx, pu, cred); err != nil {
+		return nil, err
+	}
+	if !pu.user.IsActive() {
+		return nil, ErrUserNotActive
+	}
+	return pu.user, nil
+}
+
+// HasSecondFactor reports whether passkeys can be used as a second factor for the user
+func (s *PasskeyService) HasSecondFactor(ctx context.Context, userID int64) bool {
+	if !s.settingService.IsPasskeyEnabled(ctx) {
+		return false
+	}
+	count, err := s.repo.CountByUser(ctx, userID)
+	if err != nil {
+		slog.Warn("passkey_count_failed", "user_id", userID, "error", err)
+		return false
+	}
+	return count > 0
+}
+
+// BeginSecondFactor returns assertion options for a pending 2FA login session
+func (s *PasskeyService) BeginSecondFactor(ctx context.Context, tempToken string, userID int64) (*protocol.CredentialAssertion, error) {
+	wa, err := s.checkEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pu, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pu.passkeys) == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+	assertion, session, err := wa.BeginLogin(pu, webauthn.WithUserVerification(protocol.VerificationPreferred))
+	if err != nil {
+		return nil, fmt.Errorf("begin passkey assertion: %w", err)
+	}
+	if err := s.cache.SetChallenge(ctx, passkeyChallengeKey(passkeyChallenge2FA, tempToken), &PasskeyChallenge{UserID: userID, Session: *session}, passkeyChallengeTTL); err != nil {
+		return nil, fmt.Errorf("store passkey challenge: %w", err)
+	}
+	return assertion, nil
+}
+
+// FinishSecondFactor verifies a passkey assertion for a pending 2FA login session
+func (s *PasskeyService) FinishSecondFactor(ctx context.Context, tempToken string, userID int64, response []byte) error {
+	wa, err := s.checkEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	challenge, err := s.cache.ConsumeChallenge(ctx, passkeyChallengeKey(passkeyChallenge2FA, tempToken))
+	if err != nil {
+		return fmt.Errorf("load passkey challenge: %w", err)
+	}
+	if challenge == nil || challenge.UserID != userID {
+		return ErrPasskeyChallengeExpired
+	}
+	pu, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		slog.Debug("passkey_2fa_parse_failed", "user_id", userID, "error", err)
+		return ErrPasskeyVerifyFailed
+	}
+	cred, err := wa.ValidateLogin(pu, challenge.Session, parsed)
+	if err != nil {
+		slog.Debug("passkey_2fa_verify_failed", "user_id", userID, "error", err)
+		return ErrPasskeyVerifyFailed
+	}
+	return s.recordUsage(ctx, pu, cred)
+}
+
+// recordUsage 保存签名计数；计数器回退（疑似克隆的认证器）时拒绝本次登录
+func (s *PasskeyService) recordUsage(ctx context.Context, pu *passkeyUser, cred *webauthn.Credential) error {
+	pk := pu.find(cred.ID)
+	if pk == nil {
+		return ErrPasskeyVerifyFailed
+	}
+	cloneWarning := cred.Authenticator.CloneWarning
+	if err := s.repo.UpdateUsage(ctx, pk.ID, cred.Authenticator.SignCount, cloneWarning, cred.Flags.BackupState, time.Now()); err != nil {
+		return fmt.Errorf("update passkey usage: %w", err)
+	}
+	if cloneWarning {
+		slog.Warn("passkey_clone_warning", "user_id", pu.user.ID, "passkey_id", pk.ID)
+		return ErrPasskeyVerifyFailed
+	}
+	return nil
+}
+
+// RequiresSecondFactorSetup reports whether an admin must set up 2FA before using the admin console
+func (s *PasskeyService) RequiresSecondFactorSetup(ctx context.Context, user *User) bool {
+	return AdminRequiresSecondFactorSetup(ctx, s.settingService, s, user)
+}
+
+// AdminRequiresSecondFactorSetup reports whether an admin must set up 2FA before using the admin console.
+// Enforcement only depends on the admin_2fa_required setting; without a passkey service only TOTP counts.
+func AdminRequiresSecondFactorSetup(ctx context.Context, settingService *SettingService, passkeyService *PasskeyService, user *User) bool {
+	if user == nil || !user.IsAdmin() || settingService == nil || !settingService.IsAdmin2FARequired(ctx) {
+		return false
+	}
+	if user.TotpEnabled && settingService.IsTotpEnabled(ctx) {
+		return false
+	}
+	return passkeyService == nil || !passkeyService.HasSecondFactor(ctx, user.ID)
+}
+
+func passkeyChallengeKey(kind, id string) string {
+	return kind + id
+}
@@ -0,0 +1,375 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passkeyTestRPID   = "app.example.com"
+	passkeyTestOrigin = "https://app.example.com"
+)
+
+type passkeyRepoStub struct {
+	nextID   int64
+	passkeys map[int64]*UserPasskey
+}
+
+func (s *passkeyRepoStub) ListByUser(_ context.Context, userID int64) ([]*UserPasskey, error) {
+	out := make([]*UserPasskey, 0)
+	for id := int64(1); id <= s.nextID; id++ {
+		if pk, ok := s.passkeys[id]; ok && pk.UserID == userID {
+			cp := *pk
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (s *passkeyRepoStub) CountByUser(ctx context.Context, userID int64) (int, error) {
+	list, _ := s.ListByUser(ctx, userID)
+	return len(list), nil
+}
+
+func (s *passkeyRepoStub) Create(_ context.Context, pk *UserPasskey) error {
+	for _, existing := range s.passkeys {
+		if string(existing.CredentialID) == string(pk.CredentialID) {
+			return ErrPasskeyAlreadyRegistered
+		}
+	}
+	s.nextID++
+	pk.ID = s.nextID
+	pk.CreatedAt = time.Now()
+	cp := *pk
+	s.passkeys[pk.ID] = &cp
+	return nil
+}
+
+func (s *passkeyRepoStub) UpdateUsage(_ context.Context, id int64, signCount uint32, cloneWarning, backupState bool, usedAt time.Time) error {
+	pk := s.passkeys[id]
+	pk.SignCount = signCount
+	pk.CloneWarning = pk.CloneWarning || cloneWarning
+	pk.BackupState = backupState
+	pk.LastUsedAt = &usedAt
+	return nil
+}
+
+func (s *passkeyRepoStub) Rename(_ context.Context, userID, id int64, name string) error {
+	pk, ok := s.passkeys[id]
+	if !ok || pk.UserID != userID {
+		return ErrPasskeyNotFound
+	}
+	pk.Name = name
+	return nil
+}
+
+func (s *passkeyRepoStub) Delete(_ context.Context, userID, id int64) error {
+	pk, ok := s.passkeys[id]
+	if !ok || pk.UserID != userID {
+		return ErrPasskeyNotFound
+	}
+	delete(s.passkeys, id)
+	return nil
+}
+
+type passkeyCacheStub struct {
+	challenges map[string]PasskeyChallenge
+}
+
+func (s *passkeyCacheStub) SetChallenge(_ context.Context, key string, challenge *PasskeyChallenge, _ time.Duration) error {
+	// 与 Redis 实现一致：经过 JSON 往返
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+	var cp PasskeyChallenge
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return err
+	}
+	s.challenges[key] = cp
+	return nil
+}
+
+func (s *passkeyCacheStub) ConsumeChallenge(_ context.Context, key string) (*PasskeyChallenge, error) {
+	challenge, ok := s.challenges[key]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.challenges, key)
+	return &challenge, nil
+}
+
+type passkeyUserRepoStub struct {
+	userRepoStub
+	users map[int64]*User
+}
+
+func (s *passkeyUserRepoStub) GetByID(_ context.Context, id int64) (*User, error) {
+	if u, ok := s.users[id]; ok {
+		return u, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+// softAuthenticator is a minimal WebAuthn authenticator ("none" attestation, ES256)
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	counter      uint32
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	return &softAuthenticator{key: key, credentialID: id, origin: passkeyTestOrigin}
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, typ string, challenge []byte) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func (a *softAuthenticator) authData(flags protocol.AuthenticatorFlags, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(passkeyTestRPID))
+	out := append([]byte{}, rpIDHash[:]...)
+	out = append(out, byte(flags))
+	out = binary.BigEndian.AppendUint32(out, a.counter)
+	return append(out, attested...)
+}
+
+func (a *softAuthenticator) create(t *testing.T, creation *protocol.CredentialCreation) []byte {
+	a.userHandle = creation.Response.User.ID.(protocol.URLEncodedBase64)
+	pub, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{KeyType: int64(webauthncose.EllipticKey), Algorithm: int64(webauthncose.AlgES256)},
+		Curve:         1, // P-256
+		XCoord:        a.key.X.FillBytes(make([]byte, 32)),
+		YCoord:        a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	attested := make([]byte, 16) // AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, pub...)
+	authData := a.authData(protocol.FlagUserPresent|protocol.FlagUserVerified|protocol.FlagAttestedCredentialData, attested)
+
+	attObj, err := webauthncbor.Marshal(map[string]any{"fmt": "none", "attStmt": map[string]any{}, "authData": authData})
+	require.NoError(t, err)
+	return a.encode(t, map[string]any{
+		"clientDataJSON":    a.clientData(t, "webauthn.create", creation.Response.Challenge),
+		"attestationObject": attObj,
+		"transports":        []string{"internal", "hybrid"},
+	})
+}
+
+func (a *softAuthenticator) get(t *testing.T, assertion *protocol.CredentialAssertion) []byte {
+	a.counter++
+	authData := a.authData(protocol.FlagUserPresent|protocol.FlagUserVerified, nil)
+	clientData := a.clientData(t, "webauthn.get", assertion.Response.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+	return a.encode(t, map[string]any{
+		"clientDataJSON":    clientData,
+		"authenticatorData": authData,
+		"signature":         sig,
+		"userHandle":        a.userHandle,
+	})
+}
+
+func (a *softAuthenticator) encode(t *testing.T, response map[string]any) []byte {
+	enc := map[string]any{}
+	for k, v := range response {
+		if b, ok := v.([]byte); ok {
+			v = base64.RawURLEncoding.EncodeToString(b)
+		} else if b, ok := v.(protocol.URLEncodedBase64); ok {
+			v = base64.RawURLEncoding.EncodeToString(b)
+		}
+		enc[k] = v
+	}
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	data, err := json.Marshal(map[string]any{"id": id, "rawId": id, "type": "public-key", "response": enc})
+	require.NoError(t, err)
+	return data
+}
+
+func newPasskeyServiceForTest(t *testing.T) (*PasskeyService, *passkeyRepoStub, *mockSettingRepo, *passkeyUserRepoStub) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	users := &passkeyUserRepoStub{users: map[int64]*User{
+		1: {ID: 1, Email: "admin@example.com", Role: RoleAdmin, Status: StatusActive, PasswordHash: string(hash)},
+		2: {ID: 2, Email: "user@example.com", Role: RoleUser, Status: StatusActive, PasswordHash: string(hash)},
+	}}
+	settings := newMockSettingRepo()
+	settings.data[SettingKeyPasskeyEnabled] = "true"
+	settings.data[SettingKeyFrontendURL] = passkeyTestOrigin + "/"
+	repo := &passkeyRepoStub{passkeys: map[int64]*UserPasskey{}}
+	cache := &passkeyCacheStub{challenges: map[string]PasskeyChallenge{}}
+	svc := NewPasskeyService(repo, cache, users, NewSettingService(settings, &config.Config{}), nil)
+	return svc, repo, settings, users
+}
+
+func registerSoftPasskey(t *testing.T, svc *PasskeyService, userID int64) (*softAuthenticator, *UserPasskey) {
+	ctx := context.Background()
+	auth := newSoftAuthenticator(t)
+	creation, err := svc.BeginRegistration(ctx, userID, " Laptop ", "", "secret-pass")
+	require.NoError(t, err)
+	require.Equal(t, passkeyTestRPID, creation.Response.RelyingParty.ID)
+	pk, err := svc.FinishRegistration(ctx, userID, auth.create(t, creation))
+	require.NoError(t, err)
+	return auth, pk
+}
+
+func TestPasskeyService_RegisterAndPasswordlessLogin(t *testing.T) {
+	svc, repo, _, _ := newPasskeyServiceForTest(t)
+	ctx := context.Background()
+
+	auth, pk := registerSoftPasskey(t, svc, 2)
+	require.Equal(t, "Laptop", pk.Name)
+	require.Equal(t, []string{"internal", "hybrid"}, pk.Transports)
+	require.Equal(t, auth.credentialID, pk.CredentialID)
+
+	// 同一凭据不能重复注册（排除列表由浏览器处理，服务端兜底）
+	creation, err := svc.BeginRegistration(ctx, 2, "", "", "secret-pass")
+	require.NoError(t, err)
+	require.Len(t, creation.Response.CredentialExcludeList, 1)
+	_, err = svc.FinishRegistration(ctx, 2, auth.create(t, creation))
+	require.ErrorIs(t, err, ErrPasskeyAlreadyRegistered)
+
+	for i := 0; i < 2; i++ {
+		opts, err := svc.BeginLogin(ctx)
+		require.NoError(t, err)
+		require.Equal(t, protocol.VerificationRequired, opts.Options.Response.UserVerification)
+		user, err := svc.FinishLogin(ctx, opts.ChallengeToken, auth.get(t, opts.Options))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), user.ID)
+
+		// 挑战只能使用一次
+		_, err = svc.FinishLogin(ctx, opts.ChallengeToken, auth.get(t, opts.Options))
+		require.ErrorIs(t, err, ErrPasskeyChallengeExpired)
+	}
+	stored := repo.passkeys[pk.ID]
+	require.Equal(t, auth.counter-1, stored.SignCount, "the replayed assertion was not accepted")
+	require.NotNil(t, stored.LastUsedAt)
+
+	// 来自其他源的断言被拒绝
+	auth.origin = "https://evil.example.com"
+	opts, err := svc.BeginLogin(ctx)
+	require.NoError(t, err)
+	_, err = svc.FinishLogin(ctx, opts.ChallengeToken, auth.get(t, opts.Options))
+	require.ErrorIs(t, err, ErrPasskeyVerifyFailed)
+}
+
+func TestPasskeyService_SecondFactorAndCloneDetection(t *testing.T) {
+	svc, repo, _, _ := newPasskeyServiceForTest(t)
+	ctx := context.Background()
+
+	require.False(t, svc.HasSecondFactor(ctx, 2))
+	_, err := svc.BeginSecondFactor(ctx, "temp", 2)
+	require.ErrorIs(t, err, ErrPasskeyNotFound)
+
+	auth, pk := registerSoftPasskey(t, svc, 2)
+	require.True(t, svc.HasSecondFactor(ctx, 2))
+
+	assertion, err := svc.BeginSecondFactor(ctx, "temp", 2)
+	require.NoError(t, err)
+	require.Len(t, assertion.Response.AllowedCredentials, 1)
+	require.ErrorIs(t, svc.FinishSecondFactor(ctx, "temp", 1, auth.get(t, assertion)), ErrPasskeyChallengeExpired,
+		"challenge is bound to the 2FA session's user")
+
+	assertion, err = svc.BeginSecondFactor(ctx, "temp", 2)
+	require.NoError(t, err)
+	require.NoError(t, svc.FinishSecondFactor(ctx, "temp", 2, auth.get(t, assertion)))
+
+	// 计数器回退：疑似克隆，拒绝并持久标记
+	auth.counter = 0
+	assertion, err = svc.BeginSecondFactor(ctx, "temp", 2)
+	require.NoError(t, err)
+	require.ErrorIs(t, svc.FinishSecondFactor(ctx, "temp", 2, auth.get(t, assertion)), ErrPasskeyVerifyFailed)
+	require.True(t, repo.passkeys[pk.ID].CloneWarning)
+}
+
+func TestPasskeyService_ManagementAndGuards(t *testing.T) {
+	svc, repo, settings, _ := newPasskeyServiceForTest(t)
+	ctx := context.Background()
+
+	_, err := svc.BeginRegistration(ctx, 2, "", "", "wrong")
+	require.ErrorIs(t, err, ErrPasswordIncorrect)
+	_, err = svc.BeginRegistration(ctx, 2, "", "", "")
+	require.ErrorIs(t, err, ErrPasswordRequired)
+	_, err = svc.FinishRegistration(ctx, 2, []byte(`{}`))
+	require.ErrorIs(t, err, ErrPasskeyChallengeExpired)
+
+	_, pk := registerSoftPasskey(t, svc, 2)
+	require.ErrorIs(t, svc.Rename(ctx, 1, pk.ID, "x"), ErrPasskeyNotFound, "cannot rename another user's passkey")
+	require.ErrorIs(t, svc.Rename(ctx, 2, pk.ID, "  "), ErrPasskeyNameInvalid)
+	require.NoError(t, svc.Rename(ctx, 2, pk.ID, "Phone"))
+	list, err := svc.List(ctx, 2)
+	require.NoError(t, err)
+	require.True(t, list.FeatureEnabled)
+	require.Equal(t, "Phone", list.Passkeys[0].Name)
+
+	require.ErrorIs(t, svc.Delete(ctx, 2, pk.ID, "", "wrong"), ErrPasswordIncorrect)
+	require.NoError(t, svc.Delete(ctx, 2, pk.ID, "", "secret-pass"))
+	require.Empty(t, repo.passkeys)
+
+	delete(settings.data, SettingKeyFrontendURL)
+	_, err = svc.BeginLogin(ctx)
+	require.ErrorIs(t, err, ErrPasskeyNotConfigured)
+	settings.data[SettingKeyPasskeyEnabled] = "false"
+	_, err = svc.BeginLogin(ctx)
+	require.ErrorIs(t, err, ErrPasskeyNotEnabled)
+}
+
+func TestPasskeyService_RequiresSecondFactorSetup(t *testing.T) {
+	svc, _, settings, users := newPasskeyServiceForTest(t)
+	ctx := context.Background()
+	admin, user := users.users[1], users.users[2]
+
+	require.False(t, svc.RequiresSecondFactorSetup(ctx, admin), "not enforced by default")
+
+	settings.data[SettingKeyAdmin2FARequired] = "true"
+	require.True(t, svc.RequiresSecondFactorSetup(ctx, admin))
+	require.False(t, svc.RequiresSecondFactorSetup(ctx, user), "only admins are enforced")
+	require.True(t, AdminRequiresSecondFactorSetup(ctx, svc.settingService, nil, admin), "enforced without a passkey service")
+
+	admin.TotpEnabled = true
+	require.True(t, svc.RequiresSecondFactorSetup(ctx, admin), "TOTP only counts while the feature is enabled")
+	settings.data[SettingKeyTotpEnabled] = "true"
+	require.False(t, svc.RequiresSecondFactorSetup(ctx, admin))
+
+	require.False(t, AdminRequiresSecondFactorSetup(ctx, svc.settingService, nil, admin), "TOTP is accepted without a passkey service")
+
+	admin.TotpEnabled = false
+	registerSoftPasskey(t, svc, 1)
+	require.False(t, svc.RequiresSecondFactorSetup(ctx, admin))
+	require.True(t, AdminRequiresSecondFactorSetup(ctx, svc.settingService, nil, admin), "passkeys only count when the passkey service is available")
+}
@@ -0,0 +1,537 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	PricingRevisionStatusPending    = "pending"
+	PricingRevisionStatusApplied    = "applied"
+	PricingRevisionStatusRejected   = "rejected"
+	PricingRevisionStatusSuperseded = "superseded"
+)
+
+const (
+	PriceChangeAdded           = "added"
+	PriceChangeChanged         = "changed"
+	PriceChangeRemoved         = "removed"
+	PriceChangeOverrideSet     = "override_set"
+	PriceChangeOverrideRemoved = "override_removed"
+)
+
+const maxPriceOverrideModelLength = 255
+
+var (
+	ErrPricingRevisionNotFound   = infraerrors.NotFound("PRICING_REVISION_NOT_FOUND", "pricing revision not found")
+	ErrPricingRevisionNotPending = infraerrors.Conflict("PRICING_REVISION_NOT_PENDING", "pricing revision is not pending review")
+	ErrPricingRevisionStale      = infraerrors.Conflict("PRICING_REVISION_STALE", "pricing revision was computed against an outdated snapshot, sync again to get a fresh diff")
+	ErrPriceOverrideNotFound     = infraerrors.NotFound("PRICE_OVERRIDE_NOT_FOUND", "price override not found")
+	ErrPriceOverrideInvalid      = infraerrors.BadRequest("PRICE_OVERRIDE_INVALID", "model is required and prices must be non-negative")
+)
+
+// PricingDiffEntry 价格快照之间单个模型的差异
+type PricingDiffEntry struct {
+	Model      string               `json:"model"`
+	ChangeType string               `json:"change_type"`
+	Old        *LiteLLMModelPricing `json:"old,omitempty"`
+	New        *LiteLLMModelPricing `json:"new,omitempty"`
+	// Source 新价格来自哪个数据源（删除时为空）
+	Source string `json:"source,omitempty"`
+	// Overridden 该模型存在管理员覆盖，审核通过后实际计费价格不受影响
+	Overridden bool `json:"overridden"`
+}
+
+// PricingRevision 多数据源合并后的价格快照修订
+type PricingRevision struct {
+	ID             int64                           `json:"id"`
+	Status         string                          `json:"status"`
+	SnapshotHash   string                          `json:"snapshot_hash"`
+	Snapshot       map[string]*LiteLLMModelPricing `json:"-"`
+	Diff           []PricingDiffEntry              `json:"diff,omitempty"`
+	Sources        []string                        `json:"sources"`
+	BaseRevisionID *int64                          `json:"base_revision_id,omitempty"`
+	ModelCount     int                             `json:"model_count"`
+	AddedCount     int                             `json:"added_count"`
+	ChangedCount   int                             `json:"changed_count"`
+	RemovedCount   int                             `json:"removed_count"`
+	ReviewedBy     *int64                          `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time                      `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time                       `json:"created_at"`
+}
+
+// ModelPriceOverride 管理员覆盖的模型价格，始终叠加在已生效快照之上
+type ModelPriceOverride struct {
+	Model     string              `json:"model"`
+	Pricing   LiteLLMModelPricing `json:"pricing"`
+	Note      string              `json:"note"`
+	UpdatedBy *int64              `json:"updated_by,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// ModelPriceHistory 实际生效的价格变更记录
+type ModelPriceHistory struct {
+	ID         int64                `json:"id"`
+	Model      string               `json:"model"`
+	ChangeType string               `json:"change_type"`
+	OldPricing *LiteLLMModelPricing `json:"old_pricing,omitempty"`
+	NewPricing *LiteLLMModelPricing `json:"new_pricing,omitempty"`
+	RevisionID *int64               `json:"revision_id,omitempty"`
+	ActorID    *int64               `json:"actor_id,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// PricingRevisionListFilter 修订列表筛选条件
+type PricingRevisionListFilter struct {
+	Status string
+}
+
+// PricingRepository 价格修订、覆盖与历史的持久化
+type PricingRepository interface {
+	// GetRevision 返回完整修订（含快照和差异）
+	GetRevision(ctx context.Context, id int64) (*PricingRevision, error)
+	// ListRevisions 按 ID 倒序返回修订摘要（不含快照和差异）
+	ListRevisions(ctx context.Context, filter PricingRevisionListFilter, params pagination.PaginationParams) ([]PricingRevision, *pagination.PaginationResult, error)
+	// CreateRevision 写入新修订：pending 修订会取代其他待审核修订；applied 修订同时写入 history
+	CreateRevision(ctx context.Context, rev *PricingRevision, history []ModelPriceHistory) error
+	// ReviewRevision 将待审核修订置为 applied/rejected；修订不处于 pending 时返回 ErrPricingRevisionNotPending
+	ReviewRevision(ctx context.Context, id int64, status string, reviewerID *int64, history []ModelPriceHistory) error
+
+	ListOverrides(ctx context.Context) ([]ModelPriceOverride, error)
+	UpsertOverride(ctx context.Context, override *ModelPriceOverride, history *ModelPriceHistory) error
+	// DeleteOverride 覆盖不存在时返回 ErrPriceOverrideNotFound
+	DeleteOverride(ctx context.Context, model string, history *ModelPriceHistory) error
+
+	ListHistory(ctx context.Context, model string, params pagination.PaginationParams) ([]ModelPriceHistory, *pagination.PaginationResult, error)
+}
+
+// PricingSyncResult 一次数据源同步的结果
+type PricingSyncResult struct {
+	// Changed 合并结果与已生效快照不同
+	Changed bool `json:"changed"`
+	// Applied 变化已直接生效（首次同步或未开启审核）；否则等待审核
+	Applied  bool             `json:"applied"`
+	Revision *PricingRevision `json:"revision,omitempty"`
+}
+
+// PricingSyncStatus 价格同步状态（管理后台展示）
+type PricingSyncStatus struct {
+	ModelCount       int                   `json:"model_count"`
+	OverrideCount    int                   `json:"override_count"`
+	ActiveRevisionID int64                 `json:"active_revision_id"`
+	SnapshotHash     string                `json:"snapshot_hash"`
+	LastUpdated      time.Time             `json:"last_updated"`
+	LastSyncAt       time.Time             `json:"last_sync_at"`
+	LastSyncError    string                `json:"last_sync_error,omitempty"`
+	RequireApproval  bool                  `json:"require_approval"`
+	Sources          []PricingSourceStatus `json:"sources"`
+	PendingRevision  *PricingRevision      `json:"pending_revision,omitempty"`
+}
+
+// SetPriceOverrideInput 设置价格覆盖参数
+type SetPriceOverrideInput struct {
+	Model   string
+	Pricing LiteLLMModelPricing
+	Note    string
+	ActorID *int64
+}
+
+// SyncNow 立即拉取所有数据源（忽略缓存），有变化时生成修订
+func (s *PricingService) SyncNow(ctx context.Context) (*PricingSyncResult, error) {
+	return s.syncSources(ctx, true)
+}
+
+// syncSources 合并所有数据源并与已生效快照比较。
+// 首次同步（数据库中没有已生效修订）或未开启审核时直接生效，否则生成待审核修订，旧的待审核修订被取代。
+func (s *PricingService) syncSources(ctx context.Context, force bool) (*PricingSyncResult, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	merged, err := s.collectPricingSources(ctx, force)
+	s.mu.Lock()
+	s.lastSyncAt = time.Now()
+	s.lastSyncError = ""
+	if err != nil {
+		s.lastSyncError = err.Error()
+	}
+	base, baseHash, activeID, overrides := s.basePricing, s.localHash, s.activeRevisionID, s.overrides
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hashPricingSnapshot(merged.data)
+	if err != nil {
+		return nil, err
+	}
+	if hash == baseHash {
+		return &PricingSyncResult{}, nil
+	}
+
+	bootstrap := activeID == 0
+	requireApproval := s.repo != nil && !bootstrap && s.cfg.Pricing.RequireApproval
+	if requireApproval {
+		pending, _, err := s.repo.ListRevisions(ctx, PricingRevisionListFilter{Status: PricingRevisionStatusPending}, pagination.PaginationParams{Page: 1, PageSize: 1})
+		if err != nil {
+			return nil, fmt.Errorf("list pending pricing revisions: %w", err)
+		}
+		if len(pending) > 0 && pending[0].SnapshotHash == hash {
+			// 相同的变化已在等待审核
+			return &PricingSyncResult{Changed: true, Revision: &pending[0]}, nil
+		}
+	}
+
+	rev := &PricingRevision{
+		Status:       PricingRevisionStatusApplied,
+		SnapshotHash: hash,
+		Snapshot:     merged.data,
+		Sources:      merged.sources,
+		ModelCount:   len(merged.data),
+	}
+	if requireApproval {
+		rev.Status = PricingRevisionStatusPending
+	}
+	// 首次同步没有可比较的基线，不生成差异与历史，避免写入数千条"新增"
+	if !bootstrap {
+		rev.BaseRevisionID = &activeID
+		rev.Diff = diffPricingSnapshots(base, merged.data, merged.modelSources, overrides)
+		for _, entry := range rev.Diff {
+			switch entry.ChangeType {
+			case PriceChangeAdded:
+				rev.AddedCount++
+			case PriceChangeChanged:
+				rev.ChangedCount++
+			case PriceChangeRemoved:
+				rev.RemovedCount++
+			}
+		}
+	}
+
+	if s.repo == nil {
+		s.applySnapshot(0, merged.data, hash)
+		return &PricingSyncResult{Changed: true, Applied: true, Revision: rev}, nil
+	}
+
+	var history []ModelPriceHistory
+	if rev.Status == PricingRevisionStatusApplied {
+		now := time.Now()
+		rev.ReviewedAt = &now
+		history = priceHistoryFromDiff(rev.Diff, nil)
+	}
+	if err := s.repo.CreateRevision(ctx, rev, history); err != nil {
+		return nil, fmt.Errorf("create pricing revision: %w", err)
+	}
+
+	if rev.Status == PricingRevisionStatusApplied {
+		s.applySnapshot(rev.ID, merged.data, hash)
+		logger.LegacyPrintf("service.pricing", "[Pricing] Revision %d applied: %d models (+%d ~%d -%d)",
+			rev.ID, rev.ModelCount, rev.AddedCount, rev.ChangedCount, rev.RemovedCount)
+		return &PricingSyncResult{Changed: true, Applied: true, Revision: rev}, nil
+	}
+	logger.LegacyPrintf("service.pricing", "[Pricing] Revision %d pending review: +%d ~%d -%d",
+		rev.ID, rev.AddedCount, rev.ChangedCount, rev.RemovedCount)
+	return &PricingSyncResult{Changed: true, Revision: rev}, nil
+}
+
+// ApproveRevision 审核通过待审核修订，使其快照生效
+func (s *PricingService) ApproveRevision(ctx context.Context, id int64, actorID *int64) (*PricingRevision, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	// 其他实例可能已应用了新的修订，先与数据库对齐再判断基线
+	if err := s.reloadFromStore(ctx); err != nil {
+		return nil, err
+	}
+	rev, err := s.repo.GetRevision(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rev.Status != PricingRevisionStatusPending {
+		return nil, ErrPricingRevisionNotPending
+	}
+	s.mu.RLock()
+	activeID := s.activeRevisionID
+	s.mu.RUnlock()
+	if rev.BaseRevisionID == nil || *rev.BaseRevisionID != activeID {
+		return nil, ErrPricingRevisionStale
+	}
+
+	if err := s.repo.ReviewRevision(ctx, id, PricingRevisionStatusApplied, actorID, priceHistoryFromDiff(rev.Diff, actorID)); err != nil {
+		return nil, err
+	}
+	s.applySnapshot(rev.ID, rev.Snapshot, rev.SnapshotHash)
+
+	now := time.Now()
+	rev.Status = PricingRevisionStatusApplied
+	rev.ReviewedBy = actorID
+	rev.ReviewedAt = &now
+	logger.LegacyPrintf("service.pricing", "[Pricing] Revision %d approved: %d models (+%d ~%d -%d)",
+		rev.ID, rev.ModelCount, rev.AddedCount, rev.ChangedCount, rev.RemovedCount)
+	return rev, nil
+}
+
+// RejectRevision 拒绝待审核修订，已生效价格保持不变
+func (s *PricingService) RejectRevision(ctx context.Context, id int64, actorID *int64) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.repo.ReviewRevision(ctx, id, PricingRevisionStatusRejected, actorID, nil)
+}
+
+// GetRevision 获取修订详情（含差异）
+func (s *PricingService) GetRevision(ctx context.Context, id int64) (*PricingRevision, error) {
+	return s.repo.GetRevision(ctx, id)
+}
+
+// ListRevisions 分页列出修订摘要
+func (s *PricingService) ListRevisions(ctx context.Context, filter PricingRevisionListFilter, params pagination.PaginationParams) ([]PricingRevision, *pagination.PaginationResult, error) {
+	return s.repo.ListRevisions(ctx, filter, params)
+}
+
+// ListOverrides 列出所有价格覆盖
+func (s *PricingService) ListOverrides(ctx context.Context) ([]ModelPriceOverride, error) {
+	return s.repo.ListOverrides(ctx)
+}
+
+// SetOverride 设置（或更新）模型价格覆盖，立即生效且不受后续同步影响
+func (s *PricingService) SetOverride(ctx context.Context, input *SetPriceOverrideInput) (*ModelPriceOverride, error) {
+	model := strings.ToLower(strings.TrimSpace(input.Model))
+	if model == "" || len(model) > maxPriceOverrideModelLength || !validOverridePricing(&input.Pricing) {
+		return nil, ErrPriceOverrideInvalid
+	}
+
+	s.mu.RLock()
+	oldPricing := s.pricingData[model]
+	s.mu.RUnlock()
+
+	pricing := input.Pricing
+	override := &ModelPriceOverride{
+		Model:     model,
+		Pricing:   pricing,
+		Note:      strings.TrimSpace(input.Note),
+		UpdatedBy: input.ActorID,
+	}
+	history := &ModelPriceHistory{
+		Model:      model,
+		ChangeType: PriceChangeOverrideSet,
+		OldPricing: oldPricing,
+		NewPricing: &pricing,
+		ActorID:    input.ActorID,
+	}
+	if err := s.repo.UpsertOverride(ctx, override, history); err != nil {
+		return nil, err
+	}
+	if err := s.reloadOverrides(ctx); err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// DeleteOverride 删除价格覆盖，该模型恢复为已生效快照中的价格
+func (s *PricingService) DeleteOverride(ctx context.Context, model string, actorID *int64) error {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return ErrPriceOverrideInvalid
+	}
+
+	s.mu.RLock()
+	oldPricing := s.pricingData[model]
+	newPricing := s.basePricing[model]
+	s.mu.RUnlock()
+
+	history := &ModelPriceHistory{
+		Model:      model,
+		ChangeType: PriceChangeOverrideRemoved,
+		OldPricing: oldPricing,
+		NewPricing: newPricing,
+		ActorID:    actorID,
+	}
+	if err := s.repo.DeleteOverride(ctx, model, history); err != nil {
+		return err
+	}
+	return s.reloadOverrides(ctx)
+}
+
+// ListHistory 分页列出价格变更历史，model 为空时返回全部模型
+func (s *PricingService) ListHistory(ctx context.Context, model string, params pagination.PaginationParams) ([]ModelPriceHistory, *pagination.PaginationResult, error) {
+	return s.repo.ListHistory(ctx, strings.ToLower(strings.TrimSpace(model)), params)
+}
+
+// GetSyncStatus 返回同步状态、各数据源状态和当前待审核修订
+func (s *PricingService) GetSyncStatus(ctx context.Context) (*PricingSyncStatus, error) {
+	s.mu.RLock()
+	status := &PricingSyncStatus{
+		ModelCount:       len(s.pricingData),
+		OverrideCount:    len(s.overrides),
+		ActiveRevisionID: s.activeRevisionID,
+		SnapshotHash:     s.localHash,
+		LastUpdated:      s.lastUpdated,
+		LastSyncAt:       s.lastSyncAt,
+		LastSyncError:    s.lastSyncError,
+		RequireApproval:  s.cfg.Pricing.RequireApproval,
+		Sources:          append([]PricingSourceStatus(nil), s.sourceStatuses...),
+	}
+	s.mu.RUnlock()
+
+	if s.repo != nil {
+		pending, _, err := s.repo.ListRevisions(ctx, PricingRevisionListFilter{Status: PricingRevisionStatusPending}, pagination.PaginationParams{Page: 1, PageSize: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) > 0 {
+			status.PendingRevision = &pending[0]
+		}
+	}
+	return status, nil
+}
+
+// reloadFromStore 从数据库加载覆盖和最新的已生效修订（仅在修订变化时加载完整快照）
+func (s *PricingService) reloadFromStore(ctx context.Context) error {
+	if s.repo == nil {
+		return nil
+	}
+	if err := s.reloadOverrides(ctx); err != nil {
+		return err
+	}
+
+	latest, _, err := s.repo.ListRevisions(ctx, PricingRevisionListFilter{Status: PricingRevisionStatusApplied}, pagination.PaginationParams{Page: 1, PageSize: 1})
+	if err != nil {
+		return fmt.Errorf("list applied pricing revisions: %w", err)
+	}
+	if len(latest) == 0 {
+		return nil
+	}
+	s.mu.RLock()
+	activeID := s.activeRevisionID
+	s.mu.RUnlock()
+	if latest[0].ID == activeID {
+		return nil
+	}
+
+	rev, err := s.repo.GetRevision(ctx, latest[0].ID)
+	if err != nil {
+		return fmt.Errorf("load pricing revision %d: %w", latest[0].ID, err)
+	}
+	s.applySnapshot(rev.ID, rev.Snapshot, rev.SnapshotHash)
+	logger.LegacyPrintf("service.pricing", "[Pricing] Loaded revision %d with %d models", rev.ID, len(rev.Snapshot))
+	return nil
+}
+
+func (s *PricingService) reloadOverrides(ctx context.Context) error {
+	list, err := s.repo.ListOverrides(ctx)
+	if err != nil {
+		return fmt.Errorf("list price overrides: %w", err)
+	}
+	overrides := make(map[string]*LiteLLMModelPricing, len(list))
+	for i := range list {
+		pricing := list[i].Pricing
+		overrides[list[i].Model] = &pricing
+	}
+
+	s.mu.Lock()
+	s.overrides = overrides
+	s.rebuildPricingLocked()
+	s.mu.Unlock()
+	return nil
+}
+
+// applySnapshot 替换已生效快照并重建生效价格表
+func (s *PricingService) applySnapshot(revisionID int64, snapshot map[string]*LiteLLMModelPricing, hash string) {
+	s.mu.Lock()
+	s.basePricing = snapshot
+	s.activeRevisionID = revisionID
+	s.localHash = hash
+	s.lastUpdated = time.Now()
+	s.rebuildPricingLocked()
+	s.mu.Unlock()
+}
+
+// rebuildPricingLocked 生效价格 = 已生效快照 + 管理员覆盖，调用方需持有写锁
+func (s *PricingService) rebuildPricingLocked() {
+	data := make(map[string]*LiteLLMModelPricing, len(s.basePricing)+len(s.overrides))
+	for model, pricing := range s.basePricing {
+		data[model] = pricing
+	}
+	for model, pricing := range s.overrides {
+		data[model] = pricing
+	}
+	s.pricingData = data
+}
+
+// diffPricingSnapshots 按模型名排序返回 oldData -> newData 的差异
+func diffPricingSnapshots(oldData, newData map[string]*LiteLLMModelPricing, modelSources map[string]string, overrides map[string]*LiteLLMModelPricing) []PricingDiffEntry {
+	diff := make([]PricingDiffEntry, 0)
+	for model, newPricing := range newData {
+		oldPricing, exists := oldData[model]
+		_, overridden := overrides[model]
+		switch {
+		case !exists:
+			diff = append(diff, PricingDiffEntry{Model: model, ChangeType: PriceChangeAdded, New: newPricing, Source: modelSources[model], Overridden: overridden})
+		case !reflect.DeepEqual(oldPricing, newPricing):
+			diff = append(diff, PricingDiffEntry{Model: model, ChangeType: PriceChangeChanged, Old: oldPricing, New: newPricing, Source: modelSources[model], Overridden: overridden})
+		}
+	}
+	for model, oldPricing := range oldData {
+		if _, exists := newData[model]; !exists {
+			_, overridden := overrides[model]
+			diff = append(diff, PricingDiffEntry{Model: model, ChangeType: PriceChangeRemoved, Old: oldPricing, Overridden: overridden})
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool { return diff[i].Model < diff[j].Model })
+	return diff
+}
+
+func priceHistoryFromDiff(diff []PricingDiffEntry, actorID *int64) []ModelPriceHistory {
+	history := make([]ModelPriceHistory, 0, len(diff))
+	for _, entry := range diff {
+		history = append(history, ModelPriceHistory{
+			Model:      entry.Model,
+			ChangeType: entry.ChangeType,
+			OldPricing: entry.Old,
+			NewPricing: entry.New,
+			ActorID:    actorID,
+		})
+	}
+	return history
+}
+
+// hashPricingSnapshot 计算快照哈希（json.Marshal 对 map 键排序，结果稳定）
+func hashPricingSnapshot(data map[string]*LiteLLMModelPricing) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("marshal pricing snapshot: %w", err)
+	}
+	return sha256Hex(body), nil
+}
+
+func validOverridePricing(p *LiteLLMModelPricing) bool {
+	prices := []float64{
+		p.InputCostPerToken,
+		p.InputCostPerTokenPriority,
+		p.OutputCostPerToken,
+		p.OutputCostPerTokenPriority,
+		p.CacheCreationInputTokenCost,
+		p.CacheCreationInputTokenCostAbove1hr,
+		p.CacheReadInputTokenCost,
+		p.CacheReadInputTokenCostPriority,
+		p.LongContextInputCostMultiplier,
+		p.LongContextOutputCostMultiplier,
+		p.OutputCostPerImage,
+	}
+	for _, v := range prices {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return p.LongContextInputTokenThreshold >= 0
+}
@@ -0,0 +1,485 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type pricingRepoStub struct {
+	mu        sync.Mutex
+	nextID    int64
+	revisions []*PricingRevision
+	overrides map[string]ModelPriceOverride
+	history   []ModelPriceHistory
+}
+
+func newPricingRepoStub() *pricingRepoStub {
+	return &pricingRepoStub{overrides: make(map[string]ModelPriceOverride)}
+}
+
+func (r *pricingRepoStub) GetRevision(_ context.Context, id int64) (*PricingRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rev := range r.revisions {
+		if rev.ID == id {
+			cp := *rev
+			return &cp, nil
+		}
+	}
+	return nil, ErrPricingRevisionNotFound
+}
+
+func (r *pricingRepoStub) ListRevisions(_ context.Context, filter PricingRevisionListFilter, params pagination.PaginationParams) ([]PricingRevision, *pagination.PaginationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]PricingRevision, 0)
+	for i := len(r.revisions) - 1; i >= 0; i-- {
+		rev := *r.revisions[i]
+		if filter.Status != "" && rev.Status != filter.Status {
+			continue
+		}
+		rev.Snapshot, rev.Diff = nil, nil
+		out = append(out, rev)
+	}
+	total := int64(len(out))
+	if len(out) > params.Limit() {
+		out = out[:params.Limit()]
+	}
+	return out, &pagination.PaginationResult{Total: total}, nil
+}
+
+func (r *pricingRepoStub) CreateRevision(_ context.Context, rev *PricingRevision, history []ModelPriceHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rev.Status == PricingRevisionStatusPending {
+		for _, existing := range r.revisions {
+			if existing.Status == PricingRevisionStatusPending {
+				existing.Status = PricingRevisionStatusSuperseded
+			}
+		}
+	}
+	r.nextID++
+	rev.ID = r.nextID
+	rev.CreatedAt = time.Now()
+	cp := *rev
+	r.revisions = append(r.revisions, &cp)
+	if rev.Status == PricingRevisionStatusApplied {
+		r.appendHistory(history, &rev.ID)
+	}
+	return nil
+}
+
+func (r *pricingRepoStub) ReviewRevision(_ context.Context, id int64, status string, reviewerID *int64, history []ModelPriceHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rev := range r.revisions {
+		if rev.ID != id {
+			continue
+		}
+		if rev.Status != PricingRevisionStatusPending {
+			return ErrPricingRevisionNotPending
+		}
+		rev.Status = status
+		rev.ReviewedBy = reviewerID
+		if status == PricingRevisionStatusApplied {
+			r.appendHistory(history, &id)
+		}
+		return nil
+	}
+	return ErrPricingRevisionNotFound
+}
+
+func (r *pricingRepoStub) ListOverrides(_ context.Context) ([]ModelPriceOverride, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]ModelPriceOverride, 0, len(r.overrides))
+	for _, o := range r.overrides {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+func (r *pricingRepoStub) UpsertOverride(_ context.Context, override *ModelPriceOverride, history *ModelPriceHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[override.Model] = *override
+	r.appendHistory([]ModelPriceHistory{*history}, nil)
+	return nil
+}
+
+func (r *pricingRepoStub) DeleteOverride(_ context.Context, model string, history *ModelPriceHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.overrides[model]; !ok {
+		return ErrPriceOverrideNotFound
+	}
+	delete(r.overrides, model)
+	r.appendHistory([]ModelPriceHistory{*history}, nil)
+	return nil
+}
+
+func (r *pricingRepoStub) ListHistory(_ context.Context, model string, _ pagination.PaginationParams) ([]ModelPriceHistory, *pagination.PaginationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]ModelPriceHistory, 0)
+	for _, h := range r.history {
+		if model == "" || h.Model == model {
+			out = append(out, h)
+		}
+	}
+	return out, &pagination.PaginationResult{Total: int64(len(out))}, nil
+}
+
+func (r *pricingRepoStub) appendHistory(history []ModelPriceHistory, revisionID *int64) {
+	for _, h := range history {
+		if revisionID != nil {
+			id := *revisionID
+			h.RevisionID = &id
+		}
+		r.history = append(r.history, h)
+	}
+}
+
+// pricingRemoteStub 按 URL 返回固定内容并记录下载次数
+type pricingRemoteStub struct {
+	bodies    map[string][]byte
+	hashes    map[string]string
+	downloads int
+}
+
+func (c *pricingRemoteStub) FetchPricingJSON(_ context.Context, url string) ([]byte, error) {
+	c.downloads++
+	body, ok := c.bodies[url]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return body, nil
+}
+
+func (c *pricingRemoteStub) FetchHashText(_ context.Context, url string) (string, error) {
+	hash, ok := c.hashes[url]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return hash, nil
+}
+
+type pricingTestEnv struct {
+	svc      *PricingService
+	repo     *pricingRepoStub
+	upstream string
+}
+
+func newPricingTestEnv(t *testing.T, requireApproval bool, extraSources ...config.PricingSourceConfig) *pricingTestEnv {
+	t.Helper()
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "litellm.json")
+	writePricingFile(t, upstream, `{
+		"claude-sonnet-4-5": {"input_cost_per_token": 3e-6, "output_cost_per_token": 1.5e-5},
+		"gpt-5.1-codex": {"input_cost_per_token": 1.25e-6, "output_cost_per_token": 1e-5}
+	}`)
+	cfg := &config.Config{Pricing: config.PricingConfig{
+		DataDir:         dir,
+		RequireApproval: requireApproval,
+		Sources: append([]config.PricingSourceConfig{
+			{Name: "litellm", Type: PricingSourceTypeLiteLLM, Path: upstream},
+		}, extraSources...),
+	}}
+	repo := newPricingRepoStub()
+	return &pricingTestEnv{svc: NewPricingService(cfg, nil, repo), repo: repo, upstream: upstream}
+}
+
+func writePricingFile(t *testing.T, path, body string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+}
+
+func TestPricingSync_FirstSyncAppliesWithoutHistory(t *testing.T) {
+	env := newPricingTestEnv(t, true)
+
+	result, err := env.svc.syncSources(context.Background(), false)
+	require.NoError(t, err)
+	require.True(t, result.Applied)
+	require.Equal(t, PricingRevisionStatusApplied, env.repo.revisions[0].Status)
+	require.Empty(t, env.repo.history)
+	require.InDelta(t, 3e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+	// 内置补丁并入快照
+	require.NotNil(t, env.svc.GetModelPricing("gpt-5.4"))
+
+	// 无变化时不生成新修订
+	result, err = env.svc.syncSources(context.Background(), false)
+	require.NoError(t, err)
+	require.False(t, result.Changed)
+	require.Len(t, env.repo.revisions, 1)
+}
+
+func TestPricingSync_ChangeWaitsForApproval(t *testing.T) {
+	env := newPricingTestEnv(t, true)
+	ctx := context.Background()
+	_, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+
+	// 上游把价格错改成 100 倍，并删除一个模型、新增一个模型
+	writePricingFile(t, env.upstream, `{
+		"claude-sonnet-4-5": {"input_cost_per_token": 3e-4, "output_cost_per_token": 1.5e-5},
+		"claude-haiku-4-5": {"input_cost_per_token": 1e-6, "output_cost_per_token": 5e-6}
+	}`)
+	result, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+	require.True(t, result.Changed)
+	require.False(t, result.Applied)
+	rev := result.Revision
+	require.Equal(t, PricingRevisionStatusPending, rev.Status)
+	require.Equal(t, 1, rev.AddedCount)
+	require.Equal(t, 1, rev.ChangedCount)
+	require.Equal(t, 1, rev.RemovedCount)
+	require.Equal(t, "litellm", rev.Diff[1].Source)
+
+	// 审核前计费价格不变
+	require.InDelta(t, 3e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+	require.Nil(t, env.svc.GetModelPricing("claude-haiku-4-5"))
+
+	// 重复同步不会产生重复的待审核修订
+	again, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, rev.ID, again.Revision.ID)
+	require.Len(t, env.repo.revisions, 2)
+
+	status, err := env.svc.GetSyncStatus(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, status.PendingRevision)
+	require.Equal(t, rev.ID, status.PendingRevision.ID)
+
+	adminID := int64(7)
+	approved, err := env.svc.ApproveRevision(ctx, rev.ID, &adminID)
+	require.NoError(t, err)
+	require.Equal(t, PricingRevisionStatusApplied, approved.Status)
+	require.InDelta(t, 3e-4, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+	require.NotNil(t, env.svc.GetModelPricing("claude-haiku-4-5"))
+
+	history, _, err := env.svc.ListHistory(ctx, "claude-sonnet-4-5", pagination.PaginationParams{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, PriceChangeChanged, history[0].ChangeType)
+	require.InDelta(t, 3e-6, history[0].OldPricing.InputCostPerToken, 1e-12)
+	require.Equal(t, rev.ID, *history[0].RevisionID)
+	require.Equal(t, adminID, *history[0].ActorID)
+
+	_, err = env.svc.ApproveRevision(ctx, rev.ID, &adminID)
+	require.ErrorIs(t, err, ErrPricingRevisionNotPending)
+}
+
+func TestPricingSync_RejectKeepsActivePrices(t *testing.T) {
+	env := newPricingTestEnv(t, true)
+	ctx := context.Background()
+	_, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+
+	writePricingFile(t, env.upstream, `{"claude-sonnet-4-5": {"input_cost_per_token": 0, "output_cost_per_token": 0}}`)
+	result, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+
+	require.NoError(t, env.svc.RejectRevision(ctx, result.Revision.ID, nil))
+	require.InDelta(t, 3e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+	require.Empty(t, env.repo.history)
+}
+
+func TestPricingSync_WithoutApprovalAppliesWithHistory(t *testing.T) {
+	env := newPricingTestEnv(t, false)
+	ctx := context.Background()
+	_, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+
+	writePricingFile(t, env.upstream, `{
+		"claude-sonnet-4-5": {"input_cost_per_token": 4e-6, "output_cost_per_token": 1.5e-5},
+		"gpt-5.1-codex": {"input_cost_per_token": 1.25e-6, "output_cost_per_token": 1e-5}
+	}`)
+	result, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+	require.True(t, result.Applied)
+	require.InDelta(t, 4e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+	require.Len(t, env.repo.history, 1)
+	require.Nil(t, env.repo.history[0].ActorID)
+}
+
+func TestPricingSync_StaleRevisionCannotBeApproved(t *testing.T) {
+	env := newPricingTestEnv(t, true)
+	ctx := context.Background()
+	_, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+
+	writePricingFile(t, env.upstream, `{"claude-sonnet-4-5": {"input_cost_per_token": 5e-6, "output_cost_per_token": 1.5e-5}}`)
+	result, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+
+	// 模拟另一实例在此期间直接应用了新修订
+	other := &PricingRevision{
+		Status:       PricingRevisionStatusApplied,
+		SnapshotHash: "other",
+		Snapshot:     map[string]*LiteLLMModelPricing{"claude-sonnet-4-5": {InputCostPerToken: 6e-6}},
+	}
+	require.NoError(t, env.repo.CreateRevision(ctx, other, nil))
+
+	_, err = env.svc.ApproveRevision(ctx, result.Revision.ID, nil)
+	require.ErrorIs(t, err, ErrPricingRevisionStale)
+	// 审核前已与数据库对齐到另一实例的修订
+	require.InDelta(t, 6e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+}
+
+func TestPricingOverride_SurvivesSyncAndRecordsHistory(t *testing.T) {
+	env := newPricingTestEnv(t, false)
+	ctx := context.Background()
+	_, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+
+	adminID := int64(1)
+	_, err = env.svc.SetOverride(ctx, &SetPriceOverrideInput{
+		Model:   " Claude-Sonnet-4-5 ",
+		Pricing: LiteLLMModelPricing{InputCostPerToken: 2e-6, OutputCostPerToken: 1e-5},
+		Note:    "negotiated",
+		ActorID: &adminID,
+	})
+	require.NoError(t, err)
+	require.InDelta(t, 2e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+
+	writePricingFile(t, env.upstream, `{"claude-sonnet-4-5": {"input_cost_per_token": 9e-6, "output_cost_per_token": 1.5e-5}}`)
+	result, err := env.svc.syncSources(ctx, false)
+	require.NoError(t, err)
+	require.True(t, result.Applied)
+	require.True(t, result.Revision.Diff[0].Overridden)
+	require.InDelta(t, 2e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+
+	require.NoError(t, env.svc.DeleteOverride(ctx, "claude-sonnet-4-5", &adminID))
+	require.InDelta(t, 9e-6, env.svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+	require.ErrorIs(t, env.svc.DeleteOverride(ctx, "claude-sonnet-4-5", &adminID), ErrPriceOverrideNotFound)
+
+	history, _, err := env.svc.ListHistory(ctx, "claude-sonnet-4-5", pagination.PaginationParams{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, PriceChangeOverrideSet, history[0].ChangeType)
+	require.InDelta(t, 3e-6, history[0].OldPricing.InputCostPerToken, 1e-12)
+	require.Equal(t, PriceChangeChanged, history[1].ChangeType)
+	require.Equal(t, PriceChangeOverrideRemoved, history[2].ChangeType)
+	require.InDelta(t, 9e-6, history[2].NewPricing.InputCostPerToken, 1e-12)
+}
+
+func TestPricingOverride_RejectsInvalidInput(t *testing.T) {
+	env := newPricingTestEnv(t, false)
+	ctx := context.Background()
+
+	_, err := env.svc.SetOverride(ctx, &SetPriceOverrideInput{Model: "  "})
+	require.ErrorIs(t, err, ErrPriceOverrideInvalid)
+	_, err = env.svc.SetOverride(ctx, &SetPriceOverrideInput{Model: "m", Pricing: LiteLLMModelPricing{InputCostPerToken: -1}})
+	require.ErrorIs(t, err, ErrPriceOverrideInvalid)
+}
+
+func TestPricingSources_MergeByPriority(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local.yaml")
+	writePricingFile(t, local, `
+claude-sonnet-4-5:
+  input_cost_per_token: 2.9e-06
+  output_cost_per_token: 1.4e-05
+  litellm_provider: anthropic
+`)
+	openrouter := filepath.Join(dir, "openrouter.json")
+	writePricingFile(t, openrouter, `{"data": [
+		{"id": "anthropic/claude-sonnet-4-5", "pricing": {"prompt": "0.000001", "completion": "0.000001"}},
+		{"id": "deepseek/deepseek-v3", "pricing": {"prompt": "0.0000003", "completion": "0.0000009", "input_cache_read": "0.00000003"}},
+		{"id": "deepseek/deepseek-v3:free", "pricing": {"prompt": "0", "completion": "0"}},
+		{"id": "openrouter/auto", "pricing": {"prompt": "-1", "completion": "-1"}}
+	]}`)
+
+	env := newPricingTestEnv(t, true,
+		config.PricingSourceConfig{Name: "local", Type: PricingSourceTypeYAML, Path: local, Priority: 100},
+		config.PricingSourceConfig{Name: "openrouter", Type: PricingSourceTypeOpenRouter, Path: openrouter, Priority: -10},
+	)
+	_, err := env.svc.syncSources(context.Background(), false)
+	require.NoError(t, err)
+
+	// local(100) > litellm(0) > openrouter(-10)
+	sonnet := env.svc.GetModelPricing("claude-sonnet-4-5")
+	require.InDelta(t, 2.9e-6, sonnet.InputCostPerToken, 1e-12)
+	require.Equal(t, "anthropic", sonnet.LiteLLMProvider)
+
+	deepseek := env.svc.GetModelPricing("deepseek-v3")
+	require.NotNil(t, deepseek)
+	require.InDelta(t, 3e-7, deepseek.InputCostPerToken, 1e-15)
+	require.InDelta(t, 3e-8, deepseek.CacheReadInputTokenCost, 1e-15)
+	require.True(t, deepseek.SupportsPromptCaching)
+	require.Equal(t, "deepseek", deepseek.LiteLLMProvider)
+	require.Nil(t, env.svc.GetModelPricing("auto"))
+
+	status, err := env.svc.GetSyncStatus(context.Background())
+	require.NoError(t, err)
+	names := make([]string, 0, len(status.Sources))
+	for _, src := range status.Sources {
+		names = append(names, src.Name)
+	}
+	require.Equal(t, []string{"builtin", "openrouter", "litellm", "local"}, names)
+}
+
+func TestPricingSources_FailedSourceAbortsSync(t *testing.T) {
+	env := newPricingTestEnv(t, true,
+		config.PricingSourceConfig{Name: "missing", Type: PricingSourceTypeYAML, Path: "/nonexistent/prices.yaml"},
+	)
+	_, err := env.svc.syncSources(context.Background(), false)
+	require.Error(t, err)
+	require.Empty(t, env.repo.revisions)
+
+	status, err := env.svc.GetSyncStatus(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, status.LastSyncError)
+}
+
+func TestPricingSources_RemoteHashReusesCache(t *testing.T) {
+	body := []byte(`{"claude-sonnet-4-5": {"input_cost_per_token": 3e-6, "output_cost_per_token": 1.5e-5}}`)
+	remote := &pricingRemoteStub{
+		bodies: map[string][]byte{"https://example.com/prices.json": body},
+		hashes: map[string]string{"https://example.com/prices.sha256": sha256Hex(body)},
+	}
+	cfg := &config.Config{Pricing: config.PricingConfig{
+		DataDir: t.TempDir(),
+		Sources: []config.PricingSourceConfig{{
+			Name:    "litellm",
+			Type:    PricingSourceTypeLiteLLM,
+			URL:     "https://example.com/prices.json",
+			HashURL: "https://example.com/prices.sha256",
+		}},
+	}}
+	svc := NewPricingService(cfg, remote, newPricingRepoStub())
+	ctx := context.Background()
+
+	_, err := svc.syncSources(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, remote.downloads)
+
+	// 哈希未变化时复用本地缓存
+	_, err = svc.syncSources(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, remote.downloads)
+
+	// 哈希校验失败的内容不会被采用，回退到缓存并标记 stale
+	remote.bodies["https://example.com/prices.json"] = []byte(`{"claude-sonnet-4-5": {"input_cost_per_token": 1, "output_cost_per_token": 1}}`)
+	remote.hashes["https://example.com/prices.sha256"] = "deadbeef"
+	_, err = svc.syncSources(ctx, false)
+	require.NoError(t, err)
+	require.InDelta(t, 3e-6, svc.GetModelPricing("claude-sonnet-4-5").InputCostPerToken, 1e-12)
+	status, err := svc.GetSyncStatus(ctx)
+	require.NoError(t, err)
+	require.True(t, status.Sources[1].Stale)
+	require.Contains(t, status.Sources[1].LastError, "hash mismatch")
+}
@@ -2,12 +2,9 @@ package service
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -21,19 +18,8 @@ import (
 )
 
 var (
-	openAIModelDatePattern     = regexp.MustCompile(`-\d{8}$`)
-	openAIModelBasePattern     = regexp.MustCompile(`^(gpt-\d+(?:\.\d+)?)(?:-|$)`)
-	openAIGPT54FallbackPricing = &LiteLLMModelPricing{
-		InputCostPerToken:               2.5e-06, // $2.5 per MTok
-		OutputCostPerToken:              1.5e-05, // $15 per MTok
-		CacheReadInputTokenCost:         2.5e-07, // $0.25 per MTok
-		LongContextInputTokenThreshold:  272000,
-		LongContextInputCostMultiplier:  2.0,
-		LongContextOutputCostMultiplier: 1.5,
-		LiteLLMProvider:                 "openai",
-		Mode:                            "chat",
-		SupportsPromptCaching:           true,
-	}
+	openAIModelDatePattern = regexp.MustCompile(`-\d{8}$`)
+	openAIModelBasePattern = regexp.MustCompile(`^(gpt-\d+(?:\.\d+)?)(?:-|$)`)
 )
 
 // LiteLLMModelPricing LiteLLM价格数据结构
@@ -73,6 +59,9 @@ type LiteLLMRawEntry struct {
 	CacheCreationInputTokenCostAbove1hr *float64 `json:"cache_creation_input_token_cost_above_1hr"`
 	CacheReadInputTokenCost             *float64 `json:"cache_read_input_token_cost"`
 	CacheReadInputTokenCostPriority     *float64 `json:"cache_read_input_token_cost_priority"`
+	LongContextInputTokenThreshold      *int     `json:"long_context_input_token_threshold"`
+	LongContextInputCostMultiplier      *float64 `json:"long_context_input_cost_multiplier"`
+	LongContextOutputCostMultiplier     *float64 `json:"long_context_output_cost_multiplier"`
 	SupportsServiceTier                 bool     `json:"supports_service_tier"`
 	LiteLLMProvider                     string   `json:"litellm_provider"`
 	Mode                                string   `json:"mode"`
@@ -81,24 +70,41 @@ type LiteLLMRawEntry struct {
 }
 
 // PricingService 动态价格服务
+// 生效价格 = 已生效修订快照（多数据源按优先级合并）+ 管理员覆盖。
+// 同步到的变化在开启审核时先生成待审核修订，管理员通过后才影响计费。
 type PricingService struct {
 	cfg          *config.Config
 	remoteClient PricingRemoteClient
+	repo         PricingRepository
 	mu           sync.RWMutex
 	pricingData  map[string]*LiteLLMModelPricing
 	lastUpdated  time.Time
 	localHash    string
 
+	// 已生效快照（不含覆盖）与管理员覆盖
+	basePricing      map[string]*LiteLLMModelPricing
+	overrides        map[string]*LiteLLMModelPricing
+	activeRevisionID int64
+
+	// 最近一次同步状态
+	sourceStatuses []PricingSourceStatus
+	lastSyncAt     time.Time
+	lastSyncError  string
+
+	// 串行化同步与审核，避免并发生成重复修订
+	syncMu sync.Mutex
+
 	// 停止信号
 	stopCh chan struct{}
 	wg     sync.WaitGroup
 }
 
 // NewPricingService 创建价格服务
-func NewPricingService(cfg *config.Config, remoteClient PricingRemoteClient) *PricingService {
+func NewPricingService(cfg *config.Config, remoteClient PricingRemoteClient, repo PricingRepository) *PricingService {
 	s := &PricingService{
 		cfg:          cfg,
 		remoteClient: remoteClient,
+		repo:         repo,
 		pricingData:  make(map[string]*LiteLLMModelPricing),
 		stopCh:       make(chan struct{}),
 	}
@@ -112,16 +118,28 @@ func (s *PricingService) Initialize() error {
 		logger.LegacyPrintf("service.pricing", "[Pricing] Failed to create data directory: %v", err)
 	}
 
-	// 首次加载价格数据
-	if err := s.checkAndUpdatePricing(); err != nil {
-		logger.LegacyPrintf("service.pricing", "[Pricing] Initial load failed, using fallback: %v", err)
-		if err := s.useFallbackPricing(); err != nil {
-			return fmt.Errorf("failed to load pricing data: %w", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// 优先使用数据库中已生效的修订，避免重启时未经审核的上游变化直接生效
+	if err := s.reloadFromStore(ctx); err != nil {
+		logger.LegacyPrintf("service.pricing", "[Pricing] Failed to load pricing from database: %v", err)
+	}
+	s.mu.RLock()
+	loaded := s.activeRevisionID > 0
+	s.mu.RUnlock()
+
+	if !loaded {
+		if _, err := s.syncSources(ctx, false); err != nil {
+			logger.LegacyPrintf("service.pricing", "[Pricing] Initial sync failed, using fallback: %v", err)
+			if err := s.useFallbackPricing(); err != nil {
+				return fmt.Errorf("failed to load pricing data: %w", err)
+			}
 		}
 	}
 
-	// 启动定时更新
-	s.startUpdateScheduler()
+	// 启动定时更新（已从数据库加载时立即做一次同步检查）
+	s.startUpdateScheduler(loaded)
 
 	logger.LegacyPrintf("service.pricing", "[Pricing] Service initialized with %d models", len(s.pricingData))
 	return nil
@@ -135,7 +153,7 @@ func (s *PricingService) Stop() {
 }
 
 // startUpdateScheduler 启动定时更新调度器
-func (s *PricingService) startUpdateScheduler() {
+func (s *PricingService) startUpdateScheduler(syncImmediately bool) {
 	// 定期检查哈希更新
 	hashInterval := time.Duration(s.cfg.Pricing.HashCheckIntervalMinutes) * time.Minute
 	if hashInterval < time.Minute {
@@ -148,12 +166,13 @@ func (s *PricingService) startUpdateScheduler() {
 		ticker := time.NewTicker(hashInterval)
 		defer ticker.Stop()
 
+		if syncImmediately {
+			s.runScheduledSync()
+		}
 		for {
 			select {
 			case <-ticker.C:
-				if err := s.syncWithRemote(); err != nil {
-					logger.LegacyPrintf("service.pricing", "[Pricing] Sync failed: %v", err)
-				}
+				s.runScheduledSync()
 			case <-s.stopCh:
 				return
 			}
@@ -163,140 +182,17 @@ func (s *PricingService) startUpdateScheduler() {
 	logger.LegacyPrintf("service.pricing", "[Pricing] Update scheduler started (check every %v)", hashInterval)
 }
 
-// checkAndUpdatePricing 检查并更新价格数据
-func (s *PricingService) checkAndUpdatePricing() error {
-	pricingFile := s.getPricingFilePath()
-
-	// 检查本地文件是否存在
-	if _, err := os.Stat(pricingFile); os.IsNotExist(err) {
-		logger.LegacyPrintf("service.pricing", "%s", "[Pricing] Local pricing file not found, downloading...")
-		return s.downloadPricingData()
-	}
-
-	// 检查文件是否过期
-	info, err := os.Stat(pricingFile)
-	if err != nil {
-		return s.downloadPricingData()
-	}
-
-	fileAge := time.Since(info.ModTime())
-	maxAge := time.Duration(s.cfg.Pricing.UpdateIntervalHours) * time.Hour
-
-	if fileAge > maxAge {
-		logger.LegacyPrintf("service.pricing", "[Pricing] Local file is %v old, updating...", fileAge.Round(time.Hour))
-		if err := s.downloadPricingData(); err != nil {
-			logger.LegacyPrintf("service.pricing", "[Pricing] Download failed, using existing file: %v", err)
-		}
-	}
-
-	// 加载本地文件
-	return s.loadPricingData(pricingFile)
-}
-
-// syncWithRemote 与远程同步（基于哈希校验）
-func (s *PricingService) syncWithRemote() error {
-	pricingFile := s.getPricingFilePath()
-
-	// 计算本地文件哈希
-	localHash, err := s.computeFileHash(pricingFile)
-	if err != nil {
-		logger.LegacyPrintf("service.pricing", "[Pricing] Failed to compute local hash: %v", err)
-		return s.downloadPricingData()
-	}
-
-	// 如果配置了哈希URL，从远程获取哈希进行比对
-	if s.cfg.Pricing.HashURL != "" {
-		remoteHash, err := s.fetchRemoteHash()
-		if err != nil {
-			logger.LegacyPrintf("service.pricing", "[Pricing] Failed to fetch remote hash: %v", err)
-			return nil // 哈希获取失败不影响正常使用
-		}
-
-		if remoteHash != localHash {
-			logger.LegacyPrintf("service.pricing", "%s", "[Pricing] Remote hash differs, downloading new version...")
-			return s.downloadPricingData()
-		}
-		logger.LegacyPrintf("service.pricing", "%s", "[Pricing] Hash check passed, no update needed")
-		return nil
-	}
-
-	// 没有哈希URL时，基于时间检查
-	info, err := os.Stat(pricingFile)
-	if err != nil {
-		return s.downloadPricingData()
-	}
-
-	fileAge := time.Since(info.ModTime())
-	maxAge := time.Duration(s.cfg.Pricing.UpdateIntervalHours) * time.Hour
-
-	if fileAge > maxAge {
-		logger.LegacyPrintf("service.pricing", "[Pricing] File is %v old, downloading...", fileAge.Round(time.Hour))
-		return s.downloadPricingData()
-	}
-
-	return nil
-}
-
-// downloadPricingData 从远程下载价格数据
-func (s *PricingService) downloadPricingData() error {
-	remoteURL, err := s.validatePricingURL(s.cfg.Pricing.RemoteURL)
-	if err != nil {
-		return err
-	}
-	logger.LegacyPrintf("service.pricing", "[Pricing] Downloading from %s", remoteURL)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// runScheduledSync 先与数据库对齐（其他实例的审核结果、覆盖），再同步数据源
+func (s *PricingService) runScheduledSync() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	var expectedHash string
-	if strings.TrimSpace(s.cfg.Pricing.HashURL) != "" {
-		expectedHash, err = s.fetchRemoteHash()
-		if err != nil {
-			return fmt.Errorf("fetch remote hash: %w", err)
-		}
-	}
-
-	body, err := s.remoteClient.FetchPricingJSON(ctx, remoteURL)
-	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+	if err := s.reloadFromStore(ctx); err != nil {
+		logger.LegacyPrintf("service.pricing", "[Pricing] Reload from database failed: %v", err)
 	}
-
-	if expectedHash != "" {
-		actualHash := sha256.Sum256(body)
-		if !strings.EqualFold(expectedHash, hex.EncodeToString(actualHash[:])) {
-			return fmt.Errorf("pricing hash mismatch")
-		}
+	if _, err := s.syncSources(ctx, false); err != nil {
+		logger.LegacyPrintf("service.pricing", "[Pricing] Sync failed: %v", err)
 	}
-
-	// 解析JSON数据（使用灵活的解析方式）
-	data, err := s.parsePricingData(body)
-	if err != nil {
-		return fmt.Errorf("parse pricing data: %w", err)
-	}
-
-	// 保存到本地文件
-	pricingFile := s.getPricingFilePath()
-	if err := os.WriteFile(pricingFile, body, 0644); err != nil {
-		logger.LegacyPrintf("service.pricing", "[Pricing] Failed to save file: %v", err)
-	}
-
-	// 保存哈希
-	hash := sha256.Sum256(body)
-	hashStr := hex.EncodeToString(hash[:])
-	hashFile := s.getHashFilePath()
-	if err := os.WriteFile(hashFile, []byte(hashStr+"\n"), 0644); err != nil {
-		logger.LegacyPrintf("service.pricing", "[Pricing] Failed to save hash: %v", err)
-	}
-
-	// 更新内存数据
-	s.mu.Lock()
-	s.pricingData = data
-	s.lastUpdated = time.Now()
-	s.localHash = hashStr
-	s.mu.Unlock()
-
-	logger.LegacyPrintf("service.pricing", "[Pricing] Downloaded %d models successfully", len(data))
-	return nil
 }
 
 // parsePricingData 解析价格数据（处理各种格式）
@@ -362,6 +258,15 @@ func (s *PricingService) parsePricingData(body []byte) (map[string]*LiteLLMModel
 		if entry.OutputCostPerImage != nil {
 			pricing.OutputCostPerImage = *entry.OutputCostPerImage
 		}
+		if entry.LongContextInputTokenThreshold != nil {
+			pricing.LongContextInputTokenThreshold = *entry.LongContextInputTokenThreshold
+		}
+		if entry.LongContextInputCostMultiplier != nil {
+			pricing.LongContextInputCostMultiplier = *entry.LongContextInputCostMultiplier
+		}
+		if entry.LongContextOutputCostMultiplier != nil {
+			pricing.LongContextOutputCostMultiplier = *entry.LongContextOutputCostMultiplier
+		}
 
 		result[modelName] = pricing
 	}
@@ -377,40 +282,7 @@ func (s *PricingService) parsePricingData(body []byte) (map[string]*LiteLLMModel
 	return result, nil
 }
 
-// loadPricingData 从本地文件加载价格数据
-func (s *PricingService) loadPricingData(filePath string) error {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("read file failed: %w", err)
-	}
-
-	// 使用灵活的解析方式
-	pricingData, err := s.parsePricingData(data)
-	if err != nil {
-		return fmt.Errorf("parse pricing data: %w", err)
-	}
-
-	// 计算哈希
-	hash := sha256.Sum256(data)
-	hashStr := hex.EncodeToString(hash[:])
-
-	s.mu.Lock()
-	s.pricingData = pricingData
-	s.localHash = hashStr
-
-	info, _ := os.Stat(filePath)
-	if info != nil {
-		s.lastUpdated = info.ModTime()
-	} else {
-		s.lastUpdated = time.Now()
-	}
-	s.mu.Unlock()
-
-	logger.LegacyPrintf("service.pricing", "[Pricing] Loaded %d models from %s", len(pricingData), filePath)
-	return nil
-}
-
-// useFallbackPricing 使用回退价格文件
+// useFallbackPricing 使用回退价格文件（仅在内存中生效，不写入修订，下次同步成功时作为首次同步直接生效）
 func (s *PricingService) useFallbackPricing() error {
 	fallbackFile := s.cfg.Pricing.FallbackFile
 
@@ -420,28 +292,40 @@ func (s *PricingService) useFallbackPricing() error {
 
 	logger.LegacyPrintf("service.pricing", "[Pricing] Using fallback file: %s", fallbackFile)
 
-	// 复制到数据目录
-	data, err := os.ReadFile(fallbackFile)
+	body, err := os.ReadFile(fallbackFile)
 	if err != nil {
 		return fmt.Errorf("read fallback failed: %w", err)
 	}
-
-	pricingFile := s.getPricingFilePath()
-	if err := os.WriteFile(pricingFile, data, 0644); err != nil {
-		logger.LegacyPrintf("service.pricing", "[Pricing] Failed to copy fallback: %v", err)
+	data, err := s.parsePricingData(body)
+	if err != nil {
+		return fmt.Errorf("parse pricing data: %w", err)
+	}
+	// 内置补丁优先级最低，仅补齐回退文件缺失的模型
+	if builtin, err := s.parsePricingSource(PricingSourceTypeYAML, pricingBuiltinYAML); err == nil {
+		for model, pricing := range builtin {
+			if _, exists := data[model]; !exists {
+				data[model] = pricing
+			}
+		}
 	}
 
-	return s.loadPricingData(fallbackFile)
+	hash, err := hashPricingSnapshot(data)
+	if err != nil {
+		return err
+	}
+	s.applySnapshot(0, data, hash)
+	logger.LegacyPrintf("service.pricing", "[Pricing] Loaded %d models from %s", len(data), fallbackFile)
+	return nil
 }
 
 // fetchRemoteHash 从远程获取哈希值
-func (s *PricingService) fetchRemoteHash() (string, error) {
-	hashURL, err := s.validatePricingURL(s.cfg.Pricing.HashURL)
+func (s *PricingService) fetchRemoteHash(ctx context.Context, rawURL string) (string, error) {
+	hashURL, err := s.validatePricingURL(rawURL)
 	if err != nil {
 		return "", err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	hash, err := s.remoteClient.FetchHashText(ctx, hashURL)
@@ -470,16 +354,6 @@ func (s *PricingService) validatePricingURL(raw string) (string, error) {
 	return normalized, nil
 }
 
-// computeFileHash 计算文件哈希
-func (s *PricingService) computeFileHash(filePath string) (string, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", err
-	}
-	hash := sha256.Sum256(data)
-	return hex.EncodeToString(hash[:]), nil
-}
-
 // GetModelPricing 获取模型价格（带模糊匹配）
 func (s *PricingService) GetModelPricing(modelName string) *LiteLLMModelPricing {
 	s.mu.RLock()
@@ -692,8 +566,8 @@ func (s *PricingService) matchByModelFamily(model string) *LiteLLMModelPricing {
 // 2. gpt-5.2-codex -> gpt-5.2（去掉后缀如 -codex, -mini, -max 等）
 // 3. gpt-5.2-20251222 -> gpt-5.2（去掉日期版本号）
 // 4. gpt-5.3-codex -> gpt-5.2-codex
-// 5. gpt-5.4* -> 业务静态兜底价
-// 6. 最终回退到 DefaultTestModel (gpt-5.1-codex)
+// 5. 最终回退到 DefaultTestModel (gpt-5.1-codex)
+// 上游缺失的新模型（如 gpt-5.4）由内置补丁数据源 pricing_builtin.yaml 提供，经第 2 步匹配。
 func (s *PricingService) matchOpenAIModel(model string) *LiteLLMModelPricing {
 	if strings.HasPrefix(model, "gpt-5.3-codex-spark") {
 		if pricing, ok := s.pricingData["gpt-5.1-codex"]; ok {
@@ -723,12 +597,6 @@ func (s *PricingService) matchOpenAIModel(model string) *LiteLLMModelPricing {
 		}
 	}
 
-	if strings.HasPrefix(model, "gpt-5.4") {
-		logger.With(zap.String("component", "service.pricing")).
-			Info(fmt.Sprintf("[Pricing] OpenAI fallback matched %s -> %s", model, "gpt-5.4(static)"))
-		return openAIGPT54FallbackPricing
-	}
-
 	// 最终回退到 DefaultTestModel
 	defaultModel := strings.ToLower(openai.DefaultTestModel)
 	if pricing, ok := s.pricingData[defaultModel]; ok {
@@ -779,25 +647,20 @@ func (s *PricingService) GetStatus() map[string]any {
 	defer s.mu.RUnlock()
 
 	return map[string]any{
-		"model_count":  len(s.pricingData),
-		"last_updated": s.lastUpdated,
-		"local_hash":   s.localHash[:min(8, len(s.localHash))],
+		"model_count":        len(s.pricingData),
+		"override_count":     len(s.overrides),
+		"active_revision_id": s.activeRevisionID,
+		"last_updated":       s.lastUpdated,
+		"local_hash":         s.localHash[:min(8, len(s.localHash))],
 	}
 }
 
-// ForceUpdate 强制更新
+// ForceUpdate 强制同步所有数据源；开启审核时变化仍需审核后才生效
 func (s *PricingService) ForceUpdate() error {
-	return s.downloadPricingData()
-}
-
-// getPricingFilePath 获取价格文件路径
-func (s *PricingService) getPricingFilePath() string {
-	return filepath.Join(s.cfg.Pricing.DataDir, "model_pricing.json")
-}
-
-// getHashFilePath 获取哈希文件路径
-func (s *PricingService) getHashFilePath() string {
-	return filepath.Join(s.cfg.Pricing.DataDir, "model_pricing.sha256")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	_, err := s.syncSources(ctx, true)
+	return err
 }
 
 // isNumeric 检查字符串是否为纯数字
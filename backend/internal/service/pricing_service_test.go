@@ -1,9 +1,14 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/Wei-Shaw/sub2api/internal/config"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -81,12 +86,17 @@ func TestGetModelPricing_OpenAIFallbackMatchedLoggedAsInfo(t *testing.T) {
 	require.False(t, logSink.ContainsMessageAtLevel("[Pricing] OpenAI fallback matched gpt-5.3-codex -> gpt-5.2-codex", "warn"))
 }
 
-func TestGetModelPricing_Gpt54UsesStaticFallbackWhenRemoteMissing(t *testing.T) {
-	svc := &PricingService{
-		pricingData: map[string]*LiteLLMModelPricing{
-			"gpt-5.1-codex": &LiteLLMModelPricing{InputCostPerToken: 1.25e-6},
-		},
-	}
+func TestGetModelPricing_Gpt54UsesBuiltinSourceWhenRemoteMissing(t *testing.T) {
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "upstream.json")
+	require.NoError(t, os.WriteFile(upstream, []byte(`{"gpt-5.1-codex": {"input_cost_per_token": 1.25e-6, "output_cost_per_token": 1e-5}}`), 0o644))
+
+	svc := NewPricingService(&config.Config{Pricing: config.PricingConfig{
+		DataDir: dir,
+		Sources: []config.PricingSourceConfig{{Name: "upstream", Type: PricingSourceTypeLiteLLM, Path: upstream}},
+	}}, nil, nil)
+	_, err := svc.syncSources(context.Background(), false)
+	require.NoError(t, err)
 
 	got := svc.GetModelPricing("gpt-5.4")
 	require.NotNil(t, got)
@@ -96,6 +106,9 @@ func TestGetModelPricing_Gpt54UsesStaticFallbackWhenRemoteMissing(t *testing.T)
 	require.Equal(t, 272000, got.LongContextInputTokenThreshold)
 	require.InDelta(t, 2.0, got.LongContextInputCostMultiplier, 1e-12)
 	require.InDelta(t, 1.5, got.LongContextOutputCostMultiplier, 1e-12)
+
+	// 变体名称经 OpenAI 回退匹配到内置价格
+	require.Same(t, got, svc.GetModelPricing("gpt-5.4-20260301"))
 }
 
 func TestParsePricingData_PreservesPriorityAndServiceTierFields(t *testing.T) {
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	PricingSourceTypeLiteLLM    = "litellm"
+	PricingSourceTypeOpenRouter = "openrouter"
+	PricingSourceTypeYAML       = "yaml"
+
+	// pricingBuiltinSourceName 内置补丁数据源名称，优先级固定为最低
+	pricingBuiltinSourceName = "builtin"
+)
+
+//go:embed pricing_builtin.yaml
+var pricingBuiltinYAML []byte
+
+var pricingSourceFileNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// PricingSourceStatus 单个价格数据源的最近一次同步状态
+type PricingSourceStatus struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Priority   int       `json:"priority"`
+	Location   string    `json:"location"`
+	ModelCount int       `json:"model_count"`
+	Hash       string    `json:"hash"`
+	FetchedAt  time.Time `json:"fetched_at"`
+	// Stale 远程拉取失败，本次使用的是本地缓存
+	Stale     bool   `json:"stale"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// mergedPricing 多数据源合并结果
+type mergedPricing struct {
+	data         map[string]*LiteLLMModelPricing
+	modelSources map[string]string
+	sources      []string
+	statuses     []PricingSourceStatus
+}
+
+// pricingSources 返回按优先级升序排列的数据源（后合并者覆盖先合并者）。
+// 未配置 pricing.sources 时沿用 remote_url/hash_url 作为单一 LiteLLM 数据源。
+func (s *PricingService) pricingSources() []config.PricingSourceConfig {
+	sources := append([]config.PricingSourceConfig(nil), s.cfg.Pricing.Sources...)
+	if len(sources) == 0 && strings.TrimSpace(s.cfg.Pricing.RemoteURL) != "" {
+		sources = append(sources, config.PricingSourceConfig{
+			Name:    PricingSourceTypeLiteLLM,
+			Type:    PricingSourceTypeLiteLLM,
+			URL:     s.cfg.Pricing.RemoteURL,
+			HashURL: s.cfg.Pricing.HashURL,
+		})
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority < sources[j].Priority
+	})
+	return sources
+}
+
+// collectPricingSources 拉取并合并所有数据源。
+// 任一配置的数据源既拉取失败又没有本地缓存时整体失败：缺失一个数据源会让它的模型在差异中表现为"被删除"。
+func (s *PricingService) collectPricingSources(ctx context.Context, force bool) (*mergedPricing, error) {
+	sources := s.pricingSources()
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no pricing source configured")
+	}
+
+	result := &mergedPricing{
+		data:         make(map[string]*LiteLLMModelPricing),
+		modelSources: make(map[string]string),
+	}
+
+	builtin, err := s.parsePricingSource(PricingSourceTypeYAML, pricingBuiltinYAML)
+	if err != nil {
+		return nil, fmt.Errorf("parse builtin pricing: %w", err)
+	}
+	result.merge(pricingBuiltinSourceName, builtin)
+	result.statuses = append(result.statuses, PricingSourceStatus{
+		Name:       pricingBuiltinSourceName,
+		Type:       PricingSourceTypeYAML,
+		Priority:   math.MinInt32,
+		Location:   "embedded",
+		ModelCount: len(builtin),
+	})
+
+	for _, src := range sources {
+		status := PricingSourceStatus{
+			Name:     src.Name,
+			Type:     src.Type,
+			Priority: src.Priority,
+			Location: src.URL,
+		}
+		if status.Location == "" {
+			status.Location = src.Path
+		}
+		body, stale, fetchErr := s.loadPricingSource(ctx, src, force)
+		if body == nil {
+			status.LastError = fetchErr.Error()
+			s.recordSourceStatuses(append(result.statuses, status))
+			return nil, fmt.Errorf("pricing source %s: %w", src.Name, fetchErr)
+		}
+		if fetchErr != nil {
+			status.LastError = fetchErr.Error()
+		}
+		data, err := s.parsePricingSource(src.Type, body)
+		if err != nil {
+			status.LastError = err.Error()
+			s.recordSourceStatuses(append(result.statuses, status))
+			return nil, fmt.Errorf("pricing source %s: %w", src.Name, err)
+		}
+		hash := sha256.Sum256(body)
+		status.Hash = hex.EncodeToString(hash[:])[:8]
+		status.ModelCount = len(data)
+		status.FetchedAt = time.Now()
+		status.Stale = stale
+		result.statuses = append(result.statuses, status)
+		result.merge(src.Name, data)
+	}
+
+	s.recordSourceStatuses(result.statuses)
+	return result, nil
+}
+
+func (m *mergedPricing) merge(source string, data map[string]*LiteLLMModelPricing) {
+	for model, pricing := range data {
+		m.data[model] = pricing
+		m.modelSources[model] = source
+	}
+	m.sources = append(m.sources, source)
+}
+
+func (s *PricingService) recordSourceStatuses(statuses []PricingSourceStatus) {
+	s.mu.Lock()
+	s.sourceStatuses = statuses
+	s.mu.Unlock()
+}
+
+// loadPricingSource 读取单个数据源的原始内容。
+// 远程数据源会缓存到 data_dir：配置了 hash_url 时哈希一致即复用缓存，否则在 update_interval_hours 内复用缓存；
+// 远程失败但有缓存时返回缓存内容并附带错误（stale=true）。
+func (s *PricingService) loadPricingSource(ctx context.Context, src config.PricingSourceConfig, force bool) ([]byte, bool, error) {
+	if path := strings.TrimSpace(src.Path); path != "" {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("read file: %w", err)
+		}
+		return body, false, nil
+	}
+
+	cacheFile := s.pricingSourceCachePath(src.Name)
+	cached, cacheErr := os.ReadFile(cacheFile)
+	hasCache := cacheErr == nil && len(cached) > 0
+
+	var expectedHash string
+	if strings.TrimSpace(src.HashURL) != "" {
+		hash, err := s.fetchRemoteHash(ctx, src.HashURL)
+		if err != nil {
+			if hasCache {
+				return cached, true, fmt.Errorf("fetch remote hash: %w", err)
+			}
+			return nil, false, fmt.Errorf("fetch remote hash: %w", err)
+		}
+		expectedHash = hash
+		if !force && hasCache && strings.EqualFold(expectedHash, sha256Hex(cached)) {
+			return cached, false, nil
+		}
+	} else if !force && hasCache {
+		if info, err := os.Stat(cacheFile); err == nil {
+			maxAge := time.Duration(s.cfg.Pricing.UpdateIntervalHours) * time.Hour
+			if time.Since(info.ModTime()) < maxAge {
+				return cached, false, nil
+			}
+		}
+	}
+
+	body, err := s.downloadPricingSource(ctx, src.URL, expectedHash)
+	if err != nil {
+		if hasCache {
+			logger.LegacyPrintf("service.pricing", "[Pricing] Source %s download failed, using cached copy: %v", src.Name, err)
+			return cached, true, err
+		}
+		return nil, false, err
+	}
+	if err := os.WriteFile(cacheFile, body, 0644); err != nil {
+		logger.LegacyPrintf("service.pricing", "[Pricing] Failed to cache source %s: %v", src.Name, err)
+	}
+	return body, false, nil
+}
+
+// downloadPricingSource 从远程下载数据源，expectedHash 非空时校验 SHA-256
+func (s *PricingService) downloadPricingSource(ctx context.Context, rawURL, expectedHash string) ([]byte, error) {
+	remoteURL, err := s.validatePricingURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	logger.LegacyPrintf("service.pricing", "[Pricing] Downloading from %s", remoteURL)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	body, err := s.remoteClient.FetchPricingJSON(ctx, remoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	if expectedHash != "" && !strings.EqualFold(expectedHash, sha256Hex(body)) {
+		return nil, fmt.Errorf("pricing hash mismatch")
+	}
+	return body, nil
+}
+
+// pricingSourceCachePath 远程数据源的本地缓存文件路径
+func (s *PricingService) pricingSourceCachePath(name string) string {
+	return filepath.Join(s.cfg.Pricing.DataDir, "pricing_source_"+pricingSourceFileNamePattern.ReplaceAllString(name, "_")+".json")
+}
+
+// parsePricingSource 按数据源格式解析为统一的价格表
+func (s *PricingService) parsePricingSource(sourceType string, body []byte) (map[string]*LiteLLMModelPricing, error) {
+	switch sourceType {
+	case PricingSourceTypeLiteLLM:
+		return s.parsePricingData(body)
+	case PricingSourceTypeOpenRouter:
+		return parseOpenRouterPricing(body)
+	case PricingSourceTypeYAML:
+		// YAML 条目与 LiteLLM JSON 同构，转为 JSON 后复用同一套解析
+		var raw map[string]any
+		if err := yaml.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+		converted, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("convert yaml: %w", err)
+		}
+		return s.parsePricingData(converted)
+	default:
+		return nil, fmt.Errorf("unsupported pricing source type: %s", sourceType)
+	}
+}
+
+// openRouterModelsResponse OpenRouter /api/v1/models 响应（价格为每 token 美元价的字符串）
+type openRouterModelsResponse struct {
+	Data []struct {
+		ID      string `json:"id"`
+		Pricing struct {
+			Prompt          string `json:"prompt"`
+			Completion      string `json:"completion"`
+			InputCacheRead  string `json:"input_cache_read"`
+			InputCacheWrite string `json:"input_cache_write"`
+		} `json:"pricing"`
+	} `json:"data"`
+}
+
+// parseOpenRouterPricing 解析 OpenRouter 格式价格数据。
+// 模型以去掉提供商前缀后的小写名称为键（anthropic/claude-sonnet-4 -> claude-sonnet-4），以便与其他数据源按名称对齐；
+// ":free" 等变体和动态计价（负数价格）的条目会被跳过。
+func parseOpenRouterPricing(body []byte) (map[string]*LiteLLMModelPricing, error) {
+	var resp openRouterModelsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse openrouter JSON: %w", err)
+	}
+
+	result := make(map[string]*LiteLLMModelPricing)
+	for _, entry := range resp.Data {
+		id := strings.ToLower(strings.TrimSpace(entry.ID))
+		if id == "" || strings.Contains(id, ":") {
+			continue
+		}
+		input, okIn := parseOpenRouterPrice(entry.Pricing.Prompt)
+		output, okOut := parseOpenRouterPrice(entry.Pricing.Completion)
+		if !okIn || !okOut {
+			continue
+		}
+		provider, model := "", id
+		if idx := strings.LastIndex(id, "/"); idx != -1 {
+			provider, model = id[:idx], id[idx+1:]
+		}
+		if _, exists := result[model]; exists {
+			continue
+		}
+		pricing := &LiteLLMModelPricing{
+			InputCostPerToken:  input,
+			OutputCostPerToken: output,
+			LiteLLMProvider:    provider,
+			Mode:               "chat",
+		}
+		if v, ok := parseOpenRouterPrice(entry.Pricing.InputCacheRead); ok {
+			pricing.CacheReadInputTokenCost = v
+			pricing.SupportsPromptCaching = v > 0
+		}
+		if v, ok := parseOpenRouterPrice(entry.Pricing.InputCacheWrite); ok {
+			pricing.CacheCreationInputTokenCost = v
+		}
+		result[model] = pricing
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no valid pricing entries found")
+	}
+	return result, nil
+}
+
+func parseOpenRouterPrice(raw string) (float64, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// SettingKeyRequestCapturePolicy 请求抓取策略（JSON）
+const SettingKeyRequestCapturePolicy = "request_capture_policy"
+
+// RequestCapturePolicy 后台配置的抓取策略：命中分组或 API Key 之一即抓取
+type RequestCapturePolicy struct {
+	Enabled   bool    `json:"enabled"`
+	GroupIDs  []int64 `json:"group_ids"`
+	APIKeyIDs []int64 `json:"api_key_ids"`
+	// AllowUserView 是否允许用户查看自己 API Key 的抓取内容
+	AllowUserView bool `json:"allow_user_view"`
+}
+
+// RequestCapture 抓取记录的元数据（列表展示用，不含正文）
+type RequestCapture struct {
+	ID            int64     `json:"id"`
+	RequestID     string    `json:"request_id"`
+	UserID        int64     `json:"user_id"`
+	APIKeyID      int64     `json:"api_key_id"`
+	GroupID       *int64    `json:"group_id,omitempty"`
+	AccountID     *int64    `json:"account_id,omitempty"`
+	Platform      string    `json:"platform"`
+	Model         string    `json:"model"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	Stream        bool      `json:"stream"`
+	StatusCode    int       `json:"status_code"`
+	DurationMs    int       `json:"duration_ms"`
+	RequestBytes  int       `json:"request_bytes"`
+	ResponseBytes int       `json:"response_bytes"`
+	Truncated     bool      `json:"truncated"`
+	Encrypted     bool      `json:"encrypted"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// RequestCaptureRecord 抓取记录的存储形态：正文已脱敏、压缩（可选加密）
+type RequestCaptureRecord struct {
+	RequestCapture
+	RequestBody  []byte
+	ResponseBody []byte
+	ResponseText []byte
+}
+
+// RequestCaptureDetail 抓取详情：正文已解密解压
+type RequestCaptureDetail struct {
+	RequestCapture
+	RequestBody  string `json:"request_body"`
+	ResponseBody string `json:"response_body"`
+	// ResponseText 流式响应重组后的完整文本（非流式响应为空）
+	ResponseText string `json:"response_text,omitempty"`
+}
+
+// RequestCaptureListFilter 抓取记录查询条件
+type RequestCaptureListFilter struct {
+	UserID    *int64
+	APIKeyID  *int64
+	GroupID   *int64
+	RequestID string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// RequestCaptureInput 网关中间件采集到的一次请求
+type RequestCaptureInput struct {
+	RequestID     string
+	UserID        int64
+	APIKeyID      int64
+	GroupID       *int64
+	AccountID     *int64
+	Platform      string
+	Model         string
+	Method        string
+	Path          string
+	Stream        bool
+	StatusCode    int
+	Duration      time.Duration
+	RequestBody   []byte
+	ResponseBody  []byte
+	RequestBytes  int
+	ResponseBytes int
+	Truncated     bool
+	CreatedAt     time.Time
+}
+
+type RequestCaptureRepository interface {
+	Create(ctx context.Context, record *RequestCaptureRecord) error
+	GetByID(ctx context.Context, id int64) (*RequestCaptureRecord, error)
+	List(ctx context.Context, filter RequestCaptureListFilter, params pagination.PaginationParams) ([]RequestCapture, *pagination.PaginationResult, error)
+	// DeleteExpired 删除 expires_at <= now 的记录，单次最多 limit 条
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+}
+
+const (
+	requestCaptureRedacted      = "[REDACTED]"
+	requestCaptureRedactedEmail = "[REDACTED_EMAIL]"
+)
+
+// requestCaptureSecretPatterns 常见密钥格式：API Key、Bearer Token、云厂商凭证、私钥
+var requestCaptureSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{16,}=*`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bxox[abpr]-[A-Za-z0-9\-]{10,}`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`),
+}
+
+var requestCaptureEmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// requestCaptureRedactor 抓取内容的脱敏管道，按顺序应用各规则
+type requestCaptureRedactor struct {
+	patterns []*regexp.Regexp
+	emails   bool
+}
+
+func newRequestCaptureRedactor(secrets, emails bool, extra []string) *requestCaptureRedactor {
+	r := &requestCaptureRedactor{emails: emails}
+	if secrets {
+		r.patterns = append(r.patterns, requestCaptureSecretPatterns...)
+	}
+	for _, p := range extra {
+		// 配置加载时已校验，这里忽略无法编译的规则
+		if re, err := regexp.Compile(p); err == nil {
+			r.patterns = append(r.patterns, re)
+		}
+	}
+	return r
+}
+
+func (r *requestCaptureRedactor) Redact(data []byte) []byte {
+	if r == nil || len(data) == 0 {
+		return data
+	}
+	for _, re := range r.patterns {
+		data = re.ReplaceAll(data, []byte(requestCaptureRedacted))
+	}
+	if r.emails {
+		data = requestCaptureEmailPattern.ReplaceAll(data, []byte(requestCaptureRedactedEmail))
+	}
+	return data
+}
+
+// ReassembleSSEText 从流式响应中拼接模型输出的文本，支持 Anthropic Messages、
+// OpenAI Chat Completions / Responses 与 Gemini（含 v1internal 包装）格式。
+func ReassembleSSEText(body []byte) string {
+	var sb strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+	for scanner.Scan() {
+		payload, ok := bytes.CutPrefix(scanner.Bytes(), []byte("data:"))
+		if !ok {
+			continue
+		}
+		payload = bytes.TrimSpace(payload)
+		if len(payload) == 0 || payload[0] != '{' {
+			continue
+		}
+		sb.WriteString(sseChunkText(payload))
+	}
+	return sb.String()
+}
+
+type sseGeminiCandidates struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text    string `json:"text"`
+				Thought bool   `json:"thought"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+}
+
+func sseChunkText(payload []byte) string {
+	var chunk struct {
+		Type    string          `json:"type"`
+		Delta   json.RawMessage `json:"delta"`
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+		sseGeminiCandidates
+		Response *sseGeminiCandidates `json:"response"`
+	}
+	if err := json.Unmarshal(payload, &chunk); err != nil {
+		return ""
+	}
+
+	switch chunk.Type {
+	case "content_block_delta":
+		var delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(chunk.Delta, &delta) == nil && delta.Type == "text_delta" {
+			return delta.Text
+		}
+		return ""
+	case "response.output_text.delta":
+		var delta string
+		if json.Unmarshal(chunk.Delta, &delta) == nil {
+			return delta
+		}
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, choice := range chunk.Choices {
+		sb.WriteString(choice.Delta.Content)
+	}
+	candidates := chunk.Candidates
+	if len(candidates) == 0 && chunk.Response != nil {
+		candidates = chunk.Response.Candidates
+	}
+	for _, cand := range candidates {
+		for _, part := range cand.Content.Parts {
+			if !part.Thought {
+				sb.WriteString(part.Text)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func gzipRequestCapture(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipRequestCapture(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+var (
+	ErrRequestCaptureNotFound           = infraerrors.NotFound("REQUEST_CAPTURE_NOT_FOUND", "request capture not found")
+	ErrRequestCaptureViewDisabled       = infraerrors.Forbidden("REQUEST_CAPTURE_VIEW_DISABLED", "viewing request captures is not enabled")
+	ErrRequestCaptureDecryptUnavailable = infraerrors.ServiceUnavailable("REQUEST_CAPTURE_DECRYPT_UNAVAILABLE", "request capture is encrypted but no encryptor is configured")
+)
+
+const (
+	requestCapturePolicyCacheTTL = 30 * time.Second
+	requestCaptureCleanupBatch   = 1000
+)
+
+type cachedRequestCapturePolicy struct {
+	policy    *RequestCapturePolicy
+	groupIDs  map[int64]struct{}
+	apiKeyIDs map[int64]struct{}
+	expiresAt time.Time
+}
+
+// RequestCaptureService 按分组/API Key 抓取完整的请求与响应，异步脱敏、压缩（可选加密）后落库，
+// 并定期清理过期记录。
+type RequestCaptureService struct {
+	repo        RequestCaptureRepository
+	settingRepo SettingRepository
+	encryptor   SecretEncryptor
+	cfg         *config.Config
+	redactor    *requestCaptureRedactor
+
+	policyCache atomic.Pointer[cachedRequestCapturePolicy]
+
+	queue     chan *RequestCaptureInput
+	stopCh    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewRequestCaptureService(
+	repo RequestCaptureRepository,
+	settingRepo SettingRepository,
+	encryptor SecretEncryptor,
+	cfg *config.Config,
+) *RequestCaptureService {
+	s := &RequestCaptureService{
+		repo:        repo,
+		settingRepo: settingRepo,
+		encryptor:   encryptor,
+		cfg:         cfg,
+		stopCh:      make(chan struct{}),
+	}
+	if cfg != nil {
+		rc := cfg.RequestCapture
+		s.redactor = newRequestCaptureRedactor(rc.RedactSecrets, rc.RedactEmails, rc.RedactPatterns)
+		if rc.QueueSize > 0 {
+			s.queue = make(chan *RequestCaptureInput, rc.QueueSize)
+		}
+	}
+	return s
+}
+
+// Enabled 配置总开关是否打开
+func (s *RequestCaptureService) Enabled() bool {
+	return s != nil && s.repo != nil && s.cfg != nil && s.cfg.RequestCapture.Enabled && s.queue != nil
+}
+
+// MaxBodyBytes 请求体/响应体各自的抓取上限
+func (s *RequestCaptureService) MaxBodyBytes() int {
+	if s == nil || s.cfg == nil {
+		return 0
+	}
+	return s.cfg.RequestCapture.MaxBodyBytes
+}
+
+func (s *RequestCaptureService) Start() {
+	if !s.Enabled() {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(2)
+		go s.writeLoop()
+		go s.cleanupLoop()
+		logger.LegacyPrintf("service.request_capture", "[RequestCapture] started (retention=%dd max_body=%d encrypt=%v)",
+			s.cfg.RequestCapture.RetentionDays, s.cfg.RequestCapture.MaxBodyBytes, s.encryptEnabled())
+	})
+}
+
+func (s *RequestCaptureService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.stopCh != nil {
+			close(s.stopCh)
+		}
+	})
+	s.wg.Wait()
+}
+
+func (s *RequestCaptureService) encryptEnabled() bool {
+	return s.cfg.RequestCapture.EncryptAtRest && s.encryptor != nil
+}
+
+// GetPolicy 读取抓取策略，未配置时返回关闭状态
+func (s *RequestCaptureService) GetPolicy(ctx context.Context) (*RequestCapturePolicy, error) {
+	policy := &RequestCapturePolicy{GroupIDs: []int64{}, APIKeyIDs: []int64{}}
+	if s.settingRepo == nil {
+		return policy, nil
+	}
+	raw, err := s.settingRepo.GetValue(ctx, SettingKeyRequestCapturePolicy)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return policy, nil
+		}
+		return nil, fmt.Errorf("get request capture policy: %w", err)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return policy, nil
+	}
+	if err := json.Unmarshal([]byte(raw), policy); err != nil {
+		return nil, fmt.Errorf("parse request capture policy: %w", err)
+	}
+	normalizeRequestCapturePolicy(policy)
+	return policy, nil
+}
+
+// UpdatePolicy 保存抓取策略并立即刷新进程内缓存
+func (s *RequestCaptureService) UpdatePolicy(ctx context.Context, policy *RequestCapturePolicy) (*RequestCapturePolicy, error) {
+	if policy == nil {
+		return nil, infraerrors.BadRequest("INVALID_REQUEST_CAPTURE_POLICY", "policy is required")
+	}
+	normalizeRequestCapturePolicy(policy)
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyRequestCapturePolicy, string(raw)); err != nil {
+		return nil, fmt.Errorf("save request capture policy: %w", err)
+	}
+	s.storePolicyCache(policy)
+	return policy, nil
+}
+
+func normalizeRequestCapturePolicy(policy *RequestCapturePolicy) {
+	policy.GroupIDs = normalizePositiveIDs(policy.GroupIDs)
+	policy.APIKeyIDs = normalizePositiveIDs(policy.APIKeyIDs)
+}
+
+func normalizePositiveIDs(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func (s *RequestCaptureService) storePolicyCache(policy *RequestCapturePolicy) *cachedRequestCapturePolicy {
+	entry := &cachedRequestCapturePolicy{
+		policy:    policy,
+		groupIDs:  make(map[int64]struct{}, len(policy.GroupIDs)),
+		apiKeyIDs: make(map[int64]struct{}, len(policy.APIKeyIDs)),
+		expiresAt: time.Now().Add(requestCapturePolicyCacheTTL),
+	}
+	for _, id := range policy.GroupIDs {
+		entry.groupIDs[id] = struct{}{}
+	}
+	for _, id := range policy.APIKeyIDs {
+		entry.apiKeyIDs[id] = struct{}{}
+	}
+	s.policyCache.Store(entry)
+	return entry
+}
+
+// cachedPolicy 网关热路径读取策略（进程内缓存 30s），读取失败时沿用旧缓存
+func (s *RequestCaptureService) cachedPolicy(ctx context.Context) *cachedRequestCapturePolicy {
+	entry := s.policyCache.Load()
+	if entry != nil && time.Now().Before(entry.expiresAt) {
+		return entry
+	}
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		logger.LegacyPrintf("service.request_capture", "[RequestCapture] load policy failed: %v", err)
+		if entry != nil {
+			return entry
+		}
+		policy = &RequestCapturePolicy{}
+	}
+	return s.storePolicyCache(policy)
+}
+
+// ShouldCapture 判断该 API Key 的请求是否需要抓取
+func (s *RequestCaptureService) ShouldCapture(ctx context.Context, apiKey *APIKey) bool {
+	if !s.Enabled() || apiKey == nil {
+		return false
+	}
+	entry := s.cachedPolicy(ctx)
+	if !entry.policy.Enabled {
+		return false
+	}
+	if _, ok := entry.apiKeyIDs[apiKey.ID]; ok {
+		return true
+	}
+	if apiKey.GroupID != nil {
+		if _, ok := entry.groupIDs[*apiKey.GroupID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Submit 将抓取结果放入异步写入队列；队列满时丢弃
+func (s *RequestCaptureService) Submit(input *RequestCaptureInput) {
+	if !s.Enabled() || input == nil {
+		return
+	}
+	select {
+	case s.queue <- input:
+	default:
+		logger.LegacyPrintf("service.request_capture", "[RequestCapture] queue full, capture dropped: request=%s", input.RequestID)
+	}
+}
+
+func (s *RequestCaptureService) writeLoop() {
+	defer s.wg.Done()
+	for {
+		select {
+		case input := <-s.queue:
+			s.persistWithTimeout(input)
+		case <-s.stopCh:
+			// 退出前尽量写完已排队的抓取
+			for {
+				select {
+				case input := <-s.queue:
+					s.persistWithTimeout(input)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *RequestCaptureService) persistWithTimeout(input *RequestCaptureInput) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.persist(ctx, input); err != nil {
+		logger.LegacyPrintf("service.request_capture", "[RequestCapture] persist failed: request=%s err=%v", input.RequestID, err)
+	}
+}
+
+func (s *RequestCaptureService) persist(ctx context.Context, input *RequestCaptureInput) error {
+	createdAt := input.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	record := &RequestCaptureRecord{
+		RequestCapture: RequestCapture{
+			RequestID:     input.RequestID,
+			UserID:        input.UserID,
+			APIKeyID:      input.APIKeyID,
+			GroupID:       input.GroupID,
+			AccountID:     input.AccountID,
+			Platform:      input.Platform,
+			Model:         truncateString(input.Model, 255),
+			Method:        input.Method,
+			Path:          truncateString(input.Path, 255),
+			Stream:        input.Stream,
+			StatusCode:    input.StatusCode,
+			DurationMs:    int(input.Duration.Milliseconds()),
+			RequestBytes:  input.RequestBytes,
+			ResponseBytes: input.ResponseBytes,
+			Truncated:     input.Truncated,
+			Encrypted:     s.encryptEnabled(),
+			CreatedAt:     createdAt,
+			ExpiresAt:     createdAt.AddDate(0, 0, s.cfg.RequestCapture.RetentionDays),
+		},
+	}
+
+	responseBody := s.redactor.Redact(input.ResponseBody)
+	var responseText []byte
+	if input.Stream {
+		responseText = []byte(ReassembleSSEText(responseBody))
+	}
+
+	var err error
+	if record.RequestBody, err = s.seal(s.redactor.Redact(input.RequestBody)); err != nil {
+		return fmt.Errorf("seal request body: %w", err)
+	}
+	if record.ResponseBody, err = s.seal(responseBody); err != nil {
+		return fmt.Errorf("seal response body: %w", err)
+	}
+	if record.ResponseText, err = s.seal(responseText); err != nil {
+		return fmt.Errorf("seal response text: %w", err)
+	}
+	return s.repo.Create(ctx, record)
+}
+
+// seal 压缩并（按配置）加密抓取内容
+func (s *RequestCaptureService) seal(data []byte) ([]byte, error) {
+	compressed, err := gzipRequestCapture(data)
+	if err != nil || len(compressed) == 0 || !s.encryptEnabled() {
+		return compressed, err
+	}
+	encrypted, err := s.encryptor.Encrypt(string(compressed))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(encrypted), nil
+}
+
+func (s *RequestCaptureService) open(data []byte, encrypted bool) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	if encrypted {
+		if s.encryptor == nil {
+			return "", ErrRequestCaptureDecryptUnavailable
+		}
+		plain, err := s.encryptor.Decrypt(string(data))
+		if err != nil {
+			return "", fmt.Errorf("decrypt capture: %w", err)
+		}
+		data = []byte(plain)
+	}
+	out, err := gunzipRequestCapture(data)
+	if err != nil {
+		return "", fmt.Errorf("decompress capture: %w", err)
+	}
+	return string(out), nil
+}
+
+func (s *RequestCaptureService) cleanupLoop() {
+	defer s.wg.Done()
+	interval := time.Duration(s.cfg.RequestCapture.CleanupIntervalMinutes) * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	s.cleanupExpired()
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanupExpired()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *RequestCaptureService) cleanupExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	var total int64
+	for {
+		n, err := s.repo.DeleteExpired(ctx, time.Now(), requestCaptureCleanupBatch)
+		if err != nil {
+			logger.LegacyPrintf("service.request_capture", "[RequestCapture] cleanup failed: %v", err)
+			return
+		}
+		total += n
+		if n < requestCaptureCleanupBatch {
+			break
+		}
+	}
+	if total > 0 {
+		logger.LegacyPrintf("service.request_capture", "[RequestCapture] cleanup removed %d expired captures", total)
+	}
+}
+
+// List 管理员查询抓取记录
+func (s *RequestCaptureService) List(ctx context.Context, filter RequestCaptureListFilter, params pagination.PaginationParams) ([]RequestCapture, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, filter, params)
+}
+
+// Get 管理员查看抓取详情
+func (s *RequestCaptureService) Get(ctx context.Context, id int64) (*RequestCaptureDetail, error) {
+	record, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toDetail(record)
+}
+
+// ListForUser 用户查询自己 API Key 的抓取记录（需策略允许）
+func (s *RequestCaptureService) ListForUser(ctx context.Context, userID int64, filter RequestCaptureListFilter, params pagination.PaginationParams) ([]RequestCapture, *pagination.PaginationResult, error) {
+	if err := s.checkUserView(ctx); err != nil {
+		return nil, nil, err
+	}
+	filter.UserID = &userID
+	return s.repo.List(ctx, filter, params)
+}
+
+// GetForUser 用户查看自己 API Key 的抓取详情（需策略允许）
+func (s *RequestCaptureService) GetForUser(ctx context.Context, userID, id int64) (*RequestCaptureDetail, error) {
+	if err := s.checkUserView(ctx); err != nil {
+		return nil, err
+	}
+	record, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.UserID != userID {
+		return nil, ErrRequestCaptureNotFound
+	}
+	return s.toDetail(record)
+}
+
+func (s *RequestCaptureService) checkUserView(ctx context.Context) error {
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if !policy.AllowUserView {
+		return ErrRequestCaptureViewDisabled
+	}
+	return nil
+}
+
+func (s *RequestCaptureService) toDetail(record *RequestCaptureRecord) (*RequestCaptureDetail, error) {
+	detail := &RequestCaptureDetail{RequestCapture: record.RequestCapture}
+	var err error
+	if detail.RequestBody, err = s.open(record.RequestBody, record.Encrypted); err != nil {
+		return nil, err
+	}
+	if detail.ResponseBody, err = s.open(record.ResponseBody, record.Encrypted); err != nil {
+		return nil, err
+	}
+	if detail.ResponseText, err = s.open(record.ResponseText, record.Encrypted); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
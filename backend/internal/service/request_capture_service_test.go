@@ -0,0 +1,230 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type requestCaptureRepoStub struct {
+	records []*RequestCaptureRecord
+}
+
+func (s *requestCaptureRepoStub) Create(_ context.Context, record *RequestCaptureRecord) error {
+	record.ID = int64(len(s.records) + 1)
+	cp := *record
+	s.records = append(s.records, &cp)
+	return nil
+}
+
+func (s *requestCaptureRepoStub) GetByID(_ context.Context, id int64) (*RequestCaptureRecord, error) {
+	for _, r := range s.records {
+		if r.ID == id {
+			cp := *r
+			return &cp, nil
+		}
+	}
+	return nil, ErrRequestCaptureNotFound
+}
+
+func (s *requestCaptureRepoStub) List(_ context.Context, filter RequestCaptureListFilter, params pagination.PaginationParams) ([]RequestCapture, *pagination.PaginationResult, error) {
+	out := make([]RequestCapture, 0)
+	for _, r := range s.records {
+		if filter.UserID != nil && r.UserID != *filter.UserID {
+			continue
+		}
+		out = append(out, r.RequestCapture)
+	}
+	return out, &pagination.PaginationResult{Total: int64(len(out)), Page: params.Page, PageSize: params.PageSize}, nil
+}
+
+func (s *requestCaptureRepoStub) DeleteExpired(context.Context, time.Time, int) (int64, error) {
+	return 0, nil
+}
+
+// base64Encryptor is a reversible stand-in for the AES encryptor.
+type base64Encryptor struct{}
+
+func (base64Encryptor) Encrypt(plaintext string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(plaintext)), nil
+}
+
+func (base64Encryptor) Decrypt(ciphertext string) (string, error) {
+	out, err := base64.StdEncoding.DecodeString(ciphertext)
+	return string(out), err
+}
+
+func newRequestCaptureTestService(repo *requestCaptureRepoStub, settings *mockSettingRepo, encrypt bool) *RequestCaptureService {
+	cfg := &config.Config{RequestCapture: config.RequestCaptureConfig{
+		Enabled:       true,
+		RetentionDays: 7,
+		MaxBodyBytes:  1 << 20,
+		EncryptAtRest: encrypt,
+		RedactSecrets: true,
+		RedactEmails:  true,
+		QueueSize:     8,
+	}}
+	return NewRequestCaptureService(repo, settings, base64Encryptor{}, cfg)
+}
+
+func TestRequestCaptureService_ShouldCaptureByGroupOrKey(t *testing.T) {
+	settings := newMockSettingRepo()
+	svc := newRequestCaptureTestService(&requestCaptureRepoStub{}, settings, false)
+	ctx := context.Background()
+
+	groupID := int64(3)
+	otherGroupID := int64(4)
+	require.False(t, svc.ShouldCapture(ctx, &APIKey{ID: 1, GroupID: &groupID}), "no policy means no capture")
+
+	_, err := svc.UpdatePolicy(ctx, &RequestCapturePolicy{Enabled: true, GroupIDs: []int64{3, 3, -1}, APIKeyIDs: []int64{9}})
+	require.NoError(t, err)
+
+	require.True(t, svc.ShouldCapture(ctx, &APIKey{ID: 1, GroupID: &groupID}))
+	require.True(t, svc.ShouldCapture(ctx, &APIKey{ID: 9, GroupID: &otherGroupID}))
+	require.False(t, svc.ShouldCapture(ctx, &APIKey{ID: 2, GroupID: &otherGroupID}))
+
+	policy, err := svc.GetPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, policy.GroupIDs)
+
+	_, err = svc.UpdatePolicy(ctx, &RequestCapturePolicy{Enabled: false, GroupIDs: []int64{3}})
+	require.NoError(t, err)
+	require.False(t, svc.ShouldCapture(ctx, &APIKey{ID: 1, GroupID: &groupID}))
+}
+
+func TestRequestCaptureService_PersistRedactsCompressesAndEncrypts(t *testing.T) {
+	repo := &requestCaptureRepoStub{}
+	svc := newRequestCaptureTestService(repo, newMockSettingRepo(), true)
+	ctx := context.Background()
+
+	reqBody := `{"messages":[{"role":"user","content":"key sk-abcdefghijklmnopqrstuvwx mail alice@example.com"}]}`
+	respBody := strings.Join([]string{
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world"}}`,
+		``,
+	}, "\n")
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := svc.persist(ctx, &RequestCaptureInput{
+		RequestID:    "client:abc",
+		UserID:       7,
+		APIKeyID:     9,
+		Stream:       true,
+		RequestBody:  []byte(reqBody),
+		ResponseBody: []byte(respBody),
+		CreatedAt:    createdAt,
+	})
+	require.NoError(t, err)
+	require.Len(t, repo.records, 1)
+
+	stored := repo.records[0]
+	require.True(t, stored.Encrypted)
+	require.Equal(t, createdAt.AddDate(0, 0, 7), stored.ExpiresAt)
+	require.NotContains(t, string(stored.RequestBody), "alice")
+
+	detail, err := svc.Get(ctx, stored.ID)
+	require.NoError(t, err)
+	require.NotContains(t, detail.RequestBody, "sk-abcdefghijklmnopqrstuvwx")
+	require.NotContains(t, detail.RequestBody, "alice@example.com")
+	require.Contains(t, detail.RequestBody, requestCaptureRedacted)
+	require.Contains(t, detail.RequestBody, requestCaptureRedactedEmail)
+	require.Equal(t, respBody, detail.ResponseBody)
+	require.Equal(t, "Hello, world", detail.ResponseText)
+}
+
+func TestRequestCaptureService_UserViewRequiresPolicyAndOwnership(t *testing.T) {
+	repo := &requestCaptureRepoStub{}
+	settings := newMockSettingRepo()
+	svc := newRequestCaptureTestService(repo, settings, false)
+	ctx := context.Background()
+
+	require.NoError(t, svc.persist(ctx, &RequestCaptureInput{UserID: 7, APIKeyID: 9, RequestBody: []byte(`{}`)}))
+	params := pagination.PaginationParams{Page: 1, PageSize: 20}
+
+	_, _, err := svc.ListForUser(ctx, 7, RequestCaptureListFilter{}, params)
+	require.ErrorIs(t, err, ErrRequestCaptureViewDisabled)
+
+	_, err = svc.UpdatePolicy(ctx, &RequestCapturePolicy{Enabled: true, AllowUserView: true})
+	require.NoError(t, err)
+
+	captures, _, err := svc.ListForUser(ctx, 7, RequestCaptureListFilter{}, params)
+	require.NoError(t, err)
+	require.Len(t, captures, 1)
+
+	captures, _, err = svc.ListForUser(ctx, 8, RequestCaptureListFilter{}, params)
+	require.NoError(t, err)
+	require.Empty(t, captures)
+
+	_, err = svc.GetForUser(ctx, 8, repo.records[0].ID)
+	require.ErrorIs(t, err, ErrRequestCaptureNotFound)
+
+	detail, err := svc.GetForUser(ctx, 7, repo.records[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, `{}`, detail.RequestBody)
+}
+
+func TestReassembleSSEText_OpenAIAndGemini(t *testing.T) {
+	chat := "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: {\"choices\":[{\"delta\":{\"content\":\" there\"}}]}\n\ndata: [DONE]\n"
+	require.Equal(t, "Hi there", ReassembleSSEText([]byte(chat)))
+
+	responses := "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"ok\"}\n"
+	require.Equal(t, "ok", ReassembleSSEText([]byte(responses)))
+
+	gemini := "data: {\"response\":{\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"think\",\"thought\":true},{\"text\":\"answer\"}]}}]}}\n"
+	require.Equal(t, "answer", ReassembleSSEText([]byte(gemini)))
+}
+
+func TestRequestCapture_JoinsUsageLogByRequestID(t *testing.T) {
+	reqCtx := context.WithValue(context.Background(), ctxkey.ClientRequestID, "crid-123")
+	reqCtx = context.WithValue(reqCtx, ctxkey.RequestID, "rid-456")
+
+	// 用量记录在 worker 池中以 context.Background() 执行
+	usageRepo := &openAIRecordUsageLogRepoStub{inserted: true}
+	gatewaySvc := newGatewayRecordUsageServiceForTest(usageRepo, &openAIRecordUsageUserRepoStub{}, &openAIRecordUsageSubRepoStub{})
+	pool := NewUsageRecordWorkerPoolWithOptions(UsageRecordWorkerPoolOptions{
+		WorkerCount:    1,
+		QueueSize:      8,
+		TaskTimeout:    time.Second,
+		OverflowPolicy: config.UsageRecordOverflowPolicyDrop,
+	})
+	t.Cleanup(pool.Stop)
+	done := make(chan error, 1)
+	pool.Submit(WithUsageRecordRequestContext(reqCtx, func(ctx context.Context) {
+		done <- gatewaySvc.RecordUsage(ctx, &RecordUsageInput{
+			Result:  &ForwardResult{RequestID: "msg_upstream", Model: "claude-sonnet-4", Usage: ClaudeUsage{InputTokens: 1}},
+			APIKey:  &APIKey{ID: 11},
+			User:    &User{ID: 12},
+			Account: &Account{ID: 13},
+		})
+	}))
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("usage record task not executed")
+	}
+
+	repo := &requestCaptureRepoStub{}
+	captureSvc := newRequestCaptureTestService(repo, newMockSettingRepo(), false)
+	require.NoError(t, captureSvc.persist(context.Background(), &RequestCaptureInput{
+		RequestID: UsageRequestIDFromContext(reqCtx),
+		UserID:    12,
+		APIKeyID:  11,
+	}))
+
+	require.NotNil(t, usageRepo.lastLog)
+	require.Equal(t, "client:crid-123", usageRepo.lastLog.RequestID, "用量记录使用请求标识而非上游请求 ID")
+	require.Len(t, repo.records, 1)
+	require.Equal(t, usageRepo.lastLog.RequestID, repo.records[0].RequestID)
+}
@@ -18,6 +18,8 @@ type RequestMetadata struct {
 	PrefetchedStickyGroupID    *int64
 	SingleAccountRetry         *bool
 	AccountSwitchCount         *int
+	RequestPriority            *string
+	RequestHeaderTransforms    []RequestHeaderTransform
 }
 
 var (
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 分组请求改写规则
+//
+// 管理员可为分组配置有序的改写规则，各平台网关在转发前对入站请求体依次执行（path 按入站请求格式书写，
+// OpenAI Responses WebSocket 不应用）：
+//   - set: 将 path 设置为 value（任意 JSON 值），如强制 {"thinking": {"type": "disabled"}}
+//   - remove: 删除 path
+//   - clamp: 将数值字段限制在 [min, max] 内，如限制 max_tokens
+//   - remove_items: 删除 path 数组中匹配 match 的元素，如剔除某类 tools；数组被清空时删除该字段
+//   - prepend_system: 在系统提示词前插入 value，插入位置随请求格式而定（见 RequestTransformFormat*）
+//   - set_header / remove_header: 设置或删除上游请求头（凭证类请求头不可改写；Bedrock / Antigravity 上游与 Sora OAuth 账号不应用）
+//
+// 单条规则执行失败时跳过该规则，不影响后续规则与请求转发。
+type RequestTransformRule = domain.RequestTransformRule
+
+const (
+	RequestTransformSet           = "set"
+	RequestTransformRemove        = "remove"
+	RequestTransformClamp         = "clamp"
+	RequestTransformRemoveItems   = "remove_items"
+	RequestTransformPrependSystem = "prepend_system"
+	RequestTransformSetHeader     = "set_header"
+	RequestTransformRemoveHeader  = "remove_header"
+)
+
+// 入站请求格式，决定 prepend_system 的插入位置
+const (
+	RequestTransformFormatAnthropic       = "anthropic"        // system（字符串以空行连接，数组插入首个文本块）
+	RequestTransformFormatOpenAIResponses = "openai_responses" // instructions（以空行连接）
+	RequestTransformFormatOpenAIChat      = "openai_chat"      // messages 首部插入 system 消息
+	RequestTransformFormatGemini          = "gemini"           // systemInstruction.parts 首部插入文本
+)
+
+// maxRequestTransformRules 单个分组允许的最大规则数
+const maxRequestTransformRules = 32
+
+// requestTransformProtectedHeaders 不允许通过规则改写的请求头（凭证与传输层头部）
+var requestTransformProtectedHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-api-key":           {},
+	"x-goog-api-key":      {},
+	"cookie":              {},
+	"host":                {},
+	"content-length":      {},
+	"transfer-encoding":   {},
+	"connection":          {},
+}
+
+// RequestHeaderTransform 转发前对上游请求头的一次改写
+type RequestHeaderTransform struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Remove bool   `json:"remove,omitempty"`
+}
+
+// RequestTransformTrace 单条规则的执行结果，用于 dry-run 展示
+type RequestTransformTrace struct {
+	Index   int    `json:"index"`
+	Name    string `json:"name,omitempty"`
+	Action  string `json:"action"`
+	Applied bool   `json:"applied"`
+	// Skipped 未生效原因：disabled / model_not_matched / path_not_found / within_range / no_match
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RequestTransformResult 执行改写规则后的请求
+type RequestTransformResult struct {
+	Body    []byte
+	Headers []RequestHeaderTransform
+	Trace   []RequestTransformTrace
+	// BodyChanged 请求体是否被改写（调用方据此决定是否重新解析请求）
+	BodyChanged bool
+}
+
+// ApplyRequestTransforms 按顺序对 Anthropic Messages 请求体执行改写规则，请求头改写收集到 Headers 中由转发时应用
+func ApplyRequestTransforms(body []byte, rules []RequestTransformRule) *RequestTransformResult {
+	return ApplyRequestTransformsWithFormat(body, rules, RequestTransformFormatAnthropic, "")
+}
+
+// ApplyRequestTransformsWithFormat 按入站请求格式执行改写规则；
+// model 为空时从请求体 model 字段读取（Gemini 原生 API 的模型位于 URL 路径，需由调用方传入）
+func ApplyRequestTransformsWithFormat(body []byte, rules []RequestTransformRule, format, model string) *RequestTransformResult {
+	result := &RequestTransformResult{Body: body}
+	if len(rules) == 0 {
+		return result
+	}
+	if model == "" {
+		model = gjson.GetBytes(body, "model").String()
+	}
+	for i := range rules {
+		rule := &rules[i]
+		trace := RequestTransformTrace{Index: i, Name: rule.Name, Action: rule.Action}
+		switch {
+		case rule.Disabled:
+			trace.Skipped = "disabled"
+		case !requestTransformModelMatched(rule.Models, model):
+			trace.Skipped = "model_not_matched"
+		default:
+			next, skipped, err := applyRequestTransformRule(result, rule, format)
+			switch {
+			case err != nil:
+				trace.Error = err.Error()
+			case skipped != "":
+				trace.Skipped = skipped
+			default:
+				trace.Applied = true
+				if !bytes.Equal(next, result.Body) {
+					result.Body = next
+					result.BodyChanged = true
+				}
+			}
+		}
+		result.Trace = append(result.Trace, trace)
+	}
+	return result
+}
+
+func requestTransformModelMatched(patterns []string, model string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRequestTransformRule 执行单条规则，返回改写后的请求体或未生效原因
+func applyRequestTransformRule(result *RequestTransformResult, rule *RequestTransformRule, format string) ([]byte, string, error) {
+	body := result.Body
+	switch rule.Action {
+	case RequestTransformSet:
+		out, err := sjson.SetBytes(body, rule.Path, rule.Value)
+		return out, "", err
+	case RequestTransformRemove:
+		if !gjson.GetBytes(body, rule.Path).Exists() {
+			return body, "path_not_found", nil
+		}
+		out, err := sjson.DeleteBytes(body, rule.Path)
+		return out, "", err
+	case RequestTransformClamp:
+		return clampRequestField(body, rule)
+	case RequestTransformRemoveItems:
+		return removeMatchingRequestItems(body, rule)
+	case RequestTransformPrependSystem:
+		prefix, _ := rule.Value.(string)
+		var out []byte
+		var err error
+		switch format {
+		case RequestTransformFormatOpenAIResponses:
+			out, err = prependInstructions(body, prefix)
+		case RequestTransformFormatOpenAIChat:
+			out, err = prependSystemMessage(body, prefix)
+		case RequestTransformFormatGemini:
+			out, err = prependSystemInstruction(body, prefix)
+		default:
+			out, err = prependSystemPrompt(body, prefix)
+		}
+		return out, "", err
+	case RequestTransformSetHeader:
+		value, _ := rule.Value.(string)
+		result.Headers = append(result.Headers, RequestHeaderTransform{Name: http.CanonicalHeaderKey(rule.Header), Value: value})
+		return body, "", nil
+	case RequestTransformRemoveHeader:
+		result.Headers = append(result.Headers, RequestHeaderTransform{Name: http.CanonicalHeaderKey(rule.Header), Remove: true})
+		return body, "", nil
+	default:
+		return body, "", fmt.Errorf("unsupported action %q", rule.Action)
+	}
+}
+
+func clampRequestField(body []byte, rule *RequestTransformRule) ([]byte, string, error) {
+	field := gjson.GetBytes(body, rule.Path)
+	if !field.Exists() {
+		return body, "path_not_found", nil
+	}
+	if field.Type != gjson.Number {
+		return body, "", fmt.Errorf("%s is not a number", rule.Path)
+	}
+	value := field.Float()
+	clamped := value
+	if rule.Min != nil && clamped < *rule.Min {
+		clamped = *rule.Min
+	}
+	if rule.Max != nil && clamped > *rule.Max {
+		clamped = *rule.Max
+	}
+	if clamped == value {
+		return body, "within_range", nil
+	}
+	var out []byte
+	var err error
+	if clamped == math.Trunc(clamped) && math.Abs(clamped) < 1<<53 {
+		out, err = sjson.SetBytes(body, rule.Path, int64(clamped))
+	} else {
+		out, err = sjson.SetBytes(body, rule.Path, clamped)
+	}
+	return out, "", err
+}
+
+func removeMatchingRequestItems(body []byte, rule *RequestTransformRule) ([]byte, string, error) {
+	field := gjson.GetBytes(body, rule.Path)
+	if !field.Exists() {
+		return body, "path_not_found", nil
+	}
+	if !field.IsArray() {
+		return body, "", fmt.Errorf("%s is not an array", rule.Path)
+	}
+	kept := make([]string, 0)
+	removed := 0
+	field.ForEach(func(_, item gjson.Result) bool {
+		if requestItemMatches(item, rule.Match) {
+			removed++
+		} else {
+			kept = append(kept, item.Raw)
+		}
+		return true
+	})
+	if removed == 0 {
+		return body, "no_match", nil
+	}
+	if len(kept) == 0 {
+		out, err := sjson.DeleteBytes(body, rule.Path)
+		return out, "", err
+	}
+	out, err := sjson.SetRawBytes(body, rule.Path, []byte("["+strings.Join(kept, ",")+"]"))
+	return out, "", err
+}
+
+// requestItemMatches 数组元素的所有 match 字段均与期望值相等时视为匹配；字符串期望值支持末尾 * 通配
+func requestItemMatches(item gjson.Result, match map[string]any) bool {
+	for key, expected := range match {
+		actual := item.Get(key)
+		if !actual.Exists() {
+			return false
+		}
+		if pattern, ok := expected.(string); ok {
+			if actual.Type != gjson.String || !matchModelPattern(pattern, actual.String()) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(normalizeJSONValue(expected), actual.Value()) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeJSONValue 将值经 JSON 往返转换为与 gjson.Result.Value 一致的表示（数字统一为 float64）
+func normalizeJSONValue(v any) any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// prependSystemPrompt 在 Anthropic Messages 的 system 提示词前插入前缀
+func prependSystemPrompt(body []byte, prefix string) ([]byte, error) {
+	system := gjson.GetBytes(body, "system")
+	switch {
+	case !system.Exists() || system.Type == gjson.Null:
+		return sjson.SetBytes(body, "system", prefix)
+	case system.Type == gjson.String:
+		if system.String() == "" {
+			return sjson.SetBytes(body, "system", prefix)
+		}
+		return sjson.SetBytes(body, "system", prefix+"\n\n"+system.String())
+	case system.IsArray():
+		block, err := json.Marshal(struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}{Type: "text", Text: prefix})
+		if err != nil {
+			return body, err
+		}
+		items := []string{string(block)}
+		system.ForEach(func(_, item gjson.Result) bool {
+			items = append(items, item.Raw)
+			return true
+		})
+		return sjson.SetRawBytes(body, "system", []byte("["+strings.Join(items, ",")+"]"))
+	default:
+		return body, fmt.Errorf("system must be a string or an array")
+	}
+}
+
+// prependInstructions 在 OpenAI Responses 的 instructions 前插入前缀
+func prependInstructions(body []byte, prefix string) ([]byte, error) {
+	instructions := gjson.GetBytes(body, "instructions")
+	switch {
+	case !instructions.Exists() || instructions.Type == gjson.Null || (instructions.Type == gjson.String && instructions.String() == ""):
+		return sjson.SetBytes(body, "instructions", prefix)
+	case instructions.Type == gjson.String:
+		return sjson.SetBytes(body, "instructions", prefix+"\n\n"+instructions.String())
+	default:
+		return body, fmt.Errorf("instructions must be a string")
+	}
+}
+
+// prependSystemMessage 在 Chat Completions 的 messages 首部插入 system 消息
+func prependSystemMessage(body []byte, prefix string) ([]byte, error) {
+	messages := gjson.GetBytes(body, "messages")
+	if !messages.IsArray() {
+		return body, fmt.Errorf("messages must be an array")
+	}
+	message, err := json.Marshal(struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}{Role: "system", Content: prefix})
+	if err != nil {
+		return body, err
+	}
+	items := []string{string(message)}
+	messages.ForEach(func(_, item gjson.Result) bool {
+		items = append(items, item.Raw)
+		return true
+	})
+	return sjson.SetRawBytes(body, "messages", []byte("["+strings.Join(items, ",")+"]"))
+}
+
+// prependSystemInstruction 在 Gemini 的 systemInstruction.parts 首部插入文本
+func prependSystemInstruction(body []byte, prefix string) ([]byte, error) {
+	part, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{Text: prefix})
+	if err != nil {
+		return body, err
+	}
+	parts := gjson.GetBytes(body, "systemInstruction.parts")
+	switch {
+	case !gjson.GetBytes(body, "systemInstruction").Exists():
+		return sjson.SetRawBytes(body, "systemInstruction", []byte(`{"parts":[`+string(part)+`]}`))
+	case !parts.Exists():
+		return sjson.SetRawBytes(body, "systemInstruction.parts", []byte("["+string(part)+"]"))
+	case parts.IsArray():
+		items := []string{string(part)}
+		parts.ForEach(func(_, item gjson.Result) bool {
+			items = append(items, item.Raw)
+			return true
+		})
+		return sjson.SetRawBytes(body, "systemInstruction.parts", []byte("["+strings.Join(items, ",")+"]"))
+	default:
+		return body, fmt.Errorf("systemInstruction.parts must be an array")
+	}
+}
+
+// IsRequestTransformFormat 判断是否为支持的入站请求格式
+func IsRequestTransformFormat(format string) bool {
+	switch format {
+	case RequestTransformFormatAnthropic, RequestTransformFormatOpenAIResponses, RequestTransformFormatOpenAIChat, RequestTransformFormatGemini:
+		return true
+	}
+	return false
+}
+
+// WithRequestHeaderTransforms 在请求上下文中记录待应用的上游请求头改写
+func WithRequestHeaderTransforms(ctx context.Context, headers []RequestHeaderTransform) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	return updateRequestMetadata(ctx, false, func(md *RequestMetadata) {
+		md.RequestHeaderTransforms = headers
+	}, nil)
+}
+
+// applyRequestHeaderTransforms 将分组改写规则中的请求头改写应用到上游请求；
+// 受保护的请求头即使出现在已保存的规则中也不改写
+func applyRequestHeaderTransforms(ctx context.Context, req *http.Request) {
+	if req == nil {
+		return
+	}
+	md := metadataFromContext(ctx)
+	if md == nil {
+		return
+	}
+	for _, h := range md.RequestHeaderTransforms {
+		if _, protected := requestTransformProtectedHeaders[strings.ToLower(h.Name)]; protected {
+			continue
+		}
+		if h.Remove {
+			req.Header.Del(h.Name)
+		} else {
+			req.Header.Set(h.Name, h.Value)
+		}
+	}
+}
+
+func requestTransformInvalid(index int, format string, args ...any) error {
+	return infraerrors.Newf(http.StatusBadRequest, "REQUEST_TRANSFORM_INVALID", "request_transforms[%d]: %s", index, fmt.Sprintf(format, args...))
+}
+
+// NormalizeRequestTransforms 校验并规范化改写规则（去除空白、统一动作名称）
+func NormalizeRequestTransforms(rules []RequestTransformRule) ([]RequestTransformRule, error) {
+	if rules == nil {
+		return nil, nil
+	}
+	if len(rules) > maxRequestTransformRules {
+		return nil, infraerrors.Newf(http.StatusBadRequest, "REQUEST_TRANSFORM_INVALID", "at most %d request transform rules are allowed", maxRequestTransformRules)
+	}
+	normalized := make([]RequestTransformRule, 0, len(rules))
+	for i, rule := range rules {
+		rule.Name = strings.TrimSpace(rule.Name)
+		rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+		rule.Path = strings.TrimSpace(rule.Path)
+		rule.Header = strings.TrimSpace(rule.Header)
+		if len(rule.Name) > 64 {
+			return nil, requestTransformInvalid(i, "name must be at most 64 characters")
+		}
+		models := make([]string, 0, len(rule.Models))
+		for _, model := range rule.Models {
+			if model = strings.TrimSpace(model); model != "" {
+				models = append(models, model)
+			}
+		}
+		rule.Models = nil
+		if len(models) > 0 {
+			rule.Models = models
+		}
+		if err := validateRequestTransformRule(i, &rule); err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, rule)
+	}
+	return normalized, nil
+}
+
+func validateRequestTransformRule(i int, rule *RequestTransformRule) error {
+	switch rule.Action {
+	case RequestTransformSet, RequestTransformRemove, RequestTransformClamp, RequestTransformRemoveItems:
+		if err := validateRequestTransformPath(rule.Path); err != nil {
+			return requestTransformInvalid(i, "%v", err)
+		}
+	case RequestTransformPrependSystem, RequestTransformSetHeader, RequestTransformRemoveHeader:
+	default:
+		return requestTransformInvalid(i, "unsupported action %q", rule.Action)
+	}
+
+	switch rule.Action {
+	case RequestTransformSet:
+		if rule.Value == nil {
+			return requestTransformInvalid(i, "set requires value")
+		}
+	case RequestTransformClamp:
+		if rule.Min == nil && rule.Max == nil {
+			return requestTransformInvalid(i, "clamp requires min or max")
+		}
+		if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+			return requestTransformInvalid(i, "clamp min must not exceed max")
+		}
+	case RequestTransformRemoveItems:
+		if len(rule.Match) == 0 {
+			return requestTransformInvalid(i, "remove_items requires match")
+		}
+	case RequestTransformPrependSystem:
+		prefix, ok := rule.Value.(string)
+		if !ok || strings.TrimSpace(prefix) == "" {
+			return requestTransformInvalid(i, "prepend_system requires a non-empty string value")
+		}
+	case RequestTransformSetHeader, RequestTransformRemoveHeader:
+		if err := validateRequestTransformHeader(rule.Header); err != nil {
+			return requestTransformInvalid(i, "%v", err)
+		}
+		if rule.Action == RequestTransformSetHeader {
+			value, ok := rule.Value.(string)
+			if !ok || strings.ContainsAny(value, "\r\n") {
+				return requestTransformInvalid(i, "set_header requires a single-line string value")
+			}
+		}
+	}
+	return nil
+}
+
+// validateRequestTransformPath 仅允许 sjson 支持的普通路径（不支持 gjson 查询、通配与修饰符）
+func validateRequestTransformPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("path is required")
+	}
+	if strings.ContainsAny(path, "*?#|@") {
+		return fmt.Errorf("path %q must be a plain dotted path", path)
+	}
+	if strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+		return fmt.Errorf("path %q is malformed", path)
+	}
+	return nil
+}
+
+func validateRequestTransformHeader(name string) error {
+	if name == "" {
+		return fmt.Errorf("header is required")
+	}
+	for _, r := range name {
+		if !isHeaderTokenChar(r) {
+			return fmt.Errorf("header %q is not a valid header name", name)
+		}
+	}
+	if _, protected := requestTransformProtectedHeaders[strings.ToLower(name)]; protected {
+		return fmt.Errorf("header %q cannot be modified", name)
+	}
+	return nil
+}
+
+func isHeaderTokenChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
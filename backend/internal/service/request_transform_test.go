@@ -0,0 +1,166 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyRequestTransforms_BodyActions(t *testing.T) {
+	maxTokens := float64(8192)
+	body := []byte(`{"model":"claude-opus-4-5","max_tokens":32000,"thinking":{"type":"enabled","budget_tokens":16000},"metadata":{"user_id":"u1"},"tools":[{"type":"web_search_20250305","name":"web_search"},{"name":"get_weather","input_schema":{}}],"system":"be nice"}`)
+	rules := []RequestTransformRule{
+		{Name: "cap", Action: RequestTransformClamp, Path: "max_tokens", Max: &maxTokens},
+		{Name: "no-thinking", Action: RequestTransformSet, Path: "thinking", Value: map[string]any{"type": "disabled"}},
+		{Action: RequestTransformRemove, Path: "metadata.user_id"},
+		{Action: RequestTransformRemoveItems, Path: "tools", Match: map[string]any{"type": "web_search_*"}},
+		{Action: RequestTransformPrependSystem, Value: "You are a helpful assistant."},
+	}
+
+	result := ApplyRequestTransforms(body, rules)
+	require.True(t, result.BodyChanged)
+	for _, trace := range result.Trace {
+		require.True(t, trace.Applied, "rule %d: %+v", trace.Index, trace)
+	}
+	require.Equal(t, int64(8192), gjson.GetBytes(result.Body, "max_tokens").Int())
+	require.Equal(t, "8192", gjson.GetBytes(result.Body, "max_tokens").Raw, "整数上限写回整数")
+	require.Equal(t, `{"type":"disabled"}`, gjson.GetBytes(result.Body, "thinking").Raw)
+	require.False(t, gjson.GetBytes(result.Body, "metadata.user_id").Exists())
+	require.Equal(t, int64(1), gjson.GetBytes(result.Body, "tools.#").Int())
+	require.Equal(t, "get_weather", gjson.GetBytes(result.Body, "tools.0.name").String())
+	require.Equal(t, "You are a helpful assistant.\n\nbe nice", gjson.GetBytes(result.Body, "system").String())
+}
+
+func TestApplyRequestTransforms_SkipsAndErrors(t *testing.T) {
+	minTokens := float64(1)
+	maxTokens := float64(8192)
+	body := []byte(`{"model":"claude-sonnet-4-5","max_tokens":1024,"system":[{"type":"text","text":"existing"}],"tools":[{"name":"a"}]}`)
+	rules := []RequestTransformRule{
+		{Action: RequestTransformRemove, Path: "metadata", Disabled: true},
+		{Action: RequestTransformRemove, Path: "metadata", Models: []string{"claude-opus-*"}},
+		{Action: RequestTransformRemove, Path: "metadata"},
+		{Action: RequestTransformClamp, Path: "max_tokens", Min: &minTokens, Max: &maxTokens},
+		{Action: RequestTransformRemoveItems, Path: "tools", Match: map[string]any{"type": "web_search_*"}},
+		{Action: RequestTransformClamp, Path: "system", Max: &maxTokens},
+		{Action: RequestTransformPrependSystem, Value: "prefix", Models: []string{"claude-sonnet-*"}},
+	}
+
+	result := ApplyRequestTransforms(body, rules)
+	skipped := make([]string, 0, len(result.Trace))
+	for _, trace := range result.Trace {
+		skipped = append(skipped, trace.Skipped)
+	}
+	require.Equal(t, []string{"disabled", "model_not_matched", "path_not_found", "within_range", "no_match", "", ""}, skipped)
+	require.NotEmpty(t, result.Trace[5].Error, "非数值字段 clamp 记录错误并跳过")
+	require.False(t, result.Trace[5].Applied)
+	require.True(t, result.Trace[6].Applied)
+	require.Equal(t, `[{"type":"text","text":"prefix"},{"type":"text","text":"existing"}]`, gjson.GetBytes(result.Body, "system").Raw)
+}
+
+func TestApplyRequestTransforms_RemoveItemsClearsEmptyArray(t *testing.T) {
+	body := []byte(`{"model":"m","tools":[{"type":"bash_20250124","name":"bash"}]}`)
+	result := ApplyRequestTransforms(body, []RequestTransformRule{
+		{Action: RequestTransformRemoveItems, Path: "tools", Match: map[string]any{"name": "bash"}},
+	})
+	require.True(t, result.BodyChanged)
+	require.False(t, gjson.GetBytes(result.Body, "tools").Exists(), "数组清空后删除字段，避免上游拒绝空 tools")
+}
+
+func TestApplyRequestTransforms_HeaderActions(t *testing.T) {
+	body := []byte(`{"model":"m"}`)
+	result := ApplyRequestTransforms(body, []RequestTransformRule{
+		{Action: RequestTransformSetHeader, Header: "x-custom-tag", Value: "team-a"},
+		{Action: RequestTransformRemoveHeader, Header: "anthropic-beta"},
+	})
+	require.False(t, result.BodyChanged)
+	require.Equal(t, []RequestHeaderTransform{
+		{Name: "X-Custom-Tag", Value: "team-a"},
+		{Name: "Anthropic-Beta", Remove: true},
+	}, result.Headers)
+
+	ctx := WithRequestHeaderTransforms(context.Background(), result.Headers)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", nil)
+	require.NoError(t, err)
+	req.Header.Set("anthropic-beta", "interleaved-thinking-2025-05-14")
+	applyRequestHeaderTransforms(ctx, req)
+	require.Equal(t, "team-a", req.Header.Get("X-Custom-Tag"))
+	require.Empty(t, req.Header.Get("anthropic-beta"))
+
+	// 已保存的规则即使包含受保护请求头也不会被应用
+	ctx = WithRequestHeaderTransforms(context.Background(), []RequestHeaderTransform{{Name: "Proxy-Authorization", Value: "Basic x"}})
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", nil)
+	require.NoError(t, err)
+	applyRequestHeaderTransforms(ctx, req)
+	require.Empty(t, req.Header.Get("Proxy-Authorization"))
+}
+
+func TestApplyRequestTransformsWithFormat_PrependSystem(t *testing.T) {
+	rule := []RequestTransformRule{{Action: RequestTransformPrependSystem, Value: "prefix", Models: []string{"gpt-5*", "gemini-2.5-*"}}}
+
+	result := ApplyRequestTransformsWithFormat([]byte(`{"model":"gpt-5","instructions":"existing"}`), rule, RequestTransformFormatOpenAIResponses, "")
+	require.Equal(t, "prefix\n\nexisting", gjson.GetBytes(result.Body, "instructions").String())
+	require.False(t, gjson.GetBytes(result.Body, "system").Exists())
+
+	result = ApplyRequestTransformsWithFormat([]byte(`{"model":"gpt-5"}`), rule, RequestTransformFormatOpenAIResponses, "")
+	require.Equal(t, "prefix", gjson.GetBytes(result.Body, "instructions").String())
+
+	result = ApplyRequestTransformsWithFormat([]byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}]}`), rule, RequestTransformFormatOpenAIChat, "")
+	require.JSONEq(t, `[{"role":"system","content":"prefix"},{"role":"user","content":"hi"}]`, gjson.GetBytes(result.Body, "messages").Raw)
+
+	result = ApplyRequestTransformsWithFormat([]byte(`{"model":"gpt-5"}`), rule, RequestTransformFormatOpenAIChat, "")
+	require.NotEmpty(t, result.Trace[0].Error)
+	require.False(t, result.BodyChanged)
+
+	// Gemini 原生请求的模型来自 URL 路径
+	body := []byte(`{"contents":[],"systemInstruction":{"parts":[{"text":"existing"}]}}`)
+	result = ApplyRequestTransformsWithFormat(body, rule, RequestTransformFormatGemini, "")
+	require.Equal(t, "model_not_matched", result.Trace[0].Skipped)
+	result = ApplyRequestTransformsWithFormat(body, rule, RequestTransformFormatGemini, "gemini-2.5-pro")
+	require.JSONEq(t, `[{"text":"prefix"},{"text":"existing"}]`, gjson.GetBytes(result.Body, "systemInstruction.parts").Raw)
+
+	result = ApplyRequestTransformsWithFormat([]byte(`{"contents":[]}`), rule, RequestTransformFormatGemini, "gemini-2.5-flash")
+	require.JSONEq(t, `{"parts":[{"text":"prefix"}]}`, gjson.GetBytes(result.Body, "systemInstruction").Raw)
+}
+
+func TestNormalizeRequestTransforms_Validation(t *testing.T) {
+	maxTokens := float64(10)
+	minTokens := float64(20)
+	cases := []struct {
+		name string
+		rule RequestTransformRule
+		want string
+	}{
+		{"unknown action", RequestTransformRule{Action: "rename", Path: "a"}, "unsupported action"},
+		{"missing path", RequestTransformRule{Action: RequestTransformRemove}, "path is required"},
+		{"query path", RequestTransformRule{Action: RequestTransformRemove, Path: "tools.#(type==x)"}, "plain dotted path"},
+		{"set without value", RequestTransformRule{Action: RequestTransformSet, Path: "a"}, "set requires value"},
+		{"clamp without bounds", RequestTransformRule{Action: RequestTransformClamp, Path: "max_tokens"}, "clamp requires min or max"},
+		{"clamp inverted", RequestTransformRule{Action: RequestTransformClamp, Path: "max_tokens", Min: &minTokens, Max: &maxTokens}, "min must not exceed max"},
+		{"remove_items without match", RequestTransformRule{Action: RequestTransformRemoveItems, Path: "tools"}, "requires match"},
+		{"empty system prefix", RequestTransformRule{Action: RequestTransformPrependSystem, Value: " "}, "non-empty string"},
+		{"protected header", RequestTransformRule{Action: RequestTransformRemoveHeader, Header: "X-Api-Key"}, "cannot be modified"},
+		{"proxy credential header", RequestTransformRule{Action: RequestTransformSetHeader, Header: "Proxy-Authorization", Value: "Basic x"}, "cannot be modified"},
+		{"invalid header", RequestTransformRule{Action: RequestTransformRemoveHeader, Header: "bad header"}, "not a valid header name"},
+		{"multiline header value", RequestTransformRule{Action: RequestTransformSetHeader, Header: "x-a", Value: "a\r\nb"}, "single-line"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NormalizeRequestTransforms([]RequestTransformRule{tc.rule})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.want)
+		})
+	}
+
+	rules := make([]RequestTransformRule, maxRequestTransformRules+1)
+	_, err := NormalizeRequestTransforms(rules)
+	require.Error(t, err)
+
+	normalized, err := NormalizeRequestTransforms(nil)
+	require.NoError(t, err)
+	require.Nil(t, normalized)
+}
@@ -9,5 +9,10 @@ func compileResponseHeaderFilter(cfg *config.Config) *responseheaders.CompiledHe
 	if cfg == nil {
 		return nil
 	}
-	return responseheaders.CompileHeaderFilter(cfg.Security.ResponseHeaders)
+	filter := responseheaders.CompileHeaderFilter(cfg.Security.ResponseHeaders)
+	if cfg.Gateway.UserRateLimitHeaders.Enabled {
+		// 限流头部改由网关按用户额度生成，上游账号的限流头部不再透传
+		filter = filter.WithoutRateLimitHeaders()
+	}
+	return filter
 }
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// ErrPreviousResponseNotFound previous_response_id 对应的会话不存在或已过期
+var ErrPreviousResponseNotFound = infraerrors.NotFound("PREVIOUS_RESPONSE_NOT_FOUND", "previous response not found")
+
+// ResponsesConversationCache 存储 Responses 兼容层的会话上下文（按 API Key 隔离）
+type ResponsesConversationCache interface {
+	// GetConversation 读取会话上下文，不存在时返回 (nil, nil)
+	GetConversation(ctx context.Context, apiKeyID int64, responseID string) ([]byte, error)
+	SetConversation(ctx context.Context, apiKeyID int64, responseID string, data []byte, ttl time.Duration) error
+}
+
+// ResponsesConversationService 为 Anthropic 分组上的 /v1/responses 模拟 previous_response_id：
+// 每次响应完成后保存“输入 + 输出”的完整上下文，下一次请求引用该响应 ID 时拼接到输入前面。
+type ResponsesConversationService struct {
+	cache ResponsesConversationCache
+	cfg   *config.Config
+}
+
+func NewResponsesConversationService(cache ResponsesConversationCache, cfg *config.Config) *ResponsesConversationService {
+	return &ResponsesConversationService{cache: cache, cfg: cfg}
+}
+
+// Enabled 是否允许 Anthropic 分组处理 /v1/responses
+func (s *ResponsesConversationService) Enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Gateway.ResponsesCompat.Enabled
+}
+
+func (s *ResponsesConversationService) ttl() time.Duration {
+	if s == nil || s.cfg == nil || s.cache == nil {
+		return 0
+	}
+	return time.Duration(s.cfg.Gateway.ResponsesCompat.ConversationTTLMinutes) * time.Minute
+}
+
+// ResolveInput 返回本次请求的完整输入：存在 previous_response_id 时拼接历史上下文
+func (s *ResponsesConversationService) ResolveInput(ctx context.Context, apiKeyID int64, req *apicompat.ResponsesRequest) ([]apicompat.ResponsesInputItem, error) {
+	input, err := apicompat.ParseResponsesInput(req.Input)
+	if err != nil {
+		return nil, infraerrors.BadRequest("INVALID_INPUT", "invalid input: "+err.Error())
+	}
+	if req.PreviousResponseID == "" {
+		return input, nil
+	}
+	if s.ttl() <= 0 {
+		return nil, ErrPreviousResponseNotFound
+	}
+
+	data, err := s.cache.GetConversation(ctx, apiKeyID, req.PreviousResponseID)
+	if err != nil {
+		return nil, fmt.Errorf("get conversation: %w", err)
+	}
+	if data == nil {
+		return nil, ErrPreviousResponseNotFound
+	}
+	var history []apicompat.ResponsesInputItem
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("unmarshal conversation: %w", err)
+	}
+	return append(history, input...), nil
+}
+
+// Save 保存本轮完整上下文，供后续 previous_response_id 引用；store=false 或超出大小上限时跳过
+func (s *ResponsesConversationService) Save(ctx context.Context, apiKeyID int64, req *apicompat.ResponsesRequest, input []apicompat.ResponsesInputItem, resp *apicompat.ResponsesResponse) {
+	ttl := s.ttl()
+	if ttl <= 0 || resp == nil || resp.ID == "" || resp.Status == "failed" {
+		return
+	}
+	if req.Store != nil && !*req.Store {
+		return
+	}
+
+	history := make([]apicompat.ResponsesInputItem, 0, len(input)+len(resp.Output))
+	history = append(history, input...)
+	history = append(history, apicompat.ResponsesOutputToInputItems(resp.Output)...)
+	data, err := json.Marshal(history)
+	if err != nil {
+		logger.LegacyPrintf("service.responses_conversation", "marshal conversation failed: response=%s err=%v", resp.ID, err)
+		return
+	}
+	if limit := s.cfg.Gateway.ResponsesCompat.MaxConversationBytes; limit > 0 && len(data) > limit {
+		logger.LegacyPrintf("service.responses_conversation", "conversation too large, not stored: response=%s bytes=%d limit=%d", resp.ID, len(data), limit)
+		return
+	}
+	if err := s.cache.SetConversation(ctx, apiKeyID, resp.ID, data, ttl); err != nil {
+		logger.LegacyPrintf("service.responses_conversation", "store conversation failed: response=%s err=%v", resp.ID, err)
+	}
+}
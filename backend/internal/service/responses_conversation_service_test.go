@@ -0,0 +1,107 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/stretchr/testify/require"
+)
+
+type responsesConversationCacheStub struct {
+	data map[string][]byte
+	ttl  time.Duration
+}
+
+func newResponsesConversationCacheStub() *responsesConversationCacheStub {
+	return &responsesConversationCacheStub{data: make(map[string][]byte)}
+}
+
+func (s *responsesConversationCacheStub) key(apiKeyID int64, responseID string) string {
+	return fmt.Sprintf("%d:%s", apiKeyID, responseID)
+}
+
+func (s *responsesConversationCacheStub) GetConversation(_ context.Context, apiKeyID int64, responseID string) ([]byte, error) {
+	return s.data[s.key(apiKeyID, responseID)], nil
+}
+
+func (s *responsesConversationCacheStub) SetConversation(_ context.Context, apiKeyID int64, responseID string, data []byte, ttl time.Duration) error {
+	s.data[s.key(apiKeyID, responseID)] = data
+	s.ttl = ttl
+	return nil
+}
+
+func newResponsesConversationTestService(cache ResponsesConversationCache) *ResponsesConversationService {
+	cfg := &config.Config{}
+	cfg.Gateway.ResponsesCompat = config.GatewayResponsesCompatConfig{
+		Enabled:                true,
+		ConversationTTLMinutes: 60,
+		MaxConversationBytes:   1 << 20,
+	}
+	return NewResponsesConversationService(cache, cfg)
+}
+
+func TestResponsesConversationService_SaveAndResolve(t *testing.T) {
+	cache := newResponsesConversationCacheStub()
+	svc := newResponsesConversationTestService(cache)
+	ctx := context.Background()
+
+	first := &apicompat.ResponsesRequest{Model: "claude", Input: json.RawMessage(`"Hi"`)}
+	input, err := svc.ResolveInput(ctx, 1, first)
+	require.NoError(t, err)
+	require.Len(t, input, 1)
+
+	svc.Save(ctx, 1, first, input, &apicompat.ResponsesResponse{
+		ID:     "resp_1",
+		Status: "completed",
+		Output: []apicompat.ResponsesOutput{
+			{Type: "message", Role: "assistant", Content: []apicompat.ResponsesContentPart{{Type: "output_text", Text: "Hello"}}},
+		},
+	})
+	require.Equal(t, time.Hour, cache.ttl)
+
+	second := &apicompat.ResponsesRequest{Model: "claude", Input: json.RawMessage(`"Again"`), PreviousResponseID: "resp_1"}
+	input, err = svc.ResolveInput(ctx, 1, second)
+	require.NoError(t, err)
+	require.Len(t, input, 3)
+	require.Equal(t, "user", input[0].Role)
+	require.Equal(t, "assistant", input[1].Role)
+	require.Equal(t, "user", input[2].Role)
+
+	// 会话按 API Key 隔离
+	_, err = svc.ResolveInput(ctx, 2, second)
+	require.ErrorIs(t, err, ErrPreviousResponseNotFound)
+}
+
+func TestResponsesConversationService_SaveSkipped(t *testing.T) {
+	cache := newResponsesConversationCacheStub()
+	svc := newResponsesConversationTestService(cache)
+	ctx := context.Background()
+	store := false
+
+	svc.Save(ctx, 1, &apicompat.ResponsesRequest{Store: &store}, nil, &apicompat.ResponsesResponse{ID: "resp_a", Status: "completed"})
+	svc.Save(ctx, 1, &apicompat.ResponsesRequest{}, nil, &apicompat.ResponsesResponse{ID: "resp_b", Status: "failed"})
+
+	svc.cfg.Gateway.ResponsesCompat.MaxConversationBytes = 8
+	svc.Save(ctx, 1, &apicompat.ResponsesRequest{}, []apicompat.ResponsesInputItem{{Role: "user", Content: json.RawMessage(`"a long message"`)}}, &apicompat.ResponsesResponse{ID: "resp_c", Status: "completed"})
+
+	require.Empty(t, cache.data)
+}
+
+func TestResponsesConversationService_PreviousResponseWithoutStore(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Gateway.ResponsesCompat.Enabled = true
+	svc := NewResponsesConversationService(newResponsesConversationCacheStub(), cfg)
+
+	_, err := svc.ResolveInput(context.Background(), 1, &apicompat.ResponsesRequest{PreviousResponseID: "resp_x"})
+	require.ErrorIs(t, err, ErrPreviousResponseNotFound)
+
+	var nilSvc *ResponsesConversationService
+	require.False(t, nilSvc.Enabled())
+}
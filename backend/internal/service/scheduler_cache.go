@@ -59,6 +59,8 @@ type SchedulerCache interface {
 	UpdateLastUsed(ctx context.Context, updates map[int64]time.Time) error
 	// TryLockBucket 尝试获取分桶重建锁。
 	TryLockBucket(ctx context.Context, bucket SchedulerBucket, ttl time.Duration) (bool, error)
+	// TryLockAvailabilityCheck 尝试获取指定分钟的可用时间窗口边界检查锁（多实例中仅一个实例处理该分钟）。
+	TryLockAvailabilityCheck(ctx context.Context, minute time.Time, ttl time.Duration) (bool, error)
 	// ListBuckets 返回已注册的分桶集合。
 	ListBuckets(ctx context.Context) ([]SchedulerBucket, error)
 	// GetOutboxWatermark 读取 outbox 水位。
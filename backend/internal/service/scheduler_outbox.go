@@ -14,8 +14,10 @@ type SchedulerOutboxEvent struct {
 	CreatedAt time.Time
 }
 
-// SchedulerOutboxRepository 提供调度 outbox 的读取接口。
+// SchedulerOutboxRepository 提供调度 outbox 的读写接口。
 type SchedulerOutboxRepository interface {
 	ListAfter(ctx context.Context, afterID int64, limit int) ([]SchedulerOutboxEvent, error)
 	MaxID(ctx context.Context) (int64, error)
+	// EnqueueAccountChanged 写入账号变更事件（用于可用时间窗口到达边界时触发快照重建）
+	EnqueueAccountChanged(ctx context.Context, accountID int64, groupIDs []int64) error
 }
@@ -20,6 +20,9 @@ var (
 
 const outboxEventTimeout = 2 * time.Minute
 
+// availabilityCheckLockTTL 可用时间窗口边界检查锁的有效期，需覆盖实例间的时钟偏差
+const availabilityCheckLockTTL = 5 * time.Minute
+
 type SchedulerSnapshotService struct {
 	cache         SchedulerCache
 	outboxRepo    SchedulerOutboxRepository
@@ -84,6 +87,14 @@ func (s *SchedulerSnapshotService) Start() {
 			s.runFullRebuildWorker(fullInterval)
 		}()
 	}
+
+	if s.accountRepo != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.runAvailabilityWorker()
+		}()
+	}
 }
 
 func (s *SchedulerSnapshotService) Stop() {
@@ -213,6 +224,66 @@ func (s *SchedulerSnapshotService) runFullRebuildWorker(interval time.Duration)
 	}
 }
 
+// runAvailabilityWorker 在每个整分钟（可用时间窗口的精度）检查窗口边界并触发快照重建
+func (s *SchedulerSnapshotService) runAvailabilityWorker() {
+	last := time.Now().Truncate(time.Minute)
+	for {
+		timer := time.NewTimer(time.Until(last.Add(time.Minute)))
+		select {
+		case <-timer.C:
+		case <-s.stopCh:
+			timer.Stop()
+			return
+		}
+		// 进程停顿错过的分钟合并到一次检查中
+		now := time.Now().Truncate(time.Minute)
+		s.handleAvailabilityMinute(last, now)
+		last = now
+	}
+}
+
+// handleAvailabilityMinute 处理 (since, minute] 内的窗口边界；多实例通过分钟级锁只由一个实例查询账号并写入事件。
+// 锁读写失败时仍执行检查（重复事件只会导致重复重建，不影响正确性）
+func (s *SchedulerSnapshotService) handleAvailabilityMinute(since, minute time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ok, err := s.cache.TryLockAvailabilityCheck(ctx, minute, availabilityCheckLockTTL)
+	cancel()
+	if err != nil {
+		logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] availability check lock failed: %v", err)
+	} else if !ok {
+		return
+	}
+	s.checkAvailabilityBoundaries(since, minute)
+}
+
+// checkAvailabilityBoundaries 为在 (since, now] 内跨越可用窗口边界的账号写入 outbox 事件；
+// 未配置 outbox 时直接重建相关分桶
+func (s *SchedulerSnapshotService) checkAvailabilityBoundaries(since, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	accounts, err := s.accountRepo.ListSchedulable(ctx)
+	if err != nil {
+		logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] availability check list failed: %v", err)
+		return
+	}
+	for i := range accounts {
+		account := &accounts[i]
+		next, ok := account.NextAvailabilityChange(since)
+		if !ok || next.After(now) {
+			continue
+		}
+		if s.outboxRepo != nil {
+			err = s.outboxRepo.EnqueueAccountChanged(ctx, account.ID, account.GroupIDs)
+		} else {
+			err = s.rebuildByAccount(ctx, account, account.GroupIDs, "availability_window")
+		}
+		if err != nil {
+			logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] availability boundary handle failed: account=%d err=%v", account.ID, err)
+		}
+	}
+}
+
 func (s *SchedulerSnapshotService) pollOutbox() {
 	if s.outboxRepo == nil || s.cache == nil {
 		return
@@ -599,37 +670,58 @@ func (s *SchedulerSnapshotService) loadAccountsFromDB(ctx context.Context, bucke
 		groupID = 0
 	}
 
+	var (
+		accounts []Account
+		err      error
+	)
 	if useMixed {
-		platforms := []string{bucket.Platform, PlatformAntigravity}
-		var accounts []Account
-		var err error
-		if groupID > 0 {
-			accounts, err = s.accountRepo.ListSchedulableByGroupIDAndPlatforms(ctx, groupID, platforms)
-		} else if s.isRunModeSimple() {
-			accounts, err = s.accountRepo.ListSchedulableByPlatforms(ctx, platforms)
-		} else {
-			accounts, err = s.accountRepo.ListSchedulableUngroupedByPlatforms(ctx, platforms)
-		}
-		if err != nil {
-			return nil, err
-		}
-		filtered := make([]Account, 0, len(accounts))
-		for _, acc := range accounts {
-			if acc.Platform == PlatformAntigravity && !acc.IsMixedSchedulingEnabled() {
-				continue
-			}
+		accounts, err = s.loadMixedAccountsFromDB(ctx, bucket.Platform, groupID)
+	} else if groupID > 0 {
+		accounts, err = s.accountRepo.ListSchedulableByGroupIDAndPlatform(ctx, groupID, bucket.Platform)
+	} else if s.isRunModeSimple() {
+		accounts, err = s.accountRepo.ListSchedulableByPlatform(ctx, bucket.Platform)
+	} else {
+		accounts, err = s.accountRepo.ListSchedulableUngroupedByPlatform(ctx, bucket.Platform)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return filterAccountsByAvailability(accounts, time.Now()), nil
+}
+
+// filterAccountsByAvailability 剔除当前不在可用时间窗口内的账号
+func filterAccountsByAvailability(accounts []Account, now time.Time) []Account {
+	filtered := accounts[:0]
+	for _, acc := range accounts {
+		if acc.IsWithinAvailabilityWindow(now) {
 			filtered = append(filtered, acc)
 		}
-		return filtered, nil
 	}
+	return filtered
+}
 
+func (s *SchedulerSnapshotService) loadMixedAccountsFromDB(ctx context.Context, platform string, groupID int64) ([]Account, error) {
+	platforms := []string{platform, PlatformAntigravity}
+	var accounts []Account
+	var err error
 	if groupID > 0 {
-		return s.accountRepo.ListSchedulableByGroupIDAndPlatform(ctx, groupID, bucket.Platform)
+		accounts, err = s.accountRepo.ListSchedulableByGroupIDAndPlatforms(ctx, groupID, platforms)
+	} else if s.isRunModeSimple() {
+		accounts, err = s.accountRepo.ListSchedulableByPlatforms(ctx, platforms)
+	} else {
+		accounts, err = s.accountRepo.ListSchedulableUngroupedByPlatforms(ctx, platforms)
 	}
-	if s.isRunModeSimple() {
-		return s.accountRepo.ListSchedulableByPlatform(ctx, bucket.Platform)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]Account, 0, len(accounts))
+	for _, acc := range accounts {
+		if acc.Platform == PlatformAntigravity && !acc.IsMixedSchedulingEnabled() {
+			continue
+		}
+		filtered = append(filtered, acc)
 	}
-	return s.accountRepo.ListSchedulableUngroupedByPlatform(ctx, bucket.Platform)
+	return filtered, nil
 }
 
 func (s *SchedulerSnapshotService) bucketFor(groupID *int64, platform string, mode string) SchedulerBucket {
@@ -9,6 +9,8 @@ type SystemSettings struct {
 	FrontendURL                      string
 	InvitationCodeEnabled            bool
 	TotpEnabled                      bool // TOTP 双因素认证
+	PasskeyEnabled                   bool // WebAuthn 通行密钥
+	Admin2FARequired                 bool // 管理员必须设置 2FA
 
 	SMTPHost               string
 	SMTPPort               int
@@ -88,6 +90,7 @@ type PublicSettings struct {
 	PasswordResetEnabled             bool
 	InvitationCodeEnabled            bool
 	TotpEnabled                      bool // TOTP 双因素认证
+	PasskeyEnabled                   bool // WebAuthn 通行密钥
 	TurnstileEnabled                 bool
 	TurnstileSiteKey                 string
 	SiteName                         string
@@ -59,6 +59,7 @@ func (s *SoraGatewayService) forwardToUpstream(
 			upstreamReq.Header.Set(header, v)
 		}
 	}
+	applyRequestHeaderTransforms(ctx, upstreamReq)
 
 	logger.LegacyPrintf("service.sora", "[ForwardUpstream] account=%d url=%s", account.ID, upstreamURL)
 
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 订阅扣费类型
+const (
+	SubscriptionChargeTypeRenewal  = "renewal"  // 自动续费
+	SubscriptionChargeTypePurchase = "purchase" // 用户自助购买
+	SubscriptionChargeTypeUpgrade  = "upgrade"  // 升级（按剩余时长折算原订阅）
+)
+
+// 订阅扣费状态
+const (
+	SubscriptionChargeStatusSucceeded           = "succeeded"
+	SubscriptionChargeStatusInsufficientBalance = "insufficient_balance"
+	SubscriptionChargeStatusFailed              = "failed"
+)
+
+var (
+	ErrInvalidRenewalPeriod       = infraerrors.BadRequest("INVALID_RENEWAL_PERIOD", "renewal period days must be between 0 and 36500")
+	ErrSubscriptionNotRenewable   = infraerrors.BadRequest("SUBSCRIPTION_NOT_RENEWABLE", "subscription group has no price configured")
+	ErrSubscriptionAlreadyRenewed = infraerrors.Conflict("SUBSCRIPTION_ALREADY_RENEWED", "subscription already renewed for this period")
+	ErrGroupNotPurchasable        = infraerrors.BadRequest("GROUP_NOT_PURCHASABLE", "group is not available for purchase")
+	ErrInvalidPurchasePeriods     = infraerrors.BadRequest("INVALID_PURCHASE_PERIODS", "periods must be between 1 and 12")
+	ErrSubscriptionNotUpgradable  = infraerrors.BadRequest("SUBSCRIPTION_NOT_UPGRADABLE", "subscription cannot be upgraded")
+)
+
+// SubscriptionCharge 一条订阅扣费流水（成功或失败都会记录）
+type SubscriptionCharge struct {
+	ID             int64   `json:"id"`
+	UserID         int64   `json:"user_id"`
+	SubscriptionID *int64  `json:"subscription_id"`
+	GroupID        int64   `json:"group_id"`
+	GroupName      string  `json:"group_name"`
+	ChargeType     string  `json:"charge_type"`
+	Status         string  `json:"status"`
+	Amount         float64 `json:"amount"`
+	PeriodDays     int     `json:"period_days"`
+	// PeriodExpiresAt 触发续费时订阅的到期时间，同一值只允许一次成功续费
+	PeriodExpiresAt *time.Time `json:"period_expires_at"`
+	NewExpiresAt    *time.Time `json:"new_expires_at"`
+	// CreditAmount 升级时原订阅剩余时长折算的抵扣金额
+	CreditAmount               float64   `json:"credit_amount"`
+	UpgradedFromSubscriptionID *int64    `json:"upgraded_from_subscription_id,omitempty"`
+	ErrorMessage               string    `json:"error_message,omitempty"`
+	CreatedAt                  time.Time `json:"created_at"`
+}
+
+// SubscriptionPaidSummary 订阅的实际付费汇总（仅统计成功扣费的流水）
+type SubscriptionPaidSummary struct {
+	// Amount 实付金额与升级抵扣金额之和
+	Amount float64
+	// Days 付费购买的天数
+	Days int
+}
+
+// SubscriptionRenewalCandidate 待自动续费的订阅
+type SubscriptionRenewalCandidate struct {
+	SubscriptionID      int64
+	UserID              int64
+	GroupID             int64
+	GroupName           string
+	ExpiresAt           time.Time
+	Price               float64
+	RenewalPeriodDays   int
+	DefaultValidityDays int
+}
+
+// PeriodDays 返回单次续费延长的天数
+func (c *SubscriptionRenewalCandidate) PeriodDays() int {
+	return groupRenewalPeriodDays(c.RenewalPeriodDays, c.DefaultValidityDays)
+}
+
+// groupRenewalPeriodDays 续费周期：优先使用分组配置，其次默认有效期，最后兜底 30 天
+func groupRenewalPeriodDays(renewalPeriodDays, defaultValidityDays int) int {
+	if renewalPeriodDays > 0 {
+		return renewalPeriodDays
+	}
+	if defaultValidityDays > 0 {
+		return defaultValidityDays
+	}
+	return 30
+}
+
+// SubscriptionChargeRepository 订阅扣费流水与自动续费数据访问
+type SubscriptionChargeRepository interface {
+	// ListRenewalCandidates 列出到期时间不晚于 renewBefore、不早于 expiredAfter，
+	// 且本周期内最近一次尝试早于 retryBefore 的自动续费订阅
+	ListRenewalCandidates(ctx context.Context, renewBefore, expiredAfter, retryBefore time.Time, limit int) ([]SubscriptionRenewalCandidate, error)
+	// DeductBalanceIfSufficient 余额充足时扣减余额；余额不足返回 false（支持事务上下文）
+	DeductBalanceIfSufficient(ctx context.Context, userID int64, amount float64) (bool, error)
+	// Create 写入扣费流水（支持事务上下文）；成功续费重复时返回 ErrSubscriptionAlreadyRenewed
+	Create(ctx context.Context, charge *SubscriptionCharge) error
+	// SetAutoRenew 设置订阅的自动续费开关，订阅不属于该用户时返回 ErrSubscriptionNotFound（支持事务上下文）
+	SetAutoRenew(ctx context.Context, userID, subscriptionID int64, enabled bool) error
+	// TerminateSubscription 将仍有效的订阅立即置为过期并关闭自动续费（升级时替换原订阅）；
+	// 订阅已失效或不属于该用户时返回 false（支持事务上下文）
+	TerminateSubscription(ctx context.Context, userID, subscriptionID int64, at time.Time) (bool, error)
+	// SumPaidBySubscription 汇总订阅成功扣费的金额与天数；管理员分配或兑换码开通的订阅返回零值（支持事务上下文）
+	SumPaidBySubscription(ctx context.Context, subscriptionID int64) (*SubscriptionPaidSummary, error)
+	ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams) ([]SubscriptionCharge, *pagination.PaginationResult, error)
+}
@@ -10,6 +10,7 @@ import (
 // SubscriptionExpiryService periodically updates expired subscription status.
 type SubscriptionExpiryService struct {
 	userSubRepo UserSubscriptionRepository
+	notifier    *UserNotificationService
 	interval    time.Duration
 	stopCh      chan struct{}
 	stopOnce    sync.Once
@@ -24,6 +25,11 @@ func NewSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, interv
 	}
 }
 
+// SetUserNotificationService 设置用户提醒服务（可选），每次 tick 触发到期提醒检查
+func (s *SubscriptionExpiryService) SetUserNotificationService(notifier *UserNotificationService) {
+	s.notifier = notifier
+}
+
 func (s *SubscriptionExpiryService) Start() {
 	if s == nil || s.userSubRepo == nil || s.interval <= 0 {
 		return
@@ -60,6 +66,10 @@ func (s *SubscriptionExpiryService) runOnce() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if s.notifier != nil {
+		s.notifier.OnExpiryTick()
+	}
+
 	updated, err := s.userSubRepo.BatchUpdateExpiredStatus(ctx)
 	if err != nil {
 		log.Printf("[SubscriptionExpiry] Update expired subscriptions failed: %v", err)
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// MaxSubscriptionPurchasePeriods 单次购买的最大周期数
+const MaxSubscriptionPurchasePeriods = 12
+
+// PurchaseSubscriptionInput 用户自助购买订阅的参数
+type PurchaseSubscriptionInput struct {
+	GroupID int64 `json:"group_id"`
+	// Periods 购买的周期数（每个周期为分组的续费周期天数），默认 1
+	Periods int `json:"periods"`
+	// UpgradeFromSubscriptionID 升级时被替换的原订阅；按原订阅实际支付金额折算剩余时长抵扣
+	UpgradeFromSubscriptionID *int64 `json:"upgrade_from_subscription_id,omitempty"`
+	// AutoRenew 购买后是否开启自动续费
+	AutoRenew bool `json:"auto_renew"`
+}
+
+// SubscriptionPurchaseQuote 购买报价
+type SubscriptionPurchaseQuote struct {
+	GroupID                   int64   `json:"group_id"`
+	GroupName                 string  `json:"group_name"`
+	Periods                   int     `json:"periods"`
+	PeriodDays                int     `json:"period_days"`
+	ValidityDays              int     `json:"validity_days"`
+	UnitPrice                 float64 `json:"unit_price"`
+	Subtotal                  float64 `json:"subtotal"`
+	CreditAmount              float64 `json:"credit_amount"`
+	Amount                    float64 `json:"amount"`
+	UpgradeFromSubscriptionID *int64  `json:"upgrade_from_subscription_id,omitempty"`
+}
+
+// SubscriptionPurchaseResult 购买结果
+type SubscriptionPurchaseResult struct {
+	Quote        *SubscriptionPurchaseQuote
+	Subscription *UserSubscription
+	Charge       *SubscriptionCharge
+	// Extended 为 true 表示延长了该分组已有的订阅
+	Extended bool
+}
+
+// SubscriptionPurchaseService 用户使用余额自助购买/升级订阅
+type SubscriptionPurchaseService struct {
+	chargeRepo           SubscriptionChargeRepository
+	groupRepo            GroupRepository
+	subscriptionService  *SubscriptionService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	entClient            *dbent.Client
+}
+
+func NewSubscriptionPurchaseService(
+	chargeRepo SubscriptionChargeRepository,
+	groupRepo GroupRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+) *SubscriptionPurchaseService {
+	return &SubscriptionPurchaseService{
+		chargeRepo:           chargeRepo,
+		groupRepo:            groupRepo,
+		subscriptionService:  subscriptionService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		entClient:            entClient,
+	}
+}
+
+// ListPurchasableGroups 列出可购买的订阅分组（启用、订阅类型且配置了售价）
+func (s *SubscriptionPurchaseService) ListPurchasableGroups(ctx context.Context) ([]Group, error) {
+	groups, err := s.groupRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Group, 0, len(groups))
+	for i := range groups {
+		if isGroupPurchasable(&groups[i]) {
+			out = append(out, groups[i])
+		}
+	}
+	return out, nil
+}
+
+func isGroupPurchasable(g *Group) bool {
+	return g != nil && g.IsActive() && g.IsSubscriptionType() && g.Price != nil
+}
+
+// Quote 计算购买报价；升级时按原订阅实际支付金额折算剩余时长抵扣，抵扣超过新订阅价格时不退差额
+func (s *SubscriptionPurchaseService) Quote(ctx context.Context, userID int64, input PurchaseSubscriptionInput) (*SubscriptionPurchaseQuote, error) {
+	return s.quote(ctx, userID, input, time.Now())
+}
+
+func (s *SubscriptionPurchaseService) quote(ctx context.Context, userID int64, input PurchaseSubscriptionInput, now time.Time) (*SubscriptionPurchaseQuote, error) {
+	periods := input.Periods
+	if periods == 0 {
+		periods = 1
+	}
+	if periods < 1 || periods > MaxSubscriptionPurchasePeriods {
+		return nil, ErrInvalidPurchasePeriods
+	}
+
+	group, err := s.groupRepo.GetByIDLite(ctx, input.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if !isGroupPurchasable(group) {
+		return nil, ErrGroupNotPurchasable
+	}
+
+	periodDays := groupRenewalPeriodDays(group.RenewalPeriodDays, group.DefaultValidityDays)
+	validityDays := periodDays * periods
+	if validityDays > MaxValidityDays {
+		validityDays = MaxValidityDays
+	}
+	quote := &SubscriptionPurchaseQuote{
+		GroupID:      group.ID,
+		GroupName:    group.Name,
+		Periods:      periods,
+		PeriodDays:   periodDays,
+		ValidityDays: validityDays,
+		UnitPrice:    *group.Price,
+		Subtotal:     roundSubscriptionAmount(*group.Price * float64(periods)),
+	}
+
+	if input.UpgradeFromSubscriptionID != nil {
+		old, err := s.subscriptionService.GetByID(ctx, *input.UpgradeFromSubscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		if old.UserID != userID {
+			return nil, ErrSubscriptionNotFound
+		}
+		if old.GroupID == group.ID || old.Status != SubscriptionStatusActive || !old.ExpiresAt.After(now) {
+			return nil, ErrSubscriptionNotUpgradable
+		}
+		// 抵扣以实际扣费为准：管理员分配、兑换码开通等未付费的订阅不产生抵扣
+		paid, err := s.chargeRepo.SumPaidBySubscription(ctx, old.ID)
+		if err != nil {
+			return nil, fmt.Errorf("sum subscription charges: %w", err)
+		}
+		quote.CreditAmount = proratedSubscriptionCredit(paid, old.ExpiresAt.Sub(now))
+		quote.UpgradeFromSubscriptionID = &old.ID
+	}
+
+	quote.Amount = roundSubscriptionAmount(math.Max(0, quote.Subtotal-quote.CreditAmount))
+	return quote, nil
+}
+
+// proratedSubscriptionCredit 按剩余时长折算已付费价值：实付金额 / 付费天数 × 剩余天数，
+// 剩余天数不超过付费天数，抵扣不会超过实付金额
+func proratedSubscriptionCredit(paid *SubscriptionPaidSummary, remaining time.Duration) float64 {
+	if paid == nil || paid.Amount <= 0 || paid.Days <= 0 || remaining <= 0 {
+		return 0
+	}
+	remainingDays := math.Min(remaining.Hours()/24, float64(paid.Days))
+	return roundSubscriptionAmount(paid.Amount / float64(paid.Days) * remainingDays)
+}
+
+func roundSubscriptionAmount(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
+
+// Purchase 在同一事务内扣减余额、（升级时）终止原订阅、开通或延长订阅并写入扣费流水
+func (s *SubscriptionPurchaseService) Purchase(ctx context.Context, userID int64, input PurchaseSubscriptionInput) (*SubscriptionPurchaseResult, error) {
+	quote, err := s.quote(ctx, userID, input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	opCtx := ctx
+	var tx *dbent.Tx
+	if s.entClient == nil {
+		logger.LegacyPrintf("service.subscription_purchase", "Warning: entClient is nil, skipping transaction protection for subscription purchase")
+	} else {
+		tx, err = s.entClient.Tx(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		opCtx = dbent.NewTxContext(ctx, tx)
+	}
+
+	if quote.Amount > 0 {
+		ok, err := s.chargeRepo.DeductBalanceIfSufficient(opCtx, userID, quote.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("deduct balance: %w", err)
+		}
+		if !ok {
+			return nil, ErrInsufficientBalance
+		}
+	}
+
+	chargeType := SubscriptionChargeTypePurchase
+	notes := fmt.Sprintf("余额购买 %d 天", quote.ValidityDays)
+	if quote.UpgradeFromSubscriptionID != nil {
+		// 条件更新保证同一原订阅只能被升级一次（并发请求中后到者失败）
+		ok, err := s.chargeRepo.TerminateSubscription(opCtx, userID, *quote.UpgradeFromSubscriptionID, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("terminate subscription: %w", err)
+		}
+		if !ok {
+			return nil, ErrSubscriptionNotUpgradable
+		}
+		chargeType = SubscriptionChargeTypeUpgrade
+		notes = fmt.Sprintf("由订阅 #%d 升级，余额购买 %d 天", *quote.UpgradeFromSubscriptionID, quote.ValidityDays)
+	}
+
+	sub, extended, err := s.subscriptionService.AssignOrExtendSubscription(opCtx, &AssignSubscriptionInput{
+		UserID:       userID,
+		GroupID:      quote.GroupID,
+		ValidityDays: quote.ValidityDays,
+		AssignedBy:   0, // 系统分配
+		Notes:        notes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("assign or extend subscription: %w", err)
+	}
+	if input.AutoRenew {
+		if err := s.chargeRepo.SetAutoRenew(opCtx, userID, sub.ID, true); err != nil {
+			return nil, fmt.Errorf("enable auto renew: %w", err)
+		}
+		sub.AutoRenew = true
+	}
+
+	subID := sub.ID
+	charge := &SubscriptionCharge{
+		UserID:                     userID,
+		SubscriptionID:             &subID,
+		GroupID:                    quote.GroupID,
+		GroupName:                  quote.GroupName,
+		ChargeType:                 chargeType,
+		Status:                     SubscriptionChargeStatusSucceeded,
+		Amount:                     quote.Amount,
+		PeriodDays:                 quote.ValidityDays,
+		NewExpiresAt:               &sub.ExpiresAt,
+		CreditAmount:               quote.CreditAmount,
+		UpgradedFromSubscriptionID: quote.UpgradeFromSubscriptionID,
+	}
+	if err := s.chargeRepo.Create(opCtx, charge); err != nil {
+		return nil, fmt.Errorf("record subscription charge: %w", err)
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+
+	s.invalidateCaches(ctx, userID, quote)
+	return &SubscriptionPurchaseResult{
+		Quote:        quote,
+		Subscription: sub,
+		Charge:       charge,
+		Extended:     extended,
+	}, nil
+}
+
+// invalidateCaches 事务提交后失效余额与订阅缓存（含升级替换掉的原订阅）
+func (s *SubscriptionPurchaseService) invalidateCaches(ctx context.Context, userID int64, quote *SubscriptionPurchaseQuote) {
+	groupIDs := []int64{quote.GroupID}
+	if quote.UpgradeFromSubscriptionID != nil {
+		if old, err := s.subscriptionService.GetByID(ctx, *quote.UpgradeFromSubscriptionID); err == nil {
+			groupIDs = append(groupIDs, old.GroupID)
+		}
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	for _, groupID := range groupIDs {
+		s.subscriptionService.InvalidateSubCache(userID, groupID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+		for _, groupID := range groupIDs {
+			_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, groupID)
+		}
+	}()
+}
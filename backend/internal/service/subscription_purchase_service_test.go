@@ -0,0 +1,209 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type purchaseGroupRepoStub struct {
+	groupRepoNoop
+	groups map[int64]*Group
+}
+
+func (s *purchaseGroupRepoStub) GetByID(_ context.Context, id int64) (*Group, error) {
+	g, ok := s.groups[id]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	cp := *g
+	return &cp, nil
+}
+
+func (s *purchaseGroupRepoStub) GetByIDLite(ctx context.Context, id int64) (*Group, error) {
+	return s.GetByID(ctx, id)
+}
+
+func (s *purchaseGroupRepoStub) ListActive(context.Context) ([]Group, error) {
+	out := make([]Group, 0, len(s.groups))
+	for id := int64(1); id <= int64(len(s.groups)); id++ {
+		if g, ok := s.groups[id]; ok {
+			out = append(out, *g)
+		}
+	}
+	return out, nil
+}
+
+type purchaseSubRepoStub struct {
+	userSubRepoNoop
+	subs map[int64]*UserSubscription
+}
+
+func (s *purchaseSubRepoStub) GetByID(_ context.Context, id int64) (*UserSubscription, error) {
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (s *purchaseSubRepoStub) GetByUserIDAndGroupID(_ context.Context, userID, groupID int64) (*UserSubscription, error) {
+	for _, sub := range s.subs {
+		if sub.UserID == userID && sub.GroupID == groupID {
+			cp := *sub
+			return &cp, nil
+		}
+	}
+	return nil, ErrSubscriptionNotFound
+}
+
+func (s *purchaseSubRepoStub) Create(_ context.Context, sub *UserSubscription) error {
+	sub.ID = int64(len(s.subs) + 100)
+	cp := *sub
+	s.subs[sub.ID] = &cp
+	return nil
+}
+
+func purchaseTestPrice(v float64) *float64 { return &v }
+
+func newSubscriptionPurchaseServiceForTest(repo *subscriptionChargeRepoStub, subs map[int64]*UserSubscription) *SubscriptionPurchaseService {
+	groupRepo := &purchaseGroupRepoStub{groups: map[int64]*Group{
+		1: {ID: 1, Name: "basic", Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription, Price: purchaseTestPrice(30), RenewalPeriodDays: 30},
+		2: {ID: 2, Name: "pro", Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription, Price: purchaseTestPrice(90), RenewalPeriodDays: 30},
+		3: {ID: 3, Name: "free", Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription},
+	}}
+	subSvc := NewSubscriptionService(groupRepo, &purchaseSubRepoStub{subs: subs}, nil, nil, nil)
+	return NewSubscriptionPurchaseService(repo, groupRepo, subSvc, nil, nil, nil)
+}
+
+func TestSubscriptionPurchase_ListPurchasableGroupsRequiresPrice(t *testing.T) {
+	svc := newSubscriptionPurchaseServiceForTest(&subscriptionChargeRepoStub{}, map[int64]*UserSubscription{})
+
+	groups, err := svc.ListPurchasableGroups(context.Background())
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	require.Equal(t, "basic", groups[0].Name)
+	require.Equal(t, "pro", groups[1].Name)
+}
+
+func TestSubscriptionPurchase_QuoteValidatesPeriodsAndGroup(t *testing.T) {
+	svc := newSubscriptionPurchaseServiceForTest(&subscriptionChargeRepoStub{}, map[int64]*UserSubscription{})
+	ctx := context.Background()
+
+	_, err := svc.Quote(ctx, 7, PurchaseSubscriptionInput{GroupID: 1, Periods: MaxSubscriptionPurchasePeriods + 1})
+	require.ErrorIs(t, err, ErrInvalidPurchasePeriods)
+
+	_, err = svc.Quote(ctx, 7, PurchaseSubscriptionInput{GroupID: 3})
+	require.ErrorIs(t, err, ErrGroupNotPurchasable)
+
+	quote, err := svc.Quote(ctx, 7, PurchaseSubscriptionInput{GroupID: 1, Periods: 3})
+	require.NoError(t, err)
+	require.Equal(t, 90, quote.ValidityDays)
+	require.InDelta(t, 90.0, quote.Amount, 1e-9)
+}
+
+func TestSubscriptionPurchase_UpgradeProratesRemainingTime(t *testing.T) {
+	now := time.Now()
+	subs := map[int64]*UserSubscription{
+		5: {ID: 5, UserID: 7, GroupID: 1, Status: SubscriptionStatusActive, ExpiresAt: now.Add(15 * 24 * time.Hour),
+			Group: &Group{ID: 1, Price: purchaseTestPrice(30), RenewalPeriodDays: 30}},
+	}
+	from := int64(5)
+	repo := &subscriptionChargeRepoStub{charges: []*SubscriptionCharge{
+		{SubscriptionID: &from, ChargeType: SubscriptionChargeTypePurchase, Status: SubscriptionChargeStatusSucceeded, Amount: 30, PeriodDays: 30},
+		{SubscriptionID: &from, ChargeType: SubscriptionChargeTypeRenewal, Status: SubscriptionChargeStatusInsufficientBalance, Amount: 30, PeriodDays: 30},
+	}}
+	svc := newSubscriptionPurchaseServiceForTest(repo, subs)
+
+	quote, err := svc.quote(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 2, UpgradeFromSubscriptionID: &from}, now)
+	require.NoError(t, err)
+	require.InDelta(t, 15.0, quote.CreditAmount, 1e-6)
+	require.InDelta(t, 75.0, quote.Amount, 1e-6)
+
+	_, err = svc.quote(context.Background(), 8, PurchaseSubscriptionInput{GroupID: 2, UpgradeFromSubscriptionID: &from}, now)
+	require.ErrorIs(t, err, ErrSubscriptionNotFound)
+
+	_, err = svc.quote(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 1, UpgradeFromSubscriptionID: &from}, now)
+	require.ErrorIs(t, err, ErrSubscriptionNotUpgradable)
+}
+
+func TestSubscriptionPurchase_UpgradeFromUnpaidSubscriptionHasNoCredit(t *testing.T) {
+	now := time.Now()
+	subs := map[int64]*UserSubscription{
+		// 管理员分配的订阅没有扣费流水
+		5: {ID: 5, UserID: 7, GroupID: 1, Status: SubscriptionStatusActive, ExpiresAt: now.Add(15 * 24 * time.Hour),
+			Group: &Group{ID: 1, Price: purchaseTestPrice(30), RenewalPeriodDays: 30}},
+		// 付费 30 天后又被管理员延长，抵扣不超过实付金额
+		6: {ID: 6, UserID: 7, GroupID: 1, Status: SubscriptionStatusActive, ExpiresAt: now.Add(90 * 24 * time.Hour),
+			Group: &Group{ID: 1, Price: purchaseTestPrice(30), RenewalPeriodDays: 30}},
+	}
+	extended := int64(6)
+	repo := &subscriptionChargeRepoStub{charges: []*SubscriptionCharge{
+		{SubscriptionID: &extended, ChargeType: SubscriptionChargeTypePurchase, Status: SubscriptionChargeStatusSucceeded, Amount: 20, PeriodDays: 30},
+	}}
+	svc := newSubscriptionPurchaseServiceForTest(repo, subs)
+
+	from := int64(5)
+	quote, err := svc.quote(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 2, UpgradeFromSubscriptionID: &from}, now)
+	require.NoError(t, err)
+	require.Zero(t, quote.CreditAmount)
+	require.InDelta(t, 90.0, quote.Amount, 1e-6)
+
+	quote, err = svc.quote(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 2, UpgradeFromSubscriptionID: &extended}, now)
+	require.NoError(t, err)
+	require.InDelta(t, 20.0, quote.CreditAmount, 1e-6)
+}
+
+func TestSubscriptionPurchase_InsufficientBalance(t *testing.T) {
+	repo := &subscriptionChargeRepoStub{balance: 10}
+	subs := map[int64]*UserSubscription{}
+	svc := newSubscriptionPurchaseServiceForTest(repo, subs)
+
+	_, err := svc.Purchase(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 1})
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+	require.Empty(t, subs)
+	require.Empty(t, repo.charges)
+	require.InDelta(t, 10.0, repo.balance, 1e-9)
+}
+
+func TestSubscriptionPurchase_CreatesSubscriptionAndRecordsCharge(t *testing.T) {
+	repo := &subscriptionChargeRepoStub{balance: 100}
+	svc := newSubscriptionPurchaseServiceForTest(repo, map[int64]*UserSubscription{})
+
+	result, err := svc.Purchase(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 1, Periods: 2, AutoRenew: true})
+	require.NoError(t, err)
+	require.False(t, result.Extended)
+	require.True(t, result.Subscription.AutoRenew)
+	require.InDelta(t, 40.0, repo.balance, 1e-9)
+	require.Len(t, repo.charges, 1)
+	require.Equal(t, SubscriptionChargeTypePurchase, repo.charges[0].ChargeType)
+	require.Equal(t, 60, repo.charges[0].PeriodDays)
+	require.True(t, repo.autoRenew[result.Subscription.ID])
+}
+
+func TestSubscriptionPurchase_UpgradeTerminatesOldSubscriptionOnce(t *testing.T) {
+	subs := map[int64]*UserSubscription{
+		5: {ID: 5, UserID: 7, GroupID: 1, Status: SubscriptionStatusActive, ExpiresAt: time.Now().Add(15 * 24 * time.Hour),
+			Group: &Group{ID: 1, Price: purchaseTestPrice(30), RenewalPeriodDays: 30}},
+	}
+	repo := &subscriptionChargeRepoStub{balance: 200}
+	svc := newSubscriptionPurchaseServiceForTest(repo, subs)
+	from := int64(5)
+
+	result, err := svc.Purchase(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 2, UpgradeFromSubscriptionID: &from})
+	require.NoError(t, err)
+	require.True(t, repo.terminated[5])
+	require.Len(t, repo.charges, 1)
+	require.Equal(t, SubscriptionChargeTypeUpgrade, repo.charges[0].ChargeType)
+	require.Equal(t, &from, repo.charges[0].UpgradedFromSubscriptionID)
+	require.InDelta(t, 200-result.Quote.Amount, repo.balance, 1e-9)
+
+	// 原订阅已被替换，重复升级不会再次抵扣
+	_, err = svc.Purchase(context.Background(), 7, PurchaseSubscriptionInput{GroupID: 2, UpgradeFromSubscriptionID: &from})
+	require.ErrorIs(t, err, ErrSubscriptionNotUpgradable)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// SubscriptionRenewalService 订阅自动续费：到期前从用户余额扣除分组售价并延长订阅。
+//
+// 每个续费周期以订阅当时的到期时间标识；同一周期只允许一次成功扣费（数据库部分唯一索引保证），
+// 余额不足等失败会记录流水并在 RetryIntervalMinutes 后重试，直到超过到期后的宽限期。
+type SubscriptionRenewalService struct {
+	repo                 SubscriptionChargeRepository
+	subscriptionService  *SubscriptionService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	entClient            *dbent.Client
+	notifier             *UserNotificationService
+	cfg                  config.SubscriptionRenewalConfig
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewSubscriptionRenewalService(
+	repo SubscriptionChargeRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+	cfg *config.Config,
+) *SubscriptionRenewalService {
+	svc := &SubscriptionRenewalService{
+		repo:                 repo,
+		subscriptionService:  subscriptionService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		entClient:            entClient,
+		stopCh:               make(chan struct{}),
+	}
+	if cfg != nil {
+		svc.cfg = cfg.SubscriptionRenewal
+	}
+	return svc
+}
+
+// SetUserNotificationService 设置用户提醒服务（可选），续费成功/失败时通知用户
+func (s *SubscriptionRenewalService) SetUserNotificationService(notifier *UserNotificationService) {
+	s.notifier = notifier
+}
+
+func (s *SubscriptionRenewalService) Start() {
+	if s == nil || s.repo == nil || !s.cfg.Enabled || s.cfg.ScanIntervalMinutes <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(time.Duration(s.cfg.ScanIntervalMinutes) * time.Minute)
+		defer ticker.Stop()
+
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *SubscriptionRenewalService) Stop() {
+	if s == nil || s.stopCh == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *SubscriptionRenewalService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	renewed, failed, err := s.RenewDue(ctx, time.Now())
+	if err != nil {
+		logger.LegacyPrintf("service.subscription_renewal", "[SubscriptionRenewal] scan failed: %v", err)
+		return
+	}
+	if renewed > 0 || failed > 0 {
+		logger.LegacyPrintf("service.subscription_renewal", "[SubscriptionRenewal] renewed=%d failed=%d", renewed, failed)
+	}
+}
+
+// RenewDue 处理一批到期前 LeadHours 内（或已过期但仍在宽限期内）的自动续费订阅
+func (s *SubscriptionRenewalService) RenewDue(ctx context.Context, now time.Time) (renewed, failed int, err error) {
+	renewBefore := now.Add(time.Duration(s.cfg.LeadHours) * time.Hour)
+	expiredAfter := now.Add(-time.Duration(s.cfg.GraceHours) * time.Hour)
+	retryBefore := now.Add(-time.Duration(s.cfg.RetryIntervalMinutes) * time.Minute)
+	candidates, err := s.repo.ListRenewalCandidates(ctx, renewBefore, expiredAfter, retryBefore, s.cfg.BatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	for i := range candidates {
+		charge, err := s.renew(ctx, &candidates[i])
+		if errors.Is(err, ErrSubscriptionAlreadyRenewed) {
+			continue
+		}
+		if charge == nil {
+			continue
+		}
+		if charge.Status == SubscriptionChargeStatusSucceeded {
+			renewed++
+		} else {
+			failed++
+		}
+		if s.notifier != nil {
+			s.notifier.NotifySubscriptionRenewal(ctx, charge)
+		}
+	}
+	return renewed, failed, nil
+}
+
+// renew 对单个订阅执行一次续费尝试并写入流水；返回的流水记录本次结果
+func (s *SubscriptionRenewalService) renew(ctx context.Context, c *SubscriptionRenewalCandidate) (*SubscriptionCharge, error) {
+	subID := c.SubscriptionID
+	periodExpiresAt := c.ExpiresAt
+	charge := &SubscriptionCharge{
+		UserID:          c.UserID,
+		SubscriptionID:  &subID,
+		GroupID:         c.GroupID,
+		GroupName:       c.GroupName,
+		ChargeType:      SubscriptionChargeTypeRenewal,
+		Amount:          c.Price,
+		PeriodDays:      c.PeriodDays(),
+		PeriodExpiresAt: &periodExpiresAt,
+	}
+
+	err := s.chargeAndExtend(ctx, c, charge)
+	switch {
+	case err == nil:
+		s.invalidateCaches(ctx, c.UserID, c.GroupID)
+		return charge, nil
+	case errors.Is(err, ErrSubscriptionAlreadyRenewed):
+		return nil, err
+	case errors.Is(err, ErrInsufficientBalance):
+		charge.Status = SubscriptionChargeStatusInsufficientBalance
+	default:
+		charge.Status = SubscriptionChargeStatusFailed
+		charge.ErrorMessage = err.Error()
+		if len(charge.ErrorMessage) > 500 {
+			charge.ErrorMessage = charge.ErrorMessage[:500]
+		}
+		logger.LegacyPrintf("service.subscription_renewal", "[SubscriptionRenewal] renew failed: subscription=%d err=%v", subID, err)
+	}
+
+	// 失败记录在事务外写入，作为重试间隔的依据
+	charge.NewExpiresAt = nil
+	if err := s.repo.Create(ctx, charge); err != nil {
+		logger.LegacyPrintf("service.subscription_renewal", "[SubscriptionRenewal] record failed attempt failed: subscription=%d err=%v", subID, err)
+	}
+	return charge, nil
+}
+
+// chargeAndExtend 在同一事务内扣减余额、延长订阅并写入成功流水
+func (s *SubscriptionRenewalService) chargeAndExtend(ctx context.Context, c *SubscriptionRenewalCandidate, charge *SubscriptionCharge) error {
+	opCtx := ctx
+	var tx *dbent.Tx
+	if s.entClient == nil {
+		logger.LegacyPrintf("service.subscription_renewal", "Warning: entClient is nil, skipping transaction protection for subscription renewal")
+	} else {
+		var err error
+		tx, err = s.entClient.Tx(ctx)
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		opCtx = dbent.NewTxContext(ctx, tx)
+	}
+
+	if c.Price > 0 {
+		ok, err := s.repo.DeductBalanceIfSufficient(opCtx, c.UserID, c.Price)
+		if err != nil {
+			return fmt.Errorf("deduct balance: %w", err)
+		}
+		if !ok {
+			return ErrInsufficientBalance
+		}
+	}
+
+	sub, err := s.subscriptionService.ExtendSubscription(opCtx, c.SubscriptionID, charge.PeriodDays)
+	if err != nil {
+		return fmt.Errorf("extend subscription: %w", err)
+	}
+	charge.Status = SubscriptionChargeStatusSucceeded
+	charge.NewExpiresAt = &sub.ExpiresAt
+	if err := s.repo.Create(opCtx, charge); err != nil {
+		return err
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+	return nil
+}
+
+// invalidateCaches 续费成功后失效余额与订阅缓存（事务内的失效可能早于提交，这里再做一次）
+func (s *SubscriptionRenewalService) invalidateCaches(ctx context.Context, userID, groupID int64) {
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	if s.subscriptionService != nil {
+		s.subscriptionService.InvalidateSubCache(userID, groupID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+		_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, groupID)
+	}()
+}
+
+// SetAutoRenew 用户开启/关闭订阅的自动续费；开启时要求分组配置了售价
+func (s *SubscriptionRenewalService) SetAutoRenew(ctx context.Context, userID, subscriptionID int64, enabled bool) (*UserSubscription, error) {
+	sub, err := s.subscriptionService.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID != userID {
+		return nil, ErrSubscriptionNotFound
+	}
+	if enabled && (sub.Group == nil || sub.Group.Price == nil) {
+		return nil, ErrSubscriptionNotRenewable
+	}
+	if err := s.repo.SetAutoRenew(ctx, userID, subscriptionID, enabled); err != nil {
+		return nil, err
+	}
+	s.subscriptionService.InvalidateSubCache(sub.UserID, sub.GroupID)
+	sub.AutoRenew = enabled
+	return sub, nil
+}
+
+// ListCharges 列出用户的订阅扣费流水
+func (s *SubscriptionRenewalService) ListCharges(ctx context.Context, userID int64, params pagination.PaginationParams) ([]SubscriptionCharge, *pagination.PaginationResult, error) {
+	return s.repo.ListByUser(ctx, userID, params)
+}
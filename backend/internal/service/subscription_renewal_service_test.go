@@ -0,0 +1,222 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type subscriptionChargeRepoStub struct {
+	candidates []SubscriptionRenewalCandidate
+	balance    float64
+	charges    []*SubscriptionCharge
+	autoRenew  map[int64]bool
+	terminated map[int64]bool
+}
+
+func (s *subscriptionChargeRepoStub) ListRenewalCandidates(context.Context, time.Time, time.Time, time.Time, int) ([]SubscriptionRenewalCandidate, error) {
+	out := make([]SubscriptionRenewalCandidate, 0, len(s.candidates))
+	for _, c := range s.candidates {
+		if !s.renewed(c.SubscriptionID, c.ExpiresAt) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (s *subscriptionChargeRepoStub) renewed(subscriptionID int64, periodExpiresAt time.Time) bool {
+	for _, c := range s.charges {
+		if c.ChargeType == SubscriptionChargeTypeRenewal && c.Status == SubscriptionChargeStatusSucceeded &&
+			*c.SubscriptionID == subscriptionID && c.PeriodExpiresAt.Equal(periodExpiresAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *subscriptionChargeRepoStub) DeductBalanceIfSufficient(_ context.Context, _ int64, amount float64) (bool, error) {
+	if s.balance < amount {
+		return false, nil
+	}
+	s.balance -= amount
+	return true, nil
+}
+
+func (s *subscriptionChargeRepoStub) Create(_ context.Context, charge *SubscriptionCharge) error {
+	if charge.ChargeType == SubscriptionChargeTypeRenewal && charge.Status == SubscriptionChargeStatusSucceeded && s.renewed(*charge.SubscriptionID, *charge.PeriodExpiresAt) {
+		return ErrSubscriptionAlreadyRenewed
+	}
+	cp := *charge
+	cp.ID = int64(len(s.charges) + 1)
+	s.charges = append(s.charges, &cp)
+	return nil
+}
+
+func (s *subscriptionChargeRepoStub) SetAutoRenew(_ context.Context, _ int64, subscriptionID int64, enabled bool) error {
+	if s.autoRenew == nil {
+		s.autoRenew = map[int64]bool{}
+	}
+	s.autoRenew[subscriptionID] = enabled
+	return nil
+}
+
+func (s *subscriptionChargeRepoStub) TerminateSubscription(_ context.Context, _ int64, subscriptionID int64, _ time.Time) (bool, error) {
+	if s.terminated[subscriptionID] {
+		return false, nil
+	}
+	if s.terminated == nil {
+		s.terminated = map[int64]bool{}
+	}
+	s.terminated[subscriptionID] = true
+	return true, nil
+}
+
+func (s *subscriptionChargeRepoStub) SumPaidBySubscription(_ context.Context, subscriptionID int64) (*SubscriptionPaidSummary, error) {
+	summary := &SubscriptionPaidSummary{}
+	for _, c := range s.charges {
+		if c.Status == SubscriptionChargeStatusSucceeded && c.SubscriptionID != nil && *c.SubscriptionID == subscriptionID {
+			summary.Amount += c.Amount + c.CreditAmount
+			summary.Days += c.PeriodDays
+		}
+	}
+	return summary, nil
+}
+
+func (s *subscriptionChargeRepoStub) ListByUser(context.Context, int64, pagination.PaginationParams) ([]SubscriptionCharge, *pagination.PaginationResult, error) {
+	panic("unexpected ListByUser call")
+}
+
+type renewalSubRepoStub struct {
+	userSubRepoNoop
+	sub *UserSubscription
+}
+
+func (s *renewalSubRepoStub) GetByID(context.Context, int64) (*UserSubscription, error) {
+	cp := *s.sub
+	return &cp, nil
+}
+
+func (s *renewalSubRepoStub) ExtendExpiry(_ context.Context, _ int64, newExpiresAt time.Time) error {
+	s.sub.ExpiresAt = newExpiresAt
+	return nil
+}
+
+func (s *renewalSubRepoStub) UpdateStatus(_ context.Context, _ int64, status string) error {
+	s.sub.Status = status
+	return nil
+}
+
+func newSubscriptionRenewalServiceForTest(repo *subscriptionChargeRepoStub, sub *UserSubscription) *SubscriptionRenewalService {
+	cfg := &config.Config{}
+	cfg.SubscriptionRenewal = config.SubscriptionRenewalConfig{
+		Enabled:              true,
+		ScanIntervalMinutes:  10,
+		LeadHours:            24,
+		RetryIntervalMinutes: 60,
+		GraceHours:           72,
+		BatchSize:            10,
+	}
+	subSvc := NewSubscriptionService(nil, &renewalSubRepoStub{sub: sub}, nil, nil, nil)
+	return NewSubscriptionRenewalService(repo, subSvc, nil, nil, nil, cfg)
+}
+
+func TestSubscriptionRenewal_ChargesBalanceAndExtends(t *testing.T) {
+	expiresAt := time.Now().Add(6 * time.Hour).Truncate(time.Second)
+	sub := &UserSubscription{ID: 3, UserID: 7, GroupID: 2, ExpiresAt: expiresAt, Status: SubscriptionStatusActive}
+	repo := &subscriptionChargeRepoStub{
+		balance: 25,
+		candidates: []SubscriptionRenewalCandidate{{
+			SubscriptionID: 3, UserID: 7, GroupID: 2, GroupName: "pro", ExpiresAt: expiresAt,
+			Price: 10, DefaultValidityDays: 30,
+		}},
+	}
+	svc := newSubscriptionRenewalServiceForTest(repo, sub)
+
+	renewed, failed, err := svc.RenewDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, renewed)
+	require.Equal(t, 0, failed)
+	require.InDelta(t, 15, repo.balance, 1e-9)
+	require.Equal(t, expiresAt.AddDate(0, 0, 30), sub.ExpiresAt)
+
+	require.Len(t, repo.charges, 1)
+	charge := repo.charges[0]
+	require.Equal(t, SubscriptionChargeTypeRenewal, charge.ChargeType)
+	require.Equal(t, SubscriptionChargeStatusSucceeded, charge.Status)
+	require.Equal(t, 30, charge.PeriodDays)
+	require.True(t, charge.PeriodExpiresAt.Equal(expiresAt))
+	require.True(t, charge.NewExpiresAt.Equal(sub.ExpiresAt))
+
+	// 同一周期重复扫描不会再次扣费
+	renewed, _, err = svc.RenewDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 0, renewed)
+	require.InDelta(t, 15, repo.balance, 1e-9)
+}
+
+func TestSubscriptionRenewal_InsufficientBalanceRecordsAttempt(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Hour)
+	sub := &UserSubscription{ID: 3, UserID: 7, GroupID: 2, ExpiresAt: expiresAt, Status: SubscriptionStatusExpired}
+	repo := &subscriptionChargeRepoStub{
+		balance: 4,
+		candidates: []SubscriptionRenewalCandidate{{
+			SubscriptionID: 3, UserID: 7, GroupID: 2, ExpiresAt: expiresAt, Price: 10, RenewalPeriodDays: 7,
+		}},
+	}
+	svc := newSubscriptionRenewalServiceForTest(repo, sub)
+
+	renewed, failed, err := svc.RenewDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 0, renewed)
+	require.Equal(t, 1, failed)
+	require.InDelta(t, 4, repo.balance, 1e-9)
+	require.Equal(t, expiresAt, sub.ExpiresAt)
+	require.Len(t, repo.charges, 1)
+	require.Equal(t, SubscriptionChargeStatusInsufficientBalance, repo.charges[0].Status)
+	require.Nil(t, repo.charges[0].NewExpiresAt)
+
+	// 充值后的重试：已过期订阅从当前时间起延长并恢复为 active
+	repo.balance = 12
+	renewed, _, err = svc.RenewDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, renewed)
+	require.Equal(t, SubscriptionStatusActive, sub.Status)
+	require.True(t, sub.ExpiresAt.After(time.Now().AddDate(0, 0, 6)))
+}
+
+func TestSubscriptionRenewal_SetAutoRenewRequiresPricedGroupAndOwnership(t *testing.T) {
+	sub := &UserSubscription{ID: 3, UserID: 7, GroupID: 2, Group: &Group{ID: 2}}
+	repo := &subscriptionChargeRepoStub{}
+	svc := newSubscriptionRenewalServiceForTest(repo, sub)
+	ctx := context.Background()
+
+	_, err := svc.SetAutoRenew(ctx, 8, 3, true)
+	require.ErrorIs(t, err, ErrSubscriptionNotFound)
+
+	_, err = svc.SetAutoRenew(ctx, 7, 3, true)
+	require.ErrorIs(t, err, ErrSubscriptionNotRenewable)
+
+	// 关闭不要求分组有售价
+	out, err := svc.SetAutoRenew(ctx, 7, 3, false)
+	require.NoError(t, err)
+	require.False(t, out.AutoRenew)
+
+	price := 9.9
+	sub.Group.Price = &price
+	out, err = svc.SetAutoRenew(ctx, 7, 3, true)
+	require.NoError(t, err)
+	require.True(t, out.AutoRenew)
+	require.True(t, repo.autoRenew[3])
+}
+
+func TestGroupRenewalPeriodDays(t *testing.T) {
+	require.Equal(t, 7, groupRenewalPeriodDays(7, 30))
+	require.Equal(t, 30, groupRenewalPeriodDays(0, 30))
+	require.Equal(t, 30, groupRenewalPeriodDays(0, 0))
+}
@@ -0,0 +1,581 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// 上游熔断器
+//
+// 熔断作用域分为两类：
+//   - account:{id}：单个账号（凭证失效、账号级故障）
+//   - host:{host}：上游主机（官方 API、Bedrock 各区域、自定义 base_url），一个主机故障时使用该主机的所有账号一起熔断
+//
+// 上游请求经 HTTPUpstream 装饰器记录结果（网络错误与 5xx 计为错误，按响应头耗时判断慢调用），
+// 在滑动窗口内错误率或慢调用率超过阈值时进入 open，调度时跳过；open 到期后进入 half_open，
+// 调度过滤只读取状态，账号被最终选中后才占用探测名额，仅放行一个真实请求，探测成功则恢复 closed，失败则重新 open。
+// 账号作用域会记录最近一次请求的上游主机，调度时据此查询主机作用域的状态。
+const (
+	CircuitStateClosed   = "closed"
+	CircuitStateOpen     = "open"
+	CircuitStateHalfOpen = "half_open"
+
+	CircuitScopeAccount = "account"
+	CircuitScopeHost    = "host"
+)
+
+// 结果记录工作池：每次上游请求需两次 Redis Lua 调用，使用固定 worker + 有界队列异步执行，
+// 队列满时丢弃（熔断统计允许少量样本缺失，不能拖慢或堆积请求路径）
+const (
+	upstreamCircuitRecordWorkerCount     = 8
+	upstreamCircuitRecordBufferSize      = 1024
+	upstreamCircuitRecordTimeout         = 3 * time.Second
+	upstreamCircuitRecordDropLogInterval = 5 * time.Second
+)
+
+// UpstreamCircuitSnapshot 单个熔断作用域的状态快照
+type UpstreamCircuitSnapshot struct {
+	// OpenUntil 熔断截止时间，零值表示 closed；已过期表示 half_open
+	OpenUntil time.Time
+	// ProbeToken / ProbeUntil 半开探测的占用者（请求 ID）与占用截止时间
+	ProbeToken string
+	ProbeUntil time.Time
+	// Host 账号作用域最近一次请求的上游主机
+	Host string
+}
+
+// State 返回作用域在 now 时刻的熔断状态
+func (s *UpstreamCircuitSnapshot) State(now time.Time) string {
+	if s == nil || s.OpenUntil.IsZero() {
+		return CircuitStateClosed
+	}
+	if now.Before(s.OpenUntil) {
+		return CircuitStateOpen
+	}
+	return CircuitStateHalfOpen
+}
+
+// UpstreamCircuitOutcome 单次上游请求的结果
+type UpstreamCircuitOutcome struct {
+	Failure bool
+	Slow    bool
+	// ProbeToken 请求 ID，与半开探测占用者一致时决定作用域恢复或重新熔断
+	ProbeToken string
+	// Host 上游主机，仅账号作用域记录
+	Host string
+	At   time.Time
+}
+
+// UpstreamCircuitPolicy 熔断判定参数
+type UpstreamCircuitPolicy struct {
+	Window                time.Duration
+	MinRequests           int
+	ErrorRateThreshold    int
+	SlowCallRateThreshold int
+	OpenDuration          time.Duration
+	ProbeTimeout          time.Duration
+}
+
+// UpstreamCircuitTransition 记录结果后作用域的状态变化
+type UpstreamCircuitTransition int
+
+const (
+	CircuitTransitionNone UpstreamCircuitTransition = iota
+	CircuitTransitionOpened
+	CircuitTransitionClosed
+	CircuitTransitionReopened
+)
+
+// UpstreamCircuitCache 熔断状态存储（Redis 实现，多实例共享）
+type UpstreamCircuitCache interface {
+	// GetCircuitSnapshots 批量读取作用域状态，未记录过的作用域不出现在结果中
+	GetCircuitSnapshots(ctx context.Context, scopes []string) (map[string]*UpstreamCircuitSnapshot, error)
+	// RecordCircuitOutcome 原子地记录一次请求结果并按策略更新状态
+	RecordCircuitOutcome(ctx context.Context, scope string, outcome UpstreamCircuitOutcome, policy UpstreamCircuitPolicy) (UpstreamCircuitTransition, error)
+	// ClaimCircuitProbe 为半开作用域占用探测名额；closed 或已由 token 占用时返回 true
+	ClaimCircuitProbe(ctx context.Context, scope, token string, now time.Time, probeTimeout time.Duration) (bool, error)
+}
+
+// AccountCircuitStatus 账号的熔断状态（账号与其上游主机中较差的一个）
+type AccountCircuitStatus struct {
+	State     string
+	Scope     string
+	Host      string
+	OpenUntil *time.Time
+}
+
+// circuitRecordTask 待写入的一次上游请求结果
+type circuitRecordTask struct {
+	host      string
+	accountID int64
+	outcome   UpstreamCircuitOutcome
+}
+
+// UpstreamCircuitBreaker 按账号与上游主机熔断
+type UpstreamCircuitBreaker struct {
+	cache UpstreamCircuitCache
+	cfg   config.GatewayCircuitBreakerConfig
+	now   func() time.Time
+
+	recordChan     chan circuitRecordTask
+	recordWg       sync.WaitGroup
+	recordStopOnce sync.Once
+	recordMu       sync.RWMutex
+	// 丢弃日志节流计数器
+	recordDropCount   uint64
+	recordDropLastLog int64
+}
+
+// NewUpstreamCircuitBreaker 创建上游熔断器；启用时启动结果记录工作池
+func NewUpstreamCircuitBreaker(cache UpstreamCircuitCache, cfg *config.Config) *UpstreamCircuitBreaker {
+	b := &UpstreamCircuitBreaker{cache: cache, now: time.Now}
+	if cfg != nil {
+		b.cfg = cfg.Gateway.CircuitBreaker
+	}
+	if b.Enabled() {
+		b.startRecordWorkers()
+	}
+	return b
+}
+
+// Stop 关闭结果记录工作池，等待已入队的结果写入完成
+func (b *UpstreamCircuitBreaker) Stop() {
+	if b == nil {
+		return
+	}
+	b.recordStopOnce.Do(func() {
+		b.recordMu.Lock()
+		ch := b.recordChan
+		b.recordChan = nil
+		if ch != nil {
+			close(ch)
+		}
+		b.recordMu.Unlock()
+		b.recordWg.Wait()
+	})
+}
+
+func (b *UpstreamCircuitBreaker) startRecordWorkers() {
+	ch := make(chan circuitRecordTask, upstreamCircuitRecordBufferSize)
+	b.recordChan = ch
+	for i := 0; i < upstreamCircuitRecordWorkerCount; i++ {
+		b.recordWg.Add(1)
+		go b.recordWorker(ch)
+	}
+}
+
+// enqueueRecord 非阻塞入队；队列满或已停止时丢弃并节流告警
+func (b *UpstreamCircuitBreaker) enqueueRecord(task circuitRecordTask) bool {
+	b.recordMu.RLock()
+	defer b.recordMu.RUnlock()
+	if b.recordChan == nil {
+		b.logRecordDrop()
+		return false
+	}
+	select {
+	case b.recordChan <- task:
+		return true
+	default:
+		b.logRecordDrop()
+		return false
+	}
+}
+
+func (b *UpstreamCircuitBreaker) recordWorker(ch <-chan circuitRecordTask) {
+	defer b.recordWg.Done()
+	for task := range ch {
+		ctx, cancel := context.WithTimeout(context.Background(), upstreamCircuitRecordTimeout)
+		if task.host != "" {
+			b.recordScope(ctx, hostCircuitScope(task.host), task.outcome)
+		}
+		if task.accountID > 0 {
+			accountOutcome := task.outcome
+			accountOutcome.Host = task.host
+			b.recordScope(ctx, accountCircuitScope(task.accountID), accountOutcome)
+		}
+		cancel()
+	}
+}
+
+// logRecordDrop 节流记录丢弃数量，避免高负载下日志刷屏
+func (b *UpstreamCircuitBreaker) logRecordDrop() {
+	atomic.AddUint64(&b.recordDropCount, 1)
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&b.recordDropLastLog)
+	if now-last < int64(upstreamCircuitRecordDropLogInterval) {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&b.recordDropLastLog, last, now) {
+		return
+	}
+	if dropped := atomic.SwapUint64(&b.recordDropCount, 0); dropped > 0 {
+		logger.LegacyPrintf("service.circuit_breaker", "[CircuitBreaker] Warning: record queue full or stopped, dropped %d outcomes in last %s", dropped, upstreamCircuitRecordDropLogInterval)
+	}
+}
+
+// Enabled 熔断器是否启用
+func (b *UpstreamCircuitBreaker) Enabled() bool {
+	return b != nil && b.cache != nil && b.cfg.Enabled
+}
+
+func (b *UpstreamCircuitBreaker) policy() UpstreamCircuitPolicy {
+	return UpstreamCircuitPolicy{
+		Window:                time.Duration(b.cfg.WindowSeconds) * time.Second,
+		MinRequests:           b.cfg.MinRequests,
+		ErrorRateThreshold:    b.cfg.ErrorRateThreshold,
+		SlowCallRateThreshold: b.cfg.SlowCallRateThreshold,
+		OpenDuration:          time.Duration(b.cfg.OpenDurationSeconds) * time.Second,
+		ProbeTimeout:          time.Duration(b.cfg.ProbeTimeoutSeconds) * time.Second,
+	}
+}
+
+func accountCircuitScope(accountID int64) string {
+	return CircuitScopeAccount + ":" + strconv.FormatInt(accountID, 10)
+}
+
+func hostCircuitScope(host string) string {
+	return CircuitScopeHost + ":" + strings.ToLower(host)
+}
+
+func circuitProbeToken(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxkey.RequestID).(string)
+	return strings.TrimSpace(id)
+}
+
+// loadSnapshots 读取账号作用域及其最近上游主机作用域的状态
+func (b *UpstreamCircuitBreaker) loadSnapshots(ctx context.Context, accountIDs []int64) (map[string]*UpstreamCircuitSnapshot, error) {
+	scopes := make([]string, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		scopes = append(scopes, accountCircuitScope(id))
+	}
+	snapshots, err := b.cache.GetCircuitSnapshots(ctx, scopes)
+	if err != nil {
+		return nil, err
+	}
+	hostScopes := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, snap := range snapshots {
+		if snap == nil || snap.Host == "" {
+			continue
+		}
+		scope := hostCircuitScope(snap.Host)
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		hostScopes = append(hostScopes, scope)
+	}
+	if len(hostScopes) == 0 {
+		return snapshots, nil
+	}
+	hostSnapshots, err := b.cache.GetCircuitSnapshots(ctx, hostScopes)
+	if err != nil {
+		return nil, err
+	}
+	for scope, snap := range hostSnapshots {
+		snapshots[scope] = snap
+	}
+	return snapshots, nil
+}
+
+// scopeSelectable 判断作用域当前是否可参与调度（只读）：半开状态下探测名额未被其它请求占用时可选，
+// 真正占用探测名额由 ClaimProbe 在账号被选中后完成
+func scopeSelectable(snap *UpstreamCircuitSnapshot, token string, now time.Time) bool {
+	switch snap.State(now) {
+	case CircuitStateClosed:
+		return true
+	case CircuitStateOpen:
+		return false
+	}
+	if token == "" {
+		// 无请求 ID 时无法关联探测结果，不参与探测
+		return false
+	}
+	if snap.ProbeToken != "" && now.Before(snap.ProbeUntil) {
+		return snap.ProbeToken == token
+	}
+	return true
+}
+
+// accountCircuitScopes 返回账号作用域及其最近上游主机作用域
+func accountCircuitScopes(accountID int64, snapshots map[string]*UpstreamCircuitSnapshot) []string {
+	accountScope := accountCircuitScope(accountID)
+	scopes := []string{accountScope}
+	if snap := snapshots[accountScope]; snap != nil && snap.Host != "" {
+		scopes = append(scopes, hostCircuitScope(snap.Host))
+	}
+	return scopes
+}
+
+func (b *UpstreamCircuitBreaker) allowAccount(accountID int64, snapshots map[string]*UpstreamCircuitSnapshot, token string, now time.Time) bool {
+	for _, scope := range accountCircuitScopes(accountID, snapshots) {
+		if !scopeSelectable(snapshots[scope], token, now) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterAccounts 过滤熔断中的账号（只读，不占用半开探测名额）；Redis 异常时失败开放
+func (b *UpstreamCircuitBreaker) FilterAccounts(ctx context.Context, accounts []Account) []Account {
+	if !b.Enabled() || len(accounts) == 0 {
+		return accounts
+	}
+	ids := make([]int64, 0, len(accounts))
+	for i := range accounts {
+		ids = append(ids, accounts[i].ID)
+	}
+	snapshots, err := b.loadSnapshots(ctx, ids)
+	if err != nil {
+		logger.LegacyPrintf("service.circuit_breaker", "[CircuitBreaker] load snapshots failed: %v", err)
+		return accounts
+	}
+	if len(snapshots) == 0 {
+		return accounts
+	}
+	token := circuitProbeToken(ctx)
+	now := b.now()
+	filtered := make([]Account, 0, len(accounts))
+	for i := range accounts {
+		if b.allowAccount(accounts[i].ID, snapshots, token, now) {
+			filtered = append(filtered, accounts[i])
+		}
+	}
+	return filtered
+}
+
+// AllowAccount 检查单个账号（如粘性会话绑定的账号）是否允许调度（只读）；Redis 异常时失败开放
+func (b *UpstreamCircuitBreaker) AllowAccount(ctx context.Context, account *Account) bool {
+	if !b.Enabled() || account == nil {
+		return true
+	}
+	snapshots, err := b.loadSnapshots(ctx, []int64{account.ID})
+	if err != nil {
+		return true
+	}
+	return b.allowAccount(account.ID, snapshots, circuitProbeToken(ctx), b.now())
+}
+
+// ClaimProbe 为最终选中（已获取槽位或进入等待计划）的账号占用半开作用域的探测名额。
+// 账号与主机均为 closed 时直接返回 true；名额已被其它请求占用时返回 false，调用方应放弃该账号。
+// 读取状态失败时失败开放。
+func (b *UpstreamCircuitBreaker) ClaimProbe(ctx context.Context, account *Account) bool {
+	if !b.Enabled() || account == nil {
+		return true
+	}
+	snapshots, err := b.loadSnapshots(ctx, []int64{account.ID})
+	if err != nil {
+		return true
+	}
+	token := circuitProbeToken(ctx)
+	now := b.now()
+	for _, scope := range accountCircuitScopes(account.ID, snapshots) {
+		snap := snapshots[scope]
+		if snap.State(now) == CircuitStateClosed {
+			continue
+		}
+		if !scopeSelectable(snap, token, now) {
+			return false
+		}
+		claimed, err := b.cache.ClaimCircuitProbe(ctx, scope, token, now, b.policy().ProbeTimeout)
+		if err != nil {
+			logger.LegacyPrintf("service.circuit_breaker", "[CircuitBreaker] claim probe failed: scope=%s err=%v", scope, err)
+			return false
+		}
+		if !claimed {
+			return false
+		}
+	}
+	return true
+}
+
+// claimAcquired 在已获取槽位后为账号占用探测名额；名额已被其它请求占用时退还槽位（含模型 RPM）并视为未获取
+func (b *UpstreamCircuitBreaker) claimAcquired(ctx context.Context, account *Account, result *AcquireResult, err error) (*AcquireResult, error) {
+	if err != nil || result == nil || !result.Acquired || b.ClaimProbe(ctx, account) {
+		return result, err
+	}
+	result.Cancel()
+	return &AcquireResult{Acquired: false}, nil
+}
+
+// excludeCircuitProbeAccount 返回追加了 accountID 的排除列表副本（不修改调用方的列表），
+// 用于选中账号的半开探测名额被其它请求抢先占用后重新选择
+func excludeCircuitProbeAccount(excludedIDs map[int64]struct{}, accountID int64) map[int64]struct{} {
+	next := make(map[int64]struct{}, len(excludedIDs)+1)
+	for id := range excludedIDs {
+		next[id] = struct{}{}
+	}
+	next[accountID] = struct{}{}
+	return next
+}
+
+// GetAccountStatuses 返回账号的熔断状态（用于 ops 账号可用性展示），closed 的账号不出现在结果中
+func (b *UpstreamCircuitBreaker) GetAccountStatuses(ctx context.Context, accountIDs []int64) (map[int64]*AccountCircuitStatus, error) {
+	if !b.Enabled() || len(accountIDs) == 0 {
+		return nil, nil
+	}
+	snapshots, err := b.loadSnapshots(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	now := b.now()
+	statuses := make(map[int64]*AccountCircuitStatus)
+	for _, id := range accountIDs {
+		accountSnap := snapshots[accountCircuitScope(id)]
+		if accountSnap == nil {
+			continue
+		}
+		status := circuitStatusFrom(CircuitScopeAccount, accountSnap, now)
+		if accountSnap.Host != "" {
+			hostStatus := circuitStatusFrom(CircuitScopeHost, snapshots[hostCircuitScope(accountSnap.Host)], now)
+			if circuitStateRank(hostStatus.State) > circuitStateRank(status.State) {
+				status = hostStatus
+			}
+			status.Host = accountSnap.Host
+		}
+		if status.State != CircuitStateClosed {
+			statuses[id] = status
+		}
+	}
+	return statuses, nil
+}
+
+func circuitStatusFrom(scope string, snap *UpstreamCircuitSnapshot, now time.Time) *AccountCircuitStatus {
+	status := &AccountCircuitStatus{State: snap.State(now), Scope: scope}
+	if status.State != CircuitStateClosed {
+		openUntil := snap.OpenUntil
+		status.OpenUntil = &openUntil
+	}
+	return status
+}
+
+func circuitStateRank(state string) int {
+	switch state {
+	case CircuitStateOpen:
+		return 2
+	case CircuitStateHalfOpen:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// classifyOutcome 将上游请求结果归类；客户端取消的请求不计入统计
+func (b *UpstreamCircuitBreaker) classifyOutcome(req *http.Request, elapsed time.Duration, resp *http.Response, err error) (UpstreamCircuitOutcome, bool) {
+	outcome := UpstreamCircuitOutcome{
+		ProbeToken: circuitProbeToken(req.Context()),
+		At:         b.now(),
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(req.Context().Err(), context.Canceled) {
+			return outcome, false
+		}
+		outcome.Failure = true
+	} else if resp != nil && resp.StatusCode >= http.StatusInternalServerError {
+		outcome.Failure = true
+	}
+	if b.cfg.SlowCallThresholdMs > 0 && b.cfg.SlowCallRateThreshold > 0 && elapsed >= time.Duration(b.cfg.SlowCallThresholdMs)*time.Millisecond {
+		outcome.Slow = true
+	}
+	return outcome, true
+}
+
+// record 经工作池异步记录一次上游请求结果到账号与上游主机两个作用域
+func (b *UpstreamCircuitBreaker) record(req *http.Request, accountID int64, elapsed time.Duration, resp *http.Response, err error) {
+	if !b.Enabled() || req == nil || req.URL == nil {
+		return
+	}
+	outcome, ok := b.classifyOutcome(req, elapsed, resp, err)
+	if !ok {
+		return
+	}
+	b.enqueueRecord(circuitRecordTask{host: strings.ToLower(req.URL.Host), accountID: accountID, outcome: outcome})
+}
+
+func (b *UpstreamCircuitBreaker) recordScope(ctx context.Context, scope string, outcome UpstreamCircuitOutcome) {
+	transition, err := b.cache.RecordCircuitOutcome(ctx, scope, outcome, b.policy())
+	if err != nil {
+		logger.LegacyPrintf("service.circuit_breaker", "[CircuitBreaker] record outcome failed: scope=%s err=%v", scope, err)
+		return
+	}
+	switch transition {
+	case CircuitTransitionOpened:
+		logger.LegacyPrintf("service.circuit_breaker", "[CircuitBreaker] circuit opened: scope=%s open_duration=%ds", scope, b.cfg.OpenDurationSeconds)
+	case CircuitTransitionReopened:
+		logger.LegacyPrintf("service.circuit_breaker", "[CircuitBreaker] half-open probe failed, circuit reopened: scope=%s", scope)
+	case CircuitTransitionClosed:
+		logger.LegacyPrintf("service.circuit_breaker", "[CircuitBreaker] half-open probe succeeded, circuit closed: scope=%s", scope)
+	}
+}
+
+// WrapUpstream 返回记录熔断结果的 HTTPUpstream 装饰器；未启用时原样返回
+func (b *UpstreamCircuitBreaker) WrapUpstream(upstream HTTPUpstream) HTTPUpstream {
+	if !b.Enabled() || upstream == nil {
+		return upstream
+	}
+	if _, wrapped := upstream.(*circuitRecordingUpstream); wrapped {
+		return upstream
+	}
+	return &circuitRecordingUpstream{HTTPUpstream: upstream, breaker: b}
+}
+
+// circuitRecordingUpstream 记录每次上游请求结果的 HTTPUpstream 装饰器
+type circuitRecordingUpstream struct {
+	HTTPUpstream
+	breaker *UpstreamCircuitBreaker
+}
+
+func (u *circuitRecordingUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	start := time.Now()
+	resp, err := u.HTTPUpstream.Do(req, proxyURL, accountID, accountConcurrency)
+	u.breaker.record(req, accountID, time.Since(start), resp, err)
+	return resp, err
+}
+
+func (u *circuitRecordingUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	start := time.Now()
+	resp, err := u.HTTPUpstream.DoWithTLS(req, proxyURL, accountID, accountConcurrency, enableTLSFingerprint)
+	u.breaker.record(req, accountID, time.Since(start), resp, err)
+	return resp, err
+}
+
+// SetUpstreamCircuitBreaker 启用上游熔断：记录上游请求结果，并在调度时跳过熔断中的账号（可选依赖）
+func (s *GatewayService) SetUpstreamCircuitBreaker(b *UpstreamCircuitBreaker) {
+	s.circuitBreaker = b
+	s.httpUpstream = b.WrapUpstream(s.httpUpstream)
+}
+
+// SetUpstreamCircuitBreaker 启用上游熔断：记录上游请求结果（可选依赖）
+func (s *AntigravityGatewayService) SetUpstreamCircuitBreaker(b *UpstreamCircuitBreaker) {
+	s.httpUpstream = b.WrapUpstream(s.httpUpstream)
+}
+
+// SetUpstreamCircuitBreaker 启用上游熔断：记录上游请求结果，并在调度时跳过熔断中的账号（可选依赖）
+func (s *GeminiMessagesCompatService) SetUpstreamCircuitBreaker(b *UpstreamCircuitBreaker) {
+	s.circuitBreaker = b
+	s.httpUpstream = b.WrapUpstream(s.httpUpstream)
+}
+
+// SetUpstreamCircuitBreaker 启用上游熔断：记录上游请求结果，并在调度时跳过熔断中的账号（可选依赖）
+func (s *OpenAIGatewayService) SetUpstreamCircuitBreaker(b *UpstreamCircuitBreaker) {
+	s.circuitBreaker = b
+	s.httpUpstream = b.WrapUpstream(s.httpUpstream)
+}
+
+// SetUpstreamCircuitBreaker 启用上游熔断：记录 API Key 账号透传请求的上游结果（可选依赖）
+func (s *SoraGatewayService) SetUpstreamCircuitBreaker(b *UpstreamCircuitBreaker) {
+	s.httpUpstream = b.WrapUpstream(s.httpUpstream)
+}
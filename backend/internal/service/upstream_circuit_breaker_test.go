@@ -0,0 +1,294 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/stretchr/testify/require"
+)
+
+type circuitRecord struct {
+	scope   string
+	outcome UpstreamCircuitOutcome
+}
+
+type fakeUpstreamCircuitCache struct {
+	mu        sync.Mutex
+	snapshots map[string]*UpstreamCircuitSnapshot
+	records   chan circuitRecord
+}
+
+func newFakeUpstreamCircuitCache() *fakeUpstreamCircuitCache {
+	return &fakeUpstreamCircuitCache{
+		snapshots: map[string]*UpstreamCircuitSnapshot{},
+		records:   make(chan circuitRecord, 16),
+	}
+}
+
+func (c *fakeUpstreamCircuitCache) GetCircuitSnapshots(_ context.Context, scopes []string) (map[string]*UpstreamCircuitSnapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]*UpstreamCircuitSnapshot)
+	for _, scope := range scopes {
+		if snap, ok := c.snapshots[scope]; ok {
+			cp := *snap
+			out[scope] = &cp
+		}
+	}
+	return out, nil
+}
+
+func (c *fakeUpstreamCircuitCache) RecordCircuitOutcome(_ context.Context, scope string, outcome UpstreamCircuitOutcome, _ UpstreamCircuitPolicy) (UpstreamCircuitTransition, error) {
+	c.records <- circuitRecord{scope: scope, outcome: outcome}
+	return CircuitTransitionNone, nil
+}
+
+func (c *fakeUpstreamCircuitCache) ClaimCircuitProbe(_ context.Context, scope, token string, now time.Time, probeTimeout time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snap := c.snapshots[scope]
+	if snap.State(now) != CircuitStateHalfOpen {
+		return snap.State(now) == CircuitStateClosed, nil
+	}
+	if snap.ProbeToken != "" && now.Before(snap.ProbeUntil) {
+		return snap.ProbeToken == token, nil
+	}
+	snap.ProbeToken = token
+	snap.ProbeUntil = now.Add(probeTimeout)
+	return true, nil
+}
+
+func newTestCircuitBreaker(cache UpstreamCircuitCache) *UpstreamCircuitBreaker {
+	cfg := &config.Config{}
+	cfg.Gateway.CircuitBreaker = config.GatewayCircuitBreakerConfig{
+		Enabled:               true,
+		WindowSeconds:         60,
+		MinRequests:           20,
+		ErrorRateThreshold:    50,
+		SlowCallThresholdMs:   1000,
+		SlowCallRateThreshold: 50,
+		OpenDurationSeconds:   30,
+		ProbeTimeoutSeconds:   60,
+	}
+	return NewUpstreamCircuitBreaker(cache, cfg)
+}
+
+func circuitTestAccountIDs(accounts []Account) []int64 {
+	ids := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.ID)
+	}
+	return ids
+}
+
+func TestUpstreamCircuitBreaker_FilterAccounts(t *testing.T) {
+	now := time.Now()
+	cache := newFakeUpstreamCircuitCache()
+	cache.snapshots[accountCircuitScope(1)] = &UpstreamCircuitSnapshot{OpenUntil: now.Add(time.Minute), Host: "api.anthropic.com"}
+	cache.snapshots[accountCircuitScope(2)] = &UpstreamCircuitSnapshot{Host: "bedrock-runtime.us-east-1.amazonaws.com"}
+	cache.snapshots[accountCircuitScope(3)] = &UpstreamCircuitSnapshot{Host: "api.anthropic.com"}
+	cache.snapshots[hostCircuitScope("bedrock-runtime.us-east-1.amazonaws.com")] = &UpstreamCircuitSnapshot{OpenUntil: now.Add(time.Minute)}
+	breaker := newTestCircuitBreaker(cache)
+
+	accounts := []Account{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	filtered := breaker.FilterAccounts(context.Background(), accounts)
+	require.Equal(t, []int64{3, 4}, circuitTestAccountIDs(filtered), "账号熔断与所在上游主机熔断的账号都被跳过")
+
+	require.False(t, breaker.AllowAccount(context.Background(), &accounts[1]))
+	require.True(t, breaker.AllowAccount(context.Background(), &accounts[2]))
+
+	var disabled *UpstreamCircuitBreaker
+	require.Len(t, disabled.FilterAccounts(context.Background(), accounts), 4)
+	require.True(t, disabled.AllowAccount(context.Background(), &accounts[0]))
+}
+
+func TestUpstreamCircuitBreaker_HalfOpenSingleProbe(t *testing.T) {
+	now := time.Now()
+	cache := newFakeUpstreamCircuitCache()
+	cache.snapshots[accountCircuitScope(1)] = &UpstreamCircuitSnapshot{OpenUntil: now.Add(-time.Second)}
+	breaker := newTestCircuitBreaker(cache)
+	accounts := []Account{{ID: 1}, {ID: 2}}
+
+	first := context.WithValue(context.Background(), ctxkey.RequestID, "req-1")
+	second := context.WithValue(context.Background(), ctxkey.RequestID, "req-2")
+	require.Equal(t, []int64{1, 2}, circuitTestAccountIDs(breaker.FilterAccounts(first, accounts)))
+	require.Equal(t, []int64{1, 2}, circuitTestAccountIDs(breaker.FilterAccounts(second, accounts)), "过滤不占用探测名额")
+	require.Empty(t, cache.snapshots[accountCircuitScope(1)].ProbeToken)
+
+	require.True(t, breaker.ClaimProbe(first, &accounts[0]), "最终选中的请求占用探测名额")
+	require.Equal(t, []int64{2}, circuitTestAccountIDs(breaker.FilterAccounts(second, accounts)), "探测进行中其它请求跳过该账号")
+	require.False(t, breaker.ClaimProbe(second, &accounts[0]))
+	require.False(t, breaker.AllowAccount(second, &accounts[0]))
+
+	require.Equal(t, []int64{1, 2}, circuitTestAccountIDs(breaker.FilterAccounts(first, accounts)), "探测请求 failover 重新调度时仍可使用")
+	require.True(t, breaker.ClaimProbe(first, &accounts[0]))
+	require.True(t, breaker.ClaimProbe(second, &accounts[1]), "closed 账号无需占用")
+	require.Equal(t, []int64{2}, circuitTestAccountIDs(breaker.FilterAccounts(context.Background(), accounts)), "无请求 ID 不参与探测")
+}
+
+func TestUpstreamCircuitBreaker_ClaimAcquiredReleasesSlot(t *testing.T) {
+	now := time.Now()
+	cache := newFakeUpstreamCircuitCache()
+	cache.snapshots[accountCircuitScope(1)] = &UpstreamCircuitSnapshot{
+		OpenUntil:  now.Add(-time.Second),
+		ProbeToken: "req-1",
+		ProbeUntil: now.Add(time.Minute),
+	}
+	breaker := newTestCircuitBreaker(cache)
+	ctx := context.WithValue(context.Background(), ctxkey.RequestID, "req-2")
+
+	cancelled := 0
+	acquired := &AcquireResult{Acquired: true, ReleaseFunc: func() {}, CancelFunc: func() { cancelled++ }}
+	result, err := breaker.claimAcquired(ctx, &Account{ID: 1}, acquired, nil)
+	require.NoError(t, err)
+	require.False(t, result.Acquired, "探测名额已被占用时放弃该账号")
+	require.Equal(t, 1, cancelled, "退还已获取的槽位与 RPM")
+
+	result, err = breaker.claimAcquired(ctx, &Account{ID: 2}, acquired, nil)
+	require.NoError(t, err)
+	require.Same(t, acquired, result)
+	require.Equal(t, 1, cancelled)
+}
+
+func TestUpstreamCircuitBreaker_GetAccountStatuses(t *testing.T) {
+	now := time.Now()
+	cache := newFakeUpstreamCircuitCache()
+	cache.snapshots[accountCircuitScope(1)] = &UpstreamCircuitSnapshot{OpenUntil: now.Add(-time.Second), Host: "gw.example.com"}
+	cache.snapshots[hostCircuitScope("gw.example.com")] = &UpstreamCircuitSnapshot{OpenUntil: now.Add(time.Minute)}
+	cache.snapshots[accountCircuitScope(2)] = &UpstreamCircuitSnapshot{Host: "api.anthropic.com"}
+	breaker := newTestCircuitBreaker(cache)
+
+	statuses, err := breaker.GetAccountStatuses(context.Background(), []int64{1, 2, 3})
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	status := statuses[1]
+	require.Equal(t, CircuitStateOpen, status.State, "取账号与主机中较差的状态")
+	require.Equal(t, CircuitScopeHost, status.Scope)
+	require.Equal(t, "gw.example.com", status.Host)
+	require.NotNil(t, status.OpenUntil)
+}
+
+type circuitStubUpstream struct {
+	resp *http.Response
+	err  error
+}
+
+func (u *circuitStubUpstream) Do(*http.Request, string, int64, int) (*http.Response, error) {
+	return u.resp, u.err
+}
+
+func (u *circuitStubUpstream) DoWithTLS(*http.Request, string, int64, int, bool) (*http.Response, error) {
+	return u.resp, u.err
+}
+
+func TestUpstreamCircuitBreaker_WrapUpstreamRecordsOutcome(t *testing.T) {
+	cache := newFakeUpstreamCircuitCache()
+	breaker := newTestCircuitBreaker(cache)
+
+	receive := func() map[string]UpstreamCircuitOutcome {
+		out := make(map[string]UpstreamCircuitOutcome)
+		for i := 0; i < 2; i++ {
+			select {
+			case rec := <-cache.records:
+				out[rec.scope] = rec.outcome
+			case <-time.After(2 * time.Second):
+				t.Fatal("timed out waiting for circuit record")
+			}
+		}
+		return out
+	}
+
+	ctx := context.WithValue(context.Background(), ctxkey.RequestID, "req-1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://Bedrock-Runtime.eu-west-1.amazonaws.com/model/x/invoke", nil)
+	require.NoError(t, err)
+
+	upstream := breaker.WrapUpstream(&circuitStubUpstream{resp: &http.Response{StatusCode: http.StatusServiceUnavailable}})
+	require.Same(t, upstream, breaker.WrapUpstream(upstream), "重复挂载不重复包装")
+	_, _ = upstream.DoWithTLS(req, "", 7, 1, false)
+	records := receive()
+	hostOutcome := records[hostCircuitScope("bedrock-runtime.eu-west-1.amazonaws.com")]
+	require.True(t, hostOutcome.Failure)
+	require.Equal(t, "req-1", hostOutcome.ProbeToken)
+	accountOutcome := records[accountCircuitScope(7)]
+	require.True(t, accountOutcome.Failure)
+	require.Equal(t, "bedrock-runtime.eu-west-1.amazonaws.com", accountOutcome.Host)
+
+	upstream = breaker.WrapUpstream(&circuitStubUpstream{resp: &http.Response{StatusCode: http.StatusTooManyRequests}})
+	_, _ = upstream.Do(req, "", 7, 1)
+	for _, outcome := range receive() {
+		require.False(t, outcome.Failure, "429 不计为上游故障")
+	}
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	canceledReq := req.WithContext(canceledCtx)
+	upstream = breaker.WrapUpstream(&circuitStubUpstream{err: errors.New("context canceled")})
+	_, _ = upstream.Do(canceledReq, "", 7, 1)
+	select {
+	case rec := <-cache.records:
+		t.Fatalf("client canceled request should not be recorded: %+v", rec)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestUpstreamCircuitBreaker_RecordQueueBoundedAndDrainedOnStop(t *testing.T) {
+	cache := newFakeUpstreamCircuitCache()
+	cache.records = make(chan circuitRecord) // 无人接收时 worker 阻塞，模拟 Redis 写入变慢
+	breaker := newTestCircuitBreaker(cache)
+	task := circuitRecordTask{host: "api.anthropic.com", accountID: 1}
+
+	accepted := 0
+	for breaker.enqueueRecord(task) {
+		accepted++
+		require.LessOrEqual(t, accepted, upstreamCircuitRecordWorkerCount+upstreamCircuitRecordBufferSize, "队列满后必须丢弃而不是无限堆积")
+	}
+	require.GreaterOrEqual(t, accepted, upstreamCircuitRecordBufferSize)
+
+	recorded := 0
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for range cache.records {
+			recorded++
+		}
+	}()
+	breaker.Stop()
+	close(cache.records)
+	<-drained
+	require.Equal(t, 2*accepted, recorded, "停止时已入队的结果写入主机与账号两个作用域")
+
+	require.False(t, breaker.enqueueRecord(task), "停止后丢弃")
+	breaker.Stop()
+	var disabled *UpstreamCircuitBreaker
+	disabled.Stop()
+}
+
+func TestOpenAISelectAccountWithScheduler_SkipsOpenCircuit(t *testing.T) {
+	cache := newFakeUpstreamCircuitCache()
+	cache.snapshots[accountCircuitScope(1)] = &UpstreamCircuitSnapshot{OpenUntil: time.Now().Add(time.Minute)}
+	svc := &OpenAIGatewayService{
+		accountRepo: stubOpenAIAccountRepo{accounts: []Account{
+			{ID: 1, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 5, Priority: 1},
+			{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 5, Priority: 2},
+		}},
+		cache:              &stubGatewayCache{sessionBindings: map[string]int64{"openai:sticky": 1}},
+		concurrencyService: NewConcurrencyService(stubConcurrencyCache{}),
+		httpUpstream:       &circuitStubUpstream{},
+	}
+	svc.SetUpstreamCircuitBreaker(newTestCircuitBreaker(cache))
+	_, wrapped := svc.httpUpstream.(*circuitRecordingUpstream)
+	require.True(t, wrapped, "OpenAI 上游请求记录熔断结果")
+
+	selection, _, err := svc.SelectAccountWithScheduler(context.Background(), nil, "", "sticky", "gpt-4", nil, OpenAIUpstreamTransportAny)
+	require.NoError(t, err)
+	require.True(t, selection.Acquired)
+	require.Equal(t, int64(2), selection.Account.ID, "粘性账号与负载均衡候选都跳过熔断中的账号")
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	UsageExportStatusPending   = "pending"
+	UsageExportStatusRunning   = "running"
+	UsageExportStatusSucceeded = "succeeded"
+	UsageExportStatusFailed    = "failed"
+	UsageExportStatusCanceled  = "canceled"
+	UsageExportStatusExpired   = "expired"
+)
+
+const (
+	UsageExportFormatCSV     = "csv"
+	UsageExportFormatJSONL   = "jsonl"
+	UsageExportFormatParquet = "parquet"
+)
+
+const (
+	UsageExportStorageLocal = "local"
+	UsageExportStorageS3    = "s3"
+)
+
+// NormalizeUsageExportFormat 规范化导出格式，非法值返回空字符串
+func NormalizeUsageExportFormat(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case UsageExportFormatCSV:
+		return UsageExportFormatCSV
+	case UsageExportFormatJSONL, "ndjson":
+		return UsageExportFormatJSONL
+	case UsageExportFormatParquet:
+		return UsageExportFormatParquet
+	default:
+		return ""
+	}
+}
+
+// UsageExportFilters 定义导出任务过滤条件
+// 时间范围为必填，其他字段可选，均为精确匹配。
+// 用户自助导出时 UserID 由服务端强制设置为当前用户。
+type UsageExportFilters struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	APIKeyID  *int64    `json:"api_key_id,omitempty"`
+	AccountID *int64    `json:"account_id,omitempty"`
+	GroupID   *int64    `json:"group_id,omitempty"`
+	Model     *string   `json:"model,omitempty"`
+}
+
+// UsageExportJob 表示一次使用记录导出任务
+// 状态包含 pending/running/succeeded/failed/canceled/expired
+type UsageExportJob struct {
+	ID      int64              `json:"id"`
+	Status  string             `json:"status"`
+	Format  string             `json:"format"`
+	Filters UsageExportFilters `json:"filters"`
+	// CreatedBy 任务发起人；AdminScope 表示管理员发起（可导出任意用户、包含账号维度字段）
+	CreatedBy    int64      `json:"created_by"`
+	AdminScope   bool       `json:"admin_scope"`
+	Storage      string     `json:"storage"`
+	ObjectKey    string     `json:"-"`
+	FileName     string     `json:"file_name"`
+	FileSize     int64      `json:"file_size"`
+	ExportedRows int64      `json:"exported_rows"`
+	ErrorMsg     *string    `json:"error_message,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// UsageExportListFilter 任务列表过滤；CreatedBy 为 nil 表示不限发起人（管理员视图）
+type UsageExportListFilter struct {
+	CreatedBy *int64
+}
+
+// UsageExportRepository 定义导出任务持久层接口
+type UsageExportRepository interface {
+	CreateJob(ctx context.Context, job *UsageExportJob) error
+	GetJob(ctx context.Context, id int64) (*UsageExportJob, error)
+	ListJobs(ctx context.Context, filter UsageExportListFilter, params pagination.PaginationParams) ([]UsageExportJob, *pagination.PaginationResult, error)
+	CountActiveJobs(ctx context.Context, createdBy int64) (int64, error)
+	// ClaimNextPendingJob 抢占下一条 pending 任务；running 超过 staleRunningAfterSeconds 的任务视为中断，可重新抢占
+	ClaimNextPendingJob(ctx context.Context, staleRunningAfterSeconds int64) (*UsageExportJob, error)
+	UpdateJobProgress(ctx context.Context, id int64, exportedRows int64) error
+	CancelJob(ctx context.Context, id int64) (bool, error)
+	MarkJobSucceeded(ctx context.Context, id int64, result UsageExportResult) error
+	MarkJobFailed(ctx context.Context, id int64, exportedRows int64, errorMsg string) error
+	// ListExpiredJobs 返回已过期但文件尚未清理的成功任务
+	ListExpiredJobs(ctx context.Context, now time.Time, limit int) ([]UsageExportJob, error)
+	MarkJobExpired(ctx context.Context, id int64) error
+}
+
+// UsageExportResult 任务完成后落库的结果信息
+type UsageExportResult struct {
+	ObjectKey    string
+	FileName     string
+	FileSize     int64
+	ExportedRows int64
+	ExpiresAt    *time.Time
+}
+
+// UsageLogKeysetReader 以 id 升序 keyset 分页读取使用记录，供导出等长时间流式任务使用。
+// 由 UsageLogRepository 的实现额外提供。
+type UsageLogKeysetReader interface {
+	ListUsageLogsAfterID(ctx context.Context, filters UsageExportFilters, afterID int64, limit int) ([]UsageLog, error)
+}
@@ -0,0 +1,639 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	usageExportWorkerName       = "usage_export_worker"
+	usageExportPresignExpiry    = time.Hour
+	usageExportExpirePurgeLimit = 50
+)
+
+var (
+	ErrUsageExportNotFound    = infraerrors.NotFound("USAGE_EXPORT_NOT_FOUND", "usage export job not found")
+	ErrUsageExportDisabled    = infraerrors.New(http.StatusServiceUnavailable, "USAGE_EXPORT_DISABLED", "usage export is disabled")
+	ErrUsageExportNotReady    = infraerrors.Conflict("USAGE_EXPORT_NOT_READY", "export file is not ready")
+	ErrUsageExportTooManyJobs = infraerrors.TooManyRequests("USAGE_EXPORT_TOO_MANY_JOBS", "too many export jobs in progress")
+)
+
+// UsageExportRequest 创建导出任务的输入
+type UsageExportRequest struct {
+	Format     string
+	Filters    UsageExportFilters
+	CreatedBy  int64
+	AdminScope bool
+}
+
+// UsageExportDownload 导出文件的下载方式：本地文件路径或对象存储预签名 URL（二选一）
+type UsageExportDownload struct {
+	FileName    string
+	ContentType string
+	LocalPath   string
+	URL         string
+}
+
+// UsageExportService 负责创建与执行使用记录导出任务
+type UsageExportService struct {
+	repo          UsageExportRepository
+	logReader     UsageLogKeysetReader
+	backupService *BackupService
+	timingWheel   *TimingWheelService
+	cfg           *config.Config
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+func NewUsageExportService(repo UsageExportRepository, usageRepo UsageLogRepository, backupService *BackupService, timingWheel *TimingWheelService, cfg *config.Config) *UsageExportService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	svc := &UsageExportService{
+		repo:          repo,
+		backupService: backupService,
+		timingWheel:   timingWheel,
+		cfg:           cfg,
+		workerCtx:     workerCtx,
+		workerCancel:  workerCancel,
+	}
+	if reader, ok := usageRepo.(UsageLogKeysetReader); ok {
+		svc.logReader = reader
+	}
+	return svc
+}
+
+func (s *UsageExportService) Start() {
+	if s == nil {
+		return
+	}
+	if !s.enabled() {
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] not started (disabled)")
+		return
+	}
+	if s.repo == nil || s.logReader == nil || s.timingWheel == nil {
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] not started (missing deps)")
+		return
+	}
+
+	interval := s.workerInterval()
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(usageExportWorkerName, interval, s.runOnce)
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] started (interval=%s storage=%s batch_size=%d task_timeout=%s)", interval, s.storage(), s.batchSize(), s.taskTimeout())
+	})
+}
+
+func (s *UsageExportService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(usageExportWorkerName)
+		}
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] stopped")
+	})
+}
+
+// CreateJob 校验过滤条件并创建 pending 任务；非管理员任务强制限定为本人数据
+func (s *UsageExportService) CreateJob(ctx context.Context, req UsageExportRequest) (*UsageExportJob, error) {
+	if s == nil || s.repo == nil {
+		return nil, fmt.Errorf("usage export service not ready")
+	}
+	if !s.enabled() {
+		return nil, ErrUsageExportDisabled
+	}
+	if req.CreatedBy <= 0 {
+		return nil, infraerrors.BadRequest("USAGE_EXPORT_INVALID_CREATOR", "invalid creator")
+	}
+	format := NormalizeUsageExportFormat(req.Format)
+	if format == "" {
+		return nil, infraerrors.BadRequest("USAGE_EXPORT_INVALID_FORMAT", "format must be one of: csv, jsonl, parquet")
+	}
+
+	filters := req.Filters
+	sanitizeUsageExportFilters(&filters)
+	if !req.AdminScope {
+		userID := req.CreatedBy
+		filters.UserID = &userID
+		filters.AccountID = nil
+	}
+	if err := s.validateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	if limit := s.maxActiveJobsPerUser(); limit > 0 {
+		active, err := s.repo.CountActiveJobs(ctx, req.CreatedBy)
+		if err != nil {
+			return nil, fmt.Errorf("count active export jobs: %w", err)
+		}
+		if active >= int64(limit) {
+			return nil, ErrUsageExportTooManyJobs
+		}
+	}
+
+	job := &UsageExportJob{
+		Status:     UsageExportStatusPending,
+		Format:     format,
+		Filters:    filters,
+		CreatedBy:  req.CreatedBy,
+		AdminScope: req.AdminScope,
+		Storage:    s.storage(),
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("create export job: %w", err)
+	}
+	logger.LegacyPrintf("service.usage_export", "[UsageExport] job created: job=%d operator=%d admin=%t format=%s storage=%s %s", job.ID, job.CreatedBy, job.AdminScope, job.Format, job.Storage, describeUsageExportFilters(filters))
+	return job, nil
+}
+
+// ListJobs 列出任务；createdBy 为 nil 时返回全部（管理员视图）
+func (s *UsageExportService) ListJobs(ctx context.Context, createdBy *int64, params pagination.PaginationParams) ([]UsageExportJob, *pagination.PaginationResult, error) {
+	if s == nil || s.repo == nil {
+		return nil, nil, fmt.Errorf("usage export service not ready")
+	}
+	return s.repo.ListJobs(ctx, UsageExportListFilter{CreatedBy: createdBy}, params)
+}
+
+// GetJob 获取任务；requesterID > 0 时校验任务归属（不属于本人时按不存在处理）
+func (s *UsageExportService) GetJob(ctx context.Context, id int64, requesterID int64) (*UsageExportJob, error) {
+	if s == nil || s.repo == nil {
+		return nil, fmt.Errorf("usage export service not ready")
+	}
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if requesterID > 0 && job.CreatedBy != requesterID {
+		return nil, ErrUsageExportNotFound
+	}
+	return job, nil
+}
+
+// CancelJob 取消 pending/running 任务
+func (s *UsageExportService) CancelJob(ctx context.Context, id int64, requesterID int64) error {
+	job, err := s.GetJob(ctx, id, requesterID)
+	if err != nil {
+		return err
+	}
+	if job.Status == UsageExportStatusCanceled {
+		return nil
+	}
+	if job.Status != UsageExportStatusPending && job.Status != UsageExportStatusRunning {
+		return infraerrors.Conflict("USAGE_EXPORT_CANCEL_CONFLICT", "export job cannot be canceled in current status")
+	}
+	ok, err := s.repo.CancelJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return infraerrors.Conflict("USAGE_EXPORT_CANCEL_CONFLICT", "export job cannot be canceled in current status")
+	}
+	logger.LegacyPrintf("service.usage_export", "[UsageExport] job canceled: job=%d requester=%d", id, requesterID)
+	return nil
+}
+
+// GetDownload 返回已完成任务的下载信息
+func (s *UsageExportService) GetDownload(ctx context.Context, id int64, requesterID int64) (*UsageExportDownload, error) {
+	job, err := s.GetJob(ctx, id, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != UsageExportStatusSucceeded || job.ObjectKey == "" {
+		return nil, ErrUsageExportNotReady
+	}
+	download := &UsageExportDownload{
+		FileName:    job.FileName,
+		ContentType: usageExportContentType(job.Format),
+	}
+	switch job.Storage {
+	case UsageExportStorageS3:
+		if s.backupService == nil {
+			return nil, ErrBackupS3NotConfigured
+		}
+		objectStore, _, err := s.backupService.SharedObjectStore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		url, err := objectStore.PresignURL(ctx, job.ObjectKey, usageExportPresignExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("presign export url: %w", err)
+		}
+		download.URL = url
+	default:
+		download.LocalPath = filepath.Join(s.localDir(), filepath.Clean(job.ObjectKey))
+	}
+	return download, nil
+}
+
+func (s *UsageExportService) runOnce() {
+	if s == nil || s.repo == nil || s.logReader == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+
+	s.purgeExpired(parent)
+
+	ctx, cancel := context.WithTimeout(parent, s.taskTimeout())
+	defer cancel()
+
+	job, err := s.repo.ClaimNextPendingJob(ctx, int64(s.taskTimeout().Seconds()))
+	if err != nil {
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] claim pending job failed: %v", err)
+		return
+	}
+	if job == nil {
+		slog.Debug("[UsageExport] run_once done: no_job=true")
+		return
+	}
+	logger.LegacyPrintf("service.usage_export", "[UsageExport] job claimed: job=%d format=%s storage=%s %s", job.ID, job.Format, job.Storage, describeUsageExportFilters(job.Filters))
+	s.executeJob(ctx, job)
+}
+
+func (s *UsageExportService) executeJob(ctx context.Context, job *UsageExportJob) {
+	start := time.Now()
+	dir := s.localDir()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		s.markJobFailed(job.ID, 0, fmt.Errorf("create export dir: %w", err))
+		return
+	}
+	// 被重新抢占的任务从头生成，临时文件直接覆盖
+	tmpPath := filepath.Join(dir, fmt.Sprintf(".usage_export_%d.tmp", job.ID))
+	exported, err := s.writeExportFile(ctx, job, tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		if errors.Is(err, errUsageExportCanceled) {
+			logger.LegacyPrintf("service.usage_export", "[UsageExport] job canceled during export: job=%d exported_rows=%d", job.ID, exported)
+			return
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			// 保持 running 状态，后续通过 stale reclaim 重跑
+			logger.LegacyPrintf("service.usage_export", "[UsageExport] job interrupted: job=%d err=%v", job.ID, err)
+			return
+		}
+		s.markJobFailed(job.ID, exported, err)
+		return
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		s.markJobFailed(job.ID, exported, err)
+		return
+	}
+	fileName := buildUsageExportFileName(job)
+	objectKey, err := s.storeExportFile(ctx, job, tmpPath, fileName)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		s.markJobFailed(job.ID, exported, err)
+		return
+	}
+
+	result := UsageExportResult{
+		ObjectKey:    objectKey,
+		FileName:     fileName,
+		FileSize:     info.Size(),
+		ExportedRows: exported,
+	}
+	if retention := s.retention(); retention > 0 {
+		expiresAt := time.Now().Add(retention)
+		result.ExpiresAt = &expiresAt
+	}
+	updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.MarkJobSucceeded(updateCtx, job.ID, result); err != nil {
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] update job succeeded failed: job=%d err=%v", job.ID, err)
+		return
+	}
+	logger.LegacyPrintf("service.usage_export", "[UsageExport] job succeeded: job=%d rows=%d size=%d key=%s duration=%s", job.ID, exported, info.Size(), objectKey, time.Since(start))
+}
+
+var errUsageExportCanceled = errors.New("usage export canceled")
+
+func (s *UsageExportService) writeExportFile(ctx context.Context, job *UsageExportJob, path string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return 0, fmt.Errorf("create export file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	writer, err := newUsageExportWriter(job.Format, f)
+	if err != nil {
+		return 0, err
+	}
+
+	batchSize := s.batchSize()
+	var afterID, exported int64
+	records := make([]UsageExportRecord, 0, batchSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return exported, err
+		}
+		canceled, err := s.isJobCanceled(job.ID)
+		if err != nil {
+			return exported, err
+		}
+		if canceled {
+			return exported, errUsageExportCanceled
+		}
+
+		logs, err := s.logReader.ListUsageLogsAfterID(ctx, job.Filters, afterID, batchSize)
+		if err != nil {
+			return exported, err
+		}
+		if len(logs) == 0 {
+			break
+		}
+		records = records[:0]
+		for i := range logs {
+			records = append(records, NewUsageExportRecord(&logs[i], job.AdminScope))
+		}
+		if err := writer.Write(records); err != nil {
+			return exported, fmt.Errorf("write export rows: %w", err)
+		}
+		exported += int64(len(logs))
+		afterID = logs[len(logs)-1].ID
+
+		updateCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		if err := s.repo.UpdateJobProgress(updateCtx, job.ID, exported); err != nil {
+			logger.LegacyPrintf("service.usage_export", "[UsageExport] job progress update failed: job=%d rows=%d err=%v", job.ID, exported, err)
+		}
+		cancel()
+
+		if len(logs) < batchSize {
+			break
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return exported, fmt.Errorf("finalize export file: %w", err)
+	}
+	return exported, f.Sync()
+}
+
+// storeExportFile 将临时文件落到最终位置，返回 object key（本地为相对 local_dir 的路径）
+func (s *UsageExportService) storeExportFile(ctx context.Context, job *UsageExportJob, tmpPath, fileName string) (string, error) {
+	if job.Storage == UsageExportStorageS3 {
+		if s.backupService == nil {
+			return "", ErrBackupS3NotConfigured
+		}
+		objectStore, s3Cfg, err := s.backupService.SharedObjectStore(ctx)
+		if err != nil {
+			return "", err
+		}
+		prefix := strings.TrimRight(s3Cfg.Prefix, "/")
+		if prefix == "" {
+			prefix = "backups"
+		}
+		key := fmt.Sprintf("%s/usage-exports/%d/%s", prefix, job.ID, fileName)
+		f, err := os.Open(tmpPath)
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			_ = f.Close()
+			_ = os.Remove(tmpPath)
+		}()
+		if _, err := objectStore.Upload(ctx, key, f, usageExportContentType(job.Format)); err != nil {
+			return "", fmt.Errorf("upload export file: %w", err)
+		}
+		return key, nil
+	}
+
+	key := filepath.Join(fmt.Sprintf("%d", job.ID), fileName)
+	finalPath := filepath.Join(s.localDir(), key)
+	if err := os.MkdirAll(filepath.Dir(finalPath), 0o750); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return "", fmt.Errorf("move export file: %w", err)
+	}
+	return key, nil
+}
+
+func (s *UsageExportService) purgeExpired(parent context.Context) {
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
+	defer cancel()
+	jobs, err := s.repo.ListExpiredJobs(ctx, time.Now(), usageExportExpirePurgeLimit)
+	if err != nil {
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] list expired jobs failed: %v", err)
+		return
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if err := s.deleteExportFile(ctx, job); err != nil {
+			logger.LegacyPrintf("service.usage_export", "[UsageExport] delete expired file failed: job=%d key=%s err=%v", job.ID, job.ObjectKey, err)
+			continue
+		}
+		if err := s.repo.MarkJobExpired(ctx, job.ID); err != nil {
+			logger.LegacyPrintf("service.usage_export", "[UsageExport] mark job expired failed: job=%d err=%v", job.ID, err)
+		}
+	}
+}
+
+func (s *UsageExportService) deleteExportFile(ctx context.Context, job *UsageExportJob) error {
+	if job.ObjectKey == "" {
+		return nil
+	}
+	if job.Storage == UsageExportStorageS3 {
+		if s.backupService == nil {
+			return ErrBackupS3NotConfigured
+		}
+		objectStore, _, err := s.backupService.SharedObjectStore(ctx)
+		if err != nil {
+			return err
+		}
+		return objectStore.Delete(ctx, job.ObjectKey)
+	}
+	path := filepath.Join(s.localDir(), filepath.Clean(job.ObjectKey))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = os.Remove(filepath.Dir(path))
+	return nil
+}
+
+func (s *UsageExportService) isJobCanceled(jobID int64) (bool, error) {
+	checkCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	job, err := s.repo.GetJob(checkCtx, jobID)
+	if err != nil {
+		if errors.Is(err, ErrUsageExportNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return job.Status == UsageExportStatusCanceled, nil
+}
+
+func (s *UsageExportService) markJobFailed(jobID int64, exportedRows int64, err error) {
+	msg := strings.TrimSpace(err.Error())
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	logger.LegacyPrintf("service.usage_export", "[UsageExport] job failed: job=%d rows=%d err=%s", jobID, exportedRows, msg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if updateErr := s.repo.MarkJobFailed(ctx, jobID, exportedRows, msg); updateErr != nil {
+		logger.LegacyPrintf("service.usage_export", "[UsageExport] update job failed failed: job=%d err=%v", jobID, updateErr)
+	}
+}
+
+func (s *UsageExportService) validateFilters(filters UsageExportFilters) error {
+	if filters.StartTime.IsZero() || filters.EndTime.IsZero() {
+		return infraerrors.BadRequest("USAGE_EXPORT_MISSING_RANGE", "start_date and end_date are required")
+	}
+	if filters.EndTime.Before(filters.StartTime) {
+		return infraerrors.BadRequest("USAGE_EXPORT_INVALID_RANGE", "end_date must be after start_date")
+	}
+	if maxDays := s.maxRangeDays(); maxDays > 0 {
+		if filters.EndTime.Sub(filters.StartTime) > time.Duration(maxDays)*24*time.Hour {
+			return infraerrors.BadRequest("USAGE_EXPORT_RANGE_TOO_LARGE", fmt.Sprintf("date range exceeds %d days", maxDays))
+		}
+	}
+	return nil
+}
+
+func sanitizeUsageExportFilters(filters *UsageExportFilters) {
+	if filters == nil {
+		return
+	}
+	if filters.UserID != nil && *filters.UserID <= 0 {
+		filters.UserID = nil
+	}
+	if filters.APIKeyID != nil && *filters.APIKeyID <= 0 {
+		filters.APIKeyID = nil
+	}
+	if filters.AccountID != nil && *filters.AccountID <= 0 {
+		filters.AccountID = nil
+	}
+	if filters.GroupID != nil && *filters.GroupID <= 0 {
+		filters.GroupID = nil
+	}
+	if filters.Model != nil {
+		model := strings.TrimSpace(*filters.Model)
+		if model == "" {
+			filters.Model = nil
+		} else {
+			filters.Model = &model
+		}
+	}
+}
+
+func describeUsageExportFilters(filters UsageExportFilters) string {
+	parts := []string{
+		"start=" + filters.StartTime.UTC().Format(time.RFC3339),
+		"end=" + filters.EndTime.UTC().Format(time.RFC3339),
+	}
+	if filters.UserID != nil {
+		parts = append(parts, fmt.Sprintf("user_id=%d", *filters.UserID))
+	}
+	if filters.APIKeyID != nil {
+		parts = append(parts, fmt.Sprintf("api_key_id=%d", *filters.APIKeyID))
+	}
+	if filters.AccountID != nil {
+		parts = append(parts, fmt.Sprintf("account_id=%d", *filters.AccountID))
+	}
+	if filters.GroupID != nil {
+		parts = append(parts, fmt.Sprintf("group_id=%d", *filters.GroupID))
+	}
+	if filters.Model != nil {
+		parts = append(parts, "model="+*filters.Model)
+	}
+	return strings.Join(parts, " ")
+}
+
+func buildUsageExportFileName(job *UsageExportJob) string {
+	return fmt.Sprintf("usage_%s_%s_%d.%s",
+		job.Filters.StartTime.UTC().Format("20060102"),
+		job.Filters.EndTime.UTC().Format("20060102"),
+		job.ID,
+		job.Format,
+	)
+}
+
+func (s *UsageExportService) enabled() bool {
+	return s.cfg == nil || s.cfg.UsageExport.Enabled
+}
+
+func (s *UsageExportService) storage() string {
+	if s.cfg != nil && strings.EqualFold(strings.TrimSpace(s.cfg.UsageExport.Storage), UsageExportStorageS3) {
+		return UsageExportStorageS3
+	}
+	return UsageExportStorageLocal
+}
+
+func (s *UsageExportService) localDir() string {
+	if s.cfg != nil && strings.TrimSpace(s.cfg.UsageExport.LocalDir) != "" {
+		return strings.TrimSpace(s.cfg.UsageExport.LocalDir)
+	}
+	return "./data/exports"
+}
+
+func (s *UsageExportService) maxRangeDays() int {
+	if s.cfg == nil {
+		return 93
+	}
+	return s.cfg.UsageExport.MaxRangeDays
+}
+
+func (s *UsageExportService) batchSize() int {
+	if s.cfg != nil && s.cfg.UsageExport.BatchSize > 0 {
+		return s.cfg.UsageExport.BatchSize
+	}
+	return 5000
+}
+
+func (s *UsageExportService) workerInterval() time.Duration {
+	if s.cfg != nil && s.cfg.UsageExport.WorkerIntervalSeconds > 0 {
+		return time.Duration(s.cfg.UsageExport.WorkerIntervalSeconds) * time.Second
+	}
+	return 10 * time.Second
+}
+
+func (s *UsageExportService) taskTimeout() time.Duration {
+	if s.cfg != nil && s.cfg.UsageExport.TaskTimeoutSeconds > 0 {
+		return time.Duration(s.cfg.UsageExport.TaskTimeoutSeconds) * time.Second
+	}
+	return time.Hour
+}
+
+func (s *UsageExportService) retention() time.Duration {
+	if s.cfg == nil {
+		return 72 * time.Hour
+	}
+	return time.Duration(s.cfg.UsageExport.RetentionHours) * time.Hour
+}
+
+func (s *UsageExportService) maxActiveJobsPerUser() int {
+	if s.cfg == nil {
+		return 3
+	}
+	return s.cfg.UsageExport.MaxActiveJobsPerUser
+}
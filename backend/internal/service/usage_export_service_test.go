@@ -0,0 +1,386 @@
+//go:build unit
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+)
+
+type exportRepoStub struct {
+	mu          sync.Mutex
+	created     []*UsageExportJob
+	jobs        map[int64]*UsageExportJob
+	claimQueue  []*UsageExportJob
+	activeCount int64
+	progress    []int64
+	succeeded   map[int64]UsageExportResult
+	failed      map[int64]string
+}
+
+func newExportRepoStub() *exportRepoStub {
+	return &exportRepoStub{
+		jobs:      map[int64]*UsageExportJob{},
+		succeeded: map[int64]UsageExportResult{},
+		failed:    map[int64]string{},
+	}
+}
+
+func (s *exportRepoStub) CreateJob(ctx context.Context, job *UsageExportJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = int64(len(s.created) + 1)
+	job.CreatedAt = time.Now()
+	s.created = append(s.created, job)
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *exportRepoStub) GetJob(ctx context.Context, id int64) (*UsageExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrUsageExportNotFound
+	}
+	cp := *job
+	return &cp, nil
+}
+
+func (s *exportRepoStub) ListJobs(ctx context.Context, filter UsageExportListFilter, params pagination.PaginationParams) ([]UsageExportJob, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func (s *exportRepoStub) CountActiveJobs(ctx context.Context, createdBy int64) (int64, error) {
+	return s.activeCount, nil
+}
+
+func (s *exportRepoStub) ClaimNextPendingJob(ctx context.Context, staleRunningAfterSeconds int64) (*UsageExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.claimQueue) == 0 {
+		return nil, nil
+	}
+	job := s.claimQueue[0]
+	s.claimQueue = s.claimQueue[1:]
+	job.Status = UsageExportStatusRunning
+	s.jobs[job.ID] = job
+	cp := *job
+	return &cp, nil
+}
+
+func (s *exportRepoStub) UpdateJobProgress(ctx context.Context, id int64, exportedRows int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = append(s.progress, exportedRows)
+	return nil
+}
+
+func (s *exportRepoStub) CancelJob(ctx context.Context, id int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || (job.Status != UsageExportStatusPending && job.Status != UsageExportStatusRunning) {
+		return false, nil
+	}
+	job.Status = UsageExportStatusCanceled
+	return true, nil
+}
+
+func (s *exportRepoStub) MarkJobSucceeded(ctx context.Context, id int64, result UsageExportResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.succeeded[id] = result
+	if job, ok := s.jobs[id]; ok {
+		job.Status = UsageExportStatusSucceeded
+		job.ObjectKey = result.ObjectKey
+		job.FileName = result.FileName
+	}
+	return nil
+}
+
+func (s *exportRepoStub) MarkJobFailed(ctx context.Context, id int64, exportedRows int64, errorMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed[id] = errorMsg
+	return nil
+}
+
+func (s *exportRepoStub) ListExpiredJobs(ctx context.Context, now time.Time, limit int) ([]UsageExportJob, error) {
+	return nil, nil
+}
+
+func (s *exportRepoStub) MarkJobExpired(ctx context.Context, id int64) error {
+	return nil
+}
+
+type exportLogReaderStub struct {
+	logs    []UsageLog
+	filters []UsageExportFilters
+}
+
+func (r *exportLogReaderStub) ListUsageLogsAfterID(ctx context.Context, filters UsageExportFilters, afterID int64, limit int) ([]UsageLog, error) {
+	r.filters = append(r.filters, filters)
+	out := make([]UsageLog, 0, limit)
+	for _, log := range r.logs {
+		if log.ID > afterID {
+			out = append(out, log)
+			if len(out) == limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func newUsageExportTestService(t *testing.T, repo UsageExportRepository, reader UsageLogKeysetReader) *UsageExportService {
+	t.Helper()
+	cfg := &config.Config{UsageExport: config.UsageExportConfig{
+		Enabled:              true,
+		Storage:              UsageExportStorageLocal,
+		LocalDir:             t.TempDir(),
+		MaxRangeDays:         31,
+		BatchSize:            2,
+		TaskTimeoutSeconds:   60,
+		RetentionHours:       24,
+		MaxActiveJobsPerUser: 2,
+	}}
+	svc := NewUsageExportService(repo, nil, nil, nil, cfg)
+	svc.logReader = reader
+	return svc
+}
+
+func sampleExportLogs(n int) []UsageLog {
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	logs := make([]UsageLog, 0, n)
+	for i := 1; i <= n; i++ {
+		logs = append(logs, UsageLog{
+			ID:           int64(i),
+			UserID:       7,
+			APIKeyID:     11,
+			AccountID:    99,
+			RequestID:    "req",
+			Model:        "claude-sonnet-4",
+			InputTokens:  10 * i,
+			OutputTokens: 5,
+			TotalCost:    0.0012345,
+			ActualCost:   0.001,
+			CreatedAt:    base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return logs
+}
+
+func TestUsageExportServiceCreateJobForcesUserScope(t *testing.T) {
+	repo := newExportRepoStub()
+	svc := newUsageExportTestService(t, repo, &exportLogReaderStub{})
+
+	otherUser := int64(42)
+	accountID := int64(5)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	job, err := svc.CreateJob(context.Background(), UsageExportRequest{
+		Format: "NDJSON",
+		Filters: UsageExportFilters{
+			StartTime: start,
+			EndTime:   start.AddDate(0, 0, 7),
+			UserID:    &otherUser,
+			AccountID: &accountID,
+		},
+		CreatedBy: 7,
+	})
+	require.NoError(t, err)
+	require.Equal(t, UsageExportFormatJSONL, job.Format)
+	require.Equal(t, UsageExportStatusPending, job.Status)
+	require.NotNil(t, job.Filters.UserID)
+	require.Equal(t, int64(7), *job.Filters.UserID)
+	require.Nil(t, job.Filters.AccountID)
+	require.False(t, job.AdminScope)
+}
+
+func TestUsageExportServiceCreateJobValidation(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		req    UsageExportRequest
+		active int64
+		status int
+		reason string
+	}{
+		{
+			name:   "invalid format",
+			req:    UsageExportRequest{Format: "xlsx", Filters: UsageExportFilters{StartTime: start, EndTime: start.Add(time.Hour)}, CreatedBy: 1},
+			status: http.StatusBadRequest,
+			reason: "USAGE_EXPORT_INVALID_FORMAT",
+		},
+		{
+			name:   "missing range",
+			req:    UsageExportRequest{Format: "csv", CreatedBy: 1},
+			status: http.StatusBadRequest,
+			reason: "USAGE_EXPORT_MISSING_RANGE",
+		},
+		{
+			name:   "range too large",
+			req:    UsageExportRequest{Format: "csv", Filters: UsageExportFilters{StartTime: start, EndTime: start.AddDate(0, 0, 40)}, CreatedBy: 1},
+			status: http.StatusBadRequest,
+			reason: "USAGE_EXPORT_RANGE_TOO_LARGE",
+		},
+		{
+			name:   "too many active jobs",
+			req:    UsageExportRequest{Format: "csv", Filters: UsageExportFilters{StartTime: start, EndTime: start.Add(time.Hour)}, CreatedBy: 1},
+			active: 2,
+			status: http.StatusTooManyRequests,
+			reason: "USAGE_EXPORT_TOO_MANY_JOBS",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newExportRepoStub()
+			repo.activeCount = tc.active
+			svc := newUsageExportTestService(t, repo, &exportLogReaderStub{})
+			_, err := svc.CreateJob(context.Background(), tc.req)
+			require.Error(t, err)
+			require.Equal(t, tc.status, infraerrors.Code(err))
+			require.Equal(t, tc.reason, infraerrors.Reason(err))
+			require.Empty(t, repo.created)
+		})
+	}
+}
+
+func TestUsageExportServiceGetJobHidesOtherUsersJobs(t *testing.T) {
+	repo := newExportRepoStub()
+	repo.jobs[1] = &UsageExportJob{ID: 1, CreatedBy: 7, Status: UsageExportStatusSucceeded}
+	svc := newUsageExportTestService(t, repo, &exportLogReaderStub{})
+
+	_, err := svc.GetJob(context.Background(), 1, 8)
+	require.ErrorIs(t, err, ErrUsageExportNotFound)
+
+	job, err := svc.GetJob(context.Background(), 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), job.CreatedBy)
+}
+
+func TestUsageExportServiceRunOnceWritesLocalCSV(t *testing.T) {
+	repo := newExportRepoStub()
+	reader := &exportLogReaderStub{logs: sampleExportLogs(5)}
+	svc := newUsageExportTestService(t, repo, reader)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	userID := int64(7)
+	job := &UsageExportJob{
+		ID:        3,
+		Status:    UsageExportStatusPending,
+		Format:    UsageExportFormatCSV,
+		Filters:   UsageExportFilters{StartTime: start, EndTime: start.AddDate(0, 0, 2), UserID: &userID},
+		CreatedBy: 7,
+		Storage:   UsageExportStorageLocal,
+	}
+	repo.jobs[job.ID] = job
+	repo.claimQueue = []*UsageExportJob{job}
+
+	svc.runOnce()
+
+	require.Empty(t, repo.failed)
+	result, ok := repo.succeeded[job.ID]
+	require.True(t, ok)
+	require.Equal(t, int64(5), result.ExportedRows)
+	require.Equal(t, "usage_20260101_20260103_3.csv", result.FileName)
+	require.NotNil(t, result.ExpiresAt)
+	require.Equal(t, []int64{2, 4, 5}, repo.progress)
+
+	download, err := svc.GetDownload(context.Background(), job.ID, 7)
+	require.NoError(t, err)
+	require.Empty(t, download.URL)
+	require.Equal(t, filepath.Join(svc.localDir(), result.ObjectKey), download.LocalPath)
+
+	data, err := os.ReadFile(download.LocalPath)
+	require.NoError(t, err)
+	require.Equal(t, result.FileSize, int64(len(data)))
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	require.Equal(t, usageExportCSVHeader, rows[0])
+	require.Equal(t, "1", rows[1][0])
+	require.Equal(t, "", rows[1][5], "account_id must be hidden for user exports")
+	require.Equal(t, "0.0012345000", rows[1][20])
+}
+
+func TestUsageExportServiceRunOnceStopsWhenCanceled(t *testing.T) {
+	repo := newExportRepoStub()
+	reader := &exportLogReaderStub{logs: sampleExportLogs(3)}
+	svc := newUsageExportTestService(t, repo, reader)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	job := &UsageExportJob{
+		ID:      1,
+		Status:  UsageExportStatusPending,
+		Format:  UsageExportFormatJSONL,
+		Filters: UsageExportFilters{StartTime: start, EndTime: start.AddDate(0, 0, 1)},
+		Storage: UsageExportStorageLocal,
+	}
+	// 执行过程中任务被取消：持久层状态为 canceled，而 worker 持有的是抢占时的 running 副本
+	canceled := *job
+	canceled.Status = UsageExportStatusCanceled
+	repo.jobs[job.ID] = &canceled
+	claim := *job
+	claim.Status = UsageExportStatusRunning
+
+	svc.executeJob(context.Background(), &claim)
+
+	require.Empty(t, repo.succeeded)
+	require.Empty(t, repo.failed)
+	require.Empty(t, reader.filters)
+	entries, err := os.ReadDir(svc.localDir())
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestUsageExportWriterJSONLAndParquet(t *testing.T) {
+	logs := sampleExportLogs(3)
+	records := make([]UsageExportRecord, 0, len(logs))
+	for i := range logs {
+		records = append(records, NewUsageExportRecord(&logs[i], true))
+	}
+
+	var jsonlBuf bytes.Buffer
+	w, err := newUsageExportWriter(UsageExportFormatJSONL, &jsonlBuf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(records))
+	require.NoError(t, w.Close())
+
+	scanner := bufio.NewScanner(&jsonlBuf)
+	var lines int
+	for scanner.Scan() {
+		var rec UsageExportRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		require.NotNil(t, rec.AccountID)
+		require.Equal(t, int64(99), *rec.AccountID)
+		lines++
+	}
+	require.Equal(t, 3, lines)
+
+	var parquetBuf bytes.Buffer
+	w, err = newUsageExportWriter(UsageExportFormatParquet, &parquetBuf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(records[:2]))
+	require.NoError(t, w.Write(records[2:]))
+	require.NoError(t, w.Close())
+
+	rows, err := parquet.Read[UsageExportRecord](bytes.NewReader(parquetBuf.Bytes()), int64(parquetBuf.Len()))
+	require.NoError(t, err)
+	require.Equal(t, records, rows)
+}
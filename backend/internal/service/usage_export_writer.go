@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// UsageExportRecord 导出文件中的一行，CSV/JSONL/Parquet 共用同一列定义。
+// 账号维度字段（account_id/account_rate_multiplier）仅在管理员导出中填充。
+type UsageExportRecord struct {
+	ID                    int64    `json:"id" parquet:"id"`
+	CreatedAt             string   `json:"created_at" parquet:"created_at"`
+	RequestID             string   `json:"request_id" parquet:"request_id"`
+	UserID                int64    `json:"user_id" parquet:"user_id"`
+	APIKeyID              int64    `json:"api_key_id" parquet:"api_key_id"`
+	AccountID             *int64   `json:"account_id,omitempty" parquet:"account_id,optional"`
+	GroupID               *int64   `json:"group_id,omitempty" parquet:"group_id,optional"`
+	SubscriptionID        *int64   `json:"subscription_id,omitempty" parquet:"subscription_id,optional"`
+	Model                 string   `json:"model" parquet:"model"`
+	RequestType           string   `json:"request_type" parquet:"request_type"`
+	BillingType           int32    `json:"billing_type" parquet:"billing_type"`
+	InputTokens           int64    `json:"input_tokens" parquet:"input_tokens"`
+	OutputTokens          int64    `json:"output_tokens" parquet:"output_tokens"`
+	CacheCreationTokens   int64    `json:"cache_creation_tokens" parquet:"cache_creation_tokens"`
+	CacheReadTokens       int64    `json:"cache_read_tokens" parquet:"cache_read_tokens"`
+	ImageCount            int64    `json:"image_count" parquet:"image_count"`
+	InputCost             float64  `json:"input_cost" parquet:"input_cost"`
+	OutputCost            float64  `json:"output_cost" parquet:"output_cost"`
+	CacheCreationCost     float64  `json:"cache_creation_cost" parquet:"cache_creation_cost"`
+	CacheReadCost         float64  `json:"cache_read_cost" parquet:"cache_read_cost"`
+	TotalCost             float64  `json:"total_cost" parquet:"total_cost"`
+	ActualCost            float64  `json:"actual_cost" parquet:"actual_cost"`
+	RateMultiplier        float64  `json:"rate_multiplier" parquet:"rate_multiplier"`
+	AccountRateMultiplier *float64 `json:"account_rate_multiplier,omitempty" parquet:"account_rate_multiplier,optional"`
+	DurationMs            *int64   `json:"duration_ms,omitempty" parquet:"duration_ms,optional"`
+	FirstTokenMs          *int64   `json:"first_token_ms,omitempty" parquet:"first_token_ms,optional"`
+	InboundEndpoint       string   `json:"inbound_endpoint,omitempty" parquet:"inbound_endpoint"`
+}
+
+var usageExportCSVHeader = []string{
+	"id", "created_at", "request_id", "user_id", "api_key_id", "account_id", "group_id", "subscription_id",
+	"model", "request_type", "billing_type",
+	"input_tokens", "output_tokens", "cache_creation_tokens", "cache_read_tokens", "image_count",
+	"input_cost", "output_cost", "cache_creation_cost", "cache_read_cost", "total_cost", "actual_cost",
+	"rate_multiplier", "account_rate_multiplier", "duration_ms", "first_token_ms", "inbound_endpoint",
+}
+
+// NewUsageExportRecord 将 UsageLog 转换为导出行；includeAccount=false 时隐藏上游账号信息
+func NewUsageExportRecord(log *UsageLog, includeAccount bool) UsageExportRecord {
+	rec := UsageExportRecord{
+		ID:                  log.ID,
+		CreatedAt:           log.CreatedAt.UTC().Format(time.RFC3339),
+		RequestID:           log.RequestID,
+		UserID:              log.UserID,
+		APIKeyID:            log.APIKeyID,
+		GroupID:             log.GroupID,
+		SubscriptionID:      log.SubscriptionID,
+		Model:               log.Model,
+		RequestType:         log.EffectiveRequestType().String(),
+		BillingType:         int32(log.BillingType),
+		InputTokens:         int64(log.InputTokens),
+		OutputTokens:        int64(log.OutputTokens),
+		CacheCreationTokens: int64(log.CacheCreationTokens),
+		CacheReadTokens:     int64(log.CacheReadTokens),
+		ImageCount:          int64(log.ImageCount),
+		InputCost:           log.InputCost,
+		OutputCost:          log.OutputCost,
+		CacheCreationCost:   log.CacheCreationCost,
+		CacheReadCost:       log.CacheReadCost,
+		TotalCost:           log.TotalCost,
+		ActualCost:          log.ActualCost,
+		RateMultiplier:      log.RateMultiplier,
+	}
+	if includeAccount {
+		accountID := log.AccountID
+		rec.AccountID = &accountID
+		rec.AccountRateMultiplier = log.AccountRateMultiplier
+	}
+	if log.DurationMs != nil {
+		v := int64(*log.DurationMs)
+		rec.DurationMs = &v
+	}
+	if log.FirstTokenMs != nil {
+		v := int64(*log.FirstTokenMs)
+		rec.FirstTokenMs = &v
+	}
+	if log.InboundEndpoint != nil {
+		rec.InboundEndpoint = *log.InboundEndpoint
+	}
+	return rec
+}
+
+// usageExportWriter 按格式写出导出行；Close 负责 flush 尾部数据（不关闭底层 io.Writer）
+type usageExportWriter interface {
+	Write(records []UsageExportRecord) error
+	Close() error
+}
+
+func newUsageExportWriter(format string, w io.Writer) (usageExportWriter, error) {
+	switch format {
+	case UsageExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(usageExportCSVHeader); err != nil {
+			return nil, err
+		}
+		return &usageExportCSVWriter{w: cw}, nil
+	case UsageExportFormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &usageExportJSONLWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case UsageExportFormatParquet:
+		return &usageExportParquetWriter{w: parquet.NewGenericWriter[UsageExportRecord](w, parquet.Compression(&parquet.Zstd))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func usageExportContentType(format string) string {
+	switch format {
+	case UsageExportFormatCSV:
+		return "text/csv"
+	case UsageExportFormatJSONL:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+type usageExportCSVWriter struct {
+	w *csv.Writer
+}
+
+func (cw *usageExportCSVWriter) Write(records []UsageExportRecord) error {
+	for i := range records {
+		r := &records[i]
+		row := []string{
+			strconv.FormatInt(r.ID, 10),
+			r.CreatedAt,
+			r.RequestID,
+			strconv.FormatInt(r.UserID, 10),
+			strconv.FormatInt(r.APIKeyID, 10),
+			formatOptionalInt64(r.AccountID),
+			formatOptionalInt64(r.GroupID),
+			formatOptionalInt64(r.SubscriptionID),
+			r.Model,
+			r.RequestType,
+			strconv.FormatInt(int64(r.BillingType), 10),
+			strconv.FormatInt(r.InputTokens, 10),
+			strconv.FormatInt(r.OutputTokens, 10),
+			strconv.FormatInt(r.CacheCreationTokens, 10),
+			strconv.FormatInt(r.CacheReadTokens, 10),
+			strconv.FormatInt(r.ImageCount, 10),
+			formatExportCost(r.InputCost),
+			formatExportCost(r.OutputCost),
+			formatExportCost(r.CacheCreationCost),
+			formatExportCost(r.CacheReadCost),
+			formatExportCost(r.TotalCost),
+			formatExportCost(r.ActualCost),
+			strconv.FormatFloat(r.RateMultiplier, 'f', -1, 64),
+			formatOptionalFloat64(r.AccountRateMultiplier),
+			formatOptionalInt64(r.DurationMs),
+			formatOptionalInt64(r.FirstTokenMs),
+			r.InboundEndpoint,
+		}
+		if err := cw.w.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *usageExportCSVWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type usageExportJSONLWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (jw *usageExportJSONLWriter) Write(records []UsageExportRecord) error {
+	for i := range records {
+		if err := jw.enc.Encode(&records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (jw *usageExportJSONLWriter) Close() error {
+	return jw.w.Flush()
+}
+
+type usageExportParquetWriter struct {
+	w *parquet.GenericWriter[UsageExportRecord]
+}
+
+func (pw *usageExportParquetWriter) Write(records []UsageExportRecord) error {
+	_, err := pw.w.Write(records)
+	return err
+}
+
+func (pw *usageExportParquetWriter) Close() error {
+	return pw.w.Close()
+}
+
+func formatOptionalInt64(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+func formatOptionalFloat64(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// formatExportCost 成本保留 10 位小数，与 usage_logs 的 DECIMAL(20,10) 精度一致
+func formatExportCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 10, 64)
+}
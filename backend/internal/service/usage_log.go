@@ -98,6 +98,9 @@ type UsageLog struct {
 	AccountID int64
 	RequestID string
 	Model     string
+	// ServedModel is the model that actually served the request when a group
+	// model fallback chain kicked in. Nil means Model was served directly.
+	ServedModel *string
 	// ServiceTier records the OpenAI service tier used for billing, e.g. "priority" / "flex".
 	ServiceTier *string
 	// ReasoningEffort is the request's reasoning effort level.
@@ -108,6 +111,9 @@ type UsageLog struct {
 	InboundEndpoint *string
 	// UpstreamEndpoint is the normalized upstream endpoint path, e.g. /v1/responses.
 	UpstreamEndpoint *string
+	// SessionID is the sticky session (conversation) key the request was routed with.
+	// Nil means the request carried no session identifier.
+	SessionID *string
 
 	GroupID        *int64
 	SubscriptionID *int64
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	UsageLogArchiveStatusArchived  = "archived"
+	UsageLogArchiveStatusFailed    = "failed"
+	UsageLogArchiveStatusRestoring = "restoring"
+	UsageLogArchiveStatusRestored  = "restored"
+)
+
+// NormalizeUsageLogArchiveFormat 规范化归档格式（parquet / jsonl），非法值返回空字符串。
+// jsonl 归档文件始终以 gzip 压缩。
+func NormalizeUsageLogArchiveFormat(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case UsageExportFormatParquet:
+		return UsageExportFormatParquet
+	case UsageExportFormatJSONL, "ndjson", "jsonl.gz":
+		return UsageExportFormatJSONL
+	default:
+		return ""
+	}
+}
+
+// UsageLogPartition 表示 usage_logs 的一个范围分区，区间为 [RangeStart, RangeEnd)。
+// RangeStart 为 nil 表示下界为 MINVALUE（迁移时由原表转换而来的 legacy 分区）。
+type UsageLogPartition struct {
+	Name          string     `json:"name"`
+	RangeStart    *time.Time `json:"range_start,omitempty"`
+	RangeEnd      time.Time  `json:"range_end"`
+	EstimatedRows int64      `json:"estimated_rows"`
+	SizeBytes     int64      `json:"size_bytes"`
+}
+
+// IsLegacy 是否为无下界的 legacy 分区
+func (p *UsageLogPartition) IsLegacy() bool {
+	return p.RangeStart == nil
+}
+
+// UsageLogArchive 表示一个月份的 usage_logs 归档文件。
+// 状态：archived（已归档，分区可删除）/ failed（导出失败，下次维护重试）/
+// restoring（正在重新导入）/ restored（已导回数据库，保留期清理跳过该月份）。
+type UsageLogArchive struct {
+	ID            int64      `json:"id"`
+	RangeStart    time.Time  `json:"range_start"`
+	RangeEnd      time.Time  `json:"range_end"`
+	PartitionName string     `json:"partition_name"`
+	Status        string     `json:"status"`
+	Format        string     `json:"format"`
+	Storage       string     `json:"storage"`
+	ObjectKey     string     `json:"-"`
+	FileName      string     `json:"file_name"`
+	FileSize      int64      `json:"file_size"`
+	RowCount      int64      `json:"row_count"`
+	Checksum      string     `json:"checksum"`
+	ErrorMsg      *string    `json:"error_message,omitempty"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
+	RestoredAt    *time.Time `json:"restored_at,omitempty"`
+	RestoredRows  int64      `json:"restored_rows"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// UsageLogArchiveRecordFilters 查询归档文件内容的过滤条件，均为精确匹配
+type UsageLogArchiveRecordFilters struct {
+	UserID    *int64
+	APIKeyID  *int64
+	AccountID *int64
+	GroupID   *int64
+	Model     string
+	RequestID string
+	SessionID string
+}
+
+// UsageLogPartitionRepository 定义 usage_logs 分区维护与归档记录的持久层接口
+type UsageLogPartitionRepository interface {
+	IsUsageLogsPartitioned(ctx context.Context) (bool, error)
+	ListUsageLogPartitions(ctx context.Context) ([]UsageLogPartition, error)
+	// EnsureUsageLogPartition 创建 month 所在月份的分区；该范围已被其他分区（如 legacy）覆盖时忽略
+	EnsureUsageLogPartition(ctx context.Context, month time.Time) error
+	DropUsageLogPartition(ctx context.Context, name string) error
+	// MinUsageLogCreatedAt 返回指定分区中最早的 created_at，分区为空时返回 nil
+	MinUsageLogCreatedAt(ctx context.Context, partition string) (*time.Time, error)
+	// DeleteUsageLogsRange 分批删除 [start, end) 的记录（legacy 分区无法按月整体删除）
+	DeleteUsageLogsRange(ctx context.Context, start, end time.Time) (int64, error)
+	// ImportUsageLogs 批量写回归档记录（按 request_id + api_key_id 去重），返回实际写入行数
+	ImportUsageLogs(ctx context.Context, logs []UsageLog) (int64, error)
+
+	// UpsertArchive 按 range_start 写入或覆盖归档记录
+	UpsertArchive(ctx context.Context, archive *UsageLogArchive) error
+	GetArchive(ctx context.Context, id int64) (*UsageLogArchive, error)
+	// GetArchiveByRangeStart 查找月份的归档记录，不存在时返回 nil, nil
+	GetArchiveByRangeStart(ctx context.Context, rangeStart time.Time) (*UsageLogArchive, error)
+	ListArchives(ctx context.Context, params pagination.PaginationParams) ([]UsageLogArchive, *pagination.PaginationResult, error)
+	// TransitionArchiveStatus 仅当当前状态为 from 时切换为 to
+	TransitionArchiveStatus(ctx context.Context, id int64, from, to string) (bool, error)
+	MarkArchiveRestored(ctx context.Context, id int64, restoredRows int64) error
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// UsageLogArchiveRecord 归档文件中的一行。与 UsageExportRecord 不同，归档保留 usage_logs 的全部列，
+// 以便原样重新导入；Parquet 与 JSONL 共用同一列定义。
+type UsageLogArchiveRecord struct {
+	ID                    int64     `json:"id" parquet:"id"`
+	CreatedAt             time.Time `json:"created_at" parquet:"created_at,timestamp(microsecond)"`
+	UserID                int64     `json:"user_id" parquet:"user_id"`
+	APIKeyID              int64     `json:"api_key_id" parquet:"api_key_id"`
+	AccountID             int64     `json:"account_id" parquet:"account_id"`
+	RequestID             string    `json:"request_id" parquet:"request_id"`
+	Model                 string    `json:"model" parquet:"model"`
+	GroupID               *int64    `json:"group_id,omitempty" parquet:"group_id,optional"`
+	SubscriptionID        *int64    `json:"subscription_id,omitempty" parquet:"subscription_id,optional"`
+	InputTokens           int64     `json:"input_tokens" parquet:"input_tokens"`
+	OutputTokens          int64     `json:"output_tokens" parquet:"output_tokens"`
+	CacheCreationTokens   int64     `json:"cache_creation_tokens" parquet:"cache_creation_tokens"`
+	CacheReadTokens       int64     `json:"cache_read_tokens" parquet:"cache_read_tokens"`
+	CacheCreation5mTokens int64     `json:"cache_creation_5m_tokens" parquet:"cache_creation_5m_tokens"`
+	CacheCreation1hTokens int64     `json:"cache_creation_1h_tokens" parquet:"cache_creation_1h_tokens"`
+	InputCost             float64   `json:"input_cost" parquet:"input_cost"`
+	OutputCost            float64   `json:"output_cost" parquet:"output_cost"`
+	CacheCreationCost     float64   `json:"cache_creation_cost" parquet:"cache_creation_cost"`
+	CacheReadCost         float64   `json:"cache_read_cost" parquet:"cache_read_cost"`
+	TotalCost             float64   `json:"total_cost" parquet:"total_cost"`
+	ActualCost            float64   `json:"actual_cost" parquet:"actual_cost"`
+	RateMultiplier        float64   `json:"rate_multiplier" parquet:"rate_multiplier"`
+	AccountRateMultiplier *float64  `json:"account_rate_multiplier,omitempty" parquet:"account_rate_multiplier,optional"`
+	BillingType           int32     `json:"billing_type" parquet:"billing_type"`
+	RequestType           int32     `json:"request_type" parquet:"request_type"`
+	Stream                bool      `json:"stream" parquet:"stream"`
+	OpenAIWSMode          bool      `json:"openai_ws_mode" parquet:"openai_ws_mode"`
+	DurationMs            *int64    `json:"duration_ms,omitempty" parquet:"duration_ms,optional"`
+	FirstTokenMs          *int64    `json:"first_token_ms,omitempty" parquet:"first_token_ms,optional"`
+	UserAgent             *string   `json:"user_agent,omitempty" parquet:"user_agent,optional"`
+	IPAddress             *string   `json:"ip_address,omitempty" parquet:"ip_address,optional"`
+	ImageCount            int64     `json:"image_count" parquet:"image_count"`
+	ImageSize             *string   `json:"image_size,omitempty" parquet:"image_size,optional"`
+	MediaType             *string   `json:"media_type,omitempty" parquet:"media_type,optional"`
+	ServiceTier           *string   `json:"service_tier,omitempty" parquet:"service_tier,optional"`
+	ReasoningEffort       *string   `json:"reasoning_effort,omitempty" parquet:"reasoning_effort,optional"`
+	InboundEndpoint       *string   `json:"inbound_endpoint,omitempty" parquet:"inbound_endpoint,optional"`
+	UpstreamEndpoint      *string   `json:"upstream_endpoint,omitempty" parquet:"upstream_endpoint,optional"`
+	SessionID             *string   `json:"session_id,omitempty" parquet:"session_id,optional"`
+	ServedModel           *string   `json:"served_model,omitempty" parquet:"served_model,optional"`
+	CacheTTLOverridden    bool      `json:"cache_ttl_overridden" parquet:"cache_ttl_overridden"`
+}
+
+// NewUsageLogArchiveRecord 将 UsageLog 转换为归档行（created_at 按 UTC 微秒精度存储，与 TIMESTAMPTZ 一致）
+func NewUsageLogArchiveRecord(log *UsageLog) UsageLogArchiveRecord {
+	return UsageLogArchiveRecord{
+		ID:                    log.ID,
+		CreatedAt:             log.CreatedAt.UTC().Truncate(time.Microsecond),
+		UserID:                log.UserID,
+		APIKeyID:              log.APIKeyID,
+		AccountID:             log.AccountID,
+		RequestID:             log.RequestID,
+		Model:                 log.Model,
+		GroupID:               log.GroupID,
+		SubscriptionID:        log.SubscriptionID,
+		InputTokens:           int64(log.InputTokens),
+		OutputTokens:          int64(log.OutputTokens),
+		CacheCreationTokens:   int64(log.CacheCreationTokens),
+		CacheReadTokens:       int64(log.CacheReadTokens),
+		CacheCreation5mTokens: int64(log.CacheCreation5mTokens),
+		CacheCreation1hTokens: int64(log.CacheCreation1hTokens),
+		InputCost:             log.InputCost,
+		OutputCost:            log.OutputCost,
+		CacheCreationCost:     log.CacheCreationCost,
+		CacheReadCost:         log.CacheReadCost,
+		TotalCost:             log.TotalCost,
+		ActualCost:            log.ActualCost,
+		RateMultiplier:        log.RateMultiplier,
+		AccountRateMultiplier: log.AccountRateMultiplier,
+		BillingType:           int32(log.BillingType),
+		RequestType:           int32(log.RequestType),
+		Stream:                log.Stream,
+		OpenAIWSMode:          log.OpenAIWSMode,
+		DurationMs:            intPtrToInt64(log.DurationMs),
+		FirstTokenMs:          intPtrToInt64(log.FirstTokenMs),
+		UserAgent:             log.UserAgent,
+		IPAddress:             log.IPAddress,
+		ImageCount:            int64(log.ImageCount),
+		ImageSize:             log.ImageSize,
+		MediaType:             log.MediaType,
+		ServiceTier:           log.ServiceTier,
+		ReasoningEffort:       log.ReasoningEffort,
+		InboundEndpoint:       log.InboundEndpoint,
+		UpstreamEndpoint:      log.UpstreamEndpoint,
+		SessionID:             log.SessionID,
+		ServedModel:           log.ServedModel,
+		CacheTTLOverridden:    log.CacheTTLOverridden,
+	}
+}
+
+// ToUsageLog 还原为 UsageLog（重新导入时使用，id 由数据库重新分配）
+func (r *UsageLogArchiveRecord) ToUsageLog() UsageLog {
+	return UsageLog{
+		ID:                    r.ID,
+		CreatedAt:             r.CreatedAt.UTC(),
+		UserID:                r.UserID,
+		APIKeyID:              r.APIKeyID,
+		AccountID:             r.AccountID,
+		RequestID:             r.RequestID,
+		Model:                 r.Model,
+		GroupID:               r.GroupID,
+		SubscriptionID:        r.SubscriptionID,
+		InputTokens:           int(r.InputTokens),
+		OutputTokens:          int(r.OutputTokens),
+		CacheCreationTokens:   int(r.CacheCreationTokens),
+		CacheReadTokens:       int(r.CacheReadTokens),
+		CacheCreation5mTokens: int(r.CacheCreation5mTokens),
+		CacheCreation1hTokens: int(r.CacheCreation1hTokens),
+		InputCost:             r.InputCost,
+		OutputCost:            r.OutputCost,
+		CacheCreationCost:     r.CacheCreationCost,
+		CacheReadCost:         r.CacheReadCost,
+		TotalCost:             r.TotalCost,
+		ActualCost:            r.ActualCost,
+		RateMultiplier:        r.RateMultiplier,
+		AccountRateMultiplier: r.AccountRateMultiplier,
+		BillingType:           int8(r.BillingType),
+		RequestType:           RequestTypeFromInt16(int16(r.RequestType)),
+		Stream:                r.Stream,
+		OpenAIWSMode:          r.OpenAIWSMode,
+		DurationMs:            int64PtrToInt(r.DurationMs),
+		FirstTokenMs:          int64PtrToInt(r.FirstTokenMs),
+		UserAgent:             r.UserAgent,
+		IPAddress:             r.IPAddress,
+		ImageCount:            int(r.ImageCount),
+		ImageSize:             r.ImageSize,
+		MediaType:             r.MediaType,
+		ServiceTier:           r.ServiceTier,
+		ReasoningEffort:       r.ReasoningEffort,
+		InboundEndpoint:       r.InboundEndpoint,
+		UpstreamEndpoint:      r.UpstreamEndpoint,
+		SessionID:             r.SessionID,
+		ServedModel:           r.ServedModel,
+		CacheTTLOverridden:    r.CacheTTLOverridden,
+	}
+}
+
+func (r *UsageLogArchiveRecord) matches(filters UsageLogArchiveRecordFilters) bool {
+	if filters.UserID != nil && r.UserID != *filters.UserID {
+		return false
+	}
+	if filters.APIKeyID != nil && r.APIKeyID != *filters.APIKeyID {
+		return false
+	}
+	if filters.AccountID != nil && r.AccountID != *filters.AccountID {
+		return false
+	}
+	if filters.GroupID != nil && (r.GroupID == nil || *r.GroupID != *filters.GroupID) {
+		return false
+	}
+	if filters.Model != "" && r.Model != filters.Model {
+		return false
+	}
+	if filters.RequestID != "" && r.RequestID != filters.RequestID {
+		return false
+	}
+	if filters.SessionID != "" && (r.SessionID == nil || *r.SessionID != filters.SessionID) {
+		return false
+	}
+	return true
+}
+
+func intPtrToInt64(v *int) *int64 {
+	if v == nil {
+		return nil
+	}
+	out := int64(*v)
+	return &out
+}
+
+func int64PtrToInt(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+	out := int(*v)
+	return &out
+}
+
+// usageLogArchiveFileExt 归档文件扩展名；jsonl 始终 gzip 压缩，parquet 使用内建 zstd 压缩
+func usageLogArchiveFileExt(format string) string {
+	if format == UsageExportFormatJSONL {
+		return "jsonl.gz"
+	}
+	return "parquet"
+}
+
+func usageLogArchiveContentType(format string) string {
+	if format == UsageExportFormatJSONL {
+		return "application/gzip"
+	}
+	return usageExportContentType(UsageExportFormatParquet)
+}
+
+// usageLogArchiveWriter 按格式写出归档行；Close 负责 flush 尾部数据（不关闭底层 io.Writer）
+type usageLogArchiveWriter interface {
+	Write(records []UsageLogArchiveRecord) error
+	Close() error
+}
+
+func newUsageLogArchiveWriter(format string, w io.Writer) (usageLogArchiveWriter, error) {
+	switch format {
+	case UsageExportFormatJSONL:
+		gz := gzip.NewWriter(w)
+		bw := bufio.NewWriter(gz)
+		return &usageLogArchiveJSONLWriter{gz: gz, w: bw, enc: json.NewEncoder(bw)}, nil
+	case UsageExportFormatParquet:
+		return &usageLogArchiveParquetWriter{w: parquet.NewGenericWriter[UsageLogArchiveRecord](w, parquet.Compression(&parquet.Zstd))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+type usageLogArchiveJSONLWriter struct {
+	gz  *gzip.Writer
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (jw *usageLogArchiveJSONLWriter) Write(records []UsageLogArchiveRecord) error {
+	for i := range records {
+		if err := jw.enc.Encode(&records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (jw *usageLogArchiveJSONLWriter) Close() error {
+	if err := jw.w.Flush(); err != nil {
+		return err
+	}
+	return jw.gz.Close()
+}
+
+type usageLogArchiveParquetWriter struct {
+	w *parquet.GenericWriter[UsageLogArchiveRecord]
+}
+
+func (pw *usageLogArchiveParquetWriter) Write(records []UsageLogArchiveRecord) error {
+	_, err := pw.w.Write(records)
+	return err
+}
+
+func (pw *usageLogArchiveParquetWriter) Close() error {
+	return pw.w.Close()
+}
+
+// usageLogArchiveReader 顺序读取归档行；Read 在数据读完时返回 io.EOF（可能同时返回最后一批数据）
+type usageLogArchiveReader interface {
+	Read(records []UsageLogArchiveRecord) (int, error)
+	Close() error
+}
+
+// newUsageLogArchiveReader 打开归档文件；parquet 需要随机读取，因此要求传入 *os.File 等 io.ReaderAt
+func newUsageLogArchiveReader(format string, r io.ReaderAt, size int64) (usageLogArchiveReader, error) {
+	switch format {
+	case UsageExportFormatJSONL:
+		gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("open archive: %w", err)
+		}
+		return &usageLogArchiveJSONLReader{gz: gz, dec: json.NewDecoder(bufio.NewReader(gz))}, nil
+	case UsageExportFormatParquet:
+		f, err := parquet.OpenFile(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("open archive: %w", err)
+		}
+		return &usageLogArchiveParquetReader{r: parquet.NewGenericReader[UsageLogArchiveRecord](f)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+type usageLogArchiveJSONLReader struct {
+	gz  *gzip.Reader
+	dec *json.Decoder
+}
+
+func (jr *usageLogArchiveJSONLReader) Read(records []UsageLogArchiveRecord) (int, error) {
+	n := 0
+	for n < len(records) {
+		records[n] = UsageLogArchiveRecord{}
+		if err := jr.dec.Decode(&records[n]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, io.EOF
+			}
+			return n, fmt.Errorf("decode archive row: %w", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (jr *usageLogArchiveJSONLReader) Close() error {
+	return jr.gz.Close()
+}
+
+type usageLogArchiveParquetReader struct {
+	r *parquet.GenericReader[UsageLogArchiveRecord]
+}
+
+func (pr *usageLogArchiveParquetReader) Read(records []UsageLogArchiveRecord) (int, error) {
+	return pr.r.Read(records)
+}
+
+func (pr *usageLogArchiveParquetReader) Close() error {
+	return pr.r.Close()
+}
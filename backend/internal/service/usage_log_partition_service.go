@@ -0,0 +1,749 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	usageLogPartitionWorkerName = "usage_log_partition_maintenance"
+	usageLogPartitionLockKey    = "usage_logs:partition:maintenance"
+	// usageLogLegacyMonthsPerRun legacy 分区每次维护最多清理的月份数，避免单次运行过久
+	usageLogLegacyMonthsPerRun = 3
+	usageLogArchiveReadBatch   = 1000
+)
+
+var (
+	ErrUsageLogArchiveNotFound      = infraerrors.NotFound("USAGE_LOG_ARCHIVE_NOT_FOUND", "usage log archive not found")
+	ErrUsageLogArchiveNotRestorable = infraerrors.Conflict("USAGE_LOG_ARCHIVE_NOT_RESTORABLE", "only archived months can be restored")
+	ErrUsageLogArchiveNotRestored   = infraerrors.Conflict("USAGE_LOG_ARCHIVE_NOT_RESTORED", "archive has not been restored")
+	ErrUsageLogArchiveUnavailable   = infraerrors.New(http.StatusServiceUnavailable, "USAGE_LOG_ARCHIVE_UNAVAILABLE", "usage log archive service unavailable")
+)
+
+// UsageLogPartitionService 维护 usage_logs 的月度分区：预创建未来分区，
+// 过期分区按配置先导出归档（Parquet / JSONL.gz，本地或 S3）再删除，并支持查询与重新导入归档。
+//
+// 迁移生成的 usage_logs_legacy 分区（下界 MINVALUE）无法整体删除，按月导出后分批 DELETE，
+// 清空且上界早于保留期时再删除该分区。
+type UsageLogPartitionService struct {
+	repo          UsageLogPartitionRepository
+	logReader     UsageLogKeysetReader
+	backupService *BackupService
+	timingWheel   *TimingWheelService
+	db            *sql.DB
+	cfg           *config.Config
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+	restoreWG sync.WaitGroup
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+
+	now func() time.Time
+}
+
+func NewUsageLogPartitionService(repo UsageLogPartitionRepository, usageRepo UsageLogRepository, backupService *BackupService, timingWheel *TimingWheelService, db *sql.DB, cfg *config.Config) *UsageLogPartitionService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	svc := &UsageLogPartitionService{
+		repo:          repo,
+		backupService: backupService,
+		timingWheel:   timingWheel,
+		db:            db,
+		cfg:           cfg,
+		workerCtx:     workerCtx,
+		workerCancel:  workerCancel,
+		now:           time.Now,
+	}
+	if reader, ok := usageRepo.(UsageLogKeysetReader); ok {
+		svc.logReader = reader
+	}
+	return svc
+}
+
+func (s *UsageLogPartitionService) Start() {
+	if s == nil {
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] not started (missing deps)")
+		return
+	}
+
+	interval := s.maintenanceInterval()
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(usageLogPartitionWorkerName, interval, s.runOnce)
+		// 启动时立即执行一次，保证当月及后续分区存在
+		go s.runOnce()
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] started (interval=%s premake_months=%d retention_days=%d archive=%t storage=%s format=%s)",
+			interval, s.premakeMonths(), s.retentionDays(), s.archiveEnabled(), s.archiveStorage(), s.archiveFormat())
+	})
+}
+
+func (s *UsageLogPartitionService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(usageLogPartitionWorkerName)
+		}
+		s.restoreWG.Wait()
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] stopped")
+	})
+}
+
+// ListPartitions 返回 usage_logs 当前的分区列表（按上界升序）
+func (s *UsageLogPartitionService) ListPartitions(ctx context.Context) ([]UsageLogPartition, error) {
+	if s == nil || s.repo == nil {
+		return nil, ErrUsageLogArchiveUnavailable
+	}
+	return s.repo.ListUsageLogPartitions(ctx)
+}
+
+func (s *UsageLogPartitionService) ListArchives(ctx context.Context, params pagination.PaginationParams) ([]UsageLogArchive, *pagination.PaginationResult, error) {
+	if s == nil || s.repo == nil {
+		return nil, nil, ErrUsageLogArchiveUnavailable
+	}
+	return s.repo.ListArchives(ctx, params)
+}
+
+func (s *UsageLogPartitionService) GetArchive(ctx context.Context, id int64) (*UsageLogArchive, error) {
+	if s == nil || s.repo == nil {
+		return nil, ErrUsageLogArchiveUnavailable
+	}
+	return s.repo.GetArchive(ctx, id)
+}
+
+// GetArchiveDownload 返回归档文件的下载方式：本地文件路径或对象存储预签名 URL
+func (s *UsageLogPartitionService) GetArchiveDownload(ctx context.Context, id int64) (*UsageExportDownload, error) {
+	archive, err := s.GetArchive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if archive.ObjectKey == "" {
+		return nil, ErrUsageLogArchiveNotFound
+	}
+	download := &UsageExportDownload{
+		FileName:    archive.FileName,
+		ContentType: usageLogArchiveContentType(archive.Format),
+	}
+	if archive.Storage == UsageExportStorageS3 {
+		objectStore, err := s.objectStore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		url, err := objectStore.PresignURL(ctx, archive.ObjectKey, usageExportPresignExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("presign archive url: %w", err)
+		}
+		download.URL = url
+		return download, nil
+	}
+	download.LocalPath = filepath.Join(s.localDir(), filepath.Clean(archive.ObjectKey))
+	return download, nil
+}
+
+// QueryArchiveRecords 顺序扫描归档文件，按过滤条件分页返回记录（无需重新导入）
+func (s *UsageLogPartitionService) QueryArchiveRecords(ctx context.Context, id int64, filters UsageLogArchiveRecordFilters, params pagination.PaginationParams) ([]UsageLogArchiveRecord, *pagination.PaginationResult, error) {
+	archive, err := s.GetArchive(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if archive.ObjectKey == "" {
+		return nil, nil, ErrUsageLogArchiveNotFound
+	}
+	filters.Model = strings.TrimSpace(filters.Model)
+	filters.RequestID = strings.TrimSpace(filters.RequestID)
+	filters.SessionID = strings.TrimSpace(filters.SessionID)
+
+	offset := int64(params.Offset())
+	limit := int64(params.Limit())
+	out := make([]UsageLogArchiveRecord, 0)
+	var total int64
+	err = s.scanArchive(ctx, archive, func(batch []UsageLogArchiveRecord) error {
+		for i := range batch {
+			if !batch[i].matches(filters) {
+				continue
+			}
+			if total >= offset && total < offset+limit {
+				out = append(out, batch[i])
+			}
+			total++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	pages := int((total + limit - 1) / limit)
+	if pages < 1 {
+		pages = 1
+	}
+	return out, &pagination.PaginationResult{Total: total, Page: params.Page, PageSize: params.PageSize, Pages: pages}, nil
+}
+
+// RestoreArchive 将归档月份重新导入 usage_logs（后台执行）。导入后的月份在保留期清理中跳过，
+// 直到管理员调用 ReleaseArchive。重新导入的记录由数据库分配新的 id。
+func (s *UsageLogPartitionService) RestoreArchive(ctx context.Context, id int64) (*UsageLogArchive, error) {
+	archive, err := s.GetArchive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if archive.ObjectKey == "" {
+		return nil, ErrUsageLogArchiveNotRestorable
+	}
+	ok, err := s.repo.TransitionArchiveStatus(ctx, id, UsageLogArchiveStatusArchived, UsageLogArchiveStatusRestoring)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUsageLogArchiveNotRestorable
+	}
+	archive.Status = UsageLogArchiveStatusRestoring
+
+	snapshot := *archive
+	s.restoreWG.Add(1)
+	go func() {
+		defer s.restoreWG.Done()
+		s.importArchive(&snapshot)
+	}()
+	return archive, nil
+}
+
+// ReleaseArchive 解除已导入月份的保留，下次维护时按保留期再次清理（已有归档文件，不会重复导出）
+func (s *UsageLogPartitionService) ReleaseArchive(ctx context.Context, id int64) (*UsageLogArchive, error) {
+	if _, err := s.GetArchive(ctx, id); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.TransitionArchiveStatus(ctx, id, UsageLogArchiveStatusRestored, UsageLogArchiveStatusArchived)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUsageLogArchiveNotRestored
+	}
+	return s.repo.GetArchive(ctx, id)
+}
+
+func (s *UsageLogPartitionService) runOnce() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+	ctx, cancel := context.WithTimeout(parent, s.taskTimeout())
+	defer cancel()
+
+	// 多实例部署时只由一个节点执行维护
+	if s.db != nil {
+		release, ok := tryAcquireDBAdvisoryLock(ctx, s.db, hashAdvisoryLockID(usageLogPartitionLockKey))
+		if !ok {
+			return
+		}
+		defer release()
+	}
+
+	if err := s.maintain(ctx); err != nil {
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] maintenance failed: %v", err)
+	}
+}
+
+func (s *UsageLogPartitionService) maintain(ctx context.Context) error {
+	partitioned, err := s.repo.IsUsageLogsPartitioned(ctx)
+	if err != nil {
+		return err
+	}
+	if !partitioned {
+		return nil
+	}
+
+	monthStart := truncateToMonthUTC(s.now())
+	for i := 0; i <= s.premakeMonths(); i++ {
+		if err := s.repo.EnsureUsageLogPartition(ctx, monthStart.AddDate(0, i, 0)); err != nil {
+			return fmt.Errorf("ensure partition: %w", err)
+		}
+	}
+
+	days := s.retentionDays()
+	if days <= 0 {
+		return nil
+	}
+	cutoffMonth := truncateToMonthUTC(s.now().AddDate(0, 0, -days))
+
+	partitions, err := s.repo.ListUsageLogPartitions(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range partitions {
+		p := &partitions[i]
+		if p.IsLegacy() {
+			if err := s.retireLegacy(ctx, p, cutoffMonth); err != nil {
+				return err
+			}
+			continue
+		}
+		if p.RangeEnd.After(cutoffMonth) {
+			continue
+		}
+		if _, err := s.retireMonth(ctx, p.Name, *p.RangeStart, p.RangeEnd, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retireLegacy 按月清理 legacy 分区中早于保留期的数据，清空后删除分区
+func (s *UsageLogPartitionService) retireLegacy(ctx context.Context, p *UsageLogPartition, cutoffMonth time.Time) error {
+	minAt, err := s.repo.MinUsageLogCreatedAt(ctx, p.Name)
+	if err != nil {
+		return err
+	}
+	if minAt == nil {
+		if !p.RangeEnd.After(cutoffMonth) {
+			logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] drop empty legacy partition: %s", p.Name)
+			return s.repo.DropUsageLogPartition(ctx, p.Name)
+		}
+		return nil
+	}
+
+	end := p.RangeEnd
+	if cutoffMonth.Before(end) {
+		end = cutoffMonth
+	}
+	retired := 0
+	for m := truncateToMonthUTC(*minAt); m.Before(end) && retired < usageLogLegacyMonthsPerRun; m = m.AddDate(0, 1, 0) {
+		done, err := s.retireMonth(ctx, p.Name, m, m.AddDate(0, 1, 0), false)
+		if err != nil {
+			return err
+		}
+		if done {
+			retired++
+		}
+	}
+	return nil
+}
+
+// retireMonth 归档并清理 [start, end) 月份。dropPartition=true 时整体删除分区，否则分批删除该范围的记录。
+// 已重新导入（restoring/restored）的月份跳过，返回 false。
+func (s *UsageLogPartitionService) retireMonth(ctx context.Context, partition string, start, end time.Time, dropPartition bool) (bool, error) {
+	existing, err := s.repo.GetArchiveByRangeStart(ctx, start)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil && (existing.Status == UsageLogArchiveStatusRestoring || existing.Status == UsageLogArchiveStatusRestored) {
+		return false, nil
+	}
+	if s.archiveEnabled() && (existing == nil || existing.Status != UsageLogArchiveStatusArchived) {
+		if err := s.archiveRange(ctx, partition, start, end); err != nil {
+			return false, fmt.Errorf("archive %s: %w", start.Format("2006-01"), err)
+		}
+	}
+
+	if dropPartition {
+		if err := s.repo.DropUsageLogPartition(ctx, partition); err != nil {
+			return false, err
+		}
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] partition dropped: %s", partition)
+		return true, nil
+	}
+	deleted, err := s.repo.DeleteUsageLogsRange(ctx, start, end)
+	if err != nil {
+		return false, err
+	}
+	logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] legacy rows deleted: partition=%s month=%s rows=%d", partition, start.Format("2006-01"), deleted)
+	return true, nil
+}
+
+// archiveRange 导出 [start, end) 的记录并记录归档；无数据时不生成归档
+func (s *UsageLogPartitionService) archiveRange(ctx context.Context, partition string, start, end time.Time) error {
+	if s.logReader == nil {
+		return errors.New("usage log reader unavailable")
+	}
+	format := s.archiveFormat()
+	storage := s.archiveStorage()
+	dir := s.localDir()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+
+	monthTag := start.UTC().Format("200601")
+	fileName := fmt.Sprintf("usage_logs_%s.%s", monthTag, usageLogArchiveFileExt(format))
+	tmpPath := filepath.Join(dir, "."+fileName+".tmp")
+	archive := &UsageLogArchive{
+		RangeStart:    start.UTC(),
+		RangeEnd:      end.UTC(),
+		PartitionName: partition,
+		Format:        format,
+		Storage:       storage,
+	}
+
+	rows, checksum, err := s.writeArchiveFile(ctx, tmpPath, format, start, end)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		s.recordArchiveFailure(archive, err)
+		return err
+	}
+	if rows == 0 {
+		_ = os.Remove(tmpPath)
+		return nil
+	}
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		s.recordArchiveFailure(archive, err)
+		return err
+	}
+	objectKey, err := s.storeArchiveFile(ctx, storage, tmpPath, monthTag, fileName, format)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		s.recordArchiveFailure(archive, err)
+		return err
+	}
+
+	archivedAt := time.Now().UTC()
+	archive.Status = UsageLogArchiveStatusArchived
+	archive.ObjectKey = objectKey
+	archive.FileName = fileName
+	archive.FileSize = info.Size()
+	archive.RowCount = rows
+	archive.Checksum = checksum
+	archive.ArchivedAt = &archivedAt
+	if err := s.repo.UpsertArchive(ctx, archive); err != nil {
+		return fmt.Errorf("record archive: %w", err)
+	}
+	logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] month archived: month=%s rows=%d size=%d storage=%s key=%s", start.Format("2006-01"), rows, info.Size(), storage, objectKey)
+	return nil
+}
+
+func (s *UsageLogPartitionService) writeArchiveFile(ctx context.Context, path, format string, start, end time.Time) (int64, string, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return 0, "", fmt.Errorf("create archive file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	hasher := sha256.New()
+	writer, err := newUsageLogArchiveWriter(format, io.MultiWriter(f, hasher))
+	if err != nil {
+		return 0, "", err
+	}
+
+	batchSize := s.batchSize()
+	filters := UsageExportFilters{StartTime: start, EndTime: end}
+	var afterID, written int64
+	records := make([]UsageLogArchiveRecord, 0, batchSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, "", err
+		}
+		logs, err := s.logReader.ListUsageLogsAfterID(ctx, filters, afterID, batchSize)
+		if err != nil {
+			return written, "", err
+		}
+		if len(logs) == 0 {
+			break
+		}
+		records = records[:0]
+		for i := range logs {
+			records = append(records, NewUsageLogArchiveRecord(&logs[i]))
+		}
+		if err := writer.Write(records); err != nil {
+			return written, "", fmt.Errorf("write archive rows: %w", err)
+		}
+		written += int64(len(logs))
+		afterID = logs[len(logs)-1].ID
+		if len(logs) < batchSize {
+			break
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return written, "", fmt.Errorf("finalize archive file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return written, "", err
+	}
+	return written, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// storeArchiveFile 将临时文件落到最终位置，返回 object key（本地为相对 local_dir 的路径）
+func (s *UsageLogPartitionService) storeArchiveFile(ctx context.Context, storage, tmpPath, monthTag, fileName, format string) (string, error) {
+	if storage == UsageExportStorageS3 {
+		objectStore, err := s.objectStore(ctx)
+		if err != nil {
+			return "", err
+		}
+		_, s3Cfg, err := s.backupService.SharedObjectStore(ctx)
+		if err != nil {
+			return "", err
+		}
+		prefix := strings.TrimRight(s3Cfg.Prefix, "/")
+		if prefix == "" {
+			prefix = "backups"
+		}
+		key := fmt.Sprintf("%s/usage-archives/%s/%s", prefix, monthTag, fileName)
+		f, err := os.Open(tmpPath)
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			_ = f.Close()
+			_ = os.Remove(tmpPath)
+		}()
+		if _, err := objectStore.Upload(ctx, key, f, usageLogArchiveContentType(format)); err != nil {
+			return "", fmt.Errorf("upload archive file: %w", err)
+		}
+		return key, nil
+	}
+
+	key := filepath.Join(monthTag, fileName)
+	finalPath := filepath.Join(s.localDir(), key)
+	if err := os.MkdirAll(filepath.Dir(finalPath), 0o750); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return "", fmt.Errorf("move archive file: %w", err)
+	}
+	return key, nil
+}
+
+// scanArchive 打开归档文件并按批回调；S3 归档先下载到本地临时文件（parquet 需要随机读取）
+func (s *UsageLogPartitionService) scanArchive(ctx context.Context, archive *UsageLogArchive, fn func([]UsageLogArchiveRecord) error) error {
+	f, cleanup, err := s.openArchiveFile(ctx, archive)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	reader, err := newUsageLogArchiveReader(archive.Format, f, info.Size())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reader.Close() }()
+
+	buf := make([]UsageLogArchiveRecord, usageLogArchiveReadBatch)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, readErr := reader.Read(buf)
+		if n > 0 {
+			if err := fn(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+func (s *UsageLogPartitionService) openArchiveFile(ctx context.Context, archive *UsageLogArchive) (*os.File, func(), error) {
+	if archive.Storage != UsageExportStorageS3 {
+		f, err := os.Open(filepath.Join(s.localDir(), filepath.Clean(archive.ObjectKey)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("open archive file: %w", err)
+		}
+		return f, func() { _ = f.Close() }, nil
+	}
+
+	objectStore, err := s.objectStore(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := objectStore.Download(ctx, archive.ObjectKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("download archive file: %w", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if err := os.MkdirAll(s.localDir(), 0o750); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.CreateTemp(s.localDir(), ".usage_archive_download_*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("download archive file: %w", err)
+	}
+	return f, cleanup, nil
+}
+
+func (s *UsageLogPartitionService) importArchive(archive *UsageLogArchive) {
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+	ctx, cancel := context.WithTimeout(parent, s.taskTimeout())
+	defer cancel()
+
+	start := time.Now()
+	var imported int64
+	err := s.repo.EnsureUsageLogPartition(ctx, archive.RangeStart)
+	if err == nil {
+		logs := make([]UsageLog, 0, usageLogArchiveReadBatch)
+		err = s.scanArchive(ctx, archive, func(batch []UsageLogArchiveRecord) error {
+			logs = logs[:0]
+			for i := range batch {
+				logs = append(logs, batch[i].ToUsageLog())
+			}
+			n, err := s.repo.ImportUsageLogs(ctx, logs)
+			imported += n
+			return err
+		})
+	}
+
+	updateCtx, updateCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer updateCancel()
+	if err != nil {
+		// 已写入的部分保留（按 request_id 去重，可再次导入补齐）；状态回到 archived 以便重试
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] restore failed: archive=%d month=%s imported=%d err=%v", archive.ID, archive.RangeStart.Format("2006-01"), imported, err)
+		if _, tErr := s.repo.TransitionArchiveStatus(updateCtx, archive.ID, UsageLogArchiveStatusRestoring, UsageLogArchiveStatusArchived); tErr != nil {
+			logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] reset archive status failed: archive=%d err=%v", archive.ID, tErr)
+		}
+		return
+	}
+	if err := s.repo.MarkArchiveRestored(updateCtx, archive.ID, imported); err != nil {
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] mark archive restored failed: archive=%d err=%v", archive.ID, err)
+		return
+	}
+	logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] archive restored: archive=%d month=%s rows=%d duration=%s", archive.ID, archive.RangeStart.Format("2006-01"), imported, time.Since(start))
+}
+
+func (s *UsageLogPartitionService) recordArchiveFailure(archive *UsageLogArchive, cause error) {
+	msg := strings.TrimSpace(cause.Error())
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] archive failed: month=%s err=%s", archive.RangeStart.Format("2006-01"), msg)
+	failed := *archive
+	failed.Status = UsageLogArchiveStatusFailed
+	failed.ErrorMsg = &msg
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.UpsertArchive(ctx, &failed); err != nil {
+		logger.LegacyPrintf("service.usage_log_partition", "[UsageLogPartition] record archive failure failed: month=%s err=%v", archive.RangeStart.Format("2006-01"), err)
+	}
+}
+
+func (s *UsageLogPartitionService) objectStore(ctx context.Context) (BackupObjectStore, error) {
+	if s.backupService == nil {
+		return nil, ErrBackupS3NotConfigured
+	}
+	objectStore, _, err := s.backupService.SharedObjectStore(ctx)
+	return objectStore, err
+}
+
+func (s *UsageLogPartitionService) premakeMonths() int {
+	if s.cfg == nil {
+		return 2
+	}
+	return s.cfg.UsageLogPartition.PremakeMonths
+}
+
+// retentionDays 在线保留天数；未单独配置时沿用预聚合的 usage_logs 保留期（仅预聚合启用时生效）
+func (s *UsageLogPartitionService) retentionDays() int {
+	if s.cfg == nil {
+		return 0
+	}
+	if days := s.cfg.UsageLogPartition.RetentionDays; days > 0 {
+		return days
+	}
+	if s.cfg.DashboardAgg.Enabled {
+		return s.cfg.DashboardAgg.Retention.UsageLogsDays
+	}
+	return 0
+}
+
+func (s *UsageLogPartitionService) maintenanceInterval() time.Duration {
+	if s.cfg != nil && s.cfg.UsageLogPartition.MaintenanceIntervalMinutes > 0 {
+		return time.Duration(s.cfg.UsageLogPartition.MaintenanceIntervalMinutes) * time.Minute
+	}
+	return time.Hour
+}
+
+func (s *UsageLogPartitionService) archiveEnabled() bool {
+	return s.cfg == nil || s.cfg.UsageLogPartition.Archive.Enabled
+}
+
+func (s *UsageLogPartitionService) archiveFormat() string {
+	if s.cfg != nil {
+		if format := NormalizeUsageLogArchiveFormat(s.cfg.UsageLogPartition.Archive.Format); format != "" {
+			return format
+		}
+	}
+	return UsageExportFormatParquet
+}
+
+func (s *UsageLogPartitionService) archiveStorage() string {
+	if s.cfg != nil && strings.EqualFold(strings.TrimSpace(s.cfg.UsageLogPartition.Archive.Storage), UsageExportStorageS3) {
+		return UsageExportStorageS3
+	}
+	return UsageExportStorageLocal
+}
+
+func (s *UsageLogPartitionService) localDir() string {
+	if s.cfg != nil && strings.TrimSpace(s.cfg.UsageLogPartition.Archive.LocalDir) != "" {
+		return strings.TrimSpace(s.cfg.UsageLogPartition.Archive.LocalDir)
+	}
+	return "./data/usage-archives"
+}
+
+func (s *UsageLogPartitionService) batchSize() int {
+	if s.cfg != nil && s.cfg.UsageLogPartition.Archive.BatchSize > 0 {
+		return s.cfg.UsageLogPartition.Archive.BatchSize
+	}
+	return 5000
+}
+
+func (s *UsageLogPartitionService) taskTimeout() time.Duration {
+	if s.cfg != nil && s.cfg.UsageLogPartition.Archive.TaskTimeoutSeconds > 0 {
+		return time.Duration(s.cfg.UsageLogPartition.Archive.TaskTimeoutSeconds) * time.Second
+	}
+	return 2 * time.Hour
+}
+
+func truncateToMonthUTC(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
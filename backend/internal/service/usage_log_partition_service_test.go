@@ -0,0 +1,376 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type partitionRepoStub struct {
+	mu          sync.Mutex
+	partitioned bool
+	partitions  []UsageLogPartition
+	minCreated  map[string]*time.Time
+	ensured     []time.Time
+	dropped     []string
+	deleted     [][2]time.Time
+	imported    []UsageLog
+	archives    map[int64]*UsageLogArchive
+	nextID      int64
+}
+
+func newPartitionRepoStub() *partitionRepoStub {
+	return &partitionRepoStub{
+		partitioned: true,
+		minCreated:  map[string]*time.Time{},
+		archives:    map[int64]*UsageLogArchive{},
+	}
+}
+
+func (s *partitionRepoStub) IsUsageLogsPartitioned(ctx context.Context) (bool, error) {
+	return s.partitioned, nil
+}
+
+func (s *partitionRepoStub) ListUsageLogPartitions(ctx context.Context) ([]UsageLogPartition, error) {
+	return append([]UsageLogPartition(nil), s.partitions...), nil
+}
+
+func (s *partitionRepoStub) EnsureUsageLogPartition(ctx context.Context, month time.Time) error {
+	s.ensured = append(s.ensured, month)
+	return nil
+}
+
+func (s *partitionRepoStub) DropUsageLogPartition(ctx context.Context, name string) error {
+	s.dropped = append(s.dropped, name)
+	return nil
+}
+
+func (s *partitionRepoStub) MinUsageLogCreatedAt(ctx context.Context, partition string) (*time.Time, error) {
+	return s.minCreated[partition], nil
+}
+
+func (s *partitionRepoStub) DeleteUsageLogsRange(ctx context.Context, start, end time.Time) (int64, error) {
+	s.deleted = append(s.deleted, [2]time.Time{start, end})
+	return 1, nil
+}
+
+func (s *partitionRepoStub) ImportUsageLogs(ctx context.Context, logs []UsageLog) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.imported = append(s.imported, logs...)
+	return int64(len(logs)), nil
+}
+
+func (s *partitionRepoStub) UpsertArchive(ctx context.Context, archive *UsageLogArchive) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.archives {
+		if existing.RangeStart.Equal(archive.RangeStart) {
+			archive.ID = existing.ID
+		}
+	}
+	if archive.ID == 0 {
+		s.nextID++
+		archive.ID = s.nextID
+	}
+	cp := *archive
+	s.archives[archive.ID] = &cp
+	return nil
+}
+
+func (s *partitionRepoStub) GetArchive(ctx context.Context, id int64) (*UsageLogArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	archive, ok := s.archives[id]
+	if !ok {
+		return nil, ErrUsageLogArchiveNotFound
+	}
+	cp := *archive
+	return &cp, nil
+}
+
+func (s *partitionRepoStub) GetArchiveByRangeStart(ctx context.Context, rangeStart time.Time) (*UsageLogArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, archive := range s.archives {
+		if archive.RangeStart.Equal(rangeStart) {
+			cp := *archive
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *partitionRepoStub) ListArchives(ctx context.Context, params pagination.PaginationParams) ([]UsageLogArchive, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func (s *partitionRepoStub) TransitionArchiveStatus(ctx context.Context, id int64, from, to string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	archive, ok := s.archives[id]
+	if !ok || archive.Status != from {
+		return false, nil
+	}
+	archive.Status = to
+	return true, nil
+}
+
+func (s *partitionRepoStub) MarkArchiveRestored(ctx context.Context, id int64, restoredRows int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	archive := s.archives[id]
+	archive.Status = UsageLogArchiveStatusRestored
+	archive.RestoredRows = restoredRows
+	return nil
+}
+
+// rangeLogReaderStub 按 [StartTime, EndTime) 过滤的 keyset 读取桩
+type rangeLogReaderStub struct {
+	logs []UsageLog
+}
+
+func (r *rangeLogReaderStub) ListUsageLogsAfterID(ctx context.Context, filters UsageExportFilters, afterID int64, limit int) ([]UsageLog, error) {
+	out := make([]UsageLog, 0, limit)
+	for _, log := range r.logs {
+		if log.ID <= afterID || log.CreatedAt.Before(filters.StartTime) || !log.CreatedAt.Before(filters.EndTime) {
+			continue
+		}
+		out = append(out, log)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func newUsageLogPartitionTestService(t *testing.T, repo UsageLogPartitionRepository, reader UsageLogKeysetReader, format string) *UsageLogPartitionService {
+	t.Helper()
+	cfg := &config.Config{UsageLogPartition: config.UsageLogPartitionConfig{
+		PremakeMonths: 2,
+		RetentionDays: 60,
+		Archive: config.UsageLogArchiveConfig{
+			Enabled:            true,
+			Format:             format,
+			Storage:            UsageExportStorageLocal,
+			LocalDir:           t.TempDir(),
+			BatchSize:          2,
+			TaskTimeoutSeconds: 60,
+		},
+	}}
+	svc := NewUsageLogPartitionService(repo, nil, nil, nil, nil, cfg)
+	svc.logReader = reader
+	svc.now = func() time.Time { return time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC) }
+	return svc
+}
+
+func monthUTC(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthPartition(year int, month time.Month) UsageLogPartition {
+	start := monthUTC(year, month)
+	return UsageLogPartition{
+		Name:       usageLogsTestPartitionName(start),
+		RangeStart: &start,
+		RangeEnd:   start.AddDate(0, 1, 0),
+	}
+}
+
+func usageLogsTestPartitionName(month time.Time) string {
+	return "usage_logs_" + month.Format("200601")
+}
+
+func sampleArchiveLogs() []UsageLog {
+	groupID := int64(3)
+	sessionID := "sess-1"
+	durationMs := 120
+	logs := sampleExportLogs(5)
+	for i := range logs {
+		logs[i].RequestID = "req-" + string(rune('a'+i))
+		logs[i].CreatedAt = time.Date(2026, 1, 15, 0, i, 0, 0, time.UTC)
+	}
+	logs[1].GroupID = &groupID
+	logs[1].SessionID = &sessionID
+	logs[1].DurationMs = &durationMs
+	logs[3].Model = "gpt-5"
+	return logs
+}
+
+func TestUsageLogArchiveFileRoundTrip(t *testing.T) {
+	logs := sampleArchiveLogs()
+	for _, format := range []string{UsageExportFormatParquet, UsageExportFormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "archive."+usageLogArchiveFileExt(format))
+			f, err := os.Create(path)
+			require.NoError(t, err)
+			writer, err := newUsageLogArchiveWriter(format, f)
+			require.NoError(t, err)
+			records := make([]UsageLogArchiveRecord, 0, len(logs))
+			for i := range logs {
+				records = append(records, NewUsageLogArchiveRecord(&logs[i]))
+			}
+			require.NoError(t, writer.Write(records))
+			require.NoError(t, writer.Close())
+			require.NoError(t, f.Close())
+
+			f, err = os.Open(path)
+			require.NoError(t, err)
+			defer func() { _ = f.Close() }()
+			info, err := f.Stat()
+			require.NoError(t, err)
+			reader, err := newUsageLogArchiveReader(format, f, info.Size())
+			require.NoError(t, err)
+			defer func() { _ = reader.Close() }()
+
+			var got []UsageLogArchiveRecord
+			buf := make([]UsageLogArchiveRecord, 2)
+			for {
+				n, err := reader.Read(buf)
+				got = append(got, buf[:n]...)
+				if err != nil {
+					break
+				}
+			}
+			require.Len(t, got, len(logs))
+			restored := got[1].ToUsageLog()
+			require.Equal(t, logs[1].RequestID, restored.RequestID)
+			require.True(t, logs[1].CreatedAt.Equal(restored.CreatedAt))
+			require.Equal(t, *logs[1].GroupID, *restored.GroupID)
+			require.Equal(t, *logs[1].SessionID, *restored.SessionID)
+			require.Equal(t, *logs[1].DurationMs, *restored.DurationMs)
+			require.Equal(t, logs[1].InputTokens, restored.InputTokens)
+			require.InDelta(t, logs[1].TotalCost, restored.TotalCost, 1e-12)
+			require.Nil(t, got[0].GroupID)
+		})
+	}
+}
+
+func TestUsageLogPartitionMaintainArchivesAndDropsExpiredPartitions(t *testing.T) {
+	repo := newPartitionRepoStub()
+	repo.partitions = []UsageLogPartition{
+		monthPartition(2026, time.January),
+		monthPartition(2026, time.February),
+		monthPartition(2026, time.March),
+	}
+	// 2 月已被管理员重新导入，保留期清理跳过
+	require.NoError(t, repo.UpsertArchive(context.Background(), &UsageLogArchive{
+		RangeStart: monthUTC(2026, time.February),
+		Status:     UsageLogArchiveStatusRestored,
+	}))
+	svc := newUsageLogPartitionTestService(t, repo, &rangeLogReaderStub{logs: sampleArchiveLogs()}, UsageExportFormatParquet)
+
+	require.NoError(t, svc.maintain(context.Background()))
+
+	require.Equal(t, []time.Time{monthUTC(2026, time.May), monthUTC(2026, time.June), monthUTC(2026, time.July)}, repo.ensured)
+	require.Equal(t, []string{"usage_logs_202601"}, repo.dropped)
+
+	archive, err := repo.GetArchiveByRangeStart(context.Background(), monthUTC(2026, time.January))
+	require.NoError(t, err)
+	require.NotNil(t, archive)
+	require.Equal(t, UsageLogArchiveStatusArchived, archive.Status)
+	require.Equal(t, int64(5), archive.RowCount)
+	require.Equal(t, "usage_logs_202601.parquet", archive.FileName)
+	require.Len(t, archive.Checksum, 64)
+	info, err := os.Stat(filepath.Join(svc.localDir(), archive.ObjectKey))
+	require.NoError(t, err)
+	require.Equal(t, archive.FileSize, info.Size())
+
+	// 再次维护不会重复导出已归档月份
+	repo.partitions = repo.partitions[1:]
+	require.NoError(t, svc.maintain(context.Background()))
+	require.Equal(t, []string{"usage_logs_202601"}, repo.dropped)
+}
+
+func TestUsageLogPartitionMaintainRetiresLegacyByMonth(t *testing.T) {
+	repo := newPartitionRepoStub()
+	legacyEnd := monthUTC(2026, time.April)
+	repo.partitions = []UsageLogPartition{{Name: "usage_logs_legacy", RangeEnd: legacyEnd}}
+	minAt := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	repo.minCreated["usage_logs_legacy"] = &minAt
+	svc := newUsageLogPartitionTestService(t, repo, &rangeLogReaderStub{logs: sampleArchiveLogs()}, UsageExportFormatJSONL)
+	svc.cfg.UsageLogPartition.Archive.Enabled = false
+
+	require.NoError(t, svc.maintain(context.Background()))
+
+	require.Equal(t, [][2]time.Time{
+		{monthUTC(2026, time.January), monthUTC(2026, time.February)},
+		{monthUTC(2026, time.February), monthUTC(2026, time.March)},
+	}, repo.deleted)
+	require.Empty(t, repo.dropped)
+
+	// legacy 分区清空且上界早于保留期后整体删除
+	repo.partitions[0].RangeEnd = monthUTC(2026, time.February)
+	repo.minCreated["usage_logs_legacy"] = nil
+	require.NoError(t, svc.maintain(context.Background()))
+	require.Equal(t, []string{"usage_logs_legacy"}, repo.dropped)
+}
+
+func TestUsageLogPartitionMaintainSkipsWithoutRetention(t *testing.T) {
+	repo := newPartitionRepoStub()
+	repo.partitions = []UsageLogPartition{monthPartition(2025, time.January)}
+	svc := newUsageLogPartitionTestService(t, repo, &rangeLogReaderStub{}, UsageExportFormatParquet)
+	svc.cfg.UsageLogPartition.RetentionDays = 0
+
+	require.NoError(t, svc.maintain(context.Background()))
+	require.Len(t, repo.ensured, 3)
+	require.Empty(t, repo.dropped)
+
+	repo.partitioned = false
+	repo.ensured = nil
+	require.NoError(t, svc.maintain(context.Background()))
+	require.Empty(t, repo.ensured)
+}
+
+func TestUsageLogPartitionQueryAndRestoreArchive(t *testing.T) {
+	repo := newPartitionRepoStub()
+	repo.partitions = []UsageLogPartition{monthPartition(2026, time.January)}
+	svc := newUsageLogPartitionTestService(t, repo, &rangeLogReaderStub{logs: sampleArchiveLogs()}, UsageExportFormatJSONL)
+	require.NoError(t, svc.maintain(context.Background()))
+
+	archive, err := repo.GetArchiveByRangeStart(context.Background(), monthUTC(2026, time.January))
+	require.NoError(t, err)
+	require.NotNil(t, archive)
+
+	records, result, err := svc.QueryArchiveRecords(context.Background(), archive.ID, UsageLogArchiveRecordFilters{Model: "claude-sonnet-4"}, pagination.PaginationParams{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), result.Total)
+	require.Equal(t, 2, result.Pages)
+	require.Len(t, records, 2)
+	require.Equal(t, "req-c", records[0].RequestID)
+
+	sessionID := "sess-1"
+	records, _, err = svc.QueryArchiveRecords(context.Background(), archive.ID, UsageLogArchiveRecordFilters{SessionID: sessionID}, pagination.PaginationParams{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "req-b", records[0].RequestID)
+
+	_, err = svc.ReleaseArchive(context.Background(), archive.ID)
+	require.ErrorIs(t, err, ErrUsageLogArchiveNotRestored)
+
+	restoring, err := svc.RestoreArchive(context.Background(), archive.ID)
+	require.NoError(t, err)
+	require.Equal(t, UsageLogArchiveStatusRestoring, restoring.Status)
+	_, err = svc.RestoreArchive(context.Background(), archive.ID)
+	require.ErrorIs(t, err, ErrUsageLogArchiveNotRestorable)
+	svc.restoreWG.Wait()
+
+	require.Len(t, repo.imported, 5)
+	restored, err := repo.GetArchive(context.Background(), archive.ID)
+	require.NoError(t, err)
+	require.Equal(t, UsageLogArchiveStatusRestored, restored.Status)
+	require.Equal(t, int64(5), restored.RestoredRows)
+
+	released, err := svc.ReleaseArchive(context.Background(), archive.ID)
+	require.NoError(t, err)
+	require.Equal(t, UsageLogArchiveStatusArchived, released.Status)
+}
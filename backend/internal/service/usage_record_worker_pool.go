@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/alitto/pond/v2"
 	"go.uber.org/zap"
@@ -36,6 +37,29 @@ const (
 // 任务实现应自行处理业务错误日志；池本身只负责调度与超时控制。
 type UsageRecordTask func(ctx context.Context)
 
+// WithUsageRecordRequestContext 将请求上下文中的 client_request_id / request_id 带入异步任务的上下文。
+// worker 以 context.Background() 执行任务，不带入时 usage_logs.request_id 会退回上游请求 ID，
+// 无法与请求捕获等按请求标识记录的数据关联。
+func WithUsageRecordRequestContext(requestCtx context.Context, task UsageRecordTask) UsageRecordTask {
+	if task == nil || requestCtx == nil {
+		return task
+	}
+	clientRequestID, _ := requestCtx.Value(ctxkey.ClientRequestID).(string)
+	requestID, _ := requestCtx.Value(ctxkey.RequestID).(string)
+	if clientRequestID == "" && requestID == "" {
+		return task
+	}
+	return func(ctx context.Context) {
+		if clientRequestID != "" {
+			ctx = context.WithValue(ctx, ctxkey.ClientRequestID, clientRequestID)
+		}
+		if requestID != "" {
+			ctx = context.WithValue(ctx, ctxkey.RequestID, requestID)
+		}
+		task(ctx)
+	}
+}
+
 // UsageRecordSubmitMode 表示任务提交结果。
 type UsageRecordSubmitMode string
 
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// usageSessionIDMaxLen 与 usage_logs.session_id 列宽一致
+const usageSessionIDMaxLen = 64
+
+const (
+	UsageSessionSortLastActive = "last_active_at"
+	UsageSessionSortStartedAt  = "started_at"
+	UsageSessionSortActualCost = "actual_cost"
+	UsageSessionSortTotalCost  = "total_cost"
+	UsageSessionSortTurns      = "turns"
+)
+
+// NormalizeUsageSessionID 规范化写入 usage_logs 的会话标识。
+// 空值返回 nil；超过列宽的标识（如摘要链会话键）替换为其 SHA-256 十六进制摘要，保证同一会话始终映射到同一值。
+func NormalizeUsageSessionID(sessionID string) *string {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return nil
+	}
+	if len(sessionID) > usageSessionIDMaxLen {
+		sum := sha256.Sum256([]byte(sessionID))
+		sessionID = hex.EncodeToString(sum[:])
+	}
+	return &sessionID
+}
+
+// NormalizeUsageSessionSort 规范化会话列表排序字段，非法值回退为最近活跃时间
+func NormalizeUsageSessionSort(sortBy string) string {
+	switch strings.ToLower(strings.TrimSpace(sortBy)) {
+	case UsageSessionSortStartedAt:
+		return UsageSessionSortStartedAt
+	case UsageSessionSortActualCost, "cost":
+		return UsageSessionSortActualCost
+	case UsageSessionSortTotalCost:
+		return UsageSessionSortTotalCost
+	case UsageSessionSortTurns:
+		return UsageSessionSortTurns
+	default:
+		return UsageSessionSortLastActive
+	}
+}
+
+// UsageSession 是按 session_id 聚合的会话（对话）维度用量
+type UsageSession struct {
+	SessionID string `json:"session_id"`
+	UserID    int64  `json:"user_id"`
+	// APIKeyID 会话首个请求使用的 API Key
+	APIKeyID int64 `json:"api_key_id"`
+	// Turns 会话内的请求（轮次）数
+	Turns int64 `json:"turns"`
+
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_tokens"`
+	// CacheHitRatio = cache_read / (input + cache_creation + cache_read)，无输入时为 0
+	CacheHitRatio float64 `json:"cache_hit_ratio"`
+
+	TotalCost  float64 `json:"total_cost"`
+	ActualCost float64 `json:"actual_cost"`
+
+	Models []string `json:"models"`
+	// AccountSwitches 按时间顺序相邻请求落到不同上游账号的次数
+	AccountSwitches int64 `json:"account_switches"`
+	AccountCount    int64 `json:"account_count"`
+
+	StartedAt    time.Time `json:"started_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	DurationMs   int64     `json:"duration_ms"`
+}
+
+// ComputeCacheHitRatio 根据 token 汇总计算缓存命中率
+func (s *UsageSession) ComputeCacheHitRatio() {
+	if s == nil {
+		return
+	}
+	total := s.InputTokens + s.CacheCreationTokens + s.CacheReadTokens
+	if total <= 0 {
+		s.CacheHitRatio = 0
+		return
+	}
+	s.CacheHitRatio = float64(s.CacheReadTokens) / float64(total)
+}
+
+// UsageSessionFilters 会话列表过滤条件。
+// 时间范围、用户、API Key、分组按请求过滤后再聚合；AccountID 与 Model 匹配包含该账号/模型请求的会话，聚合值仍覆盖会话内全部请求。
+type UsageSessionFilters struct {
+	StartTime *time.Time
+	EndTime   *time.Time
+	UserID    *int64
+	APIKeyID  *int64
+	GroupID   *int64
+	AccountID *int64
+	Model     string
+	SortBy    string
+}
+
+// UsageSessionRepository 定义会话维度用量的查询接口
+type UsageSessionRepository interface {
+	ListSessions(ctx context.Context, filters UsageSessionFilters, params pagination.PaginationParams) ([]UsageSession, *pagination.PaginationResult, error)
+	// GetSession 返回会话聚合；userID 非 nil 时仅统计该用户的请求
+	GetSession(ctx context.Context, sessionID string, userID *int64) (*UsageSession, error)
+	// ListSessionLogs 按时间升序返回会话内的请求记录
+	ListSessionLogs(ctx context.Context, sessionID string, userID *int64, params pagination.PaginationParams) ([]UsageLog, *pagination.PaginationResult, error)
+}
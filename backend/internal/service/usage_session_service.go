@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+var (
+	ErrUsageSessionNotFound     = infraerrors.NotFound("USAGE_SESSION_NOT_FOUND", "usage session not found")
+	ErrUsageSessionInvalidRange = infraerrors.BadRequest("USAGE_SESSION_INVALID_RANGE", "start_time must be before end_time")
+)
+
+// UsageSessionService 提供会话（对话）维度的用量分析。
+// 会话由写入 usage_logs.session_id 的粘性会话标识界定，聚合在查询时实时计算。
+type UsageSessionService struct {
+	repo UsageSessionRepository
+}
+
+// NewUsageSessionService creates a new UsageSessionService
+func NewUsageSessionService(repo UsageSessionRepository) *UsageSessionService {
+	return &UsageSessionService{repo: repo}
+}
+
+// ListSessions 分页列出会话聚合
+func (s *UsageSessionService) ListSessions(ctx context.Context, filters UsageSessionFilters, params pagination.PaginationParams) ([]UsageSession, *pagination.PaginationResult, error) {
+	if filters.StartTime != nil && filters.EndTime != nil && !filters.StartTime.Before(*filters.EndTime) {
+		return nil, nil, ErrUsageSessionInvalidRange
+	}
+	filters.Model = strings.TrimSpace(filters.Model)
+	filters.SortBy = NormalizeUsageSessionSort(filters.SortBy)
+
+	sessions, result, err := s.repo.ListSessions(ctx, filters, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list usage sessions: %w", err)
+	}
+	for i := range sessions {
+		sessions[i].ComputeCacheHitRatio()
+	}
+	return sessions, result, nil
+}
+
+// GetSession 获取单个会话聚合；userID 非 nil 时限定为该用户的请求
+func (s *UsageSessionService) GetSession(ctx context.Context, sessionID string, userID *int64) (*UsageSession, error) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return nil, ErrUsageSessionNotFound
+	}
+	session, err := s.repo.GetSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	session.ComputeCacheHitRatio()
+	return session, nil
+}
+
+// ListSessionLogs 分页列出会话内的请求记录（按时间升序）
+func (s *UsageSessionService) ListSessionLogs(ctx context.Context, sessionID string, userID *int64, params pagination.PaginationParams) ([]UsageLog, *pagination.PaginationResult, error) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return nil, nil, ErrUsageSessionNotFound
+	}
+	logs, result, err := s.repo.ListSessionLogs(ctx, sessionID, userID, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list usage session logs: %w", err)
+	}
+	return logs, result, nil
+}
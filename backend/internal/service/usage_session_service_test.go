@@ -0,0 +1,112 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type usageSessionRepoStub struct {
+	sessions    []UsageSession
+	listFilters UsageSessionFilters
+	getUserID   *int64
+	listCalls   int
+}
+
+func (s *usageSessionRepoStub) ListSessions(ctx context.Context, filters UsageSessionFilters, params pagination.PaginationParams) ([]UsageSession, *pagination.PaginationResult, error) {
+	s.listCalls++
+	s.listFilters = filters
+	out := append([]UsageSession(nil), s.sessions...)
+	return out, &pagination.PaginationResult{Total: int64(len(out)), Page: params.Page, PageSize: params.PageSize}, nil
+}
+
+func (s *usageSessionRepoStub) GetSession(ctx context.Context, sessionID string, userID *int64) (*UsageSession, error) {
+	s.getUserID = userID
+	for i := range s.sessions {
+		if s.sessions[i].SessionID == sessionID {
+			cp := s.sessions[i]
+			return &cp, nil
+		}
+	}
+	return nil, ErrUsageSessionNotFound
+}
+
+func (s *usageSessionRepoStub) ListSessionLogs(ctx context.Context, sessionID string, userID *int64, params pagination.PaginationParams) ([]UsageLog, *pagination.PaginationResult, error) {
+	return []UsageLog{}, &pagination.PaginationResult{}, nil
+}
+
+func TestNormalizeUsageSessionID(t *testing.T) {
+	require.Nil(t, NormalizeUsageSessionID(""))
+	require.Nil(t, NormalizeUsageSessionID("   "))
+
+	id := NormalizeUsageSessionID(" 8f14e45f-ceea-4e1a-9f5b-6c1d2a3b4c5d ")
+	require.NotNil(t, id)
+	require.Equal(t, "8f14e45f-ceea-4e1a-9f5b-6c1d2a3b4c5d", *id)
+
+	long := strings.Repeat("a", 100) + ":" + "uuid"
+	first := NormalizeUsageSessionID(long)
+	second := NormalizeUsageSessionID(long)
+	require.NotNil(t, first)
+	require.Len(t, *first, usageSessionIDMaxLen)
+	require.Equal(t, *first, *second, "long keys must hash deterministically")
+}
+
+func TestNormalizeUsageSessionSort(t *testing.T) {
+	require.Equal(t, UsageSessionSortLastActive, NormalizeUsageSessionSort(""))
+	require.Equal(t, UsageSessionSortLastActive, NormalizeUsageSessionSort("drop table"))
+	require.Equal(t, UsageSessionSortActualCost, NormalizeUsageSessionSort("cost"))
+	require.Equal(t, UsageSessionSortTotalCost, NormalizeUsageSessionSort(" TOTAL_COST "))
+	require.Equal(t, UsageSessionSortTurns, NormalizeUsageSessionSort("turns"))
+}
+
+func TestUsageSessionService_ListSessionsComputesCacheHitRatio(t *testing.T) {
+	repo := &usageSessionRepoStub{sessions: []UsageSession{
+		{SessionID: "s1", InputTokens: 100, CacheCreationTokens: 100, CacheReadTokens: 800},
+		{SessionID: "s2"},
+	}}
+	svc := NewUsageSessionService(repo)
+
+	sessions, result, err := svc.ListSessions(context.Background(), UsageSessionFilters{SortBy: "cost", Model: " claude-sonnet-4-5 "}, pagination.PaginationParams{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Total)
+	require.InDelta(t, 0.8, sessions[0].CacheHitRatio, 1e-9)
+	require.Zero(t, sessions[1].CacheHitRatio)
+	require.Equal(t, UsageSessionSortActualCost, repo.listFilters.SortBy)
+	require.Equal(t, "claude-sonnet-4-5", repo.listFilters.Model)
+}
+
+func TestUsageSessionService_ListSessionsRejectsInvalidRange(t *testing.T) {
+	repo := &usageSessionRepoStub{}
+	svc := NewUsageSessionService(repo)
+
+	start := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, -1)
+	_, _, err := svc.ListSessions(context.Background(), UsageSessionFilters{StartTime: &start, EndTime: &end}, pagination.PaginationParams{Page: 1, PageSize: 20})
+	require.ErrorIs(t, err, ErrUsageSessionInvalidRange)
+	require.Zero(t, repo.listCalls)
+}
+
+func TestUsageSessionService_GetSession(t *testing.T) {
+	repo := &usageSessionRepoStub{sessions: []UsageSession{
+		{SessionID: "s1", InputTokens: 50, CacheReadTokens: 50},
+	}}
+	svc := NewUsageSessionService(repo)
+	userID := int64(7)
+
+	session, err := svc.GetSession(context.Background(), " s1 ", &userID)
+	require.NoError(t, err)
+	require.InDelta(t, 0.5, session.CacheHitRatio, 1e-9)
+	require.Equal(t, &userID, repo.getUserID)
+
+	_, err = svc.GetSession(context.Background(), "", &userID)
+	require.ErrorIs(t, err, ErrUsageSessionNotFound)
+
+	_, err = svc.GetSession(context.Background(), "missing", nil)
+	require.ErrorIs(t, err, ErrUsageSessionNotFound)
+}
@@ -29,6 +29,10 @@ type User struct {
 	SoraStorageQuotaBytes int64 // 用户级 Sora 存储配额（0 表示使用分组或系统默认值）
 	SoraStorageUsedBytes  int64 // Sora 存储已用量
 
+	// 公平排队配置（0 表示使用分组配置）
+	QueueWeight   int
+	QueuePriority int
+
 	// TOTP 双因素认证字段
 	TotpSecretEncrypted *string    // AES-256-GCM 加密的 TOTP 密钥
 	TotpEnabled         bool       // 是否启用 TOTP
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 用户提醒事件类型
+const (
+	UserNotificationBalanceLow           = "balance_low"
+	UserNotificationSubscriptionUsage80  = "subscription_usage_80"
+	UserNotificationSubscriptionUsage100 = "subscription_usage_100"
+	UserNotificationSubscriptionExpiring = "subscription_expiring"
+	UserNotificationAPIKeyQuota          = "api_key_quota"
+	UserNotificationAPIKeyExpiring       = "api_key_expiring"
+	// 自动续费结果：开启自动续费即视为订阅此类提醒
+	UserNotificationSubscriptionRenewed       = "subscription_renewed"
+	UserNotificationSubscriptionRenewalFailed = "subscription_renewal_failed"
+)
+
+// 提醒投递状态
+const (
+	UserNotificationDeliveryQueued  = "queued"
+	UserNotificationDeliverySent    = "sent"
+	UserNotificationDeliveryFailed  = "failed"
+	UserNotificationDeliverySkipped = "skipped"
+)
+
+// UserNotificationPreference 用户提醒偏好；各阈值为 0/nil 时表示关闭对应提醒
+type UserNotificationPreference struct {
+	UserID                  int64     `json:"user_id"`
+	EmailEnabled            bool      `json:"email_enabled"`
+	WebhookURL              string    `json:"webhook_url"`
+	WebhookSecret           string    `json:"-"`
+	BalanceThreshold        *float64  `json:"balance_threshold"`
+	SubscriptionUsageAlerts bool      `json:"subscription_usage_alerts"`
+	SubscriptionExpiryDays  int       `json:"subscription_expiry_days"`
+	APIKeyQuotaPercent      int       `json:"api_key_quota_percent"`
+	APIKeyExpiryDays        int       `json:"api_key_expiry_days"`
+	Locale                  string    `json:"locale"` // 邮件语言，为空表示使用站点默认语言
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// HasAnyAlert 是否开启了任一提醒
+func (p *UserNotificationPreference) HasAnyAlert() bool {
+	if p == nil {
+		return false
+	}
+	return (p.BalanceThreshold != nil && *p.BalanceThreshold > 0) ||
+		p.SubscriptionUsageAlerts ||
+		p.SubscriptionExpiryDays > 0 ||
+		p.APIKeyQuotaPercent > 0 ||
+		p.APIKeyExpiryDays > 0
+}
+
+// HasChannel 是否配置了至少一个投递渠道
+func (p *UserNotificationPreference) HasChannel() bool {
+	return p != nil && (p.EmailEnabled || p.WebhookURL != "")
+}
+
+// UserNotificationEvent 一条已触发的提醒（同时用作去重记录）
+type UserNotificationEvent struct {
+	ID            int64          `json:"id"`
+	UserID        int64          `json:"user_id"`
+	EventType     string         `json:"event_type"`
+	SubjectKey    string         `json:"subject_key"`
+	PeriodKey     string         `json:"period_key"`
+	Payload       map[string]any `json:"payload"`
+	EmailStatus   string         `json:"email_status"`
+	WebhookStatus string         `json:"webhook_status"`
+	WebhookError  string         `json:"webhook_error,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// UserNotificationExpiringItem 即将到期的订阅或 API Key（由到期扫描返回）
+type UserNotificationExpiringItem struct {
+	UserID    int64
+	SubjectID int64
+	Name      string
+	ExpiresAt time.Time
+}
+
+// UserNotificationRepository 用户提醒持久层接口
+type UserNotificationRepository interface {
+	GetPreference(ctx context.Context, userID int64) (*UserNotificationPreference, error)
+	UpsertPreference(ctx context.Context, pref *UserNotificationPreference) error
+
+	// CreateEventIfAbsent 按去重键写入提醒记录；已存在时返回 false
+	CreateEventIfAbsent(ctx context.Context, event *UserNotificationEvent) (bool, error)
+	UpdateEventDelivery(ctx context.Context, id int64, emailStatus, webhookStatus, webhookError string) error
+	ListEvents(ctx context.Context, userID int64, params pagination.PaginationParams) ([]UserNotificationEvent, *pagination.PaginationResult, error)
+
+	// ListExpiringSubscriptions 返回开启了到期提醒、且在各自提前天数内到期的有效订阅
+	ListExpiringSubscriptions(ctx context.Context, now time.Time) ([]UserNotificationExpiringItem, error)
+	// ListExpiringAPIKeys 返回开启了到期提醒、且在各自提前天数内到期的有效 API Key
+	ListExpiringAPIKeys(ctx context.Context, now time.Time) ([]UserNotificationExpiringItem, error)
+}
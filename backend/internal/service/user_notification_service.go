@@ -0,0 +1,751 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+)
+
+var (
+	ErrUserNotificationInvalidWebhook = infraerrors.BadRequest("USER_NOTIFICATION_INVALID_WEBHOOK", "invalid webhook url")
+	ErrUserNotificationInvalidValue   = infraerrors.BadRequest("USER_NOTIFICATION_INVALID_VALUE", "invalid notification preference")
+)
+
+const (
+	userNotificationPrefCacheTTL        = time.Minute
+	userNotificationSubscriptionWarnPct = 80
+	userNotificationMaxExpiryDays       = 90
+	userNotificationWebhookMaxBody      = 4 << 10
+)
+
+// UsageBillingNotifier 扣费完成后的回调（由网关扣费流程调用，必须非阻塞）
+type UsageBillingNotifier interface {
+	NotifyUsageBilled(userID, apiKeyID int64, subscriptionID *int64)
+}
+
+// UpdateUserNotificationPreferenceInput 更新提醒偏好的输入；nil 字段保持不变
+type UpdateUserNotificationPreferenceInput struct {
+	EmailEnabled            *bool
+	WebhookURL              *string
+	WebhookSecret           *string
+	BalanceThreshold        *float64 // <= 0 表示关闭
+	SubscriptionUsageAlerts *bool
+	SubscriptionExpiryDays  *int
+	APIKeyQuotaPercent      *int
+	APIKeyExpiryDays        *int
+	Locale                  *string // 空字符串表示使用站点默认语言
+}
+
+type userNotificationCheck struct {
+	checkBalance    bool
+	apiKeyIDs       map[int64]struct{}
+	subscriptionIDs map[int64]struct{}
+}
+
+type userNotificationPrefEntry struct {
+	pref      *UserNotificationPreference
+	expiresAt time.Time
+}
+
+// UserNotificationService 终端用户提醒：余额不足、订阅用量阈值、到期提醒。
+// 扣费后的检查通过内存队列合并后异步执行；到期类检查由订阅过期服务的定时 tick 触发，
+// 按 scan_interval_minutes 节流。每条提醒按去重键落库，同一周期内只发送一次。
+type UserNotificationService struct {
+	repo           UserNotificationRepository
+	userRepo       UserRepository
+	userSubRepo    UserSubscriptionRepository
+	apiKeyRepo     APIKeyRepository
+	emailQueue     *EmailQueueService
+	settingService *SettingService
+	cfg            *config.Config
+
+	mu            sync.Mutex
+	pending       map[int64]*userNotificationCheck
+	scanRequested bool
+	lastScanAt    time.Time
+
+	prefMu    sync.Mutex
+	prefCache map[int64]userNotificationPrefEntry
+
+	wakeCh    chan struct{}
+	stopCh    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewUserNotificationService(
+	repo UserNotificationRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	apiKeyRepo APIKeyRepository,
+	emailQueue *EmailQueueService,
+	settingService *SettingService,
+	cfg *config.Config,
+) *UserNotificationService {
+	return &UserNotificationService{
+		repo:           repo,
+		userRepo:       userRepo,
+		userSubRepo:    userSubRepo,
+		apiKeyRepo:     apiKeyRepo,
+		emailQueue:     emailQueue,
+		settingService: settingService,
+		cfg:            cfg,
+		pending:        make(map[int64]*userNotificationCheck),
+		prefCache:      make(map[int64]userNotificationPrefEntry),
+		wakeCh:         make(chan struct{}, 1),
+		stopCh:         make(chan struct{}),
+	}
+}
+
+func (s *UserNotificationService) enabled() bool {
+	return s != nil && s.repo != nil && s.cfg != nil && s.cfg.UserNotification.Enabled
+}
+
+func (s *UserNotificationService) Start() {
+	if !s.enabled() {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go s.loop()
+		logger.LegacyPrintf("service.user_notification", "[UserNotification] started (scan_interval=%dm)", s.cfg.UserNotification.ScanIntervalMinutes)
+	})
+}
+
+func (s *UserNotificationService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.stopCh != nil {
+			close(s.stopCh)
+		}
+	})
+	s.wg.Wait()
+}
+
+// NotifyUsageBilled 记录一次扣费，待后台合并检查；队列满时直接丢弃
+func (s *UserNotificationService) NotifyUsageBilled(userID, apiKeyID int64, subscriptionID *int64) {
+	if !s.enabled() || userID <= 0 {
+		return
+	}
+	s.mu.Lock()
+	check, ok := s.pending[userID]
+	if !ok {
+		if len(s.pending) >= s.cfg.UserNotification.QueueSize {
+			s.mu.Unlock()
+			return
+		}
+		check = &userNotificationCheck{}
+		s.pending[userID] = check
+	}
+	if subscriptionID != nil && *subscriptionID > 0 {
+		if check.subscriptionIDs == nil {
+			check.subscriptionIDs = make(map[int64]struct{})
+		}
+		check.subscriptionIDs[*subscriptionID] = struct{}{}
+	} else {
+		check.checkBalance = true
+	}
+	if apiKeyID > 0 {
+		if check.apiKeyIDs == nil {
+			check.apiKeyIDs = make(map[int64]struct{})
+		}
+		check.apiKeyIDs[apiKeyID] = struct{}{}
+	}
+	s.mu.Unlock()
+	s.wake()
+}
+
+// OnExpiryTick 由订阅过期服务的定时 tick 调用，到达扫描间隔时触发一次到期扫描
+func (s *UserNotificationService) OnExpiryTick() {
+	if !s.enabled() {
+		return
+	}
+	interval := time.Duration(s.cfg.UserNotification.ScanIntervalMinutes) * time.Minute
+	s.mu.Lock()
+	if s.scanRequested || time.Since(s.lastScanAt) < interval {
+		s.mu.Unlock()
+		return
+	}
+	s.scanRequested = true
+	s.mu.Unlock()
+	s.wake()
+}
+
+func (s *UserNotificationService) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *UserNotificationService) loop() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.wakeCh:
+			s.drain()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *UserNotificationService) drain() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[int64]*userNotificationCheck)
+	scan := s.scanRequested
+	s.mu.Unlock()
+
+	for userID, check := range pending {
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := s.checkUser(ctx, userID, check); err != nil {
+			logger.LegacyPrintf("service.user_notification", "[UserNotification] check failed: user=%d err=%v", userID, err)
+		}
+		cancel()
+	}
+
+	if scan {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		s.runExpiryScan(ctx, time.Now())
+		cancel()
+		s.mu.Lock()
+		s.scanRequested = false
+		s.lastScanAt = time.Now()
+		s.mu.Unlock()
+	}
+}
+
+func (s *UserNotificationService) checkUser(ctx context.Context, userID int64, check *userNotificationCheck) error {
+	pref, err := s.cachedPreference(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !pref.HasAnyAlert() || !pref.HasChannel() {
+		return nil
+	}
+
+	if check.checkBalance && pref.BalanceThreshold != nil && *pref.BalanceThreshold > 0 {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.Balance < *pref.BalanceThreshold {
+			// 余额提醒按自然日去重
+			period := timezone.Now().Format("2006-01-02")
+			s.emit(ctx, pref, UserNotificationBalanceLow, "", period, map[string]any{
+				"balance":   roundNotificationAmount(user.Balance),
+				"threshold": *pref.BalanceThreshold,
+			})
+		}
+	}
+
+	if pref.SubscriptionUsageAlerts {
+		for subID := range check.subscriptionIDs {
+			sub, err := s.userSubRepo.GetByID(ctx, subID)
+			if err != nil {
+				if errors.Is(err, ErrSubscriptionNotFound) {
+					continue
+				}
+				return err
+			}
+			if sub.UserID != userID || sub.Group == nil {
+				continue
+			}
+			s.checkSubscriptionUsage(ctx, pref, sub)
+		}
+	}
+
+	if pref.APIKeyQuotaPercent > 0 {
+		for keyID := range check.apiKeyIDs {
+			key, err := s.apiKeyRepo.GetByID(ctx, keyID)
+			if err != nil {
+				if errors.Is(err, ErrAPIKeyNotFound) {
+					continue
+				}
+				return err
+			}
+			if key.UserID != userID || key.Quota <= 0 {
+				continue
+			}
+			percent := key.QuotaUsed / key.Quota * 100
+			if percent < float64(pref.APIKeyQuotaPercent) {
+				continue
+			}
+			// 以额度值作为周期键：管理员/用户调整额度后可再次提醒
+			period := "quota:" + strconv.FormatFloat(key.Quota, 'f', -1, 64)
+			s.emit(ctx, pref, UserNotificationAPIKeyQuota, strconv.FormatInt(key.ID, 10), period, map[string]any{
+				"api_key_id":   key.ID,
+				"api_key_name": key.Name,
+				"quota":        key.Quota,
+				"quota_used":   roundNotificationAmount(key.QuotaUsed),
+				"percent":      math.Floor(percent),
+			})
+		}
+	}
+	return nil
+}
+
+func (s *UserNotificationService) checkSubscriptionUsage(ctx context.Context, pref *UserNotificationPreference, sub *UserSubscription) {
+	type window struct {
+		name  string
+		has   bool
+		limit *float64
+		usage float64
+		start *time.Time
+	}
+	windows := []window{
+		{name: "daily", has: sub.Group.HasDailyLimit(), limit: sub.Group.DailyLimitUSD, usage: sub.DailyUsageUSD, start: sub.DailyWindowStart},
+		{name: "weekly", has: sub.Group.HasWeeklyLimit(), limit: sub.Group.WeeklyLimitUSD, usage: sub.WeeklyUsageUSD, start: sub.WeeklyWindowStart},
+		{name: "monthly", has: sub.Group.HasMonthlyLimit(), limit: sub.Group.MonthlyLimitUSD, usage: sub.MonthlyUsageUSD, start: sub.MonthlyWindowStart},
+	}
+	for _, w := range windows {
+		if !w.has || w.start == nil {
+			continue
+		}
+		limit := *w.limit
+		percent := w.usage / limit * 100
+		eventType := ""
+		switch {
+		case percent >= 100:
+			eventType = UserNotificationSubscriptionUsage100
+		case percent >= userNotificationSubscriptionWarnPct:
+			eventType = UserNotificationSubscriptionUsage80
+		default:
+			continue
+		}
+		// 按用量窗口去重：窗口重置后重新提醒
+		period := w.name + ":" + strconv.FormatInt(w.start.Unix(), 10)
+		s.emit(ctx, pref, eventType, strconv.FormatInt(sub.ID, 10), period, map[string]any{
+			"subscription_id": sub.ID,
+			"group_id":        sub.GroupID,
+			"group_name":      sub.Group.Name,
+			"window":          w.name,
+			"usage_usd":       roundNotificationAmount(w.usage),
+			"limit_usd":       limit,
+			"percent":         math.Floor(percent),
+		})
+	}
+}
+
+func (s *UserNotificationService) runExpiryScan(ctx context.Context, now time.Time) {
+	subs, err := s.repo.ListExpiringSubscriptions(ctx, now)
+	if err != nil {
+		logger.LegacyPrintf("service.user_notification", "[UserNotification] list expiring subscriptions failed: %v", err)
+	}
+	for _, item := range subs {
+		s.emitExpiring(ctx, UserNotificationSubscriptionExpiring, item, map[string]any{
+			"subscription_id": item.SubjectID,
+			"group_name":      item.Name,
+		})
+	}
+
+	keys, err := s.repo.ListExpiringAPIKeys(ctx, now)
+	if err != nil {
+		logger.LegacyPrintf("service.user_notification", "[UserNotification] list expiring api keys failed: %v", err)
+	}
+	for _, item := range keys {
+		s.emitExpiring(ctx, UserNotificationAPIKeyExpiring, item, map[string]any{
+			"api_key_id":   item.SubjectID,
+			"api_key_name": item.Name,
+		})
+	}
+}
+
+// NotifySubscriptionRenewal 通知自动续费结果；同一续费周期内成功/失败各只提醒一次
+func (s *UserNotificationService) NotifySubscriptionRenewal(ctx context.Context, charge *SubscriptionCharge) {
+	if !s.enabled() || charge == nil || charge.SubscriptionID == nil || charge.PeriodExpiresAt == nil {
+		return
+	}
+	pref, err := s.cachedPreference(ctx, charge.UserID)
+	if err != nil || !pref.HasChannel() {
+		return
+	}
+	eventType := UserNotificationSubscriptionRenewalFailed
+	payload := map[string]any{
+		"subscription_id": *charge.SubscriptionID,
+		"group_name":      charge.GroupName,
+		"amount":          roundNotificationAmount(charge.Amount),
+		"period_days":     charge.PeriodDays,
+		"status":          charge.Status,
+		"expires_at":      *charge.PeriodExpiresAt,
+	}
+	if charge.Status == SubscriptionChargeStatusSucceeded {
+		eventType = UserNotificationSubscriptionRenewed
+		if charge.NewExpiresAt != nil {
+			payload["expires_at"] = *charge.NewExpiresAt
+		}
+	}
+	period := "expires:" + strconv.FormatInt(charge.PeriodExpiresAt.Unix(), 10)
+	s.emit(ctx, pref, eventType, strconv.FormatInt(*charge.SubscriptionID, 10), period, payload)
+}
+
+func (s *UserNotificationService) emitExpiring(ctx context.Context, eventType string, item UserNotificationExpiringItem, payload map[string]any) {
+	pref, err := s.cachedPreference(ctx, item.UserID)
+	if err != nil || !pref.HasChannel() {
+		return
+	}
+	payload["expires_at"] = item.ExpiresAt
+	payload["days_remaining"] = int(math.Ceil(time.Until(item.ExpiresAt).Hours() / 24))
+	// 以到期时间作为周期键：续期后可再次提醒
+	period := "expires:" + strconv.FormatInt(item.ExpiresAt.Unix(), 10)
+	s.emit(ctx, pref, eventType, strconv.FormatInt(item.SubjectID, 10), period, payload)
+}
+
+// emit 写入去重记录，首次写入成功时投递邮件与 Webhook
+func (s *UserNotificationService) emit(ctx context.Context, pref *UserNotificationPreference, eventType, subjectKey, periodKey string, payload map[string]any) {
+	event := &UserNotificationEvent{
+		UserID:     pref.UserID,
+		EventType:  eventType,
+		SubjectKey: subjectKey,
+		PeriodKey:  periodKey,
+		Payload:    payload,
+	}
+	created, err := s.repo.CreateEventIfAbsent(ctx, event)
+	if err != nil {
+		logger.LegacyPrintf("service.user_notification", "[UserNotification] record event failed: user=%d type=%s err=%v", pref.UserID, eventType, err)
+		return
+	}
+	if !created {
+		return
+	}
+
+	emailStatus := s.deliverEmail(ctx, pref, event)
+	webhookStatus, webhookErr := s.deliverWebhook(ctx, pref, event)
+	if len(webhookErr) > 500 {
+		webhookErr = webhookErr[:500]
+	}
+	if err := s.repo.UpdateEventDelivery(ctx, event.ID, emailStatus, webhookStatus, webhookErr); err != nil {
+		logger.LegacyPrintf("service.user_notification", "[UserNotification] update delivery failed: event=%d err=%v", event.ID, err)
+	}
+}
+
+func (s *UserNotificationService) deliverEmail(ctx context.Context, pref *UserNotificationPreference, event *UserNotificationEvent) string {
+	if !pref.EmailEnabled {
+		return ""
+	}
+	if s.emailQueue == nil || s.userRepo == nil {
+		return UserNotificationDeliverySkipped
+	}
+	user, err := s.userRepo.GetByID(ctx, pref.UserID)
+	if err != nil || strings.TrimSpace(user.Email) == "" {
+		return UserNotificationDeliverySkipped
+	}
+	siteName := "Sub2API"
+	if s.settingService != nil {
+		siteName = s.settingService.GetSiteName(ctx)
+	}
+	data := buildUserNotificationEmailData(siteName, event)
+	if err := s.emailQueue.EnqueueNotification(user.Email, EmailTemplateUserNotification, pref.Locale, data); err != nil {
+		logger.LegacyPrintf("service.user_notification", "[UserNotification] enqueue email failed: event=%d err=%v", event.ID, err)
+		return UserNotificationDeliveryFailed
+	}
+	return UserNotificationDeliveryQueued
+}
+
+// userNotificationWebhookBody Webhook 推送体
+type userNotificationWebhookBody struct {
+	ID        int64          `json:"id"`
+	Event     string         `json:"event"`
+	UserID    int64          `json:"user_id"`
+	Data      map[string]any `json:"data"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// deliverWebhook 推送 Webhook；配置了 secret 时附带
+// X-Sub2API-Signature: sha256=HMAC_SHA256(secret, "<timestamp>.<body>")
+func (s *UserNotificationService) deliverWebhook(ctx context.Context, pref *UserNotificationPreference, event *UserNotificationEvent) (string, string) {
+	if pref.WebhookURL == "" {
+		return "", ""
+	}
+	if _, err := s.validateWebhookURL(pref.WebhookURL); err != nil {
+		return UserNotificationDeliveryFailed, infraerrors.Message(err)
+	}
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	body, err := json.Marshal(userNotificationWebhookBody{
+		ID:        event.ID,
+		Event:     event.EventType,
+		UserID:    event.UserID,
+		Data:      event.Payload,
+		CreatedAt: createdAt.UTC(),
+	})
+	if err != nil {
+		return UserNotificationDeliveryFailed, err.Error()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pref.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return UserNotificationDeliveryFailed, err.Error()
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sub2API-Notifier")
+	req.Header.Set("X-Sub2API-Event", event.EventType)
+	req.Header.Set("X-Sub2API-Timestamp", timestamp)
+	if pref.WebhookSecret != "" {
+		req.Header.Set("X-Sub2API-Signature", "sha256="+signUserNotificationWebhook(pref.WebhookSecret, timestamp, body))
+	}
+
+	client, err := s.webhookClient()
+	if err != nil {
+		return UserNotificationDeliveryFailed, err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return UserNotificationDeliveryFailed, err.Error()
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, userNotificationWebhookMaxBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return UserNotificationDeliveryFailed, fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return UserNotificationDeliverySent, ""
+}
+
+func signUserNotificationWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *UserNotificationService) webhookClient() (*http.Client, error) {
+	allowPrivate := s.cfg != nil && s.cfg.Security.URLAllowlist.AllowPrivateHosts
+	timeout := 10 * time.Second
+	if s.cfg != nil && s.cfg.UserNotification.WebhookTimeoutSeconds > 0 {
+		timeout = time.Duration(s.cfg.UserNotification.WebhookTimeoutSeconds) * time.Second
+	}
+	shared, err := httpclient.GetClient(httpclient.Options{
+		Timeout:            timeout,
+		ValidateResolvedIP: true,
+		AllowPrivateHosts:  allowPrivate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 复制共享客户端并禁止跟随重定向，避免绕过地址校验
+	client := *shared
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &client, nil
+}
+
+// validateWebhookURL 校验用户 Webhook 地址（用户可控地址，始终阻断内网，除非显式允许私网）
+func (s *UserNotificationService) validateWebhookURL(raw string) (string, error) {
+	allowInsecureHTTP := false
+	allowPrivate := false
+	if s.cfg != nil {
+		allowInsecureHTTP = s.cfg.Security.URLAllowlist.AllowInsecureHTTP
+		allowPrivate = s.cfg.Security.URLAllowlist.AllowPrivateHosts
+	}
+	normalized, err := urlvalidator.ValidateHTTPURL(raw, allowInsecureHTTP, urlvalidator.ValidationOptions{
+		AllowPrivate: allowPrivate,
+	})
+	if err != nil {
+		return "", infraerrors.BadRequest(ErrUserNotificationInvalidWebhook.Reason, "invalid webhook url: "+err.Error())
+	}
+	return normalized, nil
+}
+
+// GetPreference 获取用户提醒偏好；未设置时返回默认值（全部关闭）
+func (s *UserNotificationService) GetPreference(ctx context.Context, userID int64) (*UserNotificationPreference, error) {
+	pref, err := s.repo.GetPreference(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pref == nil {
+		pref = defaultUserNotificationPreference(userID)
+	}
+	return pref, nil
+}
+
+// UpdatePreference 更新用户提醒偏好
+func (s *UserNotificationService) UpdatePreference(ctx context.Context, userID int64, in UpdateUserNotificationPreferenceInput) (*UserNotificationPreference, error) {
+	pref, err := s.GetPreference(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.EmailEnabled != nil {
+		pref.EmailEnabled = *in.EmailEnabled
+	}
+	if in.WebhookURL != nil {
+		raw := strings.TrimSpace(*in.WebhookURL)
+		if raw == "" {
+			pref.WebhookURL = ""
+			pref.WebhookSecret = ""
+		} else {
+			normalized, err := s.validateWebhookURL(raw)
+			if err != nil {
+				return nil, err
+			}
+			pref.WebhookURL = normalized
+		}
+	}
+	if in.WebhookSecret != nil {
+		pref.WebhookSecret = strings.TrimSpace(*in.WebhookSecret)
+	}
+	if in.BalanceThreshold != nil {
+		if math.IsNaN(*in.BalanceThreshold) || math.IsInf(*in.BalanceThreshold, 0) {
+			return nil, ErrUserNotificationInvalidValue
+		}
+		if *in.BalanceThreshold <= 0 {
+			pref.BalanceThreshold = nil
+		} else {
+			v := *in.BalanceThreshold
+			pref.BalanceThreshold = &v
+		}
+	}
+	if in.SubscriptionUsageAlerts != nil {
+		pref.SubscriptionUsageAlerts = *in.SubscriptionUsageAlerts
+	}
+	if in.SubscriptionExpiryDays != nil {
+		if *in.SubscriptionExpiryDays < 0 || *in.SubscriptionExpiryDays > userNotificationMaxExpiryDays {
+			return nil, infraerrors.BadRequest("USER_NOTIFICATION_INVALID_VALUE", fmt.Sprintf("subscription_expiry_days must be between 0 and %d", userNotificationMaxExpiryDays))
+		}
+		pref.SubscriptionExpiryDays = *in.SubscriptionExpiryDays
+	}
+	if in.APIKeyQuotaPercent != nil {
+		if *in.APIKeyQuotaPercent < 0 || *in.APIKeyQuotaPercent > 100 {
+			return nil, infraerrors.BadRequest("USER_NOTIFICATION_INVALID_VALUE", "api_key_quota_percent must be between 0 and 100")
+		}
+		pref.APIKeyQuotaPercent = *in.APIKeyQuotaPercent
+	}
+	if in.APIKeyExpiryDays != nil {
+		if *in.APIKeyExpiryDays < 0 || *in.APIKeyExpiryDays > userNotificationMaxExpiryDays {
+			return nil, infraerrors.BadRequest("USER_NOTIFICATION_INVALID_VALUE", fmt.Sprintf("api_key_expiry_days must be between 0 and %d", userNotificationMaxExpiryDays))
+		}
+		pref.APIKeyExpiryDays = *in.APIKeyExpiryDays
+	}
+	if in.Locale != nil {
+		raw := strings.TrimSpace(*in.Locale)
+		locale := NormalizeEmailLocale(raw)
+		if raw != "" && locale == "" {
+			return nil, ErrEmailLocaleInvalid
+		}
+		pref.Locale = locale
+	}
+
+	if err := s.repo.UpsertPreference(ctx, pref); err != nil {
+		return nil, err
+	}
+	s.prefMu.Lock()
+	delete(s.prefCache, userID)
+	s.prefMu.Unlock()
+	return pref, nil
+}
+
+// ListEvents 列出用户的提醒记录
+func (s *UserNotificationService) ListEvents(ctx context.Context, userID int64, params pagination.PaginationParams) ([]UserNotificationEvent, *pagination.PaginationResult, error) {
+	return s.repo.ListEvents(ctx, userID, params)
+}
+
+func (s *UserNotificationService) cachedPreference(ctx context.Context, userID int64) (*UserNotificationPreference, error) {
+	now := time.Now()
+	s.prefMu.Lock()
+	if entry, ok := s.prefCache[userID]; ok && now.Before(entry.expiresAt) {
+		s.prefMu.Unlock()
+		return entry.pref, nil
+	}
+	s.prefMu.Unlock()
+
+	pref, err := s.GetPreference(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.prefMu.Lock()
+	s.prefCache[userID] = userNotificationPrefEntry{pref: pref, expiresAt: now.Add(userNotificationPrefCacheTTL)}
+	s.prefMu.Unlock()
+	return pref, nil
+}
+
+func defaultUserNotificationPreference(userID int64) *UserNotificationPreference {
+	return &UserNotificationPreference{
+		UserID:       userID,
+		EmailEnabled: true,
+	}
+}
+
+func roundNotificationAmount(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// userNotificationMessage 返回提醒的标题与正文（纯文本）
+func userNotificationMessage(event *UserNotificationEvent) (string, string) {
+	p := event.Payload
+	str := func(key string) string { return fmt.Sprint(p[key]) }
+	expires := func() string {
+		if t, ok := p["expires_at"].(time.Time); ok {
+			return t.In(timezone.Location()).Format("2006-01-02 15:04")
+		}
+		return str("expires_at")
+	}
+	switch event.EventType {
+	case UserNotificationBalanceLow:
+		return "Low balance", fmt.Sprintf("Your balance is $%s, below your alert threshold of $%s. Please top up to avoid service interruption.", str("balance"), str("threshold"))
+	case UserNotificationSubscriptionUsage80:
+		return "Subscription usage at 80%", fmt.Sprintf("Your %s usage of subscription \"%s\" has reached %s%% ($%s of $%s).", str("window"), str("group_name"), str("percent"), str("usage_usd"), str("limit_usd"))
+	case UserNotificationSubscriptionUsage100:
+		return "Subscription usage limit reached", fmt.Sprintf("Your %s usage of subscription \"%s\" has reached its limit ($%s of $%s). Requests will be rejected until the window resets.", str("window"), str("group_name"), str("usage_usd"), str("limit_usd"))
+	case UserNotificationSubscriptionExpiring:
+		return "Subscription expiring soon", fmt.Sprintf("Your subscription \"%s\" expires at %s (%s day(s) remaining).", str("group_name"), expires(), str("days_remaining"))
+	case UserNotificationAPIKeyQuota:
+		return "API key quota almost used", fmt.Sprintf("API key \"%s\" has used %s%% of its quota ($%s of $%s).", str("api_key_name"), str("percent"), str("quota_used"), str("quota"))
+	case UserNotificationAPIKeyExpiring:
+		return "API key expiring soon", fmt.Sprintf("API key \"%s\" expires at %s (%s day(s) remaining).", str("api_key_name"), expires(), str("days_remaining"))
+	case UserNotificationSubscriptionRenewed:
+		return "Subscription renewed", fmt.Sprintf("Your subscription \"%s\" was renewed for %s day(s). $%s was charged from your balance; it now expires at %s.", str("group_name"), str("period_days"), str("amount"), expires())
+	case UserNotificationSubscriptionRenewalFailed:
+		if str("status") == SubscriptionChargeStatusInsufficientBalance {
+			return "Subscription renewal failed", fmt.Sprintf("We could not renew your subscription \"%s\" (expires at %s): your balance is below the renewal price of $%s. Please top up; renewal will be retried automatically.", str("group_name"), expires(), str("amount"))
+		}
+		return "Subscription renewal failed", fmt.Sprintf("We could not renew your subscription \"%s\" (expires at %s). Renewal will be retried automatically.", str("group_name"), expires())
+	default:
+		return event.EventType, ""
+	}
+}
+
+// buildUserNotificationEmailData 构建提醒邮件的模板数据
+func buildUserNotificationEmailData(siteName string, event *UserNotificationEvent) UserNotificationEmailData {
+	title, message := userNotificationMessage(event)
+	return UserNotificationEmailData{
+		SiteName:  siteName,
+		EventType: event.EventType,
+		Title:     title,
+		Message:   message,
+		Payload:   event.Payload,
+	}
+}
@@ -0,0 +1,270 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type userNotificationRepoStub struct {
+	mu     sync.Mutex
+	pref   *UserNotificationPreference
+	events []*UserNotificationEvent
+}
+
+func (s *userNotificationRepoStub) GetPreference(_ context.Context, userID int64) (*UserNotificationPreference, error) {
+	if s.pref == nil || s.pref.UserID != userID {
+		return nil, nil
+	}
+	cp := *s.pref
+	return &cp, nil
+}
+
+func (s *userNotificationRepoStub) UpsertPreference(_ context.Context, pref *UserNotificationPreference) error {
+	cp := *pref
+	s.pref = &cp
+	return nil
+}
+
+func (s *userNotificationRepoStub) CreateEventIfAbsent(_ context.Context, event *UserNotificationEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.events {
+		if e.UserID == event.UserID && e.EventType == event.EventType && e.SubjectKey == event.SubjectKey && e.PeriodKey == event.PeriodKey {
+			return false, nil
+		}
+	}
+	event.ID = int64(len(s.events) + 1)
+	event.CreatedAt = time.Now()
+	s.events = append(s.events, event)
+	return true, nil
+}
+
+func (s *userNotificationRepoStub) UpdateEventDelivery(_ context.Context, id int64, emailStatus, webhookStatus, webhookError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.events {
+		if e.ID == id {
+			e.EmailStatus, e.WebhookStatus, e.WebhookError = emailStatus, webhookStatus, webhookError
+		}
+	}
+	return nil
+}
+
+func (s *userNotificationRepoStub) ListEvents(context.Context, int64, pagination.PaginationParams) ([]UserNotificationEvent, *pagination.PaginationResult, error) {
+	panic("unexpected ListEvents call")
+}
+
+func (s *userNotificationRepoStub) ListExpiringSubscriptions(context.Context, time.Time) ([]UserNotificationExpiringItem, error) {
+	return nil, nil
+}
+
+func (s *userNotificationRepoStub) ListExpiringAPIKeys(context.Context, time.Time) ([]UserNotificationExpiringItem, error) {
+	return nil, nil
+}
+
+type notificationSubRepoStub struct {
+	userSubRepoNoop
+	sub *UserSubscription
+}
+
+func (s *notificationSubRepoStub) GetByID(context.Context, int64) (*UserSubscription, error) {
+	if s.sub == nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	cp := *s.sub
+	return &cp, nil
+}
+
+func newUserNotificationServiceForTest(repo *userNotificationRepoStub, user *User, sub *UserSubscription, key *APIKey) *UserNotificationService {
+	cfg := &config.Config{}
+	cfg.UserNotification.Enabled = true
+	cfg.UserNotification.QueueSize = 2
+	cfg.UserNotification.ScanIntervalMinutes = 60
+	cfg.UserNotification.WebhookTimeoutSeconds = 5
+	cfg.Security.URLAllowlist.AllowInsecureHTTP = true
+	cfg.Security.URLAllowlist.AllowPrivateHosts = true
+	return NewUserNotificationService(repo, &userRepoStub{user: user}, &notificationSubRepoStub{sub: sub}, &apiKeyRepoStub{apiKey: key}, nil, nil, cfg)
+}
+
+func TestUserNotification_BalanceLowDedupedPerDayWithSignedWebhook(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   [][]byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	threshold := 5.0
+	repo := &userNotificationRepoStub{pref: &UserNotificationPreference{
+		UserID:           7,
+		WebhookURL:       srv.URL,
+		WebhookSecret:    "s3cret",
+		BalanceThreshold: &threshold,
+	}}
+	svc := newUserNotificationServiceForTest(repo, &User{ID: 7, Email: "u@example.com", Balance: 1.5}, nil, nil)
+
+	check := &userNotificationCheck{checkBalance: true}
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+
+	require.Len(t, repo.events, 1)
+	event := repo.events[0]
+	require.Equal(t, UserNotificationBalanceLow, event.EventType)
+	require.Equal(t, UserNotificationDeliverySent, event.WebhookStatus)
+	require.Empty(t, event.EmailStatus)
+
+	require.Len(t, received, 1)
+	req := received[0]
+	require.Equal(t, UserNotificationBalanceLow, req.Header.Get("X-Sub2API-Event"))
+	ts := req.Header.Get("X-Sub2API-Timestamp")
+	require.Equal(t, "sha256="+signUserNotificationWebhook("s3cret", ts, bodies[0]), req.Header.Get("X-Sub2API-Signature"))
+
+	var payload userNotificationWebhookBody
+	require.NoError(t, json.Unmarshal(bodies[0], &payload))
+	require.Equal(t, int64(7), payload.UserID)
+	require.InDelta(t, 1.5, payload.Data["balance"], 1e-9)
+}
+
+func TestUserNotification_SubscriptionUsageThresholds(t *testing.T) {
+	limit := 10.0
+	windowStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := &UserSubscription{
+		ID: 3, UserID: 7, GroupID: 2,
+		DailyWindowStart: &windowStart,
+		DailyUsageUSD:    8.5,
+		Group:            &Group{ID: 2, Name: "pro", DailyLimitUSD: &limit},
+	}
+	repo := &userNotificationRepoStub{pref: &UserNotificationPreference{UserID: 7, EmailEnabled: true, SubscriptionUsageAlerts: true}}
+	svc := newUserNotificationServiceForTest(repo, &User{ID: 7}, sub, nil)
+	check := &userNotificationCheck{subscriptionIDs: map[int64]struct{}{3: {}}}
+
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+	require.Len(t, repo.events, 1)
+	require.Equal(t, UserNotificationSubscriptionUsage80, repo.events[0].EventType)
+
+	sub.DailyUsageUSD = 10
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+	require.Len(t, repo.events, 2)
+	require.Equal(t, UserNotificationSubscriptionUsage100, repo.events[1].EventType)
+	require.Equal(t, "3", repo.events[1].SubjectKey)
+
+	// 同一窗口内不重复提醒；窗口重置后重新计算
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+	require.Len(t, repo.events, 2)
+	nextWindow := windowStart.Add(24 * time.Hour)
+	sub.DailyWindowStart = &nextWindow
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+	require.Len(t, repo.events, 3)
+}
+
+func TestUserNotification_APIKeyQuotaPeriodFollowsQuota(t *testing.T) {
+	key := &APIKey{ID: 11, UserID: 7, Name: "ci", Quota: 100, QuotaUsed: 91}
+	repo := &userNotificationRepoStub{pref: &UserNotificationPreference{UserID: 7, EmailEnabled: true, APIKeyQuotaPercent: 90}}
+	svc := newUserNotificationServiceForTest(repo, &User{ID: 7}, nil, key)
+	check := &userNotificationCheck{apiKeyIDs: map[int64]struct{}{11: {}}}
+
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+	require.NoError(t, svc.checkUser(context.Background(), 7, check))
+	require.Len(t, repo.events, 1)
+	require.Equal(t, "quota:"+strconv.Itoa(100), repo.events[0].PeriodKey)
+	// 邮件队列未配置时记录为 skipped
+	require.Equal(t, UserNotificationDeliverySkipped, repo.events[0].EmailStatus)
+}
+
+func TestUserNotification_OtherUsersResourcesIgnored(t *testing.T) {
+	key := &APIKey{ID: 11, UserID: 8, Quota: 1, QuotaUsed: 1}
+	repo := &userNotificationRepoStub{pref: &UserNotificationPreference{UserID: 7, EmailEnabled: true, APIKeyQuotaPercent: 50}}
+	svc := newUserNotificationServiceForTest(repo, &User{ID: 7}, nil, key)
+
+	require.NoError(t, svc.checkUser(context.Background(), 7, &userNotificationCheck{apiKeyIDs: map[int64]struct{}{11: {}}}))
+	require.Empty(t, repo.events)
+}
+
+func TestUserNotification_NotifyUsageBilledMergesAndBounds(t *testing.T) {
+	svc := newUserNotificationServiceForTest(&userNotificationRepoStub{}, &User{ID: 7}, nil, nil)
+	subID := int64(3)
+
+	svc.NotifyUsageBilled(1, 10, nil)
+	svc.NotifyUsageBilled(1, 11, &subID)
+	svc.NotifyUsageBilled(2, 20, nil)
+	svc.NotifyUsageBilled(3, 30, nil) // 超出队列容量，丢弃
+
+	require.Len(t, svc.pending, 2)
+	check := svc.pending[1]
+	require.True(t, check.checkBalance)
+	require.Len(t, check.apiKeyIDs, 2)
+	require.Contains(t, check.subscriptionIDs, subID)
+}
+
+func TestUserNotification_UpdatePreferenceValidation(t *testing.T) {
+	repo := &userNotificationRepoStub{}
+	svc := newUserNotificationServiceForTest(repo, &User{ID: 7}, nil, nil)
+	svc.cfg.Security.URLAllowlist.AllowPrivateHosts = false
+	svc.cfg.Security.URLAllowlist.AllowInsecureHTTP = false
+
+	ctx := context.Background()
+	private := "https://127.0.0.1/hook"
+	_, err := svc.UpdatePreference(ctx, 7, UpdateUserNotificationPreferenceInput{WebhookURL: &private})
+	require.ErrorIs(t, err, ErrUserNotificationInvalidWebhook)
+
+	insecure := "http://hooks.example.com/x"
+	_, err = svc.UpdatePreference(ctx, 7, UpdateUserNotificationPreferenceInput{WebhookURL: &insecure})
+	require.ErrorIs(t, err, ErrUserNotificationInvalidWebhook)
+
+	badPercent := 101
+	_, err = svc.UpdatePreference(ctx, 7, UpdateUserNotificationPreferenceInput{APIKeyQuotaPercent: &badPercent})
+	require.Error(t, err)
+
+	url := "https://hooks.example.com/x/"
+	threshold := 3.0
+	days := 7
+	pref, err := svc.UpdatePreference(ctx, 7, UpdateUserNotificationPreferenceInput{
+		WebhookURL:             &url,
+		BalanceThreshold:       &threshold,
+		SubscriptionExpiryDays: &days,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://hooks.example.com/x", pref.WebhookURL)
+	require.True(t, pref.EmailEnabled)
+	require.Equal(t, 7, repo.pref.SubscriptionExpiryDays)
+
+	zero := 0.0
+	pref, err = svc.UpdatePreference(ctx, 7, UpdateUserNotificationPreferenceInput{BalanceThreshold: &zero})
+	require.NoError(t, err)
+	require.Nil(t, pref.BalanceThreshold)
+}
+
+func TestRenderUserNotificationEmail_EscapesContent(t *testing.T) {
+	data := buildUserNotificationEmailData("Site", &UserNotificationEvent{
+		EventType: UserNotificationAPIKeyExpiring,
+		Payload:   map[string]any{"api_key_name": "<b>x</b>", "expires_at": time.Now(), "days_remaining": 2},
+	})
+	var templates *EmailTemplateService
+	msg, err := templates.Render(context.Background(), EmailTemplateUserNotification, "", data)
+	require.NoError(t, err)
+	require.Equal(t, "[Site] API key expiring soon", msg.Subject)
+	require.Contains(t, msg.HTML, "&lt;b&gt;x&lt;/b&gt;")
+	require.NotContains(t, msg.HTML, "<b>x</b>")
+	require.Contains(t, msg.Text, "<b>x</b>")
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// 用户限流响应头
+//
+// 上游返回的 anthropic-ratelimit-* / x-ratelimit-* 描述的是上游账号的限额，对用户没有意义。
+// 启用 gateway.user_rate_limit_headers 后，网关丢弃这些上游头部，改为按用户自身的限额生成同名头部：
+//   - 额度窗口（USD）：API Key 5h / 1d / 7d 限速、订阅日 / 周 / 月限额、API Key 总额度
+//
+// 同名窗口（如 API Key 7d 限速与订阅周限额）取使用率更高者。
+// 用户没有 RPM 限额，因此不输出 requests 限额头部；并发槽位随进行中的请求结束释放、没有重置时间，
+// 单独通过 x-sub2api-concurrency-* 头部输出，避免客户端按 requests 窗口做退避。
+
+const (
+	UserConcurrencyLimitHeader     = "x-sub2api-concurrency-limit"
+	UserConcurrencyRemainingHeader = "x-sub2api-concurrency-remaining"
+)
+
+const (
+	UserRateLimitStatusAllowed  = "allowed"
+	UserRateLimitStatusWarning  = "allowed_warning"
+	UserRateLimitStatusRejected = "rejected"
+)
+
+// 额度窗口名称，与 Anthropic unified 限流头中的窗口标识一致（30d 对应订阅月限额，total 为不重置的总额度）
+const (
+	UserRateLimitWindow5h    = "5h"
+	UserRateLimitWindow1d    = "1d"
+	UserRateLimitWindow7d    = "7d"
+	UserRateLimitWindow30d   = "30d"
+	UserRateLimitWindowTotal = "total"
+)
+
+var userRateLimitWindowOrder = []string{
+	UserRateLimitWindow5h,
+	UserRateLimitWindow1d,
+	UserRateLimitWindow7d,
+	UserRateLimitWindow30d,
+	UserRateLimitWindowTotal,
+}
+
+// UserRateLimitWindow 用户的一个额度窗口（USD）
+type UserRateLimitWindow struct {
+	Name     string
+	LimitUSD float64
+	UsedUSD  float64
+	// ResetAt 窗口重置时间，nil 表示窗口尚未开始或不会重置
+	ResetAt *time.Time
+}
+
+// RemainingUSD 返回窗口剩余额度
+func (w UserRateLimitWindow) RemainingUSD() float64 {
+	return math.Max(0, w.LimitUSD-w.UsedUSD)
+}
+
+// Utilization 返回窗口使用率（0-1，超额时可能大于 1）
+func (w UserRateLimitWindow) Utilization() float64 {
+	if w.LimitUSD <= 0 {
+		return 0
+	}
+	return math.Max(0, w.UsedUSD/w.LimitUSD)
+}
+
+// Status 根据使用率返回窗口状态
+func (w UserRateLimitWindow) Status(warningThreshold float64) string {
+	utilization := w.Utilization()
+	switch {
+	case utilization >= 1:
+		return UserRateLimitStatusRejected
+	case warningThreshold > 0 && utilization >= warningThreshold:
+		return UserRateLimitStatusWarning
+	default:
+		return UserRateLimitStatusAllowed
+	}
+}
+
+// UserRateLimitState 请求开始时用户的限额状态
+type UserRateLimitState struct {
+	// ConcurrencyLimit 用户并发上限，0 表示不限制
+	ConcurrencyLimit int
+	ConcurrencyInUse int
+	// Windows 按 5h / 1d / 7d / 30d / total 排序
+	Windows []UserRateLimitWindow
+}
+
+// ConcurrencyRemaining 返回剩余并发槽位
+func (st *UserRateLimitState) ConcurrencyRemaining() int {
+	return max(0, st.ConcurrencyLimit-st.ConcurrencyInUse)
+}
+
+// addWindow 添加额度窗口，同名窗口保留使用率更高者
+func (st *UserRateLimitState) addWindow(window UserRateLimitWindow) {
+	if window.LimitUSD <= 0 {
+		return
+	}
+	for i := range st.Windows {
+		if st.Windows[i].Name == window.Name {
+			if window.Utilization() > st.Windows[i].Utilization() {
+				st.Windows[i] = window
+			}
+			return
+		}
+	}
+	st.Windows = append(st.Windows, window)
+}
+
+// bindingWindow 返回最受约束的窗口（使用率最高，相同时取重置更晚者）
+func (st *UserRateLimitState) bindingWindow() *UserRateLimitWindow {
+	var binding *UserRateLimitWindow
+	for i := range st.Windows {
+		w := &st.Windows[i]
+		if binding == nil || w.Utilization() > binding.Utilization() ||
+			(w.Utilization() == binding.Utilization() && resetsLater(w.ResetAt, binding.ResetAt)) {
+			binding = w
+		}
+	}
+	return binding
+}
+
+// resetsLater 不重置的窗口（nil）视为最晚
+func resetsLater(a, b *time.Time) bool {
+	if a == nil {
+		return b != nil
+	}
+	return b != nil && a.After(*b)
+}
+
+func (st *UserRateLimitState) sortWindows() {
+	sorted := make([]UserRateLimitWindow, 0, len(st.Windows))
+	for _, name := range userRateLimitWindowOrder {
+		for _, w := range st.Windows {
+			if w.Name == name {
+				sorted = append(sorted, w)
+			}
+		}
+	}
+	st.Windows = sorted
+}
+
+// writeConcurrencyHeaders 写入用户并发槽位（不限并发时不写入）
+func (st *UserRateLimitState) writeConcurrencyHeaders(h http.Header) {
+	if st.ConcurrencyLimit <= 0 {
+		return
+	}
+	h.Set(UserConcurrencyLimitHeader, strconv.Itoa(st.ConcurrencyLimit))
+	h.Set(UserConcurrencyRemainingHeader, strconv.Itoa(st.ConcurrencyRemaining()))
+}
+
+// WriteAnthropicHeaders 按 Anthropic 限流头格式写入用户限额
+//   - anthropic-ratelimit-unified-{window}-utilization / -reset / -status：各额度窗口
+//   - anthropic-ratelimit-unified-status / -reset：最受约束窗口的状态与重置时间
+//   - x-sub2api-concurrency-limit / -remaining：并发槽位
+func (st *UserRateLimitState) WriteAnthropicHeaders(h http.Header, warningThreshold float64) {
+	st.writeConcurrencyHeaders(h)
+	for _, w := range st.Windows {
+		prefix := "anthropic-ratelimit-unified-" + w.Name + "-"
+		h.Set(prefix+"utilization", formatUtilization(w.Utilization()))
+		h.Set(prefix+"status", w.Status(warningThreshold))
+		if w.ResetAt != nil {
+			h.Set(prefix+"reset", strconv.FormatInt(w.ResetAt.Unix(), 10))
+		}
+	}
+	if binding := st.bindingWindow(); binding != nil {
+		h.Set("anthropic-ratelimit-unified-status", binding.Status(warningThreshold))
+		if binding.ResetAt != nil {
+			h.Set("anthropic-ratelimit-unified-reset", strconv.FormatInt(binding.ResetAt.Unix(), 10))
+		}
+	}
+}
+
+// WriteOpenAIHeaders 按 OpenAI 限流头格式（x-ratelimit-{limit|remaining|reset}-{resource}）写入用户限额
+//   - usd：最受约束的额度窗口，reset 为距重置的时长（如 "1h2m3s"）
+//   - x-sub2api-concurrency-limit / -remaining：并发槽位
+func (st *UserRateLimitState) WriteOpenAIHeaders(h http.Header, now time.Time) {
+	st.writeConcurrencyHeaders(h)
+	if binding := st.bindingWindow(); binding != nil {
+		h.Set("x-ratelimit-limit-usd", formatUSD(binding.LimitUSD))
+		h.Set("x-ratelimit-remaining-usd", formatUSD(binding.RemainingUSD()))
+		if binding.ResetAt != nil {
+			h.Set("x-ratelimit-reset-usd", formatResetDuration(binding.ResetAt.Sub(now)))
+		}
+	}
+}
+
+func formatUtilization(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10000)/10000, 'f', -1, 64)
+}
+
+func formatUSD(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
+
+func formatResetDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+	return d.Round(time.Second).String()
+}
+
+// UserRateLimitHeaderService 计算用户限额状态，供网关生成限流响应头
+type UserRateLimitHeaderService struct {
+	cfg                 *config.Config
+	billingCacheService *BillingCacheService
+	concurrencyService  *ConcurrencyService
+}
+
+// NewUserRateLimitHeaderService 创建用户限流响应头服务
+func NewUserRateLimitHeaderService(cfg *config.Config, billingCacheService *BillingCacheService, concurrencyService *ConcurrencyService) *UserRateLimitHeaderService {
+	return &UserRateLimitHeaderService{
+		cfg:                 cfg,
+		billingCacheService: billingCacheService,
+		concurrencyService:  concurrencyService,
+	}
+}
+
+// Enabled 是否启用用户限流响应头
+func (s *UserRateLimitHeaderService) Enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Gateway.UserRateLimitHeaders.Enabled
+}
+
+// WarningThreshold 返回 allowed_warning 的使用率阈值
+func (s *UserRateLimitHeaderService) WarningThreshold() float64 {
+	if s == nil || s.cfg == nil {
+		return 0
+	}
+	return s.cfg.Gateway.UserRateLimitHeaders.WarningThreshold
+}
+
+// GetState 读取用户当前的限额状态（缓存读取失败的部分省略，不影响请求）
+// concurrencyLimit 为用户并发上限；subscription 仅在订阅分组时使用
+func (s *UserRateLimitHeaderService) GetState(ctx context.Context, apiKey *APIKey, subscription *UserSubscription, concurrencyLimit int) *UserRateLimitState {
+	state := &UserRateLimitState{}
+	if apiKey == nil {
+		return state
+	}
+
+	if concurrencyLimit > 0 && s.concurrencyService != nil {
+		inUse, err := s.concurrencyService.GetUserConcurrency(ctx, apiKey.UserID)
+		if err != nil {
+			logger.LegacyPrintf("service.user_rate_limit_headers", "Warning: get user concurrency failed for user %d: %v", apiKey.UserID, err)
+		} else {
+			state.ConcurrencyLimit = concurrencyLimit
+			state.ConcurrencyInUse = inUse
+		}
+	}
+
+	s.addAPIKeyWindows(ctx, state, apiKey)
+	if apiKey.Group != nil && apiKey.Group.IsSubscriptionType() && subscription != nil {
+		s.addSubscriptionWindows(ctx, state, apiKey.Group, subscription)
+	}
+	state.sortWindows()
+	return state
+}
+
+func (s *UserRateLimitHeaderService) addAPIKeyWindows(ctx context.Context, state *UserRateLimitState, apiKey *APIKey) {
+	if apiKey.Quota > 0 {
+		state.addWindow(UserRateLimitWindow{Name: UserRateLimitWindowTotal, LimitUSD: apiKey.Quota, UsedUSD: apiKey.QuotaUsed})
+	}
+	if !apiKey.HasRateLimits() || s.billingCacheService == nil {
+		return
+	}
+	data := s.billingCacheService.GetAPIKeyRateLimitData(ctx, apiKey.ID)
+	if data == nil {
+		return
+	}
+	state.addWindow(UserRateLimitWindow{
+		Name:     UserRateLimitWindow5h,
+		LimitUSD: apiKey.RateLimit5h,
+		UsedUSD:  data.EffectiveUsage5h(),
+		ResetAt:  windowResetAt(data.Window5hStart, RateLimitWindow5h),
+	})
+	state.addWindow(UserRateLimitWindow{
+		Name:     UserRateLimitWindow1d,
+		LimitUSD: apiKey.RateLimit1d,
+		UsedUSD:  data.EffectiveUsage1d(),
+		ResetAt:  windowResetAt(data.Window1dStart, RateLimitWindow1d),
+	})
+	state.addWindow(UserRateLimitWindow{
+		Name:     UserRateLimitWindow7d,
+		LimitUSD: apiKey.RateLimit7d,
+		UsedUSD:  data.EffectiveUsage7d(),
+		ResetAt:  windowResetAt(data.Window7dStart, RateLimitWindow7d),
+	})
+}
+
+func (s *UserRateLimitHeaderService) addSubscriptionWindows(ctx context.Context, state *UserRateLimitState, group *Group, subscription *UserSubscription) {
+	daily, weekly, monthly := subscription.DailyUsageUSD, subscription.WeeklyUsageUSD, subscription.MonthlyUsageUSD
+	// 计费缓存中的用量比认证快照更新及时
+	if s.billingCacheService != nil {
+		if cached, err := s.billingCacheService.GetSubscriptionStatus(ctx, subscription.UserID, group.ID); err == nil && cached != nil {
+			daily, weekly, monthly = cached.DailyUsage, cached.WeeklyUsage, cached.MonthlyUsage
+		}
+	}
+	if group.HasDailyLimit() {
+		state.addWindow(subscriptionWindow(UserRateLimitWindow1d, *group.DailyLimitUSD, daily, subscription.DailyWindowStart, 24*time.Hour))
+	}
+	if group.HasWeeklyLimit() {
+		state.addWindow(subscriptionWindow(UserRateLimitWindow7d, *group.WeeklyLimitUSD, weekly, subscription.WeeklyWindowStart, 7*24*time.Hour))
+	}
+	if group.HasMonthlyLimit() {
+		state.addWindow(subscriptionWindow(UserRateLimitWindow30d, *group.MonthlyLimitUSD, monthly, subscription.MonthlyWindowStart, 30*24*time.Hour))
+	}
+}
+
+// subscriptionWindow 构建订阅额度窗口，已过期的窗口视为用量清零（下次请求时重新开窗）
+func subscriptionWindow(name string, limit, used float64, windowStart *time.Time, duration time.Duration) UserRateLimitWindow {
+	resetAt := windowResetAt(windowStart, duration)
+	if resetAt == nil {
+		used = 0
+	}
+	return UserRateLimitWindow{Name: name, LimitUSD: limit, UsedUSD: used, ResetAt: resetAt}
+}
+
+// windowResetAt 返回进行中窗口的重置时间，窗口未开始或已过期时返回 nil
+func windowResetAt(windowStart *time.Time, duration time.Duration) *time.Time {
+	if IsWindowExpired(windowStart, duration) {
+		return nil
+	}
+	t := windowStart.Add(duration)
+	return &t
+}
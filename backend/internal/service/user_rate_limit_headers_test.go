@@ -0,0 +1,130 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRateLimitState_AddWindowKeepsMostUtilized(t *testing.T) {
+	state := &UserRateLimitState{}
+	state.addWindow(UserRateLimitWindow{Name: UserRateLimitWindow7d, LimitUSD: 100, UsedUSD: 20})
+	state.addWindow(UserRateLimitWindow{Name: UserRateLimitWindow7d, LimitUSD: 50, UsedUSD: 40})
+	state.addWindow(UserRateLimitWindow{Name: UserRateLimitWindow7d, LimitUSD: 200, UsedUSD: 10})
+	state.addWindow(UserRateLimitWindow{Name: UserRateLimitWindow5h, LimitUSD: 0, UsedUSD: 10})
+	state.addWindow(UserRateLimitWindow{Name: UserRateLimitWindow5h, LimitUSD: 10, UsedUSD: 1})
+	state.sortWindows()
+
+	require.Len(t, state.Windows, 2, "未配置限额的窗口被忽略")
+	require.Equal(t, UserRateLimitWindow5h, state.Windows[0].Name)
+	require.Equal(t, UserRateLimitWindow7d, state.Windows[1].Name)
+	require.Equal(t, float64(50), state.Windows[1].LimitUSD, "同名窗口保留使用率更高者")
+}
+
+func TestUserRateLimitState_WriteAnthropicHeaders(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	reset5h := now.Add(2 * time.Hour)
+	reset7d := now.Add(72 * time.Hour)
+	state := &UserRateLimitState{
+		ConcurrencyLimit: 5,
+		ConcurrencyInUse: 2,
+		Windows: []UserRateLimitWindow{
+			{Name: UserRateLimitWindow5h, LimitUSD: 10, UsedUSD: 8.5, ResetAt: &reset5h},
+			{Name: UserRateLimitWindow7d, LimitUSD: 100, UsedUSD: 20, ResetAt: &reset7d},
+			{Name: UserRateLimitWindowTotal, LimitUSD: 500, UsedUSD: 100},
+		},
+	}
+
+	h := http.Header{}
+	state.WriteAnthropicHeaders(h, 0.8)
+	require.Equal(t, "5", h.Get(UserConcurrencyLimitHeader))
+	require.Equal(t, "3", h.Get(UserConcurrencyRemainingHeader))
+	require.Empty(t, h.Get("anthropic-ratelimit-requests-limit"), "用户没有 RPM 限额，不输出 requests 头部")
+	require.Empty(t, h.Get("anthropic-ratelimit-requests-reset"))
+	require.Equal(t, "0.85", h.Get("anthropic-ratelimit-unified-5h-utilization"))
+	require.Equal(t, UserRateLimitStatusWarning, h.Get("anthropic-ratelimit-unified-5h-status"))
+	require.Equal(t, "1700007200", h.Get("anthropic-ratelimit-unified-5h-reset"))
+	require.Equal(t, "0.2", h.Get("anthropic-ratelimit-unified-7d-utilization"))
+	require.Equal(t, UserRateLimitStatusAllowed, h.Get("anthropic-ratelimit-unified-7d-status"))
+	require.Equal(t, "0.2", h.Get("anthropic-ratelimit-unified-total-utilization"))
+	require.Empty(t, h.Get("anthropic-ratelimit-unified-total-reset"), "总额度不重置")
+	require.Equal(t, UserRateLimitStatusWarning, h.Get("anthropic-ratelimit-unified-status"))
+	require.Equal(t, "1700007200", h.Get("anthropic-ratelimit-unified-reset"), "整体重置时间取最受约束窗口")
+
+	// 与网关解析上游 429 的逻辑兼容：超额窗口被识别为 exceeded
+	state.Windows[0].UsedUSD = 12
+	h = http.Header{}
+	state.WriteAnthropicHeaders(h, 0.8)
+	require.Equal(t, UserRateLimitStatusRejected, h.Get("anthropic-ratelimit-unified-status"))
+	require.True(t, isAnthropicWindowExceeded(h, "5h"))
+	require.False(t, isAnthropicWindowExceeded(h, "7d"))
+}
+
+func TestUserRateLimitState_WriteOpenAIHeaders(t *testing.T) {
+	now := time.Now()
+	reset1d := now.Add(90*time.Minute + 20*time.Second)
+	state := &UserRateLimitState{
+		ConcurrencyLimit: 3,
+		ConcurrencyInUse: 5,
+		Windows: []UserRateLimitWindow{
+			{Name: UserRateLimitWindow1d, LimitUSD: 20, UsedUSD: 15.25, ResetAt: &reset1d},
+			{Name: UserRateLimitWindow30d, LimitUSD: 300, UsedUSD: 30},
+		},
+	}
+
+	h := http.Header{}
+	state.WriteOpenAIHeaders(h, now)
+	require.Equal(t, "3", h.Get(UserConcurrencyLimitHeader))
+	require.Equal(t, "0", h.Get(UserConcurrencyRemainingHeader))
+	require.Empty(t, h.Get("x-ratelimit-limit-requests"), "用户没有 RPM 限额，不输出 requests 头部")
+	require.Empty(t, h.Get("x-ratelimit-reset-requests"))
+	require.Equal(t, "20", h.Get("x-ratelimit-limit-usd"))
+	require.Equal(t, "4.75", h.Get("x-ratelimit-remaining-usd"))
+	require.Equal(t, "1h30m20s", h.Get("x-ratelimit-reset-usd"))
+
+	h = http.Header{}
+	(&UserRateLimitState{}).WriteOpenAIHeaders(h, now)
+	require.Empty(t, h, "无限额时不写入头部")
+}
+
+func TestUserRateLimitHeaderService_GetStateSubscriptionWindows(t *testing.T) {
+	daily := 10.0
+	monthly := 100.0
+	activeStart := time.Now().Add(-time.Hour)
+	expiredStart := time.Now().Add(-31 * 24 * time.Hour)
+	svc := NewUserRateLimitHeaderService(nil, nil, nil)
+
+	state := svc.GetState(context.Background(), &APIKey{
+		ID:        1,
+		UserID:    2,
+		Quota:     50,
+		QuotaUsed: 5,
+		Group: &Group{
+			ID:               3,
+			SubscriptionType: SubscriptionTypeSubscription,
+			DailyLimitUSD:    &daily,
+			MonthlyLimitUSD:  &monthly,
+		},
+	}, &UserSubscription{
+		UserID:             2,
+		DailyWindowStart:   &activeStart,
+		DailyUsageUSD:      4,
+		MonthlyWindowStart: &expiredStart,
+		MonthlyUsageUSD:    99,
+	}, 0)
+
+	require.Len(t, state.Windows, 3)
+	require.Equal(t, UserRateLimitWindow1d, state.Windows[0].Name)
+	require.Equal(t, float64(4), state.Windows[0].UsedUSD)
+	require.WithinDuration(t, activeStart.Add(24*time.Hour), *state.Windows[0].ResetAt, time.Second)
+	require.Equal(t, UserRateLimitWindow30d, state.Windows[1].Name)
+	require.Zero(t, state.Windows[1].UsedUSD, "已过期的订阅窗口视为用量清零")
+	require.Nil(t, state.Windows[1].ResetAt)
+	require.Equal(t, UserRateLimitWindowTotal, state.Windows[2].Name)
+	require.Zero(t, state.ConcurrencyLimit, "无并发服务时不输出并发限额")
+}
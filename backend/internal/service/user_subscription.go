@@ -23,6 +23,9 @@ type UserSubscription struct {
 	AssignedAt time.Time
 	Notes      string
 
+	// 到期前自动从余额扣费续订
+	AutoRenew bool
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
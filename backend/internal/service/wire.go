@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/google/wire"
@@ -18,8 +19,8 @@ type BuildInfo struct {
 }
 
 // ProvidePricingService creates and initializes PricingService
-func ProvidePricingService(cfg *config.Config, remoteClient PricingRemoteClient) (*PricingService, error) {
-	svc := NewPricingService(cfg, remoteClient)
+func ProvidePricingService(cfg *config.Config, remoteClient PricingRemoteClient, repo PricingRepository) (*PricingService, error) {
+	svc := NewPricingService(cfg, remoteClient, repo)
 	if err := svc.Initialize(); err != nil {
 		// Pricing service initialization failure should not block startup, use fallback prices
 		println("[Service] Warning: Pricing service initialization failed:", err.Error())
@@ -136,6 +137,72 @@ func ProvideUsageCleanupService(repo UsageCleanupRepository, timingWheel *Timing
 	return svc
 }
 
+// ProvideUsageExportService 创建并启动使用记录导出任务服务
+func ProvideUsageExportService(repo UsageExportRepository, usageRepo UsageLogRepository, backupService *BackupService, timingWheel *TimingWheelService, cfg *config.Config) *UsageExportService {
+	svc := NewUsageExportService(repo, usageRepo, backupService, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideUsageLogPartitionService 创建并启动 usage_logs 分区维护与归档服务
+func ProvideUsageLogPartitionService(repo UsageLogPartitionRepository, usageRepo UsageLogRepository, backupService *BackupService, timingWheel *TimingWheelService, db *sql.DB, cfg *config.Config) *UsageLogPartitionService {
+	svc := NewUsageLogPartitionService(repo, usageRepo, backupService, timingWheel, db, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideInvoiceService 创建账单服务并启动月度出账调度
+func ProvideInvoiceService(repo InvoiceRepository, userRepo UserRepository, cfg *config.Config) *InvoiceService {
+	svc := NewInvoiceService(repo, userRepo, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideRequestCaptureService creates and starts RequestCaptureService
+func ProvideRequestCaptureService(repo RequestCaptureRepository, settingRepo SettingRepository, encryptor SecretEncryptor, cfg *config.Config) *RequestCaptureService {
+	svc := NewRequestCaptureService(repo, settingRepo, encryptor, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideUserNotificationService creates and starts UserNotificationService,
+// and registers it as the post-billing notifier of the gateway services.
+func ProvideUserNotificationService(
+	repo UserNotificationRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	apiKeyRepo APIKeyRepository,
+	emailQueue *EmailQueueService,
+	settingService *SettingService,
+	gatewayService *GatewayService,
+	openAIGatewayService *OpenAIGatewayService,
+	cfg *config.Config,
+) *UserNotificationService {
+	svc := NewUserNotificationService(repo, userRepo, userSubRepo, apiKeyRepo, emailQueue, settingService, cfg)
+	if cfg.UserNotification.Enabled {
+		gatewayService.SetUsageNotifier(svc)
+		openAIGatewayService.SetUsageNotifier(svc)
+	}
+	svc.Start()
+	return svc
+}
+
+// ProvideSubscriptionRenewalService creates and starts SubscriptionRenewalService.
+func ProvideSubscriptionRenewalService(
+	repo SubscriptionChargeRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+	notifier *UserNotificationService,
+	cfg *config.Config,
+) *SubscriptionRenewalService {
+	svc := NewSubscriptionRenewalService(repo, subscriptionService, billingCacheService, authCacheInvalidator, entClient, cfg)
+	svc.SetUserNotificationService(notifier)
+	svc.Start()
+	return svc
+}
+
 // ProvideAccountExpiryService creates and starts AccountExpiryService.
 func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpiryService {
 	svc := NewAccountExpiryService(accountRepo, time.Minute)
@@ -144,8 +211,9 @@ func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpirySe
 }
 
 // ProvideSubscriptionExpiryService creates and starts SubscriptionExpiryService.
-func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository) *SubscriptionExpiryService {
+func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, notifier *UserNotificationService) *SubscriptionExpiryService {
 	svc := NewSubscriptionExpiryService(userSubRepo, time.Minute)
+	svc.SetUserNotificationService(notifier)
 	svc.Start()
 	return svc
 }
@@ -168,8 +236,11 @@ func ProvideDeferredService(accountRepo AccountRepository, timingWheel *TimingWh
 }
 
 // ProvideConcurrencyService creates ConcurrencyService and starts slot cleanup worker.
-func ProvideConcurrencyService(cache ConcurrencyCache, accountRepo AccountRepository, cfg *config.Config) *ConcurrencyService {
+func ProvideConcurrencyService(cache ConcurrencyCache, fairQueueCache FairQueueCache, accountRepo AccountRepository, cfg *config.Config) *ConcurrencyService {
 	svc := NewConcurrencyService(cache)
+	if cfg != nil && cfg.Gateway.Scheduling.FairQueueEnabled {
+		svc.SetFairQueue(fairQueueCache, cfg.Gateway.Scheduling.FairQueuePrincipal)
+	}
 	if err := svc.CleanupStaleProcessSlots(context.Background()); err != nil {
 		logger.LegacyPrintf("service.concurrency", "Warning: startup cleanup stale process slots failed: %v", err)
 	}
@@ -179,6 +250,69 @@ func ProvideConcurrencyService(cache ConcurrencyCache, accountRepo AccountReposi
 	return svc
 }
 
+// ProvideUpstreamCircuitBreaker 创建上游熔断器，启用时为各网关服务的上游请求挂载熔断记录与调度过滤
+func ProvideUpstreamCircuitBreaker(
+	cache UpstreamCircuitCache,
+	cfg *config.Config,
+	gatewayService *GatewayService,
+	openAIGatewayService *OpenAIGatewayService,
+	antigravityGatewayService *AntigravityGatewayService,
+	geminiCompatService *GeminiMessagesCompatService,
+) *UpstreamCircuitBreaker {
+	b := NewUpstreamCircuitBreaker(cache, cfg)
+	if !b.Enabled() {
+		return b
+	}
+	if gatewayService != nil {
+		gatewayService.SetUpstreamCircuitBreaker(b)
+	}
+	if openAIGatewayService != nil {
+		openAIGatewayService.SetUpstreamCircuitBreaker(b)
+	}
+	if antigravityGatewayService != nil {
+		antigravityGatewayService.SetUpstreamCircuitBreaker(b)
+	}
+	if geminiCompatService != nil {
+		geminiCompatService.SetUpstreamCircuitBreaker(b)
+	}
+	return b
+}
+
+// ProvideSoraGatewayService 创建 SoraGatewayService，并为 API Key 账号的上游透传挂载熔断记录
+func ProvideSoraGatewayService(
+	soraClient SoraClient,
+	rateLimitService *RateLimitService,
+	httpUpstream HTTPUpstream,
+	cfg *config.Config,
+	circuitBreaker *UpstreamCircuitBreaker,
+) *SoraGatewayService {
+	svc := NewSoraGatewayService(soraClient, rateLimitService, httpUpstream, cfg)
+	if circuitBreaker.Enabled() {
+		svc.SetUpstreamCircuitBreaker(circuitBreaker)
+	}
+	return svc
+}
+
+// ProvideOpsService 创建 OpsService，并挂载上游熔断器用于账号可用性展示
+func ProvideOpsService(
+	opsRepo OpsRepository,
+	settingRepo SettingRepository,
+	cfg *config.Config,
+	accountRepo AccountRepository,
+	userRepo UserRepository,
+	concurrencyService *ConcurrencyService,
+	gatewayService *GatewayService,
+	openAIGatewayService *OpenAIGatewayService,
+	geminiCompatService *GeminiMessagesCompatService,
+	antigravityGatewayService *AntigravityGatewayService,
+	systemLogSink *OpsSystemLogSink,
+	circuitBreaker *UpstreamCircuitBreaker,
+) *OpsService {
+	svc := NewOpsService(opsRepo, settingRepo, cfg, accountRepo, userRepo, concurrencyService, gatewayService, openAIGatewayService, geminiCompatService, antigravityGatewayService, systemLogSink)
+	svc.SetUpstreamCircuitBreaker(circuitBreaker)
+	return svc
+}
+
 // ProvideUserMessageQueueService 创建用户消息串行队列服务并启动清理 worker
 func ProvideUserMessageQueueService(cache UserMsgQueueCache, rpmCache RPMCache, cfg *config.Config) *UserMessageQueueService {
 	svc := NewUserMessageQueueService(cache, rpmCache, &cfg.Gateway.UserMessageQueue)
@@ -427,7 +561,7 @@ var ProviderSet = wire.NewSet(
 	ProvideSoraMediaCleanupService,
 	ProvideSoraSDKClient,
 	wire.Bind(new(SoraClient), new(*SoraSDKClient)),
-	NewSoraGatewayService,
+	ProvideSoraGatewayService,
 	NewOpenAIGatewayService,
 	NewOAuthService,
 	NewOpenAIOAuthService,
@@ -450,7 +584,8 @@ var ProviderSet = wire.NewSet(
 	NewDataManagementService,
 	ProvideBackupService,
 	ProvideOpsSystemLogSink,
-	NewOpsService,
+	ProvideUpstreamCircuitBreaker,
+	ProvideOpsService,
 	ProvideOpsMetricsCollector,
 	ProvideOpsAggregationService,
 	ProvideOpsAlertEvaluatorService,
@@ -474,11 +609,25 @@ var ProviderSet = wire.NewSet(
 	ProvideTimingWheelService,
 	ProvideDashboardAggregationService,
 	ProvideUsageCleanupService,
+	ProvideUsageExportService,
+	NewUsageSessionService,
+	ProvideUsageLogPartitionService,
+	ProvideInvoiceService,
+	ProvideUserNotificationService,
+	ProvideSubscriptionRenewalService,
+	NewSubscriptionPurchaseService,
+	NewResponsesConversationService,
+	NewHedgeDelayService,
+	ProvideRequestCaptureService,
+	NewContentModerationService,
+	NewUserRateLimitHeaderService,
+	NewEmailTemplateService,
 	ProvideDeferredService,
 	NewAntigravityQuotaFetcher,
 	NewUserAttributeService,
 	NewUsageCache,
 	NewTotpService,
+	NewPasskeyService,
 	NewErrorPassthroughService,
 	NewDigestSessionStore,
 	ProvideIdempotencyCoordinator,
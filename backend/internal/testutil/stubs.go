@@ -39,6 +39,12 @@ func (c StubConcurrencyCache) DecrementAccountWaitCount(_ context.Context, _ int
 func (c StubConcurrencyCache) GetAccountWaitingCount(_ context.Context, _ int64) (int, error) {
 	return 0, nil
 }
+func (c StubConcurrencyCache) IncrementAccountBatchWaitCount(_ context.Context, _ int64, _ int) (bool, error) {
+	return true, nil
+}
+func (c StubConcurrencyCache) DecrementAccountBatchWaitCount(_ context.Context, _ int64) error {
+	return nil
+}
 func (c StubConcurrencyCache) AcquireUserSlot(_ context.Context, _ int64, _ int, _ string) (bool, error) {
 	return true, nil
 }
@@ -79,6 +85,21 @@ func (c StubConcurrencyCache) CleanupExpiredAccountSlots(_ context.Context, _ in
 func (c StubConcurrencyCache) CleanupStaleProcessSlots(_ context.Context, _ string) error {
 	return nil
 }
+func (c StubConcurrencyCache) AcquireAccountModelSlot(_ context.Context, _ service.AccountModelScope, _ int, _ string) (bool, error) {
+	return true, nil
+}
+func (c StubConcurrencyCache) ReleaseAccountModelSlot(_ context.Context, _ service.AccountModelScope, _ string) error {
+	return nil
+}
+func (c StubConcurrencyCache) AcquireAccountModelRPM(_ context.Context, _ service.AccountModelScope, _ int, _ string) (bool, error) {
+	return true, nil
+}
+func (c StubConcurrencyCache) ReleaseAccountModelRPM(_ context.Context, _ service.AccountModelScope, _ string) error {
+	return nil
+}
+func (c StubConcurrencyCache) GetAccountModelLoadBatch(_ context.Context, _ []service.AccountModelScope) (map[int64]*service.AccountModelLoadInfo, error) {
+	return map[int64]*service.AccountModelLoadInfo{}, nil
+}
 
 // ============================================================
 // StubGatewayCache — service.GatewayCache 的空实现
@@ -41,9 +41,14 @@ var hopByHopHeaders = map[string]struct{}{
 	"connection":        {},
 }
 
+// rateLimitHeaderPrefixes 上游限流头部前缀（描述的是上游账号的限额）
+var rateLimitHeaderPrefixes = []string{"x-ratelimit-", "anthropic-ratelimit-"}
+
 type CompiledHeaderFilter struct {
 	allowed     map[string]struct{}
 	forceRemove map[string]struct{}
+	// stripRateLimit 为 true 时丢弃所有上游限流头部（由网关按用户额度另行生成）
+	stripRateLimit bool
 }
 
 var defaultCompiledHeaderFilter = CompileHeaderFilter(config.ResponseHeaderConfig{})
@@ -82,6 +87,27 @@ func CompileHeaderFilter(cfg config.ResponseHeaderConfig) *CompiledHeaderFilter
 	}
 }
 
+// WithoutRateLimitHeaders 返回丢弃上游限流头部（x-ratelimit-* / anthropic-ratelimit-*）的过滤器副本
+func (f *CompiledHeaderFilter) WithoutRateLimitHeaders() *CompiledHeaderFilter {
+	if f == nil {
+		f = defaultCompiledHeaderFilter
+	}
+	stripped := *f
+	stripped.stripRateLimit = true
+	return &stripped
+}
+
+// IsRateLimitHeader 判断是否为限流头部
+func IsRateLimitHeader(key string) bool {
+	lower := strings.ToLower(key)
+	for _, prefix := range rateLimitHeaderPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func FilterHeaders(src http.Header, filter *CompiledHeaderFilter) http.Header {
 	if filter == nil {
 		filter = defaultCompiledHeaderFilter
@@ -93,6 +119,9 @@ func FilterHeaders(src http.Header, filter *CompiledHeaderFilter) http.Header {
 		if _, blocked := filter.forceRemove[lower]; blocked {
 			continue
 		}
+		if filter.stripRateLimit && IsRateLimitHeader(lower) {
+			continue
+		}
 		if _, ok := filter.allowed[lower]; !ok {
 			continue
 		}
@@ -65,3 +65,33 @@ func TestFilterHeadersEnabledUsesAllowlist(t *testing.T) {
 		t.Fatalf("expected X-Blocked removed, got %q", filtered.Get("X-Blocked"))
 	}
 }
+
+func TestFilterHeadersWithoutRateLimitHeaders(t *testing.T) {
+	src := http.Header{}
+	src.Add("Content-Type", "application/json")
+	src.Add("X-Ratelimit-Remaining-Requests", "99")
+	src.Add("Anthropic-Ratelimit-Unified-5h-Utilization", "0.5")
+
+	filter := CompileHeaderFilter(config.ResponseHeaderConfig{
+		Enabled:           true,
+		AdditionalAllowed: []string{"anthropic-ratelimit-unified-5h-utilization"},
+	})
+	filtered := FilterHeaders(src, filter)
+	if filtered.Get("X-Ratelimit-Remaining-Requests") != "99" {
+		t.Fatalf("expected upstream x-ratelimit header passthrough by default, got %q", filtered.Get("X-Ratelimit-Remaining-Requests"))
+	}
+
+	filtered = FilterHeaders(src, filter.WithoutRateLimitHeaders())
+	if filtered.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected Content-Type passthrough, got %q", filtered.Get("Content-Type"))
+	}
+	if filtered.Get("X-Ratelimit-Remaining-Requests") != "" {
+		t.Fatalf("expected x-ratelimit header removed, got %q", filtered.Get("X-Ratelimit-Remaining-Requests"))
+	}
+	if filtered.Get("Anthropic-Ratelimit-Unified-5h-Utilization") != "" {
+		t.Fatalf("expected anthropic-ratelimit header removed, got %q", filtered.Get("Anthropic-Ratelimit-Unified-5h-Utilization"))
+	}
+	if FilterHeaders(src, filter).Get("X-Ratelimit-Remaining-Requests") != "99" {
+		t.Fatalf("expected original filter to be unchanged")
+	}
+}
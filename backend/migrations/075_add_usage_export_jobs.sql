@@ -0,0 +1,26 @@
+-- 075_add_usage_export_jobs.sql
+-- 使用记录异步导出任务（CSV / JSONL / Parquet），文件落地本地磁盘或备份 S3。
+
+CREATE TABLE IF NOT EXISTS usage_export_jobs (
+    id            BIGSERIAL PRIMARY KEY,
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending',
+    format        VARCHAR(16) NOT NULL,
+    filters       JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_by    BIGINT NOT NULL,
+    admin_scope   BOOLEAN NOT NULL DEFAULT FALSE,
+    storage       VARCHAR(16) NOT NULL DEFAULT 'local',
+    object_key    TEXT NOT NULL DEFAULT '',
+    file_name     VARCHAR(255) NOT NULL DEFAULT '',
+    file_size     BIGINT NOT NULL DEFAULT 0,
+    exported_rows BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    started_at    TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ,
+    expires_at    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_export_jobs_created_by ON usage_export_jobs(created_by, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_usage_export_jobs_status_created ON usage_export_jobs(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_usage_export_jobs_expires_at ON usage_export_jobs(expires_at) WHERE status = 'succeeded';
@@ -0,0 +1,38 @@
+-- 076_add_invoices.sql
+-- 月度账单（对账单）：每个用户每个结算周期一张，出具后不可修改。
+-- content 保存出账时的完整快照（充值明细、订阅费用、按分组/模型汇总的用量），
+-- content_hash 为快照的 SHA256，用于校验账单未被篡改。
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id                  BIGSERIAL PRIMARY KEY,
+    invoice_number      VARCHAR(32) NOT NULL UNIQUE,
+    user_id             BIGINT NOT NULL,
+    period_start        TIMESTAMPTZ NOT NULL,
+    period_end          TIMESTAMPTZ NOT NULL,
+    currency            VARCHAR(8) NOT NULL DEFAULT 'USD',
+    topup_amount        DECIMAL(20,8) NOT NULL DEFAULT 0,
+    subscription_amount DECIMAL(20,8) NOT NULL DEFAULT 0,
+    usage_total_cost    DECIMAL(20,10) NOT NULL DEFAULT 0,
+    usage_actual_cost   DECIMAL(20,10) NOT NULL DEFAULT 0,
+    request_count       BIGINT NOT NULL DEFAULT 0,
+    content             JSONB NOT NULL,
+    content_hash        VARCHAR(64) NOT NULL,
+    issued_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, period_start, period_end)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_user_period ON invoices(user_id, period_start DESC);
+CREATE INDEX IF NOT EXISTS idx_invoices_period_start ON invoices(period_start DESC);
+
+-- 账单编号计数器：按 scope（前缀+年份）递增，与账单插入处于同一事务，保证编号连续无空洞
+CREATE TABLE IF NOT EXISTS invoice_number_sequences (
+    scope      VARCHAR(32) PRIMARY KEY,
+    last_value BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE invoices IS '月度账单（出具后不可修改）';
+COMMENT ON COLUMN invoices.content IS '出账快照 JSON：用户信息、充值明细、订阅费用、用量汇总';
+COMMENT ON COLUMN invoices.content_hash IS 'content 的 SHA256（十六进制）';
+COMMENT ON TABLE invoice_number_sequences IS '账单编号计数器';
@@ -0,0 +1,45 @@
+-- 077_add_user_notifications.sql
+-- 终端用户提醒：余额不足、订阅用量阈值、订阅/API Key 即将到期、API Key 额度即将用尽。
+-- user_notification_preferences 保存每个用户的提醒偏好（默认不存在即不提醒）；
+-- user_notification_events 同时作为去重表与提醒历史：同一事件在同一周期内只会写入一次。
+
+CREATE TABLE IF NOT EXISTS user_notification_preferences (
+    user_id                   BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_enabled             BOOLEAN NOT NULL DEFAULT TRUE,
+    webhook_url               TEXT NOT NULL DEFAULT '',
+    webhook_secret            TEXT NOT NULL DEFAULT '',
+    balance_threshold         DECIMAL(20,8),
+    subscription_usage_alerts BOOLEAN NOT NULL DEFAULT FALSE,
+    subscription_expiry_days  INT NOT NULL DEFAULT 0,
+    api_key_quota_percent     INT NOT NULL DEFAULT 0,
+    api_key_expiry_days       INT NOT NULL DEFAULT 0,
+    created_at                TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_notification_events (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT NOT NULL,
+    event_type     VARCHAR(40) NOT NULL,
+    subject_key    VARCHAR(64) NOT NULL DEFAULT '',
+    period_key     VARCHAR(64) NOT NULL DEFAULT '',
+    payload        JSONB NOT NULL DEFAULT '{}'::jsonb,
+    email_status   VARCHAR(20) NOT NULL DEFAULT '',
+    webhook_status VARCHAR(20) NOT NULL DEFAULT '',
+    webhook_error  TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, event_type, subject_key, period_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_notification_events_user_created
+    ON user_notification_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_notification_events_created
+    ON user_notification_events(created_at);
+
+COMMENT ON TABLE user_notification_preferences IS '用户提醒偏好';
+COMMENT ON COLUMN user_notification_preferences.balance_threshold IS '余额低于该值时提醒，NULL 表示关闭';
+COMMENT ON COLUMN user_notification_preferences.subscription_usage_alerts IS '订阅日/周/月用量达到 80% 与 100% 时提醒';
+COMMENT ON COLUMN user_notification_preferences.subscription_expiry_days IS '订阅到期前 N 天提醒，0 表示关闭';
+COMMENT ON COLUMN user_notification_preferences.api_key_quota_percent IS 'API Key 额度使用达到该百分比时提醒，0 表示关闭';
+COMMENT ON COLUMN user_notification_preferences.api_key_expiry_days IS 'API Key 到期前 N 天提醒，0 表示关闭';
+COMMENT ON TABLE user_notification_events IS '用户提醒记录（去重键：user_id + event_type + subject_key + period_key）';
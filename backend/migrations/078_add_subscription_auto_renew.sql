@@ -0,0 +1,46 @@
+-- 078_add_subscription_auto_renew.sql
+-- 订阅自动续费：分组增加售价与续费周期，订阅增加 auto_renew 开关；
+-- subscription_charges 记录每一次从余额扣费的订阅操作（成功或失败），作为续费流水与重试依据。
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS price DECIMAL(20,8);
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS renewal_period_days INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN groups.price IS '订阅售价（从余额扣除），NULL 表示不可购买/续费';
+COMMENT ON COLUMN groups.renewal_period_days IS '每次购买/续费延长的天数，0 表示使用 default_validity_days';
+
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS auto_renew BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN user_subscriptions.auto_renew IS '到期前自动从余额扣费续订';
+
+CREATE INDEX IF NOT EXISTS idx_user_subscriptions_auto_renew_expires
+    ON user_subscriptions(expires_at)
+    WHERE auto_renew = TRUE AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS subscription_charges (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL,
+    subscription_id   BIGINT,
+    group_id          BIGINT NOT NULL,
+    charge_type       VARCHAR(20) NOT NULL,
+    status            VARCHAR(30) NOT NULL,
+    amount            DECIMAL(20,8) NOT NULL DEFAULT 0,
+    period_days       INT NOT NULL DEFAULT 0,
+    period_expires_at TIMESTAMPTZ,
+    new_expires_at    TIMESTAMPTZ,
+    error_message     TEXT NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 同一订阅的同一到期时间只允许一次成功续费（多实例并发扫描时保证不重复扣费）
+CREATE UNIQUE INDEX IF NOT EXISTS uq_subscription_charges_renewal_period
+    ON subscription_charges(subscription_id, period_expires_at)
+    WHERE charge_type = 'renewal' AND status = 'succeeded';
+CREATE INDEX IF NOT EXISTS idx_subscription_charges_subscription_period
+    ON subscription_charges(subscription_id, period_expires_at, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_subscription_charges_user_created
+    ON subscription_charges(user_id, created_at DESC);
+
+COMMENT ON TABLE subscription_charges IS '订阅扣费流水（自动续费等）';
+COMMENT ON COLUMN subscription_charges.charge_type IS 'renewal: 自动续费';
+COMMENT ON COLUMN subscription_charges.status IS 'succeeded / insufficient_balance / failed';
+COMMENT ON COLUMN subscription_charges.period_expires_at IS '触发续费时订阅的到期时间（续费周期标识）';
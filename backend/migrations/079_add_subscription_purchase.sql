@@ -0,0 +1,10 @@
+-- 079_add_subscription_purchase.sql
+-- 用户自助购买订阅（从余额扣费）：subscription_charges 增加升级抵扣信息。
+-- 购买与升级同样写入 subscription_charges（charge_type = purchase / upgrade）。
+
+ALTER TABLE subscription_charges ADD COLUMN IF NOT EXISTS credit_amount DECIMAL(20,8) NOT NULL DEFAULT 0;
+ALTER TABLE subscription_charges ADD COLUMN IF NOT EXISTS upgraded_from_subscription_id BIGINT;
+
+COMMENT ON COLUMN subscription_charges.charge_type IS 'renewal: 自动续费 / purchase: 购买 / upgrade: 升级（按剩余时长折算原订阅）';
+COMMENT ON COLUMN subscription_charges.credit_amount IS '升级时原订阅剩余时长折算的抵扣金额';
+COMMENT ON COLUMN subscription_charges.upgraded_from_subscription_id IS '升级时被替换的原订阅 ID';
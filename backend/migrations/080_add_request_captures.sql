@@ -0,0 +1,34 @@
+-- 080_add_request_captures.sql
+-- 请求/响应完整抓取：按分组或 API Key 开启（策略保存在 settings.request_capture_policy）。
+-- 请求体、响应体与流式响应重组后的文本均经过脱敏并 gzip 压缩存储，可选 AES-256-GCM 加密；
+-- request_id 与 usage_logs.request_id 一致，用于从用量记录跳转到抓取内容。
+
+CREATE TABLE IF NOT EXISTS request_captures (
+    id              BIGSERIAL PRIMARY KEY,
+    request_id      VARCHAR(255) NOT NULL DEFAULT '',
+    user_id         BIGINT NOT NULL,
+    api_key_id      BIGINT NOT NULL,
+    group_id        BIGINT,
+    account_id      BIGINT,
+    platform        VARCHAR(50) NOT NULL DEFAULT '',
+    model           VARCHAR(255) NOT NULL DEFAULT '',
+    method          VARCHAR(10) NOT NULL DEFAULT '',
+    path            VARCHAR(255) NOT NULL DEFAULT '',
+    stream          BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code     INT NOT NULL DEFAULT 0,
+    duration_ms     INT NOT NULL DEFAULT 0,
+    request_bytes   INT NOT NULL DEFAULT 0,
+    response_bytes  INT NOT NULL DEFAULT 0,
+    truncated       BOOLEAN NOT NULL DEFAULT FALSE,
+    encrypted       BOOLEAN NOT NULL DEFAULT FALSE,
+    request_body    BYTEA,
+    response_body   BYTEA,
+    response_text   BYTEA,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_captures_request_id ON request_captures (request_id);
+CREATE INDEX IF NOT EXISTS idx_request_captures_api_key_created ON request_captures (api_key_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_request_captures_user_created ON request_captures (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_request_captures_expires_at ON request_captures (expires_at);
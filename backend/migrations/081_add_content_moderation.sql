@@ -0,0 +1,58 @@
+-- 081_add_content_moderation.sql
+-- 入站提示词内容审核：content_moderation_rules 保存按分组生效的审核规则
+-- （关键词 / 正则 / OpenAI Moderation / 通用 HTTP Hook），在选择账号前执行；
+-- content_moderation_violations 记录每次命中，用于按用户统计与 API Key 自动停用。
+
+CREATE TABLE IF NOT EXISTS content_moderation_rules (
+    id               BIGSERIAL PRIMARY KEY,
+    name             VARCHAR(100) NOT NULL,
+    enabled          BOOLEAN NOT NULL DEFAULT TRUE,
+    priority         INT NOT NULL DEFAULT 0,
+    kind             VARCHAR(30) NOT NULL,
+    action           VARCHAR(20) NOT NULL DEFAULT 'block',
+    group_ids        BIGINT[] NOT NULL DEFAULT '{}',
+    patterns         TEXT[] NOT NULL DEFAULT '{}',
+    case_sensitive   BOOLEAN NOT NULL DEFAULT FALSE,
+    account_id       BIGINT,
+    moderation_model VARCHAR(100) NOT NULL DEFAULT '',
+    categories       TEXT[] NOT NULL DEFAULT '{}',
+    webhook_url      TEXT NOT NULL DEFAULT '',
+    webhook_secret   TEXT NOT NULL DEFAULT '',
+    refusal_message  TEXT NOT NULL DEFAULT '',
+    description      TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS content_moderation_violations (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL,
+    api_key_id    BIGINT NOT NULL,
+    group_id      BIGINT,
+    rule_id       BIGINT,
+    rule_name     VARCHAR(100) NOT NULL DEFAULT '',
+    rule_kind     VARCHAR(30) NOT NULL DEFAULT '',
+    action        VARCHAR(20) NOT NULL,
+    endpoint      VARCHAR(64) NOT NULL DEFAULT '',
+    model         VARCHAR(255) NOT NULL DEFAULT '',
+    reason        TEXT NOT NULL DEFAULT '',
+    excerpt       TEXT NOT NULL DEFAULT '',
+    key_suspended BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_moderation_violations_user_created
+    ON content_moderation_violations(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_content_moderation_violations_api_key_created
+    ON content_moderation_violations(api_key_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_content_moderation_violations_created
+    ON content_moderation_violations(created_at);
+
+COMMENT ON TABLE content_moderation_rules IS '入站内容审核规则';
+COMMENT ON COLUMN content_moderation_rules.kind IS 'keyword / regex / openai_moderation / webhook';
+COMMENT ON COLUMN content_moderation_rules.action IS 'block：拒绝请求；flag：仅记录';
+COMMENT ON COLUMN content_moderation_rules.group_ids IS '生效分组，空数组表示所有分组';
+COMMENT ON COLUMN content_moderation_rules.account_id IS 'openai_moderation 规则使用的 OpenAI API Key 账号';
+COMMENT ON COLUMN content_moderation_rules.categories IS 'openai_moderation / webhook 规则仅在命中这些分类时生效，空数组表示任意分类';
+COMMENT ON TABLE content_moderation_violations IS '内容审核命中记录';
+COMMENT ON COLUMN content_moderation_violations.key_suspended IS '本次命中是否触发了 API Key 自动停用';
@@ -0,0 +1,25 @@
+-- 082_add_email_templates.sql
+-- 可自定义、多语言的邮件模板：内置模板随代码发布，此表只保存管理员的覆盖版本。
+-- 查找顺序：用户语言 -> 语言主标签（zh-cn -> zh）-> 站点默认语言（settings.email_default_locale）-> en，
+-- 每一级先查覆盖、再查内置模板。用户语言保存在 user_notification_preferences.locale。
+
+CREATE TABLE IF NOT EXISTS email_templates (
+    id            BIGSERIAL PRIMARY KEY,
+    template_key  VARCHAR(64) NOT NULL,
+    locale        VARCHAR(16) NOT NULL,
+    subject       TEXT NOT NULL,
+    html_body     TEXT NOT NULL,
+    text_body     TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (template_key, locale)
+);
+
+ALTER TABLE user_notification_preferences
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(16) NOT NULL DEFAULT '';
+
+COMMENT ON TABLE email_templates IS '邮件模板覆盖（按 template_key + locale 唯一）';
+COMMENT ON COLUMN email_templates.subject IS '邮件主题（Go text/template）';
+COMMENT ON COLUMN email_templates.html_body IS 'HTML 正文（Go html/template）';
+COMMENT ON COLUMN email_templates.text_body IS '纯文本正文（Go text/template），为空时由 HTML 正文自动生成';
+COMMENT ON COLUMN user_notification_preferences.locale IS '邮件语言（如 en、zh），为空表示使用站点默认语言';
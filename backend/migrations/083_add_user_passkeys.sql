@@ -0,0 +1,29 @@
+-- 083_add_user_passkeys.sql
+-- WebAuthn 通行密钥（passkey）：每个用户可注册多个，用于无密码登录，或作为 /auth/login/2fa 的第二因素。
+-- 注册/登录挑战只保存在 Redis（与 TOTP 登录会话一致），此表只保存已完成注册的凭据。
+-- 功能开关与"管理员必须启用 2FA"分别由 settings.passkey_enabled、settings.admin_2fa_required 控制。
+
+CREATE TABLE IF NOT EXISTS user_passkeys (
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id    BYTEA NOT NULL UNIQUE,
+    public_key       BYTEA NOT NULL,
+    attestation_type VARCHAR(32) NOT NULL DEFAULT '',
+    aaguid           BYTEA,
+    sign_count       BIGINT NOT NULL DEFAULT 0,
+    clone_warning    BOOLEAN NOT NULL DEFAULT FALSE,
+    transports       TEXT NOT NULL DEFAULT '',
+    backup_eligible  BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state     BOOLEAN NOT NULL DEFAULT FALSE,
+    name             VARCHAR(100) NOT NULL DEFAULT '',
+    last_used_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_passkeys_user_id ON user_passkeys (user_id);
+
+COMMENT ON TABLE user_passkeys IS '用户 WebAuthn 通行密钥';
+COMMENT ON COLUMN user_passkeys.credential_id IS '凭据 ID（认证器生成，全局唯一）';
+COMMENT ON COLUMN user_passkeys.public_key IS 'COSE 编码的凭据公钥';
+COMMENT ON COLUMN user_passkeys.sign_count IS '签名计数器，回退时标记 clone_warning 并拒绝登录';
+COMMENT ON COLUMN user_passkeys.transports IS '认证器传输方式，逗号分隔（usb、nfc、ble、internal、hybrid）';
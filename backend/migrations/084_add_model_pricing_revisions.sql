@@ -0,0 +1,57 @@
+-- 084_add_model_pricing_revisions.sql
+-- 模型价格多数据源同步：合并后的价格快照以"修订"形式保存，管理员审核通过后才生效；
+-- 管理员覆盖价格单独保存，始终叠加在已生效快照之上，不受后续同步影响；
+-- 所有生效的价格变化（同步审核、覆盖增删）都写入价格历史，便于追溯账单差异。
+
+CREATE TABLE IF NOT EXISTS model_pricing_revisions (
+    id               BIGSERIAL PRIMARY KEY,
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending',
+    snapshot_hash    VARCHAR(64) NOT NULL,
+    snapshot         JSONB NOT NULL,
+    diff             JSONB NOT NULL DEFAULT '[]'::jsonb,
+    sources          JSONB NOT NULL DEFAULT '[]'::jsonb,
+    base_revision_id BIGINT,
+    model_count      INTEGER NOT NULL DEFAULT 0,
+    added_count      INTEGER NOT NULL DEFAULT 0,
+    changed_count    INTEGER NOT NULL DEFAULT 0,
+    removed_count    INTEGER NOT NULL DEFAULT 0,
+    reviewed_by      BIGINT,
+    reviewed_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_model_pricing_revisions_status ON model_pricing_revisions (status, id DESC);
+
+COMMENT ON TABLE model_pricing_revisions IS '模型价格快照修订（多数据源合并结果）';
+COMMENT ON COLUMN model_pricing_revisions.status IS 'pending=待审核, applied=已生效, rejected=已拒绝, superseded=被更新的待审核修订取代';
+COMMENT ON COLUMN model_pricing_revisions.snapshot IS '合并后的完整价格表（不含管理员覆盖）';
+COMMENT ON COLUMN model_pricing_revisions.diff IS '相对 base_revision_id 快照的逐模型差异';
+COMMENT ON COLUMN model_pricing_revisions.reviewed_by IS '审核管理员 ID，系统自动生效时为空';
+
+CREATE TABLE IF NOT EXISTS model_price_overrides (
+    model      VARCHAR(255) PRIMARY KEY,
+    pricing    JSONB NOT NULL,
+    note       TEXT NOT NULL DEFAULT '',
+    updated_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE model_price_overrides IS '管理员覆盖的模型价格，优先于所有同步数据源';
+
+CREATE TABLE IF NOT EXISTS model_price_history (
+    id          BIGSERIAL PRIMARY KEY,
+    model       VARCHAR(255) NOT NULL,
+    change_type VARCHAR(20) NOT NULL,
+    old_pricing JSONB,
+    new_pricing JSONB,
+    revision_id BIGINT,
+    actor_id    BIGINT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_model_price_history_model ON model_price_history (model, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_model_price_history_created_at ON model_price_history (created_at DESC);
+
+COMMENT ON TABLE model_price_history IS '模型价格变更历史';
+COMMENT ON COLUMN model_price_history.change_type IS 'added/changed/removed（修订生效）, override_set/override_removed（管理员覆盖）';
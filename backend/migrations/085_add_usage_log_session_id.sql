@@ -0,0 +1,5 @@
+-- Add conversation (sticky session) tracking to usage_logs.
+-- session_id: sticky session key the request was routed with
+--   (metadata user_id session, Gemini CLI / digest-chain session, OpenAI session hash, ...).
+-- NULL for requests without a session identifier and for historical rows.
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS session_id VARCHAR(64);
@@ -0,0 +1,6 @@
+-- 会话维度聚合与明细查询按 session_id 定位请求，并按时间排序。
+-- 仅索引有会话标识的行；使用 CONCURRENTLY 避免在热表上长时间阻塞写入。
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_usage_logs_session_id_created_at
+    ON usage_logs (session_id, created_at)
+    WHERE session_id IS NOT NULL;
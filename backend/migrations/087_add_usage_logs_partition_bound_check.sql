@@ -0,0 +1,38 @@
+-- 为 087_partition_usage_logs.sql 做准备：给 usage_logs 添加与 legacy 分区范围一致的 CHECK 约束。
+--
+-- ATTACH PARTITION 时若分区表上已有能推出分区范围的有效 CHECK 约束，会跳过全表校验扫描。
+-- 约束以 NOT VALID 添加（仅短暂持锁、不扫描），由 087_enforce_usage_logs_partition_bound_check.sql
+-- 在单独的事务中 VALIDATE（SHARE UPDATE EXCLUSIVE 锁，扫描期间不阻塞读写），
+-- 避免在 087 改名 + ATTACH 持有 ACCESS EXCLUSIVE 锁期间做全表扫描。
+--
+-- 上界取「下月 1 日」与「最大 created_at 所在月的下月 1 日」中较大者（UTC），087 从该约束读取同一上界。
+
+DO $$
+DECLARE
+    legacy_upper TIMESTAMPTZ;
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM pg_partitioned_table pt
+        JOIN pg_class c ON c.oid = pt.partrelid
+        WHERE c.relname = 'usage_logs'
+    ) OR EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conrelid = 'usage_logs'::regclass
+          AND conname = 'usage_logs_partition_bound_check'
+    ) THEN
+        RETURN;
+    END IF;
+
+    SELECT (date_trunc('month', GREATEST(now(), COALESCE(MAX(created_at), now())) AT TIME ZONE 'UTC')
+            + INTERVAL '1 month') AT TIME ZONE 'UTC'
+    INTO legacy_upper
+    FROM usage_logs;
+
+    EXECUTE format(
+        'ALTER TABLE usage_logs ADD CONSTRAINT usage_logs_partition_bound_check CHECK (created_at IS NOT NULL AND created_at < %L) NOT VALID',
+        legacy_upper
+    );
+END
+$$;
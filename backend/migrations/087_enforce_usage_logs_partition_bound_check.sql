@@ -0,0 +1,17 @@
+-- 在单独的事务中校验 087_add_usage_logs_partition_bound_check.sql 添加的约束。
+-- VALIDATE CONSTRAINT 仅持有 SHARE UPDATE EXCLUSIVE 锁，全表扫描期间不阻塞 usage_logs 的读写；
+-- 校验通过后 087_partition_usage_logs.sql 的 ATTACH PARTITION 可以跳过扫描。
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conrelid = to_regclass('usage_logs')
+          AND conname = 'usage_logs_partition_bound_check'
+          AND NOT convalidated
+    ) THEN
+        ALTER TABLE usage_logs VALIDATE CONSTRAINT usage_logs_partition_bound_check;
+    END IF;
+END
+$$;
@@ -0,0 +1,118 @@
+-- Convert usage_logs into a RANGE(created_at) partitioned table (monthly partitions, UTC).
+--
+-- 不复制数据：原表整体改名为 usage_logs_legacy 并作为第一个分区挂载，范围为
+-- [MINVALUE, 下月 1 日)，之后的月份使用 usage_logs_YYYYMM 分区。
+--
+-- 说明：
+-- 1) 分区表上的唯一索引必须包含分区键，因此 (request_id, api_key_id) 幂等唯一索引与 id 唯一索引
+--    改为在每个分区上单独创建（legacy 分区保留原有的主键与唯一索引），写入改用不带冲突目标的
+--    ON CONFLICT DO NOTHING。跨月边界的重试可能产生重复行，计费幂等由 usage_billing_dedup 保证。
+-- 2) 原表上的非唯一索引在父表上以原名重建，ATTACH 时自动复用 legacy 分区上的同构索引，不会重建。
+-- 3) billing_usage_entries 为遗留对账表（已无写入），外键无法引用分区表，这里移除其外键。
+-- 4) 后续分区由 UsageLogPartitionService 预创建；过期分区先归档再删除。
+-- 5) 分区表不支持 CREATE INDEX CONCURRENTLY，后续给 usage_logs 加索引请使用普通迁移。
+-- 6) legacy 分区的上界取自 087_add_usage_logs_partition_bound_check.sql 添加、并已在单独事务中校验的
+--    CHECK 约束，ATTACH 据此跳过全表扫描，改名到提交之间的 ACCESS EXCLUSIVE 锁只持有很短时间。
+--    约束缺失时（理论上不会出现）退回按相同规则计算上界，ATTACH 会在锁内扫描全表。
+
+DO $$
+DECLARE
+    legacy_upper TIMESTAMPTZ;
+    part_start TIMESTAMPTZ;
+    part_name TEXT;
+    r RECORD;
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM pg_partitioned_table pt
+        JOIN pg_class c ON c.oid = pt.partrelid
+        WHERE c.relname = 'usage_logs'
+    ) THEN
+        RAISE NOTICE 'usage_logs is already partitioned; skip conversion';
+        RETURN;
+    END IF;
+
+    ALTER TABLE IF EXISTS billing_usage_entries
+        DROP CONSTRAINT IF EXISTS billing_usage_entries_usage_log_id_fkey;
+
+    SELECT substring(pg_get_constraintdef(oid) FROM '''([^'']+)''')::timestamptz
+    INTO legacy_upper
+    FROM pg_constraint
+    WHERE conrelid = 'usage_logs'::regclass
+      AND conname = 'usage_logs_partition_bound_check'
+      AND convalidated;
+
+    -- legacy 上界取「下月 1 日」与「最大 created_at 所在月的下月 1 日」中较大者，保证现有数据全部落入 legacy
+    IF legacy_upper IS NULL THEN
+        SELECT (date_trunc('month', GREATEST(now(), COALESCE(MAX(created_at), now())) AT TIME ZONE 'UTC')
+                + INTERVAL '1 month') AT TIME ZONE 'UTC'
+        INTO legacy_upper
+        FROM usage_logs;
+    END IF;
+
+    ALTER TABLE usage_logs RENAME TO usage_logs_legacy;
+
+    CREATE TABLE usage_logs (
+        LIKE usage_logs_legacy INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING STORAGE INCLUDING COMMENTS
+    ) PARTITION BY RANGE (created_at);
+
+    -- 上界约束只属于 legacy 分区，不能随 INCLUDING CONSTRAINTS 留在父表上
+    ALTER TABLE usage_logs DROP CONSTRAINT IF EXISTS usage_logs_partition_bound_check;
+
+    -- 序列归属父表，避免日后删除 legacy 分区时连带删除 id 序列
+    ALTER SEQUENCE IF EXISTS usage_logs_id_seq OWNED BY usage_logs.id;
+
+    FOR r IN
+        SELECT conname, pg_get_constraintdef(oid) AS def
+        FROM pg_constraint
+        WHERE conrelid = 'usage_logs_legacy'::regclass
+          AND contype = 'f'
+    LOOP
+        EXECUTE format('ALTER TABLE usage_logs ADD CONSTRAINT %I %s', r.conname, r.def);
+    END LOOP;
+
+    -- 原索引改名为 *_legacy，非唯一索引以原名在父表重建；唯一索引仅保留在 legacy 分区
+    FOR r IN
+        SELECT ic.relname AS name,
+               pg_get_indexdef(i.indexrelid) AS def,
+               (NOT i.indisunique AND NOT i.indisprimary AND i.indisvalid) AS copy_to_parent
+        FROM pg_index i
+        JOIN pg_class ic ON ic.oid = i.indexrelid
+        WHERE i.indrelid = 'usage_logs_legacy'::regclass
+    LOOP
+        EXECUTE format('ALTER INDEX %I RENAME TO %I', r.name, left(r.name, 56) || '_legacy');
+        IF r.copy_to_parent THEN
+            EXECUTE regexp_replace(
+                r.def,
+                '^CREATE INDEX \S+ ON \S+ ',
+                'CREATE INDEX ' || quote_ident(r.name) || ' ON usage_logs '
+            );
+        END IF;
+    END LOOP;
+
+    EXECUTE format(
+        'ALTER TABLE usage_logs ATTACH PARTITION usage_logs_legacy FOR VALUES FROM (MINVALUE) TO (%L)',
+        legacy_upper
+    );
+
+    -- 挂载后分区约束已覆盖该 CHECK
+    ALTER TABLE usage_logs_legacy DROP CONSTRAINT IF EXISTS usage_logs_partition_bound_check;
+
+    FOR i IN 0..2 LOOP
+        part_start := ((legacy_upper AT TIME ZONE 'UTC') + make_interval(months => i)) AT TIME ZONE 'UTC';
+        part_name := 'usage_logs_' || to_char(part_start AT TIME ZONE 'UTC', 'YYYYMM');
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF usage_logs FOR VALUES FROM (%L) TO (%L)',
+            part_name,
+            part_start,
+            ((part_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC'
+        );
+        EXECUTE format('CREATE UNIQUE INDEX IF NOT EXISTS %I ON %I (id)', part_name || '_id_uidx', part_name);
+        EXECUTE format(
+            'CREATE UNIQUE INDEX IF NOT EXISTS %I ON %I (request_id, api_key_id)',
+            part_name || '_request_api_key_uidx',
+            part_name
+        );
+    END LOOP;
+END
+$$;
@@ -0,0 +1,25 @@
+-- usage_logs 月度归档记录：过期分区删除前导出为压缩文件（Parquet / JSONL.gz），可查询或重新导入
+CREATE TABLE IF NOT EXISTS usage_log_archives (
+    id             BIGSERIAL PRIMARY KEY,
+    range_start    TIMESTAMPTZ NOT NULL,
+    range_end      TIMESTAMPTZ NOT NULL,
+    partition_name VARCHAR(63) NOT NULL DEFAULT '',
+    status         VARCHAR(20) NOT NULL DEFAULT 'archived',
+    format         VARCHAR(16) NOT NULL,
+    storage        VARCHAR(16) NOT NULL DEFAULT 'local',
+    object_key     TEXT NOT NULL DEFAULT '',
+    file_name      VARCHAR(255) NOT NULL DEFAULT '',
+    file_size      BIGINT NOT NULL DEFAULT 0,
+    row_count      BIGINT NOT NULL DEFAULT 0,
+    checksum       VARCHAR(64) NOT NULL DEFAULT '',
+    error_message  TEXT,
+    archived_at    TIMESTAMPTZ,
+    restored_at    TIMESTAMPTZ,
+    restored_rows  BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 每个月份只保留一条归档记录，失败后原地重试
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_log_archives_range_start ON usage_log_archives(range_start);
+CREATE INDEX IF NOT EXISTS idx_usage_log_archives_status ON usage_log_archives(status, range_start);
@@ -0,0 +1,11 @@
+-- 089_add_group_model_fallbacks.sql
+-- 添加分组级别的模型降级链配置
+
+-- model_fallbacks：模型模式 -> 有序降级目标列表（JSONB 格式）
+-- 格式: {"model_pattern": [{"model": "...", "group_id": 123}, ...]}
+-- group_id 省略表示在当前分组内改用该模型
+-- 例如: {"claude-opus-*": [{"model": "claude-sonnet-4-5"}, {"model": "claude-sonnet-4-5", "group_id": 7}]}
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS model_fallbacks JSONB DEFAULT '{}';
+
+COMMENT ON COLUMN groups.model_fallbacks IS '模型降级链：{"model_pattern": [{"model": "...", "group_id": 123}, ...]}，支持通配符匹配，按顺序尝试';
@@ -0,0 +1,5 @@
+-- Record the model that actually served a request when a group model fallback chain kicked in.
+-- served_model: NULL when the requested model (usage_logs.model) was served directly.
+-- Billing for fallback requests uses served_model pricing.
+-- usage_logs is partitioned (087); ALTER TABLE on the parent propagates to all partitions.
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS served_model VARCHAR(100);
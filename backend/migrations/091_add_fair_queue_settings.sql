@@ -0,0 +1,23 @@
+-- 091_add_fair_queue_settings.sql
+-- 添加公平排队的权重与优先级档位配置（用户级与分组级）
+
+-- queue_weight：加权轮转权重，数值越大在排队中获得的放行份额越多
+-- 用户级 0 表示使用分组配置；分组级 0 表示默认权重 1
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS queue_weight INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS queue_weight INTEGER NOT NULL DEFAULT 0;
+
+-- queue_priority：优先级档位（0-9），高档位的等待请求总是先于低档位放行
+-- 用户级 0 表示使用分组配置
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS queue_priority INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS queue_priority INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN users.queue_weight IS '公平排队权重，0 表示使用分组配置';
+COMMENT ON COLUMN users.queue_priority IS '公平排队优先级档位（0-9），0 表示使用分组配置';
+COMMENT ON COLUMN groups.queue_weight IS '公平排队权重，0 表示默认权重 1';
+COMMENT ON COLUMN groups.queue_priority IS '公平排队优先级档位（0-9），数值越大越优先';
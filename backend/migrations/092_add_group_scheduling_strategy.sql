@@ -0,0 +1,8 @@
+-- 092_add_group_scheduling_strategy.sql
+-- 分组级账号调度策略：空字符串为默认策略（优先级 → 负载率 → LRU），
+-- cost_aware 优先消耗即将重置的预付订阅额度，其次按边际成本与剩余配额选择账号
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS scheduling_strategy VARCHAR(32) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN groups.scheduling_strategy IS '账号调度策略：空表示默认，cost_aware 表示成本感知';
@@ -0,0 +1,12 @@
+-- 093_add_group_hedge_settings.sql
+-- 分组级对冲请求配置：非流式请求在首个上游超过分位延迟仍未返回时，
+-- 在另一账号上并发发起第二个请求，取先完成者
+
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS hedge_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS hedge_percentile INTEGER NOT NULL DEFAULT 95,
+ADD COLUMN IF NOT EXISTS hedge_loser_billing VARCHAR(16) NOT NULL DEFAULT 'ignore';
+
+COMMENT ON COLUMN groups.hedge_enabled IS '是否对非流式请求启用对冲';
+COMMENT ON COLUMN groups.hedge_percentile IS '触发对冲的延迟分位数（50-99）';
+COMMENT ON COLUMN groups.hedge_loser_billing IS '对冲落败请求的计费策略：ignore / bill';
@@ -0,0 +1,8 @@
+-- 094_add_api_key_priority.sql
+-- API Key 调度优先级：interactive / normal / batch
+-- batch 请求只使用低负载账号，并为 interactive 请求预留部分账号槽位
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS priority VARCHAR(16) NOT NULL DEFAULT 'normal';
+
+COMMENT ON COLUMN api_keys.priority IS '调度优先级：interactive / normal / batch';
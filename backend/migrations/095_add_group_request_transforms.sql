@@ -0,0 +1,10 @@
+-- 095_add_group_request_transforms.sql
+-- 添加分组级别的请求改写规则
+
+-- request_transforms：转发前按顺序执行的改写规则（JSONB 数组）
+-- 动作: set / remove / clamp / remove_items / prepend_system / set_header / remove_header
+-- 例如: [{"action": "clamp", "path": "max_tokens", "max": 8192}, {"action": "remove_items", "path": "tools", "match": {"type": "web_search_*"}}]
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS request_transforms JSONB DEFAULT '[]';
+
+COMMENT ON COLUMN groups.request_transforms IS '请求改写规则：[{"action": "set|remove|clamp|remove_items|prepend_system|set_header|remove_header", ...}]，按顺序执行';
@@ -446,6 +446,10 @@ export interface AccountAvailability {
   overload_remaining_sec?: number
   has_error: boolean
   error_message?: string
+  circuit_state?: 'open' | 'half_open'
+  circuit_scope?: 'account' | 'host'
+  circuit_host?: string
+  circuit_open_until?: string
 }
 
 export interface OpsAccountAvailabilityStatsResponse {
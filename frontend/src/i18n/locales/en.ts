@@ -3984,7 +3984,9 @@ export default {
       accountAvailability: {
         available: 'Available',
         unavailable: 'Unavailable',
-        accountError: 'Error'
+        accountError: 'Error',
+        circuitOpen: 'Circuit open',
+        circuitHalfOpen: 'Circuit half-open'
       },
       tooltips: {
         totalRequests: 'Total number of requests (including both successful and failed requests) in the selected time window.',
@@ -4150,7 +4150,9 @@ export default {
       accountAvailability: {
         available: '可用',
         unavailable: '不可用',
-        accountError: '异常'
+        accountError: '异常',
+        circuitOpen: '熔断中',
+        circuitHalfOpen: '半开探测'
       },
       tooltips: {
         totalRequests: '当前时间窗口内的总请求数和Token消耗量。',